@@ -0,0 +1,250 @@
+// Command staging-refresh copies a production database into a staging database and anonymizes the
+// copy, so staging can be tested with realistic volumes without exposing personal data.
+//
+// Usage:
+//
+//	SOURCE_DATABASE_URL=postgres://... STAGING_DATABASE_URL=postgres://... \
+//	  go run ./cmd/staging-refresh -confirm <staging database name> [-password <staging password>]
+//
+// The staging schema must already be migrated. Every table of its public schema is emptied and
+// refilled from the source in one transaction, which is only committed once anonymized: staging is
+// either left as it was or fully refreshed, and never holds real data. The staging role must be
+// allowed to set session_replication_role, since foreign keys are checked on the source, not
+// while tables are refilled in alphabetical order.
+package main
+
+import (
+	"context" // For database calls
+	"errors"  // For error checks
+	"flag"    // For command-line flags
+	"fmt"     // For error formatting
+	"io"      // For streaming COPY data between databases
+	"log"     // For logging
+	"os"      // For environment variables
+	"slices"  // For column lookups
+	"strings" // For column lists
+	"time"    // For the run duration
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"rideshare/backend/services"
+)
+
+// excludedTables are public tables that aren't application data (PostGIS metadata).
+var excludedTables = []string{"spatial_ref_sys"}
+
+func main() {
+	source := flag.String("source", os.Getenv("SOURCE_DATABASE_URL"), "Connection URL of the production database, read in one read-only snapshot")
+	target := flag.String("target", os.Getenv("STAGING_DATABASE_URL"), "Connection URL of the staging database to refresh")
+	confirm := flag.String("confirm", "", "Name of the staging database, confirming its data may be replaced")
+	password := flag.String("password", getEnv("STAGING_PASSWORD", "staging-password"), "Password every staging user signs in with")
+	flag.Parse()
+
+	if err := run(context.Background(), *source, *target, *confirm, *password); err != nil {
+		log.Fatalf("Staging refresh failed: %v", err)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// run refreshes the target database from the source, after checking they are distinct and the
+// target was confirmed.
+func run(ctx context.Context, sourceURL, targetURL, confirm, password string) error {
+	if sourceURL == "" || targetURL == "" {
+		return errors.New("both -source (SOURCE_DATABASE_URL) and -target (STAGING_DATABASE_URL) are required")
+	}
+	sourceConfig, err := pgx.ParseConfig(sourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid source URL: %w", err)
+	}
+	targetConfig, err := pgx.ParseConfig(targetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target URL: %w", err)
+	}
+	if sourceConfig.Host == targetConfig.Host && sourceConfig.Port == targetConfig.Port && sourceConfig.Database == targetConfig.Database {
+		return errors.New("source and target are the same database")
+	}
+	if confirm != targetConfig.Database {
+		return fmt.Errorf("pass -confirm %s to replace the data of the target database", targetConfig.Database)
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash the staging password: %w", err)
+	}
+
+	sourceConn, err := pgx.ConnectConfig(ctx, sourceConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the source database: %w", err)
+	}
+	defer sourceConn.Close(ctx)
+	targetConn, err := pgx.ConnectConfig(ctx, targetConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the target database: %w", err)
+	}
+	defer targetConn.Close(ctx)
+
+	started := time.Now()
+	sourceTx, err := sourceConn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to start the source snapshot: %w", err)
+	}
+	defer sourceTx.Rollback(ctx)
+	targetTx, err := targetConn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start the target transaction: %w", err)
+	}
+	defer targetTx.Rollback(ctx)
+
+	if _, err := targetTx.Exec(ctx, `SET LOCAL session_replication_role = replica`); err != nil {
+		return fmt.Errorf("failed to disable foreign key checks on the target: %w", err)
+	}
+	rows, err := cloneTables(ctx, sourceTx, targetTx)
+	if err != nil {
+		return err
+	}
+	if err := resetSequences(ctx, targetTx); err != nil {
+		return err
+	}
+	if err := services.AnonymizeStagingData(ctx, targetTx, string(passwordHash)); err != nil {
+		return err
+	}
+	if err := targetTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit the refreshed staging data: %w", err)
+	}
+	log.Printf("Staging database %s refreshed with %d rows in %s", targetConfig.Database, rows, time.Since(started).Round(time.Second))
+	return nil
+}
+
+// cloneTables empties the target's public tables and copies the source's rows into them, returning
+// the number of rows copied. Only columns present in both databases are copied, so a staging schema
+// a migration ahead of production still refreshes; tables missing from the source are left empty.
+func cloneTables(ctx context.Context, source, target pgx.Tx) (int64, error) {
+	tables, err := publicTables(ctx, target)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list target tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return 0, errors.New("the target has no tables; run the migrations first")
+	}
+	identifiers := make([]string, len(tables))
+	for i, table := range tables {
+		identifiers[i] = pgx.Identifier{table}.Sanitize()
+	}
+	if _, err := target.Exec(ctx, `TRUNCATE `+strings.Join(identifiers, ", ")); err != nil {
+		return 0, fmt.Errorf("failed to empty the target tables: %w", err)
+	}
+
+	var total int64
+	for _, table := range tables {
+		targetColumns, err := tableColumns(ctx, target, table)
+		if err != nil {
+			return total, fmt.Errorf("failed to list target columns of %s: %w", table, err)
+		}
+		sourceColumns, err := tableColumns(ctx, source, table)
+		if err != nil {
+			return total, fmt.Errorf("failed to list source columns of %s: %w", table, err)
+		}
+		var columns []string
+		for _, column := range targetColumns {
+			if slices.Contains(sourceColumns, column) {
+				columns = append(columns, pgx.Identifier{column}.Sanitize())
+			}
+		}
+		if len(columns) == 0 {
+			log.Printf("Skipping %s: not in the source database", table)
+			continue
+		}
+		copied, err := copyTable(ctx, source, target, pgx.Identifier{table}.Sanitize(), strings.Join(columns, ", "))
+		if err != nil {
+			return total, fmt.Errorf("failed to copy %s: %w", table, err)
+		}
+		log.Printf("Copied %d rows of %s", copied, table)
+		total += copied
+	}
+	return total, nil
+}
+
+// copyTable streams the rows of a table from the source into the target with COPY.
+func copyTable(ctx context.Context, source, target pgx.Tx, table, columns string) (int64, error) {
+	reader, writer := io.Pipe()
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := source.Conn().PgConn().CopyTo(ctx, writer, `COPY (SELECT `+columns+` FROM `+table+`) TO STDOUT (FORMAT binary)`)
+		writer.CloseWithError(err)
+		copyErr <- err
+	}()
+	tag, err := target.Conn().PgConn().CopyFrom(ctx, reader, `COPY `+table+` (`+columns+`) FROM STDIN (FORMAT binary)`)
+	reader.Close() // Unblocks the source if the target failed first
+	sourceErr := <-copyErr
+	if err != nil {
+		return 0, fmt.Errorf("writing the target: %w", err)
+	}
+	if sourceErr != nil {
+		return 0, fmt.Errorf("reading the source: %w", sourceErr)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// resetSequences moves the target's serial and identity sequences past the copied values.
+func resetSequences(ctx context.Context, target pgx.Tx) error {
+	rows, err := target.Query(ctx, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND (column_default LIKE 'nextval(%' OR is_identity = 'YES')`)
+	if err != nil {
+		return fmt.Errorf("failed to list target sequences: %w", err)
+	}
+	type sequenceColumn struct{ table, column string }
+	var sequences []sequenceColumn
+	for rows.Next() {
+		var sequence sequenceColumn
+		if err := rows.Scan(&sequence.table, &sequence.column); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read target sequences: %w", err)
+		}
+		sequences = append(sequences, sequence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list target sequences: %w", err)
+	}
+
+	for _, sequence := range sequences {
+		query := `SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(` + pgx.Identifier{sequence.column}.Sanitize() + `), 0) + 1, false) FROM ` + pgx.Identifier{sequence.table}.Sanitize()
+		if _, err := target.Exec(ctx, query, pgx.Identifier{sequence.table}.Sanitize(), sequence.column); err != nil {
+			return fmt.Errorf("failed to reset the sequence of %s.%s: %w", sequence.table, sequence.column, err)
+		}
+	}
+	return nil
+}
+
+// publicTables lists the ordinary tables of the public schema, partitions included (partitioned
+// parents hold no rows of their own).
+func publicTables(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relkind = 'r' AND c.relname <> ALL($1)
+		ORDER BY c.relname`, excludedTables)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// tableColumns lists the writable columns of a public table, in order; none if it doesn't exist.
+func tableColumns(ctx context.Context, tx pgx.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND table_name = $1 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -1,29 +1,216 @@
 package config
 
 import (
-	"log" // Standard log package
-	"os"  // Package to interact with the OS, including environment variables
+	"log"         // Standard log package
+	"strconv"     // For parsing numeric values
+	"strings"     // For splitting list values
+	"sync/atomic" // For hot-swapped runtime settings
+	"time"        // For duration values
 
 	"github.com/joho/godotenv" // Package to load .env files
 )
 
+// Proximity query strategies (PROXIMITY_STRATEGY).
+const (
+	ProximityPostGIS = "postgis" // ST_DWithin on the coordinate columns
+	ProximityGeohash = "geohash" // Prefix match on the geohash columns (migration 019)
+)
+
+// Retention job modes (RETENTION_MODE).
+const (
+	RetentionOff     = "off"     // Retention rules never run
+	RetentionDryRun  = "dry_run" // Rules only count and report what they would purge
+	RetentionEnforce = "enforce" // Rules purge data
+)
+
+// Analytics event sinks (ANALYTICS_SINK).
+const (
+	AnalyticsOff     = "off"     // Events are dropped
+	AnalyticsDB      = "db"      // Events go to the analytics_events table (migration 046)
+	AnalyticsPostHog = "posthog" // Events are sent to PostHog's batch API
+	AnalyticsSegment = "segment" // Events are sent to Segment's batch API
+)
+
+// SQL logging modes (SQL_LOG).
+const (
+	SQLLogOff  = "off"  // Queries are not logged
+	SQLLogSlow = "slow" // Queries taking SLOW_QUERY_THRESHOLD or longer are logged
+	SQLLogAll  = "all"  // Every query is logged with its duration (debugging only, very verbose)
+)
+
+// Signup modes (SIGNUP_MODE).
+const (
+	SignupOpen       = "open"        // Anyone can sign up; invite codes are optional (cohort attribution)
+	SignupInviteOnly = "invite_only" // Signing up needs a valid invite code (soft launches, city-by-city rollouts)
+)
+
+// Response contract validation modes (RESPONSE_VALIDATION).
+const (
+	ResponseValidationOff  = "off"  // Responses are not checked
+	ResponseValidationLog  = "log"  // Drift from the response schemas is logged
+	ResponseValidationFail = "fail" // Drifting responses are replaced with a 500, so tests and QA notice
+)
+
+// defaultModerationBlockedWords is a starting list (English and French); deployments extend it with MODERATION_BLOCKED_WORDS.
+var defaultModerationBlockedWords = []string{
+	"asshole", "bastard", "bitch", "cunt", "dickhead", "fuck", "fucker", "motherfucker", "shit", "slut", "whore",
+	"batard", "connard", "connasse", "encule", "fdp", "salope", "pute", "ntm",
+}
+
 // Config holds all configuration for the application.
-// Values are read from environment variables.
+// Values are layered: defaults, then config.yaml and its profile overlay, then environment variables.
+// Fields tagged secret:"true" are redacted in Sanitized().
 type Config struct {
 	SupabaseURL            string
-	SupabaseAnonKey        string
-	SupabaseServiceRoleKey string
-	SupabaseDBPassword     string // Added Database password
-	StripeSecretKey        string
+	SupabaseAnonKey        string `secret:"true"`
+	SupabaseServiceRoleKey string `secret:"true"`
+	SupabaseDBPassword     string `secret:"true"` // Added Database password
+	StripeSecretKey        string `secret:"true"`
 	StripePublicKey        string
-	StripeWebhookSecret    string
+	StripeWebhookSecret    string        `secret:"true"`
+	WebhookTimeout         time.Duration // Deadline for processing one Stripe webhook event (DB work included)
+	RefundRetryInterval    time.Duration // How often refunds that failed are retried and pending automatic joins reconciled (0 disables the job)
 	ServerPort             string
-	JWTSecret              string // Added for signing JWT tokens
-	OpenRouteServiceAPIKey string // Added for OpenRouteService API
+	JWTSecret              string `secret:"true"` // Added for signing JWT tokens
+	OpenRouteServiceAPIKey string `secret:"true"` // Added for OpenRouteService API
+	OSRMURL                string // Optional OSRM server (e.g. http://localhost:5000); used for routing instead of OpenRouteService when set
+
+	DefaultCancellationPolicy   string   // Policy applied when the creator doesn't choose one
+	AllowedCancellationPolicies []string // Policies creators may choose from (platform bounds)
+
+	ModerationBlockedWords []string // Words that get user-written text blocked (matched case-insensitively, leetspeak folded)
+	ModerationAPIURL       string   // Optional OpenAI-compatible moderation endpoint (empty = local filter only)
+	ModerationAPIKey       string   `secret:"true"` // Bearer token for the moderation endpoint
+
+	ExpoPushURL     string // Expo push API endpoint
+	ExpoReceiptsURL string // Expo push receipts endpoint
+	ExpoAccessToken string `secret:"true"` // Optional Expo access token (enhanced push security)
+
+	MaxPushesPerHour   int           // Per-user cap on non-critical pushes per rolling hour
+	PushCollapseWindow time.Duration // Repeated events with the same collapse key within this window are not re-pushed
+
+	PushHygieneInterval time.Duration // How often push receipts are checked and dormant tokens deactivated (0 disables the job)
+	PushTokenDormancy   time.Duration // Tokens the app hasn't registered again for this long are deactivated
+
+	Profile       Profile  // Deployment profile from APP_ENV (dev, staging, prod); dev enables dev-only routes
+	ConfigFiles   []string // Config files that were read, base file first
+	SMTPHost      string   // SMTP server for transactional emails (empty disables sending)
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string `secret:"true"`
+	EmailFrom     string // From address for transactional emails
+	DefaultLocale string // Locale used when a user has no preference or a template has no variant
+
+	PublicBaseURL     string // Public URL of this API, used to build links in emails
+	UnsubscribeSecret string `secret:"true"` // HMAC key for signed unsubscribe tokens (defaults to JWT secret)
+	ShareLinkSecret   string `secret:"true"` // HMAC key for signed public ride links (defaults to JWT secret)
+
+	ImpersonationTokenTTL time.Duration // Lifetime of support impersonation tokens
+
+	JWTAccessTokenTTL time.Duration // Lifetime of login tokens (can be shortened once refresh tokens exist)
+	JWTIssuer         string        // "iss" claim of issued tokens, required by middleware.Protected
+	JWTAudience       string        // "aud" claim of issued tokens, required by middleware.Protected
+
+	MinSignupAge int // Users younger than this cannot create an account
+	MinJoinAge   int // Users younger than this need an admin guardianship override to join rides
+
+	FraudRulesRefreshInterval time.Duration // How long fraud rules are cached before being re-read from the database
+	FraudClearedGrace         time.Duration // After an admin clears a review, holds/verification for that user are downgraded to flags
+
+	runtime               atomic.Pointer[RuntimeSettings] // Quotas, booking fee, feature flags, log level; see Runtime()
+	RuntimeReloadInterval time.Duration                   // How often the .env file is checked for runtime changes (0 = SIGHUP only)
+
+	GeoIPProvider     string        // "ipinfo", "maxmind" or empty to disable IP geolocation
+	GeoIPToken        string        `secret:"true"` // IPinfo access token
+	MaxMindAccountID  string        // MaxMind GeoIP2 web service account
+	MaxMindLicenseKey string        `secret:"true"` // MaxMind GeoIP2 web service license key
+	GeoIPCacheTTL     time.Duration // How long IP lookups are cached in memory
+
+	StartupRetryAttempts   int           // How many times startup checks (Postgres, Stripe) are tried before giving up
+	StartupRetryMaxBackoff time.Duration // Upper bound of the exponential backoff between startup check attempts
+
+	SentryDSN         string `secret:"true"` // Sentry/GlitchTip DSN for error reports (empty disables reporting)
+	SentryEnvironment string // Environment tag on error reports (defaults to the profile)
+	Release           string // Release tag on error reports (defaults to the VCS revision of the build)
+
+	MetricsToken string `secret:"true"` // Bearer token required to scrape /metrics (empty = open)
+
+	FieldEncryptionKeys string `secret:"true"` // "id:base64key,..." key-encryption keys for PII columns, active key first
+	FieldIndexKey       string `secret:"true"` // Base64 HMAC key for blind indexes on encrypted columns
+
+	SearchCacheTTL        time.Duration // How long ride search pages are cached (0 disables the cache)
+	SearchCacheMaxPages   int           // Only pages up to this number are cached
+	SearchCacheMaxEntries int           // Upper bound on cached search pages
+
+	ProximityStrategy string // "postgis" (ST_DWithin) or "geohash" (prefix match on geohash columns) for proximity queries
+
+	ServiceAreas []string // Codes of the service_areas rides must depart from and arrive in, e.g. "FR,BE" (empty = anywhere)
+
+	DriverStatsCacheTTL time.Duration // How long driver reliability stats on ride listings are cached (0 disables the stats)
+
+	SeatHoldDuration time.Duration // How long a seat stays reserved for a joiner who hasn't paid yet (0 disables holds)
+
+	RideConflictWindow time.Duration // Creating or joining a ride departing this close to another of the user's rides needs confirmation (0 disables)
+	RideDriveBuffer    time.Duration // Slack a driver needs between arriving from one of their rides and departing the next; less needs confirmation (0 disables the drive time check)
+
+	SeatPriceMinCents int64 // Lowest seat price a driver may set, in cents of the payment currency
+	SeatPriceMaxCents int64 // Highest seat price a driver may set
+
+	UserRateLimitPerMinute int // Soft per-user request budget advertised in X-RateLimit-* headers (0 disables the headers)
+
+	AnonymousSessionTTL time.Duration // Lifetime of anonymous browsing tokens; sessions unseen for this long are purged by retention
+
+	AnalyticsSink          string        // "db", "posthog", "segment" or "off": where product analytics events go
+	AnalyticsEndpoint      string        // PostHog instance or Segment API URL (defaults to the provider's cloud)
+	AnalyticsAPIKey        string        `secret:"true"` // PostHog project key or Segment write key
+	AnalyticsSampleRate    int           // Percentage of users (or of events, for visitors) whose events are kept
+	AnalyticsFlushInterval time.Duration // Queued events are sent at least this often
+
+	StaticMapProvider string        // "mapbox", "geoapify" or empty to disable ride map thumbnails
+	StaticMapAPIKey   string        `secret:"true"` // Map provider key, kept server-side
+	StaticMapCacheTTL time.Duration // How long rendered ride maps are cached in memory
+
+	TravelMatrixRefreshInterval time.Duration // How often driving estimates between frequent city pairs are refreshed (0 disables)
+	TravelMatrixMaxPairs        int           // Most frequent city pairs kept in the travel matrix
+
+	HolidayCountries       []string      // ISO codes of the countries whose public holidays make peak travel days (empty disables)
+	HolidayAPIURL          string        // Nager.Date compatible public holiday API
+	HolidayRefreshInterval time.Duration // How often the holiday calendar is fetched again (0 = stored holidays only)
+
+	RideArchivalInterval  time.Duration // How often active rides whose departure has passed are archived (0 disables the job)
+	RideAutoCompleteDelay time.Duration // Time after a ride's estimated arrival before it is completed without the creator's confirmation
+
+	CheckinReminderInterval time.Duration // How often departure-day check-in messages are sent to drivers and passengers (0 disables the job)
+	CheckinReminderTime     string        // Time of day (HH:MM, database time) from which check-in messages go out; earlier departures get theirs from midnight
+
+	ErasureGracePeriod time.Duration // Time between account deletion and irreversible erasure of personal data
+	ErasureJobInterval time.Duration // How often due erasures are processed (0 disables the job)
+
+	RetentionMode          string        // "off", "dry_run" (report only) or "enforce"
+	RetentionJobInterval   time.Duration // How often retention rules run
+	RetentionLocationData  time.Duration // Location and IP data on fraud events is cleared after this
+	RetentionNotifications time.Duration // In-app notifications are deleted after this
+	RetentionArchivedRides time.Duration // Archived and cancelled rides without payments are deleted after this
+	RetentionAuditLogs     time.Duration // Audit log entries are deleted after this
+	RetentionAnalytics     time.Duration // Analytics events stored in the database are deleted after this
+
+	ResponseValidation string // "off", "log" or "fail": check ride and payment responses against their schemas (defaults to log outside prod)
+
+	SQLLog             string        // "off", "slow" or "all": SQL statement logging, with bound parameters redacted
+	SlowQueryThreshold time.Duration // Queries taking this long or longer are logged as slow
+	QueryCountLogMin   int           // Requests issuing at least this many queries are logged with their count (0 disables)
+
+	SignupMode string // "open" or "invite_only": whether signing up needs an invite code minted by an admin
+
+	FinanceVATRateBasisPoints int    // VAT included in booking fees, in basis points (1900 = 19%)
+	FinanceRevenueAccount     string // Ledger account booking fees are credited to (DATEV SKR03 8400 by default)
+	FinanceClearingAccount    string // Ledger account Stripe settles through (DATEV SKR03 1360 by default)
+	DATEVConsultantNumber     string // DATEV export header: Beraternummer
+	DATEVClientNumber         string // DATEV export header: Mandantennummer
 }
 
-// LoadConfig reads configuration from environment variables.
-// It loads a .env file first if it exists.
+// LoadConfig reads configuration from the config files and environment variables.
+// It loads a .env file first if it exists; environment variables override the config files.
 func LoadConfig() (*Config, error) {
 	// Attempt to load .env file. Ignore error if it doesn't exist.
 	err := godotenv.Load() // Loads .env from the current directory
@@ -31,6 +218,12 @@ func LoadConfig() (*Config, error) {
 		log.Println("No .env file found, relying on environment variables")
 	}
 
+	// config.yaml (or CONFIG_FILE) and its profile overlay, e.g. config.staging.yaml
+	profile, configFiles, err := loadConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+
 	// Read environment variables or use defaults
 	cfg := &Config{
 		SupabaseURL:            getEnv("SUPABASE_URL", ""),
@@ -40,30 +233,277 @@ func LoadConfig() (*Config, error) {
 		StripeSecretKey:        getEnv("STRIPE_SECRET_KEY", ""),
 		StripePublicKey:        getEnv("STRIPE_PUBLIC_KEY", ""),
 		StripeWebhookSecret:    getEnv("STRIPE_WEBHOOK_SECRET", ""),
-		ServerPort:             getEnv("SERVER_PORT", "8080"),                // Default port 8080
-		JWTSecret:              getEnv("JWT_SECRET", "your-very-secret-key"), // !! CHANGE THIS IN PRODUCTION !!
-		OpenRouteServiceAPIKey: getEnv("OPENROUTESERVICE_API_KEY", ""),       // Load OpenRouteService API Key
+		WebhookTimeout:         getEnvDuration("STRIPE_WEBHOOK_TIMEOUT", 10*time.Second), // Stripe gives up on slow endpoints and retries
+		RefundRetryInterval:    getEnvDuration("REFUND_RETRY_INTERVAL", 15*time.Minute),  // Pending refunds are retried this often
+		ServerPort:             getEnv("SERVER_PORT", "8080"),                            // Default port 8080
+		JWTSecret:              getEnv("JWT_SECRET", "your-very-secret-key"),             // !! CHANGE THIS IN PRODUCTION !!
+		OpenRouteServiceAPIKey: getEnv("OPENROUTESERVICE_API_KEY", ""),                   // Load OpenRouteService API Key
+		OSRMURL:                strings.TrimRight(getEnv("OSRM_URL", ""), "/"),
+
+		DefaultCancellationPolicy:   getEnv("DEFAULT_CANCELLATION_POLICY", "moderate"),
+		AllowedCancellationPolicies: getEnvList("ALLOWED_CANCELLATION_POLICIES", []string{"flexible", "moderate", "strict"}),
+
+		ModerationBlockedWords: getEnvList("MODERATION_BLOCKED_WORDS", defaultModerationBlockedWords),
+		ModerationAPIURL:       getEnv("MODERATION_API_URL", ""),
+		ModerationAPIKey:       getEnv("MODERATION_API_KEY", ""),
+
+		ExpoPushURL:     getEnv("EXPO_PUSH_URL", "https://exp.host/--/api/v2/push/send"),
+		ExpoReceiptsURL: getEnv("EXPO_RECEIPTS_URL", "https://exp.host/--/api/v2/push/getReceipts"),
+		ExpoAccessToken: getEnv("EXPO_ACCESS_TOKEN", ""),
+
+		MaxPushesPerHour:   getEnvInt("MAX_PUSHES_PER_HOUR", 6),
+		PushCollapseWindow: getEnvDuration("PUSH_COLLAPSE_WINDOW", 10*time.Minute),
+
+		PushHygieneInterval: getEnvDuration("PUSH_HYGIENE_INTERVAL", 30*time.Minute),
+		PushTokenDormancy:   getEnvDuration("PUSH_TOKEN_DORMANCY", 180*24*time.Hour),
+
+		Profile:       profile,
+		ConfigFiles:   configFiles,
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnv("SMTP_PORT", "587"),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:     getEnv("EMAIL_FROM", "RideShare <no-reply@rideshare.app>"),
+		DefaultLocale: getEnv("DEFAULT_LOCALE", "en"),
+
+		PublicBaseURL:     getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		UnsubscribeSecret: getEnv("UNSUBSCRIBE_SECRET", ""),
+		ShareLinkSecret:   getEnv("SHARE_LINK_SECRET", ""),
+
+		ImpersonationTokenTTL: getEnvDuration("IMPERSONATION_TOKEN_TTL", 15*time.Minute),
+
+		JWTAccessTokenTTL: getEnvDuration("JWT_ACCESS_TOKEN_TTL", 72*time.Hour),
+		JWTIssuer:         getEnv("JWT_ISSUER", "rideshare-backend"),
+		JWTAudience:       getEnv("JWT_AUDIENCE", "rideshare-app"),
+
+		MinSignupAge: getEnvInt("MIN_SIGNUP_AGE", 16),
+		MinJoinAge:   getEnvInt("MIN_JOIN_AGE", 18),
+
+		FraudRulesRefreshInterval: getEnvDuration("FRAUD_RULES_REFRESH_INTERVAL", time.Minute),
+		FraudClearedGrace:         getEnvDuration("FRAUD_CLEARED_GRACE", 24*time.Hour),
+
+		RuntimeReloadInterval: getEnvDuration("RUNTIME_RELOAD_INTERVAL", 0),
+
+		GeoIPProvider:     getEnv("GEOIP_PROVIDER", ""),
+		GeoIPToken:        getEnv("GEOIP_TOKEN", ""),
+		MaxMindAccountID:  getEnv("MAXMIND_ACCOUNT_ID", ""),
+		MaxMindLicenseKey: getEnv("MAXMIND_LICENSE_KEY", ""),
+		GeoIPCacheTTL:     getEnvDuration("GEOIP_CACHE_TTL", 6*time.Hour),
+
+		StartupRetryAttempts:   getEnvInt("STARTUP_RETRY_ATTEMPTS", 8),
+		StartupRetryMaxBackoff: getEnvDuration("STARTUP_RETRY_MAX_BACKOFF", 30*time.Second),
+
+		SentryDSN:         getEnv("SENTRY_DSN", ""),
+		SentryEnvironment: getEnv("SENTRY_ENVIRONMENT", ""),
+		Release:           getEnv("SENTRY_RELEASE", ""),
+
+		MetricsToken: getEnv("METRICS_TOKEN", ""),
+
+		FieldEncryptionKeys: getEnv("FIELD_ENCRYPTION_KEYS", ""),
+		FieldIndexKey:       getEnv("FIELD_INDEX_KEY", ""),
+
+		SearchCacheTTL:        getEnvDuration("SEARCH_CACHE_TTL", time.Minute),
+		SearchCacheMaxPages:   getEnvInt("SEARCH_CACHE_MAX_PAGES", 2),
+		SearchCacheMaxEntries: getEnvInt("SEARCH_CACHE_MAX_ENTRIES", 1000),
+
+		ProximityStrategy: getEnv("PROXIMITY_STRATEGY", ProximityPostGIS),
+
+		ServiceAreas: getEnvList("SERVICE_AREAS", []string{}),
+
+		DriverStatsCacheTTL: getEnvDuration("DRIVER_STATS_CACHE_TTL", 15*time.Minute),
+
+		SeatHoldDuration: getEnvDuration("SEAT_HOLD_DURATION", 10*time.Minute),
+
+		RideConflictWindow: getEnvDuration("RIDE_CONFLICT_WINDOW", 2*time.Hour),
+		RideDriveBuffer:    getEnvDuration("RIDE_DRIVE_BUFFER", 30*time.Minute),
+
+		SeatPriceMinCents: int64(getEnvInt("SEAT_PRICE_MIN_CENTS", 100)),   // 1 EUR
+		SeatPriceMaxCents: int64(getEnvInt("SEAT_PRICE_MAX_CENTS", 20000)), // 200 EUR
+
+		UserRateLimitPerMinute: getEnvInt("USER_RATE_LIMIT_PER_MINUTE", 120),
+
+		AnonymousSessionTTL: getEnvDuration("ANONYMOUS_SESSION_TTL", 30*24*time.Hour),
+
+		AnalyticsSink:          getEnv("ANALYTICS_SINK", AnalyticsDB),
+		AnalyticsEndpoint:      getEnv("ANALYTICS_ENDPOINT", ""),
+		AnalyticsAPIKey:        getEnv("ANALYTICS_API_KEY", ""),
+		AnalyticsSampleRate:    getEnvInt("ANALYTICS_SAMPLE_RATE", 100),
+		AnalyticsFlushInterval: getEnvDuration("ANALYTICS_FLUSH_INTERVAL", 10*time.Second),
+
+		StaticMapProvider: getEnv("STATIC_MAP_PROVIDER", ""),
+		StaticMapAPIKey:   getEnv("STATIC_MAP_API_KEY", ""),
+		StaticMapCacheTTL: getEnvDuration("STATIC_MAP_CACHE_TTL", 24*time.Hour),
+
+		TravelMatrixRefreshInterval: getEnvDuration("TRAVEL_MATRIX_REFRESH_INTERVAL", 6*time.Hour),
+		TravelMatrixMaxPairs:        getEnvInt("TRAVEL_MATRIX_MAX_PAIRS", 50),
+
+		HolidayCountries:       getEnvList("HOLIDAY_COUNTRIES", []string{"FR"}),
+		HolidayAPIURL:          getEnv("HOLIDAY_API_URL", "https://date.nager.at/api/v3"),
+		HolidayRefreshInterval: getEnvDuration("HOLIDAY_REFRESH_INTERVAL", 24*time.Hour),
+
+		RideArchivalInterval:  getEnvDuration("RIDE_ARCHIVAL_INTERVAL", 5*time.Minute),
+		RideAutoCompleteDelay: getEnvDuration("RIDE_AUTO_COMPLETE_DELAY", 2*time.Hour),
+
+		CheckinReminderInterval: getEnvDuration("CHECKIN_REMINDER_INTERVAL", 15*time.Minute),
+		CheckinReminderTime:     getEnv("CHECKIN_REMINDER_TIME", "07:00"),
+
+		ErasureGracePeriod: getEnvDuration("ERASURE_GRACE_PERIOD", 30*24*time.Hour),
+		ErasureJobInterval: getEnvDuration("ERASURE_JOB_INTERVAL", time.Hour),
+
+		RetentionMode:          getEnv("RETENTION_MODE", RetentionDryRun),
+		RetentionJobInterval:   getEnvDuration("RETENTION_JOB_INTERVAL", 24*time.Hour),
+		RetentionLocationData:  getEnvDuration("RETENTION_LOCATION_DATA", 30*24*time.Hour),
+		RetentionNotifications: getEnvDuration("RETENTION_NOTIFICATIONS", 365*24*time.Hour),
+		RetentionArchivedRides: getEnvDuration("RETENTION_ARCHIVED_RIDES", 3*365*24*time.Hour),
+		RetentionAuditLogs:     getEnvDuration("RETENTION_AUDIT_LOGS", 2*365*24*time.Hour),
+		RetentionAnalytics:     getEnvDuration("RETENTION_ANALYTICS", 395*24*time.Hour), // 13 months, so years can be compared
+
+		FinanceVATRateBasisPoints: getEnvInt("FINANCE_VAT_RATE_BASIS_POINTS", 1900),
+		FinanceRevenueAccount:     getEnv("FINANCE_REVENUE_ACCOUNT", "8400"),
+		FinanceClearingAccount:    getEnv("FINANCE_CLEARING_ACCOUNT", "1360"),
+		DATEVConsultantNumber:     getEnv("DATEV_CONSULTANT_NUMBER", ""),
+		DATEVClientNumber:         getEnv("DATEV_CLIENT_NUMBER", ""),
+
+		SQLLog:             getEnv("SQL_LOG", SQLLogSlow),
+		SlowQueryThreshold: getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		QueryCountLogMin:   getEnvInt("QUERY_COUNT_LOG_MIN", 25),
+
+		SignupMode: getEnv("SIGNUP_MODE", SignupOpen),
+	}
+	defaultResponseValidation := ResponseValidationLog
+	if profile == ProfileProd {
+		defaultResponseValidation = ResponseValidationOff // Validation costs a JSON decode per response
+	}
+	cfg.ResponseValidation = getEnv("RESPONSE_VALIDATION", defaultResponseValidation)
+	cfg.SetRuntime(loadRuntimeSettings())
+	if cfg.RetentionMode != RetentionOff && cfg.RetentionMode != RetentionDryRun && cfg.RetentionMode != RetentionEnforce {
+		log.Printf("Warning: Unknown RETENTION_MODE '%s', using '%s'", cfg.RetentionMode, RetentionDryRun)
+		cfg.RetentionMode = RetentionDryRun
+	}
+	if cfg.ResponseValidation != ResponseValidationOff && cfg.ResponseValidation != ResponseValidationLog && cfg.ResponseValidation != ResponseValidationFail {
+		log.Printf("Warning: Unknown RESPONSE_VALIDATION '%s', using '%s'", cfg.ResponseValidation, defaultResponseValidation)
+		cfg.ResponseValidation = defaultResponseValidation
+	}
+	if cfg.SQLLog != SQLLogOff && cfg.SQLLog != SQLLogSlow && cfg.SQLLog != SQLLogAll {
+		log.Printf("Warning: Unknown SQL_LOG '%s', using '%s'", cfg.SQLLog, SQLLogSlow)
+		cfg.SQLLog = SQLLogSlow
+	}
+	if cfg.SignupMode != SignupOpen && cfg.SignupMode != SignupInviteOnly {
+		log.Printf("Warning: Unknown SIGNUP_MODE '%s', using '%s'", cfg.SignupMode, SignupOpen)
+		cfg.SignupMode = SignupOpen
+	}
+	if cfg.ProximityStrategy != ProximityPostGIS && cfg.ProximityStrategy != ProximityGeohash {
+		log.Printf("Warning: Unknown PROXIMITY_STRATEGY '%s', using '%s'", cfg.ProximityStrategy, ProximityPostGIS)
+		cfg.ProximityStrategy = ProximityPostGIS
+	}
+	for i, code := range cfg.ServiceAreas {
+		cfg.ServiceAreas[i] = strings.ToUpper(code) // Codes are stored upper-case
+	}
+	for i, code := range cfg.HolidayCountries {
+		cfg.HolidayCountries[i] = strings.ToUpper(code)
+	}
+	if cfg.AnalyticsSink != AnalyticsOff && cfg.AnalyticsSink != AnalyticsDB && cfg.AnalyticsSink != AnalyticsPostHog && cfg.AnalyticsSink != AnalyticsSegment {
+		log.Printf("Warning: Unknown ANALYTICS_SINK '%s', using '%s'", cfg.AnalyticsSink, AnalyticsOff)
+		cfg.AnalyticsSink = AnalyticsOff
+	}
+	if cfg.AnalyticsSampleRate < 0 || cfg.AnalyticsSampleRate > 100 {
+		log.Printf("Warning: ANALYTICS_SAMPLE_RATE must be a percentage, using 100")
+		cfg.AnalyticsSampleRate = 100
+	}
+	if cfg.JWTAccessTokenTTL <= 0 {
+		log.Printf("Warning: JWT_ACCESS_TOKEN_TTL must be positive, using %s", 72*time.Hour)
+		cfg.JWTAccessTokenTTL = 72 * time.Hour
+	}
+	if cfg.SeatPriceMinCents <= 0 || cfg.SeatPriceMaxCents < cfg.SeatPriceMinCents {
+		log.Printf("Warning: Invalid seat price range %d-%d, using 100-20000 cents", cfg.SeatPriceMinCents, cfg.SeatPriceMaxCents)
+		cfg.SeatPriceMinCents, cfg.SeatPriceMaxCents = 100, 20000
+	}
+	if _, err := time.Parse("15:04", cfg.CheckinReminderTime); err != nil {
+		log.Printf("Warning: CHECKIN_REMINDER_TIME must be HH:MM, using 07:00")
+		cfg.CheckinReminderTime = "07:00"
+	}
+	if cfg.UnsubscribeSecret == "" {
+		cfg.UnsubscribeSecret = cfg.JWTSecret
+	}
+	if cfg.ShareLinkSecret == "" {
+		cfg.ShareLinkSecret = cfg.JWTSecret
 	}
 
 	// Basic validation (ensure critical keys are present)
 	// Basic validation (ensure critical keys are present)
 	// Add OpenRouteServiceAPIKey check
-	if cfg.SupabaseURL == "" || cfg.SupabaseServiceRoleKey == "" || cfg.SupabaseDBPassword == "" || cfg.StripeSecretKey == "" || cfg.JWTSecret == "your-very-secret-key" || cfg.OpenRouteServiceAPIKey == "" {
-		log.Println("Warning: One or more critical configuration keys (Supabase URL/Service Key/DB Password, Stripe Secret, JWT Secret, OpenRouteService API Key) are missing or using default/empty values.")
+	if cfg.SupabaseURL == "" || cfg.SupabaseServiceRoleKey == "" || cfg.SupabaseDBPassword == "" || cfg.StripeSecretKey == "" || cfg.JWTSecret == "your-very-secret-key" || (cfg.OpenRouteServiceAPIKey == "" && cfg.OSRMURL == "") {
+		log.Println("Warning: One or more critical configuration keys (Supabase URL/Service Key/DB Password, Stripe Secret, JWT Secret, OpenRouteService API Key or OSRM URL) are missing or using default/empty values.")
 		// In a real app, you might return an error here or handle it more robustly.
 		// For JWT_SECRET, it's crucial to set a strong, unique secret via environment variables.
-		// OpenRouteService key is needed for routing features.
+		// An OpenRouteService key or OSRM server is needed for routing features.
 	}
 
-	log.Println("Configuration loaded successfully")
+	log.Printf("Configuration loaded successfully (profile %s)", cfg.Profile)
 	return cfg, nil
 }
 
 // getEnv retrieves an environment variable or returns a default value.
 func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
+	if value, exists := lookupEnv(key); exists {
 		return value
 	}
-	log.Printf("Environment variable %s not set, using fallback '%s'", key, fallback)
+	log.Printf("Setting %s not set, using fallback '%s'", key, fallback)
 	return fallback
 }
+
+// getEnvList retrieves a comma-separated environment variable as a slice or returns a default value.
+func getEnvList(key string, fallback []string) []string {
+	value, exists := lookupEnv(key)
+	if !exists {
+		log.Printf("Setting %s not set, using fallback '%s'", key, strings.Join(fallback, ","))
+		return fallback
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt retrieves an integer environment variable or returns a default value.
+func getEnvInt(key string, fallback int) int {
+	value, exists := lookupEnv(key)
+	if !exists {
+		log.Printf("Setting %s not set, using fallback '%d'", key, fallback)
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: Environment variable %s has invalid integer '%s', using fallback '%d'", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvDuration retrieves a duration environment variable (e.g. "10m") or returns a default value.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := lookupEnv(key)
+	if !exists {
+		log.Printf("Setting %s not set, using fallback '%s'", key, fallback)
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: Environment variable %s has invalid duration '%s', using fallback '%s'", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// IsDevelopment reports whether the app runs in development mode (enables dev-only tooling).
+func (c *Config) IsDevelopment() bool {
+	return c.Profile == ProfileDev
+}
+
+// IsProduction reports whether the app runs with the prod profile.
+func (c *Config) IsProduction() bool {
+	return c.Profile == ProfileProd
+}
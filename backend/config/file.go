@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"  // For missing file checks
+	"fmt"     // For error formatting and scalar conversion
+	"log"     // Standard log package
+	"os"      // For reading config files and environment variables
+	"strings" // For key flattening
+
+	"gopkg.in/yaml.v3" // YAML config files
+)
+
+// Profile is the deployment profile selected by APP_ENV.
+type Profile string
+
+const (
+	ProfileDev     Profile = "dev"
+	ProfileStaging Profile = "staging"
+	ProfileProd    Profile = "prod"
+)
+
+// parseProfile maps an APP_ENV value to a profile. The long names used before profiles
+// existed ("development", "production") are still accepted.
+func parseProfile(value string) (Profile, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "dev", "development":
+		return ProfileDev, nil
+	case "staging", "stage":
+		return ProfileStaging, nil
+	case "prod", "production", "":
+		return ProfileProd, nil
+	}
+	return "", fmt.Errorf("unknown APP_ENV profile %q (expected dev, staging or prod)", value)
+}
+
+// fileValues holds the settings read from the config files, keyed like environment variables.
+// Environment variables win over these; see lookupEnv.
+var fileValues = map[string]string{}
+
+// lookupEnv returns a setting from the environment, falling back to the config files.
+func lookupEnv(key string) (string, bool) {
+	if value, exists := os.LookupEnv(key); exists {
+		return value, true
+	}
+	value, exists := fileValues[key]
+	return value, exists
+}
+
+// configFilePaths returns the base config file and the profile overlay next to it,
+// e.g. config.yaml and config.staging.yaml.
+func configFilePaths(base string, profile Profile) []string {
+	ext := ".yaml"
+	if strings.HasSuffix(base, ".yml") {
+		ext = ".yml"
+	}
+	return []string{base, strings.TrimSuffix(base, ext) + "." + string(profile) + ext}
+}
+
+// loadConfigFiles reads the base config file and the profile overlay (if present) into fileValues.
+// The profile comes from APP_ENV in the environment, or from the base file if not set there.
+// Returns the profile and the files that were read.
+func loadConfigFiles() (Profile, []string, error) {
+	base, exists := os.LookupEnv("CONFIG_FILE")
+	if !exists {
+		base = "config.yaml"
+	}
+
+	values := map[string]string{}
+	var loaded []string
+	if err := readConfigFile(base, values); err == nil {
+		loaded = append(loaded, base)
+	} else if !errors.Is(err, os.ErrNotExist) || exists {
+		// A missing default config.yaml is fine; an explicitly configured file must exist
+		return "", nil, err
+	}
+
+	appEnv, set := os.LookupEnv("APP_ENV")
+	if !set {
+		appEnv = values["APP_ENV"]
+	}
+	profile, err := parseProfile(appEnv)
+	if err != nil {
+		return "", nil, err
+	}
+
+	overlay := configFilePaths(base, profile)[1]
+	if err := readConfigFile(overlay, values); err == nil {
+		loaded = append(loaded, overlay)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+
+	fileValues = values
+	return profile, loaded, nil
+}
+
+// readConfigFile parses a YAML file into values. Nested keys are joined with "_" and upper-cased,
+// so `stripe: {webhook_timeout: 15s}` sets STRIPE_WEBHOOK_TIMEOUT. Lists become comma-separated.
+func readConfigFile(path string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := flattenConfig("", doc, values); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	log.Printf("Config file %s loaded", path)
+	return nil
+}
+
+// flattenConfig writes a YAML mapping into values using environment-variable style keys.
+func flattenConfig(prefix string, node map[string]any, values map[string]string) error {
+	for key, value := range node {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flattenConfig(name, v, values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, nested := item.(map[string]any); nested {
+					return fmt.Errorf("%s: lists of mappings are not supported", name)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"bytes" // For matching log lines
+	"io"    // For the wrapped writer
+	"log"   // Standard log package
+	"os"    // Default log output
+)
+
+// warningMarkers identify warning and error lines; the codebase logs them with these words.
+var warningMarkers = [][]byte{[]byte("Error"), []byte("error"), []byte("ERROR"), []byte("Warning"), []byte("CRITICAL"), []byte("Failed"), []byte("failed")}
+
+// levelFilter drops informational lines while the runtime log level is "warn".
+type levelFilter struct {
+	cfg *Config
+	out io.Writer
+}
+
+func (w *levelFilter) Write(line []byte) (int, error) {
+	if w.cfg.Runtime().LogLevel == "warn" && !isWarningLine(line) {
+		return len(line), nil // Report the line as written so the logger doesn't fail
+	}
+	return w.out.Write(line)
+}
+
+// isWarningLine reports whether a log line is a warning or an error.
+func isWarningLine(line []byte) bool {
+	for _, marker := range warningMarkers {
+		if bytes.Contains(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyLogLevel routes the standard logger through the runtime log level, so LOG_LEVEL
+// changes picked up by a reload take effect immediately.
+func (c *Config) ApplyLogLevel() {
+	log.SetOutput(&levelFilter{cfg: c, out: os.Stderr})
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"log"       // Standard log package
+	"os"        // For the config file modification time
+	"os/signal" // For SIGHUP reloads
+	"strings"   // For flag names
+	"syscall"   // For SIGHUP
+	"time"      // For the polling interval
+
+	"github.com/joho/godotenv" // Package to load .env files
+)
+
+// RuntimeSettings are non-critical settings that can change without a restart.
+// Critical settings (database, secrets, ports) stay on Config and need a restart.
+type RuntimeSettings struct {
+	QuotaRidesPerDay  int             // Max rides a user may create per rolling 24h (0 = unlimited)
+	QuotaJoinsPerHour int             // Max rides a user may join per rolling hour (0 = unlimited)
+	BookingFeeCents   int64           // Amount charged to join a ride, in cents of paymentCurrency
+	PeakFeeCents      int64           // Booking fee on peak travel days (public holidays and the day before); 0 = BookingFeeCents
+	FeatureFlags      map[string]bool // Feature flags, defaults from defaultFeatureFlags
+	LogLevel          string          // "info" or "warn"; at "warn" only warnings and errors are logged (app and HTTP access logs)
+	SearchWeights     SearchWeights   // Relevance weights of ride search results (search_ranking flag)
+}
+
+// SearchWeights weigh the signals ride search results are ranked by when the search_ranking flag is
+// on. Each signal scores a ride between 0 and 1; a weight of 0 ignores the signal.
+type SearchWeights struct {
+	DepartureTime     int // Sooner departures score higher
+	DriverReliability int // Drivers who rarely cancel score higher (stands in for ratings)
+	Price             int // Cheaper seats score higher
+	PickupDistance    int // Departures near the caller score higher; only for searches without a start location
+}
+
+// Feature flags read through RuntimeSettings.FeatureEnabled.
+const (
+	FeatureSearchCache = "search_cache"   // Serve common ride searches from the in-memory cache
+	FeatureAutoJoin    = "auto_join"      // Allow joining with an off-session charge of the saved card
+	FeatureSearchRank  = "search_ranking" // Order ride search results by weighted relevance instead of departure time
+)
+
+// defaultFeatureFlags lists the known flags and their state when FEATURE_FLAGS doesn't mention them.
+var defaultFeatureFlags = map[string]bool{
+	FeatureSearchCache: true,
+	FeatureAutoJoin:    true,
+	FeatureSearchRank:  true,
+}
+
+// FeatureEnabled reports whether a feature flag is on.
+func (r *RuntimeSettings) FeatureEnabled(name string) bool {
+	return r.FeatureFlags[strings.ToLower(name)]
+}
+
+// parseFeatureFlags applies FEATURE_FLAGS entries ("name" turns a flag on, "-name" turns it off)
+// over the defaults. Unknown names are logged and ignored.
+func parseFeatureFlags(entries []string) map[string]bool {
+	flags := map[string]bool{}
+	for name, enabled := range defaultFeatureFlags {
+		flags[name] = enabled
+	}
+	for _, entry := range entries {
+		name := strings.ToLower(strings.TrimPrefix(entry, "-"))
+		if _, known := defaultFeatureFlags[name]; !known {
+			log.Printf("Warning: Ignoring unknown feature flag '%s'", entry)
+			continue
+		}
+		flags[name] = !strings.HasPrefix(entry, "-")
+	}
+	return flags
+}
+
+// loadRuntimeSettings reads the runtime settings from environment variables.
+func loadRuntimeSettings() *RuntimeSettings {
+	flags := parseFeatureFlags(getEnvList("FEATURE_FLAGS", []string{}))
+	return &RuntimeSettings{
+		QuotaRidesPerDay:  getEnvInt("QUOTA_RIDES_PER_DAY", 5),
+		QuotaJoinsPerHour: getEnvInt("QUOTA_JOINS_PER_HOUR", 10),
+		BookingFeeCents:   int64(getEnvInt("BOOKING_FEE_CENTS", 200)), // 2 EUR
+		PeakFeeCents:      int64(getEnvInt("PEAK_BOOKING_FEE_CENTS", 0)),
+		FeatureFlags:      flags,
+		LogLevel:          strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		SearchWeights: SearchWeights{
+			DepartureTime:     getEnvInt("SEARCH_WEIGHT_DEPARTURE_TIME", defaultSearchWeights.DepartureTime),
+			DriverReliability: getEnvInt("SEARCH_WEIGHT_DRIVER_RELIABILITY", defaultSearchWeights.DriverReliability),
+			Price:             getEnvInt("SEARCH_WEIGHT_PRICE", defaultSearchWeights.Price),
+			PickupDistance:    getEnvInt("SEARCH_WEIGHT_PICKUP_DISTANCE", defaultSearchWeights.PickupDistance),
+		},
+	}
+}
+
+// defaultSearchWeights favour departure time, so ranked results stay close to the chronological order.
+var defaultSearchWeights = SearchWeights{DepartureTime: 50, DriverReliability: 20, Price: 15, PickupDistance: 15}
+
+// Runtime returns the current runtime settings. Read it once per operation, so an in-flight
+// join sees one consistent snapshot even if a reload happens halfway through.
+func (c *Config) Runtime() *RuntimeSettings {
+	if settings := c.runtime.Load(); settings != nil {
+		return settings
+	}
+	return defaultRuntimeSettings
+}
+
+// SetRuntime replaces the runtime settings (used by reloads and tests).
+func (c *Config) SetRuntime(settings *RuntimeSettings) {
+	c.runtime.Store(settings)
+}
+
+// defaultRuntimeSettings is used by Configs not built with LoadConfig (e.g. in tests).
+var defaultRuntimeSettings = &RuntimeSettings{
+	QuotaRidesPerDay:  5,
+	QuotaJoinsPerHour: 10,
+	BookingFeeCents:   200,
+	FeatureFlags:      parseFeatureFlags(nil),
+	LogLevel:          "info",
+	SearchWeights:     defaultSearchWeights,
+}
+
+// ReloadRuntime re-reads the .env file (its values win over the process environment, unlike
+// at startup) and the config files, and swaps in the new runtime settings. Invalid values fall
+// back to defaults; an unreadable config file keeps the previous file values.
+func (c *Config) ReloadRuntime() {
+	if err := godotenv.Overload(); err != nil {
+		log.Printf("Config reload: No .env file read (%v), using process environment only", err)
+	}
+	if _, _, err := loadConfigFiles(); err != nil {
+		log.Printf("Config reload: Keeping previous config file values: %v", err)
+	}
+	settings := loadRuntimeSettings()
+	c.SetRuntime(settings)
+	log.Printf("Config reload: Runtime settings updated (rides/day=%d, joins/hour=%d, booking fee=%d, peak fee=%d, flags=%v, log level=%s, search weights=%+v)",
+		settings.QuotaRidesPerDay, settings.QuotaJoinsPerHour, settings.BookingFeeCents, settings.PeakFeeCents, settings.FeatureFlags, settings.LogLevel, settings.SearchWeights)
+}
+
+// WatchRuntime reloads the runtime settings on SIGHUP and, if RuntimeReloadInterval is set,
+// whenever the .env file's modification time changes.
+func (c *Config) WatchRuntime() {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	var ticks <-chan time.Time
+	if c.RuntimeReloadInterval > 0 {
+		ticks = time.NewTicker(c.RuntimeReloadInterval).C
+	}
+
+	go func() {
+		lastModified := envFileModTime()
+		for {
+			select {
+			case <-hangups:
+				log.Println("Config reload: SIGHUP received")
+				c.ReloadRuntime()
+				lastModified = envFileModTime()
+			case <-ticks:
+				if modified := envFileModTime(); !modified.Equal(lastModified) {
+					log.Println("Config reload: .env file changed")
+					c.ReloadRuntime()
+					lastModified = modified
+				}
+			}
+		}
+	}()
+	log.Printf("Runtime config watcher started (SIGHUP, polling every %s)", c.RuntimeReloadInterval)
+}
+
+// envFileModTime returns the .env file's modification time, zero if it doesn't exist.
+func envFileModTime() time.Time {
+	info, err := os.Stat(".env")
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
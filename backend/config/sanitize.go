@@ -0,0 +1,38 @@
+package config
+
+import (
+	"reflect" // For walking the Config fields
+	"time"    // For readable durations
+)
+
+// redacted replaces the value of secret settings in Sanitized().
+const redacted = "[REDACTED]"
+
+// Sanitized returns the effective configuration for debugging, keyed by field name.
+// Secret fields (tagged secret:"true") only show whether they are set.
+func (c *Config) Sanitized() map[string]any {
+	dump := map[string]any{}
+	value := reflect.ValueOf(c).Elem()
+	fields := value.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		v := value.Field(i).Interface()
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if value.Field(i).IsZero() {
+				dump[field.Name] = ""
+			} else {
+				dump[field.Name] = redacted
+			}
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			dump[field.Name] = v.(time.Duration).String()
+		default:
+			dump[field.Name] = v
+		}
+	}
+	dump["Runtime"] = c.Runtime()
+	return dump
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"     // For tracer contexts
+	"fmt"         // For formatting redacted arguments
+	"log"         // For logging queries
+	"strings"     // For compacting SQL text
+	"sync/atomic" // For the per-request counter
+	"time"        // For query durations
+
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+)
+
+// maxLoggedSQLLength bounds the SQL text of a logged query.
+const maxLoggedSQLLength = 2000
+
+// QueryCounterKey is the context key of a request's *QueryCounter. middleware.LogQueryCount sets it
+// in the request locals, which the request context passed to queries exposes.
+var QueryCounterKey = queryCounterKey{}
+
+type queryCounterKey struct{}
+
+// QueryCounter counts the queries issued under a context.
+type QueryCounter struct {
+	n atomic.Int64
+}
+
+// Count returns the number of queries counted so far.
+func (c *QueryCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// queryTraceKey carries a query's start between TraceQueryStart and TraceQueryEnd.
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql   string
+	args  []any
+	start time.Time
+}
+
+// QueryTracer is a pgx tracer logging slow queries (or every query, with SQL_LOG=all) and counting
+// queries per request. Bound parameters are never logged, only their types, since they carry
+// personal data (emails, locations, tokens).
+type QueryTracer struct {
+	mode      string        // config.SQLLogOff, SQLLogSlow or SQLLogAll
+	threshold time.Duration // Queries taking this long are slow
+}
+
+// NewQueryTracer creates the tracer for cfg. It returns nil when there is nothing to log or count.
+func NewQueryTracer(cfg *config.Config) *QueryTracer {
+	if cfg.SQLLog == config.SQLLogOff && cfg.QueryCountLogMin <= 0 {
+		return nil
+	}
+	return &QueryTracer{mode: cfg.SQLLog, threshold: cfg.SlowQueryThreshold}
+}
+
+// TraceQueryStart counts the query and records its start.
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if counter, ok := ctx.Value(QueryCounterKey).(*QueryCounter); ok {
+		counter.n.Add(1)
+	}
+	if t.mode == config.SQLLogOff {
+		return ctx
+	}
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{sql: data.SQL, args: data.Args, start: time.Now()})
+}
+
+// TraceQueryEnd logs the query if it was slow, or always with SQL_LOG=all.
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(trace.start)
+	slow := t.threshold > 0 && elapsed >= t.threshold
+	if !slow && t.mode != config.SQLLogAll {
+		return
+	}
+	label := "SQL"
+	if slow {
+		label = "Slow SQL"
+	}
+	if data.Err != nil {
+		log.Printf("%s (%s, error %v): %s %s", label, elapsed.Round(time.Millisecond), data.Err, compactSQL(trace.sql), redactArgs(trace.args))
+		return
+	}
+	log.Printf("%s (%s, %s): %s %s", label, elapsed.Round(time.Millisecond), data.CommandTag, compactSQL(trace.sql), redactArgs(trace.args))
+}
+
+// compactSQL collapses the whitespace of multi-line queries and truncates long ones.
+func compactSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxLoggedSQLLength {
+		sql = sql[:maxLoggedSQLLength] + "…"
+	}
+	return sql
+}
+
+// redactArgs describes bound parameters by type only, e.g. "[$1=uuid.UUID $2=string $3=NULL]".
+func redactArgs(args []any) string {
+	if len(args) == 0 {
+		return "[]"
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			parts[i] = fmt.Sprintf("$%d=NULL", i+1)
+			continue
+		}
+		parts[i] = fmt.Sprintf("$%d=%T", i+1, arg)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
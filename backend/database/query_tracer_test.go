@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+)
+
+// Test that logged queries describe their parameters by type only
+func TestRedactArgs(t *testing.T) {
+	got := redactArgs([]any{uuid.Nil, "ana@example.com", nil, 42})
+	if want := "[$1=uuid.UUID $2=string $3=NULL $4=int]"; got != want {
+		t.Errorf("redactArgs() = %q, want %q", got, want)
+	}
+}
+
+// Test that queries are counted under a request's counter, even with logging off
+func TestQueryTracer_CountsQueries(t *testing.T) {
+	tracer := NewQueryTracer(&config.Config{SQLLog: config.SQLLogOff, QueryCountLogMin: 10})
+	if tracer == nil {
+		t.Fatal("NewQueryTracer() = nil, want a tracer counting queries")
+	}
+	counter := &QueryCounter{}
+	ctx := context.WithValue(context.Background(), QueryCounterKey, counter)
+	for i := 0; i < 3; i++ {
+		tracer.TraceQueryEnd(tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"}), nil, pgx.TraceQueryEndData{})
+	}
+	if got := counter.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+	if NewQueryTracer(&config.Config{SQLLog: config.SQLLogOff}) != nil {
+		t.Error("NewQueryTracer() without logging or counting should return nil")
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/services"
+)
+
+// AccountDeletionHandler previews and performs account deletion.
+type AccountDeletionHandler struct {
+	accountDeletion *services.AccountDeletionService
+}
+
+// NewAccountDeletionHandler creates a new AccountDeletionHandler instance.
+func NewAccountDeletionHandler(accountDeletion *services.AccountDeletionService) *AccountDeletionHandler {
+	return &AccountDeletionHandler{
+		accountDeletion: accountDeletion,
+	}
+}
+
+// PreviewDeletion handles GET /api/v1/users/account/deletion-preview
+// Lists the rides that deleting the account would cancel and the joins it would refund.
+func (h *AccountDeletionHandler) PreviewDeletion(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	impact, err := h.accountDeletion.Preview(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Account deletion preview retrieved successfully",
+		"data":    impact,
+	})
+}
+
+// DeleteAccount handles DELETE /api/v1/users/account
+// Cancels the user's upcoming rides, leaves the rides they joined, then deletes the account.
+func (h *AccountDeletionHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	log.Printf("Received delete account request from user %s", userID)
+
+	impact, err := h.accountDeletion.Delete(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error deleting account for user %s: %v", userID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Account deleted successfully",
+		"data":    impact,
+	})
+}
+
+// SetupAccountDeletionRoutes registers the account deletion routes.
+func SetupAccountDeletionRoutes(api fiber.Router, accountDeletion *services.AccountDeletionService, authMiddleware fiber.Handler) {
+	handler := NewAccountDeletionHandler(accountDeletion)
+	api.Get("/users/account/deletion-preview", authMiddleware, handler.PreviewDeletion)
+	api.Delete("/users/account", authMiddleware, handler.DeleteAccount)
+	log.Println("Account deletion routes (/users/account, /users/account/deletion-preview) setup complete.")
+}
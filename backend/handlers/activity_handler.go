@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// ActivityHandler exposes the user's activity feed.
+type ActivityHandler struct {
+	activityService *services.ActivityService
+}
+
+// NewActivityHandler creates a new ActivityHandler instance.
+func NewActivityHandler(activityService *services.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+	}
+}
+
+// ListActivity handles GET /api/v1/users/me/activity
+// Returns a page of the user's recent rides, payments and notifications, newest first.
+func (h *ActivityHandler) ListActivity(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var params models.ActivityRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	items, err := h.activityService.List(c.Context(), userID, params)
+	if err != nil {
+		log.Printf("Error listing activity for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Activity retrieved successfully",
+		"data":    items,
+	})
+}
+
+// SetupActivityRoutes registers the activity feed route.
+func SetupActivityRoutes(api fiber.Router, activityService *services.ActivityService, authMiddleware fiber.Handler) {
+	handler := NewActivityHandler(activityService)
+	api.Get("/users/me/activity", authMiddleware, handler.ListActivity)
+	log.Println("Activity routes (/users/me/activity) setup complete.")
+}
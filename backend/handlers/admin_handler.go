@@ -0,0 +1,636 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"     // Local models
+	"rideshare/backend/services"   // Local services
+)
+
+// AdminHandler handles HTTP requests for admin and support tooling.
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+// NewAdminHandler creates a new AdminHandler instance.
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// Impersonate handles POST /api/v1/admin/impersonate/:userId
+// Issues a short-lived token acting as the user. Requires admin access.
+func (h *AdminHandler) Impersonate(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	targetUserID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	var req models.ImpersonateRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	response, err := h.adminService.Impersonate(c.Context(), adminID, targetUserID, req, c.IP())
+	if err != nil {
+		log.Printf("Error starting impersonation of user %s by admin %s: %v", targetUserID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Impersonation token issued",
+		"data":    response,
+	})
+}
+
+// SearchUsers handles GET /api/v1/admin/users?q=
+func (h *AdminHandler) SearchUsers(c *fiber.Ctx) error {
+	users, err := h.adminService.SearchUsers(c.Context(), c.Query("q"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Users retrieved successfully",
+		"data":    users,
+	})
+}
+
+// GetUserDetail handles GET /api/v1/admin/users/:userId
+func (h *AdminHandler) GetUserDetail(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	detail, err := h.adminService.GetUserDetail(c.Context(), adminID, userID, c.IP())
+	if err != nil {
+		log.Printf("Error fetching admin detail for user %s: %v", userID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "User details retrieved successfully",
+		"data":    detail,
+	})
+}
+
+// ForceCancelRide handles POST /api/v1/admin/rides/:rideId/cancel
+// Set "force" to also cancel a ride that already departed or was archived.
+func (h *AdminHandler) ForceCancelRide(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rideID, err := uuid.Parse(c.Params("rideId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+
+	var req models.AdminCancelRideRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	result, err := h.adminService.ForceCancelRide(c.Context(), adminID, rideID, req, c.IP())
+	if err != nil {
+		log.Printf("Error force-cancelling ride %s by admin %s: %v", rideID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride cancelled successfully",
+		"data":    result,
+	})
+}
+
+// EditRide handles PATCH /api/v1/admin/rides/:rideId
+func (h *AdminHandler) EditRide(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rideID, err := uuid.Parse(c.Params("rideId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+
+	var req models.AdminEditRideRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	ride, err := h.adminService.EditRide(c.Context(), adminID, rideID, req, c.IP())
+	if err != nil {
+		log.Printf("Error editing ride %s by admin %s: %v", rideID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride updated successfully",
+		"data":    ride,
+	})
+}
+
+// ListPayments handles GET /api/v1/admin/payments
+// Filters: status, from, to, min_amount, max_amount, stripe_payment_intent_id, user_id, ride_id, page, limit.
+func (h *AdminHandler) ListPayments(c *fiber.Ctx) error {
+	var filter models.AdminPaymentFilter
+	if handled, respErr := bindQuery(c, &filter); handled {
+		return respErr
+	}
+
+	payments, err := h.adminService.ListPayments(c.Context(), filter)
+	if err != nil {
+		if handled, respErr := validationFailed(c, err); handled {
+			return respErr
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to list payments"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Payments retrieved successfully",
+		"data":    payments,
+	})
+}
+
+// GetPayment handles GET /api/v1/admin/payments/:paymentId
+func (h *AdminHandler) GetPayment(c *fiber.Ctx) error {
+	paymentID, err := uuid.Parse(c.Params("paymentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid payment ID format"})
+	}
+
+	payment, err := h.adminService.GetPayment(c.Context(), paymentID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Payment retrieved successfully",
+		"data":    payment,
+	})
+}
+
+// RefundPayment handles POST /api/v1/admin/payments/:paymentId/refund
+func (h *AdminHandler) RefundPayment(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	paymentID, err := uuid.Parse(c.Params("paymentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid payment ID format"})
+	}
+
+	var req models.AdminRefundRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	result, err := h.adminService.RefundPayment(c.Context(), adminID, paymentID, req, c.IP())
+	if err != nil {
+		log.Printf("Error refunding payment %s by admin %s: %v", paymentID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Payment refunded successfully",
+		"data":    result,
+	})
+}
+
+// ListFraudRules handles GET /api/v1/admin/fraud/rules
+func (h *AdminHandler) ListFraudRules(c *fiber.Ctx) error {
+	rules, err := h.adminService.ListFraudRules(c.Context())
+	if err != nil {
+		log.Printf("Error listing fraud rules: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to list fraud rules"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Fraud rules retrieved successfully",
+		"data":    rules,
+	})
+}
+
+// UpdateFraudRule handles PATCH /api/v1/admin/fraud/rules/:ruleId
+func (h *AdminHandler) UpdateFraudRule(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	ruleID, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid rule ID format"})
+	}
+
+	var req models.UpdateFraudRuleRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	rule, err := h.adminService.UpdateFraudRule(c.Context(), adminID, ruleID, req, c.IP())
+	if err != nil {
+		log.Printf("Error updating fraud rule %s by admin %s: %v", ruleID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Fraud rule updated successfully",
+		"data":    rule,
+	})
+}
+
+// ListFraudFlags handles GET /api/v1/admin/fraud/flags?status=open
+func (h *AdminHandler) ListFraudFlags(c *fiber.Ctx) error {
+	status := c.Query("status", models.FraudFlagStatusOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	flags, err := h.adminService.ListFraudFlags(c.Context(), status)
+	if err != nil {
+		log.Printf("Error listing fraud flags: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to list fraud flags"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Fraud flags retrieved successfully",
+		"data":    flags,
+	})
+}
+
+// ResolveFraudFlag handles POST /api/v1/admin/fraud/flags/:flagId/resolve
+func (h *AdminHandler) ResolveFraudFlag(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	flagID, err := uuid.Parse(c.Params("flagId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid flag ID format"})
+	}
+
+	var req models.ResolveFraudFlagRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	flag, err := h.adminService.ResolveFraudFlag(c.Context(), adminID, flagID, req, c.IP())
+	if err != nil {
+		log.Printf("Error resolving fraud flag %s by admin %s: %v", flagID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Fraud flag resolved successfully",
+		"data":    flag,
+	})
+}
+
+// ListModerationFlags handles GET /api/v1/admin/moderation/flags?status=open
+func (h *AdminHandler) ListModerationFlags(c *fiber.Ctx) error {
+	status := c.Query("status", models.ModerationFlagStatusOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	flags, err := h.adminService.ListModerationFlags(c.Context(), status)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Moderation flags retrieved successfully",
+		"data":    flags,
+	})
+}
+
+// ResolveModerationFlag handles POST /api/v1/admin/moderation/flags/:flagId/resolve
+func (h *AdminHandler) ResolveModerationFlag(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	flagID, err := uuid.Parse(c.Params("flagId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid flag ID format"})
+	}
+
+	var req models.ResolveModerationFlagRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	flag, err := h.adminService.ResolveModerationFlag(c.Context(), adminID, flagID, req, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Moderation flag resolved successfully",
+		"data":    flag,
+	})
+}
+
+// GetUserQuotas handles GET /api/v1/admin/users/:userId/quotas
+func (h *AdminHandler) GetUserQuotas(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	quotas, err := h.adminService.GetUserQuotas(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching quotas for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve user quotas"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "User quotas retrieved successfully",
+		"data":    quotas,
+	})
+}
+
+// SetQuotaOverride handles PUT /api/v1/admin/users/:userId/quotas/:quota
+func (h *AdminHandler) SetQuotaOverride(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	quota := models.QuotaName(c.Params("quota"))
+
+	var req models.SetQuotaOverrideRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	override, err := h.adminService.SetQuotaOverride(c.Context(), adminID, userID, quota, req, c.IP())
+	if err != nil {
+		log.Printf("Error setting %s override for user %s by admin %s: %v", quota, userID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Quota override set successfully",
+		"data":    override,
+	})
+}
+
+// RemoveQuotaOverride handles DELETE /api/v1/admin/users/:userId/quotas/:quota
+func (h *AdminHandler) RemoveQuotaOverride(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	quota := models.QuotaName(c.Params("quota"))
+
+	if err := h.adminService.RemoveQuotaOverride(c.Context(), adminID, userID, quota, c.IP()); err != nil {
+		log.Printf("Error removing %s override for user %s by admin %s: %v", quota, userID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Quota override removed successfully",
+	})
+}
+
+// SetAgeOverride handles PUT /api/v1/admin/users/:userId/age-override
+// Lets a user below the minimum join age join rides, e.g. with a guardian's consent.
+func (h *AdminHandler) SetAgeOverride(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	var req models.SetAgeOverrideRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	override, err := h.adminService.SetAgeOverride(c.Context(), adminID, userID, req, c.IP())
+	if err != nil {
+		log.Printf("Error setting age override for user %s by admin %s: %v", userID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Age override set successfully",
+		"data":    override,
+	})
+}
+
+// RemoveAgeOverride handles DELETE /api/v1/admin/users/:userId/age-override
+func (h *AdminHandler) RemoveAgeOverride(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	if err := h.adminService.RemoveAgeOverride(c.Context(), adminID, userID, c.IP()); err != nil {
+		log.Printf("Error removing age override for user %s by admin %s: %v", userID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Age override removed successfully",
+	})
+}
+
+// CancelUserRides handles POST /api/v1/admin/users/:userId/rides/cancel
+// Queues the cancellation of every upcoming ride of the user; poll GET /admin/jobs/:jobId for progress.
+func (h *AdminHandler) CancelUserRides(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	var req models.BulkCancelUserRidesRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	job, err := h.adminService.EnqueueCancelUserRides(c.Context(), adminID, userID, req, c.IP())
+	if err != nil {
+		log.Printf("Error queueing ride cancellation of user %s by admin %s: %v", userID, adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride cancellation queued",
+		"data":    job,
+	})
+}
+
+// ArchiveRides handles POST /api/v1/admin/rides/archive
+// Queues the archiving of the active rides that departed in a date range.
+func (h *AdminHandler) ArchiveRides(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.BulkArchiveRidesRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	job, err := h.adminService.EnqueueArchiveRides(c.Context(), adminID, req, c.IP())
+	if err != nil {
+		log.Printf("Error queueing ride archiving by admin %s: %v", adminID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride archiving queued",
+		"data":    job,
+	})
+}
+
+// ListJobs handles GET /api/v1/admin/jobs
+func (h *AdminHandler) ListJobs(c *fiber.Ctx) error {
+	jobs, err := h.adminService.ListJobs(c.Context())
+	if err != nil {
+		log.Printf("Error listing admin jobs: %v", err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Jobs retrieved successfully",
+		"data":    jobs,
+	})
+}
+
+// GetJob handles GET /api/v1/admin/jobs/:jobId
+func (h *AdminHandler) GetJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid job ID format"})
+	}
+
+	job, err := h.adminService.GetJob(c.Context(), jobID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Job retrieved successfully",
+		"data":    job,
+	})
+}
+
+// GetConfig handles GET /api/v1/admin/config
+// Returns the effective configuration (defaults, config files, environment) with secrets redacted.
+func (h *AdminHandler) GetConfig(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Configuration retrieved successfully",
+		"data":    h.adminService.GetSanitizedConfig(c.Context(), adminID, c.IP()),
+	})
+}
+
+// SetupAdminRoutes registers admin routes. All of them require an authenticated admin.
+func SetupAdminRoutes(api fiber.Router, adminService *services.AdminService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewAdminHandler(adminService)
+
+	adminGroup := api.Group("/admin", authMiddleware, adminMiddleware)
+	adminGroup.Post("/impersonate/:userId", handler.Impersonate)
+	adminGroup.Get("/users", handler.SearchUsers)
+	adminGroup.Get("/users/:userId", handler.GetUserDetail)
+	adminGroup.Get("/users/:userId/quotas", handler.GetUserQuotas)
+	adminGroup.Put("/users/:userId/quotas/:quota", handler.SetQuotaOverride)
+	adminGroup.Delete("/users/:userId/quotas/:quota", handler.RemoveQuotaOverride)
+	adminGroup.Put("/users/:userId/age-override", handler.SetAgeOverride)
+	adminGroup.Delete("/users/:userId/age-override", handler.RemoveAgeOverride)
+	adminGroup.Post("/users/:userId/rides/cancel", handler.CancelUserRides)
+	adminGroup.Post("/rides/archive", handler.ArchiveRides)
+	adminGroup.Post("/rides/:rideId/cancel", handler.ForceCancelRide)
+	adminGroup.Patch("/rides/:rideId", handler.EditRide)
+	adminGroup.Get("/payments", handler.ListPayments)
+	adminGroup.Get("/payments/:paymentId", handler.GetPayment)
+	adminGroup.Post("/payments/:paymentId/refund", handler.RefundPayment)
+	adminGroup.Get("/fraud/rules", handler.ListFraudRules)
+	adminGroup.Patch("/fraud/rules/:ruleId", handler.UpdateFraudRule)
+	adminGroup.Get("/fraud/flags", handler.ListFraudFlags)
+	adminGroup.Post("/fraud/flags/:flagId/resolve", handler.ResolveFraudFlag)
+	adminGroup.Get("/moderation/flags", handler.ListModerationFlags)
+	adminGroup.Post("/moderation/flags/:flagId/resolve", handler.ResolveModerationFlag)
+	adminGroup.Get("/jobs", handler.ListJobs)
+	adminGroup.Get("/jobs/:jobId", handler.GetJob)
+	adminGroup.Get("/config", handler.GetConfig)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/services"
+)
+
+// AnonymousSessionHandler exposes anonymous browsing sessions and recent searches.
+type AnonymousSessionHandler struct {
+	anonymousSessions *services.AnonymousSessionService
+}
+
+// NewAnonymousSessionHandler creates a new AnonymousSessionHandler instance.
+func NewAnonymousSessionHandler(anonymousSessions *services.AnonymousSessionService) *AnonymousSessionHandler {
+	return &AnonymousSessionHandler{
+		anonymousSessions: anonymousSessions,
+	}
+}
+
+// StartSession handles POST /api/v1/auth/anonymous
+// Public: returns a browsing token for visitors without an account, sent back in the
+// X-Anonymous-Session header on ride searches and at signup.
+func (h *AnonymousSessionHandler) StartSession(c *fiber.Ctx) error {
+	session, err := h.anonymousSessions.Start(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Anonymous session started",
+		"data":    session,
+	})
+}
+
+// ListRecentSearches handles GET /api/v1/users/me/recent-searches
+// Returns the user's recent ride searches, including those made before signing up.
+func (h *AnonymousSessionHandler) ListRecentSearches(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	searches, err := h.anonymousSessions.RecentSearches(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing recent searches for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Recent searches retrieved successfully",
+		"data":    searches,
+	})
+}
+
+// SetupAnonymousSessionRoutes registers the anonymous session and recent search routes.
+func SetupAnonymousSessionRoutes(api fiber.Router, anonymousSessions *services.AnonymousSessionService, authMiddleware fiber.Handler) {
+	handler := NewAnonymousSessionHandler(anonymousSessions)
+	api.Post("/auth/anonymous", handler.StartSession)
+	api.Get("/users/me/recent-searches", authMiddleware, handler.ListRecentSearches)
+	log.Println("Anonymous session routes (/auth/anonymous, /users/me/recent-searches) setup complete.")
+}
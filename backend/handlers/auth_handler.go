@@ -2,21 +2,22 @@ package handlers
 
 import (
 	"errors"
-	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 
+	"rideshare/backend/middleware" // Authenticated user accessor
 	"rideshare/backend/models"
 	"rideshare/backend/services"
 )
 
 // AuthHandler handles HTTP requests related to authentication and user management.
 type AuthHandler struct {
-	authService *services.AuthService
+	authService       *services.AuthService
+	anonymousSessions *services.AnonymousSessionService // Upgraded at signup; nil on routes without signup
 }
 
 // RegisterPushTokenRequest defines the structure for the push token registration request.
@@ -25,48 +26,35 @@ type RegisterPushTokenRequest struct {
 }
 
 // NewAuthHandler creates a new AuthHandler instance.
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, anonymousSessions *services.AnonymousSessionService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:       authService,
+		anonymousSessions: anonymousSessions,
 	}
 }
 
 // SignUp handles the POST /api/v1/auth/signup request.
 func (h *AuthHandler) SignUp(c *fiber.Ctx) error {
 	var req models.SignUpRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing signup request body: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request body",
-			"details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
 	log.Printf("Received signup request for email: %s", req.Email)
+	req.IPAddress = c.IP()
 
 	user, err := h.authService.SignUp(c.Context(), req)
 	if err != nil {
 		log.Printf("Error during signup process for email %s: %v", req.Email, err)
-		statusCode := fiber.StatusInternalServerError
-		errorMessage := "Signup failed due to an internal error"
-		errMsg := err.Error()
-		if errMsg == "email or WhatsApp number already registered" {
-			statusCode = fiber.StatusConflict
-			errorMessage = errMsg
-		} else if errMsg == "invalid birth date format (use YYYY-MM-DD)" {
-			statusCode = fiber.StatusBadRequest
-			errorMessage = errMsg
-		} else {
-			var validationErrors validator.ValidationErrors
-			if errors.As(err, &validationErrors) {
-				statusCode = fiber.StatusBadRequest
-				errorMessage = fmt.Sprintf("Invalid signup data: %v", validationErrors)
-			}
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	log.Printf("Signup successful for user: %s (ID: %s)", user.Email, user.ID)
+	// Keep the recent searches made while browsing anonymously; the account exists either way
+	if sessionID, ok := middleware.CurrentAnonymousSessionID(c); ok && h.anonymousSessions != nil {
+		if err := h.anonymousSessions.Upgrade(c.Context(), sessionID, user.ID); err != nil {
+			log.Printf("Warning: Failed upgrading anonymous session %s to user %s: %v", sessionID, user.ID, err)
+		}
+	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"status":  "success",
 		"message": "User registered successfully",
@@ -77,31 +65,15 @@ func (h *AuthHandler) SignUp(c *fiber.Ctx) error {
 // Login handles the POST /api/v1/auth/login request.
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing login request body: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status": "error", "message": "Invalid request body", "details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
 	log.Printf("Received login request for email: %s", req.Email)
 
 	loginResponse, err := h.authService.Login(c.Context(), req)
 	if err != nil {
 		log.Printf("Error during login process for email %s: %v", req.Email, err)
-		statusCode := fiber.StatusInternalServerError
-		errorMessage := "Login failed due to an internal error"
-		errMsg := err.Error()
-		if errMsg == "invalid email or password" {
-			statusCode = fiber.StatusUnauthorized
-			errorMessage = errMsg
-		} else {
-			var validationErrors validator.ValidationErrors
-			if errors.As(err, &validationErrors) {
-				statusCode = fiber.StatusBadRequest
-				errorMessage = fmt.Sprintf("Invalid login data: %v", validationErrors)
-			}
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	log.Printf("Login successful for user: %s (ID: %s)", loginResponse.User.Email, loginResponse.User.ID)
@@ -110,69 +82,40 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	})
 }
 
-// Helper to get userID from context, handling potential string or uuid.UUID types
-func getUserIDFromContext(c *fiber.Ctx, handlerName string) (uuid.UUID, error) {
-	userIDLocal := c.Locals("userID")
-	if userIDLocal == nil {
-		log.Printf("Error: User ID not found in context (%s)", handlerName)
-		return uuid.Nil, errors.New("unauthorized: Missing user identification")
-	}
-
-	switch id := userIDLocal.(type) {
-	case uuid.UUID:
-		return id, nil
-	case string:
-		parsedID, err := uuid.Parse(id)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context (%s): %s", handlerName, id)
-			return uuid.Nil, errors.New("unauthorized: Invalid user identification format")
-		}
-		return parsedID, nil
-	default:
-		log.Printf("Error: Unexpected User ID type in context (%s): %T", handlerName, userIDLocal)
-		return uuid.Nil, errors.New("unauthorized: Unexpected user identification type")
-	}
+// quotaExceededResponse writes a 429 with Retry-After if err is a per-account quota error.
+// Returns false if err is not a quota error, so the caller can keep mapping it.
+func quotaExceededResponse(c *fiber.Ctx, err error) (bool, error) {
+	var quotaErr *services.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false, nil
+	}
+	retryAfter := int(math.Ceil(quotaErr.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return true, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":  "error",
+		"message": quotaErr.Error(),
+		"data":    fiber.Map{"quota": quotaErr.Quota, "limit": quotaErr.Limit, "retry_after_seconds": retryAfter},
+	})
 }
 
 // UpdateProfile handles PUT /api/v1/users/profile
 func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
-	userID, err := getUserIDFromContext(c, "UpdateProfile")
+	userID, err := middleware.CurrentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return err
 	}
 
 	var req models.UpdateProfileRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing update profile request body for user %s: %v", userID, err)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"status": "error", "message": "Invalid request body", "details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
+	req.IPAddress = c.IP()
 	log.Printf("Received update profile request from user %s: %+v", userID, req)
 
 	updatedUser, err := h.authService.UpdateProfile(c.Context(), userID, req)
 	if err != nil {
 		log.Printf("Error updating profile for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to update profile"
-		errMsg := err.Error()
-		if errMsg == "no update data provided" || errMsg == "invalid birth date format (use YYYY-MM-DD)" {
-			statusCode = http.StatusBadRequest
-			errorMessage = errMsg
-		} else if errMsg == "whatsapp number already registered" {
-			statusCode = http.StatusConflict
-			errorMessage = errMsg
-		} else if errMsg == "user not found or deleted" {
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		} else {
-			var validationErrors validator.ValidationErrors
-			if errors.As(err, &validationErrors) {
-				statusCode = http.StatusBadRequest
-				errorMessage = fmt.Sprintf("Invalid profile data: %v", validationErrors)
-			}
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	log.Printf("Profile updated successfully for user %s", userID)
@@ -181,68 +124,41 @@ func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteAccount handles DELETE /api/v1/users/account
-func (h *AuthHandler) DeleteAccount(c *fiber.Ctx) error {
-	userID, err := getUserIDFromContext(c, "DeleteAccount")
+// ProfileHistory handles GET /api/v1/users/me/profile-history
+func (h *AuthHandler) ProfileHistory(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return err
 	}
-	log.Printf("Received delete account request from user %s", userID)
 
-	err = h.authService.DeleteAccount(c.Context(), userID)
+	changes, err := h.authService.ProfileHistory(c.Context(), userID)
 	if err != nil {
-		log.Printf("Error deleting account for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to delete account"
-		if err.Error() == "user not found or already deleted" {
-			statusCode = http.StatusNotFound
-			errorMessage = err.Error()
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		log.Printf("Error fetching profile history for user %s: %v", userID, err)
+		return err
 	}
 
-	log.Printf("Account deleted successfully for user %s", userID)
 	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"status": "success", "message": "Account deleted successfully",
+		"status": "success", "message": "Profile history retrieved successfully", "data": changes,
 	})
 }
 
 // UpdateLocation handles PUT /api/v1/users/location
 func (h *AuthHandler) UpdateLocation(c *fiber.Ctx) error {
-	userID, err := getUserIDFromContext(c, "UpdateLocation")
+	userID, err := middleware.CurrentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return err
 	}
 
 	var req models.UpdateLocationRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing update location request body for user %s: %v", userID, err)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"status": "error", "message": "Invalid request body", "details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
 	log.Printf("Received update location request from user %s: Lat=%f, Lon=%f", userID, req.Latitude, req.Longitude)
 
 	err = h.authService.UpdateLocation(c.Context(), userID, req.Latitude, req.Longitude)
 	if err != nil {
 		log.Printf("Error updating location for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to update location"
-		errMsg := err.Error()
-		if errMsg == "user not found or deleted" {
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		} else if errMsg == "invalid latitude or longitude provided" {
-			statusCode = http.StatusBadRequest
-			errorMessage = errMsg
-		} else {
-			var validationErrors validator.ValidationErrors
-			if errors.As(err, &validationErrors) {
-				statusCode = http.StatusBadRequest
-				errorMessage = fmt.Sprintf("Invalid location data: %v", validationErrors)
-			}
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	log.Printf("Location updated successfully for user %s", userID)
@@ -251,17 +167,14 @@ func (h *AuthHandler) UpdateLocation(c *fiber.Ctx) error {
 
 // RegisterPushToken handles POST /api/v1/users/push-token
 func (h *AuthHandler) RegisterPushToken(c *fiber.Ctx) error {
-	userID, err := getUserIDFromContext(c, "RegisterPushToken")
+	userID, err := middleware.CurrentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return err
 	}
 
 	var req RegisterPushTokenRequest // Use the struct defined at package level
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing register push token request body for user %s: %v", userID, err)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"status": "error", "message": "Invalid request body", "details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
 
 	// Basic validation on the token itself
@@ -273,17 +186,7 @@ func (h *AuthHandler) RegisterPushToken(c *fiber.Ctx) error {
 	err = h.authService.RegisterPushToken(c.Context(), userID, req.Token)
 	if err != nil {
 		log.Printf("Error registering push token for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to register push token"
-		errMsg := err.Error()
-		if errMsg == "user not found or deleted" {
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		} else if errMsg == "invalid push token format" {
-			statusCode = http.StatusBadRequest
-			errorMessage = errMsg
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	log.Printf("Push token registered successfully for user %s", userID)
@@ -292,18 +195,19 @@ func (h *AuthHandler) RegisterPushToken(c *fiber.Ctx) error {
 
 // SetupUserRoutes registers user profile and account management routes.
 func SetupUserRoutes(api fiber.Router, authService *services.AuthService, authMiddleware fiber.Handler) {
-	handler := NewAuthHandler(authService)
+	handler := NewAuthHandler(authService, nil)
 	userGroup := api.Group("/users")
 	userGroup.Put("/profile", authMiddleware, handler.UpdateProfile)
-	userGroup.Delete("/account", authMiddleware, handler.DeleteAccount)
+	userGroup.Get("/me/profile-history", authMiddleware, handler.ProfileHistory)
 	userGroup.Put("/location", authMiddleware, handler.UpdateLocation)
 	userGroup.Post("/push-token", authMiddleware, handler.RegisterPushToken) // Register the new route
-	log.Println("User routes (/users/profile, /users/account, /users/location, /users/push-token) setup complete.")
+	log.Println("User routes (/users/profile, /users/me/profile-history, /users/location, /users/push-token) setup complete.")
 }
 
-// SetupAuthRoutes registers the public authentication routes.
-func SetupAuthRoutes(api fiber.Router, authService *services.AuthService) {
-	handler := NewAuthHandler(authService)
+// SetupAuthRoutes registers the public authentication routes. Signing up with an
+// X-Anonymous-Session header upgrades that anonymous session to the new account.
+func SetupAuthRoutes(api fiber.Router, authService *services.AuthService, anonymousSessions *services.AnonymousSessionService) {
+	handler := NewAuthHandler(authService, anonymousSessions)
 	authGroup := api.Group("/auth")
 	authGroup.Post("/signup", handler.SignUp)
 	authGroup.Post("/login", handler.Login)
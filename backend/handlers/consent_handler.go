@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// ConsentHandler exposes the user's data processing consents.
+type ConsentHandler struct {
+	consentService *services.ConsentService
+}
+
+// NewConsentHandler creates a new ConsentHandler instance.
+func NewConsentHandler(consentService *services.ConsentService) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+	}
+}
+
+// ListConsents handles GET /api/v1/users/me/consents
+func (h *ConsentHandler) ListConsents(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	consents, err := h.consentService.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Consents retrieved successfully",
+		"data":    consents,
+	})
+}
+
+// UpdateConsent handles PUT /api/v1/users/me/consents/:type
+// Grants or withdraws one consent and returns the state of all of them.
+func (h *ConsentHandler) UpdateConsent(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	consentType := models.ConsentType(c.Params("type"))
+
+	var req models.UpdateConsentRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	consents, err := h.consentService.Set(c.Context(), userID, consentType, req, c.IP())
+	if err != nil {
+		log.Printf("Error updating %s consent for user %s: %v", consentType, userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Consent updated successfully",
+		"data":    consents,
+	})
+}
+
+// SetupConsentRoutes registers the consent routes.
+func SetupConsentRoutes(api fiber.Router, consentService *services.ConsentService, authMiddleware fiber.Handler) {
+	handler := NewConsentHandler(consentService)
+	api.Get("/users/me/consents", authMiddleware, handler.ListConsents)
+	api.Put("/users/me/consents/:type", authMiddleware, handler.UpdateConsent)
+	log.Println("Consent routes (/users/me/consents) setup complete.")
+}
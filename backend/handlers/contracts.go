@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"rideshare/backend/models"
+)
+
+// ResponseContracts maps the ride and payment routes the mobile app consumes to the type their
+// handler returns as "data". middleware.ValidateResponses generates the schemas from these types,
+// so keep an entry in sync when a handler starts returning a different type.
+func ResponseContracts() map[string]any {
+	return map[string]any{
+		"GET /api/v1/rides":                                 []models.Ride{},
+		"GET /api/v1/rides/search":                          []models.Ride{},
+		"GET /api/v1/rides/search/suggestions":              []models.SearchSuggestion{},
+		"GET /api/v1/rides/nearby":                          []models.Ride{},
+		"POST /api/v1/rides":                                models.Ride{},
+		"GET /api/v1/rides/:id":                             models.Ride{},
+		"POST /api/v1/rides/:id/duplicate":                  models.Ride{},
+		"POST /api/v1/rides/from-template/:templateId":      models.Ride{},
+		"POST /api/v1/rides/:id/join":                       models.JoinRideResponse{},
+		"POST /api/v1/ride-invitations/:id/accept":          models.JoinRideResponse{},
+		"POST /api/v1/rides/:id/leave":                      models.LeaveRideResponse{},
+		"POST /api/v1/rides/:id/cancel":                     models.CancelRideResponse{},
+		"DELETE /api/v1/rides/:id":                          models.CancelRideResponse{},
+		"PUT /api/v1/rides/:id/pickup-point":                models.Ride{},
+		"GET /api/v1/rides/:id/share-link":                  models.RideShareLink{},
+		"GET /api/v1/public/rides/:token":                   models.SharedRide{},
+		"POST /api/v1/rides/:id/reviews":                    models.Review{},
+		"GET /api/v1/users/:id/reviews":                     models.UserReviews{},
+		"GET /api/v1/users/me/rides/created":                []models.Ride{},
+		"GET /api/v1/users/me/rides/joined":                 []models.Ride{},
+		"GET /api/v1/users/me/rides/history":                []models.Ride{},
+		"GET /api/v1/users/me/activity":                     []models.ActivityItem{},
+		"GET /api/v1/users/me/sync":                         models.SyncResponse{},
+		"GET /api/v1/users/me/driver-dashboard":             models.DriverDashboard{},
+		"POST /api/v1/rides/:ride_id/create-payment-intent": models.CreatePaymentIntentResponse{},
+		"POST /api/v1/payments/setup-intent":                models.CreateSetupIntentResponse{},
+	}
+}
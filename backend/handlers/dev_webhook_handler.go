@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors" // For the unhealthy server check
+	"log"    // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/services"
+)
+
+// DevWebhookHandler signs and posts Stripe webhook events to the local server for development.
+type DevWebhookHandler struct {
+	devWebhooks *services.DevWebhookService
+}
+
+// NewDevWebhookHandler creates a new DevWebhookHandler instance.
+func NewDevWebhookHandler(devWebhooks *services.DevWebhookService) *DevWebhookHandler {
+	return &DevWebhookHandler{
+		devWebhooks: devWebhooks,
+	}
+}
+
+// SendStripeWebhook handles POST /api/v1/dev/stripe-webhook
+// The body is a Stripe event, at least {"type": "payment_intent.succeeded", "data": {"object": {...}}}.
+// It is signed with STRIPE_WEBHOOK_SECRET and posted to /api/v1/stripe-webhook; the response
+// reports how the webhook endpoint answered.
+func (h *DevWebhookHandler) SendStripeWebhook(c *fiber.Ctx) error {
+	delivery, err := h.devWebhooks.Send(c.Context(), c.Body())
+	if err != nil {
+		if errors.Is(err, services.ErrLocalServerUnhealthy) {
+			log.Printf("Dev webhook not sent: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Webhook event signed and posted",
+		"data":    delivery,
+	})
+}
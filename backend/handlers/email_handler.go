@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"     // For building the unsubscribe page
+	"html"    // For escaping the unsubscribe page
+	"log"     // For logging
+	"net/url" // For the unsubscribe form action
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"     // Local models
+	"rideshare/backend/services"   // Local services
+)
+
+// EmailHandler handles unsubscribe links and the email preference center.
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailHandler creates a new EmailHandler instance.
+func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
+	return &EmailHandler{
+		emailService: emailService,
+	}
+}
+
+// unsubscribePage renders the minimal HTML page shown by the unsubscribe link.
+// When confirmed is false, it asks for confirmation with a form posting back to the same link.
+func unsubscribePage(token string, category models.EmailCategory, confirmed bool) string {
+	if confirmed {
+		return fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>Unsubscribed</title></head>`+
+			`<body><p>You will no longer receive %s emails.</p></body></html>`, html.EscapeString(string(category)))
+	}
+	return fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>Unsubscribe</title></head>`+
+		`<body><p>Stop receiving %s emails?</p>`+
+		`<form method="post" action="?token=%s"><input type="hidden" name="List-Unsubscribe" value="One-Click">`+
+		`<button type="submit">Unsubscribe</button></form></body></html>`,
+		html.EscapeString(string(category)), html.EscapeString(url.QueryEscape(token)))
+}
+
+// ConfirmUnsubscribe handles GET /api/v1/email/unsubscribe?token=...
+// Public: only renders a confirmation page. Nothing is recorded, so link scanners and
+// prefetching mail clients cannot unsubscribe users by following the link.
+func (h *EmailHandler) ConfirmUnsubscribe(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Missing unsubscribe token"})
+	}
+
+	category, err := h.emailService.UnsubscribeCategory(token)
+	if err != nil {
+		return err
+	}
+
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).SendString(unsubscribePage(token, category, false))
+}
+
+// Unsubscribe handles POST /api/v1/email/unsubscribe?token=...
+// Public: the signed token identifies the user. Records the opt-out, both for RFC 8058 one-click
+// requests from mail clients and for the confirmation form.
+func (h *EmailHandler) Unsubscribe(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Missing unsubscribe token"})
+	}
+
+	category, err := h.emailService.Unsubscribe(c.Context(), token)
+	if err != nil {
+		return err
+	}
+
+	// Browsers submitting the confirmation form get a page back; mail clients get JSON
+	if c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+		c.Type("html", "utf-8")
+		return c.Status(fiber.StatusOK).SendString(unsubscribePage(token, category, true))
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "You have been unsubscribed",
+		"data":    fiber.Map{"category": category},
+	})
+}
+
+// GetPreferences handles GET /api/v1/users/me/email-preferences
+func (h *EmailHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	preferences, err := h.emailService.GetPreferences(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve email preferences"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Email preferences retrieved successfully",
+		"data":    preferences,
+	})
+}
+
+// UpdatePreferences handles PUT /api/v1/users/me/email-preferences
+func (h *EmailHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateEmailPreferencesRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	preferences, err := h.emailService.UpdatePreferences(c.Context(), userID, req)
+	if err != nil {
+		log.Printf("Error updating email preferences for user %s: %v", userID, err)
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Email preferences updated successfully",
+		"data":    preferences,
+	})
+}
+
+// SetupEmailRoutes registers the public unsubscribe endpoint and the email preference center.
+func SetupEmailRoutes(api fiber.Router, emailService *services.EmailService, authMiddleware fiber.Handler) {
+	handler := NewEmailHandler(emailService)
+
+	api.Get("/email/unsubscribe", handler.ConfirmUnsubscribe)
+	api.Post("/email/unsubscribe", handler.Unsubscribe) // RFC 8058 one-click unsubscribe
+
+	api.Get("/users/me/email-preferences", authMiddleware, handler.GetPreferences)
+	api.Put("/users/me/email-preferences", authMiddleware, handler.UpdatePreferences)
+}
+
+// EmailPreviewHandler serves rendered email templates for local development.
+type EmailPreviewHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailPreviewHandler creates a new EmailPreviewHandler instance.
+func NewEmailPreviewHandler(emailService *services.EmailService) *EmailPreviewHandler {
+	return &EmailPreviewHandler{
+		emailService: emailService,
+	}
+}
+
+// ListTemplates handles GET /api/v1/dev/emails
+func (h *EmailPreviewHandler) ListTemplates(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Email templates retrieved successfully",
+		"data":    h.emailService.Templates(),
+	})
+}
+
+// PreviewTemplate handles GET /api/v1/dev/emails/:template?locale=fr
+// Returns the rendered HTML so it can be opened directly in a browser.
+func (h *EmailPreviewHandler) PreviewTemplate(c *fiber.Ctx) error {
+	templateName := c.Params("template")
+	locale := c.Query("locale", "en")
+
+	rendered, err := h.emailService.Preview(templateName, locale)
+	if err != nil {
+		log.Printf("Error rendering email preview %s (%s): %v", templateName, locale, err)
+		return err
+	}
+
+	c.Set("X-Email-Subject", rendered.Subject)
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).SendString(rendered.HTML)
+}
+
+// SetupDevRoutes registers development-only routes. Only call this when running in development mode.
+func SetupDevRoutes(api fiber.Router, emailService *services.EmailService, devWebhooks *services.DevWebhookService) {
+	handler := NewEmailPreviewHandler(emailService)
+	webhookHandler := NewDevWebhookHandler(devWebhooks)
+
+	devGroup := api.Group("/dev")
+	devGroup.Get("/emails", handler.ListTemplates)
+	devGroup.Get("/emails/:template", handler.PreviewTemplate)
+	devGroup.Post("/stripe-webhook", webhookHandler.SendStripeWebhook)
+
+	log.Println("Development routes (/dev/emails, /dev/stripe-webhook) registered")
+}
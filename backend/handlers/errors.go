@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors" // For error type checks
+	"log"    // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Request context for error reports
+	"rideshare/backend/services"
+)
+
+// serviceErrorStatus maps service error kinds to HTTP status codes.
+var serviceErrorStatus = map[services.ErrorKind]int{
+	services.KindInvalid:         fiber.StatusBadRequest,
+	services.KindUnauthorized:    fiber.StatusUnauthorized,
+	services.KindPaymentRequired: fiber.StatusPaymentRequired,
+	services.KindForbidden:       fiber.StatusForbidden,
+	services.KindNotFound:        fiber.StatusNotFound,
+	services.KindConflict:        fiber.StatusConflict,
+}
+
+// NewErrorHandler returns the app-wide Fiber error handler. Handlers return service errors as-is
+// and this maps them to a status code and the standard error envelope:
+//   - validation errors: 400 with per-field errors
+//   - quota errors: 429 with Retry-After
+//   - age requirement errors: 403 with the requirement and minimum age
+//   - ride conflict errors: 409 with the conflicting ride
+//   - drive time errors: 409 with the conflicting ride and the time available for the drive
+//   - *services.Error: the status for its kind, with its client-safe message
+//   - *fiber.Error: its own code and message (e.g. unknown routes)
+//   - anything else: 500 without internal details, reported to the error reporter
+func NewErrorHandler(reporter *services.ErrorReporter) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		return handleError(c, err, reporter)
+	}
+}
+
+// handleError implements the error handler returned by NewErrorHandler.
+func handleError(c *fiber.Ctx, err error, reporter *services.ErrorReporter) error {
+	if handled, respErr := validationFailed(c, err); handled {
+		return respErr
+	}
+	if handled, respErr := quotaExceededResponse(c, err); handled {
+		return respErr
+	}
+	if handled, respErr := ageRequirementResponse(c, err); handled {
+		return respErr
+	}
+	if handled, respErr := rideConflictResponse(c, err); handled {
+		return respErr
+	}
+	if handled, respErr := driveTimeResponse(c, err); handled {
+		return respErr
+	}
+
+	var serviceErr *services.Error
+	if errors.As(err, &serviceErr) {
+		statusCode, ok := serviceErrorStatus[serviceErr.Kind]
+		if !ok {
+			statusCode = fiber.StatusInternalServerError
+		}
+		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": serviceErr.Message})
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{"status": "error", "message": fiberErr.Message})
+	}
+
+	log.Printf("Internal error on %s %s: %v", c.Method(), c.Path(), err)
+	reporter.CaptureError(err, middleware.ErrorContext(c))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status":  "error",
+		"message": "An internal error occurred. Please try again later.",
+	})
+}
+
+// ageRequirementResponse writes a 403 with the minimum age if err is an age requirement error.
+// Returns false if err is not an age requirement error, so the caller can keep mapping it.
+func ageRequirementResponse(c *fiber.Ctx, err error) (bool, error) {
+	var ageErr *services.AgeRequirementError
+	if !errors.As(err, &ageErr) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": ageErr.Error(),
+		"data":    fiber.Map{"requirement": ageErr.Requirement, "minimum_age": ageErr.MinimumAge},
+	})
+}
+
+// rideConflictResponse writes a 409 with the conflicting ride if err is a ride conflict error.
+// Returns false if err is not a ride conflict error, so the caller can keep mapping it.
+func rideConflictResponse(c *fiber.Ctx, err error) (bool, error) {
+	var conflictErr *services.RideConflictError
+	if !errors.As(err, &conflictErr) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"status":  "error",
+		"message": conflictErr.Error(),
+		"data":    fiber.Map{"conflicting_ride": conflictErr.Ride},
+	})
+}
+
+// driveTimeResponse writes a 409 with the drive time details if err is a drive time error.
+// Returns false if err is not a drive time error, so the caller can keep mapping it.
+func driveTimeResponse(c *fiber.Ctx, err error) (bool, error) {
+	var driveErr *services.DriveTimeError
+	if !errors.As(err, &driveErr) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"status":  "error",
+		"message": driveErr.Error(),
+		"data":    driveErr.Conflict,
+	})
+}
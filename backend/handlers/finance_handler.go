@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt" // For the download file name
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// FinanceHandler exposes accounting exports to admins.
+type FinanceHandler struct {
+	financeExports *services.FinanceExportService
+}
+
+// NewFinanceHandler creates a new FinanceHandler instance.
+func NewFinanceHandler(financeExports *services.FinanceExportService) *FinanceHandler {
+	return &FinanceHandler{
+		financeExports: financeExports,
+	}
+}
+
+// CreateExport handles POST /api/v1/admin/finance/exports
+// Queues a journal export of a period; poll GET /admin/finance/exports/:exportId until it succeeded.
+func (h *FinanceHandler) CreateExport(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateFinanceExportRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	export, err := h.financeExports.Create(c.Context(), adminID, req, c.IP())
+	if err != nil {
+		log.Printf("Error queueing finance export by admin %s: %v", adminID, err)
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Export queued",
+		"data":    export,
+	})
+}
+
+// ListExports handles GET /api/v1/admin/finance/exports
+func (h *FinanceHandler) ListExports(c *fiber.Ctx) error {
+	exports, err := h.financeExports.List(c.Context())
+	if err != nil {
+		log.Printf("Error listing finance exports: %v", err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Exports retrieved successfully",
+		"data":    exports,
+	})
+}
+
+// GetExport handles GET /api/v1/admin/finance/exports/:exportId
+func (h *FinanceHandler) GetExport(c *fiber.Ctx) error {
+	exportID, err := uuid.Parse(c.Params("exportId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid export ID format"})
+	}
+
+	export, err := h.financeExports.Get(c.Context(), exportID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Export retrieved successfully",
+		"data":    export,
+	})
+}
+
+// DownloadExport handles GET /api/v1/admin/finance/exports/:exportId/download
+// Returns the generated file as an attachment.
+func (h *FinanceHandler) DownloadExport(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	exportID, err := uuid.Parse(c.Params("exportId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid export ID format"})
+	}
+
+	content, filename, contentType, err := h.financeExports.Download(c.Context(), adminID, exportID, c.IP())
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Status(fiber.StatusOK).Send(content)
+}
+
+// SetupFinanceRoutes registers the admin accounting export routes.
+func SetupFinanceRoutes(api fiber.Router, financeExports *services.FinanceExportService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewFinanceHandler(financeExports)
+	api.Post("/admin/finance/exports", authMiddleware, adminMiddleware, handler.CreateExport)
+	api.Get("/admin/finance/exports", authMiddleware, adminMiddleware, handler.ListExports)
+	api.Get("/admin/finance/exports/:exportId", authMiddleware, adminMiddleware, handler.GetExport)
+	api.Get("/admin/finance/exports/:exportId/download", authMiddleware, adminMiddleware, handler.DownloadExport)
+	log.Println("Finance routes (/admin/finance/exports) setup complete.")
+}
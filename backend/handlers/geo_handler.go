@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/models"   // Local models
+	"rideshare/backend/services" // Local services
+)
+
+// GeoHandler exposes the caller's location-based defaults.
+type GeoHandler struct {
+	geoService *services.GeoIPService
+}
+
+// NewGeoHandler creates a new GeoHandler instance.
+func NewGeoHandler(geoService *services.GeoIPService) *GeoHandler {
+	return &GeoHandler{
+		geoService: geoService,
+	}
+}
+
+// GetGeoContext handles GET /api/v1/geo
+// Returns the caller's country and the currency and locale the app should default to.
+func (h *GeoHandler) GetGeoContext(c *fiber.Ctx) error {
+	geo := models.GeoFromContext(c.Context())
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Geo context retrieved successfully",
+		"data":    h.geoService.ContextFor(geo),
+	})
+}
+
+// SetupGeoRoutes registers the geo context route. Requires the GeoContext middleware on the group.
+func SetupGeoRoutes(api fiber.Router, geoService *services.GeoIPService) {
+	handler := NewGeoHandler(geoService)
+	api.Get("/geo", handler.GetGeoContext)
+	log.Println("Geo routes (/geo) setup complete.")
+}
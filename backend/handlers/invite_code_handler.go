@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// InviteCodeHandler exposes the admin endpoints managing signup invite codes.
+type InviteCodeHandler struct {
+	inviteCodes *services.InviteCodeService
+}
+
+// NewInviteCodeHandler creates a new InviteCodeHandler instance.
+func NewInviteCodeHandler(inviteCodes *services.InviteCodeService) *InviteCodeHandler {
+	return &InviteCodeHandler{
+		inviteCodes: inviteCodes,
+	}
+}
+
+// MintInviteCodes handles POST /api/v1/admin/invite-codes
+func (h *InviteCodeHandler) MintInviteCodes(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.MintInviteCodesRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	codes, err := h.inviteCodes.Mint(c.Context(), adminID, req, c.IP())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Invite codes minted successfully", "data": codes})
+}
+
+// ListInviteCodes handles GET /api/v1/admin/invite-codes?cohort=lyon-beta
+func (h *InviteCodeHandler) ListInviteCodes(c *fiber.Ctx) error {
+	var params models.ListInviteCodesRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	codes, err := h.inviteCodes.List(c.Context(), params)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Invite codes retrieved successfully", "data": codes})
+}
+
+// RevokeInviteCode handles DELETE /api/v1/admin/invite-codes/:codeId
+func (h *InviteCodeHandler) RevokeInviteCode(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	codeID, err := uuid.Parse(c.Params("codeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid invite code ID format"})
+	}
+
+	if err := h.inviteCodes.Revoke(c.Context(), adminID, codeID, c.IP()); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Invite code revoked successfully"})
+}
+
+// SetupInviteCodeRoutes registers the admin routes minting and revoking signup invite codes.
+func SetupInviteCodeRoutes(api fiber.Router, inviteCodes *services.InviteCodeService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewInviteCodeHandler(inviteCodes)
+	api.Post("/admin/invite-codes", authMiddleware, adminMiddleware, handler.MintInviteCodes)
+	api.Get("/admin/invite-codes", authMiddleware, adminMiddleware, handler.ListInviteCodes)
+	api.Delete("/admin/invite-codes/:codeId", authMiddleware, adminMiddleware, handler.RevokeInviteCode)
+	log.Println("Invite code routes (/admin/invite-codes) setup complete.")
+}
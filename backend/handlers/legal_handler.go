@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// LegalHandler serves the terms of service and privacy policy and records their acceptance.
+type LegalHandler struct {
+	legalService *services.LegalService
+}
+
+// NewLegalHandler creates a new LegalHandler instance.
+func NewLegalHandler(legalService *services.LegalService) *LegalHandler {
+	return &LegalHandler{
+		legalService: legalService,
+	}
+}
+
+// GetDocuments handles GET /api/v1/legal/documents
+// Public. Returns the current version of each legal document.
+func (h *LegalHandler) GetDocuments(c *fiber.Ctx) error {
+	documents, err := h.legalService.CurrentDocuments(c.Context())
+	if err != nil {
+		log.Printf("Error fetching current legal documents: %v", err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Legal documents retrieved successfully",
+		"data":    documents,
+	})
+}
+
+// Accept handles POST /api/v1/legal/accept
+// Records the user's acceptance of current document versions, lifting the 451 once all mandatory ones are accepted.
+func (h *LegalHandler) Accept(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.AcceptLegalDocumentsRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	acceptances, err := h.legalService.Accept(c.Context(), userID, req, c.IP())
+	if err != nil {
+		log.Printf("Error recording legal acceptance for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Documents accepted successfully",
+		"data":    acceptances,
+	})
+}
+
+// SetupLegalRoutes registers the legal document routes.
+func SetupLegalRoutes(api fiber.Router, legalService *services.LegalService, authMiddleware fiber.Handler) {
+	handler := NewLegalHandler(legalService)
+	legalGroup := api.Group("/legal")
+	legalGroup.Get("/documents", handler.GetDocuments)
+	legalGroup.Post("/accept", authMiddleware, handler.Accept)
+	log.Println("Legal routes (/legal/documents, /legal/accept) setup complete.")
+}
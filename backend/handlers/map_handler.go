@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/services" // Local services
+)
+
+// MapHandler serves map images rendered server-side, so provider keys never reach clients.
+type MapHandler struct {
+	staticMapService *services.StaticMapService
+}
+
+// NewMapHandler creates a new MapHandler instance.
+func NewMapHandler(staticMapService *services.StaticMapService) *MapHandler {
+	return &MapHandler{
+		staticMapService: staticMapService,
+	}
+}
+
+// GetRideMap handles GET /api/v1/rides/:id/map.png
+// Public, like search results: returns a thumbnail of the ride's departure, arrival and route.
+func (h *MapHandler) GetRideMap(c *fiber.Ctx) error {
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	if !h.staticMapService.Enabled() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "error", "message": "Map images are not available"})
+	}
+
+	image, err := h.staticMapService.RideMapImage(c.Context(), rideID)
+	if err != nil {
+		log.Printf("Error rendering map for ride %s: %v", rideID, err)
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Status(fiber.StatusOK).Send(image)
+}
+
+// SetupMapRoutes registers the map image routes. Call it before SetupRideRoutes: routes under
+// /rides registered after the protected ride group would require authentication.
+func SetupMapRoutes(api fiber.Router, staticMapService *services.StaticMapService) {
+	handler := NewMapHandler(staticMapService)
+	api.Get("/rides/:id/map.png", handler.GetRideMap)
+	log.Println("Map routes (/rides/:id/map.png) setup complete.")
+}
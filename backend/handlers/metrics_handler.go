@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"bytes"         // For building the exposition
+	"crypto/subtle" // For constant-time token comparison
+	"log"           // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/services" // Local services
+)
+
+// MetricsHandler serves Prometheus metrics (request counters, latency, SLO burn rates, caches).
+type MetricsHandler struct {
+	sources []services.MetricsSource
+	token   string // Optional bearer token required to scrape
+}
+
+// NewMetricsHandler creates a new MetricsHandler instance.
+func NewMetricsHandler(token string, sources ...services.MetricsSource) *MetricsHandler {
+	return &MetricsHandler{
+		sources: sources,
+		token:   token,
+	}
+}
+
+// GetMetrics handles GET /metrics
+// Requires "Authorization: Bearer <METRICS_TOKEN>" when a token is configured.
+func (h *MetricsHandler) GetMetrics(c *fiber.Ctx) error {
+	if h.token != "" {
+		expected := "Bearer " + h.token
+		if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte(expected)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid metrics token"})
+		}
+	}
+
+	var body bytes.Buffer
+	for _, source := range h.sources {
+		source.WritePrometheus(&body)
+	}
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Status(fiber.StatusOK).Send(body.Bytes())
+}
+
+// SetupMetricsRoutes registers the Prometheus scrape endpoint at the app root (outside /api/v1).
+func SetupMetricsRoutes(app fiber.Router, token string, sources ...services.MetricsSource) {
+	handler := NewMetricsHandler(token, sources...)
+	app.Get("/metrics", handler.GetMetrics)
+	if token == "" {
+		log.Println("Warning: METRICS_TOKEN not set, /metrics is unauthenticated (restrict it at the network level)")
+	}
+	log.Println("Metrics route (/metrics) setup complete.")
+}
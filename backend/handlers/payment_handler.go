@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"   // For service error checks
 	"fmt"      // Import fmt
 	"log"      // For error checking
 	"net/http" // For status codes and request object
@@ -8,7 +9,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
-	"rideshare/backend/services" // Local services
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/services"   // Local services
 )
 
 // PaymentHandler handles HTTP requests related to payments.
@@ -27,19 +29,9 @@ func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler
 // Requires authentication.
 func (h *PaymentHandler) CreatePaymentIntent(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (CreatePaymentIntent)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing user identification."})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Invalid user identification format."})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Get ride ID from URL parameter
@@ -60,27 +52,7 @@ func (h *PaymentHandler) CreatePaymentIntent(c *fiber.Ctx) error {
 	response, err := h.paymentService.CreatePaymentIntent(c.Context(), rideID, userID)
 	if err != nil {
 		log.Printf("Error creating payment intent for user %s, ride %s: %v", userID, rideID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to create payment intent"
-		// Handle specific errors from service
-		errMsg := err.Error()
-		// Use strings.Contains or errors.Is if error wrapping is used in service
-		if errMsg == "user has not joined this ride or participation record not found" || errMsg == "cannot create payment for participation with status" {
-			statusCode = http.StatusConflict // 409 Conflict
-			errorMessage = errMsg
-		} else if errMsg == "database error fetching participation record" {
-			// Keep internal error
-		} else if errMsg == "failed to create payment intent with Stripe" {
-			// Keep internal error
-		} else if errMsg == "failed to save transaction record" {
-			// Keep internal error
-		}
-
-		return c.Status(statusCode).JSON(fiber.Map{
-			"status":  "error",
-			"message": errorMessage,
-			// "details": err.Error(), // Optional
-		})
+		return err
 	}
 
 	// 5. Return successful response with client secret
@@ -96,19 +68,9 @@ func (h *PaymentHandler) CreatePaymentIntent(c *fiber.Ctx) error {
 // Requires authentication.
 func (h *PaymentHandler) CreateSetupIntent(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (CreateSetupIntent)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing user identification."})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Invalid user identification format."})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Received create setup intent request from user %s", userID)
@@ -117,16 +79,7 @@ func (h *PaymentHandler) CreateSetupIntent(c *fiber.Ctx) error {
 	response, err := h.paymentService.CreateSetupIntent(c.Context(), userID)
 	if err != nil {
 		log.Printf("Error creating setup intent for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to create setup intent"
-		// Use errors.Is for specific error checking if the service returns wrapped errors, e.g.:
-		// if errors.Is(err, services.ErrUserNotFound) { ... }
-		if err.Error() == "user not found" { // Simple string comparison used here
-			statusCode = http.StatusNotFound
-			errorMessage = err.Error()
-		}
-		// Add more specific error handling if needed
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	// 3. Return successful response with client secret and customer ID
@@ -142,19 +95,9 @@ func (h *PaymentHandler) CreateSetupIntent(c *fiber.Ctx) error {
 // Requires authentication and saved payment method.
 func (h *PaymentHandler) JoinRideAutomatically(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (JoinRideAutomatically)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing user identification."})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Invalid user identification format."})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Get ride ID from URL parameter
@@ -168,36 +111,19 @@ func (h *PaymentHandler) JoinRideAutomatically(c *fiber.Ctx) error {
 	log.Printf("Received automatic join request from user %s for ride %s", userID, rideID)
 
 	// 3. Call service to handle automatic join and payment
-	err = h.paymentService.JoinRideAutomatically(c.Context(), rideID, userID)
+	// ?seats=n books several seats; ?ignore_conflicts=true confirms the join despite another ride around the same time
+	err = h.paymentService.JoinRideAutomatically(c.Context(), rideID, userID, c.QueryInt("seats", 1), c.QueryBool("ignore_conflicts"))
+	if errors.Is(err, services.ErrBookingOnHold) {
+		// Not a failure: the seat request is recorded but won't be charged until reviewed
+		log.Printf("Automatic join for user %s, ride %s is on hold pending review", userID, rideID)
+		return c.Status(http.StatusAccepted).JSON(fiber.Map{
+			"status":  "pending",
+			"message": "Your booking is on hold pending review. You will be able to pay once it is approved.",
+		})
+	}
 	if err != nil {
 		log.Printf("Error during automatic join for user %s, ride %s: %v", userID, rideID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to join ride automatically"
-
-		// Handle specific errors from the service
-		errMsg := err.Error()
-		// TODO: Use errors.Is or specific error types if defined in service
-		switch errMsg {
-		case "user not found":
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		case "ride is full", "already joined", "cannot join your own ride": // Add other validation errors from service
-			statusCode = http.StatusConflict // 409 Conflict for business logic errors
-			errorMessage = errMsg
-		case "user has no saved payment method setup":
-			statusCode = http.StatusPaymentRequired // 402 Payment Required might be suitable
-			errorMessage = errMsg
-		case "payment failed": // Catch generic payment failure from service
-			statusCode = http.StatusConflict // Or 400 Bad Request? Conflict seems okay.
-			errorMessage = "Payment using saved method failed. Please update your payment details."
-		case "critical error: payment processed but failed to update records":
-			// This is a serious internal error, return 500 but log it critically
-			errorMessage = "An internal error occurred while finalizing your participation."
-		default:
-			// Keep 500 for other unexpected errors
-		}
-
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": errorMessage})
+		return err
 	}
 
 	// 4. Return successful response
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/models"   // Local models
+	"rideshare/backend/services" // Local services
+)
+
+// PlacesHandler exposes curated places such as suggested pickup points.
+type PlacesHandler struct {
+	pickupPointService *services.PickupPointService
+}
+
+// NewPlacesHandler creates a new PlacesHandler instance.
+func NewPlacesHandler(pickupPointService *services.PickupPointService) *PlacesHandler {
+	return &PlacesHandler{
+		pickupPointService: pickupPointService,
+	}
+}
+
+// ListPickupPoints handles GET /api/v1/places/pickup-points?near=lat,lon
+// Suggests park-and-rides, stations and landmarks near a departure, closest first.
+func (h *PlacesHandler) ListPickupPoints(c *fiber.Ctx) error {
+	var params models.NearbyPickupPointsRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	points, err := h.pickupPointService.NearbyPickupPoints(c.Context(), params)
+	if err != nil {
+		log.Printf("Error listing pickup points near %s: %v", params.Near, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pickup points retrieved successfully", "data": points})
+}
+
+// CreatePickupPoint handles POST /api/v1/admin/pickup-points
+// Adds a curated pickup point. Admin only.
+func (h *PlacesHandler) CreatePickupPoint(c *fiber.Ctx) error {
+	var req models.CreatePickupPointRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	point, err := h.pickupPointService.CreatePickupPoint(c.Context(), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Pickup point created successfully", "data": point})
+}
+
+// SetupPlacesRoutes registers the places routes and the admin route to curate pickup points.
+func SetupPlacesRoutes(api fiber.Router, pickupPointService *services.PickupPointService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewPlacesHandler(pickupPointService)
+	api.Get("/places/pickup-points", authMiddleware, handler.ListPickupPoints)
+	api.Post("/admin/pickup-points", authMiddleware, adminMiddleware, handler.CreatePickupPoint)
+	log.Println("Places routes (/places/pickup-points) setup complete.")
+}
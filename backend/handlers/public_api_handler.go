@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Local middleware
+	"rideshare/backend/models"     // Local models
+	"rideshare/backend/services"   // Local services
+)
+
+// PublicAPIHandler serves the key-authenticated public data API and its key management.
+type PublicAPIHandler struct {
+	publicAPIService *services.PublicAPIService
+}
+
+// NewPublicAPIHandler creates a new PublicAPIHandler instance.
+func NewPublicAPIHandler(publicAPIService *services.PublicAPIService) *PublicAPIHandler {
+	return &PublicAPIHandler{
+		publicAPIService: publicAPIService,
+	}
+}
+
+// ListPublicRides handles GET /api/v1/public/rides?city=
+// Requires an API key with the rides:read scope. Returns anonymized rides only.
+func (h *PublicAPIHandler) ListPublicRides(c *fiber.Ctx) error {
+	var params models.PublicRidesRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	rides, err := h.publicAPIService.PublicRides(c.Context(), params)
+	if err != nil {
+		log.Printf("Error listing public rides for city %q: %v", params.City, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Rides retrieved successfully", "data": rides})
+}
+
+// CreateAPIKey handles POST /api/v1/admin/api-keys
+// The key is only returned in this response.
+func (h *PublicAPIHandler) CreateAPIKey(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.CreateAPIKeyRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	key, err := h.publicAPIService.CreateAPIKey(c.Context(), adminID, req, c.IP())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "API key created; store it now, it will not be shown again", "data": key})
+}
+
+// ListAPIKeys handles GET /api/v1/admin/api-keys
+func (h *PublicAPIHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.publicAPIService.ListAPIKeys(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "API keys retrieved successfully", "data": keys})
+}
+
+// RevokeAPIKey handles DELETE /api/v1/admin/api-keys/:keyId
+func (h *PublicAPIHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	adminID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	keyID, err := uuid.Parse(c.Params("keyId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid API key ID format"})
+	}
+
+	if err := h.publicAPIService.RevokeAPIKey(c.Context(), adminID, keyID, c.IP()); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "API key revoked successfully"})
+}
+
+// SetupPublicAPIRoutes registers the public data API and the admin routes managing its keys.
+func SetupPublicAPIRoutes(api fiber.Router, publicAPIService *services.PublicAPIService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewPublicAPIHandler(publicAPIService)
+
+	publicGroup := api.Group("/public")
+	publicGroup.Get("/rides", middleware.RequireAPIKey(publicAPIService, models.APIScopeRidesRead), handler.ListPublicRides)
+
+	api.Post("/admin/api-keys", authMiddleware, adminMiddleware, handler.CreateAPIKey)
+	api.Get("/admin/api-keys", authMiddleware, adminMiddleware, handler.ListAPIKeys)
+	api.Delete("/admin/api-keys/:keyId", authMiddleware, adminMiddleware, handler.RevokeAPIKey)
+	log.Println("Public API routes (/public) setup complete.")
+}
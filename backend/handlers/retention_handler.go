@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/services" // Local services
+)
+
+// RetentionHandler exposes data retention reports to admins.
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+}
+
+// NewRetentionHandler creates a new RetentionHandler instance.
+func NewRetentionHandler(retentionService *services.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+// GetLastReport handles GET /api/v1/admin/retention
+// Returns the report of the last scheduled retention run.
+func (h *RetentionHandler) GetLastReport(c *fiber.Ctx) error {
+	report := h.retentionService.LastReport()
+	if report == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "No retention run yet"})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Retention report retrieved successfully", "data": report})
+}
+
+// Run handles POST /api/v1/admin/retention/run?dry_run=true
+// Runs the retention rules now. Defaults to a dry run; dry_run=false purges data.
+func (h *RetentionHandler) Run(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dry_run", true)
+	report := h.retentionService.Run(c.Context(), dryRun)
+	log.Printf("Retention run triggered by admin (dry run: %t)", dryRun)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Retention rules applied", "data": report})
+}
+
+// SetupRetentionRoutes registers the admin retention routes.
+func SetupRetentionRoutes(api fiber.Router, retentionService *services.RetentionService, authMiddleware fiber.Handler, adminMiddleware fiber.Handler) {
+	handler := NewRetentionHandler(retentionService)
+	api.Get("/admin/retention", authMiddleware, adminMiddleware, handler.GetLastReport)
+	api.Post("/admin/retention/run", authMiddleware, adminMiddleware, handler.Run)
+	log.Println("Retention routes (/admin/retention) setup complete.")
+}
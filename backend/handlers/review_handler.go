@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"      // For logging
+	"net/http" // For HTTP status codes
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware"
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// ReviewHandler handles ride ratings and reviews.
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+// NewReviewHandler creates a new ReviewHandler instance.
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// CreateReview handles POST /api/v1/rides/{id}/reviews
+// Once the ride is completed, passengers rate the driver and the driver rates each passenger.
+func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	var req models.CreateReviewRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	review, err := h.reviewService.CreateReview(c.Context(), rideID, userID, req)
+	if err != nil {
+		log.Printf("Error reviewing ride %s by user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Review saved", "data": review})
+}
+
+// ListUserReviews handles GET /api/v1/users/{id}/reviews
+// Returns the user's ratings as driver and as passenger, with their most recent reviews.
+func (h *ReviewHandler) ListUserReviews(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	reviews, err := h.reviewService.ListUserReviews(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Reviews retrieved successfully", "data": reviews})
+}
+
+// SetupReviewRoutes registers the ride review routes.
+func SetupReviewRoutes(api fiber.Router, reviewService *services.ReviewService, authMiddleware fiber.Handler) {
+	handler := NewReviewHandler(reviewService)
+	api.Post("/rides/:id/reviews", authMiddleware, handler.CreateReview)
+	api.Get("/users/:id/reviews", authMiddleware, handler.ListUserReviews)
+	log.Println("Review routes (/rides/:id/reviews, /users/:id/reviews) setup complete.")
+}
@@ -1,29 +1,35 @@
 package handlers
 
 import (
-	"fmt" // Import fmt for error formatting
 	"log"
 	"net/http" // For status codes
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"rideshare/backend/middleware" // Authenticated user accessor
 	"rideshare/backend/models"
 	"rideshare/backend/services"
-	// Import middleware package once created, e.g., "rideshare/backend/middleware"
 )
 
 // RideHandler handles HTTP requests related to rides.
 type RideHandler struct {
-	rideService *services.RideService
+	rideService       *services.RideService
+	paymentService    *services.PaymentService          // Needed for refunds when leaving a ride
+	anonymousSessions *services.AnonymousSessionService // Recent searches of anonymous visitors
+	analytics         *services.AnalyticsService        // Product analytics events (nil-safe)
+	rideViews         *services.RideViewCounter         // Distinct viewers of ride details, shown to creators
 	// authService *services.AuthService // Might be needed if we fetch creator details here
 }
 
 // NewRideHandler creates a new RideHandler instance.
-func NewRideHandler(rideService *services.RideService) *RideHandler {
+func NewRideHandler(rideService *services.RideService, paymentService *services.PaymentService, anonymousSessions *services.AnonymousSessionService, analytics *services.AnalyticsService, rideViews *services.RideViewCounter) *RideHandler {
 	return &RideHandler{
-		rideService: rideService,
+		rideService:       rideService,
+		paymentService:    paymentService,
+		anonymousSessions: anonymousSessions,
+		analytics:         analytics,
+		rideViews:         rideViews,
 	}
 }
 
@@ -31,37 +37,15 @@ func NewRideHandler(rideService *services.RideService) *RideHandler {
 // Requires authentication.
 func (h *RideHandler) CreateRide(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context (set by auth middleware)
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		// Attempt to retrieve as string and parse, as some middleware might store it as string
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context or invalid type in CreateRide")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Unauthorized: Missing user identification.",
-			})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Unauthorized: Invalid user identification format.",
-			})
-		}
-		userID = parsedID // Assign the parsed UUID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Parse request body
 	var req models.CreateRideRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.Printf("Error parsing create ride request body for user %s: %v", userID, err)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request body",
-			"details": err.Error(),
-		})
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
 	}
 
 	// Log request
@@ -71,27 +55,7 @@ func (h *RideHandler) CreateRide(c *fiber.Ctx) error {
 	ride, err := h.rideService.CreateRide(c.Context(), req, userID)
 	if err != nil {
 		log.Printf("Error creating ride for user %s: %v", userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to create ride due to an internal error"
-
-		// Handle specific errors from service
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			statusCode = http.StatusBadRequest
-			errorMessage = fmt.Sprintf("Invalid ride data: %v", validationErrors)
-		} else if err.Error() == "departure date and time must be in the future" {
-			statusCode = http.StatusBadRequest
-			errorMessage = err.Error()
-		} else if err.Error() == "invalid departure date format (use YYYY-MM-DD)" || err.Error() == "invalid departure date or time format" || err.Error() == "departure or arrival coordinates are missing" {
-			// Added check for missing coordinates error from service
-			statusCode = http.StatusBadRequest
-			errorMessage = err.Error()
-		}
-
-		return c.Status(statusCode).JSON(fiber.Map{
-			"status":  "error",
-			"message": errorMessage,
-			// "details": err.Error(), // Optional
-		})
+		return err
 	}
 
 	// 4. Return successful response
@@ -103,20 +67,71 @@ func (h *RideHandler) CreateRide(c *fiber.Ctx) error {
 	})
 }
 
-// ListAvailableRides handles GET /api/v1/rides
+// DuplicateRide handles POST /api/v1/rides/{id}/duplicate
+// Creates a copy of one of the user's rides departing at a new date and time.
+func (h *RideHandler) DuplicateRide(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	var req models.DuplicateRideRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	ride, err := h.rideService.DuplicateRide(c.Context(), rideID, userID, req)
+	if err != nil {
+		log.Printf("Error duplicating ride %s for user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride created successfully",
+		"data":    ride,
+	})
+}
+
+// ListNearbyRides handles GET /api/v1/rides/nearby
+// Requires authentication. Uses ?lat=&lon= when given, otherwise the user's last known location.
+func (h *RideHandler) ListNearbyRides(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var params models.NearbyRidesRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	rides, err := h.rideService.NearbyRides(c.Context(), userID, params)
+	if err != nil {
+		log.Printf("Error listing nearby rides for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Nearby rides retrieved successfully",
+		"data":    rides,
+	})
+}
+
+// ListAvailableRides handles GET /api/v1/rides?page=&limit=
 // Publicly accessible (no auth required).
 func (h *RideHandler) ListAvailableRides(c *fiber.Ctx) error {
 	log.Println("Received request to list available rides")
-	// TODO: Add query param handling for filtering/pagination
+	var params models.RideListRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
 
-	rides, err := h.rideService.ListAvailableRides(c.Context())
+	rides, err := h.rideService.ListAvailableRides(c.Context(), params)
 	if err != nil {
 		log.Printf("Error listing available rides: %v", err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to retrieve available rides",
-			// "details": err.Error(), // Optional
-		})
+		return err
 	}
 
 	log.Printf("Returning %d available rides", len(rides))
@@ -141,8 +156,10 @@ func (h *RideHandler) GetRideDetails(c *fiber.Ctx) error {
 		})
 	}
 
-	// Optional: Get user ID from context if needed for authorization checks later
-	// userID, _ := c.Locals("userID").(uuid.UUID)
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
 
 	log.Printf("Received request for ride details: ID %s", rideID)
 
@@ -150,21 +167,27 @@ func (h *RideHandler) GetRideDetails(c *fiber.Ctx) error {
 	ride, err := h.rideService.GetRideDetails(c.Context(), rideID)
 	if err != nil {
 		log.Printf("Error getting ride details for ID %s: %v", rideID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to retrieve ride details"
-		if err.Error() == "ride not found" {
-			statusCode = http.StatusNotFound
-			errorMessage = err.Error()
+		return err
+	}
+
+	// 3. Count the view, or show the count to the creator
+	if ride.UserID == userID {
+		views, err := h.rideViews.Count(c.Context(), rideID)
+		if err != nil {
+			return err
 		}
-		return c.Status(statusCode).JSON(fiber.Map{
-			"status":  "error",
-			"message": errorMessage,
-			// "details": err.Error(), // Optional
-		})
+		ride.ViewCount = &views
+	} else {
+		h.rideViews.Record(rideID, userID)
 	}
 
-	// 3. Return successful response
+	// 4. Return successful response
 	log.Printf("Returning details for ride ID %s", rideID)
+	h.analytics.Track(services.AnalyticsEvent{
+		Name:       services.AnalyticsRideViewed,
+		UserID:     userID,
+		Properties: map[string]interface{}{"ride_id": rideID.String(), "own_ride": ride.UserID == userID},
+	})
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"status":  "success",
 		"message": "Ride details retrieved successfully",
@@ -176,26 +199,9 @@ func (h *RideHandler) GetRideDetails(c *fiber.Ctx) error {
 // Requires authentication.
 func (h *RideHandler) JoinRide(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		// Attempt to retrieve as string and parse, as some middleware might store it as string
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context or invalid type in JoinRide")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Unauthorized: Missing user identification.",
-			})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Unauthorized: Invalid user identification format.",
-			})
-		}
-		userID = parsedID // Assign the parsed UUID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Get ride ID from URL parameter
@@ -209,72 +215,64 @@ func (h *RideHandler) JoinRide(c *fiber.Ctx) error {
 		})
 	}
 
+	// The body is optional: on waypoint rides it picks the boarding and alighting stops
+	var req models.JoinRideRequest
+	if len(c.Body()) > 0 {
+		if handled, respErr := bindBody(c, &req); handled {
+			return respErr
+		}
+	}
+
 	log.Printf("Received request from user %s to join ride %s", userID, rideID)
 
 	// 3. Call service to handle joining the ride
-	participant, err := h.rideService.JoinRide(c.Context(), rideID, userID)
+	participant, err := h.rideService.JoinRide(c.Context(), rideID, userID, req)
 	if err != nil {
 		log.Printf("Error joining ride %s for user %s: %v", rideID, userID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to join ride due to an internal error"
-
-		// Handle specific errors from service
-		errMsg := err.Error()
-		switch errMsg {
-		case "ride not found":
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		case "ride is not open for joining", "ride is already full", "you cannot join your own ride", "you have already joined this ride":
-			statusCode = http.StatusConflict // 409 Conflict for business rule violations
-			errorMessage = errMsg
-		case "database does not support transactions required for JoinRide":
-			// This indicates a setup issue, likely internal error
-			errorMessage = "Cannot process join request at this time."
-		default:
-			// Keep internal server error for other db errors
-		}
-
-		return c.Status(statusCode).JSON(fiber.Map{
-			"status":  "error",
-			"message": errorMessage,
-			// "details": err.Error(), // Optional
-		})
+		return err
 	}
 
 	// 4. Return successful response (participant details)
 	log.Printf("User %s joined ride %s successfully (Participant ID: %s)", userID, rideID, participant.ID)
-	// Create the specific response structure
+	h.analytics.Track(services.AnalyticsEvent{
+		Name:       services.AnalyticsJoinStarted,
+		UserID:     userID,
+		Properties: map[string]interface{}{"ride_id": rideID.String(), "seat_count": participant.SeatCount, "status": participant.Status},
+	})
+	response := joinRideResponse(participant)
+	return c.Status(http.StatusOK).JSON(fiber.Map{ // 200 OK might be better than 201 Created here
+		"status":  "success",
+		"message": response.Message,
+		"data":    response,
+	})
+}
+
+// joinRideResponse describes a new booking, with the next step for the joiner.
+func joinRideResponse(participant *models.Participant) models.JoinRideResponse {
 	response := models.JoinRideResponse{
 		ParticipationID: participant.ID,
 		RideID:          participant.RideID,
 		UserID:          participant.UserID,
 		Status:          participant.Status,
+		BoardingStop:    participant.BoardingStop,
+		AlightingStop:   participant.AlightingStop,
+		SeatCount:       participant.SeatCount,
 		Message:         "Successfully joined ride. Proceed to payment.", // Or similar message
+		SeatHeldUntil:   participant.SeatHeldUntil,
 	}
-	return c.Status(http.StatusOK).JSON(fiber.Map{ // 200 OK might be better than 201 Created here
-		"status":  "success",
-		"message": response.Message,
-		"data":    response,
-	})
+	if participant.Status == string(models.ParticipantStatusOnHold) {
+		response.Message = "Your booking is on hold pending review. You will be able to pay once it is approved."
+	}
+	return response
 }
 
 // GetRideContacts handles GET /api/v1/rides/{id}/contacts
 // Requires authentication, user must be a confirmed participant or creator.
 func (h *RideHandler) GetRideContacts(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID from context
-	requestingUserID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (GetRideContacts)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing user identification."})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Invalid user identification format."})
-		}
-		requestingUserID = parsedID
+	requestingUserID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Get ride ID from URL parameter
@@ -291,25 +289,7 @@ func (h *RideHandler) GetRideContacts(c *fiber.Ctx) error {
 	contacts, err := h.rideService.GetRideContacts(c.Context(), rideID, requestingUserID)
 	if err != nil {
 		log.Printf("Error getting contacts for ride %s, requested by user %s: %v", rideID, requestingUserID, err)
-		statusCode := http.StatusInternalServerError
-		errorMessage := "Failed to retrieve ride contacts"
-
-		errMsg := err.Error()
-		switch errMsg {
-		case "ride not found":
-			statusCode = http.StatusNotFound
-			errorMessage = errMsg
-		case "unauthorized to view contacts for this ride":
-			statusCode = http.StatusForbidden // 403 Forbidden is more appropriate than 401 here
-			errorMessage = errMsg
-		default:
-			// Keep internal server error for other db errors
-		}
-
-		return c.Status(statusCode).JSON(fiber.Map{
-			"status":  "error",
-			"message": errorMessage,
-		})
+		return err
 	}
 
 	// 4. Return successful response
@@ -321,18 +301,62 @@ func (h *RideHandler) GetRideContacts(c *fiber.Ctx) error {
 	})
 }
 
+// GetRideRoster handles GET /api/v1/rides/{id}/participants
+// Requires authentication, only the ride creator can see the roster.
+func (h *RideHandler) GetRideRoster(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+
+	roster, err := h.rideService.GetRideRoster(c.Context(), rideID, userID)
+	if err != nil {
+		log.Printf("Error getting roster for ride %s, requested by user %s: %v", rideID, userID, err)
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride participants retrieved successfully",
+		"data":    roster,
+	})
+}
+
+// ListUpcomingRideContacts handles GET /api/v1/users/me/rides/upcoming/contacts?days=
+// Returns the contacts of all the user's upcoming rides at once, grouped by ride.
+func (h *RideHandler) ListUpcomingRideContacts(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var params models.UpcomingContactsRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	rides, err := h.rideService.UpcomingRideContacts(c.Context(), userID, params)
+	if err != nil {
+		log.Printf("Error getting upcoming ride contacts for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Upcoming ride contacts retrieved successfully",
+		"data":    rides,
+	})
+}
+
 // SearchRides handles GET /api/v1/rides/search
-// Publicly accessible. Parses query parameters.
+// Publicly accessible; signed-in callers' searches are kept as recent searches. Parses query parameters.
 func (h *RideHandler) SearchRides(c *fiber.Ctx) error {
 	// Parse query parameters into SearchRidesRequest struct
 	var params models.SearchRidesRequest
-	if err := c.QueryParser(&params); err != nil {
-		log.Printf("Error parsing search query parameters: %v", err)
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid search query parameters",
-			"details": err.Error(),
-		})
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
 	}
 
 	// Optional: Validate parsed parameters if needed (e.g., date format)
@@ -344,13 +368,28 @@ func (h *RideHandler) SearchRides(c *fiber.Ctx) error {
 	rides, err := h.rideService.SearchRides(c.Context(), params)
 	if err != nil {
 		log.Printf("Error searching rides with params %+v: %v", params, err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to search for rides",
-		})
+		return err
 	}
 
 	log.Printf("Returning %d rides for search params %+v", len(rides), params)
+	userID, signedIn := middleware.OptionalUserID(c)
+	sessionID, anonymous := middleware.CurrentAnonymousSessionID(c)
+	if signedIn {
+		h.anonymousSessions.RecordUserSearch(c.Context(), userID, string(c.Request().URI().QueryString()))
+	} else if anonymous {
+		h.anonymousSessions.RecordSearch(c.Context(), sessionID, string(c.Request().URI().QueryString()))
+	}
+	properties := map[string]interface{}{
+		"near_departure": params.DepartureLat != nil,
+		"near_arrival":   params.ArrivalLat != nil,
+		"results":        len(rides),
+	}
+	for key, value := range map[string]*string{"departure": params.StartLocation, "arrival": params.EndLocation, "date": params.DepartureDate} {
+		if value != nil {
+			properties[key] = *value
+		}
+	}
+	h.analytics.Track(services.AnalyticsEvent{Name: services.AnalyticsSearchPerformed, UserID: userID, AnonymousSessionID: sessionID, Properties: properties})
 	return c.Status(http.StatusOK).JSON(fiber.Map{
 		"status":  "success",
 		"message": "Rides search successful",
@@ -358,141 +397,213 @@ func (h *RideHandler) SearchRides(c *fiber.Ctx) error {
 	})
 }
 
-// ListUserCreatedRides handles GET /api/v1/users/me/rides/created
+// GetRideMap handles GET /api/v1/rides/map?bbox=&zoom=
+// Public, like search: returns clustered departure markers of active rides in the visible area.
+func (h *RideHandler) GetRideMap(c *fiber.Ctx) error {
+	var params models.RideMapRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	clusters, err := h.rideService.ClusterRides(c.Context(), params)
+	if err != nil {
+		log.Printf("Error clustering rides for bbox %s zoom %d: %v", params.BBox, params.Zoom, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride clusters retrieved successfully",
+		"data":    clusters,
+	})
+}
+
+// ListUserCreatedRides handles GET /api/v1/users/me/rides/created?page=&limit=
 // Requires authentication.
 func (h *RideHandler) ListUserCreatedRides(c *fiber.Ctx) error {
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok { /* ... handle missing/invalid userID ... */
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid ID"})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Received request for rides created by user %s", userID)
-	rides, err := h.rideService.ListUserCreatedRides(c.Context(), userID)
-	if err != nil { /* ... handle error ... */
+	var params models.RideListRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+	rides, err := h.rideService.ListUserCreatedRides(c.Context(), userID, params)
+	if err != nil {
 		log.Printf("Error fetching created rides for user %s: %v", userID, err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve created rides"})
+		return err
 	}
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "data": rides})
 }
 
-// ListUserJoinedRides handles GET /api/v1/users/me/rides/joined
+// GetDriverDashboard handles GET /api/v1/users/me/driver-dashboard
+// Returns the user's upcoming rides with fill rates, pending requests, unread notifications and
+// expected earnings, so the app's driver screen needs a single call.
+func (h *RideHandler) GetDriverDashboard(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	dashboard, err := h.rideService.DriverDashboard(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error building driver dashboard for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "data": dashboard})
+}
+
+// ListUserJoinedRides handles GET /api/v1/users/me/rides/joined?page=&limit=
 // Requires authentication.
 func (h *RideHandler) ListUserJoinedRides(c *fiber.Ctx) error {
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok { /* ... handle missing/invalid userID ... */
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid ID"})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Received request for rides joined by user %s", userID)
-	rides, err := h.rideService.ListUserJoinedRides(c.Context(), userID)
-	if err != nil { /* ... handle error ... */
+	var params models.RideListRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+	rides, err := h.rideService.ListUserJoinedRides(c.Context(), userID, params)
+	if err != nil {
 		log.Printf("Error fetching joined rides for user %s: %v", userID, err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve joined rides"})
+		return err
 	}
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "data": rides})
 }
 
-// ListUserHistoryRides handles GET /api/v1/users/me/rides/history
+// ListUserHistoryRides handles GET /api/v1/users/me/rides/history?page=&limit=
 // Requires authentication.
 func (h *RideHandler) ListUserHistoryRides(c *fiber.Ctx) error {
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok { /* ... handle missing/invalid userID ... */
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid ID"})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Received request for ride history for user %s", userID)
-	rides, err := h.rideService.ListUserHistoryRides(c.Context(), userID)
-	if err != nil { /* ... handle error ... */
+	var params models.RideListRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+	rides, err := h.rideService.ListUserHistoryRides(c.Context(), userID, params)
+	if err != nil {
 		log.Printf("Error fetching history rides for user %s: %v", userID, err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve ride history"})
+		return err
 	}
 	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "data": rides})
 }
 
-// DeleteRide handles DELETE /api/v1/rides/{id}
-// Requires authentication.
-func (h *RideHandler) DeleteRide(c *fiber.Ctx) error {
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok { /* ... handle missing/invalid userID ... */
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid ID"})
-		}
-		userID = parsedID
+// CancelRide handles POST /api/v1/rides/{id}/cancel (and DELETE /api/v1/rides/{id})
+// Requires authentication. Only the ride creator can cancel; the ride is kept as cancelled, and
+// its participants are refunded under the ride's policy and notified.
+func (h *RideHandler) CancelRide(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
-	rideIDParam := c.Params("id")
-	rideID, err := uuid.Parse(rideIDParam)
-	if err != nil { /* ... handle invalid ride ID ... */
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
 	}
 
-	log.Printf("Received delete request for ride %s from user %s", rideID, userID)
-	hadParticipants, err := h.rideService.DeleteRide(c.Context(), rideID, userID)
-	if err != nil { /* ... handle service error (not found, unauthorized, db error) ... */
-		statusCode := http.StatusInternalServerError
-		message := "Failed to delete ride"
-		errMsg := err.Error()
-		if errMsg == "ride not found" {
-			statusCode = http.StatusNotFound
-			message = errMsg
-		} else if errMsg == "unauthorized to delete this ride" {
-			statusCode = http.StatusForbidden
-			message = errMsg
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": message})
+	cancellation, err := h.paymentService.CancelOwnRide(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride cancelled successfully. Participants were refunded and notified.",
+		"data":    cancellation,
+	})
+}
+
+// CompleteRide handles POST /api/v1/rides/{id}/complete
+// Requires authentication. Only the ride creator can confirm a departed ride took place, which
+// marks its participants completed and opens reviews.
+func (h *RideHandler) CompleteRide(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
 	}
 
-	message := "Ride deleted successfully."
-	if hadParticipants {
-		message = "Ride cancelled successfully. Participants were notified (logic pending)." // Adjust message
+	completion, err := h.rideService.CompleteRide(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride completed. Participants can now leave a review.",
+		"data":    completion,
+	})
+}
+
+// GetShareLink handles GET /api/v1/rides/{id}/share-link
+// Requires authentication. Only the ride creator gets the public link of the ride.
+func (h *RideHandler) GetShareLink(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+
+	link, err := h.rideService.ShareLink(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Share link created successfully", "data": link})
+}
+
+// GetSharedRide handles GET /api/v1/public/rides/{token}
+// No authentication: returns the limited view of the ride a share link points to.
+func (h *RideHandler) GetSharedRide(c *fiber.Ctx) error {
+	ride, err := h.rideService.SharedRide(c.Context(), c.Params("token"))
+	if err != nil {
+		return err
 	}
-	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": message})
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Ride retrieved successfully", "data": ride})
+}
+
+// SetPickupPoint handles PUT /api/v1/rides/{id}/pickup-point
+// Requires authentication. Only the ride creator can attach (or clear) the official pickup point.
+func (h *RideHandler) SetPickupPoint(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	var req models.SetRidePickupPointRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	ride, err := h.rideService.SetPickupPoint(c.Context(), rideID, userID, req.PickupPointID)
+	if err != nil {
+		log.Printf("Error setting pickup point on ride %s for user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pickup point updated successfully", "data": ride})
 }
 
 // LeaveRide handles POST /api/v1/rides/{id}/leave
 // Requires authentication.
 func (h *RideHandler) LeaveRide(c *fiber.Ctx) error {
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (LeaveRide)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized"})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid ID"})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 	rideIDParam := c.Params("id")
 	rideID, err := uuid.Parse(rideIDParam)
@@ -502,39 +613,107 @@ func (h *RideHandler) LeaveRide(c *fiber.Ctx) error {
 	}
 
 	log.Printf("Received leave request for ride %s from user %s", rideID, userID)
-	err = h.rideService.LeaveRide(c.Context(), rideID, userID)
+	result, err := h.paymentService.LeaveRide(c.Context(), rideID, userID)
 	if err != nil {
 		log.Printf("Error leaving ride %s for user %s: %v", rideID, userID, err)
-		statusCode := http.StatusInternalServerError
-		message := "Failed to leave ride"
-		errMsg := err.Error()
-		if errMsg == "could not leave ride: participation not found or already left/cancelled" {
-			statusCode = http.StatusConflict
-			message = errMsg
-		}
-		return c.Status(statusCode).JSON(fiber.Map{"status": "error", "message": message})
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Successfully left the ride.", "data": result})
+}
+
+// RemoveParticipant handles DELETE /api/v1/rides/{id}/participants/{userId}
+// Requires authentication, only the ride creator can remove participants. A reason is mandatory.
+func (h *RideHandler) RemoveParticipant(c *fiber.Ctx) error {
+	creatorID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	participantUserID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	var req models.RemoveParticipantRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	log.Printf("Received request from creator %s to remove user %s from ride %s", creatorID, participantUserID, rideID)
+	result, err := h.paymentService.RemoveParticipant(c.Context(), rideID, creatorID, participantUserID, req.Reason)
+	if err != nil {
+		log.Printf("Error removing user %s from ride %s: %v", participantUserID, rideID, err)
+		return err
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Successfully left the ride."})
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Participant removed from the ride.", "data": result})
+}
+
+// SetPickupNote handles PUT /api/v1/rides/{id}/pickup-note
+// Requires authentication. A participant tells the driver where and how to find them.
+func (h *RideHandler) SetPickupNote(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+
+	var req models.UpdatePickupNoteRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	result, err := h.rideService.SetPickupNote(c.Context(), rideID, userID, req)
+	if err != nil {
+		log.Printf("Error setting pickup note of user %s on ride %s: %v", userID, rideID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pickup note updated successfully", "data": result})
+}
+
+// AssignPickup handles PUT /api/v1/rides/{id}/participants/{userId}/pickup
+// Requires authentication. Only the ride creator can set a participant's pickup order and seat.
+func (h *RideHandler) AssignPickup(c *fiber.Ctx) error {
+	creatorID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	participantUserID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+
+	var req models.AssignPickupRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	result, err := h.rideService.AssignPickup(c.Context(), rideID, creatorID, participantUserID, req)
+	if err != nil {
+		log.Printf("Error assigning pickup of user %s on ride %s: %v", participantUserID, rideID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pickup assigned successfully", "data": result})
 }
 
 // GetMyParticipationStatus handles GET /api/v1/rides/{id}/my-status
 // Requires authentication.
 func (h *RideHandler) GetMyParticipationStatus(c *fiber.Ctx) error {
 	// 1. Get authenticated user ID
-	userID, ok := c.Locals("userID").(uuid.UUID)
-	if !ok {
-		userIDStr, okStr := c.Locals("userID").(string)
-		if !okStr {
-			log.Println("Error: User ID not found in context (GetMyParticipationStatus)")
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing user identification."})
-		}
-		parsedID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("Error: Invalid User ID format in context: %s", userIDStr)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Invalid user identification format."})
-		}
-		userID = parsedID
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// 2. Get ride ID from URL parameter
@@ -551,8 +730,7 @@ func (h *RideHandler) GetMyParticipationStatus(c *fiber.Ctx) error {
 	status, err := h.rideService.GetUserParticipationStatus(c.Context(), rideID, userID)
 	if err != nil {
 		log.Printf("Error fetching participation status for user %s, ride %s: %v", userID, rideID, err)
-		// Don't expose internal DB errors directly
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to retrieve participation status"})
+		return err
 	}
 
 	// 4. Return status
@@ -562,12 +740,17 @@ func (h *RideHandler) GetMyParticipationStatus(c *fiber.Ctx) error {
 
 // SetupRideRoutes registers the ride-related routes with the Fiber app group.
 // It requires the auth middleware for protected routes.
-func SetupRideRoutes(api fiber.Router, rideService *services.RideService, authMiddleware fiber.Handler) {
-	handler := NewRideHandler(rideService)
+func SetupRideRoutes(api fiber.Router, rideService *services.RideService, paymentService *services.PaymentService, anonymousSessions *services.AnonymousSessionService, analytics *services.AnalyticsService, rideViews *services.RideViewCounter, authMiddleware fiber.Handler) {
+	handler := NewRideHandler(rideService, paymentService, anonymousSessions, analytics, rideViews)
 
 	// Public routes
-	api.Get("/rides/search", handler.SearchRides) // New search endpoint
-	api.Get("/rides", handler.ListAvailableRides) // Keep old endpoint for all available? Or remove? Let's keep for now.
+	api.Get("/rides/search", middleware.OptionalAuth(authMiddleware), handler.SearchRides) // New search endpoint; auth optional (recent searches)
+	api.Get("/rides/map", handler.GetRideMap)                                              // Clustered markers for the map view
+	api.Get("/rides", handler.ListAvailableRides)                                          // Keep old endpoint for all available? Or remove? Let's keep for now.
+	api.Get("/public/rides/:token", handler.GetSharedRide)                                 // Ride behind a share link, without an account
+
+	// Registered before the group so "nearby" isn't taken for a ride ID
+	api.Get("/rides/nearby", authMiddleware, handler.ListNearbyRides) // Today's and tomorrow's departures around the user
 
 	// Protected routes
 	rideGroup := api.Group("/rides", authMiddleware) // Apply middleware to group for protected routes
@@ -575,14 +758,25 @@ func SetupRideRoutes(api fiber.Router, rideService *services.RideService, authMi
 	rideGroup.Get("/:id", handler.GetRideDetails)
 	rideGroup.Post("/:id/join", handler.JoinRide)
 	rideGroup.Get("/:id/contacts", handler.GetRideContacts)
-	rideGroup.Delete("/:id", handler.DeleteRide)    // New delete route
-	rideGroup.Post("/:id/leave", handler.LeaveRide) // New leave route
+	rideGroup.Get("/:id/participants", handler.GetRideRoster) // Creator-only roster with statuses
+	rideGroup.Delete("/:id/participants/:userId", handler.RemoveParticipant)
+	rideGroup.Put("/:id/participants/:userId/pickup", handler.AssignPickup) // Creator-only pickup order and seat
+	rideGroup.Put("/:id/pickup-note", handler.SetPickupNote)                // Participant's note to the driver
+	rideGroup.Post("/:id/cancel", handler.CancelRide)                       // Creator-only; participants are refunded and notified
+	rideGroup.Delete("/:id", handler.CancelRide)                            // Older app versions delete to cancel
+	rideGroup.Post("/:id/complete", handler.CompleteRide)                   // Creator-only, once departed; opens reviews
+	rideGroup.Get("/:id/share-link", handler.GetShareLink)                  // Creator-only public link, see /public/rides/:token
+	rideGroup.Post("/:id/leave", handler.LeaveRide)                         // New leave route
+	rideGroup.Put("/:id/pickup-point", handler.SetPickupPoint)
+	rideGroup.Post("/:id/duplicate", handler.DuplicateRide) // Same route and preferences, new departure
 
 	// Routes for user-specific rides (My Rides) - Protected
 	userRideGroup := api.Group("/users/me/rides", authMiddleware)
 	userRideGroup.Get("/created", handler.ListUserCreatedRides)
 	userRideGroup.Get("/joined", handler.ListUserJoinedRides)
 	userRideGroup.Get("/history", handler.ListUserHistoryRides) // Add history route
+	userRideGroup.Get("/upcoming/contacts", handler.ListUpcomingRideContacts)
+	api.Get("/users/me/driver-dashboard", authMiddleware, handler.GetDriverDashboard)
 	// Add route for participation status under rides group
 	rideGroup.Get("/:id/my-status", handler.GetMyParticipationStatus)
 
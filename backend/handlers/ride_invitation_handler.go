@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"      // For logging
+	"net/http" // For HTTP status codes
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware"
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// RideInvitationHandler handles invitations to join rides.
+type RideInvitationHandler struct {
+	invitationService *services.RideInvitationService
+}
+
+// NewRideInvitationHandler creates a new RideInvitationHandler instance.
+func NewRideInvitationHandler(invitationService *services.RideInvitationService) *RideInvitationHandler {
+	return &RideInvitationHandler{invitationService: invitationService}
+}
+
+// Invite handles POST /api/v1/rides/{id}/invitations
+// The creator invites a user, found by user ID, email or WhatsApp number.
+func (h *RideInvitationHandler) Invite(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	var req models.CreateRideInvitationRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	invitation, err := h.invitationService.Invite(c.Context(), rideID, userID, req)
+	if err != nil {
+		log.Printf("Error inviting to ride %s by user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Invitation sent", "data": invitation})
+}
+
+// ListRideInvitations handles GET /api/v1/rides/{id}/invitations
+func (h *RideInvitationHandler) ListRideInvitations(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	invitations, err := h.invitationService.ListRideInvitations(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Invitations retrieved successfully", "data": invitations})
+}
+
+// ListPendingInvitations handles GET /api/v1/users/me/ride-invitations
+// Lists the invitations waiting for the user's answer.
+func (h *RideInvitationHandler) ListPendingInvitations(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	invitations, err := h.invitationService.ListPendingInvitations(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pending invitations retrieved successfully", "data": invitations})
+}
+
+// AcceptInvitation handles POST /api/v1/ride-invitations/{id}/accept
+// One-tap join: the invitee books the whole route and proceeds to payment.
+func (h *RideInvitationHandler) AcceptInvitation(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	invitationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid invitation ID format"})
+	}
+	// The body is optional: it books seats for a group or ignores departure conflicts
+	var req models.AcceptRideInvitationRequest
+	if len(c.Body()) > 0 {
+		if handled, respErr := bindBody(c, &req); handled {
+			return respErr
+		}
+	}
+
+	participant, err := h.invitationService.AcceptInvitation(c.Context(), invitationID, userID, req)
+	if err != nil {
+		log.Printf("Error accepting invitation %s by user %s: %v", invitationID, userID, err)
+		return err
+	}
+	response := joinRideResponse(participant)
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": response.Message, "data": response})
+}
+
+// DeclineInvitation handles POST /api/v1/ride-invitations/{id}/decline
+func (h *RideInvitationHandler) DeclineInvitation(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	invitationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid invitation ID format"})
+	}
+	invitation, err := h.invitationService.DeclineInvitation(c.Context(), invitationID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Invitation declined", "data": invitation})
+}
+
+// RevokeInvitation handles DELETE /api/v1/ride-invitations/{id}
+// The creator withdraws a pending invitation.
+func (h *RideInvitationHandler) RevokeInvitation(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	invitationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid invitation ID format"})
+	}
+	invitation, err := h.invitationService.RevokeInvitation(c.Context(), invitationID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Invitation revoked", "data": invitation})
+}
+
+// SetupRideInvitationRoutes registers the ride invitation routes.
+func SetupRideInvitationRoutes(api fiber.Router, invitationService *services.RideInvitationService, authMiddleware fiber.Handler) {
+	handler := NewRideInvitationHandler(invitationService)
+	api.Post("/rides/:id/invitations", authMiddleware, handler.Invite)
+	api.Get("/rides/:id/invitations", authMiddleware, handler.ListRideInvitations)
+	api.Post("/ride-invitations/:id/accept", authMiddleware, handler.AcceptInvitation)
+	api.Post("/ride-invitations/:id/decline", authMiddleware, handler.DeclineInvitation)
+	api.Delete("/ride-invitations/:id", authMiddleware, handler.RevokeInvitation)
+	api.Get("/users/me/ride-invitations", authMiddleware, handler.ListPendingInvitations)
+	log.Println("Ride invitation routes (/rides/:id/invitations, /ride-invitations/:id, /users/me/ride-invitations) setup complete.")
+}
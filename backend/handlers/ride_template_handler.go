@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// RideTemplateHandler exposes the ride templates drivers create rides from.
+type RideTemplateHandler struct {
+	templates *services.RideTemplateService
+}
+
+// NewRideTemplateHandler creates a new RideTemplateHandler instance.
+func NewRideTemplateHandler(templates *services.RideTemplateService) *RideTemplateHandler {
+	return &RideTemplateHandler{
+		templates: templates,
+	}
+}
+
+// ListTemplates handles GET /api/v1/users/me/ride-templates
+func (h *RideTemplateHandler) ListTemplates(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	templates, err := h.templates.List(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing ride templates for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride templates retrieved successfully",
+		"data":    templates,
+	})
+}
+
+// CreateTemplate handles POST /api/v1/users/me/ride-templates
+func (h *RideTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.RideTemplateRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	template, err := h.templates.Create(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride template saved",
+		"data":    template,
+	})
+}
+
+// UpdateTemplate handles PUT /api/v1/users/me/ride-templates/:id
+func (h *RideTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride template ID format"})
+	}
+	var req models.RideTemplateRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	template, err := h.templates.Update(c.Context(), userID, templateID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride template updated",
+		"data":    template,
+	})
+}
+
+// DeleteTemplate handles DELETE /api/v1/users/me/ride-templates/:id
+func (h *RideTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride template ID format"})
+	}
+	if err := h.templates.Delete(c.Context(), userID, templateID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride template deleted",
+	})
+}
+
+// CreateRideFromTemplate handles POST /api/v1/rides/from-template/:templateId
+func (h *RideTemplateHandler) CreateRideFromTemplate(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride template ID format"})
+	}
+	var req models.RideFromTemplateRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	ride, err := h.templates.CreateRide(c.Context(), userID, templateID, req)
+	if err != nil {
+		log.Printf("Error creating ride from template %s for user %s: %v", templateID, userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Ride created successfully",
+		"data":    ride,
+	})
+}
+
+// SetupRideTemplateRoutes registers the ride template routes.
+func SetupRideTemplateRoutes(api fiber.Router, templates *services.RideTemplateService, authMiddleware fiber.Handler) {
+	handler := NewRideTemplateHandler(templates)
+	templateGroup := api.Group("/users/me/ride-templates", authMiddleware)
+	templateGroup.Get("/", handler.ListTemplates)
+	templateGroup.Post("/", handler.CreateTemplate)
+	templateGroup.Put("/:id", handler.UpdateTemplate)
+	templateGroup.Delete("/:id", handler.DeleteTemplate)
+	api.Post("/rides/from-template/:templateId", authMiddleware, handler.CreateRideFromTemplate)
+	log.Println("Ride template routes (/users/me/ride-templates, /rides/from-template) setup complete.")
+}
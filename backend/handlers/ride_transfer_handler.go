@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"log"      // For logging
+	"net/http" // For HTTP status codes
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware"
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// RideTransferHandler handles ride ownership transfers.
+type RideTransferHandler struct {
+	transferService *services.RideTransferService
+}
+
+// NewRideTransferHandler creates a new RideTransferHandler instance.
+func NewRideTransferHandler(transferService *services.RideTransferService) *RideTransferHandler {
+	return &RideTransferHandler{transferService: transferService}
+}
+
+// ProposeTransfer handles POST /api/v1/rides/{id}/transfer
+// The creator asks another user to take over the ride.
+func (h *RideTransferHandler) ProposeTransfer(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	var req models.ProposeRideTransferRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+
+	transfer, err := h.transferService.ProposeTransfer(c.Context(), rideID, userID, req)
+	if err != nil {
+		log.Printf("Error proposing transfer of ride %s by user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Transfer proposed", "data": transfer})
+}
+
+// CancelTransfer handles DELETE /api/v1/rides/{id}/transfer
+// The creator withdraws a pending proposal.
+func (h *RideTransferHandler) CancelTransfer(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	transfer, err := h.transferService.CancelTransfer(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Transfer cancelled", "data": transfer})
+}
+
+// AcceptTransfer handles POST /api/v1/rides/{id}/transfer/accept
+// The proposed driver becomes the ride's creator.
+func (h *RideTransferHandler) AcceptTransfer(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	transfer, err := h.transferService.AcceptTransfer(c.Context(), rideID, userID)
+	if err != nil {
+		log.Printf("Error accepting transfer of ride %s by user %s: %v", rideID, userID, err)
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "You are now the driver of this ride", "data": transfer})
+}
+
+// DeclineTransfer handles POST /api/v1/rides/{id}/transfer/decline
+func (h *RideTransferHandler) DeclineTransfer(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	rideID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ride ID format"})
+	}
+	transfer, err := h.transferService.DeclineTransfer(c.Context(), rideID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Transfer declined", "data": transfer})
+}
+
+// ListPendingTransfers handles GET /api/v1/users/me/ride-transfers
+// Lists the transfer proposals waiting for the user's answer.
+func (h *RideTransferHandler) ListPendingTransfers(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	transfers, err := h.transferService.ListPendingTransfers(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"status": "success", "message": "Pending transfers retrieved successfully", "data": transfers})
+}
+
+// SetupRideTransferRoutes registers the ride ownership transfer routes.
+func SetupRideTransferRoutes(api fiber.Router, transferService *services.RideTransferService, authMiddleware fiber.Handler) {
+	handler := NewRideTransferHandler(transferService)
+	api.Post("/rides/:id/transfer", authMiddleware, handler.ProposeTransfer)
+	api.Delete("/rides/:id/transfer", authMiddleware, handler.CancelTransfer)
+	api.Post("/rides/:id/transfer/accept", authMiddleware, handler.AcceptTransfer)
+	api.Post("/rides/:id/transfer/decline", authMiddleware, handler.DeclineTransfer)
+	api.Get("/users/me/ride-transfers", authMiddleware, handler.ListPendingTransfers)
+	log.Println("Ride transfer routes (/rides/:id/transfer, /users/me/ride-transfers) setup complete.")
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// SavedSearchHandler exposes the saved searches users are alerted of new matching rides for.
+type SavedSearchHandler struct {
+	searches *services.SavedSearchService
+}
+
+// NewSavedSearchHandler creates a new SavedSearchHandler instance.
+func NewSavedSearchHandler(searches *services.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		searches: searches,
+	}
+}
+
+// ListSavedSearches handles GET /api/v1/users/me/saved-searches
+func (h *SavedSearchHandler) ListSavedSearches(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	searches, err := h.searches.List(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing saved searches for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Saved searches retrieved successfully",
+		"data":    searches,
+	})
+}
+
+// CreateSavedSearch handles POST /api/v1/users/me/saved-searches
+func (h *SavedSearchHandler) CreateSavedSearch(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.CreateSavedSearchRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	search, err := h.searches.Create(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Search saved, you'll be notified of new matching rides",
+		"data":    search,
+	})
+}
+
+// DeleteSavedSearch handles DELETE /api/v1/users/me/saved-searches/:id
+func (h *SavedSearchHandler) DeleteSavedSearch(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	searchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid saved search ID format"})
+	}
+	if err := h.searches.Delete(c.Context(), userID, searchID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Saved search deleted",
+	})
+}
+
+// SetupSavedSearchRoutes registers the saved search routes.
+func SetupSavedSearchRoutes(api fiber.Router, searches *services.SavedSearchService, authMiddleware fiber.Handler) {
+	handler := NewSavedSearchHandler(searches)
+	searchGroup := api.Group("/users/me/saved-searches", authMiddleware)
+	searchGroup.Get("/", handler.ListSavedSearches)
+	searchGroup.Post("/", handler.CreateSavedSearch)
+	searchGroup.Delete("/:id", handler.DeleteSavedSearch)
+	log.Println("Saved search routes (/users/me/saved-searches) setup complete.")
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// SearchSuggestionHandler exposes search suggestions and saved commutes.
+type SearchSuggestionHandler struct {
+	suggestions *services.SearchSuggestionService
+}
+
+// NewSearchSuggestionHandler creates a new SearchSuggestionHandler instance.
+func NewSearchSuggestionHandler(suggestions *services.SearchSuggestionService) *SearchSuggestionHandler {
+	return &SearchSuggestionHandler{
+		suggestions: suggestions,
+	}
+}
+
+// ListSuggestions handles GET /api/v1/rides/search/suggestions?q=
+// Public: signed-in users get their commutes and recent searches, visitors the recent searches of
+// their anonymous session; both get popular routes.
+func (h *SearchSuggestionHandler) ListSuggestions(c *fiber.Ctx) error {
+	var req models.SearchSuggestionsRequest
+	if handled, respErr := bindQuery(c, &req); handled {
+		return respErr
+	}
+	userID, _ := middleware.OptionalUserID(c)
+	sessionID, _ := middleware.CurrentAnonymousSessionID(c)
+	suggestions, err := h.suggestions.Suggestions(c.Context(), userID, sessionID, req)
+	if err != nil {
+		log.Printf("Error listing search suggestions: %v", err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Search suggestions retrieved successfully",
+		"data":    suggestions,
+	})
+}
+
+// ListCommutes handles GET /api/v1/users/me/commutes
+func (h *SearchSuggestionHandler) ListCommutes(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	commutes, err := h.suggestions.ListCommutes(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing commutes for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Commutes retrieved successfully",
+		"data":    commutes,
+	})
+}
+
+// SaveCommute handles POST /api/v1/users/me/commutes
+func (h *SearchSuggestionHandler) SaveCommute(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.SaveCommuteRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	commute, err := h.suggestions.SaveCommute(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Commute saved",
+		"data":    commute,
+	})
+}
+
+// DeleteCommute handles DELETE /api/v1/users/me/commutes/:id
+func (h *SearchSuggestionHandler) DeleteCommute(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	commuteID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid commute ID format"})
+	}
+	if err := h.suggestions.DeleteCommute(c.Context(), userID, commuteID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Commute deleted",
+	})
+}
+
+// SetupSearchSuggestionRoutes registers the search suggestion and saved commute routes. Register it
+// before the protected ride group, so the suggestions route stays public.
+func SetupSearchSuggestionRoutes(api fiber.Router, suggestions *services.SearchSuggestionService, authMiddleware fiber.Handler) {
+	handler := NewSearchSuggestionHandler(suggestions)
+	api.Get("/rides/search/suggestions", middleware.OptionalAuth(authMiddleware), handler.ListSuggestions)
+
+	commuteGroup := api.Group("/users/me/commutes", authMiddleware)
+	commuteGroup.Get("/", handler.ListCommutes)
+	commuteGroup.Post("/", handler.SaveCommute)
+	commuteGroup.Delete("/:id", handler.DeleteCommute)
+	log.Println("Search suggestion routes (/rides/search/suggestions, /users/me/commutes) setup complete.")
+}
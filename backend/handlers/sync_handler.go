@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// SyncHandler exposes the changes the app reconciles after an offline period.
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+// NewSyncHandler creates a new SyncHandler instance.
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Sync handles GET /api/v1/users/me/sync?since=2030-05-10T08:00:00Z
+// Returns the user's rides, participations, payments and notifications changed since the cursor.
+func (h *SyncHandler) Sync(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var params models.SyncRequest
+	if handled, respErr := bindQuery(c, &params); handled {
+		return respErr
+	}
+
+	changes, err := h.syncService.Changes(c.Context(), userID, params)
+	if err != nil {
+		log.Printf("Error syncing user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Changes retrieved successfully",
+		"data":    changes,
+	})
+}
+
+// SetupSyncRoutes registers the sync route.
+func SetupSyncRoutes(api fiber.Router, syncService *services.SyncService, authMiddleware fiber.Handler) {
+	handler := NewSyncHandler(syncService)
+	api.Get("/users/me/sync", authMiddleware, handler.Sync)
+	log.Println("Sync routes (/users/me/sync) setup complete.")
+}
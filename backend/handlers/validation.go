@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json" // For body decoding errors
+	"errors"        // For error type checks
+	"log"           // For logging
+	"strings"       // For field paths
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/services" // Shared validator
+)
+
+// requestValidator validates request DTOs; field errors use the JSON/query field names.
+var requestValidator = services.NewValidator()
+
+// FieldError describes one invalid field in a 400 response,
+// e.g. {"field":"departure_time","rule":"datetime","param":"15:04"}.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// fieldErrorsFrom converts validator errors (possibly wrapped by a service) to FieldErrors.
+func fieldErrorsFrom(err error) ([]FieldError, bool) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		// Namespace is "Struct.field.nested"; drop the struct name to get the client-facing path
+		field := fe.Field()
+		if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+			field = path
+		}
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Rule: fe.Tag(), Param: fe.Param()})
+	}
+	return fieldErrors, true
+}
+
+// validationFailed writes a 400 with per-field errors if err contains validator errors.
+// Returns false if err is not a validation error, so the caller can keep mapping it.
+func validationFailed(c *fiber.Ctx, err error) (bool, error) {
+	fieldErrors, ok := fieldErrorsFrom(err)
+	if !ok {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Validation failed",
+		"errors":  fieldErrors,
+	})
+}
+
+// parseFailed writes a 400 for a body or query that could not be decoded.
+func parseFailed(c *fiber.Ctx, source string, err error) error {
+	fieldError := FieldError{Field: source, Rule: "format"}
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &typeErr) {
+		fieldError = FieldError{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}
+	} else if errors.As(err, &syntaxErr) {
+		fieldError.Rule = "json"
+	}
+
+	message := "Invalid request body"
+	if source == "query" {
+		message = "Invalid query parameters"
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"errors":  []FieldError{fieldError},
+	})
+}
+
+// bindBody parses the request body into dst and validates it.
+// On failure it writes the 400 response and returns true; the caller returns the error as-is:
+//
+//	if handled, respErr := bindBody(c, &req); handled {
+//		return respErr
+//	}
+func bindBody(c *fiber.Ctx, dst interface{}) (bool, error) {
+	if err := c.BodyParser(dst); err != nil {
+		log.Printf("Error parsing request body for %s %s: %v", c.Method(), c.Path(), err)
+		return true, parseFailed(c, "body", err)
+	}
+	if err := requestValidator.Struct(dst); err != nil {
+		return validationFailed(c, err)
+	}
+	return false, nil
+}
+
+// bindQuery parses the query string into dst and validates it. Same contract as bindBody.
+func bindQuery(c *fiber.Ctx, dst interface{}) (bool, error) {
+	if err := c.QueryParser(dst); err != nil {
+		log.Printf("Error parsing query parameters for %s %s: %v", c.Method(), c.Path(), err)
+		return true, parseFailed(c, "query", err)
+	}
+	if err := requestValidator.Struct(dst); err != nil {
+		return validationFailed(c, err)
+	}
+	return false, nil
+}
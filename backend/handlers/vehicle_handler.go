@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/middleware" // Authenticated user accessor
+	"rideshare/backend/models"
+	"rideshare/backend/services"
+)
+
+// VehicleHandler exposes the vehicles users drive their rides with.
+type VehicleHandler struct {
+	vehicles *services.VehicleService
+}
+
+// NewVehicleHandler creates a new VehicleHandler instance.
+func NewVehicleHandler(vehicles *services.VehicleService) *VehicleHandler {
+	return &VehicleHandler{
+		vehicles: vehicles,
+	}
+}
+
+// ListVehicles handles GET /api/v1/users/me/vehicles
+func (h *VehicleHandler) ListVehicles(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	vehicles, err := h.vehicles.List(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing vehicles for user %s: %v", userID, err)
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vehicles retrieved successfully",
+		"data":    vehicles,
+	})
+}
+
+// CreateVehicle handles POST /api/v1/users/me/vehicles
+func (h *VehicleHandler) CreateVehicle(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req models.VehicleRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	vehicle, err := h.vehicles.Create(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vehicle registered",
+		"data":    vehicle,
+	})
+}
+
+// UpdateVehicle handles PUT /api/v1/users/me/vehicles/:id
+func (h *VehicleHandler) UpdateVehicle(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	vehicleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid vehicle ID format"})
+	}
+	var req models.VehicleRequest
+	if handled, respErr := bindBody(c, &req); handled {
+		return respErr
+	}
+	vehicle, err := h.vehicles.Update(c.Context(), userID, vehicleID, req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vehicle updated",
+		"data":    vehicle,
+	})
+}
+
+// DeleteVehicle handles DELETE /api/v1/users/me/vehicles/:id
+func (h *VehicleHandler) DeleteVehicle(c *fiber.Ctx) error {
+	userID, err := middleware.CurrentUserID(c)
+	if err != nil {
+		return err
+	}
+	vehicleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid vehicle ID format"})
+	}
+	if err := h.vehicles.Delete(c.Context(), userID, vehicleID); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vehicle deleted",
+	})
+}
+
+// SetupVehicleRoutes registers the vehicle routes.
+func SetupVehicleRoutes(api fiber.Router, vehicles *services.VehicleService, authMiddleware fiber.Handler) {
+	handler := NewVehicleHandler(vehicles)
+	vehicleGroup := api.Group("/users/me/vehicles", authMiddleware)
+	vehicleGroup.Get("/", handler.ListVehicles)
+	vehicleGroup.Post("/", handler.CreateVehicle)
+	vehicleGroup.Put("/:id", handler.UpdateVehicle)
+	vehicleGroup.Delete("/:id", handler.DeleteVehicle)
+	log.Println("Vehicle routes (/users/me/vehicles) setup complete.")
+}
@@ -25,19 +25,51 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize database connection
-	database.InitDB()        // This also loads config, but we load it above for clarity and potential use
-	defer database.CloseDB() // Ensure DB connection is closed when main function exits
+	// Reload quotas, booking fee, feature flags and log level on SIGHUP without a restart
+	cfg.ApplyLogLevel()
+	cfg.WatchRuntime()
 
 	// Initialize Stripe client
 	stripe.Key = cfg.StripeSecretKey
 	log.Println("Stripe client initialized with configured secret key.")
 
+	// Connect to the database and verify Stripe credentials, retrying while dependencies come up
+	if err := runStartupChecks(cfg, services.NewStripeServiceImpl()); err != nil {
+		log.Fatalf("Startup failed: %v", err)
+	}
+	defer database.CloseDB() // Ensure DB connection is closed when main function exits
+
+	// Error reporting to Sentry/GlitchTip (no-op without SENTRY_DSN)
+	errorReporter := services.NewErrorReporter(cfg)
+
 	// Create a new Fiber app instance
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handlers.NewErrorHandler(errorReporter), // Maps service errors to status codes and the standard error envelope; reports 500s
+	})
+
+	// Per route group latency and error-budget tracking; outermost so it sees the final status (panics included)
+	sloTracker := services.NewSLOTracker(services.DefaultSLOTargets)
+	app.Use(middleware.TrackSLO(sloTracker))
 
-	// Add logger middleware for http requests
-	app.Use(logger.New())
+	// Recover from panics before any other middleware runs
+	app.Use(middleware.Recover(errorReporter))
+
+	// Add logger middleware for http requests (skipped when LOG_LEVEL is "warn"; reloadable)
+	// It writes to stdout, not through the standard logger, so it is skipped here rather than filtered
+	app.Use(logger.New(logger.Config{
+		Next: func(c *fiber.Ctx) bool { return cfg.Runtime().LogLevel == "warn" },
+	}))
+
+	// Log requests issuing many SQL queries (N+1 regressions); counted by the database tracer
+	if cfg.QueryCountLogMin > 0 {
+		app.Use(middleware.LogQueryCount(cfg.QueryCountLogMin))
+	}
+
+	// Check ride and payment responses against the schemas of their models (dev and staging by default)
+	if cfg.ResponseValidation != config.ResponseValidationOff {
+		app.Use(middleware.ValidateResponses(cfg.ResponseValidation, handlers.ResponseContracts()))
+		log.Printf("Response contract validation enabled (mode %s)", cfg.ResponseValidation)
+	}
 
 	// Simple health check route at the root
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -46,24 +78,115 @@ func main() {
 	})
 
 	// Setup API v1 group
-	apiV1 := app.Group("/api/v1")
+	geoService := services.NewGeoIPService(cfg)                              // IP geolocation (country, region) for request defaults and fraud signals
+	apiV1 := app.Group("/api/v1", middleware.GeoContext(geoService))         // Resolve caller location for every API request
+	apiV1.Use(middleware.AnonymousSession(cfg))                              // Browsing sessions of visitors without an account
+	apiV1.Use(middleware.RateLimitHeaders(services.NewUserRateLimiter(cfg))) // Soft per-user budget in X-RateLimit-* headers
 	log.Println("API group /api/v1 setup")
 
 	// --- Setup application services ---
-	authService := services.NewAuthService(cfg)
+	fieldEncryptor, err := services.NewFieldEncryptor(cfg) // Envelope encryption of WhatsApp numbers, birth dates and locations
+	if err != nil {
+		log.Fatalf("Failed to initialize field encryption: %v", err)
+	}
+	services.NewPIIEncryptionJob(database.DB, fieldEncryptor).Start()          // Encrypt legacy plaintext, re-wrap values under rotated keys
+	fraudService := services.NewFraudService(cfg, database.DB, fieldEncryptor) // Fraud rules on signup, join and payment
+	quotaService := services.NewQuotaService(cfg, database.DB)                 // Per-account abuse quotas on write endpoints
+	legalService := services.NewLegalService(database.DB)                      // Versioned terms and privacy policy, per-user acceptance
+	consentService := services.NewConsentService(database.DB)                  // Opt-in consents (marketing emails, location storage, analytics)
+	authService := services.NewAuthService(cfg, fraudService, fieldEncryptor, legalService, consentService)
 	// Pass the database pool interface to NewRideService
-	rideService := services.NewRideService(database.DB)
-	stripeService := services.NewStripeServiceImpl()                                           // Create real Stripe service implementation
-	paymentService := services.NewPaymentService(cfg, database.DB, rideService, stripeService) // Inject rideService and stripeService
+	emailService, err := services.NewEmailService(cfg, database.DB, consentService) // Transactional emails (HTML templates)
+	if err != nil {
+		log.Fatalf("Failed to initialize email templates: %v", err)
+	}
+	notificationService := services.NewNotificationService(cfg, database.DB, emailService) // Notification dispatcher (in-app, Expo push, email)
+	eventBus := services.NewEventBus()                                                     // In-process ride change events
+	searchCache := services.NewSearchCache(cfg)                                            // First pages of common ride searches
+	eventBus.Subscribe(searchCache.HandleRideEvent)                                        // Drop cached pages a ride change affects (write-through)
+	travelMatrix := services.NewTravelMatrix(cfg, database.DB)                             // Driving estimates between frequent city pairs
+	travelMatrix.Start()                                                                   // Load persisted estimates, refresh stale pairs in the background
+	holidayCalendar := services.NewHolidayCalendar(cfg, database.DB)                       // Public holidays making peak travel days
+	holidayCalendar.Start()                                                                // Load stored holidays, fetch the calendar in the background
+	moderationService := services.NewModerationService(cfg, database.DB)                   // Screens user-written text shown to other users
+	driverStats := services.NewDriverStatsCache(cfg, database.DB)                          // Cached creator reliability stats on ride listings
+	rideService := services.NewRideService(cfg, database.DB, notificationService, fraudService, quotaService, eventBus, searchCache, travelMatrix, holidayCalendar, driverStats, fieldEncryptor, moderationService)
+	staticMapService := services.NewStaticMapService(cfg, rideService) // Ride map thumbnails (provider key stays server-side)
+	eventBus.Subscribe(staticMapService.HandleRideEvent)
+	analyticsService := services.NewAnalyticsService(cfg, database.DB, consentService) // Product analytics events (consent-aware, PII scrubbed, sampled)
+	analyticsService.Start()
+	stripeService := services.NewStripeServiceImpl()                                                                                                               // Create real Stripe service implementation
+	paymentService := services.NewPaymentService(cfg, database.DB, rideService, stripeService, notificationService, fraudService, errorReporter, analyticsService) // Inject rideService and stripeService
+	paymentService.StartRefundRetries()                                                                                                                            // Retry refunds that failed (e.g. Stripe unavailable)
+	rideTransferService := services.NewRideTransferService(database.DB, rideService, paymentService, notificationService)                                          // Hand rides over to another driver
+	rideInvitationService := services.NewRideInvitationService(database.DB, rideService, notificationService, fieldEncryptor)                                      // Creators invite people to their rides
+	pickupPointService := services.NewPickupPointService(database.DB)                                                                                              // Curated meeting spots near departures
+	auditService := services.NewAuditService(database.DB)                                                                                                          // Audit trail for admin and impersonated actions
+	adminService := services.NewAdminService(cfg, database.DB, auditService, paymentService, fraudService, quotaService, moderationService, fieldEncryptor)
+	adminService.StartJobWorker()
+	services.NewRideArchivalJob(cfg, database.DB, eventBus).Start()                             // Archive active rides once they departed, complete them once they arrived
+	services.NewDepartureCheckinJob(cfg, database.DB, rideService, notificationService).Start() // Departure-day check-in messages to drivers and passengers
+	erasureService := services.NewErasureService(cfg, database.DB, stripeService)               // Anonymizes deleted accounts after the grace period
+	erasureService.Start()
+	retentionService := services.NewRetentionService(cfg, database.DB) // Scheduled purges per retention rule (RETENTION_MODE)
+	retentionService.Start()
+	publicAPIService := services.NewPublicAPIService(database.DB, auditService)   // Scoped API keys and anonymized public data
+	inviteCodeService := services.NewInviteCodeService(database.DB, auditService) // Signup invite codes (SIGNUP_MODE=invite_only)
+	activityService := services.NewActivityService(database.DB)                   // Profile activity timeline
+	syncService := services.NewSyncService(database.DB, rideService)              // Changes since the app's last sync
+	anonymousSessions := services.NewAnonymousSessionService(cfg, database.DB)    // Anonymous browsing and recent searches
+	searchSuggestions := services.NewSearchSuggestionService(database.DB)         // Search box suggestions, saved commutes
+	vehicleService := services.NewVehicleService(database.DB)                     // Drivers' vehicles shown on rides
+	accountDeletionService := services.NewAccountDeletionService(database.DB, authService, paymentService)
+	financeExportService := services.NewFinanceExportService(cfg, database.DB, auditService) // Accounting journals of fees and refunds (CSV, JSON, DATEV)
+	financeExportService.Start()
+	pushHygieneJob := services.NewPushHygieneJob(cfg, database.DB) // Prune unregistered push tokens, deactivate dormant ones
+	pushHygieneJob.Start()
+	reviewService := services.NewReviewService(database.DB, notificationService, moderationService) // Ratings and reviews after rides
+	rideTemplateService := services.NewRideTemplateService(database.DB, rideService)                // Saved routes drivers create rides from
+	savedSearchService := services.NewSavedSearchService(database.DB, notificationService)          // Saved searches alerted of new matching rides
+	eventBus.Subscribe(savedSearchService.HandleRideEvent)
+	savedSearchService.Start()
+	rideViewCounter := services.NewRideViewCounter(database.DB) // Distinct viewers of ride details, for their creators
+	rideViewCounter.Start()
+
+	// Prometheus metrics (request counters, latency histograms, SLO burn rates, search cache, login attempts, push deliverability)
+	handlers.SetupMetricsRoutes(app, cfg.MetricsToken, sloTracker, searchCache, authService.Metrics(), pushHygieneJob)
 
 	// --- Setup middleware ---
-	authMiddleware := middleware.Protected(cfg) // Create auth middleware instance
+	authMiddleware := middleware.Protected(cfg, auditService, legalService) // Create auth middleware instance (audits impersonated requests, requires current terms)
+	adminMiddleware := middleware.RequireAdmin(adminService)                // Restricts /admin routes to admins
 
 	// --- Setup routes ---
-	handlers.SetupAuthRoutes(apiV1, authService)
-	handlers.SetupRideRoutes(apiV1, rideService, authMiddleware)
-	handlers.SetupPaymentRoutes(apiV1, paymentService, authMiddleware) // This sets up payment routes EXCEPT webhook
-	handlers.SetupUserRoutes(apiV1, authService, authMiddleware)       // Add user routes
+	handlers.SetupAuthRoutes(apiV1, authService, anonymousSessions)
+	handlers.SetupAnonymousSessionRoutes(apiV1, anonymousSessions, authMiddleware) // Anonymous browsing tokens, recent searches
+	handlers.SetupMapRoutes(apiV1, staticMapService)                               // Public, so registered before the protected ride group
+	handlers.SetupSearchSuggestionRoutes(apiV1, searchSuggestions, authMiddleware) // Public suggestions, so registered before the protected ride group
+	handlers.SetupRideRoutes(apiV1, rideService, paymentService, anonymousSessions, analyticsService, rideViewCounter, authMiddleware)
+	handlers.SetupRideTransferRoutes(apiV1, rideTransferService, authMiddleware)
+	handlers.SetupReviewRoutes(apiV1, reviewService, authMiddleware)                        // Rate drivers and passengers once rides are completed
+	handlers.SetupRideInvitationRoutes(apiV1, rideInvitationService, authMiddleware)        // Invitations with one-tap join
+	handlers.SetupPaymentRoutes(apiV1, paymentService, authMiddleware)                      // This sets up payment routes EXCEPT webhook
+	handlers.SetupUserRoutes(apiV1, authService, authMiddleware)                            // Add user routes
+	handlers.SetupVehicleRoutes(apiV1, vehicleService, authMiddleware)                      // Register vehicles referenced by rides
+	handlers.SetupRideTemplateRoutes(apiV1, rideTemplateService, authMiddleware)            // Saved ride templates, rides created from them
+	handlers.SetupSavedSearchRoutes(apiV1, savedSearchService, authMiddleware)              // Saved searches with new ride alerts
+	handlers.SetupAccountDeletionRoutes(apiV1, accountDeletionService, authMiddleware)      // Deletion preview, cascading account deletion
+	handlers.SetupEmailRoutes(apiV1, emailService, authMiddleware)                          // Unsubscribe links and email preferences
+	handlers.SetupLegalRoutes(apiV1, legalService, authMiddleware)                          // Current terms and privacy policy, acceptance
+	handlers.SetupConsentRoutes(apiV1, consentService, authMiddleware)                      // Grant and withdraw data processing consents
+	handlers.SetupActivityRoutes(apiV1, activityService, authMiddleware)                    // Recent rides, payments and notifications
+	handlers.SetupSyncRoutes(apiV1, syncService, authMiddleware)                            // Offline reconciliation in one call
+	handlers.SetupGeoRoutes(apiV1, geoService)                                              // Location-based defaults (currency, locale)
+	handlers.SetupPlacesRoutes(apiV1, pickupPointService, authMiddleware, adminMiddleware)  // Suggested pickup points
+	handlers.SetupPublicAPIRoutes(apiV1, publicAPIService, authMiddleware, adminMiddleware) // Key-authenticated, anonymized data for dashboards
+	handlers.SetupInviteCodeRoutes(apiV1, inviteCodeService, authMiddleware, adminMiddleware)
+	handlers.SetupRetentionRoutes(apiV1, retentionService, authMiddleware, adminMiddleware)
+	handlers.SetupFinanceRoutes(apiV1, financeExportService, authMiddleware, adminMiddleware)
+	handlers.SetupAdminRoutes(apiV1, adminService, authMiddleware, adminMiddleware)
+	if cfg.IsDevelopment() {
+		handlers.SetupDevRoutes(apiV1, emailService, services.NewDevWebhookService(cfg)) // Email previews, signed Stripe webhook events, development only
+	}
 
 	// --- Setup Stripe Webhook Route using net/http adaptor ---
 	// Create a separate http handler instance for the webhook
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AdminChecker reports whether a user has admin access.
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// RequireAdmin restricts a route to admins. It must run after Protected.
+// Impersonation tokens are always rejected so support can't escalate through a user session.
+func RequireAdmin(checker AdminChecker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals("impersonatorID") != nil {
+			log.Println("Admin Middleware: Impersonation token used on an admin route")
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Forbidden: Admin routes are not available while impersonating",
+			})
+		}
+
+		userID, err := CurrentUserID(c)
+		if err != nil {
+			return err
+		}
+
+		isAdmin, err := checker.IsAdmin(c.Context(), userID)
+		if err != nil {
+			log.Printf("Admin Middleware: Error checking admin status for user %s: %v", userID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to verify admin access",
+			})
+		}
+		if !isAdmin {
+			log.Printf("Admin Middleware: User %s denied access to %s", userID, c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Forbidden: Admin access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// anonymousSessionKey is the Locals key under which AnonymousSession stores the session's uuid.UUID.
+const anonymousSessionKey = "anonymousSessionID"
+
+// AnonymousSession reads the anonymous browsing token of the X-Anonymous-Session header, if any,
+// and stores its session for the rest of the request (read with CurrentAnonymousSessionID). The
+// token is checked like user tokens (signature, expiry, issuer, audience); an invalid or expired
+// token is ignored, so browsing never fails because of it.
+func AnonymousSession(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString := c.Get(models.AnonymousSessionHeader)
+		if tokenString == "" {
+			return c.Next()
+		}
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return []byte(cfg.JWTSecret), nil
+		}, jwt.WithIssuer(cfg.JWTIssuer), jwt.WithAudience(cfg.JWTAudience), jwt.WithExpirationRequired())
+		if err != nil {
+			log.Printf("Anonymous Session Middleware: Ignoring invalid token: %v", err)
+			return c.Next()
+		}
+		sessionIDStr, _ := claims["anonymous_session_id"].(string)
+		sessionID, err := uuid.Parse(sessionIDStr)
+		if err != nil {
+			log.Println("Anonymous Session Middleware: Ignoring token without a valid anonymous_session_id claim")
+			return c.Next()
+		}
+		c.Locals(anonymousSessionKey, sessionID)
+		return c.Next()
+	}
+}
+
+// CurrentAnonymousSessionID returns the caller's anonymous session, set by AnonymousSession.
+func CurrentAnonymousSessionID(c *fiber.Ctx) (uuid.UUID, bool) {
+	sessionID, ok := c.Locals(anonymousSessionKey).(uuid.UUID)
+	return sessionID, ok
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/models"
+)
+
+// apiKeyLocalsKey is the Locals key under which RequireAPIKey stores the *models.APIKey.
+const apiKeyLocalsKey = "apiKey"
+
+// APIKeyAuthenticator validates public API keys and enforces their rate limits.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+	Allow(key *models.APIKey) models.RateLimitStatus
+}
+
+// RequireAPIKey restricts a route to API keys holding scope, read from the X-API-Key header
+// or an "Authorization: Bearer" header. Requests above the key's per-minute limit get a 429;
+// every response carries the key's X-RateLimit-* headers.
+func RequireAPIKey(authenticator APIKeyAuthenticator, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		}
+		if key == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Unauthorized: Missing API key"})
+		}
+
+		apiKey, err := authenticator.Authenticate(c.Context(), key)
+		if err != nil {
+			return err
+		}
+		hasScope := false
+		for _, granted := range apiKey.Scopes {
+			hasScope = hasScope || granted == scope
+		}
+		if !hasScope {
+			log.Printf("API Key Middleware: Key %s lacks scope %s for %s", apiKey.KeyPrefix, scope, c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Forbidden: API key lacks the " + scope + " scope"})
+		}
+		status := authenticator.Allow(apiKey)
+		setRateLimitHeaders(c, status)
+		if !status.Allowed {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"status": "error", "message": "Rate limit exceeded for this API key"})
+		}
+
+		c.Locals(apiKeyLocalsKey, apiKey)
+		return c.Next()
+	}
+}
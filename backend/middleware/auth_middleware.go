@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"errors" // Import errors package
+	"context" // For the auditor interface
+	"errors"  // Import errors package
 	"log"
 	"strings" // For string manipulation (Bearer token)
 
@@ -12,9 +13,19 @@ import (
 	"rideshare/backend/config" // To get JWT secret
 )
 
+// ImpersonationAuditor records requests made with admin impersonation tokens.
+type ImpersonationAuditor interface {
+	RecordImpersonatedRequest(ctx context.Context, impersonatorID, userID uuid.UUID, method, path string, statusCode int, ip string)
+}
+
 // Protected is a middleware function to protect routes that require authentication.
-// It verifies the JWT token from the Authorization header.
-func Protected(cfg *config.Config) fiber.Handler {
+// It verifies the JWT token from the Authorization header, including its expiry, issuer
+// (cfg.JWTIssuer) and audience (cfg.JWTAudience).
+// Impersonation tokens (carrying an 'impersonator_id' claim) are flagged in the response
+// headers and every request made with them is written to the audit log.
+// Users who haven't accepted the current mandatory terms get a 451 (see TermsChecker); support
+// sessions are not blocked. terms may be nil to skip the check.
+func Protected(cfg *config.Config, auditor ImpersonationAuditor, terms TermsChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -46,7 +57,7 @@ func Protected(cfg *config.Config) fiber.Handler {
 			}
 			// Return the secret key for validation
 			return []byte(cfg.JWTSecret), nil
-		})
+		}, jwt.WithIssuer(cfg.JWTIssuer), jwt.WithAudience(cfg.JWTAudience), jwt.WithExpirationRequired())
 
 		if err != nil {
 			log.Printf("Auth Middleware: Error parsing or validating token: %v", err)
@@ -86,10 +97,37 @@ func Protected(cfg *config.Config) fiber.Handler {
 				})
 			}
 
-			// Store user ID in locals for subsequent handlers
-			c.Locals("userID", userID) // Store as uuid.UUID
-			// c.Locals("userID_str", userIDStr) // Optionally store string version too if needed elsewhere
+			// Store user ID in locals for subsequent handlers (read with CurrentUserID)
+			setCurrentUserID(c, userID)
+
+			// Impersonation token: flag the response and audit the request
+			if impersonatorIDStr, isImpersonation := claims["impersonator_id"].(string); isImpersonation {
+				impersonatorID, err := uuid.Parse(impersonatorIDStr)
+				if err != nil {
+					log.Printf("Auth Middleware: Invalid impersonator_id claim '%s': %v", impersonatorIDStr, err)
+					return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+						"status":  "error",
+						"message": "Unauthorized: Invalid token claims (invalid impersonator_id format)",
+					})
+				}
+				c.Locals("impersonatorID", impersonatorID)
+				c.Set("X-Impersonated-User", userID.String())
+				c.Set("X-Impersonated-By", impersonatorID.String())
+				log.Printf("Auth Middleware: User %s impersonated by admin %s (%s %s)", userID, impersonatorID, c.Method(), c.Path())
+
+				// Run the error handler here so the audit records the final status code
+				if err := c.Next(); err != nil {
+					if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+						_ = c.SendStatus(fiber.StatusInternalServerError)
+					}
+				}
+				auditor.RecordImpersonatedRequest(c.Context(), impersonatorID, userID, c.Method(), c.Path(), c.Response().StatusCode(), c.IP())
+				return nil
+			}
 			log.Printf("Auth Middleware: User %s authenticated successfully.", userID)
+			if blocked, respErr := requireAcceptedTerms(c, terms, userID); blocked {
+				return respErr
+			}
 
 			// Token is valid, proceed to the next handler
 			return c.Next()
@@ -103,3 +141,15 @@ func Protected(cfg *config.Config) fiber.Handler {
 		})
 	}
 }
+
+// OptionalAuth runs the auth middleware only on requests carrying an Authorization header, so a
+// public route can tell signed-in callers (OptionalUserID) from visitors. A header with an invalid
+// or expired token is still rejected, so the app refreshes it instead of silently browsing signed out.
+func OptionalAuth(auth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return auth(c)
+	}
+}
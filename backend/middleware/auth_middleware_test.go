@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+)
+
+func TestProtectedChecksIssuerAndAudience(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "test-secret-key", JWTIssuer: "rideshare-test", JWTAudience: "rideshare-test-app"}
+	app := fiber.New()
+	app.Get("/me", Protected(cfg, nil, nil), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	sign := func(issuer, audience string) string {
+		claims := jwt.MapClaims{"user_id": uuid.NewString(), "exp": time.Now().Add(time.Hour).Unix()}
+		if issuer != "" {
+			claims["iss"] = issuer
+		}
+		if audience != "" {
+			claims["aud"] = audience
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name, issuer, audience string
+		want                   int
+	}{
+		{"matching claims", "rideshare-test", "rideshare-test-app", fiber.StatusOK},
+		{"other audience", "rideshare-test", "partner-app", fiber.StatusUnauthorized},
+		{"other issuer", "someone-else", "rideshare-test-app", fiber.StatusUnauthorized},
+		{"no claims", "", "", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(tt.issuer, tt.audience))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
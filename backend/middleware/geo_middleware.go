@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/models"
+)
+
+// GeoResolver resolves an IP address to an approximate location (nil if unknown).
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip string) *models.GeoLocation
+}
+
+// GeoContext resolves the caller's IP and stores the location in Locals under models.GeoContextKey.
+// Services read it back with models.GeoFromContext(ctx). Unresolvable IPs leave it unset.
+func GeoContext(resolver GeoResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if geo := resolver.Resolve(c.Context(), c.IP()); geo != nil {
+			c.Locals(models.GeoContextKey, geo)
+			c.Set("X-Geo-Country", geo.CountryCode)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"log" // For logging query counts
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/database"
+)
+
+// LogQueryCount counts the SQL queries each request issues (through database.QueryTracer) and logs
+// requests issuing at least min of them, so N+1 regressions show up in staging logs.
+// Queries must run under the request context (c.Context()) to be counted.
+func LogQueryCount(min int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		counter := &database.QueryCounter{}
+		c.Locals(database.QueryCounterKey, counter)
+		err := c.Next()
+		if n := counter.Count(); n >= int64(min) {
+			log.Printf("Query count: %s %s issued %d queries", c.Method(), c.Route().Path, n)
+		}
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// UserRequestTracker counts requests of users (or anonymous sessions) against a soft budget.
+type UserRequestTracker interface {
+	Track(callerID uuid.UUID) (models.RateLimitStatus, bool)
+}
+
+// setRateLimitHeaders advertises a request budget. X-RateLimit-Reset is the number of seconds
+// until the budget is restored; an exhausted budget also gets a Retry-After.
+func setRateLimitHeaders(c *fiber.Ctx, status models.RateLimitStatus) {
+	reset := int(time.Until(status.Reset).Seconds() + 0.5)
+	if reset < 0 {
+		reset = 0
+	}
+	c.Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(max(status.Remaining, 0)))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(reset))
+	if status.Remaining <= 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(reset, 1)))
+	}
+}
+
+// RateLimitHeaders sets X-RateLimit-* headers on the responses of authenticated requests and of
+// anonymous browsing sessions so clients can back off (e.g. stop retrying searches on a poor
+// connection). The limit is soft: requests over it are served. It runs after the route's handlers,
+// since Protected identifies the user.
+func RateLimitHeaders(tracker UserRequestTracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		callerID, ok := c.Locals(userIDKey).(uuid.UUID)
+		if !ok {
+			callerID, ok = CurrentAnonymousSessionID(c)
+		}
+		if !ok {
+			return err // Public route without a session, or rejected by Protected
+		}
+		if status, enabled := tracker.Track(callerID); enabled {
+			setRateLimitHeaders(c, status)
+		}
+		return err
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log"           // For logging
+	"runtime/debug" // For the panic stack in logs
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/services"
+)
+
+// reportedHeaders are the request headers attached to error reports; credentials are never sent.
+var reportedHeaders = []string{"User-Agent", "Content-Type", "Accept-Language", "X-Request-ID"}
+
+// ErrorContext collects the request context (user, method, URL, IP, safe headers) for an error report.
+func ErrorContext(c *fiber.Ctx) services.ErrorContext {
+	errCtx := services.ErrorContext{
+		Method:  c.Method(),
+		URL:     c.BaseURL() + c.Path(),
+		IP:      c.IP(),
+		Headers: map[string]string{},
+	}
+	if userID, ok := c.Locals(userIDKey).(uuid.UUID); ok {
+		errCtx.UserID = &userID
+	}
+	for _, name := range reportedHeaders {
+		if value := c.Get(name); value != "" {
+			errCtx.Headers[name] = value
+		}
+	}
+	return errCtx
+}
+
+// Recover turns a panic in a handler into a 500 response and reports it with its stack trace.
+// Register it before other middleware so it covers them.
+func Recover(reporter *services.ErrorReporter) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("PANIC on %s %s: %v\n%s", c.Method(), c.Path(), recovered, debug.Stack())
+				reporter.CapturePanic(recovered, ErrorContext(c))
+				// A *fiber.Error isn't reported again by the error handler
+				err = fiber.NewError(fiber.StatusInternalServerError, "An internal error occurred. Please try again later.")
+			}
+		}()
+		return c.Next()
+	}
+}
@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"encoding"      // For types encoded as JSON strings (e.g. uuid.UUID)
+	"encoding/json" // For decoding response bodies
+	"fmt"           // For drift messages
+	"log"           // For logging drift
+	"reflect"       // For generating schemas from the models
+	"sort"          // For stable drift reports
+	"strings"       // For json tags and route keys
+	"time"          // For date-time fields
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/config"
+)
+
+// Schema is an OpenAPI schema object, generated from the Go type a handler returns as "data".
+type Schema struct {
+	Type                 string             `json:"type,omitempty"` // object, array, string, integer, number, boolean; empty accepts anything
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"` // Value schema of maps
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaFor generates the schema of the JSON encoding of t, following encoding/json rules:
+// omitempty fields are optional, pointers, slices and maps may be null.
+func SchemaFor(t reflect.Type) *Schema {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := *schemaFor(t.Elem(), visiting)
+		schema.Nullable = true
+		return &schema
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), visiting), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), visiting), Nullable: true}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{} // Recursive type (e.g. Participant.Ride.…): nested levels are not checked
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addStructFields(schema, t, visiting)
+		return schema
+	}
+	return &Schema{} // interface{} and anything else encoding/json accepts
+}
+
+// addStructFields adds the JSON fields of struct type t to schema, flattening embedded structs.
+func addStructFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addStructFields(schema, field.Type, visiting)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaFor(field.Type, visiting)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+}
+
+// ValidateValue checks a decoded JSON value against schema and returns one message per drift, e.g.
+// a missing or renamed field, a field the schema doesn't know or a changed type.
+func ValidateValue(schema *Schema, value any) []string {
+	var drift []string
+	validateValue(schema, value, "data", &drift)
+	return drift
+}
+
+func validateValue(schema *Schema, value any, path string, drift *[]string) {
+	if schema.Type == "" {
+		return
+	}
+	if value == nil {
+		if !schema.Nullable {
+			*drift = append(*drift, fmt.Sprintf("%s: null, expected %s", path, schema.Type))
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: %s, expected object", path, jsonType(value)))
+			return
+		}
+		if schema.AdditionalProperties != nil {
+			for key, item := range object {
+				validateValue(schema.AdditionalProperties, item, path+"."+key, drift)
+			}
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				*drift = append(*drift, fmt.Sprintf("%s.%s: missing required field", path, name))
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := schema.Properties[key]
+			if !ok {
+				*drift = append(*drift, fmt.Sprintf("%s.%s: field not in schema", path, key))
+				continue
+			}
+			validateValue(property, object[key], path+"."+key, drift)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: %s, expected array", path, jsonType(value)))
+			return
+		}
+		for i, item := range items {
+			validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), drift)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			*drift = append(*drift, fmt.Sprintf("%s: %s, expected integer", path, jsonType(value)))
+		}
+	default:
+		if actual := jsonType(value); actual != schema.Type && !(schema.Type == "number" && actual == "integer") {
+			*drift = append(*drift, fmt.Sprintf("%s: %s, expected %s", path, actual, schema.Type))
+		}
+	}
+}
+
+// jsonType names the JSON type of a value decoded by encoding/json.
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
+
+// ValidateResponses checks the "data" of successful JSON responses against the schema of their
+// route (contracts is keyed by "METHOD /route/pattern" and holds a value of the returned type).
+// Drift is logged; in fail mode the response is also replaced with a 500, so a service change that
+// alters a shape the mobile app relies on breaks tests and QA instead of the app.
+// Meant for dev and staging (RESPONSE_VALIDATION): it decodes every checked response.
+func ValidateResponses(mode string, contracts map[string]any) fiber.Handler {
+	schemas := make(map[string]*Schema, len(contracts))
+	for route, example := range contracts {
+		schemas[route] = SchemaFor(reflect.TypeOf(example))
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err // Error responses use the standard error envelope, not the route's schema
+		}
+		status := c.Response().StatusCode()
+		if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			return nil
+		}
+		path := c.Route().Path
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/") // Group roots are registered as "/rides/"
+		}
+		route := c.Method() + " " + path
+		schema, ok := schemas[route]
+		if !ok {
+			return nil
+		}
+
+		var envelope struct {
+			Data any `json:"data"`
+		}
+		if err := json.Unmarshal(c.Response().Body(), &envelope); err != nil {
+			log.Printf("Response contract: %s returned a body that is not JSON: %v", route, err)
+			return nil
+		}
+		drift := ValidateValue(schema, envelope.Data)
+		if len(drift) == 0 {
+			return nil
+		}
+		log.Printf("Response contract drift on %s (%d issue(s)): %s", route, len(drift), strings.Join(drift, "; "))
+		if mode == config.ResponseValidationFail {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Response does not match its schema",
+				"data":    fiber.Map{"route": route, "drift": drift},
+			})
+		}
+		return nil
+	}
+}
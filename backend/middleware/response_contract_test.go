@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"rideshare/backend/models"
+)
+
+func TestValidateValueDetectsDrift(t *testing.T) {
+	schema := SchemaFor(reflect.TypeOf(models.JoinRideResponse{}))
+
+	var valid any
+	if err := json.Unmarshal([]byte(`{"participation_id":"6c5c1f5e-8d4e-4a43-9a55-3f0b2e7f9a10","ride_id":"0f8fad5b-d9cb-469f-a165-70867728950e","user_id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","status":"pending_payment","boarding_stop":0,"alighting_stop":1,"seat_count":1,"message":"ok"}`), &valid); err != nil {
+		t.Fatal(err)
+	}
+	if drift := ValidateValue(schema, valid); len(drift) != 0 {
+		t.Errorf("unexpected drift: %v", drift)
+	}
+
+	var drifted any
+	if err := json.Unmarshal([]byte(`{"participation_id":"x","ride_id":"y","user_id":"z","status":"active","boarding_stop":"0","alighting_stop":1,"seat_count":1,"message":"ok","seat":2}`), &drifted); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"data.boarding_stop: string, expected integer", "data.seat: field not in schema"}
+	if drift := ValidateValue(schema, drifted); !reflect.DeepEqual(drift, want) {
+		t.Errorf("drift = %v, want %v", drift, want)
+	}
+}
+
+func TestSchemaForOptionalAndNullableFields(t *testing.T) {
+	schema := SchemaFor(reflect.TypeOf(models.Ride{}))
+	for _, name := range schema.Required {
+		if name == "min_age" || name == "stops" {
+			t.Errorf("omitempty field %s is required", name)
+		}
+	}
+	if !schema.Properties["departure_coords"].Nullable {
+		t.Error("pointer field departure_coords should be nullable")
+	}
+	if got := schema.Properties["departure_date"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("departure_date schema = %+v, want date-time string", got)
+	}
+	if got := schema.Properties["id"]; got.Type != "string" {
+		t.Errorf("id schema = %+v, want string", got)
+	}
+}
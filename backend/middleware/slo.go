@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strings" // For route matching
+	"time"    // For request durations
+
+	"github.com/gofiber/fiber/v2"
+
+	"rideshare/backend/services"
+)
+
+// routeGroup maps a matched route pattern to its SLO group, so a slow search (rides)
+// and a failing payment (payments) burn different error budgets.
+func routeGroup(route string) string {
+	switch {
+	case route == "/api/v1/stripe-webhook":
+		return "webhook"
+	case strings.HasPrefix(route, "/api/v1/payments"),
+		strings.HasSuffix(route, "/join-automatic"),
+		strings.HasSuffix(route, "/create-payment-intent"):
+		return "payments"
+	case strings.HasPrefix(route, "/api/v1/auth"):
+		return "auth"
+	case strings.HasPrefix(route, "/api/v1/rides"), strings.HasPrefix(route, "/api/v1/users/me/rides"):
+		return "rides"
+	}
+	return "other"
+}
+
+// TrackSLO records every request's route group, status code and latency.
+// Register it first: it runs the error handler itself so it sees the final status code.
+func TrackSLO(tracker *services.SLOTracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		tracker.Record(routeGroup(c.Route().Path), c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// TermsChecker reports the mandatory legal documents a user has not accepted yet.
+type TermsChecker interface {
+	PendingDocuments(ctx context.Context, userID uuid.UUID) ([]models.LegalDocument, error)
+}
+
+// termsExempt reports whether a request is allowed before the current terms are accepted:
+// reading and accepting them, and deleting the account.
+func termsExempt(c *fiber.Ctx) bool {
+	path := c.Path()
+	return strings.HasPrefix(path, "/api/v1/legal/") ||
+		(c.Method() == fiber.MethodDelete && path == "/api/v1/users/account")
+}
+
+// requireAcceptedTerms writes a 451 listing the documents to accept if the user has not accepted
+// the current mandatory versions. Returns false if the request may proceed. Lookup failures are
+// logged and let the request through, so a legal documents outage never takes the API down.
+func requireAcceptedTerms(c *fiber.Ctx, checker TermsChecker, userID uuid.UUID) (bool, error) {
+	if checker == nil || termsExempt(c) {
+		return false, nil
+	}
+	pending, err := checker.PendingDocuments(c.Context(), userID)
+	if err != nil {
+		log.Printf("Terms Middleware: Failed checking accepted documents for user %s, allowing: %v", userID, err)
+		return false, nil
+	}
+	if len(pending) == 0 {
+		return false, nil
+	}
+	log.Printf("Terms Middleware: User %s must accept %d updated document(s) (%s %s)", userID, len(pending), c.Method(), c.Path())
+	return true, c.Status(fiber.StatusUnavailableForLegalReasons).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Please review and accept the updated terms to continue",
+		"data":    fiber.Map{"reaccept_required": true, "documents": pending},
+	})
+}
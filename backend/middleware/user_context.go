@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log" // For logging
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// userIDKey is the Locals key under which Protected stores the authenticated user's uuid.UUID.
+const userIDKey = "userID"
+
+// setCurrentUserID stores the authenticated user for the rest of the request. Only Protected calls it,
+// so Locals("userID") is always a uuid.UUID when set.
+func setCurrentUserID(c *fiber.Ctx, userID uuid.UUID) {
+	c.Locals(userIDKey, userID)
+}
+
+// CurrentUserID returns the authenticated user's ID, set by Protected.
+// On a route without Protected it returns a 401 *fiber.Error, which handlers return as-is.
+func CurrentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, ok := c.Locals(userIDKey).(uuid.UUID)
+	if !ok {
+		log.Printf("Error: No authenticated user in context for %s %s (route missing auth middleware?)", c.Method(), c.Path())
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Unauthorized: Missing user identification")
+	}
+	return userID, nil
+}
+
+// OptionalUserID returns the authenticated user's ID on routes where authentication is optional
+// (see OptionalAuth), and false for visitors.
+func OptionalUserID(c *fiber.Ctx) (uuid.UUID, bool) {
+	userID, ok := c.Locals(userIDKey).(uuid.UUID)
+	return userID, ok
+}
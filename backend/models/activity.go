@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityType identifies an entry of the user's activity feed.
+type ActivityType string
+
+const (
+	ActivityRideCreated      ActivityType = "ride_created"      // The user published a ride
+	ActivityRideCancelled    ActivityType = "ride_cancelled"    // The user cancelled their own ride
+	ActivityRideJoined       ActivityType = "ride_joined"       // The user booked a seat
+	ActivityRideLeft         ActivityType = "ride_left"         // The user left a ride they had joined
+	ActivityPaymentSucceeded ActivityType = "payment_succeeded" // A seat payment went through
+	ActivityPaymentRefunded  ActivityType = "payment_refunded"  // A seat payment was fully refunded
+	ActivityNotification     ActivityType = "notification"      // Something that happened to the user (see Event)
+)
+
+// ActivityItem is one entry of GET /users/me/activity, newest first.
+type ActivityItem struct {
+	Type       ActivityType       `json:"type"`
+	Event      *NotificationEvent `json:"event,omitempty"` // Notification entries: what happened (e.g. ride_cancelled by the driver)
+	OccurredAt time.Time          `json:"occurred_at"`
+	RideID     *uuid.UUID         `json:"ride_id,omitempty"`
+	Route      *string            `json:"route,omitempty"`    // "Departure → Arrival" of the related ride
+	Amount     *int64             `json:"amount,omitempty"`   // Payment entries, in cents
+	Currency   *string            `json:"currency,omitempty"` // Payment entries
+	Title      *string            `json:"title,omitempty"`    // Notification entries: text the user was sent
+	Body       *string            `json:"body,omitempty"`
+}
+
+// ActivityRequest defines the pagination of GET /users/me/activity.
+type ActivityRequest struct {
+	Page  *int `query:"page" validate:"omitempty,min=1"`
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=100"` // Default 20
+}
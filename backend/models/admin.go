@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit log actions.
+const (
+	AuditActionImpersonationStart     = "impersonation.start"           // An admin issued an impersonation token
+	AuditActionImpersonatedRequest    = "impersonated.request"          // A request made with an impersonation token
+	AuditActionUserViewed             = "admin.user.view"               // An admin opened a user's detail view
+	AuditActionRideCancelled          = "admin.ride.cancel"             // An admin force-cancelled a ride
+	AuditActionRideEdited             = "admin.ride.edit"               // An admin corrected ride data
+	AuditActionPaymentRefunded        = "admin.payment.refund"          // An admin refunded a payment
+	AuditActionFraudRuleUpdated       = "admin.fraud_rule.update"       // An admin changed a fraud rule
+	AuditActionFraudFlagResolved      = "admin.fraud_flag.resolve"      // An admin closed a fraud review
+	AuditActionModerationFlagResolved = "admin.moderation_flag.resolve" // An admin closed a moderation review
+	AuditActionQuotaOverridden        = "admin.quota.override"          // An admin set a per-user quota override
+	AuditActionQuotaOverrideRemoved   = "admin.quota.override_remove"   // An admin removed a per-user quota override
+	AuditActionAgeOverrideGranted     = "admin.age_override.grant"      // An admin let a minor join rides under guardianship
+	AuditActionAgeOverrideRemoved     = "admin.age_override.remove"     // An admin withdrew a guardianship override
+	AuditActionConfigViewed           = "admin.config.view"             // An admin dumped the sanitized configuration
+	AuditActionAPIKeyCreated          = "admin.api_key.create"          // An admin issued a public API key
+	AuditActionAPIKeyRevoked          = "admin.api_key.revoke"          // An admin revoked a public API key
+	AuditActionBulkJobQueued          = "admin.job.queue"               // An admin queued a bulk ride operation
+	AuditActionFinanceExportCreated   = "admin.finance_export.create"   // An admin requested an accounting export
+	AuditActionFinanceExportFetched   = "admin.finance_export.download" // An admin downloaded an accounting export
+	AuditActionInviteCodesMinted      = "admin.invite_code.mint"        // An admin minted signup invite codes
+	AuditActionInviteCodeRevoked      = "admin.invite_code.revoke"      // An admin revoked a signup invite code
+)
+
+// AuditLogEntry represents a row of the 'audit_logs' table.
+type AuditLogEntry struct {
+	ID                 uuid.UUID              `json:"id" db:"id"`
+	ActorID            *uuid.UUID             `json:"actor_id,omitempty" db:"actor_id"`                         // Admin who acted
+	ImpersonatedUserID *uuid.UUID             `json:"impersonated_user_id,omitempty" db:"impersonated_user_id"` // User acted on behalf of
+	Action             string                 `json:"action" db:"action"`
+	TargetType         string                 `json:"target_type,omitempty" db:"target_type"`
+	TargetID           string                 `json:"target_id,omitempty" db:"target_id"`
+	Method             string                 `json:"method,omitempty" db:"method"`
+	Path               string                 `json:"path,omitempty" db:"path"`
+	StatusCode         int                    `json:"status_code,omitempty" db:"status_code"`
+	IPAddress          string                 `json:"ip_address,omitempty" db:"ip_address"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt          time.Time              `json:"created_at" db:"created_at"`
+}
+
+// ImpersonateRequest defines the structure for starting an impersonation session.
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"` // Why support needs to act as the user (e.g. ticket reference)
+}
+
+// ImpersonationResponse is returned when an admin starts impersonating a user.
+type ImpersonationResponse struct {
+	Token          string    `json:"token"`           // Short-lived JWT acting as the user
+	ExpiresAt      time.Time `json:"expires_at"`      // Token expiry
+	UserID         uuid.UUID `json:"user_id"`         // Impersonated user
+	ImpersonatorID uuid.UUID `json:"impersonator_id"` // Admin who requested the token
+}
+
+// AdminUserSummary is a user row in admin search results.
+type AdminUserSummary struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	FirstName *string    `json:"first_name,omitempty"`
+	LastName  *string    `json:"last_name,omitempty"`
+	WhatsApp  string     `json:"whatsapp"`
+	IsAdmin   bool       `json:"is_admin"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Soft-deleted accounts are included for support
+	Score     float64    `json:"score"`                // Match similarity (0-1), exact substring matches rank first
+}
+
+// AdminRideSummary is a ride created by the user, as shown in the admin detail view.
+type AdminRideSummary struct {
+	ID            uuid.UUID `json:"id"`
+	Route         string    `json:"route"` // "Departure → Arrival"
+	DepartureDate time.Time `json:"departure_date"`
+	DepartureTime string    `json:"departure_time"`
+	Status        string    `json:"status"`
+	TotalSeats    int       `json:"total_seats"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AdminParticipationSummary is a ride the user joined, as shown in the admin detail view.
+type AdminParticipationSummary struct {
+	ID            uuid.UUID `json:"id"`
+	RideID        uuid.UUID `json:"ride_id"`
+	Route         string    `json:"route"`
+	DepartureDate time.Time `json:"departure_date"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AdminDevice is a device registered for push notifications.
+type AdminDevice struct {
+	Platform  string `json:"platform"`   // Push provider (currently always "expo")
+	PushToken string `json:"push_token"` // Registered push token
+}
+
+// AdminUserDetail aggregates everything support needs about one user.
+type AdminUserDetail struct {
+	User             AdminUserSummary            `json:"user"`
+	BirthDate        *time.Time                  `json:"birth_date,omitempty"`
+	Nationality      *string                     `json:"nationality,omitempty"`
+	PreferredLocale  string                      `json:"preferred_locale"`
+	StripeCustomerID *string                     `json:"stripe_customer_id,omitempty"`
+	Rides            []AdminRideSummary          `json:"rides"`          // Most recent rides created
+	Participations   []AdminParticipationSummary `json:"participations"` // Most recent rides joined
+	Payments         []Payment                   `json:"payments"`       // Most recent payments
+	Devices          []AdminDevice               `json:"devices"`
+	AuditEntries     []AuditLogEntry             `json:"audit_entries"` // Most recent audit entries involving the user
+	AgeOverride      *AgeOverride                `json:"age_override,omitempty"`
+}
+
+// AgeOverride lets a user below MIN_JOIN_AGE join rides, e.g. when a guardian has given consent.
+// It does not lift the minimum age a creator sets on a ride.
+type AgeOverride struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	Reason    string     `json:"reason"`
+	GrantedBy *uuid.UUID `json:"granted_by,omitempty"`
+	GrantedAt time.Time  `json:"granted_at"`
+}
+
+// SetAgeOverrideRequest is the body of PUT /admin/users/:userId/age-override.
+type SetAgeOverrideRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"` // e.g. how the guardian's consent was verified
+}
+
+// AdminCancelRideRequest defines the structure for force-cancelling a ride.
+type AdminCancelRideRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"` // Why the ride is cancelled (e.g. driver unreachable)
+	Force  bool   `json:"force"`                            // Also cancel departed or archived rides
+}
+
+// AdminEditRideRequest defines the ride fields an admin may correct. Only provided fields are updated.
+type AdminEditRideRequest struct {
+	DepartureLocationName *string `json:"departure_location_name,omitempty" validate:"omitempty,min=2"`
+	ArrivalLocationName   *string `json:"arrival_location_name,omitempty" validate:"omitempty,min=2"`
+	Reason                string  `json:"reason" validate:"required,min=5"` // Why the data is corrected
+}
+
+// AdminPaymentFilter defines the query parameters of the admin payments list.
+type AdminPaymentFilter struct {
+	Status                *string `query:"status" validate:"omitempty,oneof=pending succeeded failed refunded"`
+	From                  *string `query:"from" validate:"omitempty,datetime=2006-01-02"` // Created on or after (YYYY-MM-DD)
+	To                    *string `query:"to" validate:"omitempty,datetime=2006-01-02"`   // Created on or before (YYYY-MM-DD)
+	MinAmount             *int64  `query:"min_amount" validate:"omitempty,min=0"`         // Smallest currency unit
+	MaxAmount             *int64  `query:"max_amount" validate:"omitempty,min=0"`
+	StripePaymentIntentID *string `query:"stripe_payment_intent_id"` // Exact Stripe PaymentIntent ID (pi_...)
+	UserID                *string `query:"user_id" validate:"omitempty,uuid"`
+	RideID                *string `query:"ride_id" validate:"omitempty,uuid"`
+	Page                  *int    `query:"page" validate:"omitempty,min=1"`
+	Limit                 *int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// AdminPayment is a payment with its linked user, ride and participation, as shown to admins.
+type AdminPayment struct {
+	Payment
+	UserEmail           string     `json:"user_email"`
+	RideRoute           *string    `json:"ride_route,omitempty"` // NULL if the ride was deleted
+	RideStatus          *string    `json:"ride_status,omitempty"`
+	RideDepartureDate   *time.Time `json:"ride_departure_date,omitempty"`
+	ParticipantStatus   *string    `json:"participant_status,omitempty"`
+	RefundableRemaining int64      `json:"refundable_remaining"` // Amount still refundable (0 unless succeeded)
+}
+
+// AdminRefundRequest defines the structure for an admin-triggered refund.
+type AdminRefundRequest struct {
+	Amount *int64 `json:"amount,omitempty" validate:"omitempty,min=1"` // Defaults to the full remaining amount
+	Reason string `json:"reason" validate:"required,min=5"`
+}
+
+// AdminRefundResponse is returned after an admin-triggered refund.
+type AdminRefundResponse struct {
+	PaymentID      uuid.UUID `json:"payment_id"`
+	RefundedAmount int64     `json:"refunded_amount"` // Amount refunded by this request
+	Payment        Payment   `json:"payment"`         // Payment after the refund
+}
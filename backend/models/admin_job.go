@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminJobKind identifies a bulk admin operation.
+type AdminJobKind string
+
+const (
+	AdminJobCancelUserRides AdminJobKind = "cancel_user_rides" // Cancel (and refund) every upcoming ride of a user
+	AdminJobArchiveRides    AdminJobKind = "archive_rides"     // Archive departed rides in a date range
+)
+
+// AdminJobStatus is the state of a queued admin job.
+type AdminJobStatus string
+
+const (
+	AdminJobQueued    AdminJobStatus = "queued"
+	AdminJobRunning   AdminJobStatus = "running"
+	AdminJobSucceeded AdminJobStatus = "succeeded" // Every ride was processed, possibly with per-ride failures
+	AdminJobFailed    AdminJobStatus = "failed"    // The job stopped before processing every ride
+)
+
+// AdminJob is a bulk operation on rides and its progress (GET /admin/jobs/:jobId).
+type AdminJob struct {
+	ID         uuid.UUID      `json:"id"`
+	Kind       AdminJobKind   `json:"kind"`
+	Params     AdminJobParams `json:"params"`
+	Status     AdminJobStatus `json:"status"`
+	Total      int            `json:"total"`     // Rides the job applies to, known once it starts
+	Processed  int            `json:"processed"` // Rides done so far, failures included
+	Failed     int            `json:"failed"`
+	LastError  *string        `json:"last_error,omitempty"`
+	CreatedBy  *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// AdminJobParams are the parameters of an admin job, stored as JSON.
+type AdminJobParams struct {
+	UserID *uuid.UUID `json:"user_id,omitempty"` // cancel_user_rides
+	From   string     `json:"from,omitempty"`    // archive_rides: first departure date (YYYY-MM-DD)
+	To     string     `json:"to,omitempty"`      // archive_rides: last departure date (YYYY-MM-DD)
+	Reason string     `json:"reason"`
+}
+
+// BulkCancelUserRidesRequest is the body of POST /admin/users/:userId/rides/cancel.
+type BulkCancelUserRidesRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"` // E.g. the account was suspended
+}
+
+// BulkArchiveRidesRequest is the body of POST /admin/rides/archive.
+type BulkArchiveRidesRequest struct {
+	From   string `json:"from" validate:"required,datetime=2006-01-02"`
+	To     string `json:"to" validate:"required,datetime=2006-01-02"`
+	Reason string `json:"reason" validate:"required,min=5"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnonymousSessionHeader carries the anonymous browsing token of visitors without an account.
+const AnonymousSessionHeader = "X-Anonymous-Session"
+
+// AnonymousSessionResponse returns a new anonymous browsing token.
+type AnonymousSessionResponse struct {
+	SessionID uuid.UUID `json:"session_id"`
+	Token     string    `json:"token"`      // Sent back in the X-Anonymous-Session header, and at signup to keep recent searches
+	ExpiresAt time.Time `json:"expires_at"` // ANONYMOUS_SESSION_TTL after creation
+}
+
+// RecentSearch represents a row of the 'recent_searches' table.
+type RecentSearch struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Query     string    `json:"query" db:"query"` // Query string of GET /rides/search, without pagination
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ConsentType is a kind of data processing users opt in to.
+type ConsentType string
+
+const (
+	ConsentMarketingEmails ConsentType = "marketing_emails" // Promotional emails (email category "marketing")
+	ConsentLocationStorage ConsentType = "location_storage" // Keeping the user's last known location (PUT /users/location)
+	ConsentAnalytics       ConsentType = "analytics"        // Product analytics events tied to the account
+)
+
+// ConsentTypes lists every consent shown in the privacy settings.
+var ConsentTypes = []ConsentType{ConsentMarketingEmails, ConsentLocationStorage, ConsentAnalytics}
+
+// Consent is the state of one consent for a user (GET /users/me/consents).
+type Consent struct {
+	Type        ConsentType `json:"type"`
+	Granted     bool        `json:"granted"` // False when never given
+	GrantedAt   *time.Time  `json:"granted_at,omitempty"`
+	WithdrawnAt *time.Time  `json:"withdrawn_at,omitempty"`
+}
+
+// UpdateConsentRequest is the body of PUT /users/me/consents/:type.
+type UpdateConsentRequest struct {
+	Granted *bool `json:"granted" validate:"required"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// DriverDashboard is the data of GET /users/me/driver-dashboard: everything the app's driver home
+// screen shows, in one call. Amounts are in cents of Currency.
+type DriverDashboard struct {
+	UpcomingRides       []DashboardRide `json:"upcoming_rides"`       // Active rides that haven't departed, soonest first
+	PendingRequests     int             `json:"pending_requests"`     // Over all upcoming rides
+	UnreadNotifications int             `json:"unread_notifications"` // All of the driver's unread notifications
+	ExpectedEarnings    int64           `json:"expected_earnings"`    // Over all upcoming rides
+	Currency            string          `json:"currency"`
+}
+
+// DashboardRide is one upcoming ride on the driver dashboard.
+type DashboardRide struct {
+	Ride
+	FillRate            float64 `json:"fill_rate"`            // Share of seats taken (0-1)
+	PendingRequests     int     `json:"pending_requests"`     // Joiners who haven't paid yet or whose booking is on hold
+	UnreadNotifications int     `json:"unread_notifications"` // The driver's unread notifications about this ride
+	ExpectedEarnings    int64   `json:"expected_earnings"`    // Paid seats net of refunds, plus held seats at the seat price
+}
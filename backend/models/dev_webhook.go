@@ -0,0 +1,10 @@
+package models
+
+// DevWebhookDelivery is the outcome of a webhook event signed and posted to the local server by
+// the development webhook tool.
+type DevWebhookDelivery struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	StatusCode int    `json:"status_code"` // Status returned by POST /api/v1/stripe-webhook
+	Response   string `json:"response"`    // Response body, truncated
+}
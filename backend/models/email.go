@@ -0,0 +1,26 @@
+package models
+
+// EmailCategory groups non-critical emails that users can opt out of.
+// Critical transactional emails (seat confirmations, cancellations) have no category and are always sent.
+type EmailCategory string
+
+const (
+	EmailCategoryRideActivity EmailCategory = "ride_activity" // Activity on rides the user created (new passengers, ...)
+	EmailCategoryMarketing    EmailCategory = "marketing"     // Promotions; only sent with the marketing_emails consent
+)
+
+// EmailCategories lists every category shown in the email preference center.
+// Marketing is not listed: it is opt-in through the marketing_emails consent.
+var EmailCategories = []EmailCategory{EmailCategoryRideActivity}
+
+// EmailCategoryPreference is one entry of the email preference center.
+type EmailCategoryPreference struct {
+	Category   EmailCategory `json:"category"`
+	Subscribed bool          `json:"subscribed"`
+}
+
+// UpdateEmailPreferencesRequest defines the structure for updating email preferences.
+// Keys are email categories, values whether the user wants to receive them.
+type UpdateEmailPreferencesRequest struct {
+	Categories map[EmailCategory]bool `json:"categories" validate:"required,min=1"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Erasure steps recorded on a certificate.
+const (
+	ErasureStepUserProfile    = "user_profile"  // Email, phone, names, birth date, nationality, password, push token, location
+	ErasureStepNotifications  = "notifications" // In-app notifications received
+	ErasureStepEmailPrefs     = "email_preferences"
+	ErasureStepFraudSignals   = "fraud_signals"   // IP addresses, payment methods and locations on fraud events and flags
+	ErasureStepProfileHistory = "profile_history" // Past profile values and request IPs
+	ErasureStepStripeCustomer = "stripe_customer" // Stripe Customer deleted (saved cards detached)
+)
+
+// ErasureCertificate represents a row of the 'erasure_certificates' table.
+type ErasureCertificate struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	RequestedAt time.Time `json:"requested_at" db:"requested_at"` // When the account was deleted
+	ErasedAt    time.Time `json:"erased_at" db:"erased_at"`
+	Steps       []string  `json:"steps" db:"steps"`
+	Digest      string    `json:"digest" db:"digest"` // SHA-256 over the fields above
+}
+
+// AccountDeletionImpact lists what deleting an account does to the user's upcoming rides
+// (GET /users/account/deletion-preview), or what it did once the account is deleted.
+type AccountDeletionImpact struct {
+	CancelledRides         int   `json:"cancelled_rides"`         // Upcoming rides the user created, cancelled
+	AffectedParticipants   int   `json:"affected_participants"`   // Passengers of those rides, notified and refunded in full
+	LeftParticipations     int   `json:"left_participations"`     // Upcoming rides the user joined, left
+	RefundedParticipations int   `json:"refunded_participations"` // Paid joins among them, refunded in full
+	RefundAmount           int64 `json:"refund_amount"`           // Total refunded to the user (smallest currency unit)
+}
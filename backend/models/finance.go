@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FinanceExportFormat is the file format of an accounting export.
+type FinanceExportFormat string
+
+const (
+	FinanceExportCSV   FinanceExportFormat = "csv"
+	FinanceExportJSON  FinanceExportFormat = "json"
+	FinanceExportDATEV FinanceExportFormat = "datev" // DATEV Buchungsstapel (EXTF) CSV
+)
+
+// FinanceEntryType identifies what a journal entry books.
+type FinanceEntryType string
+
+const (
+	FinanceEntryFee    FinanceEntryType = "fee"    // Booking fee collected from a participant
+	FinanceEntryRefund FinanceEntryType = "refund" // Refund of (part of) a booking fee
+)
+
+// FinanceExport is an accounting export and its generation status (GET /admin/finance/exports/:exportId).
+type FinanceExport struct {
+	ID          uuid.UUID           `json:"id"`
+	PeriodStart string              `json:"period_start"` // YYYY-MM-DD
+	PeriodEnd   string              `json:"period_end"`   // YYYY-MM-DD, inclusive
+	Format      FinanceExportFormat `json:"format"`
+	Status      AdminJobStatus      `json:"status"`
+	EntryCount  int                 `json:"entry_count"`
+	LastError   *string             `json:"last_error,omitempty"`
+	CreatedBy   *uuid.UUID          `json:"created_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+}
+
+// FinanceJournalEntry is one booking of an export. Amounts are positive in the smallest currency
+// unit; the type tells whether it is revenue or a refund. Gross = Net + VAT.
+type FinanceJournalEntry struct {
+	Number    int64            `json:"number"` // Stable document number, assigned on first export
+	BookedAt  time.Time        `json:"booked_at"`
+	Type      FinanceEntryType `json:"type"`
+	PaymentID uuid.UUID        `json:"payment_id"`
+	Reference string           `json:"reference"` // Stripe PaymentIntent or refund ID
+	Gross     int64            `json:"gross"`
+	Net       int64            `json:"net"`
+	VAT       int64            `json:"vat"`
+	Currency  string           `json:"currency"`
+}
+
+// CreateFinanceExportRequest is the body of POST /admin/finance/exports.
+type CreateFinanceExportRequest struct {
+	From   string `json:"from" validate:"required,datetime=2006-01-02"`
+	To     string `json:"to" validate:"required,datetime=2006-01-02"` // Inclusive
+	Format string `json:"format" validate:"required,oneof=csv json datev"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FraudEvent is an action evaluated by the fraud rules engine.
+type FraudEvent string
+
+const (
+	FraudEventSignup  FraudEvent = "signup"
+	FraudEventJoin    FraudEvent = "join"
+	FraudEventPayment FraudEvent = "payment"
+)
+
+// FraudRuleType selects how a rule is evaluated.
+type FraudRuleType string
+
+const (
+	FraudRuleVelocity      FraudRuleType = "velocity"       // More than threshold events within the window
+	FraudRuleDistinctCards FraudRuleType = "distinct_cards" // More than threshold distinct payment methods within the window
+	FraudRuleGeoImpossible FraudRuleType = "geo_impossible" // Travel speed since the previous event above threshold km/h
+	FraudRuleCountryChange FraudRuleType = "country_change" // More than threshold distinct IP countries within the window
+)
+
+// FraudAction is the outcome of a triggered rule. Ordered from least to most restrictive.
+type FraudAction string
+
+const (
+	FraudActionAllow FraudAction = "allow"
+	FraudActionFlag  FraudAction = "flag" // Let the action through, queue for review
+	FraudActionHold  FraudAction = "hold" // Keep the booking but don't charge it until reviewed (signups are refused)
+)
+
+// FraudFlag review statuses.
+const (
+	FraudFlagStatusOpen      = "open"
+	FraudFlagStatusCleared   = "cleared"   // Reviewed, not fraud
+	FraudFlagStatusConfirmed = "confirmed" // Reviewed, fraud confirmed
+)
+
+// FraudRulePaymentMismatch names the built-in check flagging payments whose succeeded PaymentIntent
+// doesn't match the stored amount or currency. It isn't a configurable rule.
+const FraudRulePaymentMismatch = "payment_mismatch"
+
+// FraudRule represents a row of the 'fraud_rules' table.
+type FraudRule struct {
+	ID            uuid.UUID     `json:"id" db:"id"`
+	Name          string        `json:"name" db:"name"`
+	Event         FraudEvent    `json:"event" db:"event"`
+	RuleType      FraudRuleType `json:"rule_type" db:"rule_type"`
+	Threshold     float64       `json:"threshold" db:"threshold"`
+	WindowSeconds int           `json:"window_seconds" db:"window_seconds"`
+	Action        FraudAction   `json:"action" db:"action"`
+	Enabled       bool          `json:"enabled" db:"enabled"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// FraudCheck describes the action being evaluated.
+type FraudCheck struct {
+	Event           FraudEvent
+	UserID          *uuid.UUID // Nil for signups
+	RideID          *uuid.UUID // Set for join and payment
+	IPAddress       string
+	PaymentMethodID string // Stripe payment method, for payment events
+}
+
+// FraudDecision is the result of evaluating the rules for a FraudCheck.
+type FraudDecision struct {
+	Action         FraudAction `json:"action"`
+	TriggeredRules []string    `json:"triggered_rules,omitempty"`
+	FlagID         *uuid.UUID  `json:"flag_id,omitempty"`
+}
+
+// FraudFlag represents a row of the 'fraud_flags' table.
+type FraudFlag struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	Event          FraudEvent  `json:"event" db:"event"`
+	UserID         *uuid.UUID  `json:"user_id,omitempty" db:"user_id"`
+	UserEmail      *string     `json:"user_email,omitempty"`
+	RideID         *uuid.UUID  `json:"ride_id,omitempty" db:"ride_id"`
+	IPAddress      *string     `json:"ip_address,omitempty" db:"ip_address"`
+	Action         FraudAction `json:"action" db:"action"`
+	Rules          []string    `json:"rules" db:"rules"`
+	Status         string      `json:"status" db:"status"`
+	ResolvedBy     *uuid.UUID  `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt     *time.Time  `json:"resolved_at,omitempty" db:"resolved_at"`
+	ResolutionNote *string     `json:"resolution_note,omitempty" db:"resolution_note"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+}
+
+// UpdateFraudRuleRequest defines the rule fields an admin may change. Only provided fields are updated.
+type UpdateFraudRuleRequest struct {
+	Threshold     *float64     `json:"threshold,omitempty" validate:"omitempty,gt=0"`
+	WindowSeconds *int         `json:"window_seconds,omitempty" validate:"omitempty,gt=0"`
+	Action        *FraudAction `json:"action,omitempty" validate:"omitempty,oneof=flag hold"`
+	Enabled       *bool        `json:"enabled,omitempty"`
+}
+
+// ResolveFraudFlagRequest defines the structure for closing a review.
+type ResolveFraudFlagRequest struct {
+	Status string `json:"status" validate:"required,oneof=cleared confirmed"`
+	Note   string `json:"note" validate:"required,min=5"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "context"
+
+// GeoContextKey is the request context key (fiber Locals) holding the caller's *GeoLocation.
+const GeoContextKey = "geo"
+
+// GeoLocation is the approximate location of a request, resolved from its IP address.
+type GeoLocation struct {
+	IP          string   `json:"ip"`
+	CountryCode string   `json:"country_code"` // ISO 3166-1 alpha-2, e.g. "FR"
+	Region      string   `json:"region,omitempty"`
+	City        string   `json:"city,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+}
+
+// GeoContextResponse is returned by GET /api/v1/geo so clients can pick sensible defaults.
+type GeoContextResponse struct {
+	CountryCode string `json:"country_code,omitempty"`
+	Region      string `json:"region,omitempty"`
+	City        string `json:"city,omitempty"`
+	Currency    string `json:"currency"`
+	Locale      string `json:"locale"`
+}
+
+// GeoFromContext returns the caller's location stored by the geo middleware, or nil if unknown.
+// Works with the context passed from fiber handlers (c.Context()), which exposes Locals as values.
+func GeoFromContext(ctx context.Context) *GeoLocation {
+	if ctx == nil {
+		return nil
+	}
+	geo, _ := ctx.Value(GeoContextKey).(*GeoLocation)
+	return geo
+}
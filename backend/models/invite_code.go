@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InviteCode represents a row of the 'invite_codes' table: a code letting up to MaxUses people
+// sign up while signups are invite-only, attributing them to a rollout cohort.
+type InviteCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Code      string     `json:"code" db:"code"`
+	Cohort    string     `json:"cohort" db:"cohort"` // e.g. "lyon-beta"
+	MaxUses   int        `json:"max_uses" db:"max_uses"`
+	Uses      int        `json:"uses" db:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// MintInviteCodesRequest defines the body of POST /admin/invite-codes.
+type MintInviteCodesRequest struct {
+	Cohort    string     `json:"cohort" validate:"required,max=64"`
+	Count     int        `json:"count" validate:"omitempty,min=1,max=500"`       // Codes to mint (default 1)
+	MaxUses   int        `json:"max_uses" validate:"omitempty,min=1,max=100000"` // Signups allowed per code (default 1)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`                           // Optional expiry, in the future
+}
+
+// ListInviteCodesRequest defines the query parameters of GET /admin/invite-codes.
+type ListInviteCodesRequest struct {
+	Cohort *string `query:"cohort"`                                   // Optional: only this cohort's codes
+	Page   *int    `query:"page" validate:"omitempty,min=1"`          // 1-based
+	Limit  *int    `query:"limit" validate:"omitempty,min=1,max=500"` // Default 100
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LegalDocumentKind is the kind of a versioned legal document.
+type LegalDocumentKind string
+
+const (
+	LegalDocumentTerms   LegalDocumentKind = "terms"   // Terms of service
+	LegalDocumentPrivacy LegalDocumentKind = "privacy" // Privacy policy
+)
+
+// LegalDocument is one version of the terms of service or privacy policy (see the legal_documents table).
+type LegalDocument struct {
+	ID          uuid.UUID         `json:"id"`
+	Kind        LegalDocumentKind `json:"kind"`
+	Version     string            `json:"version"`
+	Title       string            `json:"title"`
+	Content     string            `json:"content,omitempty"` // Markdown; omitted when only listing versions to accept
+	Mandatory   bool              `json:"mandatory"`         // Must be accepted before the API can be used again
+	PublishedAt time.Time         `json:"published_at"`
+}
+
+// AcceptLegalDocumentsRequest is the body of POST /legal/accept.
+type AcceptLegalDocumentsRequest struct {
+	DocumentIDs []uuid.UUID `json:"document_ids" validate:"required,min=1,max=10"` // Current document versions being accepted
+}
+
+// LegalAcceptance records that a user accepted a document version.
+type LegalAcceptance struct {
+	DocumentID uuid.UUID         `json:"document_id"`
+	Kind       LegalDocumentKind `json:"kind"`
+	Version    string            `json:"version"`
+	AcceptedAt time.Time         `json:"accepted_at"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationAction is the outcome of moderating a piece of user-written text.
+type ModerationAction string
+
+const (
+	ModerationActionAllow ModerationAction = "allow"
+	ModerationActionFlag  ModerationAction = "flag"  // Accept the text, queue it for review
+	ModerationActionBlock ModerationAction = "block" // Refuse the text
+)
+
+// ModerationContentType identifies where moderated text comes from.
+type ModerationContentType string
+
+const (
+	ModerationContentRemovalReason ModerationContentType = "removal_reason" // Reason shown to a participant removed by the creator
+	ModerationContentPickupNote    ModerationContentType = "pickup_note"    // Participant's note shown to the driver and the other travellers
+	ModerationContentReview        ModerationContentType = "review"         // Review comment shown on the reviewed user's profile
+)
+
+// Moderation reasons, kept with flagged content so reviewers can see why it was caught.
+const (
+	ModerationReasonProfanity      = "profanity"       // Matches the blocked words list
+	ModerationReasonContactDetails = "contact_details" // Phone number, email or messaging link (contact harvesting)
+	ModerationReasonLink           = "link"            // URL in the text
+	ModerationReasonExternal       = "external"        // Flagged by the external moderation API
+)
+
+// ModerationFlag review statuses.
+const (
+	ModerationFlagStatusOpen      = "open"
+	ModerationFlagStatusDismissed = "dismissed" // Reviewed, content is acceptable
+	ModerationFlagStatusConfirmed = "confirmed" // Reviewed, content is abusive
+)
+
+// ModerationCheck describes the text being moderated.
+type ModerationCheck struct {
+	UserID      uuid.UUID             // Author
+	ContentType ModerationContentType // Where the text is used
+	RideID      *uuid.UUID            // Ride the text relates to, if any
+	Text        string
+}
+
+// ModerationDecision is the result of moderating a ModerationCheck.
+type ModerationDecision struct {
+	Action  ModerationAction `json:"action"`
+	Reasons []string         `json:"reasons,omitempty"`
+}
+
+// ModerationFlag represents a row of the 'moderation_flags' table.
+type ModerationFlag struct {
+	ID             uuid.UUID             `json:"id" db:"id"`
+	UserID         *uuid.UUID            `json:"user_id,omitempty" db:"user_id"`
+	UserEmail      *string               `json:"user_email,omitempty"`
+	ContentType    ModerationContentType `json:"content_type" db:"content_type"`
+	RideID         *uuid.UUID            `json:"ride_id,omitempty" db:"ride_id"`
+	Content        string                `json:"content" db:"content"`
+	Action         ModerationAction      `json:"action" db:"action"`
+	Reasons        []string              `json:"reasons" db:"reasons"`
+	Status         string                `json:"status" db:"status"`
+	ResolvedBy     *uuid.UUID            `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt     *time.Time            `json:"resolved_at,omitempty" db:"resolved_at"`
+	ResolutionNote *string               `json:"resolution_note,omitempty" db:"resolution_note"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+}
+
+// ResolveModerationFlagRequest defines the structure for closing a moderation review.
+type ResolveModerationFlagRequest struct {
+	Status string `json:"status" validate:"required,oneof=dismissed confirmed"`
+	Note   string `json:"note" validate:"required,min=5"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEvent identifies what happened, and therefore how the app should route a tap.
+type NotificationEvent string
+
+const (
+	NotificationEventJoinConfirmed        NotificationEvent = "join_confirmed"         // Sent to a participant once their payment succeeded
+	NotificationEventParticipantJoined    NotificationEvent = "participant_joined"     // Sent to the creator when a seat is taken
+	NotificationEventParticipantLeft      NotificationEvent = "participant_left"       // Sent to the creator when a participant leaves
+	NotificationEventRideCancelled        NotificationEvent = "ride_cancelled"         // Sent to participants when the creator cancels
+	NotificationEventParticipantRemoved   NotificationEvent = "participant_removed"    // Sent to a participant the creator removed
+	NotificationEventRideTransferProposed NotificationEvent = "ride_transfer_proposed" // Sent to the user asked to take over a ride
+	NotificationEventRideTransferred      NotificationEvent = "ride_transferred"       // Sent to the previous creator and participants once a transfer is accepted
+	NotificationEventRideTransferDeclined NotificationEvent = "ride_transfer_declined" // Sent to the creator when the proposed driver declines
+	NotificationEventReviewReceived       NotificationEvent = "review_received"        // Sent to a user reviewed after a ride
+	NotificationEventRideInvitation       NotificationEvent = "ride_invitation"        // Sent to a user the creator invited to their ride
+	NotificationEventAutoJoinRefunded     NotificationEvent = "auto_join_refunded"     // Sent when an automatic join was charged but couldn't be confirmed
+	NotificationEventSavedSearchMatch     NotificationEvent = "saved_search_match"     // Sent when a new ride matches one of the user's saved searches
+	NotificationEventDepartureCheckin     NotificationEvent = "departure_checkin"      // Sent to the driver and passengers on the morning of departure
+)
+
+// PushPriority mirrors the priority values accepted by the Expo push API.
+type PushPriority string
+
+const (
+	PushPriorityDefault PushPriority = "default"
+	PushPriorityHigh    PushPriority = "high"
+)
+
+// PushPayload is the typed 'data' object delivered with every push notification.
+// The mobile app reads it to navigate to the right screen when the user taps.
+type PushPayload struct {
+	Event       NotificationEvent `json:"event"`                  // Event type
+	RideID      *uuid.UUID        `json:"ride_id,omitempty"`      // Related ride, if any
+	Screen      string            `json:"screen"`                 // App route to open (e.g. RideDetails)
+	CollapseKey string            `json:"collapse_key,omitempty"` // Notifications sharing a key replace each other on the device
+	Priority    PushPriority      `json:"priority"`               // Delivery priority for this event type
+}
+
+// Notification represents a row of the 'notifications' table.
+type Notification struct {
+	ID        uuid.UUID         `json:"id" db:"id"`
+	UserID    uuid.UUID         `json:"user_id" db:"user_id"`       // Recipient
+	EventType NotificationEvent `json:"event_type" db:"event_type"` // Event type
+	RideID    *uuid.UUID        `json:"ride_id,omitempty" db:"ride_id"`
+	Title     string            `json:"title" db:"title"`
+	Body      string            `json:"body" db:"body"`
+	Payload   PushPayload       `json:"payload" db:"payload"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	ReadAt    *time.Time        `json:"read_at,omitempty" db:"read_at"`
+}
@@ -11,10 +11,11 @@ import (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "pending"   // Initial status before Stripe confirmation
-	PaymentStatusSucceeded PaymentStatus = "succeeded" // Payment confirmed by Stripe webhook
-	PaymentStatusFailed    PaymentStatus = "failed"    // Payment failed according to Stripe webhook
-	// TransactionStatusRefunded is removed as it's not in the simplified V2 status list
+	PaymentStatusPending     PaymentStatus = "pending"      // Initial status before Stripe confirmation
+	PaymentStatusSucceeded   PaymentStatus = "succeeded"    // Payment confirmed by Stripe webhook
+	PaymentStatusFailed      PaymentStatus = "failed"       // Payment failed according to Stripe webhook
+	PaymentStatusRefunded    PaymentStatus = "refunded"     // Payment fully refunded (partial refunds keep 'succeeded')
+	PaymentStatusUnderReview PaymentStatus = "under_review" // Stripe confirmed another amount or currency, held for admin review
 )
 
 // Payment represents the structure for the 'payments' table (renamed from 'transactions').
@@ -27,6 +28,7 @@ type Payment struct {
 	Status                PaymentStatus `json:"status" db:"status"`                                     // Use new type
 	Amount                int64         `json:"amount" db:"amount"`                                     // Amount in smallest currency unit (e.g., cents)
 	Currency              string        `json:"currency" db:"currency"`                                 // 3-letter ISO currency code
+	RefundedAmount        int64         `json:"refunded_amount" db:"refunded_amount"`                   // Amount refunded so far (smallest currency unit)
 	CreatedAt             time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time     `json:"updated_at" db:"updated_at"`
 }
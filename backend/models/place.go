@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PickupPointKind is the type of a curated meeting spot.
+type PickupPointKind string
+
+const (
+	PickupPointParkAndRide PickupPointKind = "park_and_ride"
+	PickupPointStation     PickupPointKind = "station"
+	PickupPointLandmark    PickupPointKind = "landmark"
+)
+
+// PickupPoint represents the structure for the 'pickup_points' table.
+type PickupPoint struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Kind           string    `json:"kind" db:"kind"` // park_and_ride, station, landmark
+	Location       GeoPoint  `json:"location" db:"location"`
+	City           *string   `json:"city,omitempty" db:"city"`
+	DistanceMeters *float64  `json:"distance_meters,omitempty"` // From the searched point, in suggestions only
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// NearbyPickupPointsRequest defines the query parameters for GET /places/pickup-points.
+type NearbyPickupPointsRequest struct {
+	Near         string `query:"near" validate:"required"`                      // "latitude,longitude"
+	RadiusMeters *int   `query:"radius" validate:"omitempty,min=100,max=20000"` // Search radius, default 3 km
+	Limit        *int   `query:"limit" validate:"omitempty,min=1,max=50"`       // Max suggestions, default 10
+}
+
+// CreatePickupPointRequest defines the structure for curating a new pickup point (admin).
+type CreatePickupPointRequest struct {
+	Name     string    `json:"name" validate:"required,max=200"`
+	Kind     string    `json:"kind" validate:"required,oneof=park_and_ride station landmark"`
+	Location *GeoPoint `json:"location" validate:"required"`
+	City     *string   `json:"city,omitempty" validate:"omitempty,max=100"`
+}
+
+// SetRidePickupPointRequest defines the structure for attaching a pickup point to a ride.
+type SetRidePickupPointRequest struct {
+	PickupPointID *uuid.UUID `json:"pickup_point_id"` // null clears the official pickup location
+}
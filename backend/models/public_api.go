@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key scopes of the public data API.
+const (
+	APIScopeRidesRead = "rides:read" // Anonymized active rides
+)
+
+// APIKey represents a row of the 'api_keys' table. The key itself is never stored.
+type APIKey struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	Name               string     `json:"name" db:"name"`
+	KeyPrefix          string     `json:"key_prefix" db:"key_prefix"` // Recognizable start of the key
+	Scopes             []string   `json:"scopes" db:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute" db:"rate_limit_per_minute"`
+	CreatedBy          *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// CreateAPIKeyRequest defines the structure for issuing a public API key.
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name" validate:"required,max=200"`
+	Scopes             []string `json:"scopes" validate:"required,min=1,dive,oneof=rides:read"`
+	RateLimitPerMinute int      `json:"rate_limit_per_minute,omitempty" validate:"omitempty,min=1,max=6000"` // Default 60
+}
+
+// CreateAPIKeyResponse returns a new key. Key is only available in this response.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// PublicRidesRequest defines the query parameters of the public rides endpoint.
+type PublicRidesRequest struct {
+	City          string  `query:"city" validate:"required,min=2,max=100"`                  // Matches the departure or arrival location
+	DepartureDate *string `query:"departure_date" validate:"omitempty,datetime=2006-01-02"` // Optional date filter (YYYY-MM-DD)
+}
+
+// PublicRide is an anonymized active ride. It carries no identifiers, names or exact addresses:
+// coordinates are rounded to about 1 km and the departure time to the hour.
+type PublicRide struct {
+	DepartureArea  GeoPoint `json:"departure_area"`
+	ArrivalArea    GeoPoint `json:"arrival_area"`
+	DepartureDate  string   `json:"departure_date"` // YYYY-MM-DD
+	DepartureHour  int      `json:"departure_hour"` // 0-23
+	TotalSeats     int      `json:"total_seats"`
+	SeatsAvailable int      `json:"seats_available"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuotaName identifies a per-account abuse quota.
+type QuotaName string
+
+const (
+	QuotaRidesCreated QuotaName = "rides_created" // Rides created per day
+	QuotaRideJoins    QuotaName = "ride_joins"    // Ride joins per hour
+)
+
+// QuotaStatus reports a user's usage of a quota.
+type QuotaStatus struct {
+	Quota         QuotaName  `json:"quota"`
+	Limit         *int       `json:"limit"` // nil = unlimited
+	Used          int        `json:"used"`
+	WindowSeconds int        `json:"window_seconds"`
+	Overridden    bool       `json:"overridden"` // Limit comes from an admin override
+	OverrideUntil *time.Time `json:"override_until,omitempty"`
+}
+
+// SetQuotaOverrideRequest defines the structure for granting a user a different quota limit.
+type SetQuotaOverrideRequest struct {
+	Limit     *int       `json:"limit" validate:"omitempty,min=0"` // Omit or null for unlimited
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`             // Omit for no expiry
+	Reason    string     `json:"reason" validate:"required,min=5"`
+}
+
+// QuotaOverride represents a row of the 'user_quota_overrides' table.
+type QuotaOverride struct {
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Quota     QuotaName  `json:"quota" db:"quota"`
+	Limit     *int       `json:"limit" db:"limit_value"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RateLimitStatus reports a caller's request budget in the current rate limit window.
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time // When the window ends and the budget is restored
+	Allowed   bool      // False if the request was over the limit
+}
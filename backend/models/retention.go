@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RetentionRuleResult is the outcome of one retention rule.
+type RetentionRuleResult struct {
+	Rule        string `json:"rule"`        // e.g. notifications
+	Description string `json:"description"` // What the rule purges
+	RetainDays  int    `json:"retain_days"`
+	Affected    int64  `json:"affected"`        // Rows purged, or that would be purged in a dry run
+	Error       string `json:"error,omitempty"` // Set if the rule failed; other rules still run
+}
+
+// RetentionReport is the outcome of one retention run.
+type RetentionReport struct {
+	DryRun     bool                  `json:"dry_run"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt time.Time             `json:"finished_at"`
+	Rules      []RetentionRuleResult `json:"rules"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewRole is the role the reviewed user had on the ride.
+type ReviewRole string
+
+const (
+	ReviewRoleDriver    ReviewRole = "driver"    // A passenger reviewing the ride's creator
+	ReviewRolePassenger ReviewRole = "passenger" // The creator reviewing an active participant
+)
+
+// Review represents a row of the 'ride_reviews' table.
+type Review struct {
+	ID                uuid.UUID  `json:"id" db:"id"`
+	RideID            uuid.UUID  `json:"ride_id" db:"ride_id"`
+	ReviewerID        uuid.UUID  `json:"reviewer_id" db:"reviewer_id"`
+	ReviewerFirstName *string    `json:"reviewer_first_name,omitempty"` // Listings only
+	RevieweeID        uuid.UUID  `json:"reviewee_id" db:"reviewee_id"`
+	RevieweeRole      ReviewRole `json:"reviewee_role" db:"reviewee_role"`
+	Rating            int        `json:"rating" db:"rating"` // 1-5
+	Comment           *string    `json:"comment,omitempty" db:"comment"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateReviewRequest is the body of POST /rides/:id/reviews.
+type CreateReviewRequest struct {
+	RevieweeID *uuid.UUID `json:"reviewee_id,omitempty"` // Passenger reviewed by the driver; passengers omit it to review the driver
+	Rating     int        `json:"rating" validate:"required,min=1,max=5"`
+	Comment    *string    `json:"comment,omitempty" validate:"omitempty,max=1000"`
+}
+
+// RatingSummary aggregates the ratings a user received.
+type RatingSummary struct {
+	Average float64 `json:"average"` // Mean rating, rounded to one decimal
+	Count   int     `json:"count"`
+}
+
+// UserReviews is the response of GET /users/:id/reviews.
+type UserReviews struct {
+	AsDriver    *RatingSummary `json:"as_driver,omitempty"`    // Nil without any rating
+	AsPassenger *RatingSummary `json:"as_passenger,omitempty"` // Nil without any rating
+	Reviews     []Review       `json:"reviews"`                // Most recent first
+}
@@ -13,13 +13,46 @@ const (
 	RideStatusActive    RideStatus = "active"    // Default status, ride is visible and joinable if seats available
 	RideStatusArchived  RideStatus = "archived"  // Ride is in the past or manually archived
 	RideStatusCancelled RideStatus = "cancelled" // Cancelled by the creator
+	RideStatusCompleted RideStatus = "completed" // Confirmed by the creator, or automatically once the estimated arrival passed; unlocks reviews
 	// Note: 'full' is not a status anymore, it's determined by calculation (total_seats - active_participants)
 )
 
-// GeoPoint represents geographic coordinates.
+// CancellationPolicy represents the refund policy a creator attaches to a ride.
+type CancellationPolicy string
+
+const (
+	CancellationPolicyFlexible CancellationPolicy = "flexible" // Full refund until shortly before departure
+	CancellationPolicyModerate CancellationPolicy = "moderate" // Full refund until the day before, partial afterwards
+	CancellationPolicyStrict   CancellationPolicy = "strict"   // Partial refund only when leaving well in advance
+)
+
+// ChatLevel is how much the driver likes to talk during a ride.
+type ChatLevel string
+
+const (
+	ChatLevelQuiet    ChatLevel = "quiet"
+	ChatLevelModerate ChatLevel = "moderate" // Default
+	ChatLevelChatty   ChatLevel = "chatty"
+)
+
+// LuggageCapacity is the largest luggage each passenger may bring on a ride.
+type LuggageCapacity string
+
+const (
+	LuggageNone  LuggageCapacity = "none"  // Hand luggage only
+	LuggageSmall LuggageCapacity = "small" // A backpack or small bag; default
+	LuggageLarge LuggageCapacity = "large" // A suitcase
+)
+
+// SRIDWGS84 is the spatial reference of every stored point: GPS longitude/latitude (EPSG:4326).
+const SRIDWGS84 = 4326
+
+// GeoPoint represents geographic coordinates. In requests, the shared validator also rejects
+// (0, 0) ("null island"), which is almost always a missing fix sent as zeroes.
 type GeoPoint struct {
-	Longitude float64 `json:"longitude"`
-	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude" validate:"longitude"`
+	Latitude  float64 `json:"latitude" validate:"latitude"`
+	SRID      int     `json:"srid,omitempty" validate:"omitempty,eq=4326"` // Optional; clients may state the reference system, which must be WGS 84
 }
 
 // Ride represents the structure for the 'rides' table.
@@ -34,11 +67,88 @@ type Ride struct {
 	DepartureTime         string    `json:"departure_time" db:"departure_time"`                   // Time of departure (HH:MM format) - Stored as TIME in DB
 	TotalSeats            int       `json:"total_seats" db:"total_seats"`                         // Total seats offered by creator (1-5)
 	Status                string    `json:"status" db:"status"`                                   // active, archived, cancelled (now TEXT)
+	CancellationPolicy    string    `json:"cancellation_policy" db:"cancellation_policy"`         // flexible, moderate, strict
+	MinAge                *int      `json:"min_age,omitempty" db:"min_age"`                       // Minimum traveller age set by the creator (nil = platform minimum)
+	PassengerGender       *string   `json:"passenger_gender,omitempty" db:"passenger_gender"`     // Only passengers of this gender may join, e.g. women-only rides (nil = everyone)
 	PlacesTaken           int       `json:"places_taken"`                                         // Calculated field, not directly from DB column 'nb_places_prises'
 	CreatedAt             time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
 	// Optional: Include creator info when fetching rides
-	CreatorFirstName *string `json:"creator_first_name,omitempty" db:"creator_first_name"` // Populated by JOIN in GetRideDetails
+	CreatorFirstName *string      `json:"creator_first_name,omitempty" db:"creator_first_name"` // Populated by JOIN in GetRideDetails
+	RoutePolyline    *string      `json:"route_polyline,omitempty" db:"route_polyline"`         // Encoded driving route (Google polyline, precision 5); GetRideDetails only
+	PickupPoint      *PickupPoint `json:"pickup_point,omitempty"`                               // Official pickup location chosen by the driver; GetRideDetails only
+	Vehicle          *RideVehicle `json:"vehicle,omitempty"`                                    // Car the ride is driven with, if the driver chose one; GetRideDetails only
+
+	DistanceKm *float64 `json:"distance_km,omitempty" db:"distance_meters"` // Great-circle distance between departure and arrival (one decimal)
+
+	// Driving estimates: the ride's own route, else the travel matrix estimate of its city pair; listings and details
+	EstimatedDurationMinutes *int     `json:"estimated_duration_minutes,omitempty"`
+	EstimatedDistanceKm      *float64 `json:"estimated_distance_km,omitempty"`
+	EstimatedArrivalDate     *string  `json:"estimated_arrival_date,omitempty"` // YYYY-MM-DD, the departure date unless arriving after midnight
+	EstimatedArrivalTime     *string  `json:"estimated_arrival_time,omitempty"` // HH:MM, local time like departure_time
+
+	// Price of one seat in cents of the payment currency; listings and details, the booking fee unless the ride has its own price
+	PricePerSeat *int64 `json:"price_per_seat,omitempty" db:"price_per_seat"`
+
+	// Peak travel day: the ride departs on a public holiday or the day before one (HOLIDAY_COUNTRIES); listings and details
+	PeakDay     bool    `json:"peak_day"`
+	PeakHoliday *string `json:"peak_holiday,omitempty"` // Name of the holiday
+
+	DriverStats *DriverStats `json:"driver_stats,omitempty"` // Creator's reliability; listings and details
+	ViewCount   *int         `json:"view_count,omitempty"`   // Distinct users who viewed the ride's details; details, for the creator only
+
+	// Driver's preferences; listings and details
+	SmokingAllowed bool   `json:"smoking_allowed" db:"smoking_allowed"`
+	PetsAllowed    bool   `json:"pets_allowed" db:"pets_allowed"`
+	MusicAllowed   bool   `json:"music_allowed" db:"music_allowed"`
+	ChatLevel      string `json:"chat_level" db:"chat_level"` // quiet, moderate, chatty
+
+	LuggageCapacity string `json:"luggage_capacity" db:"luggage_capacity"` // none, small, large; listings and details
+
+	// Waypoint rides; GetRideDetails only
+	Stops             []RideStop `json:"stops,omitempty"`               // Intermediate stops in driving order
+	SegmentSeatsTaken []int      `json:"segment_seats_taken,omitempty"` // Seats taken on each leg; leg i runs from stop i to stop i+1 (0 = departure)
+}
+
+// DriverStats summarizes how reliably a driver runs the rides they publish.
+type DriverStats struct {
+	CompletedRides   int            `json:"completed_rides"`             // Past rides that weren't cancelled
+	CancelledRides   int            `json:"cancelled_rides"`             // Rides cancelled by the driver
+	CancellationRate *float64       `json:"cancellation_rate,omitempty"` // Cancelled share of completed and cancelled rides (0-1); nil without any
+	Rating           *RatingSummary `json:"rating,omitempty"`            // Ratings passengers gave the driver; nil without any
+}
+
+// RideStop is an intermediate stop of a ride where participants can board or alight.
+type RideStop struct {
+	Position     int       `json:"position"` // 1..n in driving order; 0 is the departure and n+1 the arrival
+	LocationName string    `json:"location_name"`
+	Coords       *GeoPoint `json:"coords"`
+}
+
+// Route is a driving route computed by the routing integration.
+type Route struct {
+	Polyline        string  // Encoded polyline (precision 5)
+	DistanceMeters  float64 // Driving distance
+	DurationSeconds float64 // Driving time
+}
+
+// DriveTimeConflict describes another of a driver's rides that a new ride leaves too little time to
+// drive to or from. Times are in whole minutes.
+type DriveTimeConflict struct {
+	Ride             *Ride   `json:"conflicting_ride"`
+	Position         string  `json:"position"`          // "before" if the conflicting ride departs first, "after" otherwise
+	AvailableMinutes int     `json:"available_minutes"` // From the estimated arrival of the earlier ride to the departure of the later one
+	TransferMinutes  int     `json:"transfer_minutes"`  // Estimated drive from the earlier ride's arrival to the later ride's departure
+	TransferKm       float64 `json:"transfer_km"`
+	BufferMinutes    int     `json:"buffer_minutes"` // Slack required on top of the transfer
+	Impossible       bool    `json:"impossible"`     // The transfer alone doesn't fit, so the ride can't be confirmed
+}
+
+// TravelEstimate is a cached driving estimate between two locations (see the travel_matrix table).
+type TravelEstimate struct {
+	DistanceMeters  float64
+	DurationSeconds float64
+	RefreshedAt     time.Time
 }
 
 // ParticipantStatus represents the possible statuses of a participant (now using TEXT in DB).
@@ -49,6 +159,9 @@ const (
 	ParticipantStatusActive         ParticipantStatus = "active"          // Payment successful, user is an active participant
 	ParticipantStatusLeft           ParticipantStatus = "left"            // User chose to leave the ride
 	ParticipantStatusCancelledRide  ParticipantStatus = "cancelled_ride"  // Ride was cancelled by creator after user joined/paid
+	ParticipantStatusOnHold         ParticipantStatus = "on_hold"         // Booking held by a fraud rule until reviewed, not charged
+	ParticipantStatusRemoved        ParticipantStatus = "removed"         // Removed by the ride creator, refunded in full
+	ParticipantStatusCompleted      ParticipantStatus = "completed"       // Was an active participant of a completed ride
 )
 
 // Participant represents the structure for the 'participants' table.
@@ -62,6 +175,13 @@ type Participant struct {
 	// Optional: Include user/ride info when fetching participants
 	User *User `json:"user,omitempty" db:"-"` // Participating user info (populated in service)
 	Ride *Ride `json:"ride,omitempty" db:"-"` // Ride info (populated in service)
+
+	// Leg of the route travelled, as stop positions (0 = departure, number of stops + 1 = arrival)
+	BoardingStop  int `json:"boarding_stop" db:"boarding_stop"`
+	AlightingStop int `json:"alighting_stop" db:"alighting_stop"`
+
+	SeatHeldUntil *time.Time `json:"seat_held_until,omitempty" db:"seat_held_until"` // While pending payment: the seat stays reserved until then
+	SeatCount     int        `json:"seat_count" db:"seat_count"`                     // Seats booked by this participation, paid for together
 }
 
 // --- DTOs (Data Transfer Objects) for API Requests/Responses ---
@@ -75,6 +195,39 @@ type CreateRideRequest struct {
 	DepartureDate         string    `json:"departure_date" validate:"required,datetime=2006-01-02"` // YYYY-MM-DD
 	DepartureTime         string    `json:"departure_time" validate:"required,datetime=15:04"`      // HH:MM (24-hour format)
 	TotalSeats            int       `json:"total_seats" validate:"required,min=1,max=5"`
+	CancellationPolicy    string    `json:"cancellation_policy,omitempty" validate:"omitempty,oneof=flexible moderate strict"` // Optional, platform default if empty
+
+	Stops  []RideStopRequest `json:"stops,omitempty" validate:"omitempty,max=5,dive"`     // Optional intermediate stops, in driving order
+	MinAge *int              `json:"min_age,omitempty" validate:"omitempty,min=1,max=99"` // Optional minimum traveller age, e.g. 18 for adults-only rides
+
+	PassengerGender *string `json:"passenger_gender,omitempty" validate:"omitempty,oneof=female male"` // Optional: reserve the ride to passengers of the creator's gender
+
+	PricePerSeat *int64 `json:"price_per_seat,omitempty" validate:"omitempty,min=1"` // Optional seat price in cents, within SEAT_PRICE_MIN/MAX_CENTS; the booking fee if omitted
+
+	VehicleID *uuid.UUID `json:"vehicle_id,omitempty"` // Optional: one of the driver's vehicles (GET /users/me/vehicles)
+
+	// Optional preferences; smoking and pets default to not allowed, music to allowed
+	SmokingAllowed bool   `json:"smoking_allowed,omitempty"`
+	PetsAllowed    bool   `json:"pets_allowed,omitempty"`
+	MusicAllowed   *bool  `json:"music_allowed,omitempty"`
+	ChatLevel      string `json:"chat_level,omitempty" validate:"omitempty,oneof=quiet moderate chatty"` // Defaults to moderate
+
+	LuggageCapacity string `json:"luggage_capacity,omitempty" validate:"omitempty,oneof=none small large"` // Optional, defaults to small
+
+	IgnoreConflicts bool `json:"ignore_conflicts,omitempty"` // Create even if another of the user's rides departs around the same time
+}
+
+// DuplicateRideRequest is the body of POST /rides/:id/duplicate: the new departure of a copy of the ride.
+type DuplicateRideRequest struct {
+	DepartureDate   string `json:"departure_date" validate:"required,datetime=2006-01-02"` // YYYY-MM-DD
+	DepartureTime   string `json:"departure_time" validate:"required,datetime=15:04"`      // HH:MM (24-hour format)
+	IgnoreConflicts bool   `json:"ignore_conflicts,omitempty"`                             // Create even if another of the user's rides departs around the same time
+}
+
+// RideStopRequest is one intermediate stop in CreateRideRequest.
+type RideStopRequest struct {
+	LocationName string    `json:"location_name" validate:"required"`
+	Coords       *GeoPoint `json:"coords" validate:"required"`
 }
 
 // RideResponse defines a structure for returning ride details, potentially including creator info.
@@ -87,11 +240,133 @@ type RideResponse struct {
 
 // SearchRidesRequest defines optional query parameters for searching rides.
 type SearchRidesRequest struct {
-	StartLocation *string `query:"start_location"`                                          // Optional start location filter (e.g., using LIKE %query%)
-	EndLocation   *string `query:"end_location"`                                            // Optional end location filter
-	DepartureDate *string `query:"departure_date" validate:"omitempty,datetime=2006-01-02"` // Optional date filter (YYYY-MM-DD)
-	Page          *int    `query:"page" validate:"omitempty,min=1"`                         // Optional pagination: page number (1-based)
-	Limit         *int    `query:"limit" validate:"omitempty,min=1,max=100"`                // Optional pagination: items per page (e.g., 1-100)
+	StartLocation   *string `query:"start_location"`                                          // Optional start location filter (e.g., using LIKE %query%)
+	EndLocation     *string `query:"end_location"`                                            // Optional end location filter
+	DepartureDate   *string `query:"departure_date" validate:"omitempty,datetime=2006-01-02"` // Optional date filter (YYYY-MM-DD)
+	ArriveBy        *string `query:"arrive_by" validate:"omitempty,datetime=15:04"`           // Optional latest arrival time (HH:MM) on the departure day; uses the travel matrix
+	DepartureAfter  *string `query:"departure_after" validate:"omitempty,datetime=15:04"`     // Optional earliest departure time (HH:MM), inclusive
+	DepartureBefore *string `query:"departure_before" validate:"omitempty,datetime=15:04"`    // Optional latest departure time (HH:MM), inclusive
+	Seats           *int    `query:"seats" validate:"omitempty,min=1,max=5"`                  // Optional number of travellers: only rides with at least this many free seats (default 1)
+	MinPrice        *int64  `query:"min_price" validate:"omitempty,min=0"`                    // Optional lowest seat price, in cents
+	MaxPrice        *int64  `query:"max_price" validate:"omitempty,min=0"`                    // Optional highest seat price, in cents
+	Page            *int    `query:"page" validate:"omitempty,min=1"`                         // Optional pagination: page number (1-based)
+	Limit           *int    `query:"limit" validate:"omitempty,min=1,max=100"`                // Optional pagination: items per page (e.g., 1-100)
+
+	// Optional points: only rides departing (arriving) within radius_km of them; lat and lon go together
+	DepartureLat *float64 `query:"departure_lat" validate:"omitempty,latitude"`
+	DepartureLon *float64 `query:"departure_lon" validate:"omitempty,longitude"`
+	ArrivalLat   *float64 `query:"arrival_lat" validate:"omitempty,latitude"`
+	ArrivalLon   *float64 `query:"arrival_lon" validate:"omitempty,longitude"`
+	RadiusKm     *int     `query:"radius_km" validate:"omitempty,min=1,max=200"`        // Defaults to 10 km
+	Match        *string  `query:"match" validate:"omitempty,oneof=endpoints corridor"` // "corridor": the route passes near both points, in order (default "endpoints")
+
+	// Optional preference filters: only rides whose preference equals the given value
+	SmokingAllowed *bool   `query:"smoking_allowed"`
+	PetsAllowed    *bool   `query:"pets_allowed"`
+	MusicAllowed   *bool   `query:"music_allowed"`
+	ChatLevel      *string `query:"chat_level" validate:"omitempty,oneof=quiet moderate chatty"`
+
+	Luggage *string `query:"luggage" validate:"omitempty,oneof=none small large"` // Optional: only rides with room for luggage of this size (small also matches large)
+
+	PassengerGender *string `query:"passenger_gender" validate:"omitempty,oneof=female male"` // Optional: only rides reserved to passengers of this gender
+
+	Sort *string `query:"sort" validate:"omitempty,oneof=departure_time price distance recently_created"` // Optional order (default "departure_time"), see the SearchSort constants
+}
+
+// Search sort orders (SearchRidesRequest.Sort).
+const (
+	SearchSortDepartureTime   = "departure_time"   // Soonest first, after ranking or nearby departures
+	SearchSortPrice           = "price"            // Cheapest seat first, then soonest
+	SearchSortDistance        = "distance"         // Shortest distance_km first, then soonest
+	SearchSortRecentlyCreated = "recently_created" // Latest published first, then soonest
+)
+
+// Search matching modes (SearchRidesRequest.Match).
+const (
+	SearchMatchEndpoints = "endpoints" // The ride departs near the departure point and arrives near the arrival point
+	SearchMatchCorridor  = "corridor"  // The ride's route passes near the departure point, then near the arrival point
+)
+
+// RideListRequest defines the pagination parameters of the ride lists (available rides and the
+// user's created, joined and history rides).
+type RideListRequest struct {
+	Page  *int `query:"page" validate:"omitempty,min=1"`
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=100"` // Default 20
+}
+
+// UpcomingContactsRequest defines the query parameters for GET /users/me/rides/upcoming/contacts.
+type UpcomingContactsRequest struct {
+	Days *int `query:"days" validate:"omitempty,min=1,max=30"` // Rides departing within this many days (default 7)
+}
+
+// NearbyRidesRequest defines the query parameters for GET /rides/nearby. Without a point, the
+// user's last known location is used.
+type NearbyRidesRequest struct {
+	Latitude  *float64 `query:"lat" validate:"omitempty,latitude"`
+	Longitude *float64 `query:"lon" validate:"omitempty,longitude"`
+	RadiusKm  *int     `query:"radius_km" validate:"omitempty,min=1,max=200"` // Defaults to 30 km
+}
+
+// RideMapRequest defines the query parameters for GET /rides/map.
+type RideMapRequest struct {
+	BBox string `query:"bbox" validate:"required"`     // "minLon,minLat,maxLon,maxLat" of the visible map area
+	Zoom int    `query:"zoom" validate:"min=0,max=22"` // Web map zoom level; higher zooms split clusters
+}
+
+// RideCluster is a group of active rides departing from the same map cell.
+type RideCluster struct {
+	Count  int        `json:"count"`             // Rides in the cluster
+	Coords GeoPoint   `json:"coords"`            // Centroid of the departures, where the marker is drawn
+	RideID *uuid.UUID `json:"ride_id,omitempty"` // Set when the cluster is a single ride
+}
+
+// RosterEntry is one participation on a ride, as shown to its creator (GET /rides/:id/participants).
+type RosterEntry struct {
+	ParticipantID uuid.UUID      `json:"participant_id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	FirstName     *string        `json:"first_name"`
+	LastName      *string        `json:"last_name"`
+	Seats         int            `json:"seats"`                    // Seats taken by this participation
+	Status        string         `json:"status"`                   // Participation status (pending_payment, active, on_hold, left, cancelled_ride, removed)
+	PaymentStatus *PaymentStatus `json:"payment_status,omitempty"` // Status of the latest payment, nil if none was started
+	JoinedAt      time.Time      `json:"joined_at"`
+	PickupDetails
+}
+
+// PickupDetails are the informal pickup arrangements of a participation: the participant's note
+// and the order and seat assigned by the driver. Shown in the roster and the contacts.
+type PickupDetails struct {
+	PickupNote  *string `json:"pickup_note,omitempty"`  // Written by the participant
+	PickupOrder *int    `json:"pickup_order,omitempty"` // Assigned by the driver, 1 = picked up first
+	Seat        *string `json:"seat,omitempty"`         // Assigned by the driver, e.g. "front"
+}
+
+// UpdatePickupNoteRequest defines the body of PUT /rides/:id/pickup-note.
+type UpdatePickupNoteRequest struct {
+	Note *string `json:"note" validate:"omitempty,max=200"` // Omit or null to clear the note
+}
+
+// AssignPickupRequest defines the body of PUT /rides/:id/participants/:userId/pickup. Both fields
+// are replaced; omit or null one to clear it.
+type AssignPickupRequest struct {
+	PickupOrder *int    `json:"pickup_order" validate:"omitempty,min=1,max=20"`
+	Seat        *string `json:"seat" validate:"omitempty,max=30"`
+}
+
+// ParticipantPickupResponse returns the pickup arrangements of a participation after an update.
+type ParticipantPickupResponse struct {
+	RideID uuid.UUID `json:"ride_id"`
+	UserID uuid.UUID `json:"user_id"`
+	PickupDetails
+}
+
+// JoinRideRequest is the optional body of POST /rides/:id/join. Without stops the whole route is booked.
+type JoinRideRequest struct {
+	BoardingStop  *int `json:"boarding_stop" validate:"omitempty,min=0"`  // Stop position to board at (default 0, the departure)
+	AlightingStop *int `json:"alighting_stop" validate:"omitempty,min=1"` // Stop position to alight at (default the arrival)
+	Seats         *int `json:"seats" validate:"omitempty,min=1,max=5"`    // Seats to book for the user's group (default 1)
+
+	IgnoreConflicts bool `json:"ignore_conflicts,omitempty"` // Join even if another of the user's rides departs around the same time
 }
 
 // JoinRideResponse defines the structure for responding after a user joins a ride.
@@ -100,7 +375,60 @@ type JoinRideResponse struct {
 	RideID          uuid.UUID `json:"ride_id"`
 	UserID          uuid.UUID `json:"user_id"`
 	Status          string    `json:"status"` // Should be 'pending_payment' initially (now TEXT)
+	BoardingStop    int       `json:"boarding_stop"`
+	AlightingStop   int       `json:"alighting_stop"`
+	SeatCount       int       `json:"seat_count"`
 	Message         string    `json:"message"`
+
+	SeatHeldUntil *time.Time `json:"seat_held_until,omitempty"` // Pay before this to keep the seat
+}
+
+// LeaveRideResponse describes the outcome of a participant leaving a ride, including any refund.
+type LeaveRideResponse struct {
+	RideID             uuid.UUID `json:"ride_id"`
+	PreviousStatus     string    `json:"previous_status"`     // Participation status before leaving
+	CancellationPolicy string    `json:"cancellation_policy"` // Policy used to compute the refund
+	RefundPercent      int       `json:"refund_percent"`      // Share of the payment refunded (0-100)
+	RefundAmount       int64     `json:"refund_amount"`       // Amount refunded (smallest currency unit)
+	RefundStatus       string    `json:"refund_status"`       // none, refunded, failed
+}
+
+// RemoveParticipantRequest is the body of DELETE /rides/:id/participants/:userId.
+type RemoveParticipantRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"` // Shown to the removed participant
+}
+
+// RemoveParticipantResponse describes the outcome of a creator removing a participant.
+type RemoveParticipantResponse struct {
+	RideID         uuid.UUID `json:"ride_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	PreviousStatus string    `json:"previous_status"` // Participation status before the removal
+	Route          string    `json:"route"`
+	RefundAmount   int64     `json:"refund_amount"` // Amount refunded (smallest currency unit)
+	RefundStatus   string    `json:"refund_status"` // none, refunded, failed
+}
+
+// CancelledParticipation describes a participant affected by a ride cancellation.
+type CancelledParticipation struct {
+	UserID         uuid.UUID `json:"user_id"`
+	PreviousStatus string    `json:"previous_status"` // active or pending_payment
+	RefundAmount   int64     `json:"refund_amount"`   // Amount refunded (smallest currency unit)
+	RefundStatus   string    `json:"refund_status"`   // none, refunded, failed
+}
+
+// CancelRideResponse is returned when a ride is cancelled (participants are refunded and notified).
+type CancelRideResponse struct {
+	RideID             uuid.UUID                `json:"ride_id"`
+	Route              string                   `json:"route"`
+	CancellationPolicy string                   `json:"cancellation_policy"` // Policy the refunds were computed from
+	RefundPercent      int                      `json:"refund_percent"`      // Share of each active participant's payment refunded (0-100)
+	Participants       []CancelledParticipation `json:"participants"`
+}
+
+// CompleteRideResponse is returned when the creator confirms a ride took place.
+type CompleteRideResponse struct {
+	RideID                uuid.UUID `json:"ride_id"`
+	CompletedParticipants int       `json:"completed_participants"` // Active participants marked completed, who can now review the ride
 }
 
 // Note: Updated Ride/Participant statuses to string. Renamed AvailableSeats to TotalSeats.
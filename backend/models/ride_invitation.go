@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RideInvitationStatus is the state of an invitation to join a ride.
+type RideInvitationStatus string
+
+const (
+	RideInvitationStatusPending  RideInvitationStatus = "pending"  // Waiting for the invitee
+	RideInvitationStatusAccepted RideInvitationStatus = "accepted" // The invitee joined the ride
+	RideInvitationStatusDeclined RideInvitationStatus = "declined" // Refused by the invitee
+	RideInvitationStatusRevoked  RideInvitationStatus = "revoked"  // Withdrawn by the creator
+)
+
+// RideInvitation represents a row of the 'ride_invitations' table.
+type RideInvitation struct {
+	ID               uuid.UUID            `json:"id" db:"id"`
+	RideID           uuid.UUID            `json:"ride_id" db:"ride_id"`
+	InvitedBy        uuid.UUID            `json:"invited_by" db:"invited_by"` // Ride creator
+	InviteeID        uuid.UUID            `json:"invitee_id" db:"invitee_id"`
+	InviteeFirstName *string              `json:"invitee_first_name,omitempty"` // For the creator's list
+	Status           RideInvitationStatus `json:"status" db:"status"`
+	CreatedAt        time.Time            `json:"created_at" db:"created_at"`
+	RespondedAt      *time.Time           `json:"responded_at,omitempty" db:"responded_at"`
+	Route            string               `json:"route,omitempty" db:"-"` // "Departure → Arrival", for listing invitations
+}
+
+// CreateRideInvitationRequest is the body of POST /rides/:id/invitations. Exactly one of the
+// invitee's user ID (from the app's user search), email or WhatsApp number is given.
+type CreateRideInvitationRequest struct {
+	UserID   *uuid.UUID `json:"user_id,omitempty" validate:"required_without_all=Email WhatsApp,excluded_with=Email WhatsApp"`
+	Email    *string    `json:"email,omitempty" validate:"omitempty,email,excluded_with=WhatsApp"`
+	WhatsApp *string    `json:"whatsapp,omitempty" validate:"omitempty,e164"`
+}
+
+// AcceptRideInvitationRequest is the optional body of POST /ride-invitations/:id/accept.
+type AcceptRideInvitationRequest struct {
+	Seats           *int `json:"seats" validate:"omitempty,min=1,max=5"` // Seats to book for the user's group (default 1)
+	IgnoreConflicts bool `json:"ignore_conflicts,omitempty"`             // Join even if another of the user's rides departs around the same time
+}
@@ -0,0 +1,23 @@
+package models
+
+// RideShareLink is returned to a ride's creator to share the ride outside the app (e.g. in
+// WhatsApp groups).
+type RideShareLink struct {
+	Token string `json:"token"`
+	URL   string `json:"url"` // GET /public/rides/:token, viewable without an account
+}
+
+// SharedRide is the limited view of a ride behind a share link: no coordinates, participants or
+// contact details. Links outlive rides, so the status tells whether it can still be booked.
+type SharedRide struct {
+	DepartureLocationName string       `json:"departure_location_name"`
+	ArrivalLocationName   string       `json:"arrival_location_name"`
+	DepartureDate         string       `json:"departure_date"` // YYYY-MM-DD
+	DepartureTime         string       `json:"departure_time"` // HH:MM
+	EstimatedArrivalTime  *string      `json:"estimated_arrival_time,omitempty"`
+	Status                string       `json:"status"`
+	SeatsAvailable        int          `json:"seats_available"`
+	PricePerSeat          *int64       `json:"price_per_seat,omitempty"` // Cents of the payment currency
+	DriverFirstName       *string      `json:"driver_first_name,omitempty"`
+	DriverStats           *DriverStats `json:"driver_stats,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RideTemplate represents a row of the 'ride_templates' table: ride settings a driver reuses.
+type RideTemplate struct {
+	ID                    uuid.UUID `json:"id" db:"id"`
+	Name                  string    `json:"name" db:"name"` // e.g. "Friday to Lyon"
+	DepartureLocationName string    `json:"departure_location_name" db:"departure_location_name"`
+	DepartureCoords       *GeoPoint `json:"departure_coords" db:"departure_coords"`
+	ArrivalLocationName   string    `json:"arrival_location_name" db:"arrival_location_name"`
+	ArrivalCoords         *GeoPoint `json:"arrival_coords" db:"arrival_coords"`
+	TotalSeats            int       `json:"total_seats" db:"total_seats"`
+	PricePerSeat          *int64    `json:"price_per_seat,omitempty" db:"price_per_seat"` // Cents; the booking fee if nil
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RideTemplateRequest is the body of POST /users/me/ride-templates and PUT /users/me/ride-templates/:id.
+type RideTemplateRequest struct {
+	Name                  string    `json:"name" validate:"required,max=50"`
+	DepartureLocationName string    `json:"departure_location_name" validate:"required,max=255"`
+	DepartureCoords       *GeoPoint `json:"departure_coords" validate:"required"`
+	ArrivalLocationName   string    `json:"arrival_location_name" validate:"required,max=255"`
+	ArrivalCoords         *GeoPoint `json:"arrival_coords" validate:"required"`
+	TotalSeats            int       `json:"total_seats" validate:"required,min=1,max=5"`
+	PricePerSeat          *int64    `json:"price_per_seat,omitempty" validate:"omitempty,min=1"` // Checked against SEAT_PRICE_MIN/MAX_CENTS when a ride is created
+}
+
+// RideFromTemplateRequest is the body of POST /rides/from-template/:templateId: the departure of the new ride.
+type RideFromTemplateRequest struct {
+	DepartureDate   string `json:"departure_date" validate:"required,datetime=2006-01-02"` // YYYY-MM-DD
+	DepartureTime   string `json:"departure_time" validate:"required,datetime=15:04"`      // HH:MM (24-hour format)
+	IgnoreConflicts bool   `json:"ignore_conflicts,omitempty"`                             // Create even if another of the user's rides departs around the same time
+}
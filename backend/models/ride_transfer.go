@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RideTransferStatus is the state of a ride ownership transfer proposal.
+type RideTransferStatus string
+
+const (
+	RideTransferStatusPending   RideTransferStatus = "pending"   // Waiting for the proposed driver
+	RideTransferStatusAccepted  RideTransferStatus = "accepted"  // The proposed driver now owns the ride
+	RideTransferStatusDeclined  RideTransferStatus = "declined"  // Refused by the proposed driver
+	RideTransferStatusCancelled RideTransferStatus = "cancelled" // Withdrawn by the creator
+)
+
+// RideTransfer represents a row of the 'ride_transfers' table.
+type RideTransfer struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	RideID      uuid.UUID          `json:"ride_id" db:"ride_id"`
+	FromUserID  uuid.UUID          `json:"from_user_id" db:"from_user_id"` // Creator proposing the transfer
+	ToUserID    uuid.UUID          `json:"to_user_id" db:"to_user_id"`     // User asked to take over
+	Status      RideTransferStatus `json:"status" db:"status"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time         `json:"responded_at,omitempty" db:"responded_at"`
+	Route       string             `json:"route,omitempty" db:"-"` // "Departure → Arrival", for listing proposals
+}
+
+// ProposeRideTransferRequest is the body of POST /rides/:id/transfer.
+type ProposeRideTransferRequest struct {
+	ToUserID uuid.UUID `json:"to_user_id" validate:"required"` // Typically a participant acting as co-driver
+}
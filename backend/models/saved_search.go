@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearch represents a row of the 'saved_searches' table: search criteria whose user is
+// alerted when a matching ride is published.
+type SavedSearch struct {
+	ID                    uuid.UUID `json:"id" db:"id"`
+	DepartureLocationName string    `json:"departure_location_name" db:"departure_location_name"`
+	DepartureCoords       *GeoPoint `json:"departure_coords" db:"departure_coords"`
+	ArrivalLocationName   string    `json:"arrival_location_name" db:"arrival_location_name"`
+	ArrivalCoords         *GeoPoint `json:"arrival_coords" db:"arrival_coords"`
+	RadiusKm              int       `json:"radius_km" db:"radius_km"` // Rides departing and arriving this close match
+	DateFrom              time.Time `json:"date_from" db:"date_from"` // First departure date matched
+	DateTo                time.Time `json:"date_to" db:"date_to"`     // Last departure date matched
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateSavedSearchRequest is the body of POST /users/me/saved-searches.
+type CreateSavedSearchRequest struct {
+	DepartureLocationName string    `json:"departure_location_name" validate:"required,max=255"`
+	DepartureCoords       *GeoPoint `json:"departure_coords" validate:"required"`
+	ArrivalLocationName   string    `json:"arrival_location_name" validate:"required,max=255"`
+	ArrivalCoords         *GeoPoint `json:"arrival_coords" validate:"required"`
+	RadiusKm              *int      `json:"radius_km,omitempty" validate:"omitempty,min=1,max=200"` // Defaults to 10 km
+	DateFrom              string    `json:"date_from" validate:"required,datetime=2006-01-02"`      // YYYY-MM-DD
+	DateTo                string    `json:"date_to" validate:"required,datetime=2006-01-02"`        // YYYY-MM-DD, at most 90 days after date_from
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchSuggestionKind says where a search suggestion comes from.
+type SearchSuggestionKind string
+
+const (
+	SearchSuggestionRecent  SearchSuggestionKind = "recent"  // A recent search of the user or anonymous session
+	SearchSuggestionCommute SearchSuggestionKind = "commute" // One of the user's saved commutes
+	SearchSuggestionPopular SearchSuggestionKind = "popular" // A route with many upcoming rides
+)
+
+// SearchSuggestion is an entry of GET /rides/search/suggestions.
+type SearchSuggestion struct {
+	Kind                  SearchSuggestionKind `json:"kind"`
+	Label                 string               `json:"label"`                             // e.g. "Lyon → Paris", or the commute label
+	Query                 string               `json:"query"`                             // Query string to run on GET /rides/search
+	DepartureLocationName *string              `json:"departure_location_name,omitempty"` // Absent when the search had no start location
+	ArrivalLocationName   *string              `json:"arrival_location_name,omitempty"`
+	RideCount             *int                 `json:"ride_count,omitempty"` // Popular routes only: upcoming rides on the route
+}
+
+// SearchSuggestionsRequest defines the query parameters for GET /rides/search/suggestions.
+type SearchSuggestionsRequest struct {
+	Query *string `query:"q" validate:"omitempty,max=100"` // Optional text typed so far, matched against locations and labels
+}
+
+// SavedCommute represents a row of the 'saved_commutes' table.
+type SavedCommute struct {
+	ID                    uuid.UUID `json:"id" db:"id"`
+	Label                 string    `json:"label" db:"label"`
+	DepartureLocationName string    `json:"departure_location_name" db:"departure_location_name"`
+	ArrivalLocationName   string    `json:"arrival_location_name" db:"arrival_location_name"`
+	DepartureTime         *string   `json:"departure_time,omitempty" db:"departure_time"` // HH:MM
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+}
+
+// SaveCommuteRequest is the body of POST /users/me/commutes.
+type SaveCommuteRequest struct {
+	Label                 string  `json:"label" validate:"required,max=50"`
+	DepartureLocationName string  `json:"departure_location_name" validate:"required,max=255"`
+	ArrivalLocationName   string  `json:"arrival_location_name" validate:"required,max=255"`
+	DepartureTime         *string `json:"departure_time" validate:"omitempty,datetime=15:04"`
+}
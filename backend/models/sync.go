@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// SyncRequest defines the cursor of GET /users/me/sync.
+type SyncRequest struct {
+	Since *string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // RFC 3339, the synced_at of the previous sync; omitted on the first sync
+}
+
+// SyncResponse lists what changed for the user since the requested time, so the app can reconcile
+// its local state after an offline period in one call. Entities are sent whole; the app upserts
+// them by ID. An entity may be sent again on the next sync.
+type SyncResponse struct {
+	Rides          []Ride         `json:"rides"`          // Rides the user created or booked, changed or with a participation change
+	Participations []Participant  `json:"participations"` // The user's own bookings, whatever their status
+	Payments       []Payment      `json:"payments"`
+	Notifications  []Notification `json:"notifications"` // Received or read since
+	SyncedAt       time.Time      `json:"synced_at"`     // Pass as since on the next sync
+	HasMore        bool           `json:"has_more"`      // A list was cut short; sync again from synced_at right away
+}
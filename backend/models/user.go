@@ -22,19 +22,33 @@ type User struct {
 	DeletedAt        *time.Time `json:"-" db:"deleted_at"`                      // Timestamp for soft delete (excluded from JSON)
 	StripeCustomerID *string    `json:"-" db:"stripe_customer_id"`              // Stripe Customer ID (optional, excluded from JSON)
 	ExpoPushToken    *string    `json:"-" db:"expo_push_token"`                 // Expo Push Token (optional, excluded from JSON)
+	PreferredLocale  string     `json:"preferred_locale" db:"preferred_locale"` // Language for emails (e.g. 'en', 'fr')
+	Gender           *string    `json:"gender,omitempty" db:"gender"`           // Self-declared: female, male or other; checked by gender-restricted rides
 	HasPaymentMethod bool       `json:"has_payment_method"`                     // Calculated field indicating if Stripe Customer ID exists
+
+	Rating *RatingSummary `json:"rating,omitempty"` // Ratings received on rides, as driver or passenger; nil without any
 }
 
+// Genders users may declare (User.Gender). Rides may be restricted to female or male passengers.
+const (
+	GenderFemale = "female"
+	GenderMale   = "male"
+	GenderOther  = "other"
+)
+
 // SignUpRequest defines the structure for user registration requests.
 // Contains fields required for creating a new user account.
 type SignUpRequest struct {
-	Email       string `json:"email" validate:"required,email"`                    // User's email address
-	Password    string `json:"password" validate:"required,min=8"`                 // User's chosen password (min 8 chars)
-	FirstName   string `json:"first_name" validate:"required"`                     // User's first name
-	LastName    string `json:"last_name" validate:"required"`                      // User's last name
-	BirthDate   string `json:"birth_date" validate:"required,datetime=2006-01-02"` // User's birth date (YYYY-MM-DD format)
-	Nationality string `json:"nationality" validate:"required"`                    // User's nationality
-	WhatsApp    string `json:"whatsapp" validate:"required,e164"`                  // User's WhatsApp number (E.164 format validation)
+	Email       string `json:"email" validate:"required,email"`                               // User's email address
+	Password    string `json:"password" validate:"required,min=8"`                            // User's chosen password (min 8 chars)
+	FirstName   string `json:"first_name" validate:"required"`                                // User's first name
+	LastName    string `json:"last_name" validate:"required"`                                 // User's last name
+	BirthDate   string `json:"birth_date" validate:"required,datetime=2006-01-02"`            // User's birth date (YYYY-MM-DD format)
+	Nationality string `json:"nationality" validate:"required"`                               // User's nationality
+	WhatsApp    string `json:"whatsapp" validate:"required,e164"`                             // User's WhatsApp number (E.164 format validation)
+	InviteCode  string `json:"invite_code,omitempty" validate:"omitempty,max=32"`             // Required when SIGNUP_MODE is invite_only
+	Gender      string `json:"gender,omitempty" validate:"omitempty,oneof=female male other"` // Optional, needed to join gender-restricted rides
+	IPAddress   string `json:"-"`                                                             // Client IP, set by the handler for fraud checks
 }
 
 // LoginRequest defines the structure for user login requests.
@@ -46,8 +60,9 @@ type LoginRequest struct {
 // LoginResponse defines the structure for successful login responses.
 // Typically includes a session token and basic user info.
 type LoginResponse struct {
-	Token string `json:"token"` // Authentication token (e.g., JWT)
-	User  User   `json:"user"`  // Basic user information (excluding sensitive data like password hash)
+	Token     string    `json:"token"`      // Authentication token (e.g., JWT)
+	ExpiresAt time.Time `json:"expires_at"` // Token expiry (JWT_ACCESS_TOKEN_TTL after login)
+	User      User      `json:"user"`       // Basic user information (excluding sensitive data like password hash)
 }
 
 // UpdateProfileRequest defines the structure for updating user profile information.
@@ -58,6 +73,9 @@ type UpdateProfileRequest struct {
 	BirthDate   *string `json:"birth_date,omitempty" validate:"omitempty,datetime=2006-01-02"` // Optional: New birth date (YYYY-MM-DD)
 	Nationality *string `json:"nationality,omitempty"`                                         // Optional: New nationality
 	WhatsApp    *string `json:"whatsapp,omitempty" validate:"omitempty,e164"`                  // Optional: New WhatsApp number (E.164)
+	Locale      *string `json:"preferred_locale,omitempty" validate:"omitempty,oneof=en fr"`   // Optional: New preferred locale for emails
+	Gender      *string `json:"gender,omitempty" validate:"omitempty,oneof=female male other"` // Optional: New gender
+	IPAddress   string  `json:"-"`                                                             // Client IP, set by the handler for the profile history
 	// Email/Password changes might require separate flows for security (e.g., verification)
 }
 
@@ -68,6 +86,16 @@ type UpdateProfileRequest struct {
 
 // UpdateLocationRequest defines the structure for updating the user's location.
 type UpdateLocationRequest struct {
-	Latitude  float64 `json:"latitude" validate:"required,latitude"`   // User's latitude
-	Longitude float64 `json:"longitude" validate:"required,longitude"` // User's longitude
+	Latitude  float64 `json:"latitude" validate:"latitude"`   // User's latitude
+	Longitude float64 `json:"longitude" validate:"longitude"` // User's longitude
+}
+
+// ProfileChange is one field changed by a profile update (GET /users/me/profile-history).
+type ProfileChange struct {
+	ID        uuid.UUID `json:"id"`
+	Field     string    `json:"field"`                // Profile field, e.g. "whatsapp"
+	OldValue  *string   `json:"old_value,omitempty"`  // Value before the change (nil if unset)
+	NewValue  *string   `json:"new_value,omitempty"`  // Value after the change
+	IPAddress *string   `json:"ip_address,omitempty"` // Client IP of the request
+	ChangedAt time.Time `json:"changed_at"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vehicle represents a row of the 'vehicles' table.
+type Vehicle struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Make         string    `json:"make" db:"make"`   // e.g. "Renault"
+	Model        string    `json:"model" db:"model"` // e.g. "Clio"
+	Color        string    `json:"color" db:"color"`
+	LicensePlate string    `json:"license_plate" db:"license_plate"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RideVehicle is the vehicle shown on ride details. The plate is only in the ride contacts.
+type RideVehicle struct {
+	Make  string `json:"make"`
+	Model string `json:"model"`
+	Color string `json:"color"`
+}
+
+// VehicleRequest is the body of POST /users/me/vehicles and PUT /users/me/vehicles/:id.
+type VehicleRequest struct {
+	Make         string `json:"make" validate:"required,max=50"`
+	Model        string `json:"model" validate:"required,max=50"`
+	Color        string `json:"color" validate:"required,max=30"`
+	LicensePlate string `json:"license_plate" validate:"required,max=15"`
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// upcomingRideCondition matches rides (aliased r) that are active and haven't departed yet.
+const upcomingRideCondition = `r.status = 'active' AND (r.departure_date > current_date OR (r.departure_date = current_date AND r.departure_time > current_time))`
+
+// currentParticipationStatuses are the participations (aliased p) a deleted account gives up.
+const currentParticipationStatuses = `p.status IN ('active', 'pending_payment', 'on_hold')`
+
+// AccountDeletionService deletes accounts along with their commitments to other users: the
+// user's upcoming rides are cancelled (participants refunded and notified) and the user leaves
+// the upcoming rides they joined (paid joins refunded in full, creators notified).
+type AccountDeletionService struct {
+	db       database.DBPool
+	auth     *AuthService
+	payments *PaymentService
+}
+
+// NewAccountDeletionService creates a new AccountDeletionService instance.
+func NewAccountDeletionService(db database.DBPool, auth *AuthService, payments *PaymentService) *AccountDeletionService {
+	return &AccountDeletionService{
+		db:       db,
+		auth:     auth,
+		payments: payments,
+	}
+}
+
+// Preview returns what deleting the account would do, without changing anything.
+func (s *AccountDeletionService) Preview(ctx context.Context, userID uuid.UUID) (*models.AccountDeletionImpact, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM rides r WHERE r.user_id = $1 AND ` + upcomingRideCondition + `),
+			(SELECT COUNT(*) FROM participants p JOIN rides r ON r.id = p.ride_id
+			 WHERE r.user_id = $1 AND ` + upcomingRideCondition + ` AND ` + currentParticipationStatuses + `),
+			(SELECT COUNT(*) FROM participants p JOIN rides r ON r.id = p.ride_id
+			 WHERE p.user_id = $1 AND ` + upcomingRideCondition + ` AND ` + currentParticipationStatuses + `),
+			(SELECT COUNT(*) FROM participants p JOIN rides r ON r.id = p.ride_id
+			 JOIN payments pm ON pm.ride_id = p.ride_id AND pm.user_id = p.user_id AND pm.status = 'succeeded'
+			 WHERE p.user_id = $1 AND ` + upcomingRideCondition + ` AND p.status = 'active'),
+			(SELECT COALESCE(SUM(pm.amount - pm.refunded_amount), 0) FROM participants p JOIN rides r ON r.id = p.ride_id
+			 JOIN payments pm ON pm.ride_id = p.ride_id AND pm.user_id = p.user_id AND pm.status = 'succeeded'
+			 WHERE p.user_id = $1 AND ` + upcomingRideCondition + ` AND p.status = 'active')
+	`
+	var impact models.AccountDeletionImpact
+	err := s.db.QueryRow(ctx, query, userID).Scan(&impact.CancelledRides, &impact.AffectedParticipants,
+		&impact.LeftParticipations, &impact.RefundedParticipations, &impact.RefundAmount)
+	if err != nil {
+		log.Printf("Error previewing account deletion for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error previewing account deletion: %w", err)
+	}
+	return &impact, nil
+}
+
+// Delete cancels the user's upcoming rides, leaves the upcoming rides they joined, then soft
+// deletes the account. If a step fails the account is not deleted; retrying resumes with the
+// rides that are left. A failed refund is logged and does not stop the deletion.
+func (s *AccountDeletionService) Delete(ctx context.Context, userID uuid.UUID) (*models.AccountDeletionImpact, error) {
+	impact := &models.AccountDeletionImpact{}
+
+	createdRides, err := s.rideIDs(ctx, `SELECT r.id FROM rides r WHERE r.user_id = $1 AND `+upcomingRideCondition, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rideID := range createdRides {
+		result, err := s.payments.CancelRide(ctx, rideID, RefundInitiatorCreator, false, "account_deleted")
+		if err != nil {
+			log.Printf("Account deletion of user %s stopped: cancelling ride %s failed: %v", userID, rideID, err)
+			return nil, err
+		}
+		impact.CancelledRides++
+		impact.AffectedParticipants += len(result.Participants)
+	}
+
+	joinedRides, err := s.rideIDs(ctx, `
+		SELECT p.ride_id FROM participants p JOIN rides r ON r.id = p.ride_id
+		WHERE p.user_id = $1 AND `+upcomingRideCondition+` AND p.status IN ('active', 'pending_payment')`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rideID := range joinedRides {
+		result, err := s.payments.LeaveRideForAccountDeletion(ctx, rideID, userID)
+		if err != nil {
+			log.Printf("Account deletion of user %s stopped: leaving ride %s failed: %v", userID, rideID, err)
+			return nil, err
+		}
+		impact.LeftParticipations++
+		if result.RefundAmount > 0 {
+			impact.RefundedParticipations++
+			impact.RefundAmount += result.RefundAmount
+		}
+	}
+
+	// Bookings held for fraud review were never charged, so they are simply released
+	heldQuery := `
+		UPDATE participants p SET status = 'left', updated_at = NOW()
+		FROM rides r
+		WHERE r.id = p.ride_id AND p.user_id = $1 AND p.status = 'on_hold' AND ` + upcomingRideCondition
+	tag, err := s.db.Exec(ctx, heldQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error releasing held bookings: %w", err)
+	}
+	impact.LeftParticipations += int(tag.RowsAffected())
+
+	if err := s.auth.DeleteAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+	log.Printf("Account of user %s deleted: %d ride(s) cancelled, %d participation(s) left", userID, impact.CancelledRides, impact.LeftParticipations)
+	return impact, nil
+}
+
+// rideIDs runs a query returning ride IDs for the user.
+func (s *AccountDeletionService) rideIDs(ctx context.Context, query string, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing rides for account deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var rideIDs []uuid.UUID
+	for rows.Next() {
+		var rideID uuid.UUID
+		if err := rows.Scan(&rideID); err != nil {
+			return nil, fmt.Errorf("error processing ride for account deletion: %w", err)
+		}
+		rideIDs = append(rideIDs, rideID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for account deletion: %w", err)
+	}
+	return rideIDs, nil
+}
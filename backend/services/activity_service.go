@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// activityDefaultLimit is the page size of the activity feed when none is requested.
+const activityDefaultLimit = 20
+
+// ActivityService builds the user's activity timeline for the profile screen from the rides,
+// participations and payments they own and the notifications they received.
+type ActivityService struct {
+	db        database.DBPool
+	validator *validator.Validate
+}
+
+// NewActivityService creates a new ActivityService instance.
+func NewActivityService(db database.DBPool) *ActivityService {
+	return &ActivityService{
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// activityQuery merges the feed sources into one timeline. join_confirmed notifications are left
+// out: the payment_succeeded entry already reports the confirmed seat.
+const activityQuery = `
+	SELECT type, event, occurred_at, ride_id, route, amount, currency, title, body FROM (
+		SELECT 'ride_created' AS type, NULL::text AS event, r.created_at AS occurred_at, r.id AS ride_id,
+		       r.departure_location_name || ' → ' || r.arrival_location_name AS route,
+		       NULL::bigint AS amount, NULL::text AS currency, NULL::text AS title, NULL::text AS body
+		FROM rides r WHERE r.user_id = $1
+		UNION ALL
+		SELECT 'ride_cancelled', NULL, r.updated_at, r.id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       NULL, NULL, NULL, NULL
+		FROM rides r WHERE r.user_id = $1 AND r.status = 'cancelled'
+		UNION ALL
+		SELECT 'ride_joined', NULL, p.created_at, r.id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       NULL, NULL, NULL, NULL
+		FROM participants p JOIN rides r ON r.id = p.ride_id
+		WHERE p.user_id = $1 AND p.status <> 'pending_payment'
+		UNION ALL
+		SELECT 'ride_left', NULL, p.updated_at, r.id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       NULL, NULL, NULL, NULL
+		FROM participants p JOIN rides r ON r.id = p.ride_id
+		WHERE p.user_id = $1 AND p.status = 'left'
+		UNION ALL
+		SELECT 'payment_succeeded', NULL, pm.created_at, r.id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       pm.amount, pm.currency::text, NULL, NULL
+		FROM payments pm JOIN rides r ON r.id = pm.ride_id
+		WHERE pm.user_id = $1 AND pm.status IN ('succeeded', 'refunded')
+		UNION ALL
+		SELECT 'payment_refunded', NULL, pm.updated_at, r.id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       pm.amount, pm.currency::text, NULL, NULL
+		FROM payments pm JOIN rides r ON r.id = pm.ride_id
+		WHERE pm.user_id = $1 AND pm.status = 'refunded'
+		UNION ALL
+		SELECT 'notification', n.event_type, n.created_at, n.ride_id, r.departure_location_name || ' → ' || r.arrival_location_name,
+		       NULL, NULL, n.title, n.body
+		FROM notifications n LEFT JOIN rides r ON r.id = n.ride_id
+		WHERE n.user_id = $1 AND n.event_type <> 'join_confirmed'
+	) activity
+	ORDER BY occurred_at DESC
+	LIMIT $2 OFFSET $3
+`
+
+// List returns one page of the user's activity, newest first.
+func (s *ActivityService) List(ctx context.Context, userID uuid.UUID, params models.ActivityRequest) ([]models.ActivityItem, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid activity parameters: %w", err)
+	}
+	limit := activityDefaultLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	offset := 0
+	if params.Page != nil && *params.Page > 1 {
+		offset = (*params.Page - 1) * limit
+	}
+
+	rows, err := s.db.Query(ctx, activityQuery, userID, limit, offset)
+	if err != nil {
+		log.Printf("Error fetching activity for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching activity: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.ActivityItem{}
+	for rows.Next() {
+		var item models.ActivityItem
+		if err := rows.Scan(&item.Type, &item.Event, &item.OccurredAt, &item.RideID, &item.Route, &item.Amount, &item.Currency, &item.Title, &item.Body); err != nil {
+			return nil, fmt.Errorf("error processing activity: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for activity: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For the worker schedule
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+const (
+	adminJobPollInterval = 30 * time.Second // How often the worker looks for queued jobs it wasn't woken for
+	adminJobStaleAfter   = 10 * time.Minute // A running job not touched for this long is resumed (e.g. after a restart)
+	adminJobListLimit    = 50
+)
+
+// ErrAdminJobNotFound is returned when an admin job ID is unknown.
+var ErrAdminJobNotFound = newError(KindNotFound, "job not found")
+
+const adminJobColumns = `id, kind, params, status, total, processed, failed, last_error, created_by, created_at, started_at, finished_at`
+
+// EnqueueCancelUserRides queues the cancellation of every upcoming ride of a user, e.g. when the
+// account is suspended. Participants are fully refunded and notified, as for ForceCancelRide.
+func (s *AdminService) EnqueueCancelUserRides(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, req models.BulkCancelUserRidesRequest, ip string) (*models.AdminJob, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid bulk cancel request: %w", err)
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("database error checking user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return s.enqueueJob(ctx, adminID, models.AdminJobCancelUserRides, models.AdminJobParams{UserID: &userID, Reason: req.Reason}, ip)
+}
+
+// EnqueueArchiveRides queues the archiving of the active rides that departed between two dates.
+// Rides that haven't departed are left alone: they must be cancelled so participants are refunded.
+func (s *AdminService) EnqueueArchiveRides(ctx context.Context, adminID uuid.UUID, req models.BulkArchiveRidesRequest, ip string) (*models.AdminJob, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid bulk archive request: %w", err)
+	}
+	if req.From > req.To { // YYYY-MM-DD sorts chronologically
+		return nil, newError(KindInvalid, "from must not be after to")
+	}
+	return s.enqueueJob(ctx, adminID, models.AdminJobArchiveRides, models.AdminJobParams{From: req.From, To: req.To, Reason: req.Reason}, ip)
+}
+
+func (s *AdminService) enqueueJob(ctx context.Context, adminID uuid.UUID, kind models.AdminJobKind, params models.AdminJobParams, ip string) (*models.AdminJob, error) {
+	query := `INSERT INTO admin_jobs (kind, params, created_by) VALUES ($1, $2, $3) RETURNING ` + adminJobColumns
+	job, err := scanAdminJob(s.db.QueryRow(ctx, query, string(kind), params, adminID))
+	if err != nil {
+		log.Printf("Error queueing %s job for admin %s: %v", kind, adminID, err)
+		return nil, fmt.Errorf("database error queueing job: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionBulkJobQueued,
+		TargetType: "admin_job",
+		TargetID:   job.ID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"kind": string(kind), "params": params},
+	})
+	log.Printf("Admin %s queued %s job %s", adminID, kind, job.ID)
+
+	select {
+	case s.jobWake <- struct{}{}:
+	default: // The worker is already due to look for jobs
+	}
+	return job, nil
+}
+
+// GetJob returns an admin job and its progress.
+func (s *AdminService) GetJob(ctx context.Context, jobID uuid.UUID) (*models.AdminJob, error) {
+	job, err := scanAdminJob(s.db.QueryRow(ctx, `SELECT `+adminJobColumns+` FROM admin_jobs WHERE id = $1`, jobID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAdminJobNotFound
+		}
+		return nil, fmt.Errorf("database error fetching job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the most recent admin jobs, newest first.
+func (s *AdminService) ListJobs(ctx context.Context) ([]models.AdminJob, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+adminJobColumns+` FROM admin_jobs ORDER BY created_at DESC LIMIT $1`, adminJobListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.AdminJob{}
+	for rows.Next() {
+		job, err := scanAdminJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func scanAdminJob(row pgx.Row) (*models.AdminJob, error) {
+	var job models.AdminJob
+	err := row.Scan(&job.ID, &job.Kind, &job.Params, &job.Status, &job.Total, &job.Processed, &job.Failed,
+		&job.LastError, &job.CreatedBy, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StartJobWorker runs queued admin jobs one at a time in the background, when one is queued and
+// every adminJobPollInterval (to pick up jobs queued by other instances or left stale).
+func (s *AdminService) StartJobWorker() {
+	go func() {
+		ticker := time.NewTicker(adminJobPollInterval)
+		defer ticker.Stop()
+		for {
+			for s.runNextJob(context.Background()) {
+			}
+			select {
+			case <-ticker.C:
+			case <-s.jobWake:
+			}
+		}
+	}()
+}
+
+// runNextJob claims and runs the oldest pending job. It reports whether there was one.
+func (s *AdminService) runNextJob(ctx context.Context) bool {
+	claimQuery := `
+		UPDATE admin_jobs
+		SET status = $1, started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM admin_jobs
+			WHERE status = $2 OR (status = $1 AND updated_at < NOW() - make_interval(secs => $3))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + adminJobColumns
+	job, err := scanAdminJob(s.db.QueryRow(ctx, claimQuery, string(models.AdminJobRunning), string(models.AdminJobQueued), adminJobStaleAfter.Seconds()))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Warning: Failed claiming admin job: %v", err)
+		}
+		return false
+	}
+
+	log.Printf("Admin job %s (%s) started", job.ID, job.Kind)
+	rideIDs, err := s.jobRides(ctx, job)
+	if err != nil {
+		log.Printf("Admin job %s failed listing rides: %v", job.ID, err)
+		s.finishJob(ctx, job.ID, models.AdminJobFailed, err.Error())
+		return true
+	}
+	// A resumed job only lists the rides it hasn't processed yet
+	if _, err := s.db.Exec(ctx, `UPDATE admin_jobs SET total = processed + $2, updated_at = NOW() WHERE id = $1`, job.ID, len(rideIDs)); err != nil {
+		log.Printf("Warning: Failed recording total of admin job %s: %v", job.ID, err)
+	}
+
+	for _, rideID := range rideIDs {
+		var rideErr error
+		switch job.Kind {
+		case models.AdminJobCancelUserRides:
+			var result *models.CancelRideResponse
+			result, rideErr = s.paymentService.CancelRide(ctx, rideID, RefundInitiatorAdmin, false, "ride_cancelled_by_admin")
+			if rideErr == nil {
+				_ = s.audit.Record(ctx, models.AuditLogEntry{
+					ActorID:    job.CreatedBy,
+					Action:     models.AuditActionRideCancelled,
+					TargetType: "ride",
+					TargetID:   rideID.String(),
+					Metadata:   map[string]interface{}{"reason": job.Params.Reason, "job_id": job.ID.String(), "participants": result.Participants},
+				})
+			}
+		case models.AdminJobArchiveRides:
+			_, rideErr = s.db.Exec(ctx, `UPDATE rides SET status = $2, updated_at = NOW() WHERE id = $1 AND status = $3`,
+				rideID, string(models.RideStatusArchived), string(models.RideStatusActive))
+		}
+
+		failed, lastError := 0, (*string)(nil)
+		if rideErr != nil {
+			log.Printf("Admin job %s failed on ride %s: %v", job.ID, rideID, rideErr)
+			message := fmt.Sprintf("ride %s: %v", rideID, rideErr)
+			failed, lastError = 1, &message
+		}
+		progressQuery := `UPDATE admin_jobs SET processed = processed + 1, failed = failed + $2, last_error = COALESCE($3, last_error), updated_at = NOW() WHERE id = $1`
+		if _, err := s.db.Exec(ctx, progressQuery, job.ID, failed, lastError); err != nil {
+			log.Printf("Warning: Failed recording progress of admin job %s: %v", job.ID, err)
+		}
+	}
+
+	s.finishJob(ctx, job.ID, models.AdminJobSucceeded, "")
+	log.Printf("Admin job %s (%s) finished: %d ride(s)", job.ID, job.Kind, len(rideIDs))
+	return true
+}
+
+// jobRides lists the rides a job still has to process.
+func (s *AdminService) jobRides(ctx context.Context, job *models.AdminJob) ([]uuid.UUID, error) {
+	var rows pgx.Rows
+	var err error
+	switch job.Kind {
+	case models.AdminJobCancelUserRides:
+		if job.Params.UserID == nil {
+			return nil, errors.New("job has no user")
+		}
+		query := `
+			SELECT id FROM rides
+			WHERE user_id = $1 AND status = $2
+			  AND (departure_date > current_date OR (departure_date = current_date AND departure_time > current_time))
+			ORDER BY departure_date, departure_time
+		`
+		rows, err = s.db.Query(ctx, query, *job.Params.UserID, string(models.RideStatusActive))
+	case models.AdminJobArchiveRides:
+		query := `
+			SELECT id FROM rides
+			WHERE status = $1 AND departure_date BETWEEN $2::date AND $3::date AND departure_date < current_date
+			ORDER BY departure_date
+		`
+		rows, err = s.db.Query(ctx, query, string(models.RideStatusActive), job.Params.From, job.Params.To)
+	default:
+		return nil, fmt.Errorf("unknown job kind: %s", job.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error listing rides: %w", err)
+	}
+	defer rows.Close()
+
+	var rideIDs []uuid.UUID
+	for rows.Next() {
+		var rideID uuid.UUID
+		if err := rows.Scan(&rideID); err != nil {
+			return nil, fmt.Errorf("error processing ride: %w", err)
+		}
+		rideIDs = append(rideIDs, rideID)
+	}
+	return rideIDs, rows.Err()
+}
+
+// finishJob marks a job as done, recording why it failed if it did.
+func (s *AdminService) finishJob(ctx context.Context, jobID uuid.UUID, status models.AdminJobStatus, failure string) {
+	query := `UPDATE admin_jobs SET status = $2, last_error = COALESCE(NULLIF($3, ''), last_error), finished_at = NOW(), updated_at = NOW() WHERE id = $1`
+	if _, err := s.db.Exec(ctx, query, jobID, string(status), failure); err != nil {
+		log.Printf("Warning: Failed finishing admin job %s: %v", jobID, err)
+	}
+}
@@ -0,0 +1,750 @@
+package services
+
+import (
+	"context" // For database operations context
+	"errors"  // For creating standard errors
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For query normalization
+	"time"    // For token expiry
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// AdminService implements support and back-office operations for admins.
+type AdminService struct {
+	cfg            *config.Config
+	db             database.DBPool
+	validator      *validator.Validate
+	audit          *AuditService
+	paymentService *PaymentService
+	fraud          *FraudService
+	quotas         *QuotaService
+	moderation     *ModerationService
+	crypto         *FieldEncryptor // Decrypts WhatsApp numbers and birth dates for support
+	jobWake        chan struct{}   // Wakes the job worker when a job is queued
+}
+
+// NewAdminService creates a new AdminService instance.
+func NewAdminService(cfg *config.Config, db database.DBPool, audit *AuditService, paymentService *PaymentService, fraud *FraudService, quotas *QuotaService, moderation *ModerationService, crypto *FieldEncryptor) *AdminService {
+	return &AdminService{
+		cfg:            cfg,
+		db:             db,
+		validator:      NewValidator(),
+		audit:          audit,
+		paymentService: paymentService,
+		fraud:          fraud,
+		quotas:         quotas,
+		moderation:     moderation,
+		crypto:         crypto,
+		jobWake:        make(chan struct{}, 1),
+	}
+}
+
+// IsAdmin reports whether the user is an active admin.
+func (s *AdminService) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var isAdmin bool
+	query := `SELECT is_admin FROM users WHERE id = $1 AND deleted_at IS NULL`
+	err := s.db.QueryRow(ctx, query, userID).Scan(&isAdmin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("database error checking admin status: %w", err)
+	}
+	return isAdmin, nil
+}
+
+// Impersonate issues a short-lived token that acts as the target user. The token carries an
+// 'impersonator_id' claim so every request made with it is flagged and audited.
+func (s *AdminService) Impersonate(ctx context.Context, adminID uuid.UUID, targetUserID uuid.UUID, req models.ImpersonateRequest, ip string) (*models.ImpersonationResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid impersonation request: %w", err)
+	}
+	if adminID == targetUserID {
+		return nil, newError(KindForbidden, "cannot impersonate yourself")
+	}
+
+	var targetIsAdmin bool
+	query := `SELECT is_admin FROM users WHERE id = $1 AND deleted_at IS NULL`
+	if err := s.db.QueryRow(ctx, query, targetUserID).Scan(&targetIsAdmin); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error loading impersonation target %s: %v", targetUserID, err)
+		return nil, fmt.Errorf("database error fetching user: %w", err)
+	}
+	if targetIsAdmin {
+		return nil, newError(KindForbidden, "cannot impersonate another admin")
+	}
+
+	claims := jwt.MapClaims{
+		"user_id":         targetUserID.String(),
+		"impersonator_id": adminID.String(),
+	}
+	signedToken, expiresAt, err := signToken(s.cfg, claims, s.cfg.ImpersonationTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	// The token must not be issued without a trace
+	err = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:            &adminID,
+		ImpersonatedUserID: &targetUserID,
+		Action:             models.AuditActionImpersonationStart,
+		TargetType:         "user",
+		TargetID:           targetUserID.String(),
+		IPAddress:          ip,
+		Metadata:           map[string]interface{}{"reason": req.Reason, "expires_at": expiresAt},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin %s started impersonating user %s until %s", adminID, targetUserID, expiresAt.Format(time.RFC3339))
+	return &models.ImpersonationResponse{
+		Token:          signedToken,
+		ExpiresAt:      expiresAt,
+		UserID:         targetUserID,
+		ImpersonatorID: adminID,
+	}, nil
+}
+
+// adminSearchLimit caps admin user search results.
+const adminSearchLimit = 50
+
+// adminDetailLimit caps each list in the admin user detail view.
+const adminDetailLimit = 20
+
+// likePatternEscaper escapes LIKE metacharacters so user input only ever matches literally.
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern builds an ILIKE pattern matching q anywhere, to be used with ESCAPE '\'.
+func containsPattern(q string) string {
+	return "%" + likePatternEscaper.Replace(q) + "%"
+}
+
+// SearchUsers finds users by email, phone or name. Substring matches rank first, then
+// trigram similarity catches typos (e.g. "jhon" finds "John"). WhatsApp numbers are encrypted,
+// so they only match exactly (through their blind index).
+func (s *AdminService) SearchUsers(ctx context.Context, q string) ([]models.AdminUserSummary, error) {
+	q = strings.TrimSpace(q)
+	if len(q) < 2 {
+		return nil, newError(KindInvalid, "search query must be at least 2 characters")
+	}
+
+	query := `
+		WITH candidates AS (
+			SELECT id, email, first_name, last_name, COALESCE(whatsapp_encrypted, whatsapp, '') AS whatsapp, whatsapp_hash,
+				is_admin, created_at, deleted_at,
+				COALESCE(first_name, '') || ' ' || COALESCE(last_name, '') AS full_name
+			FROM users
+		)
+		SELECT id, email, first_name, last_name, whatsapp, is_admin, created_at, deleted_at,
+			CASE
+				WHEN email ILIKE $4 ESCAPE '\' OR whatsapp_hash = $3 OR full_name ILIKE $4 ESCAPE '\' THEN 1.0
+				ELSE GREATEST(similarity(email, $1), similarity(full_name, $1))
+			END AS score
+		FROM candidates
+		WHERE email ILIKE $4 ESCAPE '\'
+			OR whatsapp_hash = $3
+			OR full_name ILIKE $4 ESCAPE '\'
+			OR email % $1 OR full_name % $1
+		ORDER BY score DESC, created_at DESC
+		LIMIT $2
+	`
+	rows, err := s.db.Query(ctx, query, q, adminSearchLimit, s.crypto.BlindIndex(q), containsPattern(q))
+	if err != nil {
+		log.Printf("Error searching users: %v", err)
+		return nil, fmt.Errorf("database error searching users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUserSummary{}
+	for rows.Next() {
+		var u models.AdminUserSummary
+		if err := rows.Scan(&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.WhatsApp, &u.IsAdmin, &u.CreatedAt, &u.DeletedAt, &u.Score); err != nil {
+			return nil, fmt.Errorf("database error scanning user: %w", err)
+		}
+		if u.WhatsApp, err = s.crypto.Decrypt(u.WhatsApp); err != nil {
+			return nil, fmt.Errorf("failed to decrypt whatsapp of user %s: %w", u.ID, err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating users: %w", err)
+	}
+	return users, nil
+}
+
+// GetUserDetail aggregates a user's profile, rides, participations, payments, devices and
+// recent audit entries. Viewing the detail is itself audited.
+func (s *AdminService) GetUserDetail(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, ip string) (*models.AdminUserDetail, error) {
+	detail := &models.AdminUserDetail{
+		Rides:          []models.AdminRideSummary{},
+		Participations: []models.AdminParticipationSummary{},
+		Payments:       []models.Payment{},
+		Devices:        []models.AdminDevice{},
+		AuditEntries:   []models.AuditLogEntry{},
+	}
+
+	// 1. Profile
+	var pushToken, birthDate, ageOverrideReason *string
+	var ageOverrideBy *uuid.UUID
+	var ageOverrideAt *time.Time
+	userQuery := `
+		SELECT id, email, first_name, last_name, COALESCE(whatsapp_encrypted, whatsapp, ''), is_admin, created_at, deleted_at,
+			COALESCE(birth_date_encrypted, birth_date::text), nationality, preferred_locale, stripe_customer_id, expo_push_token,
+			age_override_reason, age_override_by, age_override_at
+		FROM users WHERE id = $1
+	`
+	u := &detail.User
+	err := s.db.QueryRow(ctx, userQuery, userID).Scan(
+		&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.WhatsApp, &u.IsAdmin, &u.CreatedAt, &u.DeletedAt,
+		&birthDate, &detail.Nationality, &detail.PreferredLocale, &detail.StripeCustomerID, &pushToken,
+		&ageOverrideReason, &ageOverrideBy, &ageOverrideAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error fetching admin detail for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching user: %w", err)
+	}
+	if u.WhatsApp, err = s.crypto.Decrypt(u.WhatsApp); err != nil {
+		return nil, fmt.Errorf("failed to decrypt whatsapp: %w", err)
+	}
+	if detail.BirthDate, err = s.crypto.DecryptDate(birthDate); err != nil {
+		return nil, fmt.Errorf("failed to decrypt birth date: %w", err)
+	}
+	if ageOverrideAt != nil && ageOverrideReason != nil {
+		detail.AgeOverride = &models.AgeOverride{UserID: userID, Reason: *ageOverrideReason, GrantedBy: ageOverrideBy, GrantedAt: *ageOverrideAt}
+	}
+	u.Score = 1
+	if pushToken != nil && *pushToken != "" {
+		detail.Devices = append(detail.Devices, models.AdminDevice{Platform: "expo", PushToken: *pushToken})
+	}
+
+	// 2. Rides created
+	ridesQuery := `
+		SELECT id, departure_location_name || ' → ' || arrival_location_name, departure_date, departure_time::text, status, total_seats, created_at
+		FROM rides WHERE user_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`
+	rows, err := s.db.Query(ctx, ridesQuery, userID, adminDetailLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching user rides: %w", err)
+	}
+	for rows.Next() {
+		var r models.AdminRideSummary
+		if err := rows.Scan(&r.ID, &r.Route, &r.DepartureDate, &r.DepartureTime, &r.Status, &r.TotalSeats, &r.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("database error scanning user ride: %w", err)
+		}
+		detail.Rides = append(detail.Rides, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating user rides: %w", err)
+	}
+
+	// 3. Participations
+	participationsQuery := `
+		SELECT p.id, p.ride_id, r.departure_location_name || ' → ' || r.arrival_location_name, r.departure_date, p.status, p.created_at
+		FROM participants p
+		JOIN rides r ON r.id = p.ride_id
+		WHERE p.user_id = $1
+		ORDER BY p.created_at DESC LIMIT $2
+	`
+	rows, err = s.db.Query(ctx, participationsQuery, userID, adminDetailLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching user participations: %w", err)
+	}
+	for rows.Next() {
+		var p models.AdminParticipationSummary
+		if err := rows.Scan(&p.ID, &p.RideID, &p.Route, &p.DepartureDate, &p.Status, &p.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("database error scanning user participation: %w", err)
+		}
+		detail.Participations = append(detail.Participations, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating user participations: %w", err)
+	}
+
+	// 4. Payments
+	paymentsQuery := `
+		SELECT id, user_id, ride_id, participant_id, stripe_payment_intent_id, status, amount, currency, refunded_amount, created_at, updated_at
+		FROM payments WHERE user_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`
+	rows, err = s.db.Query(ctx, paymentsQuery, userID, adminDetailLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching user payments: %w", err)
+	}
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.UserID, &p.RideID, &p.ParticipantID, &p.StripePaymentIntentID, &p.Status, &p.Amount, &p.Currency, &p.RefundedAmount, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("database error scanning user payment: %w", err)
+		}
+		detail.Payments = append(detail.Payments, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating user payments: %w", err)
+	}
+
+	// 5. Recent audit entries involving the user (as actor, impersonated user or target)
+	auditQuery := `
+		SELECT id, actor_id, impersonated_user_id, action, COALESCE(target_type, ''), COALESCE(target_id, ''),
+			COALESCE(method, ''), COALESCE(path, ''), COALESCE(status_code, 0), COALESCE(ip_address, ''), metadata, created_at
+		FROM audit_logs
+		WHERE actor_id = $1 OR impersonated_user_id = $1 OR (target_type = 'user' AND target_id = $1::text)
+		ORDER BY created_at DESC LIMIT $2
+	`
+	rows, err = s.db.Query(ctx, auditQuery, userID, adminDetailLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching audit entries: %w", err)
+	}
+	for rows.Next() {
+		var a models.AuditLogEntry
+		if err := rows.Scan(&a.ID, &a.ActorID, &a.ImpersonatedUserID, &a.Action, &a.TargetType, &a.TargetID, &a.Method, &a.Path, &a.StatusCode, &a.IPAddress, &a.Metadata, &a.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("database error scanning audit entry: %w", err)
+		}
+		detail.AuditEntries = append(detail.AuditEntries, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating audit entries: %w", err)
+	}
+
+	// Access to personal data is part of the audit trail
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionUserViewed,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		IPAddress:  ip,
+	})
+
+	return detail, nil
+}
+
+// ForceCancelRide cancels a ride on behalf of support (e.g. when the driver is unreachable).
+// Participants are fully refunded and notified. Departed or archived rides are rejected with a
+// conflict unless the request sets the force override.
+func (s *AdminService) ForceCancelRide(ctx context.Context, adminID uuid.UUID, rideID uuid.UUID, req models.AdminCancelRideRequest, ip string) (*models.CancelRideResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid cancel request: %w", err)
+	}
+
+	result, err := s.paymentService.CancelRide(ctx, rideID, RefundInitiatorAdmin, req.Force, "ride_cancelled_by_admin")
+	if err != nil {
+		return nil, err
+	}
+
+	// The ride is already cancelled at this point, so an audit failure is logged but not returned
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionRideCancelled,
+		TargetType: "ride",
+		TargetID:   rideID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"reason": req.Reason, "force": req.Force, "participants": result.Participants},
+	})
+	log.Printf("Admin %s force-cancelled ride %s: %s", adminID, rideID, req.Reason)
+	return result, nil
+}
+
+// EditRide corrects obviously wrong ride data (e.g. a typo in a location name).
+// Previous and new values are recorded in the audit log.
+func (s *AdminService) EditRide(ctx context.Context, adminID uuid.UUID, rideID uuid.UUID, req models.AdminEditRideRequest, ip string) (*models.Ride, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid ride edit: %w", err)
+	}
+	if req.DepartureLocationName == nil && req.ArrivalLocationName == nil {
+		return nil, newError(KindInvalid, "no update data provided")
+	}
+
+	var oldDeparture, oldArrival, newDeparture, newArrival string
+	query := `
+		WITH previous AS (
+			SELECT departure_location_name, arrival_location_name FROM rides WHERE id = $1 FOR UPDATE
+		)
+		UPDATE rides r
+		SET departure_location_name = COALESCE($2, r.departure_location_name),
+		    arrival_location_name = COALESCE($3, r.arrival_location_name),
+		    updated_at = NOW()
+		FROM previous
+		WHERE r.id = $1
+		RETURNING previous.departure_location_name, previous.arrival_location_name, r.departure_location_name, r.arrival_location_name
+	`
+	err := s.db.QueryRow(ctx, query, rideID, req.DepartureLocationName, req.ArrivalLocationName).Scan(&oldDeparture, &oldArrival, &newDeparture, &newArrival)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error editing ride %s by admin %s: %v", rideID, adminID, err)
+		return nil, fmt.Errorf("database error updating ride: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionRideEdited,
+		TargetType: "ride",
+		TargetID:   rideID.String(),
+		IPAddress:  ip,
+		Metadata: map[string]interface{}{
+			"reason": req.Reason,
+			"before": map[string]string{"departure_location_name": oldDeparture, "arrival_location_name": oldArrival},
+			"after":  map[string]string{"departure_location_name": newDeparture, "arrival_location_name": newArrival},
+		},
+	})
+	log.Printf("Admin %s edited ride %s: %s", adminID, rideID, req.Reason)
+	s.paymentService.rideService.publishRideEvent(ctx, RideEventUpdated, rideID, adminID)
+
+	return s.paymentService.rideService.GetRideDetails(ctx, rideID)
+}
+
+// adminPaymentSelect is the shared SELECT for admin payment queries (see scanAdminPayment).
+const adminPaymentSelect = `
+	SELECT pm.id, pm.user_id, pm.ride_id, pm.participant_id, pm.stripe_payment_intent_id, pm.status, pm.amount, pm.currency,
+		pm.refunded_amount, pm.created_at, pm.updated_at,
+		u.email, r.departure_location_name || ' → ' || r.arrival_location_name, r.status, r.departure_date, pa.status
+	FROM payments pm
+	JOIN users u ON u.id = pm.user_id
+	LEFT JOIN rides r ON r.id = pm.ride_id
+	LEFT JOIN participants pa ON pa.id = pm.participant_id
+`
+
+// scanAdminPayment scans a row selected with adminPaymentSelect.
+func scanAdminPayment(row pgx.Row) (*models.AdminPayment, error) {
+	var p models.AdminPayment
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.RideID, &p.ParticipantID, &p.StripePaymentIntentID, &p.Status, &p.Amount, &p.Currency,
+		&p.RefundedAmount, &p.CreatedAt, &p.UpdatedAt,
+		&p.UserEmail, &p.RideRoute, &p.RideStatus, &p.RideDepartureDate, &p.ParticipantStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PaymentStatusSucceeded {
+		p.RefundableRemaining = p.Amount - p.RefundedAmount
+	}
+	return &p, nil
+}
+
+// ListPayments lists payments matching the filter, newest first.
+func (s *AdminService) ListPayments(ctx context.Context, filter models.AdminPaymentFilter) ([]models.AdminPayment, error) {
+	if err := s.validator.Struct(filter); err != nil {
+		return nil, fmt.Errorf("invalid payment filter: %w", err)
+	}
+
+	query := adminPaymentSelect + " WHERE 1=1"
+	args := []interface{}{}
+	argID := 1
+
+	if filter.Status != nil {
+		query += fmt.Sprintf(" AND pm.status = $%d", argID)
+		args = append(args, *filter.Status)
+		argID++
+	}
+	if filter.From != nil {
+		query += fmt.Sprintf(" AND pm.created_at >= $%d::date", argID)
+		args = append(args, *filter.From)
+		argID++
+	}
+	if filter.To != nil {
+		query += fmt.Sprintf(" AND pm.created_at < $%d::date + 1", argID) // Inclusive of the whole day
+		args = append(args, *filter.To)
+		argID++
+	}
+	if filter.MinAmount != nil {
+		query += fmt.Sprintf(" AND pm.amount >= $%d", argID)
+		args = append(args, *filter.MinAmount)
+		argID++
+	}
+	if filter.MaxAmount != nil {
+		query += fmt.Sprintf(" AND pm.amount <= $%d", argID)
+		args = append(args, *filter.MaxAmount)
+		argID++
+	}
+	if filter.StripePaymentIntentID != nil && *filter.StripePaymentIntentID != "" {
+		query += fmt.Sprintf(" AND pm.stripe_payment_intent_id = $%d", argID)
+		args = append(args, *filter.StripePaymentIntentID)
+		argID++
+	}
+	if filter.UserID != nil {
+		query += fmt.Sprintf(" AND pm.user_id = $%d", argID)
+		args = append(args, *filter.UserID)
+		argID++
+	}
+	if filter.RideID != nil {
+		query += fmt.Sprintf(" AND pm.ride_id = $%d", argID)
+		args = append(args, *filter.RideID)
+		argID++
+	}
+
+	limit := 50
+	if filter.Limit != nil {
+		limit = *filter.Limit
+	}
+	offset := 0
+	if filter.Page != nil && *filter.Page > 1 {
+		offset = (*filter.Page - 1) * limit
+	}
+	query += fmt.Sprintf(" ORDER BY pm.created_at DESC LIMIT $%d OFFSET $%d", argID, argID+1)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing admin payments: %v", err)
+		return nil, fmt.Errorf("database error listing payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []models.AdminPayment{}
+	for rows.Next() {
+		payment, err := scanAdminPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("database error scanning payment: %w", err)
+		}
+		payments = append(payments, *payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating payments: %w", err)
+	}
+	return payments, nil
+}
+
+// GetPayment returns one payment with its linked user, ride and participation.
+func (s *AdminService) GetPayment(ctx context.Context, paymentID uuid.UUID) (*models.AdminPayment, error) {
+	payment, err := scanAdminPayment(s.db.QueryRow(ctx, adminPaymentSelect+" WHERE pm.id = $1", paymentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "payment not found")
+		}
+		return nil, fmt.Errorf("database error fetching payment: %w", err)
+	}
+	return payment, nil
+}
+
+// RefundPayment refunds a payment on behalf of support and records it in the audit log.
+func (s *AdminService) RefundPayment(ctx context.Context, adminID uuid.UUID, paymentID uuid.UUID, req models.AdminRefundRequest, ip string) (*models.AdminRefundResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid refund request: %w", err)
+	}
+
+	refunded, err := s.paymentService.RefundPayment(ctx, paymentID, req.Amount, "admin_refund")
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionPaymentRefunded,
+		TargetType: "payment",
+		TargetID:   paymentID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"reason": req.Reason, "amount": refunded},
+	})
+	log.Printf("Admin %s refunded %d on payment %s: %s", adminID, refunded, paymentID, req.Reason)
+
+	payment, err := s.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AdminRefundResponse{PaymentID: paymentID, RefundedAmount: refunded, Payment: payment.Payment}, nil
+}
+
+// ListFraudRules returns all fraud rules.
+func (s *AdminService) ListFraudRules(ctx context.Context) ([]models.FraudRule, error) {
+	return s.fraud.ListRules(ctx)
+}
+
+// UpdateFraudRule tunes a fraud rule and records the previous and new values in the audit log.
+func (s *AdminService) UpdateFraudRule(ctx context.Context, adminID uuid.UUID, ruleID uuid.UUID, req models.UpdateFraudRuleRequest, ip string) (*models.FraudRule, error) {
+	previous, updated, err := s.fraud.UpdateRule(ctx, ruleID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionFraudRuleUpdated,
+		TargetType: "fraud_rule",
+		TargetID:   ruleID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"before": previous, "after": updated},
+	})
+	return updated, nil
+}
+
+// ListFraudFlags returns the fraud review queue, optionally filtered by status.
+func (s *AdminService) ListFraudFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	return s.fraud.ListFlags(ctx, status)
+}
+
+// ResolveFraudFlag closes a fraud review and records the decision in the audit log.
+func (s *AdminService) ResolveFraudFlag(ctx context.Context, adminID uuid.UUID, flagID uuid.UUID, req models.ResolveFraudFlagRequest, ip string) (*models.FraudFlag, error) {
+	flag, err := s.fraud.ResolveFlag(ctx, adminID, flagID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionFraudFlagResolved,
+		TargetType: "fraud_flag",
+		TargetID:   flagID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"status": req.Status, "note": req.Note, "user_id": flag.UserID},
+	})
+	return flag, nil
+}
+
+// ListModerationFlags returns the moderation review queue, optionally filtered by status.
+func (s *AdminService) ListModerationFlags(ctx context.Context, status string) ([]models.ModerationFlag, error) {
+	return s.moderation.ListFlags(ctx, status)
+}
+
+// ResolveModerationFlag closes a moderation review and records the decision in the audit log.
+func (s *AdminService) ResolveModerationFlag(ctx context.Context, adminID uuid.UUID, flagID uuid.UUID, req models.ResolveModerationFlagRequest, ip string) (*models.ModerationFlag, error) {
+	flag, err := s.moderation.ResolveFlag(ctx, adminID, flagID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionModerationFlagResolved,
+		TargetType: "moderation_flag",
+		TargetID:   flagID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"status": req.Status, "note": req.Note, "user_id": flag.UserID},
+	})
+	return flag, nil
+}
+
+// GetUserQuotas returns a user's usage and limits for every per-account quota.
+func (s *AdminService) GetUserQuotas(ctx context.Context, userID uuid.UUID) ([]models.QuotaStatus, error) {
+	return s.quotas.ListStatuses(ctx, userID)
+}
+
+// SetAgeOverride lets a user below MIN_JOIN_AGE join rides (guardianship cases) and records it in the audit log.
+func (s *AdminService) SetAgeOverride(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, req models.SetAgeOverrideRequest, ip string) (*models.AgeOverride, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid age override: %w", err)
+	}
+
+	override := &models.AgeOverride{UserID: userID, Reason: req.Reason, GrantedBy: &adminID}
+	query := `
+		UPDATE users
+		SET age_override_reason = $2, age_override_by = $3, age_override_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING age_override_at
+	`
+	err := s.db.QueryRow(ctx, query, userID, req.Reason, adminID).Scan(&override.GrantedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error setting age override for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error setting age override: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionAgeOverrideGranted,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"reason": req.Reason},
+	})
+	log.Printf("Admin %s granted an age override to user %s: %s", adminID, userID, req.Reason)
+	return override, nil
+}
+
+// RemoveAgeOverride withdraws a user's guardianship override and records it in the audit log.
+func (s *AdminService) RemoveAgeOverride(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, ip string) error {
+	query := `
+		UPDATE users
+		SET age_override_reason = NULL, age_override_by = NULL, age_override_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND age_override_at IS NOT NULL
+	`
+	tag, err := s.db.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("database error removing age override: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return newError(KindNotFound, "age override not found")
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionAgeOverrideRemoved,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		IPAddress:  ip,
+	})
+	log.Printf("Admin %s removed the age override of user %s", adminID, userID)
+	return nil
+}
+
+// SetQuotaOverride raises (or lowers) a user's quota limit and records it in the audit log.
+func (s *AdminService) SetQuotaOverride(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, quota models.QuotaName, req models.SetQuotaOverrideRequest, ip string) (*models.QuotaOverride, error) {
+	override, err := s.quotas.SetOverride(ctx, adminID, userID, quota, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionQuotaOverridden,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"quota": quota, "limit": req.Limit, "expires_at": req.ExpiresAt, "reason": req.Reason},
+	})
+	log.Printf("Admin %s set %s override for user %s: %s", adminID, quota, userID, req.Reason)
+	return override, nil
+}
+
+// RemoveQuotaOverride restores a user's default quota limit and records it in the audit log.
+func (s *AdminService) RemoveQuotaOverride(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, quota models.QuotaName, ip string) error {
+	if err := s.quotas.RemoveOverride(ctx, userID, quota); err != nil {
+		return err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionQuotaOverrideRemoved,
+		TargetType: "user",
+		TargetID:   userID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"quota": quota},
+	})
+	return nil
+}
+
+// GetSanitizedConfig returns the effective configuration with secrets redacted. Viewing it is audited.
+func (s *AdminService) GetSanitizedConfig(ctx context.Context, adminID uuid.UUID, ip string) map[string]any {
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionConfigViewed,
+		TargetType: "config",
+		TargetID:   string(s.cfg.Profile),
+		IPAddress:  ip,
+	})
+	return s.cfg.Sanitized()
+}
@@ -0,0 +1,19 @@
+package services
+
+import "testing"
+
+// Test that LIKE metacharacters in admin searches are escaped and match literally
+func TestContainsPattern(t *testing.T) {
+	tests := map[string]string{
+		"john":      "%john%",
+		"100%":      `%100\%%`,
+		"a_b":       `%a\_b%`,
+		`back\path`: `%back\\path%`,
+		`%_\`:       `%\%\_\\%`,
+	}
+	for q, want := range tests {
+		if got := containsPattern(q); got != want {
+			t.Errorf("containsPattern(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
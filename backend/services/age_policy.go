@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Age requirements reported in AgeRequirementError.
+const (
+	AgeRequirementSignup = "signup" // Platform minimum to create an account (MIN_SIGNUP_AGE)
+	AgeRequirementJoin   = "join"   // Platform minimum to join rides (MIN_JOIN_AGE), lifted by a guardianship override
+	AgeRequirementRide   = "ride"   // Minimum set by the ride's creator
+)
+
+// AgeRequirementError is returned when a user is too young for an action.
+// Handlers map it to 403 Forbidden with the requirement and minimum age, so the app can explain it.
+type AgeRequirementError struct {
+	Requirement string
+	MinimumAge  int
+}
+
+func (e *AgeRequirementError) Error() string {
+	switch e.Requirement {
+	case AgeRequirementSignup:
+		return fmt.Sprintf("you must be at least %d years old to sign up", e.MinimumAge)
+	case AgeRequirementRide:
+		return fmt.Sprintf("this ride is reserved for travellers aged %d or over", e.MinimumAge)
+	default:
+		return fmt.Sprintf("you must be at least %d years old to join rides", e.MinimumAge)
+	}
+}
+
+// ErrBirthDateRequired is returned when joining needs an age check but the user has no birth date on file.
+var ErrBirthDateRequired = newError(KindForbidden, "add your birth date to your profile to join rides")
+
+// ageOn returns the age in whole years of someone born on birthDate, on the given day.
+func ageOn(birthDate time.Time, on time.Time) int {
+	age := on.Year() - birthDate.Year()
+	if on.Month() < birthDate.Month() || (on.Month() == birthDate.Month() && on.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+// checkSignupAge returns an *AgeRequirementError if someone born on birthDate is below MIN_SIGNUP_AGE.
+func (s *AuthService) checkSignupAge(birthDate time.Time) error {
+	if s.cfg.MinSignupAge > 0 && ageOn(birthDate, time.Now()) < s.cfg.MinSignupAge {
+		return &AgeRequirementError{Requirement: AgeRequirementSignup, MinimumAge: s.cfg.MinSignupAge}
+	}
+	return nil
+}
+
+// checkJoinAge returns an *AgeRequirementError if the user is below MIN_JOIN_AGE (unless an admin
+// recorded a guardianship override) or below the ride's own minimum age, which no override lifts.
+func (s *RideService) checkJoinAge(ctx context.Context, q rowQuerier, userID uuid.UUID, rideMinAge *int) error {
+	if s.cfg.MinJoinAge <= 0 && rideMinAge == nil {
+		return nil
+	}
+
+	var encryptedBirthDate *string
+	var hasOverride bool
+	query := `
+		SELECT COALESCE(birth_date_encrypted, birth_date::text), age_override_at IS NOT NULL
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	if err := q.QueryRow(ctx, query, userID).Scan(&encryptedBirthDate, &hasOverride); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("database error fetching birth date: %w", err)
+	}
+	birthDate, err := s.crypto.DecryptDate(encryptedBirthDate)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt birth date: %w", err)
+	}
+	if birthDate == nil {
+		log.Printf("Age check failed: User %s has no birth date on file", userID)
+		return ErrBirthDateRequired
+	}
+
+	age := ageOn(*birthDate, time.Now())
+	if rideMinAge != nil && age < *rideMinAge {
+		return &AgeRequirementError{Requirement: AgeRequirementRide, MinimumAge: *rideMinAge}
+	}
+	if age < s.cfg.MinJoinAge && !hasOverride {
+		return &AgeRequirementError{Requirement: AgeRequirementJoin, MinimumAge: s.cfg.MinJoinAge}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeOn(t *testing.T) {
+	birthDate := time.Date(2008, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		on   time.Time
+		want int
+	}{
+		{"day before birthday", time.Date(2026, time.June, 14, 12, 0, 0, 0, time.UTC), 17},
+		{"on birthday", time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), 18},
+		{"earlier month", time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC), 17},
+		{"later month", time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC), 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ageOn(birthDate, tt.on); got != tt.want {
+				t.Errorf("ageOn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
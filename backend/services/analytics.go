@@ -0,0 +1,371 @@
+package services
+
+import (
+	"bytes"         // For request bodies
+	"context"       // For database and HTTP calls
+	"encoding/json" // For event payloads
+	"fmt"           // For error formatting
+	"hash/fnv"      // For per-user sampling
+	"log"           // For logging
+	"math/rand/v2"  // For sampling visitors
+	"net/http"      // For the PostHog and Segment APIs
+	"regexp"        // For personal data in property values
+	"strings"       // For endpoint handling
+	"time"          // For batching and timestamps
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// AnalyticsEventName identifies a product analytics event.
+type AnalyticsEventName string
+
+const (
+	AnalyticsRideViewed       AnalyticsEventName = "ride_viewed"       // Ride details were opened
+	AnalyticsSearchPerformed  AnalyticsEventName = "search_performed"  // A ride search returned results (or none)
+	AnalyticsJoinStarted      AnalyticsEventName = "join_started"      // A seat was requested, before payment
+	AnalyticsPaymentSucceeded AnalyticsEventName = "payment_succeeded" // Stripe confirmed a seat payment
+)
+
+const (
+	analyticsQueueSize   = 1000            // Events waiting to be sent; more are dropped
+	analyticsBatchSize   = 100             // Events sent at once
+	analyticsSendTimeout = 5 * time.Second // Bounds one batch write or API call
+)
+
+// AnalyticsEvent is one product analytics event. The user is only attached when they gave the
+// analytics consent; the anonymous session is only used for sampling and never leaves the backend.
+type AnalyticsEvent struct {
+	Name               AnalyticsEventName
+	UserID             uuid.UUID // uuid.Nil for visitors
+	AnonymousSessionID uuid.UUID // Visitor's session, if any
+	Properties         map[string]interface{}
+	SampleRate         int // Set by Track
+	At                 time.Time
+}
+
+// analyticsSink stores or forwards batches of events.
+type analyticsSink interface {
+	Send(ctx context.Context, events []AnalyticsEvent) error
+}
+
+// AnalyticsService collects product analytics events from handlers and services and sends them
+// in batches to the configured sink (cfg.AnalyticsSink) in the background, so tracking never slows
+// or fails a request. Properties are scrubbed of personal data, events are sampled per user
+// (cfg.AnalyticsSampleRate), and events of users without the analytics consent are sent without
+// the user. A nil *AnalyticsService drops events.
+type AnalyticsService struct {
+	consents      *ConsentService
+	sink          analyticsSink
+	sampleRate    int
+	flushInterval time.Duration
+	queue         chan AnalyticsEvent
+}
+
+// NewAnalyticsService creates an AnalyticsService for cfg.AnalyticsSink, or nil when analytics are off.
+func NewAnalyticsService(cfg *config.Config, db database.DBPool, consents *ConsentService) *AnalyticsService {
+	var sink analyticsSink
+	switch cfg.AnalyticsSink {
+	case config.AnalyticsDB:
+		sink = &dbAnalyticsSink{db: db}
+	case config.AnalyticsPostHog, config.AnalyticsSegment:
+		if cfg.AnalyticsAPIKey == "" {
+			log.Printf("Analytics disabled (ANALYTICS_API_KEY not set for %s)", cfg.AnalyticsSink)
+			return nil
+		}
+		sink = newHTTPAnalyticsSink(cfg)
+	default:
+		log.Println("Analytics disabled (ANALYTICS_SINK is off)")
+		return nil
+	}
+	flushInterval := cfg.AnalyticsFlushInterval
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	log.Printf("Analytics enabled (sink %s, sample rate %d%%)", cfg.AnalyticsSink, cfg.AnalyticsSampleRate)
+	return &AnalyticsService{
+		consents:      consents,
+		sink:          sink,
+		sampleRate:    cfg.AnalyticsSampleRate,
+		flushInterval: flushInterval,
+		queue:         make(chan AnalyticsEvent, analyticsQueueSize),
+	}
+}
+
+// Track queues an event. It never blocks: sampled-out events and events arriving while the
+// queue is full are dropped.
+func (s *AnalyticsService) Track(event AnalyticsEvent) {
+	if s == nil {
+		return
+	}
+	key := event.UserID
+	if key == uuid.Nil {
+		key = event.AnonymousSessionID
+	}
+	if !analyticsSampled(s.sampleRate, key) {
+		return
+	}
+	event.SampleRate = s.sampleRate
+	event.Properties = scrubAnalyticsProperties(event.Properties)
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("Warning: Analytics queue full, dropping %s event", event.Name)
+	}
+}
+
+// Start sends queued events in the background, every analyticsBatchSize events and at least every
+// flush interval.
+func (s *AnalyticsService) Start() {
+	if s == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.flushInterval)
+		defer ticker.Stop()
+		batch := make([]AnalyticsEvent, 0, analyticsBatchSize)
+		for {
+			select {
+			case event := <-s.queue:
+				batch = append(batch, event)
+				if len(batch) < analyticsBatchSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			}
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}()
+}
+
+// flush detaches users without the analytics consent from their events, then sends the batch.
+// A failed batch is logged and dropped: analytics are best effort.
+func (s *AnalyticsService) flush(batch []AnalyticsEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), analyticsSendTimeout)
+	defer cancel()
+
+	var userIDs []uuid.UUID
+	for _, event := range batch {
+		if event.UserID != uuid.Nil {
+			userIDs = append(userIDs, event.UserID)
+		}
+	}
+	consented, err := s.consents.GrantedAmong(ctx, userIDs, models.ConsentAnalytics)
+	if err != nil {
+		log.Printf("Warning: Failed checking analytics consents, sending events without users: %v", err)
+		consented = map[uuid.UUID]bool{}
+	}
+	for i := range batch {
+		if !consented[batch[i].UserID] {
+			batch[i].UserID = uuid.Nil
+		}
+		batch[i].AnonymousSessionID = uuid.Nil
+	}
+
+	if err := s.sink.Send(ctx, batch); err != nil {
+		log.Printf("Warning: Failed sending %d analytics event(s): %v", len(batch), err)
+	}
+}
+
+// analyticsSampled reports whether an event is kept at the given rate. Events of the same user or
+// session are all kept or all dropped, so funnels stay complete; other events are sampled at random.
+func analyticsSampled(rate int, key uuid.UUID) bool {
+	switch {
+	case rate >= 100:
+		return true
+	case rate <= 0:
+		return false
+	case key == uuid.Nil:
+		return rand.IntN(100) < rate
+	}
+	h := fnv.New32a()
+	h.Write(key[:])
+	return int(h.Sum32()%100) < rate
+}
+
+// analyticsPIIKeys are property names whose values are personal data; they are removed.
+var analyticsPIIKeys = map[string]bool{
+	"email": true, "phone": true, "phone_number": true, "whatsapp": true, "whatsapp_number": true,
+	"name": true, "first_name": true, "last_name": true, "full_name": true, "birth_date": true,
+	"address": true, "ip": true, "ip_address": true, "location": true,
+	"lat": true, "lon": true, "latitude": true, "longitude": true,
+	"token": true, "password": true, "payment_method_id": true,
+}
+
+// analyticsPhonePattern matches phone numbers in property values. Unlike the moderation pattern,
+// it doesn't allow hyphens, so dates and IDs are left alone.
+var analyticsPhonePattern = regexp.MustCompile(`\+?\d[\d\s().]{7,}\d`)
+
+// scrubAnalyticsProperties returns a copy of the properties without personal data: known personal
+// keys are removed and emails or phone numbers in text values are redacted. Nested maps are scrubbed too.
+func scrubAnalyticsProperties(properties map[string]interface{}) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		if analyticsPIIKeys[strings.ToLower(key)] {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if _, err := uuid.Parse(v); err != nil && (moderationEmailPattern.MatchString(v) || analyticsPhonePattern.MatchString(v)) {
+				value = "[redacted]"
+			}
+		case map[string]interface{}:
+			value = scrubAnalyticsProperties(v)
+		}
+		scrubbed[key] = value
+	}
+	return scrubbed
+}
+
+// dbAnalyticsSink writes events to the monthly partitions of analytics_events (migration 046).
+type dbAnalyticsSink struct {
+	db               database.DBPool
+	partitionedUntil time.Time // Partitions exist for events before this
+}
+
+func (s *dbAnalyticsSink) Send(ctx context.Context, events []AnalyticsEvent) error {
+	if now := time.Now().UTC(); !now.Before(s.partitionedUntil) {
+		s.ensurePartitions(ctx, now)
+	}
+
+	names := make([]string, len(events))
+	userIDs := make([]*uuid.UUID, len(events))
+	properties := make([]string, len(events))
+	sampleRates := make([]int32, len(events))
+	occurredAt := make([]time.Time, len(events))
+	for i, event := range events {
+		names[i] = string(event.Name)
+		if event.UserID != uuid.Nil {
+			userIDs[i] = &events[i].UserID
+		}
+		encoded, err := json.Marshal(event.Properties)
+		if err != nil {
+			return fmt.Errorf("encoding properties of %s event: %w", event.Name, err)
+		}
+		properties[i] = string(encoded)
+		sampleRates[i] = int32(event.SampleRate)
+		occurredAt[i] = event.At
+	}
+	query := `
+		INSERT INTO analytics_events (name, user_id, properties, sample_rate, occurred_at)
+		SELECT * FROM unnest($1::text[], $2::uuid[], $3::jsonb[], $4::int[], $5::timestamptz[])
+	`
+	if _, err := s.db.Exec(ctx, query, names, userIDs, properties, sampleRates, occurredAt); err != nil {
+		return fmt.Errorf("database error inserting analytics events: %w", err)
+	}
+	return nil
+}
+
+// ensurePartitions creates this month's and next month's partitions if they don't exist yet.
+func (s *dbAnalyticsSink) ensurePartitions(ctx context.Context, now time.Time) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		from, to := month.AddDate(0, i, 0), month.AddDate(0, i+1, 0)
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS analytics_events_%s PARTITION OF analytics_events FOR VALUES FROM ('%s') TO ('%s')`,
+			from.Format("2006_01"), from.Format(time.RFC3339), to.Format(time.RFC3339))
+		if _, err := s.db.Exec(ctx, query); err != nil {
+			log.Printf("Warning: Failed creating analytics partition for %s, events go to the default partition: %v", from.Format("2006-01"), err)
+			return
+		}
+	}
+	s.partitionedUntil = month.AddDate(0, 1, 0)
+}
+
+// httpAnalyticsSink sends events to the PostHog or Segment batch API.
+type httpAnalyticsSink struct {
+	provider   string // config.AnalyticsPostHog or config.AnalyticsSegment
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newHTTPAnalyticsSink(cfg *config.Config) *httpAnalyticsSink {
+	endpoint := strings.TrimRight(cfg.AnalyticsEndpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://eu.i.posthog.com"
+		if cfg.AnalyticsSink == config.AnalyticsSegment {
+			endpoint = "https://api.segment.io"
+		}
+	}
+	url := endpoint + "/batch/"
+	if cfg.AnalyticsSink == config.AnalyticsSegment {
+		url = endpoint + "/v1/batch"
+	}
+	return &httpAnalyticsSink{
+		provider:   cfg.AnalyticsSink,
+		url:        url,
+		apiKey:     cfg.AnalyticsAPIKey,
+		httpClient: &http.Client{Timeout: analyticsSendTimeout},
+	}
+}
+
+// payload builds the provider's batch body. Events without a user get a random ID, so the
+// provider can't link them together.
+func (s *httpAnalyticsSink) payload(events []AnalyticsEvent) map[string]interface{} {
+	batch := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		properties := make(map[string]interface{}, len(event.Properties)+2)
+		for key, value := range event.Properties {
+			properties[key] = value
+		}
+		properties["sample_rate"] = event.SampleRate
+		item := map[string]interface{}{"timestamp": event.At.UTC().Format(time.RFC3339Nano)}
+		if s.provider == config.AnalyticsSegment {
+			item["type"] = "track"
+			item["event"] = string(event.Name)
+			if event.UserID != uuid.Nil {
+				item["userId"] = event.UserID.String()
+			} else {
+				item["anonymousId"] = uuid.NewString()
+			}
+		} else {
+			item["event"] = string(event.Name)
+			if event.UserID != uuid.Nil {
+				item["distinct_id"] = event.UserID.String()
+			} else {
+				item["distinct_id"] = uuid.NewString()
+				properties["$process_person_profile"] = false
+			}
+		}
+		item["properties"] = properties
+		batch = append(batch, item)
+	}
+	if s.provider == config.AnalyticsSegment {
+		return map[string]interface{}{"batch": batch}
+	}
+	return map[string]interface{}{"api_key": s.apiKey, "batch": batch}
+}
+
+func (s *httpAnalyticsSink) Send(ctx context.Context, events []AnalyticsEvent) error {
+	body, err := json.Marshal(s.payload(events))
+	if err != nil {
+		return fmt.Errorf("encoding analytics batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.provider == config.AnalyticsSegment {
+		req.SetBasicAuth(s.apiKey, "")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", s.provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", s.provider, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+)
+
+// Test that personal keys are removed and contact details in text values are redacted, nested maps included
+func TestScrubAnalyticsProperties(t *testing.T) {
+	rideID := uuid.NewString()
+	scrubbed := scrubAnalyticsProperties(map[string]interface{}{
+		"ride_id":   rideID,
+		"Email":     "jane@example.com",
+		"latitude":  45.76,
+		"departure": "Lyon",
+		"date":      "2025-06-01",
+		"note":      "call me at 06 12 34 56 78",
+		"context":   map[string]interface{}{"phone": "+33612345678", "referrer": "jane(at)example.com"},
+	})
+
+	if scrubbed["ride_id"] != rideID || scrubbed["departure"] != "Lyon" || scrubbed["date"] != "2025-06-01" {
+		t.Errorf("harmless properties changed: %v", scrubbed)
+	}
+	if _, ok := scrubbed["Email"]; ok {
+		t.Error("email key kept")
+	}
+	if _, ok := scrubbed["latitude"]; ok {
+		t.Error("latitude key kept")
+	}
+	if scrubbed["note"] != "[redacted]" {
+		t.Errorf("note = %v, want redacted phone number", scrubbed["note"])
+	}
+	nested := scrubbed["context"].(map[string]interface{})
+	if _, ok := nested["phone"]; ok || nested["referrer"] != "[redacted]" {
+		t.Errorf("nested properties = %v, want phone removed and email redacted", nested)
+	}
+}
+
+// Test that sampling keeps or drops all events of a user, and honours the bounds
+func TestAnalyticsSampled(t *testing.T) {
+	userID := uuid.New()
+	first := analyticsSampled(50, userID)
+	for i := 0; i < 10; i++ {
+		if analyticsSampled(50, userID) != first {
+			t.Fatal("events of the same user sampled differently")
+		}
+	}
+	if !analyticsSampled(100, uuid.Nil) || analyticsSampled(0, userID) {
+		t.Error("rates 100 and 0 must keep and drop everything")
+	}
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		if analyticsSampled(20, uuid.New()) {
+			kept++
+		}
+	}
+	if kept < 120 || kept > 280 {
+		t.Errorf("kept %d of 1000 users at 20%%, want about 200", kept)
+	}
+}
+
+// Test that events without a consenting user get a fresh ID each, so providers can't link them
+func TestHTTPAnalyticsSink_Payload(t *testing.T) {
+	userID := uuid.New()
+	events := []AnalyticsEvent{
+		{Name: AnalyticsRideViewed, UserID: userID, SampleRate: 100},
+		{Name: AnalyticsSearchPerformed, SampleRate: 100},
+		{Name: AnalyticsSearchPerformed, SampleRate: 100},
+	}
+
+	posthog := newHTTPAnalyticsSink(&config.Config{AnalyticsSink: config.AnalyticsPostHog, AnalyticsAPIKey: "phc_key"})
+	if posthog.url != "https://eu.i.posthog.com/batch/" {
+		t.Errorf("posthog url = %s", posthog.url)
+	}
+	payload := posthog.payload(events)
+	batch := payload["batch"].([]map[string]interface{})
+	if payload["api_key"] != "phc_key" || batch[0]["distinct_id"] != userID.String() {
+		t.Errorf("posthog payload = %v, want the key and the user as distinct_id", payload)
+	}
+	if batch[1]["distinct_id"] == batch[2]["distinct_id"] {
+		t.Error("anonymous events share a distinct_id")
+	}
+	if batch[1]["properties"].(map[string]interface{})["$process_person_profile"] != false {
+		t.Error("anonymous event creates a person profile")
+	}
+
+	segment := newHTTPAnalyticsSink(&config.Config{AnalyticsSink: config.AnalyticsSegment, AnalyticsAPIKey: "write_key"})
+	batch = segment.payload(events)["batch"].([]map[string]interface{})
+	if segment.url != "https://api.segment.io/v1/batch" || batch[0]["userId"] != userID.String() || batch[1]["anonymousId"] == nil {
+		t.Errorf("segment url = %s, batch = %v", segment.url, batch)
+	}
+}
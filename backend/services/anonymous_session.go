@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"net/url" // For normalizing search queries
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// recentSearchLimit is the number of recent searches kept per user or anonymous session.
+const recentSearchLimit = 10
+
+// AnonymousSessionService issues anonymous browsing sessions, so visitors without an account can
+// search rides with their own rate limit budget, and keeps their recent searches until they sign up.
+type AnonymousSessionService struct {
+	cfg *config.Config
+	db  database.DBPool
+}
+
+// NewAnonymousSessionService creates a new AnonymousSessionService instance.
+func NewAnonymousSessionService(cfg *config.Config, db database.DBPool) *AnonymousSessionService {
+	return &AnonymousSessionService{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// Start creates an anonymous session and signs its token, valid for cfg.AnonymousSessionTTL.
+// The token has no user_id claim, so Protected routes reject it.
+func (s *AnonymousSessionService) Start(ctx context.Context) (*models.AnonymousSessionResponse, error) {
+	var sessionID uuid.UUID
+	if err := s.db.QueryRow(ctx, `INSERT INTO anonymous_sessions DEFAULT VALUES RETURNING id`).Scan(&sessionID); err != nil {
+		log.Printf("Error creating anonymous session: %v", err)
+		return nil, fmt.Errorf("database error creating anonymous session: %w", err)
+	}
+	token, expiresAt, err := signToken(s.cfg, jwt.MapClaims{"anonymous_session_id": sessionID.String()}, s.cfg.AnonymousSessionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign anonymous session token: %w", err)
+	}
+	return &models.AnonymousSessionResponse{SessionID: sessionID, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// recentSearchQuery normalizes a search query string: pagination is dropped and parameters are
+// sorted, so paging through results or reordering filters doesn't add a search. Empty if nothing is left.
+func recentSearchQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	values.Del("page")
+	values.Del("limit")
+	for key, value := range values {
+		if len(value) == 0 || value[0] == "" {
+			values.Del(key)
+		}
+	}
+	return values.Encode()
+}
+
+// RecordSearch keeps a search of an anonymous session among its recent searches and counts it for
+// analytics. Sessions already upgraded to an account are ignored. Errors are logged: recent
+// searches are a convenience and never fail the search.
+func (s *AnonymousSessionService) RecordSearch(ctx context.Context, sessionID uuid.UUID, rawQuery string) {
+	query := recentSearchQuery(rawQuery)
+	recordQuery := `
+		WITH session AS (
+			UPDATE anonymous_sessions SET search_count = search_count + 1, last_seen_at = NOW()
+			WHERE id = $1 AND upgraded_at IS NULL
+			RETURNING id
+		), removed AS (
+			DELETE FROM recent_searches WHERE anonymous_session_id IN (SELECT id FROM session) AND query = $2
+		)
+		INSERT INTO recent_searches (anonymous_session_id, query)
+		SELECT id, $2 FROM session WHERE $2 <> ''
+	`
+	if _, err := s.db.Exec(ctx, recordQuery, sessionID, query); err != nil {
+		log.Printf("Warning: Failed recording search of anonymous session %s: %v", sessionID, err)
+		return
+	}
+	trimQuery := `
+		DELETE FROM recent_searches WHERE anonymous_session_id = $1 AND id NOT IN (
+			SELECT id FROM recent_searches WHERE anonymous_session_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	if _, err := s.db.Exec(ctx, trimQuery, sessionID, recentSearchLimit); err != nil {
+		log.Printf("Warning: Failed trimming recent searches of anonymous session %s: %v", sessionID, err)
+	}
+}
+
+// RecordUserSearch keeps a search of a signed-in user among their recent searches. Errors are
+// logged, as for RecordSearch.
+func (s *AnonymousSessionService) RecordUserSearch(ctx context.Context, userID uuid.UUID, rawQuery string) {
+	query := recentSearchQuery(rawQuery)
+	if query == "" {
+		return
+	}
+	recordQuery := `
+		WITH removed AS (
+			DELETE FROM recent_searches WHERE user_id = $1 AND query = $2
+		)
+		INSERT INTO recent_searches (user_id, query) VALUES ($1, $2)
+	`
+	if _, err := s.db.Exec(ctx, recordQuery, userID, query); err != nil {
+		log.Printf("Warning: Failed recording search of user %s: %v", userID, err)
+		return
+	}
+	trimQuery := `
+		DELETE FROM recent_searches WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM recent_searches WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`
+	if _, err := s.db.Exec(ctx, trimQuery, userID, recentSearchLimit); err != nil {
+		log.Printf("Warning: Failed trimming recent searches of user %s: %v", userID, err)
+	}
+}
+
+// Upgrade links an anonymous session to the account created from it and moves its recent searches
+// to the user. A session can only be upgraded once; later calls do nothing.
+func (s *AnonymousSessionService) Upgrade(ctx context.Context, sessionID, userID uuid.UUID) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE anonymous_sessions SET upgraded_user_id = $2, upgraded_at = NOW() WHERE id = $1 AND upgraded_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("database error upgrading anonymous session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil // Unknown, purged or already upgraded
+	}
+	moved, err := tx.Exec(ctx, `UPDATE recent_searches SET user_id = $2, anonymous_session_id = NULL WHERE anonymous_session_id = $1`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("database error moving recent searches: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit anonymous session upgrade: %w", err)
+	}
+	log.Printf("Anonymous session %s upgraded to user %s (%d recent search(es) kept)", sessionID, userID, moved.RowsAffected())
+	return nil
+}
+
+// RecentSearches returns the user's recent searches, newest first.
+func (s *AnonymousSessionService) RecentSearches(ctx context.Context, userID uuid.UUID) ([]models.RecentSearch, error) {
+	query := `SELECT id, query, created_at FROM recent_searches WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := s.db.Query(ctx, query, userID, recentSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing recent searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []models.RecentSearch{}
+	for rows.Next() {
+		var search models.RecentSearch
+		if err := rows.Scan(&search.ID, &search.Query, &search.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error processing recent search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for recent searches: %w", err)
+	}
+	return searches, nil
+}
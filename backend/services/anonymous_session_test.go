@@ -0,0 +1,21 @@
+package services
+
+import "testing"
+
+// Test that pagination and empty filters don't make searches look different
+func TestRecentSearchQuery(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"start_location=Lyon&end_location=Paris&page=2&limit=20", "end_location=Paris&start_location=Lyon"},
+		{"end_location=Paris&start_location=Lyon", "end_location=Paris&start_location=Lyon"},
+		{"start_location=&departure_date=2025-06-01", "departure_date=2025-06-01"},
+		{"page=3", ""},
+		{"%zz", ""},
+	}
+	for _, tt := range tests {
+		if got := recentSearchQuery(tt.raw); got != tt.want {
+			t.Errorf("recentSearchQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
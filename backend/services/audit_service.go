@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"       // For database operations context
+	"encoding/json" // For encoding metadata
+	"fmt"           // For error formatting
+	"log"           // For logging
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// AuditService writes the audit trail of admin and impersonated actions.
+type AuditService struct {
+	db database.DBPool
+}
+
+// NewAuditService creates a new AuditService instance.
+func NewAuditService(db database.DBPool) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record appends an entry to the audit log.
+func (s *AuditService) Record(ctx context.Context, entry models.AuditLogEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.Metadata == nil {
+		entry.Metadata = map[string]interface{}{}
+	}
+	metadataJSON, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (id, actor_id, impersonated_user_id, action, target_type, target_id, method, path, status_code, ip_address, metadata)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, 0), NULLIF($10, ''), $11)
+	`
+	_, err = s.db.Exec(ctx, query,
+		entry.ID, entry.ActorID, entry.ImpersonatedUserID, entry.Action, entry.TargetType, entry.TargetID,
+		entry.Method, entry.Path, entry.StatusCode, entry.IPAddress, metadataJSON,
+	)
+	if err != nil {
+		log.Printf("Audit Error: Failed to record '%s' by actor %v: %v", entry.Action, entry.ActorID, err)
+		return fmt.Errorf("database error recording audit log: %w", err)
+	}
+	return nil
+}
+
+// RecordImpersonatedRequest logs a request made with an impersonation token.
+// Used by the auth middleware; failures are logged only so the request itself is not affected.
+func (s *AuditService) RecordImpersonatedRequest(ctx context.Context, impersonatorID, userID uuid.UUID, method, path string, statusCode int, ip string) {
+	_ = s.Record(ctx, models.AuditLogEntry{
+		ActorID:            &impersonatorID,
+		ImpersonatedUserID: &userID,
+		Action:             models.AuditActionImpersonatedRequest,
+		Method:             method,
+		Path:               path,
+		StatusCode:         statusCode,
+		IPAddress:          ip,
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"  // For metric formatting
+	"io"   // For writing the exposition
+	"sort" // For stable output order
+	"sync" // For the counters
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Login outcomes counted by AuthMetrics. Only the metrics tell unknown emails and wrong passwords
+// apart: both get the same response, in about the same time.
+const (
+	LoginOutcomeSuccess        = "success"
+	LoginOutcomeInvalidRequest = "invalid_request" // Failed validation (e.g. malformed email)
+	LoginOutcomeUnknownEmail   = "unknown_email"   // No active account with this email
+	LoginOutcomeWrongPassword  = "wrong_password"
+	LoginOutcomeError          = "error" // Database or decryption failure
+)
+
+// AuthMetrics counts login attempts by outcome, so credential stuffing (a spike of unknown emails)
+// and password guessing (a spike of wrong passwords) can be alerted on.
+type AuthMetrics struct {
+	mu       sync.Mutex
+	outcomes map[string]int64
+}
+
+// NewAuthMetrics creates empty login counters.
+func NewAuthMetrics() *AuthMetrics {
+	return &AuthMetrics{outcomes: make(map[string]int64)}
+}
+
+// RecordLogin counts one login attempt.
+func (m *AuthMetrics) RecordLogin(outcome string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.outcomes[outcome]++
+	m.mu.Unlock()
+}
+
+// WritePrometheus writes the login attempt counters.
+func (m *AuthMetrics) WritePrometheus(w io.Writer) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]string, 0, len(m.outcomes))
+	for outcome := range m.outcomes {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	fmt.Fprintln(w, "# HELP rideshare_login_attempts_total Login attempts by outcome.")
+	fmt.Fprintln(w, "# TYPE rideshare_login_attempts_total counter")
+	for _, outcome := range outcomes {
+		fmt.Fprintf(w, "rideshare_login_attempts_total%s %d\n", labels("outcome", outcome), m.outcomes[outcome])
+	}
+}
+
+var (
+	dummyPasswordHashOnce sync.Once
+	dummyPasswordHash     []byte
+)
+
+// compareDummyPassword spends as long as checking a real password, so a login for an unknown
+// email can't be told apart from a wrong password by its response time.
+func compareDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		// Same cost as stored hashes (see SignUp)
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
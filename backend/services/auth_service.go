@@ -5,12 +5,14 @@ import (
 	"errors"  // For creating standard errors
 	"fmt"     // For string formatting
 	"log"     // For logging
+	"strings" // For blank invite codes
 	"time"    // For time operations (JWT expiry)
 
 	"github.com/go-playground/validator/v10" // For request validation
 	"github.com/golang-jwt/jwt/v5"           // For JWT generation and validation
 	"github.com/google/uuid"                 // For UUIDs
 	"github.com/jackc/pgx/v5"                // For pgx specific errors (like no rows)
+	"github.com/jackc/pgx/v5/pgconn"         // For unique violations
 	"golang.org/x/crypto/bcrypt"             // For password hashing
 
 	"rideshare/backend/config"   // Local config package
@@ -22,16 +24,47 @@ import (
 type AuthService struct {
 	cfg       *config.Config
 	validator *validator.Validate
+	fraud     *FraudService   // Fraud rules evaluated on signup (optional)
+	crypto    *FieldEncryptor // Encrypts WhatsApp numbers, birth dates and locations
+	legal     *LegalService   // Records acceptance of the current terms at signup (optional)
+	consents  *ConsentService // Location storage needs the user's consent (optional)
+	metrics   *AuthMetrics    // Login attempts by outcome
 }
 
 // NewAuthService creates a new AuthService instance.
-func NewAuthService(cfg *config.Config) *AuthService {
+func NewAuthService(cfg *config.Config, fraud *FraudService, crypto *FieldEncryptor, legal *LegalService, consents *ConsentService) *AuthService {
 	return &AuthService{
 		cfg:       cfg,
-		validator: validator.New(), // Initialize validator
+		validator: NewValidator(), // Initialize validator
+		fraud:     fraud,
+		crypto:    crypto,
+		legal:     legal,
+		consents:  consents,
+		metrics:   NewAuthMetrics(),
 	}
 }
 
+// Metrics returns the login attempt counters, exposed on /metrics.
+func (s *AuthService) Metrics() *AuthMetrics {
+	return s.metrics
+}
+
+// userPIIColumns selects the encrypted PII columns, falling back to legacy plaintext for rows
+// the backfill hasn't reached yet. Scan them with scanUserPII.
+const userPIIColumns = `COALESCE(birth_date_encrypted, birth_date::text), COALESCE(whatsapp_encrypted, whatsapp, '')`
+
+// decryptUserPII fills the user's birth date and WhatsApp number from userPIIColumns.
+func (s *AuthService) decryptUserPII(user *models.User, birthDate *string, whatsapp string) error {
+	var err error
+	if user.BirthDate, err = s.crypto.DecryptDate(birthDate); err != nil {
+		return fmtErrorf("failed to decrypt birth date: %w", err)
+	}
+	if user.WhatsApp, err = s.crypto.Decrypt(whatsapp); err != nil {
+		return fmtErrorf("failed to decrypt whatsapp: %w", err)
+	}
+	return nil
+}
+
 // SignUp handles user registration.
 func (s *AuthService) SignUp(ctx context.Context, req models.SignUpRequest) (*models.User, error) {
 	// 1. Validate request data
@@ -39,18 +72,35 @@ func (s *AuthService) SignUp(ctx context.Context, req models.SignUpRequest) (*mo
 		log.Printf("Validation error during signup for email %s: %v", req.Email, err)
 		return nil, fmtErrorf("invalid signup data: %w", err) // Return validation error
 	}
+	if s.cfg.SignupMode == config.SignupInviteOnly && strings.TrimSpace(req.InviteCode) == "" {
+		return nil, ErrInviteCodeRequired
+	}
 
-	// 2. Check if email or WhatsApp number already exists
+	// 2. Check if email or WhatsApp number already exists. Concurrent signups can both pass this check;
+	// the unique indexes on active users' email and WhatsApp blind index reject the second insert.
 	var exists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp = $2) AND deleted_at IS NULL)` // Also check not deleted
-	err := database.DB.QueryRow(ctx, checkQuery, req.Email, req.WhatsApp).Scan(&exists)
+	// WhatsApp numbers are encrypted, so they're matched on their blind index
+	whatsappHash := s.crypto.BlindIndex(req.WhatsApp)
+	checkQuery := `SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp_hash = $2) AND deleted_at IS NULL)` // Also check not deleted
+	err := database.DB.QueryRow(ctx, checkQuery, req.Email, whatsappHash).Scan(&exists)
 	if err != nil {
 		log.Printf("Error checking user existence for email %s: %v", req.Email, err)
 		return nil, fmtErrorf("database error checking user existence: %w", err)
 	}
 	if exists {
 		log.Printf("Signup attempt failed: Email '%s' or WhatsApp '%s' already exists.", req.Email, req.WhatsApp)
-		return nil, errors.New("email or WhatsApp number already registered") // User-friendly error
+		return nil, ErrAlreadyRegistered // User-friendly error
+	}
+
+	// 2b. Fraud rules (e.g. too many signups from one IP). There is no booking to hold, so a hold refuses the signup.
+	var fraudDecision *models.FraudDecision
+	fraudCheck := models.FraudCheck{Event: models.FraudEventSignup, IPAddress: req.IPAddress}
+	if s.fraud != nil {
+		fraudDecision = s.fraud.Evaluate(ctx, fraudCheck)
+		if fraudDecision.Action == models.FraudActionHold {
+			log.Printf("Signup blocked by fraud rules for email %s (IP %s): %v", req.Email, req.IPAddress, fraudDecision.TriggeredRules)
+			return nil, newError(KindForbidden, "signups are temporarily restricted, please try again later")
+		}
 	}
 
 	// 3. Hash the password
@@ -64,7 +114,11 @@ func (s *AuthService) SignUp(ctx context.Context, req models.SignUpRequest) (*mo
 	birthDate, err := time.Parse("2006-01-02", req.BirthDate)
 	if err != nil {
 		log.Printf("Error parsing birth date '%s' for email %s: %v", req.BirthDate, req.Email, err)
-		return nil, fmtErrorf("invalid birth date format (use YYYY-MM-DD): %w", err)
+		return nil, &Error{Kind: KindInvalid, Message: "invalid birth date format (use YYYY-MM-DD)", Err: err}
+	}
+	if err := s.checkSignupAge(birthDate); err != nil {
+		log.Printf("Signup refused for email %s: %v", req.Email, err)
+		return nil, err
 	}
 
 	// 5. Create the user in the database
@@ -80,22 +134,88 @@ func (s *AuthService) SignUp(ctx context.Context, req models.SignUpRequest) (*mo
 		// CreatedAt and UpdatedAt will be set by default in DB
 		// DeletedAt is NULL by default
 	}
+	if req.Gender != "" {
+		newUser.Gender = &req.Gender
+	}
+
+	// Default the locale from the signup country (IP geolocation) until the user picks one
+	locale := s.cfg.DefaultLocale
+	if geo := models.GeoFromContext(ctx); geo != nil {
+		locale = LocaleForCountry(geo.CountryCode, locale)
+	}
+
+	encryptedBirthDate, err := s.crypto.EncryptDate(birthDate)
+	if err != nil {
+		return nil, fmtErrorf("failed to encrypt birth date: %w", err)
+	}
+	encryptedWhatsApp, err := s.crypto.Encrypt(req.WhatsApp)
+	if err != nil {
+		return nil, fmtErrorf("failed to encrypt whatsapp: %w", err)
+	}
+
+	// A given invite code is redeemed in the transaction creating the account (required when SIGNUP_MODE is invite_only)
+	var db rowQuerier = database.DB
+	var inviteTx pgx.Tx
+	var inviteCodeID *uuid.UUID
+	if strings.TrimSpace(req.InviteCode) != "" {
+		inviteTx, err = database.DB.Begin(ctx)
+		if err != nil {
+			return nil, fmtErrorf("failed to start database transaction: %w", err)
+		}
+		defer inviteTx.Rollback(ctx)
+		codeID, err := redeemInviteCode(ctx, inviteTx, req.InviteCode)
+		if err != nil {
+			log.Printf("Signup refused for email %s: %v", req.Email, err)
+			return nil, err
+		}
+		inviteCodeID = &codeID
+		db = inviteTx
+	}
 
 	insertQuery := `
-		INSERT INTO users (id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING created_at, updated_at
+		INSERT INTO users (id, email, password_hash, first_name, last_name, birth_date_encrypted, nationality, whatsapp_encrypted, whatsapp_hash, preferred_locale, invite_code_id, gender)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'en'), $11, $12)
+		RETURNING created_at, updated_at, preferred_locale
 	`
-	err = database.DB.QueryRow(ctx, insertQuery,
-		newUser.ID, newUser.Email, newUser.PasswordHash, newUser.FirstName, newUser.LastName, newUser.BirthDate, newUser.Nationality, newUser.WhatsApp,
-	).Scan(&newUser.CreatedAt, &newUser.UpdatedAt)
+	err = db.QueryRow(ctx, insertQuery,
+		newUser.ID, newUser.Email, newUser.PasswordHash, newUser.FirstName, newUser.LastName, encryptedBirthDate, newUser.Nationality, encryptedWhatsApp, whatsappHash, locale, inviteCodeID, newUser.Gender,
+	).Scan(&newUser.CreatedAt, &newUser.UpdatedAt, &newUser.PreferredLocale)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation: registered concurrently
+			log.Printf("Signup attempt failed: Email '%s' or WhatsApp '%s' was registered concurrently.", req.Email, req.WhatsApp)
+			return nil, ErrAlreadyRegistered
+		}
 		log.Printf("Error inserting new user for email %s: %v", req.Email, err)
 		return nil, fmtErrorf("failed to create user in database: %w", err)
 	}
+	if inviteTx != nil {
+		if err := inviteTx.Commit(ctx); err != nil {
+			log.Printf("Error committing signup for email %s: %v", req.Email, err)
+			return nil, fmtErrorf("failed to finalize signup: %w", err)
+		}
+		log.Printf("User %s signed up with invite code %s", newUser.ID, *inviteCodeID)
+	}
 
 	log.Printf("User created successfully: %s (ID: %s)", newUser.Email, newUser.ID)
+	if s.fraud != nil {
+		s.fraud.RecordEvent(ctx, fraudCheck)
+	}
+	// Signing up accepts the terms and privacy policy shown on the signup screen
+	if s.legal != nil {
+		if _, err := s.legal.AcceptCurrent(ctx, newUser.ID, req.IPAddress); err != nil {
+			log.Printf("Warning: Failed recording terms acceptance for new user %s: %v", newUser.ID, err)
+		}
+	}
+
+	// Attach the flagged signup to the account so reviewers can find it
+	if fraudDecision != nil && fraudDecision.FlagID != nil {
+		if _, err := database.DB.Exec(ctx, `UPDATE fraud_flags SET user_id = $1 WHERE id = $2`, newUser.ID, *fraudDecision.FlagID); err != nil {
+			log.Printf("Warning: Failed linking fraud flag %s to new user %s: %v", *fraudDecision.FlagID, newUser.ID, err)
+		}
+	}
+
 	// Don't return password hash in the response model
 	newUser.PasswordHash = ""
 	return newUser, nil
@@ -106,26 +226,32 @@ func (s *AuthService) Login(ctx context.Context, req models.LoginRequest) (*mode
 	// 1. Validate request data
 	if err := s.validator.Struct(req); err != nil {
 		log.Printf("Validation error during login for email %s: %v", req.Email, err)
+		s.metrics.RecordLogin(LoginOutcomeInvalidRequest)
 		return nil, fmtErrorf("invalid login data: %w", err)
 	}
 
 	// 2. Find the user by email (ensure not deleted)
 	var user models.User
+	var birthDate *string
+	var whatsapp string
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp, created_at, updated_at, stripe_customer_id
+		SELECT id, email, password_hash, first_name, last_name, nationality, created_at, updated_at, stripe_customer_id, preferred_locale, gender, ` + userPIIColumns + `
 		FROM users WHERE email = $1 AND deleted_at IS NULL
 	` // Added deleted_at check and stripe_customer_id
 	// Use pointer for stripe_customer_id to handle NULL
 	err := database.DB.QueryRow(ctx, query, req.Email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.BirthDate, &user.Nationality, &user.WhatsApp, &user.CreatedAt, &user.UpdatedAt, &user.StripeCustomerID,
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Nationality, &user.CreatedAt, &user.UpdatedAt, &user.StripeCustomerID, &user.PreferredLocale, &user.Gender, &birthDate, &whatsapp,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("Login attempt failed: User not found or deleted for email %s", req.Email) // Updated log
-			return nil, errors.New("invalid email or password")                                   // Generic error for security
+			compareDummyPassword(req.Password)                                                    // Take as long as a wrong password
+			s.metrics.RecordLogin(LoginOutcomeUnknownEmail)
+			return nil, ErrInvalidCredentials // Generic error for security
 		}
 		log.Printf("Error fetching user during login for email %s: %v", req.Email, err)
+		s.metrics.RecordLogin(LoginOutcomeError)
 		return nil, fmtErrorf("database error fetching user: %w", err)
 	}
 
@@ -134,17 +260,30 @@ func (s *AuthService) Login(ctx context.Context, req models.LoginRequest) (*mode
 	if err != nil {
 		// Password doesn't match
 		log.Printf("Login attempt failed: Invalid password for email %s", req.Email)
-		return nil, errors.New("invalid email or password") // Generic error
+		s.metrics.RecordLogin(LoginOutcomeWrongPassword)
+		return nil, ErrInvalidCredentials // Generic error
+	}
+	if err := s.decryptUserPII(&user, birthDate, whatsapp); err != nil {
+		log.Printf("Error decrypting profile of user %s during login: %v", user.ID, err)
+		s.metrics.RecordLogin(LoginOutcomeError)
+		return nil, err
 	}
 
 	// 4. Generate JWT token
-	token, err := s.generateJWT(user.ID)
+	token, expiresAt, err := s.generateJWT(user.ID)
 	if err != nil {
 		log.Printf("Error generating JWT for user %s: %v", user.ID, err)
+		s.metrics.RecordLogin(LoginOutcomeError)
 		return nil, fmtErrorf("failed to generate authentication token: %w", err)
 	}
 
+	// Ratings are informative, so a failed lookup doesn't fail the login
+	if user.Rating, err = userRating(ctx, database.DB, user.ID); err != nil {
+		log.Printf("Warning: Failed loading rating of user %s: %v", user.ID, err)
+	}
+
 	log.Printf("User logged in successfully: %s (ID: %s)", user.Email, user.ID)
+	s.metrics.RecordLogin(LoginOutcomeSuccess)
 
 	// Prepare response (don't include password hash)
 	// Determine if user has a payment method based on StripeCustomerID
@@ -153,33 +292,37 @@ func (s *AuthService) Login(ctx context.Context, req models.LoginRequest) (*mode
 	user.PasswordHash = ""
 	user.StripeCustomerID = nil // Don't send stripe customer id to frontend
 	loginResponse := &models.LoginResponse{
-		Token: token,
-		User:  user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
 	}
 
 	return loginResponse, nil
 }
 
-// generateJWT creates a new JWT token for a given user ID.
-func (s *AuthService) generateJWT(userID uuid.UUID) (string, error) {
-	// Set custom claims
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Token expires after 72 hours
-		"iat":     time.Now().Unix(),                     // Issued at time
+// generateJWT creates a new JWT token for a given user ID, valid for cfg.JWTAccessTokenTTL.
+func (s *AuthService) generateJWT(userID uuid.UUID) (string, time.Time, error) {
+	signedToken, expiresAt, err := signToken(s.cfg, jwt.MapClaims{"user_id": userID.String()}, s.cfg.JWTAccessTokenTTL)
+	if err != nil {
+		return "", time.Time{}, fmtErrorf("failed to sign token: %w", err)
 	}
+	return signedToken, expiresAt, nil
+}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Generate encoded token and return it as a string.
-	// The secret key should be loaded from config and be strong!
-	signedToken, err := token.SignedString([]byte(s.cfg.JWTSecret))
+// signToken adds the expiry, issued-at, issuer and audience claims to claims and signs them with
+// the JWT secret. middleware.Protected rejects tokens whose issuer or audience doesn't match.
+func signToken(cfg *config.Config, claims jwt.MapClaims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims["exp"] = expiresAt.Unix()
+	claims["iat"] = now.Unix()
+	claims["iss"] = cfg.JWTIssuer
+	claims["aud"] = cfg.JWTAudience
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
 	if err != nil {
-		return "", fmtErrorf("failed to sign token: %w", err)
+		return "", time.Time{}, err
 	}
-
-	return signedToken, nil
+	return signedToken, expiresAt, nil
 }
 
 // Helper function to wrap errors (optional, can make error handling cleaner)
@@ -218,10 +361,14 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, req m
 		birthDate, err := time.Parse("2006-01-02", *req.BirthDate)
 		if err != nil {
 			log.Printf("Error parsing birth date '%s' during update for user %s: %v", *req.BirthDate, userID, err)
-			return nil, fmtErrorf("invalid birth date format (use YYYY-MM-DD): %w", err)
+			return nil, &Error{Kind: KindInvalid, Message: "invalid birth date format (use YYYY-MM-DD)", Err: err}
+		}
+		encryptedBirthDate, err := s.crypto.EncryptDate(birthDate)
+		if err != nil {
+			return nil, fmtErrorf("failed to encrypt birth date: %w", err)
 		}
-		query += fmt.Sprintf(", birth_date = $%d", argID)
-		args = append(args, birthDate)
+		query += fmt.Sprintf(", birth_date_encrypted = $%d, birth_date = NULL", argID)
+		args = append(args, encryptedBirthDate)
 		argID++
 	}
 	if req.Nationality != nil {
@@ -233,18 +380,33 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, req m
 		// Check for WhatsApp uniqueness before adding to query (excluding the current user)
 		var exists bool
 		// Ensure we only check against other active users
-		checkQuery := `SELECT EXISTS(SELECT 1 FROM users WHERE whatsapp = $1 AND id != $2 AND deleted_at IS NULL)`
-		err := database.DB.QueryRow(ctx, checkQuery, *req.WhatsApp, userID).Scan(&exists)
+		whatsappHash := s.crypto.BlindIndex(*req.WhatsApp)
+		checkQuery := `SELECT EXISTS(SELECT 1 FROM users WHERE whatsapp_hash = $1 AND id != $2 AND deleted_at IS NULL)`
+		err := database.DB.QueryRow(ctx, checkQuery, whatsappHash, userID).Scan(&exists)
 		if err != nil {
 			log.Printf("Error checking WhatsApp uniqueness during update for user %s: %v", userID, err)
 			return nil, fmtErrorf("database error checking whatsapp uniqueness: %w", err)
 		}
 		if exists {
 			log.Printf("Profile update failed for user %s: WhatsApp number '%s' already registered by another user.", userID, *req.WhatsApp)
-			return nil, errors.New("whatsapp number already registered")
+			return nil, ErrWhatsAppTaken
 		}
-		query += fmt.Sprintf(", whatsapp = $%d", argID)
-		args = append(args, *req.WhatsApp)
+		encryptedWhatsApp, err := s.crypto.Encrypt(*req.WhatsApp)
+		if err != nil {
+			return nil, fmtErrorf("failed to encrypt whatsapp: %w", err)
+		}
+		query += fmt.Sprintf(", whatsapp_encrypted = $%d, whatsapp_hash = $%d, whatsapp = NULL", argID, argID+1)
+		args = append(args, encryptedWhatsApp, whatsappHash)
+		argID += 2
+	}
+	if req.Locale != nil {
+		query += fmt.Sprintf(", preferred_locale = $%d", argID)
+		args = append(args, *req.Locale)
+		argID++
+	}
+	if req.Gender != nil {
+		query += fmt.Sprintf(", gender = $%d", argID)
+		args = append(args, *req.Gender)
 		argID++
 	}
 
@@ -253,40 +415,205 @@ func (s *AuthService) UpdateProfile(ctx context.Context, userID uuid.UUID, req m
 		log.Printf("No fields provided for profile update for user %s", userID)
 		// Return current user data without performing an update? Or return an error?
 		// Let's return an error indicating nothing was updated.
-		return nil, errors.New("no update data provided")
+		return nil, newError(KindInvalid, "no update data provided")
 	}
 
 	// Add WHERE clause and RETURNING clause to get updated user data
 	query += fmt.Sprintf(" WHERE id = $%d AND deleted_at IS NULL", argID) // Ensure user is not deleted
 	args = append(args, userID)
-	query += ` RETURNING id, email, first_name, last_name, birth_date, nationality, whatsapp, created_at, updated_at`
+	query += ` RETURNING id, email, first_name, last_name, nationality, created_at, updated_at, preferred_locale, gender, ` + userPIIColumns
 
 	log.Printf("Executing profile update for user %s with query: %s", userID, query)
 
-	// 3. Execute the update query
+	// 3. Lock the current profile so the history records what this update replaced
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmtErrorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var previous models.User
+	var birthDate *string
+	var whatsapp string
+	currentQuery := `
+		SELECT first_name, last_name, nationality, preferred_locale, gender, ` + userPIIColumns + `
+		FROM users WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, currentQuery, userID).Scan(&previous.FirstName, &previous.LastName, &previous.Nationality, &previous.PreferredLocale, &previous.Gender, &birthDate, &whatsapp)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Profile update failed: User %s not found or already deleted.", userID)
+			return nil, ErrUserNotFoundOrDeleted
+		}
+		return nil, fmtErrorf("database error loading profile: %w", err)
+	}
+	if err := s.decryptUserPII(&previous, birthDate, whatsapp); err != nil {
+		return nil, err
+	}
+
+	// 4. Execute the update query
 	var updatedUser models.User
-	err := database.DB.QueryRow(ctx, query, args...).Scan(
-		&updatedUser.ID, &updatedUser.Email, &updatedUser.FirstName, &updatedUser.LastName,
-		&updatedUser.BirthDate, &updatedUser.Nationality, &updatedUser.WhatsApp,
-		&updatedUser.CreatedAt, &updatedUser.UpdatedAt,
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&updatedUser.ID, &updatedUser.Email, &updatedUser.FirstName, &updatedUser.LastName, &updatedUser.Nationality,
+		&updatedUser.CreatedAt, &updatedUser.UpdatedAt, &updatedUser.PreferredLocale, &updatedUser.Gender, &birthDate, &whatsapp,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			// This could happen if the user ID doesn't exist or is already deleted
 			log.Printf("Profile update failed: User %s not found or already deleted.", userID)
-			return nil, errors.New("user not found or deleted")
+			return nil, ErrUserNotFoundOrDeleted
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation: the number was registered since the check above
+			log.Printf("Profile update failed for user %s: WhatsApp number registered concurrently.", userID)
+			return nil, ErrWhatsAppTaken
 		}
 		log.Printf("Error updating profile for user %s: %v", userID, err)
-		// Handle potential unique constraint violation on whatsapp if check above failed due to race condition? Unlikely but possible.
 		return nil, fmtErrorf("failed to update profile in database: %w", err)
 	}
 
+	if err := s.decryptUserPII(&updatedUser, birthDate, whatsapp); err != nil {
+		log.Printf("Error decrypting updated profile of user %s: %v", userID, err)
+		return nil, err
+	}
+
+	// 5. Record the changed fields in the profile history
+	if err := s.recordProfileChanges(ctx, tx, userID, &previous, &updatedUser, req.IPAddress); err != nil {
+		log.Printf("Error recording profile history for user %s: %v", userID, err)
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmtErrorf("failed to commit profile update: %w", err)
+	}
+
+	if updatedUser.Rating, err = userRating(ctx, database.DB, userID); err != nil {
+		log.Printf("Warning: Failed loading rating of user %s: %v", userID, err)
+	}
+
 	log.Printf("Profile updated successfully for user %s", userID)
 	return &updatedUser, nil
 }
 
-// DeleteAccount performs a soft delete on the user account.
+// encryptedProfileFields are the history fields whose values are stored encrypted.
+var encryptedProfileFields = map[string]bool{"whatsapp": true, "birth_date": true}
+
+// formatBirthDate formats an optional birth date for the profile history.
+func formatBirthDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.Format("2006-01-02")
+	return &formatted
+}
+
+// recordProfileChanges inserts a profile_changes row for every field that differs between the
+// previous and updated profile.
+func (s *AuthService) recordProfileChanges(ctx context.Context, tx pgx.Tx, userID uuid.UUID, previous, updated *models.User, ip string) error {
+	fields := []struct {
+		name          string
+		before, after *string
+	}{
+		{"first_name", previous.FirstName, updated.FirstName},
+		{"last_name", previous.LastName, updated.LastName},
+		{"birth_date", formatBirthDate(previous.BirthDate), formatBirthDate(updated.BirthDate)},
+		{"nationality", previous.Nationality, updated.Nationality},
+		{"whatsapp", &previous.WhatsApp, &updated.WhatsApp},
+		{"preferred_locale", &previous.PreferredLocale, &updated.PreferredLocale},
+		{"gender", previous.Gender, updated.Gender},
+	}
+	insertQuery := `
+		INSERT INTO profile_changes (user_id, field, old_value, new_value, ip_address)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`
+	for _, field := range fields {
+		if equalStringPtr(field.before, field.after) {
+			continue
+		}
+		before, after := field.before, field.after
+		if encryptedProfileFields[field.name] {
+			var err error
+			if before, err = s.encryptOptional(before); err != nil {
+				return err
+			}
+			if after, err = s.encryptOptional(after); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, insertQuery, userID, field.name, before, after, ip); err != nil {
+			return fmtErrorf("database error recording profile change: %w", err)
+		}
+	}
+	return nil
+}
+
+// encryptOptional encrypts a value if it's set and not empty.
+func (s *AuthService) encryptOptional(value *string) (*string, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	encrypted, err := s.crypto.Encrypt(*value)
+	if err != nil {
+		return nil, fmtErrorf("failed to encrypt profile history value: %w", err)
+	}
+	return &encrypted, nil
+}
+
+// equalStringPtr reports whether two optional strings hold the same value.
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// profileHistoryLimit caps the entries returned by ProfileHistory.
+const profileHistoryLimit = 100
+
+// ProfileHistory returns the user's most recent profile changes, newest first.
+func (s *AuthService) ProfileHistory(ctx context.Context, userID uuid.UUID) ([]models.ProfileChange, error) {
+	query := `
+		SELECT id, field, old_value, new_value, ip_address, changed_at
+		FROM profile_changes
+		WHERE user_id = $1
+		ORDER BY changed_at DESC
+		LIMIT $2
+	`
+	rows, err := database.DB.Query(ctx, query, userID, profileHistoryLimit)
+	if err != nil {
+		log.Printf("Error fetching profile history for user %s: %v", userID, err)
+		return nil, fmtErrorf("database error fetching profile history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.ProfileChange{}
+	for rows.Next() {
+		var change models.ProfileChange
+		if err := rows.Scan(&change.ID, &change.Field, &change.OldValue, &change.NewValue, &change.IPAddress, &change.ChangedAt); err != nil {
+			return nil, fmtErrorf("error processing profile history: %w", err)
+		}
+		if encryptedProfileFields[change.Field] {
+			for _, value := range []*string{change.OldValue, change.NewValue} {
+				if value == nil {
+					continue
+				}
+				if *value, err = s.crypto.Decrypt(*value); err != nil {
+					return nil, fmtErrorf("failed to decrypt profile history: %w", err)
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmtErrorf("database iteration error for profile history: %w", err)
+	}
+	return changes, nil
+}
+
+// DeleteAccount performs a soft delete on the user account. Personal data is erased by the
+// ErasureService once ERASURE_GRACE_PERIOD has passed. Rides and participations are handled by
+// AccountDeletionService, which calls this last.
 func (s *AuthService) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
 	log.Printf("Attempting soft delete for user %s", userID)
 
@@ -300,12 +627,10 @@ func (s *AuthService) DeleteAccount(ctx context.Context, userID uuid.UUID) error
 
 	if tag.RowsAffected() == 0 {
 		log.Printf("Soft delete failed: User %s not found or already deleted.", userID)
-		return errors.New("user not found or already deleted")
+		return newError(KindNotFound, "user not found or already deleted")
 	}
 
 	log.Printf("User %s soft deleted successfully.", userID)
-	// TODO: Add logic to handle related data if necessary (e.g., cancel active rides/participations?)
-	// For V2, just deleting the user might be sufficient as per requirements.
 	return nil
 }
 
@@ -313,21 +638,36 @@ func (s *AuthService) DeleteAccount(ctx context.Context, userID uuid.UUID) error
 func (s *AuthService) UpdateLocation(ctx context.Context, userID uuid.UUID, latitude float64, longitude float64) error {
 	log.Printf("Attempting to update location for user %s to Lat: %f, Lon: %f", userID, latitude, longitude)
 
-	// Validate coordinates roughly (basic checks, more complex validation could be added)
-	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+	// Same coordinate rules as ride points: ranges and no (0, 0)
+	if err := s.validator.Struct(models.UpdateLocationRequest{Latitude: latitude, Longitude: longitude}); err != nil {
 		log.Printf("Invalid coordinates provided for user %s: Lat=%f, Lon=%f", userID, latitude, longitude)
-		return errors.New("invalid latitude or longitude provided")
+		return fmt.Errorf("invalid location: %w", err)
+	}
+	if s.consents != nil {
+		granted, err := s.consents.Has(ctx, userID, models.ConsentLocationStorage)
+		if err != nil {
+			return err
+		}
+		if !granted {
+			log.Printf("Update location refused for user %s: no location storage consent", userID)
+			return ErrLocationConsentRequired
+		}
 	}
 
-	// Use ST_MakePoint(longitude, latitude) for PostGIS POINT type
-	// SRID 4326 corresponds to WGS 84
+	// The exact location is only stored encrypted; the plaintext geohash keeps only a coarse cell
+	encryptedLocation, err := s.crypto.EncryptLocation(latitude, longitude)
+	if err != nil {
+		return fmtErrorf("failed to encrypt location: %w", err)
+	}
 	query := `
 		UPDATE users
-		SET last_known_location = ST_SetSRID(ST_MakePoint($1, $2), 4326),
+		SET last_known_location_encrypted = $1,
+		    last_known_location = NULL,
+		    last_known_geohash = $3,
 		    updated_at = NOW()
-		WHERE id = $3 AND deleted_at IS NULL
+		WHERE id = $2 AND deleted_at IS NULL
 	`
-	tag, err := database.DB.Exec(ctx, query, longitude, latitude, userID) // Note: Longitude first for ST_MakePoint
+	tag, err := database.DB.Exec(ctx, query, encryptedLocation, userID, EncodeGeohash(latitude, longitude, userLocationGeohashPrecision))
 
 	if err != nil {
 		log.Printf("Error updating location for user %s: %v", userID, err)
@@ -336,7 +676,7 @@ func (s *AuthService) UpdateLocation(ctx context.Context, userID uuid.UUID, lati
 
 	if tag.RowsAffected() == 0 {
 		log.Printf("Update location failed: User %s not found or already deleted.", userID)
-		return errors.New("user not found or deleted")
+		return ErrUserNotFoundOrDeleted
 	}
 
 	log.Printf("Location updated successfully for user %s", userID)
@@ -350,12 +690,13 @@ func (s *AuthService) RegisterPushToken(ctx context.Context, userID uuid.UUID, p
 	// Basic validation for the token (Expo tokens usually start with ExponentPushToken[...])
 	if len(pushToken) < 10 { // Arbitrary basic check
 		log.Printf("Invalid push token format provided for user %s: %s", userID, pushToken)
-		return errors.New("invalid push token format")
+		return newError(KindInvalid, "invalid push token format")
 	}
 
 	query := `
 		UPDATE users
 		SET expo_push_token = $1,
+		    push_token_registered_at = NOW(), push_token_inactive_at = NULL, -- Registering again revives a dormant token
 		    updated_at = NOW()
 		WHERE id = $2 AND deleted_at IS NULL
 	`
@@ -368,7 +709,7 @@ func (s *AuthService) RegisterPushToken(ctx context.Context, userID uuid.UUID, p
 
 	if tag.RowsAffected() == 0 {
 		log.Printf("Register push token failed: User %s not found or already deleted.", userID)
-		return errors.New("user not found or deleted")
+		return ErrUserNotFoundOrDeleted
 	}
 
 	log.Printf("Push token registered successfully for user %s", userID)
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"regexp" // For matching SQL queries in mock
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v3" // Mocking library
 	"golang.org/x/crypto/bcrypt"
 
@@ -31,10 +34,17 @@ func setupAuthTest(t *testing.T) (*AuthService, pgxmock.PgxPoolIface) {
 	// Create a dummy config for the service
 	// Important: Use a consistent JWT secret for testing token generation/validation if needed
 	testCfg := &config.Config{
-		JWTSecret: "test-secret-key", // Use a fixed secret for tests
+		JWTSecret:         "test-secret-key", // Use a fixed secret for tests
+		JWTAccessTokenTTL: 72 * time.Hour,
+		JWTIssuer:         "rideshare-test",
+		JWTAudience:       "rideshare-test-app",
 		// Add other config fields if the service uses them directly
 	}
-	authService := NewAuthService(testCfg)
+	crypto, err := NewFieldEncryptor(testCfg) // Development keys derived from the JWT secret
+	if err != nil {
+		t.Fatalf("Failed to create field encryptor: %v", err)
+	}
+	authService := NewAuthService(testCfg, nil, crypto, nil, nil)
 
 	return authService, mock
 }
@@ -65,19 +75,20 @@ func TestAuthService_SignUp_Success(t *testing.T) {
 	// --- Mock Expectations ---
 	// 1. Expect check for existing user (email/whatsapp) - return false (not exists)
 	// Updated regex to include the deleted_at check
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp = $2) AND deleted_at IS NULL)`)).
-		WithArgs(req.Email, req.WhatsApp).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp_hash = $2) AND deleted_at IS NULL)`)).
+		WithArgs(req.Email, authService.crypto.BlindIndex(req.WhatsApp)).
 		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
 
 	// 2. Expect insertion of the new user - return timestamps
 	// Use relaxed args matching for password hash and UUID as they are generated dynamically
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		INSERT INTO users (id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING created_at, updated_at
+		INSERT INTO users (id, email, password_hash, first_name, last_name, birth_date_encrypted, nationality, whatsapp_encrypted, whatsapp_hash, preferred_locale, invite_code_id, gender)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'en'), $11, $12)
+		RETURNING created_at, updated_at, preferred_locale
 	`)).
-		WithArgs(pgxmock.AnyArg(), req.Email, pgxmock.AnyArg(), &req.FirstName, &req.LastName, &parsedBirthDate, &req.Nationality, req.WhatsApp).
-		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}).AddRow(time.Now(), time.Now()))
+		// Ciphertexts are randomized, so only the blind index is matched exactly
+		WithArgs(pgxmock.AnyArg(), req.Email, pgxmock.AnyArg(), &req.FirstName, &req.LastName, pgxmock.AnyArg(), &req.Nationality, pgxmock.AnyArg(), authService.crypto.BlindIndex(req.WhatsApp), "", (*uuid.UUID)(nil), (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at", "preferred_locale"}).AddRow(time.Now(), time.Now(), "en"))
 
 	// --- Execute Service Method ---
 	user, err := authService.SignUp(context.Background(), req)
@@ -95,6 +106,9 @@ func TestAuthService_SignUp_Success(t *testing.T) {
 	if user.PasswordHash != "" { // Ensure password hash is cleared for response
 		t.Error("Expected password hash to be empty in response, but it was not")
 	}
+	if user.WhatsApp != req.WhatsApp || user.BirthDate == nil || !user.BirthDate.Equal(parsedBirthDate) {
+		t.Errorf("Expected plaintext WhatsApp and birth date in response, got %s and %v", user.WhatsApp, user.BirthDate)
+	}
 
 	// Ensure all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -102,6 +116,43 @@ func TestAuthService_SignUp_Success(t *testing.T) {
 	}
 }
 
+// Test that invite-only signups need a valid code, and an invalid one creates no account
+func TestAuthService_SignUp_InviteOnly(t *testing.T) {
+	authService, mock := setupAuthTest(t)
+	defer mock.Close()
+	authService.cfg.SignupMode = config.SignupInviteOnly
+
+	req := models.SignUpRequest{
+		Email:       "invited@example.com",
+		Password:    "password123",
+		FirstName:   "Invited",
+		LastName:    "User",
+		BirthDate:   "1990-01-01",
+		Nationality: "Testland",
+		WhatsApp:    "+1234567890",
+	}
+	if _, err := authService.SignUp(context.Background(), req); !errors.Is(err, ErrInviteCodeRequired) {
+		t.Errorf("SignUp() without a code = %v, want ErrInviteCodeRequired", err)
+	}
+
+	req.InviteCode = "abcd2345"
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp_hash = $2) AND deleted_at IS NULL)`)).
+		WithArgs(req.Email, authService.crypto.BlindIndex(req.WhatsApp)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE invite_codes SET uses = uses \+ 1`).
+		WithArgs("ABCD2345").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	if _, err := authService.SignUp(context.Background(), req); !errors.Is(err, ErrInvalidInviteCode) {
+		t.Errorf("SignUp() with an unknown code = %v, want ErrInvalidInviteCode", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
 // Test signup failure when email already exists
 func TestAuthService_SignUp_EmailExists(t *testing.T) {
 	authService, mock := setupAuthTest(t)
@@ -119,8 +170,8 @@ func TestAuthService_SignUp_EmailExists(t *testing.T) {
 
 	// Expect check for existing user - return true (exists)
 	// Updated regex to include the deleted_at check
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp = $2) AND deleted_at IS NULL)`)).
-		WithArgs(req.Email, req.WhatsApp).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp_hash = $2) AND deleted_at IS NULL)`)).
+		WithArgs(req.Email, authService.crypto.BlindIndex(req.WhatsApp)).
 		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
 
 	// Execute
@@ -140,6 +191,92 @@ func TestAuthService_SignUp_EmailExists(t *testing.T) {
 	}
 }
 
+// Test that of two concurrent signups with the same email, the one losing the race on the unique
+// index gets the friendly conflict rather than a database error
+func TestAuthService_SignUp_ConcurrentDuplicate(t *testing.T) {
+	authService, mock := setupAuthTest(t)
+	defer mock.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	req := models.SignUpRequest{
+		Email:       "race@example.com",
+		Password:    "password123",
+		FirstName:   "Race",
+		LastName:    "User",
+		BirthDate:   "1990-01-01",
+		Nationality: "Testland",
+		WhatsApp:    "+1234567890",
+	}
+	insertArgs := []interface{}{pgxmock.AnyArg(), req.Email, pgxmock.AnyArg(), &req.FirstName, &req.LastName, pgxmock.AnyArg(), &req.Nationality,
+		pgxmock.AnyArg(), authService.crypto.BlindIndex(req.WhatsApp), "", (*uuid.UUID)(nil), (*string)(nil)}
+	// Both signups pass the EXISTS check before either inserts
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE (email = $1 OR whatsapp_hash = $2) AND deleted_at IS NULL)`)).
+			WithArgs(req.Email, authService.crypto.BlindIndex(req.WhatsApp)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	}
+	mock.ExpectQuery(`INSERT INTO users`).WithArgs(insertArgs...).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at", "preferred_locale"}).AddRow(time.Now(), time.Now(), "en"))
+	mock.ExpectQuery(`INSERT INTO users`).WithArgs(insertArgs...).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_active"})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = authService.SignUp(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded, conflicts := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrAlreadyRegistered):
+			conflicts++
+		default:
+			t.Errorf("SignUp() error = %v, want ErrAlreadyRegistered", err)
+		}
+	}
+	if succeeded != 1 || conflicts != 1 {
+		t.Errorf("got %d signups and %d conflicts, want 1 of each", succeeded, conflicts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+// Test that a profile update taking a number registered since its uniqueness check gets the friendly conflict
+func TestAuthService_UpdateProfile_WhatsAppRace(t *testing.T) {
+	authService, mock := setupAuthTest(t)
+	defer mock.Close()
+	userID := uuid.New()
+	whatsapp := "+1234567890"
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE whatsapp_hash = $1 AND id != $2 AND deleted_at IS NULL)`)).
+		WithArgs(authService.crypto.BlindIndex(whatsapp), userID).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT first_name, last_name`).WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows([]string{"first_name", "last_name", "nationality", "preferred_locale", "gender", "birth_date", "whatsapp"}).
+			AddRow(nil, nil, nil, "en", nil, nil, ""))
+	mock.ExpectQuery(`UPDATE users SET updated_at = NOW\(\)`).WithArgs(pgxmock.AnyArg(), authService.crypto.BlindIndex(whatsapp), userID).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_whatsapp_hash"})
+	mock.ExpectRollback()
+
+	_, err := authService.UpdateProfile(context.Background(), userID, models.UpdateProfileRequest{WhatsApp: &whatsapp})
+	if !errors.Is(err, ErrWhatsAppTaken) {
+		t.Errorf("UpdateProfile() error = %v, want ErrWhatsAppTaken", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
 // Test successful user login
 func TestAuthService_Login_Success(t *testing.T) {
 	authService, mock := setupAuthTest(t)
@@ -155,19 +292,28 @@ func TestAuthService_Login_Success(t *testing.T) {
 	testFirstName := "Test"
 	testLastName := "User"
 	testNationality := "Testland"
-	testWhatsapp := "+1234567890"
+	testBirthDate := "1990-01-01" // Legacy plaintext, not yet backfilled
+	testWhatsapp, err := authService.crypto.Encrypt("+1234567890")
+	if err != nil {
+		t.Fatalf("Test setup failed: could not encrypt whatsapp: %v", err)
+	}
 
 	// --- Mock Expectations ---
 	// 1. Expect query to find user by email - return user data
 	// Updated regex to include deleted_at check and select stripe_customer_id
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp, created_at, updated_at, stripe_customer_id
+		SELECT id, email, password_hash, first_name, last_name, nationality, created_at, updated_at, stripe_customer_id, preferred_locale, gender, ` + userPIIColumns + `
 		FROM users WHERE email = $1 AND deleted_at IS NULL
 	`)).
 		WithArgs(req.Email).
 		// Add stripe_customer_id (as NULL in this case) to the returned columns and row data
-		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "password_hash", "first_name", "last_name", "birth_date", "nationality", "whatsapp", "created_at", "updated_at", "stripe_customer_id"}).
-			AddRow(userID, req.Email, string(hashedPassword), &testFirstName, &testLastName, &now, &testNationality, testWhatsapp, now, now, nil)) // Use nil for NULL stripe_customer_id
+		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "password_hash", "first_name", "last_name", "nationality", "created_at", "updated_at", "stripe_customer_id", "preferred_locale", "gender", "birth_date", "whatsapp"}).
+			AddRow(userID, req.Email, string(hashedPassword), &testFirstName, &testLastName, &testNationality, now, now, nil, "en", nil, &testBirthDate, testWhatsapp)) // Use nil for NULL stripe_customer_id
+	// 2. Expect the rating lookup - the user has two reviews
+	average := 4.5
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT ROUND(AVG(rating), 1)::float8, COUNT(*) FROM ride_reviews WHERE reviewee_id = $1`)).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows([]string{"average", "count"}).AddRow(&average, 2))
 
 	// --- Execute Service Method ---
 	loginResponse, err := authService.Login(context.Background(), req)
@@ -185,6 +331,15 @@ func TestAuthService_Login_Success(t *testing.T) {
 	if loginResponse.Token == "" {
 		t.Error("Expected a JWT token, but got an empty string")
 	}
+	if loginResponse.User.WhatsApp != "+1234567890" {
+		t.Errorf("Expected decrypted WhatsApp +1234567890, but got %s", loginResponse.User.WhatsApp)
+	}
+	if loginResponse.User.BirthDate == nil || loginResponse.User.BirthDate.Format("2006-01-02") != testBirthDate {
+		t.Errorf("Expected birth date %s, but got %v", testBirthDate, loginResponse.User.BirthDate)
+	}
+	if loginResponse.User.Rating == nil || loginResponse.User.Rating.Average != 4.5 || loginResponse.User.Rating.Count != 2 {
+		t.Errorf("Expected rating 4.5 from 2 reviews, but got %+v", loginResponse.User.Rating)
+	}
 
 	// Optional: Validate JWT token structure/claims if needed
 	token, _, err := new(jwt.Parser).ParseUnverified(loginResponse.Token, jwt.MapClaims{})
@@ -195,6 +350,9 @@ func TestAuthService_Login_Success(t *testing.T) {
 		if claims["user_id"] != userID.String() {
 			t.Errorf("Expected user_id %s in token claims, but got %s", userID.String(), claims["user_id"])
 		}
+		if claims["iss"] != "rideshare-test" || claims["aud"] != "rideshare-test-app" {
+			t.Errorf("Expected issuer and audience claims from the config, but got %v and %v", claims["iss"], claims["aud"])
+		}
 		// Check expiry is roughly correct (within a small window)
 		if expFloat, ok := claims["exp"].(float64); ok {
 			expTime := time.Unix(int64(expFloat), 0)
@@ -231,18 +389,19 @@ func TestAuthService_Login_IncorrectPassword(t *testing.T) {
 	testFirstName := "Test" // Add dummy data for all scanned columns
 	testLastName := "User"
 	testNationality := "Testland"
+	testBirthDate := "1990-01-01"
 	testWhatsapp := "+1234567890"
 
 	// Expect query to find user by email - return user data with the correct hash
 	// Updated regex to include deleted_at check and select stripe_customer_id
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp, created_at, updated_at, stripe_customer_id
+		SELECT id, email, password_hash, first_name, last_name, nationality, created_at, updated_at, stripe_customer_id, preferred_locale, gender, ` + userPIIColumns + `
 		FROM users WHERE email = $1 AND deleted_at IS NULL
 	`)).
 		WithArgs(req.Email).
 		// Add stripe_customer_id (as NULL) to the returned columns and row data
-		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "password_hash", "first_name", "last_name", "birth_date", "nationality", "whatsapp", "created_at", "updated_at", "stripe_customer_id"}).
-			AddRow(userID, req.Email, string(correctHashedPassword), &testFirstName, &testLastName, &now, &testNationality, testWhatsapp, now, now, nil)) // Return the correct hash
+		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "password_hash", "first_name", "last_name", "nationality", "created_at", "updated_at", "stripe_customer_id", "preferred_locale", "gender", "birth_date", "whatsapp"}).
+			AddRow(userID, req.Email, string(correctHashedPassword), &testFirstName, &testLastName, &testNationality, now, now, nil, "en", nil, &testBirthDate, testWhatsapp)) // Return the correct hash
 
 	// Execute
 	_, err := authService.Login(context.Background(), req)
@@ -274,7 +433,7 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 	// Expect query to find user by email - return ErrNoRows
 	// Updated regex to include deleted_at check and select stripe_customer_id
 	mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT id, email, password_hash, first_name, last_name, birth_date, nationality, whatsapp, created_at, updated_at, stripe_customer_id
+		SELECT id, email, password_hash, first_name, last_name, nationality, created_at, updated_at, stripe_customer_id, preferred_locale, gender, ` + userPIIColumns + `
 		FROM users WHERE email = $1 AND deleted_at IS NULL
 	`)).
 		WithArgs(req.Email).
@@ -292,6 +451,12 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 		t.Errorf("Expected error message '%s', but got '%s'", expectedErrMsg, err.Error())
 	}
 
+	var metrics strings.Builder
+	authService.Metrics().WritePrometheus(&metrics)
+	if !strings.Contains(metrics.String(), `rideshare_login_attempts_total{outcome="unknown_email"} 1`) {
+		t.Errorf("Expected the unknown email to be counted, got metrics:\n%s", metrics.String())
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("There were unfulfilled expectations: %s", err)
 	}
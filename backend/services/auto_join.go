@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"  // For database and Stripe calls
+	"errors"   // For error checks
+	"fmt"      // For error formatting
+	"log"      // For logging
+	"net/http" // For Stripe status codes
+	"time"     // For intent ages
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stripe/stripe-go/v72"
+
+	"rideshare/backend/models"
+)
+
+const (
+	autoJoinSeatHold     = 24 * time.Hour  // Seats of a pending intent stay held until it settles
+	autoJoinSettleDelay  = 2 * time.Minute // Younger pending intents may still be settled by their own join
+	autoJoinReplayWindow = 23 * time.Hour  // Stripe keeps idempotency keys for 24 hours
+	autoJoinMaxAttempts  = 5               // Failed finalizations before the charge is refunded
+)
+
+var (
+	// ErrAutoJoinPending is returned when the outcome of an automatic join charge isn't known yet.
+	// The intent is settled later by the webhook or the reconciliation job, and the user notified.
+	ErrAutoJoinPending = newError(KindConflict, "your payment is being confirmed, you'll be notified once your seat is booked")
+
+	// errAutoJoinUnfinalizable means the charged booking can't be confirmed any more: the seats were
+	// released or the ride is no longer active. The charge is refunded.
+	errAutoJoinUnfinalizable = errors.New("booking can no longer be confirmed")
+	// errAutoJoinSettled means another path already failed or refunded the intent.
+	errAutoJoinSettled = errors.New("automatic join intent already settled")
+)
+
+// autoJoinIntent is a row of the 'auto_join_intents' table: an automatic join recorded before its
+// card is charged. Its ID is the idempotency key of the charge, so replaying it never charges twice.
+type autoJoinIntent struct {
+	id              uuid.UUID
+	userID          uuid.UUID
+	rideID          uuid.UUID
+	participantID   uuid.UUID
+	seats           int
+	amount          int64
+	customerID      string
+	paymentMethodID string
+	paymentIntentID *string // Set once the charge succeeded
+	attempts        int
+	createdAt       time.Time
+}
+
+// recordAutoJoinIntent inserts the intent within the join's transaction, before anything is charged.
+func recordAutoJoinIntent(ctx context.Context, tx pgx.Tx, intent *autoJoinIntent) error {
+	query := `
+		INSERT INTO auto_join_intents (user_id, ride_id, participant_id, seat_count, amount, currency, stripe_customer_id, stripe_payment_method_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	err := tx.QueryRow(ctx, query, intent.userID, intent.rideID, intent.participantID, intent.seats, intent.amount,
+		paymentCurrency, intent.customerID, intent.paymentMethodID).Scan(&intent.id, &intent.createdAt)
+	if err != nil {
+		return fmt.Errorf("database error recording automatic join intent: %w", err)
+	}
+	return nil
+}
+
+// chargeAutoJoin charges the intent's saved card off-session. Replays of the same intent return
+// the original charge's outcome instead of charging again.
+func (s *PaymentService) chargeAutoJoin(ctx context.Context, intent *autoJoinIntent) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:                stripe.Int64(intent.amount),
+		Currency:              stripe.String(paymentCurrency),
+		Customer:              stripe.String(intent.customerID),
+		PaymentMethod:         stripe.String(intent.paymentMethodID),
+		Confirm:               stripe.Bool(true),
+		OffSession:            stripe.Bool(true),
+		ErrorOnRequiresAction: stripe.Bool(true),
+	}
+	params.SetIdempotencyKey("auto-join-" + intent.id.String())
+	params.AddMetadata("app_user_id", intent.userID.String())
+	params.AddMetadata("ride_id", intent.rideID.String())
+	params.AddMetadata("participant_id", intent.participantID.String())
+	params.AddMetadata("auto_join_intent_id", intent.id.String())
+	params.AddMetadata("charge_type", "automatic_join_new")
+	return s.stripeClient.CreateAndConfirmPaymentIntent(ctx, params)
+}
+
+// chargeDeclined reports whether Stripe definitely refused a charge (declined card, invalid
+// request), as opposed to errors after which the charge may still have happened.
+func chargeDeclined(err error) bool {
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) {
+		return false // Network errors and timeouts: the request may have reached Stripe
+	}
+	status := stripeErr.HTTPStatusCode
+	return status >= 400 && status < 500 && status != http.StatusConflict && status != http.StatusTooManyRequests
+}
+
+// settleAutoJoin acts on the outcome of an intent's charge: declined charges release the seats,
+// succeeded ones confirm the booking, and bookings that can't be confirmed are refunded. Unknown
+// outcomes and transient failures leave the intent pending for the reconciliation job.
+func (s *PaymentService) settleAutoJoin(ctx context.Context, intent *autoJoinIntent, pi *stripe.PaymentIntent, chargeErr error) error {
+	if chargeErr != nil {
+		if !chargeDeclined(chargeErr) {
+			log.Printf("Automatic Join Warning: Charge outcome of intent %s unknown, left for reconciliation: %v", intent.id, chargeErr)
+			s.recordAutoJoinFailure(ctx, intent, chargeErr)
+			return ErrAutoJoinPending
+		}
+		log.Printf("Automatic Join Error: Charge of intent %s declined for user %s, ride %s: %v", intent.id, intent.userID, intent.rideID, chargeErr)
+		if err := s.abandonAutoJoin(ctx, intent, chargeErr.Error()); err != nil {
+			log.Printf("Automatic Join Error: Failed releasing seats of declined intent %s: %v", intent.id, err)
+		}
+		return &Error{Kind: KindConflict, Message: "payment using saved method failed, please update your payment details", Err: chargeErr}
+	}
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+	case stripe.PaymentIntentStatusProcessing:
+		log.Printf("Automatic Join Info: Charge %s of intent %s still processing", pi.ID, intent.id)
+		return ErrAutoJoinPending
+	default:
+		log.Printf("Automatic Join Error: PaymentIntent %s of intent %s is %s, expected succeeded", pi.ID, intent.id, pi.Status)
+		if err := s.abandonAutoJoin(ctx, intent, fmt.Sprintf("payment intent status %s", pi.Status)); err != nil {
+			log.Printf("Automatic Join Error: Failed releasing seats of failed intent %s: %v", intent.id, err)
+		}
+		return newError(KindConflict, fmt.Sprintf("payment confirmation failed with status: %s", pi.Status))
+	}
+
+	intent.paymentIntentID = &pi.ID
+	activated, err := s.finalizeAutoJoin(ctx, intent.id, pi.ID)
+	switch {
+	case err == nil:
+		if activated {
+			s.autoJoinConfirmed(ctx, intent)
+		}
+		return nil
+	case errors.Is(err, errAutoJoinSettled):
+		return newError(KindConflict, "this booking was cancelled, any charge is refunded")
+	case errors.Is(err, errAutoJoinUnfinalizable):
+		log.Printf("Automatic Join Warning: Intent %s charged (PI %s) but its booking can't be confirmed, refunding", intent.id, pi.ID)
+		if err := s.refundAutoJoin(ctx, intent, err.Error()); err != nil {
+			return ErrAutoJoinPending // The reconciliation job retries the refund
+		}
+		return newError(KindConflict, "the seats are no longer available, your payment was refunded")
+	}
+
+	log.Printf("Automatic Join Error: Finalizing intent %s (PI %s) failed: %v", intent.id, pi.ID, err)
+	if s.recordAutoJoinFailure(ctx, intent, err) >= autoJoinMaxAttempts {
+		log.Printf("Automatic Join Warning: Intent %s failed finalizing %d times, refunding", intent.id, autoJoinMaxAttempts)
+		if s.refundAutoJoin(ctx, intent, err.Error()) == nil {
+			return newError(KindConflict, "your booking couldn't be recorded, your payment was refunded")
+		}
+	}
+	return ErrAutoJoinPending
+}
+
+// finalizeAutoJoin records the succeeded charge of a pending intent and activates its participant,
+// in one transaction. It reports whether this call activated the booking; intents finalized before
+// are left as they are.
+func (s *PaymentService) finalizeAutoJoin(ctx context.Context, intentID uuid.UUID, paymentIntentID string) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("db transaction begin failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, rideID uuid.UUID
+	var participantID *uuid.UUID
+	var amount int64
+	var status string
+	query := `SELECT user_id, ride_id, participant_id, amount, status FROM auto_join_intents WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, intentID).Scan(&userID, &rideID, &participantID, &amount, &status); err != nil {
+		return false, fmt.Errorf("database error fetching automatic join intent: %w", err)
+	}
+	switch status {
+	case "finalized":
+		return false, nil
+	case "pending":
+	default:
+		return false, errAutoJoinSettled
+	}
+	if participantID == nil {
+		return false, errAutoJoinUnfinalizable
+	}
+
+	activateQuery := `
+		UPDATE participants p SET status = $2, seat_held_until = NULL, updated_at = NOW()
+		FROM rides r
+		WHERE p.id = $1 AND p.status = $3 AND r.id = p.ride_id AND r.status = $4
+	`
+	tag, err := tx.Exec(ctx, activateQuery, *participantID, string(models.ParticipantStatusActive),
+		string(models.ParticipantStatusPendingPayment), string(models.RideStatusActive))
+	if err != nil {
+		return false, fmt.Errorf("db participant update failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, errAutoJoinUnfinalizable
+	}
+	insertPaymentQuery := `
+		INSERT INTO payments (id, user_id, ride_id, participant_id, stripe_payment_intent_id, status, amount, currency, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+	`
+	_, err = tx.Exec(ctx, insertPaymentQuery, uuid.New(), userID, rideID, *participantID, paymentIntentID,
+		string(models.PaymentStatusSucceeded), amount, paymentCurrency)
+	if err != nil {
+		return false, fmt.Errorf("database error inserting payment: %w", err)
+	}
+	intentQuery := `UPDATE auto_join_intents SET status = 'finalized', stripe_payment_intent_id = $2, last_error = NULL, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, intentQuery, intentID, paymentIntentID); err != nil {
+		return false, fmt.Errorf("database error finalizing automatic join intent: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("db transaction commit failed: %w", err)
+	}
+	log.Printf("Automatic Join Info: Intent %s finalized with PI %s, participant %s active", intentID, paymentIntentID, *participantID)
+	return true, nil
+}
+
+// autoJoinConfirmed announces a booking activated by finalizeAutoJoin.
+func (s *PaymentService) autoJoinConfirmed(ctx context.Context, intent *autoJoinIntent) {
+	s.rideService.publishRideEvent(ctx, RideEventJoined, intent.rideID, intent.userID)
+	s.notifyJoinConfirmed(ctx, intent.participantID)
+	s.analytics.Track(AnalyticsEvent{
+		Name:       AnalyticsPaymentSucceeded,
+		UserID:     intent.userID,
+		Properties: map[string]interface{}{"ride_id": intent.rideID.String(), "amount": intent.amount, "currency": paymentCurrency},
+	})
+}
+
+// abandonAutoJoin marks an intent whose charge failed and releases its seats.
+func (s *PaymentService) abandonAutoJoin(ctx context.Context, intent *autoJoinIntent, reason string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db transaction begin failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, `UPDATE auto_join_intents SET status = 'failed', last_error = $2, updated_at = NOW() WHERE id = $1 AND status = 'pending'`, intent.id, reason)
+	if err != nil {
+		return fmt.Errorf("database error failing automatic join intent: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil // Settled meanwhile
+	}
+	if err := releaseAutoJoinSeats(ctx, tx, intent.participantID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// releaseAutoJoinSeats removes the participant an intent created, unless the booking went ahead.
+func releaseAutoJoinSeats(ctx context.Context, tx pgx.Tx, participantID uuid.UUID) error {
+	query := `DELETE FROM participants WHERE id = $1 AND status = $2`
+	if _, err := tx.Exec(ctx, query, participantID, string(models.ParticipantStatusPendingPayment)); err != nil {
+		return fmt.Errorf("database error releasing automatic join seats: %w", err)
+	}
+	return nil
+}
+
+// refundAutoJoin fully refunds the charge of an intent whose booking can't be recorded, records
+// the refunded payment for accounting and releases the seats. If recording fails, a retry gets
+// the same refund back from Stripe instead of refunding twice.
+func (s *PaymentService) refundAutoJoin(ctx context.Context, intent *autoJoinIntent, reason string) error {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(*intent.paymentIntentID),
+		Amount:        stripe.Int64(intent.amount),
+	}
+	params.SetIdempotencyKey("auto-join-refund-" + intent.id.String())
+	params.AddMetadata("auto_join_intent_id", intent.id.String())
+	params.AddMetadata("ride_id", intent.rideID.String())
+	params.AddMetadata("app_user_id", intent.userID.String())
+	params.AddMetadata("reason", "auto_join_failed")
+	refund, err := s.stripeClient.CreateRefund(ctx, params)
+	if err != nil {
+		log.Printf("Refund Error: Stripe refund failed for automatic join intent %s (PI %s): %v", intent.id, *intent.paymentIntentID, err)
+		s.recordAutoJoinFailure(ctx, intent, err)
+		return fmt.Errorf("failed to create refund with Stripe: %w", err)
+	}
+	log.Printf("Refund Info: Stripe refund %s created for automatic join intent %s (%d %s)", refund.ID, intent.id, intent.amount, paymentCurrency)
+
+	tx, err := s.db.Begin(ctx)
+	if err == nil {
+		defer tx.Rollback(ctx)
+		var paymentID uuid.UUID
+		paymentQuery := `
+			INSERT INTO payments (id, user_id, ride_id, stripe_payment_intent_id, status, amount, refunded_amount, currency, paid_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6, $7, NOW())
+			ON CONFLICT (stripe_payment_intent_id) DO UPDATE SET updated_at = NOW()
+			RETURNING id
+		`
+		err = tx.QueryRow(ctx, paymentQuery, uuid.New(), intent.userID, intent.rideID, *intent.paymentIntentID,
+			string(models.PaymentStatusRefunded), intent.amount, paymentCurrency).Scan(&paymentID)
+		if err == nil {
+			ledgerQuery := `INSERT INTO payment_refunds (payment_id, stripe_refund_id, amount, reason) VALUES ($1, $2, $3, $4) ON CONFLICT (stripe_refund_id) DO NOTHING`
+			_, err = tx.Exec(ctx, ledgerQuery, paymentID, refund.ID, intent.amount, "auto_join_failed")
+		}
+		if err == nil {
+			intentQuery := `UPDATE auto_join_intents SET status = 'refunded', stripe_payment_intent_id = $2, last_error = $3, updated_at = NOW() WHERE id = $1`
+			_, err = tx.Exec(ctx, intentQuery, intent.id, *intent.paymentIntentID, reason)
+		}
+		if err == nil {
+			err = releaseAutoJoinSeats(ctx, tx, intent.participantID)
+		}
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+	}
+	if err != nil {
+		// Stripe already moved the money; the reconciliation job records it on its next run
+		log.Printf("CRITICAL Error: Refund %s of automatic join intent %s succeeded but recording it failed: %v", refund.ID, intent.id, err)
+		s.recordAutoJoinFailure(ctx, intent, err)
+		return fmt.Errorf("refund issued but failed to record it: %w", err)
+	}
+
+	s.notifications.Notify(ctx, intent.userID, models.NotificationEventAutoJoinRefunded, &intent.rideID,
+		"Booking not confirmed", "We couldn't confirm your seat, so your payment was refunded in full.")
+	return nil
+}
+
+// recordAutoJoinFailure counts a failed attempt at settling the intent and returns the attempts so far.
+func (s *PaymentService) recordAutoJoinFailure(ctx context.Context, intent *autoJoinIntent, cause error) int {
+	query := `
+		UPDATE auto_join_intents SET attempts = attempts + 1, last_error = $2,
+			stripe_payment_intent_id = COALESCE(stripe_payment_intent_id, $3), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING attempts
+	`
+	if err := s.db.QueryRow(ctx, query, intent.id, cause.Error(), intent.paymentIntentID).Scan(&intent.attempts); err != nil {
+		log.Printf("Automatic Join Error: Failed recording failure of intent %s: %v", intent.id, err)
+	}
+	return intent.attempts
+}
+
+// finalizeAutoJoinWebhook settles the automatic join a payment_intent.succeeded event belongs to.
+func (s *PaymentService) finalizeAutoJoinWebhook(ctx context.Context, pi *stripe.PaymentIntent, intentID uuid.UUID) error {
+	intent, err := s.loadAutoJoinIntent(ctx, intentID)
+	if err != nil {
+		return err
+	}
+	if intent == nil {
+		log.Printf("Webhook Info: Automatic join intent %s of PI %s already settled", intentID, pi.ID)
+		return nil
+	}
+	if err := s.settleAutoJoin(ctx, intent, pi, nil); errors.Is(err, ErrAutoJoinPending) {
+		return fmt.Errorf("automatic join intent %s not settled yet", intentID) // Stripe delivers the event again
+	}
+	return nil
+}
+
+// loadAutoJoinIntent returns a pending intent, or nil once it's settled.
+func (s *PaymentService) loadAutoJoinIntent(ctx context.Context, intentID uuid.UUID) (*autoJoinIntent, error) {
+	query := `
+		SELECT id, user_id, ride_id, participant_id, seat_count, amount, stripe_customer_id, stripe_payment_method_id,
+			stripe_payment_intent_id, attempts, created_at
+		FROM auto_join_intents WHERE id = $1 AND status = 'pending'
+	`
+	intent, err := scanAutoJoinIntent(s.db.QueryRow(ctx, query, intentID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching automatic join intent: %w", err)
+	}
+	return intent, nil
+}
+
+func scanAutoJoinIntent(row pgx.Row) (*autoJoinIntent, error) {
+	var intent autoJoinIntent
+	var participantID *uuid.UUID
+	err := row.Scan(&intent.id, &intent.userID, &intent.rideID, &participantID, &intent.seats, &intent.amount,
+		&intent.customerID, &intent.paymentMethodID, &intent.paymentIntentID, &intent.attempts, &intent.createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if participantID != nil {
+		intent.participantID = *participantID
+	}
+	return &intent, nil
+}
+
+// ReconcileAutoJoins settles the automatic joins left pending by a crash, an unknown charge outcome
+// or a failed finalization, oldest first. Charges are replayed under their idempotency key to learn
+// their outcome; past Stripe's idempotency window, intents without a known charge are released and
+// left for manual reconciliation. It returns the number of intents settled.
+func (s *PaymentService) ReconcileAutoJoins(ctx context.Context) (int, error) {
+	query := `
+		SELECT id, user_id, ride_id, participant_id, seat_count, amount, stripe_customer_id, stripe_payment_method_id,
+			stripe_payment_intent_id, attempts, created_at
+		FROM auto_join_intents
+		WHERE status = 'pending' AND created_at < NOW() - make_interval(secs => $1)
+		ORDER BY created_at ASC
+		LIMIT 100
+	`
+	rows, err := s.db.Query(ctx, query, autoJoinSettleDelay.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("database error listing pending automatic join intents: %w", err)
+	}
+	var intents []*autoJoinIntent
+	for rows.Next() {
+		intent, err := scanAutoJoinIntent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error processing automatic join intents: %w", err)
+		}
+		intents = append(intents, intent)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("database iteration error for automatic join intents: %w", err)
+	}
+
+	settled := 0
+	for _, intent := range intents {
+		switch {
+		case intent.paymentIntentID != nil && intent.attempts >= autoJoinMaxAttempts:
+			if s.refundAutoJoin(ctx, intent, "finalization kept failing") != nil {
+				continue
+			}
+		case intent.paymentIntentID != nil:
+			pi := &stripe.PaymentIntent{ID: *intent.paymentIntentID, Status: stripe.PaymentIntentStatusSucceeded}
+			if errors.Is(s.settleAutoJoin(ctx, intent, pi, nil), ErrAutoJoinPending) {
+				continue
+			}
+		case time.Since(intent.createdAt) > autoJoinReplayWindow:
+			log.Printf("CRITICAL Error: Charge outcome of automatic join intent %s unknown past Stripe's idempotency window, releasing its seats", intent.id)
+			if err := s.abandonAutoJoin(ctx, intent, "charge outcome unknown, reconcile manually with Stripe"); err != nil {
+				log.Printf("Automatic Join Error: Failed releasing seats of intent %s: %v", intent.id, err)
+				continue
+			}
+		default:
+			// Other errors than ErrAutoJoinPending are final outcomes (declined card, refunded booking)
+			pi, chargeErr := s.chargeAutoJoin(ctx, intent)
+			if errors.Is(s.settleAutoJoin(ctx, intent, pi, chargeErr), ErrAutoJoinPending) {
+				continue
+			}
+		}
+		settled++
+	}
+	return settled, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// Test that only definite refusals count as declined charges; other errors may hide a charge
+func TestChargeDeclined(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"card declined", &stripe.Error{Type: stripe.ErrorTypeCard, HTTPStatusCode: 402}, true},
+		{"invalid request", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: 400}, true},
+		{"idempotent request in progress", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: 409}, false},
+		{"rate limited", &stripe.Error{HTTPStatusCode: 429}, false},
+		{"Stripe API error", &stripe.Error{Type: stripe.ErrorTypeAPI, HTTPStatusCode: 500}, false},
+		{"network error", fmt.Errorf("request failed: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chargeDeclined(tt.err); got != tt.want {
+				t.Errorf("chargeDeclined() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test that a declined charge fails the intent and releases its seats
+func TestPaymentService_SettleAutoJoin_Declined(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	paymentService := &PaymentService{db: mock}
+	intent := &autoJoinIntent{id: uuid.New(), userID: uuid.New(), rideID: uuid.New(), participantID: uuid.New(), seats: 1, amount: 200}
+	declined := &stripe.Error{Type: stripe.ErrorTypeCard, HTTPStatusCode: 402, Msg: "Your card was declined."}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE auto_join_intents SET status = 'failed'").WithArgs(intent.id, declined.Error()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM participants").WithArgs(intent.participantID, "pending_payment").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	err = paymentService.settleAutoJoin(context.Background(), intent, nil, declined)
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind != KindConflict || errors.Is(err, ErrAutoJoinPending) {
+		t.Fatalf("settleAutoJoin with a declined card = %v, want a KindConflict payment error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
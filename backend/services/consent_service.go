@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// ConsentService tracks which kinds of data processing each user consented to. Consents are
+// opt-in: without a granted row, the processing is refused by the subsystem that needs it.
+type ConsentService struct {
+	db        database.DBPool
+	validator *validator.Validate
+}
+
+// NewConsentService creates a new ConsentService instance.
+func NewConsentService(db database.DBPool) *ConsentService {
+	return &ConsentService{
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// ErrLocationConsentRequired is returned when a location is sent without the location_storage consent.
+var ErrLocationConsentRequired = newError(KindForbidden, "allow location storage in your privacy settings to share your location")
+
+// List returns the state of every consent for the user.
+func (s *ConsentService) List(ctx context.Context, userID uuid.UUID) ([]models.Consent, error) {
+	rows, err := s.db.Query(ctx, `SELECT consent_type, granted, granted_at, withdrawn_at FROM user_consents WHERE user_id = $1`, userID)
+	if err != nil {
+		log.Printf("Error fetching consents for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching consents: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[models.ConsentType]models.Consent)
+	for rows.Next() {
+		var consent models.Consent
+		if err := rows.Scan(&consent.Type, &consent.Granted, &consent.GrantedAt, &consent.WithdrawnAt); err != nil {
+			return nil, fmt.Errorf("error processing consent: %w", err)
+		}
+		stored[consent.Type] = consent
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for consents: %w", err)
+	}
+
+	consents := make([]models.Consent, 0, len(models.ConsentTypes))
+	for _, consentType := range models.ConsentTypes {
+		consent, ok := stored[consentType]
+		if !ok {
+			consent = models.Consent{Type: consentType}
+		}
+		consents = append(consents, consent)
+	}
+	return consents, nil
+}
+
+// Set grants or withdraws a consent. Withdrawing location storage also erases the stored location;
+// withdrawing analytics detaches the user's stored analytics events from the account.
+func (s *ConsentService) Set(ctx context.Context, userID uuid.UUID, consentType models.ConsentType, req models.UpdateConsentRequest, ip string) ([]models.Consent, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid consent: %w", err)
+	}
+	if !slices.Contains(models.ConsentTypes, consentType) {
+		return nil, newError(KindInvalid, fmt.Sprintf("unknown consent: %s", consentType))
+	}
+	granted := *req.Granted
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO user_consents (user_id, consent_type, granted, granted_at, withdrawn_at, ip_address)
+		VALUES ($1, $2, $3, CASE WHEN $3::boolean THEN NOW() END, CASE WHEN NOT $3::boolean THEN NOW() END, $4)
+		ON CONFLICT (user_id, consent_type) DO UPDATE
+		SET granted = EXCLUDED.granted,
+		    granted_at = COALESCE(EXCLUDED.granted_at, user_consents.granted_at),
+		    withdrawn_at = COALESCE(EXCLUDED.withdrawn_at, user_consents.withdrawn_at),
+		    ip_address = EXCLUDED.ip_address,
+		    updated_at = NOW()
+	`
+	if _, err := tx.Exec(ctx, query, userID, string(consentType), granted, ip); err != nil {
+		log.Printf("Error setting %s consent for user %s: %v", consentType, userID, err)
+		return nil, fmt.Errorf("database error updating consent: %w", err)
+	}
+	if consentType == models.ConsentLocationStorage && !granted {
+		clearQuery := `
+			UPDATE users
+			SET last_known_location = NULL, last_known_location_encrypted = NULL, last_known_geohash = NULL, updated_at = NOW()
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, clearQuery, userID); err != nil {
+			log.Printf("Error clearing stored location of user %s: %v", userID, err)
+			return nil, fmt.Errorf("database error clearing location: %w", err)
+		}
+	}
+	if consentType == models.ConsentAnalytics && !granted {
+		if _, err := tx.Exec(ctx, `UPDATE analytics_events SET user_id = NULL WHERE user_id = $1`, userID); err != nil {
+			log.Printf("Error detaching analytics events of user %s: %v", userID, err)
+			return nil, fmt.Errorf("database error detaching analytics events: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to finalize consent update: %w", err)
+	}
+
+	log.Printf("User %s set %s consent to %t", userID, consentType, granted)
+	return s.List(ctx, userID)
+}
+
+// Has reports whether the user currently grants the consent.
+func (s *ConsentService) Has(ctx context.Context, userID uuid.UUID, consentType models.ConsentType) (bool, error) {
+	var granted bool
+	query := `SELECT EXISTS(SELECT 1 FROM user_consents WHERE user_id = $1 AND consent_type = $2 AND granted)`
+	if err := s.db.QueryRow(ctx, query, userID, string(consentType)).Scan(&granted); err != nil {
+		return false, fmt.Errorf("database error checking %s consent: %w", consentType, err)
+	}
+	return granted, nil
+}
+
+// GrantedAmong returns which of the users currently grant the consent.
+func (s *ConsentService) GrantedAmong(ctx context.Context, userIDs []uuid.UUID, consentType models.ConsentType) (map[uuid.UUID]bool, error) {
+	granted := make(map[uuid.UUID]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return granted, nil
+	}
+	query := `SELECT user_id FROM user_consents WHERE user_id = ANY($1) AND consent_type = $2 AND granted`
+	rows, err := s.db.Query(ctx, query, userIDs, string(consentType))
+	if err != nil {
+		return nil, fmt.Errorf("database error checking %s consents: %w", consentType, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error processing consent: %w", err)
+		}
+		granted[userID] = true
+	}
+	return granted, rows.Err()
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"         // For database calls
+	"crypto/hmac"     // For confirmation codes
+	"crypto/sha256"   // For confirmation codes
+	"encoding/base32" // For readable confirmation codes
+	"fmt"             // For error formatting
+	"log"             // For logging
+	"strings"         // For message building
+	"time"            // For the job schedule
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// confirmationCodeLength is the number of characters of a participation's confirmation code.
+const confirmationCodeLength = 6
+
+// checkinDueCondition selects the active rides (aliased r) departing later today with an active
+// participant, once the check-in time ($1) is reached. Rides departing before it are due at once.
+const checkinDueCondition = `r.status = 'active' AND r.departure_date = current_date AND r.departure_time > LOCALTIME
+	AND (LOCALTIME >= $1::time OR r.departure_time < $1::time)
+	AND EXISTS (SELECT 1 FROM participants p_due WHERE p_due.ride_id = r.id AND p_due.status = 'active')`
+
+// checkinTexts are the localized texts of check-in pushes. Passenger and driver bodies are fmt
+// patterns taking the route, departure time, meeting point, contacts, confirmation code(s) and live link.
+type checkinTexts struct {
+	title     string
+	passenger string
+	driver    string
+}
+
+// checkinLocales holds the check-in push texts per locale; emails use the departure_checkin templates.
+var checkinLocales = map[string]checkinTexts{
+	"en": {
+		title:     "Your ride is today",
+		passenger: "%s departs at %s from %s. Driver: %s. Your confirmation code: %s. Live ride: %s",
+		driver:    "%s departs at %s from %s. Passengers: %s. Live ride: %s",
+	},
+	"fr": {
+		title:     "Votre trajet est aujourd'hui",
+		passenger: "%s part à %s de %s. Conducteur : %s. Votre code de confirmation : %s. Trajet en direct : %s",
+		driver:    "%s part à %s de %s. Passagers : %s. Trajet en direct : %s",
+	},
+}
+
+// checkinRide is a ride departing today, with its driver first and its active participants in pickup order.
+type checkinRide struct {
+	id            uuid.UUID
+	route         string
+	departureTime string // HH:MM
+	meetingPoint  string // Official pickup point, else the departure location
+	members       []checkinMember
+}
+
+// checkinMember is the driver or an active participant of a checkin ride.
+type checkinMember struct {
+	userID        uuid.UUID
+	participantID *uuid.UUID // Nil for the driver
+	firstName     string
+	whatsapp      string
+	locale        string
+	pickupNote    *string
+}
+
+// label names the member with their WhatsApp number, for the other members to reach them.
+func (m checkinMember) label() string {
+	return strings.TrimSpace(m.firstName + " " + m.whatsapp)
+}
+
+// DepartureCheckinJob sends the driver and the active participants of each ride one check-in
+// message on the morning of departure: meeting point, contacts, confirmation codes and the ride's
+// live link. Each recipient of a ride gets it once, as a push (localized) and an email.
+type DepartureCheckinJob struct {
+	cfg           *config.Config
+	db            database.DBPool
+	rides         *RideService
+	notifications *NotificationService
+}
+
+// NewDepartureCheckinJob creates a new DepartureCheckinJob instance.
+func NewDepartureCheckinJob(cfg *config.Config, db database.DBPool, rides *RideService, notifications *NotificationService) *DepartureCheckinJob {
+	return &DepartureCheckinJob{
+		cfg:           cfg,
+		db:            db,
+		rides:         rides,
+		notifications: notifications,
+	}
+}
+
+// Start sends due check-in messages every cfg.CheckinReminderInterval in the background.
+func (j *DepartureCheckinJob) Start() {
+	if j.cfg.CheckinReminderInterval <= 0 {
+		log.Println("Departure check-in job disabled (CHECKIN_REMINDER_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.cfg.CheckinReminderInterval)
+		defer ticker.Stop()
+		for {
+			if sent, err := j.SendDue(context.Background()); err != nil {
+				log.Printf("Warning: Departure check-in job failed: %v", err)
+			} else if sent > 0 {
+				log.Printf("Departure check-in job sent %d messages", sent)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// SendDue claims the check-in messages due and sends them, returning how many were sent. Messages
+// are recorded as sent before going out, so a failed delivery isn't retried but is never doubled.
+func (j *DepartureCheckinJob) SendDue(ctx context.Context) (int, error) {
+	claimQuery := `
+		WITH due AS (
+			SELECT r.id AS ride_id, r.user_id FROM rides r WHERE ` + checkinDueCondition + `
+			UNION
+			SELECT r.id, p.user_id FROM rides r
+			JOIN participants p ON p.ride_id = r.id AND p.status = 'active'
+			WHERE ` + checkinDueCondition + `
+		)
+		INSERT INTO departure_checkins (ride_id, user_id)
+		SELECT ride_id, user_id FROM due
+		ON CONFLICT DO NOTHING
+		RETURNING ride_id, user_id
+	`
+	rows, err := j.db.Query(ctx, claimQuery, j.cfg.CheckinReminderTime)
+	if err != nil {
+		return 0, fmt.Errorf("database error claiming check-in messages: %w", err)
+	}
+	recipients := make(map[uuid.UUID]map[uuid.UUID]bool)
+	var rideIDs []uuid.UUID
+	for rows.Next() {
+		var rideID, userID uuid.UUID
+		if err := rows.Scan(&rideID, &userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error processing check-in message: %w", err)
+		}
+		if recipients[rideID] == nil {
+			recipients[rideID] = make(map[uuid.UUID]bool)
+			rideIDs = append(rideIDs, rideID)
+		}
+		recipients[rideID][userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("database iteration error for check-in messages: %w", err)
+	}
+
+	sent := 0
+	for _, rideID := range rideIDs {
+		ride, err := j.loadRide(ctx, rideID)
+		if err != nil {
+			log.Printf("Error loading ride %s for check-in messages: %v", rideID, err)
+			continue
+		}
+		liveURL := j.rides.shareURL(j.rides.shareToken(rideID))
+		for _, member := range ride.members {
+			if !recipients[rideID][member.userID] {
+				continue
+			}
+			title, body, data := j.checkinMessage(ride, member, liveURL)
+			j.notifications.Notify(ctx, member.userID, models.NotificationEventDepartureCheckin, &ride.id, title, body)
+			j.notifications.Email(member.userID, "departure_checkin", data)
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// loadRide loads a ride departing today with its driver and active participants.
+func (j *DepartureCheckinJob) loadRide(ctx context.Context, rideID uuid.UUID) (*checkinRide, error) {
+	ride := &checkinRide{id: rideID}
+	rideQuery := `
+		SELECT r.departure_location_name || ' → ' || r.arrival_location_name, to_char(r.departure_time, 'HH24:MI'),
+		       COALESCE(pp.name, r.departure_location_name)
+		FROM rides r
+		LEFT JOIN pickup_points pp ON pp.id = r.pickup_point_id
+		WHERE r.id = $1
+	`
+	if err := j.db.QueryRow(ctx, rideQuery, rideID).Scan(&ride.route, &ride.departureTime, &ride.meetingPoint); err != nil {
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+
+	membersQuery := `
+		SELECT u.id, p.id, COALESCE(u.first_name, ''), COALESCE(u.whatsapp_encrypted, u.whatsapp, ''), u.preferred_locale, p.pickup_note
+		FROM rides r
+		JOIN LATERAL (
+			SELECT r.user_id, NULL::uuid AS participant_id
+			UNION ALL
+			SELECT user_id, id FROM participants WHERE ride_id = r.id AND status = $2
+		) member ON TRUE
+		JOIN users u ON u.id = member.user_id AND u.deleted_at IS NULL
+		LEFT JOIN participants p ON p.id = member.participant_id
+		WHERE r.id = $1
+		ORDER BY p.id IS NOT NULL, p.pickup_order ASC NULLS LAST, p.created_at
+	`
+	rows, err := j.db.Query(ctx, membersQuery, rideID, string(models.ParticipantStatusActive))
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching ride members: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var member checkinMember
+		if err := rows.Scan(&member.userID, &member.participantID, &member.firstName, &member.whatsapp, &member.locale, &member.pickupNote); err != nil {
+			return nil, fmt.Errorf("error processing ride member: %w", err)
+		}
+		if member.whatsapp, err = j.rides.crypto.Decrypt(member.whatsapp); err != nil {
+			return nil, fmt.Errorf("error decrypting contact of user %s: %w", member.userID, err)
+		}
+		ride.members = append(ride.members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ride members: %w", err)
+	}
+	return ride, nil
+}
+
+// checkinMessage builds a member's push title and body, in their locale, and their email data.
+// Passengers get the driver's contact and their confirmation code; the driver gets each passenger's
+// contact and code, to check them in at pickup.
+func (j *DepartureCheckinJob) checkinMessage(ride *checkinRide, member checkinMember, liveURL string) (string, string, map[string]string) {
+	texts, ok := checkinLocales[member.locale]
+	if !ok {
+		if texts, ok = checkinLocales[j.cfg.DefaultLocale]; !ok {
+			texts = checkinLocales["en"]
+		}
+	}
+	data := map[string]string{
+		"Route":         ride.route,
+		"DepartureTime": ride.departureTime,
+		"MeetingPoint":  ride.meetingPoint,
+		"LiveURL":       liveURL,
+	}
+
+	if member.participantID == nil {
+		var passengers []string
+		for _, other := range ride.members {
+			if other.participantID != nil {
+				passengers = append(passengers, other.label()+", code "+j.confirmationCode(*other.participantID))
+			}
+		}
+		data["Passengers"] = strings.Join(passengers, " · ")
+		body := fmt.Sprintf(texts.driver, ride.route, ride.departureTime, ride.meetingPoint, data["Passengers"], liveURL)
+		return texts.title, body, data
+	}
+
+	var driver string
+	var contacts []string
+	for _, other := range ride.members {
+		if other.userID == member.userID {
+			continue
+		}
+		if other.participantID == nil {
+			driver = other.label()
+		}
+		contacts = append(contacts, other.label())
+	}
+	code := j.confirmationCode(*member.participantID)
+	data["Driver"] = driver
+	data["Contacts"] = strings.Join(contacts, " · ")
+	data["ConfirmationCode"] = code
+	if member.pickupNote != nil {
+		data["PickupNote"] = *member.pickupNote
+	}
+	body := fmt.Sprintf(texts.passenger, ride.route, ride.departureTime, ride.meetingPoint, driver, code, liveURL)
+	return texts.title, body, data
+}
+
+// confirmationCode derives a participation's confirmation code, which the passenger shows the
+// driver at pickup. Codes are signed like share tokens, so they needn't be stored.
+func (j *DepartureCheckinJob) confirmationCode(participantID uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(j.cfg.ShareLinkSecret))
+	mac.Write([]byte("ride-checkin:" + participantID.String()))
+	return base32.StdEncoding.EncodeToString(mac.Sum(nil))[:confirmationCodeLength]
+}
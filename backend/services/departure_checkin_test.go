@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+)
+
+// Test that passengers get the driver's contact and their code, the driver every passenger's code, in their locale
+func TestDepartureCheckinJob_CheckinMessage(t *testing.T) {
+	job := NewDepartureCheckinJob(&config.Config{ShareLinkSecret: "test-secret", DefaultLocale: "en"}, nil, nil, nil)
+	samParticipation, lou := uuid.New(), uuid.New()
+	note := "Blue coat, exit 3"
+	ride := &checkinRide{
+		id:            uuid.New(),
+		route:         "Paris → Lyon",
+		departureTime: "08:30",
+		meetingPoint:  "Gare de Bercy",
+		members: []checkinMember{
+			{userID: uuid.New(), firstName: "Alex", whatsapp: "+33611111111", locale: "fr"},
+			{userID: uuid.New(), participantID: &samParticipation, firstName: "Sam", whatsapp: "+33622222222", locale: "en", pickupNote: &note},
+			{userID: uuid.New(), participantID: &lou, firstName: "Lou", locale: "de"},
+		},
+	}
+	samCode := job.confirmationCode(samParticipation)
+	if len(samCode) != confirmationCodeLength || samCode != job.confirmationCode(samParticipation) || samCode == job.confirmationCode(lou) {
+		t.Fatalf("confirmationCode() = %q, want a stable %d-character code per participation", samCode, confirmationCodeLength)
+	}
+
+	title, body, data := job.checkinMessage(ride, ride.members[1], "https://example.test/live")
+	if title != checkinLocales["en"].title || !strings.Contains(body, "Driver: Alex +33611111111") || !strings.Contains(body, samCode) {
+		t.Errorf("passenger message = %q / %q, want the driver's contact and code %s", title, body, samCode)
+	}
+	if data["ConfirmationCode"] != samCode || data["PickupNote"] != note || data["Contacts"] != "Alex +33611111111 · Lou" || data["LiveURL"] != "https://example.test/live" {
+		t.Errorf("passenger email data = %v", data)
+	}
+
+	title, body, data = job.checkinMessage(ride, ride.members[0], "https://example.test/live")
+	if title != checkinLocales["fr"].title || !strings.Contains(body, "Passagers : Sam +33622222222, code "+samCode) {
+		t.Errorf("driver message = %q / %q, want French with Sam's code", title, body)
+	}
+	if _, ok := data["ConfirmationCode"]; ok {
+		t.Errorf("driver email data = %v, want no confirmation code of their own", data)
+	}
+
+	// Locales without texts fall back to the default locale
+	if title, _, _ := job.checkinMessage(ride, ride.members[2], ""); title != checkinLocales["en"].title {
+		t.Errorf("checkinMessage(de) title = %q, want the English title", title)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"         // For request bodies
+	"context"       // For request contexts
+	"encoding/hex"  // For the signature header
+	"encoding/json" // For event payloads
+	"errors"        // For sentinel errors
+	"fmt"           // For error formatting
+	"io"            // For reading responses
+	"log"           // For logging
+	"net/http"      // For posting to the local server
+	"time"          // For signature timestamps
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+const (
+	devWebhookTimeout      = 30 * time.Second // The local webhook handler may call Stripe and the database
+	devWebhookResponseSize = 4096             // Bytes of the webhook response kept in the delivery
+)
+
+// ErrLocalServerUnhealthy is returned when the local server doesn't answer its health check, so
+// events aren't posted to a server that can't process them.
+var ErrLocalServerUnhealthy = errors.New("local server is not healthy")
+
+// DevWebhookService signs Stripe webhook events with the configured webhook secret and posts them
+// to the local server, so webhook paths (e.g. handlePaymentIntentSucceeded) can be exercised
+// without the Stripe CLI. Development only.
+type DevWebhookService struct {
+	secret     string
+	baseURL    string // Local server, e.g. http://127.0.0.1:8080
+	httpClient *http.Client
+}
+
+// NewDevWebhookService creates a DevWebhookService posting to the local server on cfg.ServerPort.
+func NewDevWebhookService(cfg *config.Config) *DevWebhookService {
+	return &DevWebhookService{
+		secret:     cfg.StripeWebhookSecret,
+		baseURL:    "http://127.0.0.1:" + cfg.ServerPort,
+		httpClient: &http.Client{Timeout: devWebhookTimeout},
+	}
+}
+
+// Send signs an event and posts it to the local webhook endpoint once the server's health check
+// passes. The event needs a type and data.object; its id, object, created and livemode fields are
+// filled in when missing, like Stripe would.
+func (s *DevWebhookService) Send(ctx context.Context, event json.RawMessage) (*models.DevWebhookDelivery, error) {
+	if s.secret == "" {
+		return nil, newError(KindConflict, "STRIPE_WEBHOOK_SECRET is not set, so the local webhook endpoint rejects every event")
+	}
+	payload, eventID, eventType, err := completeDevWebhookEvent(event, time.Now())
+	if err != nil {
+		return nil, &Error{Kind: KindInvalid, Message: "invalid webhook event", Err: err}
+	}
+	if err := s.checkHealth(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLocalServerUnhealthy, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/api/v1/stripe-webhook", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signDevWebhook(payload, s.secret, time.Now()))
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error posting webhook event: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, devWebhookResponseSize))
+
+	log.Printf("Dev webhook: posted %s event %s, local server answered %d", eventType, eventID, resp.StatusCode)
+	return &models.DevWebhookDelivery{
+		EventID:    eventID,
+		EventType:  eventType,
+		StatusCode: resp.StatusCode,
+		Response:   string(body),
+	}, nil
+}
+
+// checkHealth calls the local server's health check route.
+func (s *DevWebhookService) checkHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// completeDevWebhookEvent checks an event has a type and data.object and fills in the envelope
+// fields Stripe sets. It returns the payload to sign, the event ID and the event type.
+func completeDevWebhookEvent(event json.RawMessage, now time.Time) ([]byte, string, string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(event, &fields); err != nil {
+		return nil, "", "", fmt.Errorf("event must be a JSON object: %w", err)
+	}
+	eventType, _ := fields["type"].(string)
+	if eventType == "" {
+		return nil, "", "", errors.New("event type is required (e.g. payment_intent.succeeded)")
+	}
+	data, _ := fields["data"].(map[string]any)
+	if _, ok := data["object"].(map[string]any); !ok {
+		return nil, "", "", errors.New("event data.object is required")
+	}
+	if id, _ := fields["id"].(string); id == "" {
+		fields["id"] = "evt_dev_" + uuid.NewString()
+	}
+	if _, ok := fields["object"]; !ok {
+		fields["object"] = "event"
+	}
+	if _, ok := fields["created"]; !ok {
+		fields["created"] = now.Unix()
+	}
+	if _, ok := fields["livemode"]; !ok {
+		fields["livemode"] = false
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("error encoding event: %w", err)
+	}
+	return payload, fields["id"].(string), eventType, nil
+}
+
+// signDevWebhook builds the Stripe-Signature header of a payload, as Stripe signs webhook events.
+func signDevWebhook(payload []byte, secret string, at time.Time) string {
+	return fmt.Sprintf("t=%d,v1=%s", at.Unix(), hex.EncodeToString(webhook.ComputeSignature(at, payload, secret)))
+}
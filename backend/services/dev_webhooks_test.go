@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test that posted events carry a Stripe-style signature of the completed payload
+func TestDevWebhookService_Send(t *testing.T) {
+	secret := "whsec_test"
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		payload, _ := io.ReadAll(r.Body)
+		var timestamp int64
+		var signature string
+		fmt.Sscanf(strings.Replace(r.Header.Get("Stripe-Signature"), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature)
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "%d.%s", timestamp, payload)
+		if signature != hex.EncodeToString(mac.Sum(nil)) {
+			http.Error(w, "bad signature", http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal(payload, &received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	devWebhooks := &DevWebhookService{secret: secret, baseURL: server.URL, httpClient: server.Client()}
+
+	delivery, err := devWebhooks.Send(context.Background(), []byte(`{"type":"payment_intent.succeeded","data":{"object":{"id":"pi_123"}}}`))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if delivery.StatusCode != http.StatusOK {
+		t.Fatalf("webhook answered %d (%s), want 200", delivery.StatusCode, delivery.Response)
+	}
+	if !strings.HasPrefix(delivery.EventID, "evt_dev_") || received["id"] != delivery.EventID || received["object"] != "event" {
+		t.Errorf("posted event %v (delivery %+v), want a completed event envelope", received, delivery)
+	}
+}
+
+// Test that events aren't posted to an unhealthy server, nor without a type or object
+func TestDevWebhookService_Send_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	devWebhooks := &DevWebhookService{secret: "whsec_test", baseURL: server.URL, httpClient: server.Client()}
+
+	if _, err := devWebhooks.Send(context.Background(), []byte(`{"type":"payment_intent.succeeded","data":{"object":{}}}`)); !errors.Is(err, ErrLocalServerUnhealthy) {
+		t.Errorf("Send() to an unhealthy server = %v, want ErrLocalServerUnhealthy", err)
+	}
+	for _, event := range []string{`{"data":{"object":{}}}`, `{"type":"charge.refunded"}`, `[]`} {
+		if _, _, _, err := completeDevWebhookEvent([]byte(event), time.Now()); err == nil {
+			t.Errorf("completeDevWebhookEvent(%s) succeeded, want an error", event)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// driverDashboardRideLimit bounds the upcoming rides listed on the driver dashboard.
+const driverDashboardRideLimit = 50
+
+// rideDashboardCounts are the per-ride figures of the driver dashboard.
+type rideDashboardCounts struct {
+	pending   int
+	unread    int
+	collected int64 // Succeeded payments of active participants, net of refunds
+	held      int   // Unpaid seats still reserved for their joiner
+}
+
+// DriverDashboard returns the user's upcoming rides with their fill rate, pending requests, unread
+// notifications and expected earnings, and the totals over them.
+func (s *RideService) DriverDashboard(ctx context.Context, userID uuid.UUID) (*models.DriverDashboard, error) {
+	query := `
+		SELECT` + rideSelectColumns + `
+		FROM rides r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.user_id = $1 AND ` + upcomingRideCondition + `
+		ORDER BY r.departure_date ASC, r.departure_time ASC
+		LIMIT $2
+	`
+	rows, err := s.db.Query(ctx, query, userID, driverDashboardRideLimit)
+	if err != nil {
+		log.Printf("Error querying dashboard rides for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching dashboard rides: %w", err)
+	}
+	defer rows.Close()
+
+	var rides []models.Ride
+	var rideIDs []uuid.UUID
+	for rows.Next() {
+		ride, err := scanRideRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing dashboard ride: %w", err)
+		}
+		s.applySeatPrice(ride)
+		rides = append(rides, *ride)
+		rideIDs = append(rideIDs, ride.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for dashboard rides: %w", err)
+	}
+
+	counts, err := s.rideDashboardCounts(ctx, userID, rideIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &models.DriverDashboard{UpcomingRides: []models.DashboardRide{}, Currency: paymentCurrency}
+	for _, ride := range rides {
+		rideCounts := counts[ride.ID]
+		entry := models.DashboardRide{
+			Ride:                ride,
+			PendingRequests:     rideCounts.pending,
+			UnreadNotifications: rideCounts.unread,
+			ExpectedEarnings:    rideCounts.collected + int64(rideCounts.held)*(*ride.PricePerSeat),
+		}
+		if ride.TotalSeats > 0 {
+			entry.FillRate = float64(ride.PlacesTaken) / float64(ride.TotalSeats)
+		}
+		dashboard.UpcomingRides = append(dashboard.UpcomingRides, entry)
+		dashboard.PendingRequests += entry.PendingRequests
+		dashboard.ExpectedEarnings += entry.ExpectedEarnings
+	}
+
+	unreadQuery := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	if err := s.db.QueryRow(ctx, unreadQuery, userID).Scan(&dashboard.UnreadNotifications); err != nil {
+		log.Printf("Error counting unread notifications for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error counting unread notifications: %w", err)
+	}
+	return dashboard, nil
+}
+
+// rideDashboardCounts loads the dashboard figures of the given rides of the user in one query.
+func (s *RideService) rideDashboardCounts(ctx context.Context, userID uuid.UUID, rideIDs []uuid.UUID) (map[uuid.UUID]rideDashboardCounts, error) {
+	counts := make(map[uuid.UUID]rideDashboardCounts, len(rideIDs))
+	if len(rideIDs) == 0 {
+		return counts, nil
+	}
+	query := `
+		SELECT r.id,
+		       (SELECT COUNT(*) FROM participants p
+		        WHERE p.ride_id = r.id AND p.status IN ('pending_payment', 'on_hold')),
+		       (SELECT COUNT(*) FROM notifications n
+		        WHERE n.ride_id = r.id AND n.user_id = $2 AND n.read_at IS NULL),
+		       (SELECT COALESCE(SUM(pm.amount - pm.refunded_amount), 0) FROM payments pm
+		        JOIN participants p ON p.id = pm.participant_id
+		        WHERE pm.ride_id = r.id AND pm.status = 'succeeded' AND p.status = 'active'),
+		       (SELECT COALESCE(SUM(p.seat_count), 0) FROM participants p
+		        WHERE p.ride_id = r.id AND p.status = 'pending_payment' AND p.seat_held_until > NOW())
+		FROM rides r
+		WHERE r.id = ANY($1)
+	`
+	rows, err := s.db.Query(ctx, query, rideIDs, userID)
+	if err != nil {
+		log.Printf("Error loading dashboard counts for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching dashboard counts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rideID uuid.UUID
+		var rideCounts rideDashboardCounts
+		if err := rows.Scan(&rideID, &rideCounts.pending, &rideCounts.unread, &rideCounts.collected, &rideCounts.held); err != nil {
+			return nil, fmt.Errorf("error processing dashboard counts: %w", err)
+		}
+		counts[rideID] = rideCounts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for dashboard counts: %w", err)
+	}
+	return counts, nil
+}
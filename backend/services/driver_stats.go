@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context" // For database calls
+	"log"     // For logging
+	"slices"  // For collecting distinct drivers
+	"sync"    // For the in-memory cache
+	"time"    // For expiry
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// driverStatsMaxEntries bounds the cache; expired entries are evicted when it is reached.
+const driverStatsMaxEntries = 10000
+
+// driverStatsEntry is the cached reliability of one driver.
+type driverStatsEntry struct {
+	stats    models.DriverStats
+	loadedAt time.Time
+}
+
+// DriverStatsCache computes driver reliability stats (completed and cancelled rides, ratings) with
+// aggregated queries per listing and caches them per driver, so ride listings don't run a subquery
+// per row. A nil *DriverStatsCache attaches nothing.
+type DriverStatsCache struct {
+	db  database.DBPool
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[uuid.UUID]driverStatsEntry
+}
+
+// NewDriverStatsCache creates a DriverStatsCache from cfg. A zero DriverStatsCacheTTL disables the stats.
+func NewDriverStatsCache(cfg *config.Config, db database.DBPool) *DriverStatsCache {
+	if cfg.DriverStatsCacheTTL <= 0 {
+		log.Println("Driver stats disabled (DRIVER_STATS_CACHE_TTL is 0)")
+		return nil
+	}
+	return &DriverStatsCache{
+		db:      db,
+		ttl:     cfg.DriverStatsCacheTTL,
+		entries: make(map[uuid.UUID]driverStatsEntry),
+	}
+}
+
+// Attach sets the creator's stats on each ride. Stats are informative, so lookup errors are logged
+// and the rides are returned without them.
+func (c *DriverStatsCache) Attach(ctx context.Context, rides []models.Ride) {
+	if c == nil || len(rides) == 0 {
+		return
+	}
+	stats := make(map[uuid.UUID]models.DriverStats, len(rides))
+	var missing []uuid.UUID
+	c.mu.RLock()
+	for _, ride := range rides {
+		if _, seen := stats[ride.UserID]; seen {
+			continue
+		}
+		if entry, ok := c.entries[ride.UserID]; ok && time.Since(entry.loadedAt) < c.ttl {
+			stats[ride.UserID] = entry.stats
+		} else if !slices.Contains(missing, ride.UserID) {
+			missing = append(missing, ride.UserID)
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(missing) > 0 {
+		loaded, err := c.load(ctx, missing)
+		if err != nil {
+			log.Printf("Warning: Failed loading stats of %d driver(s): %v", len(missing), err)
+		} else {
+			c.store(loaded)
+			for driverID, driverStats := range loaded {
+				stats[driverID] = driverStats
+			}
+		}
+	}
+
+	for i := range rides {
+		if driverStats, ok := stats[rides[i].UserID]; ok {
+			rides[i].DriverStats = &driverStats
+		}
+	}
+}
+
+// load computes the stats of the given drivers, with the ratings passengers gave them. Archived rides
+// (departed, see RideArchivalJob) count as completed, like confirmed ones.
+func (c *DriverStatsCache) load(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]models.DriverStats, error) {
+	query := `
+		SELECT user_id,
+		       COUNT(*) FILTER (WHERE status = ANY($2)),
+		       COUNT(*) FILTER (WHERE status = $3)
+		FROM rides
+		WHERE user_id = ANY($1)
+		GROUP BY user_id
+	`
+	completed := []string{string(models.RideStatusArchived), string(models.RideStatusCompleted)}
+	rows, err := c.db.Query(ctx, query, driverIDs, completed, string(models.RideStatusCancelled))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Drivers without rides get zero stats, so they are cached too
+	loaded := make(map[uuid.UUID]models.DriverStats, len(driverIDs))
+	for _, driverID := range driverIDs {
+		loaded[driverID] = models.DriverStats{}
+	}
+	for rows.Next() {
+		var driverID uuid.UUID
+		var stats models.DriverStats
+		if err := rows.Scan(&driverID, &stats.CompletedRides, &stats.CancelledRides); err != nil {
+			return nil, err
+		}
+		if total := stats.CompletedRides + stats.CancelledRides; total > 0 {
+			rate := float64(stats.CancelledRides) / float64(total)
+			stats.CancellationRate = &rate
+		}
+		loaded[driverID] = stats
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ratings, err := ratingSummaries(ctx, c.db, driverIDs, models.ReviewRoleDriver)
+	if err != nil {
+		return nil, err
+	}
+	for driverID, rating := range ratings {
+		stats := loaded[driverID]
+		stats.Rating = &rating
+		loaded[driverID] = stats
+	}
+	return loaded, nil
+}
+
+// store caches freshly loaded stats, evicting expired entries when the cache is full.
+func (c *DriverStatsCache) store(loaded map[uuid.UUID]models.DriverStats) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries)+len(loaded) > driverStatsMaxEntries {
+		for driverID, entry := range c.entries {
+			if now.Sub(entry.loadedAt) >= c.ttl {
+				delete(c.entries, driverID)
+			}
+		}
+		if len(c.entries)+len(loaded) > driverStatsMaxEntries {
+			return // Full of fresh entries; these stats are used uncached
+		}
+	}
+	for driverID, stats := range loaded {
+		c.entries[driverID] = driverStatsEntry{stats: stats, loadedAt: now}
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bytes"           // For building the MIME message
+	"context"         // For database operations context
+	"crypto/hmac"     // For signing unsubscribe tokens
+	"crypto/sha256"   // HMAC hash function
+	"encoding/base64" // For URL-safe token encoding
+	"fmt"             // For error formatting
+	"log"             // For logging
+	"mime"            // For encoding non-ASCII subjects
+	"net"             // For building the SMTP address
+	"net/mail"        // For parsing the From address
+	"net/smtp"        // For sending emails
+	"net/url"         // For building unsubscribe links
+	"strings"         // For token parsing
+	"time"            // For the Date header
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// emailTemplateCategories maps non-critical templates to the category users can opt out of.
+// Templates not listed here are critical: they are always sent and carry no unsubscribe link.
+var emailTemplateCategories = map[string]models.EmailCategory{
+	"participant_joined": models.EmailCategoryRideActivity,
+}
+
+// emailCategoryConsents maps opt-in categories to the consent they need; without it the email is not sent.
+var emailCategoryConsents = map[models.EmailCategory]models.ConsentType{
+	models.EmailCategoryMarketing: models.ConsentMarketingEmails,
+}
+
+// ErrInvalidUnsubscribeToken is returned for malformed or tampered unsubscribe tokens.
+var ErrInvalidUnsubscribeToken = newError(KindInvalid, "invalid unsubscribe token")
+
+// EmailService renders and sends transactional emails.
+type EmailService struct {
+	cfg       *config.Config
+	db        database.DBPool
+	validator *validator.Validate
+	templates *EmailTemplateEngine
+	consents  *ConsentService // Opt-in categories (marketing) need the user's consent
+}
+
+// NewEmailService creates a new EmailService instance, parsing all email templates.
+func NewEmailService(cfg *config.Config, db database.DBPool, consents *ConsentService) (*EmailService, error) {
+	templates, err := NewEmailTemplateEngine(cfg.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SMTPHost == "" {
+		log.Println("Warning: SMTP_HOST not set, transactional emails will be rendered but not sent")
+	}
+	return &EmailService{cfg: cfg, db: db, validator: NewValidator(), templates: templates, consents: consents}, nil
+}
+
+// SendToUser renders a template in the user's preferred locale and sends it to their email address.
+// Non-critical emails are skipped when the user opted out of their category, and opt-in categories
+// (marketing) when the user hasn't given the matching consent.
+func (s *EmailService) SendToUser(ctx context.Context, userID uuid.UUID, templateName string, data map[string]string) error {
+	templateData := EmailTemplateData{Data: data}
+	category, nonCritical := emailTemplateCategories[templateName]
+	if nonCritical {
+		suppressed, err := s.isSuppressed(ctx, userID, category)
+		if err != nil {
+			return err
+		}
+		if suppressed {
+			log.Printf("Email Info: User %s opted out of %s, skipping %s email", userID, category, templateName)
+			return nil
+		}
+		if consentType, optIn := emailCategoryConsents[category]; optIn {
+			granted, err := s.consents.Has(ctx, userID, consentType)
+			if err != nil {
+				return err
+			}
+			if !granted {
+				log.Printf("Email Info: User %s has no %s consent, skipping %s email", userID, consentType, templateName)
+				return nil
+			}
+		}
+		templateData.UnsubscribeURL = s.unsubscribeURL(userID, category)
+	}
+
+	var email, locale string
+	var firstName *string
+	query := `SELECT email, first_name, preferred_locale FROM users WHERE id = $1 AND deleted_at IS NULL`
+	if err := s.db.QueryRow(ctx, query, userID).Scan(&email, &firstName, &locale); err != nil {
+		return fmt.Errorf("failed to load recipient %s: %w", userID, err)
+	}
+
+	if firstName != nil {
+		templateData.FirstName = *firstName
+	}
+	rendered, err := s.templates.Render(templateName, locale, templateData)
+	if err != nil {
+		return err
+	}
+	if err := s.send(email, rendered, templateData.UnsubscribeURL); err != nil {
+		return fmt.Errorf("failed to send %s email to user %s: %w", templateName, userID, err)
+	}
+	log.Printf("Email Info: Sent %s email to user %s (%s)", templateName, userID, locale)
+	return nil
+}
+
+// Preview renders a template with sample data (used by the dev-only preview endpoint).
+func (s *EmailService) Preview(templateName, locale string) (*RenderedEmail, error) {
+	data := EmailTemplateData{
+		FirstName: "Alex",
+		Data:      map[string]string{"Route": "Paris → Lyon"},
+	}
+	if category, ok := emailTemplateCategories[templateName]; ok {
+		data.UnsubscribeURL = s.unsubscribeURL(uuid.Nil, category)
+	}
+	return s.templates.Render(templateName, locale, data)
+}
+
+// Templates lists the available templates and their locales.
+func (s *EmailService) Templates() map[string][]string {
+	return s.templates.Templates()
+}
+
+// isSuppressed reports whether the user opted out of the email category.
+func (s *EmailService) isSuppressed(ctx context.Context, userID uuid.UUID, category models.EmailCategory) (bool, error) {
+	var suppressed bool
+	query := `SELECT EXISTS(SELECT 1 FROM email_suppressions WHERE user_id = $1 AND category = $2)`
+	if err := s.db.QueryRow(ctx, query, userID, string(category)).Scan(&suppressed); err != nil {
+		return false, fmt.Errorf("database error checking email suppression for user %s: %w", userID, err)
+	}
+	return suppressed, nil
+}
+
+// signUnsubscribe computes the HMAC signature of an unsubscribe token payload.
+func (s *EmailService) signUnsubscribe(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.cfg.UnsubscribeSecret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// unsubscribeToken builds a signed token of the form "<payload>.<signature>", where the payload
+// encodes the user ID and the email category. Tokens don't expire: old emails must keep working.
+func (s *EmailService) unsubscribeToken(userID uuid.UUID, category models.EmailCategory) string {
+	payload := userID.String() + ":" + string(category)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.signUnsubscribe(payload))
+}
+
+// parseUnsubscribeToken verifies a token's signature and returns the user and category it encodes.
+func (s *EmailService) parseUnsubscribeToken(token string) (uuid.UUID, models.EmailCategory, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.signUnsubscribe(string(payload))) {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	userIDStr, category, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	return userID, models.EmailCategory(category), nil
+}
+
+// unsubscribeURL returns the public one-click unsubscribe link for a user and category.
+func (s *EmailService) unsubscribeURL(userID uuid.UUID, category models.EmailCategory) string {
+	return strings.TrimRight(s.cfg.PublicBaseURL, "/") + "/api/v1/email/unsubscribe?token=" +
+		url.QueryEscape(s.unsubscribeToken(userID, category))
+}
+
+// UnsubscribeCategory verifies a signed unsubscribe token and returns its category without
+// recording anything, for the confirmation page shown before the opt-out.
+func (s *EmailService) UnsubscribeCategory(token string) (models.EmailCategory, error) {
+	_, category, err := s.parseUnsubscribeToken(token)
+	return category, err
+}
+
+// Unsubscribe records the opt-out encoded in a signed unsubscribe token.
+func (s *EmailService) Unsubscribe(ctx context.Context, token string) (models.EmailCategory, error) {
+	userID, category, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		log.Printf("Unsubscribe attempt with invalid token")
+		return "", err
+	}
+	if err := s.suppress(ctx, userID, category, "unsubscribe_link"); err != nil {
+		return "", err
+	}
+	log.Printf("User %s unsubscribed from %s emails via link", userID, category)
+	return category, nil
+}
+
+// suppress adds a category to the user's suppression list (idempotent).
+func (s *EmailService) suppress(ctx context.Context, userID uuid.UUID, category models.EmailCategory, source string) error {
+	query := `
+		INSERT INTO email_suppressions (user_id, category, source)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, category) DO NOTHING
+	`
+	if _, err := s.db.Exec(ctx, query, userID, string(category), source); err != nil {
+		log.Printf("Error recording email suppression %s for user %s: %v", category, userID, err)
+		return fmt.Errorf("database error recording email opt-out: %w", err)
+	}
+	return nil
+}
+
+// GetPreferences returns the subscription state of every email category for the user.
+func (s *EmailService) GetPreferences(ctx context.Context, userID uuid.UUID) ([]models.EmailCategoryPreference, error) {
+	rows, err := s.db.Query(ctx, `SELECT category FROM email_suppressions WHERE user_id = $1`, userID)
+	if err != nil {
+		log.Printf("Error fetching email suppressions for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching email preferences: %w", err)
+	}
+	defer rows.Close()
+
+	suppressed := make(map[models.EmailCategory]bool)
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("database error scanning email preferences: %w", err)
+		}
+		suppressed[models.EmailCategory(category)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating email preferences: %w", err)
+	}
+
+	preferences := make([]models.EmailCategoryPreference, 0, len(models.EmailCategories))
+	for _, category := range models.EmailCategories {
+		preferences = append(preferences, models.EmailCategoryPreference{Category: category, Subscribed: !suppressed[category]})
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences subscribes or unsubscribes the user from the given categories.
+func (s *EmailService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req models.UpdateEmailPreferencesRequest) ([]models.EmailCategoryPreference, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid email preferences: %w", err)
+	}
+	for category := range req.Categories {
+		if !isKnownEmailCategory(category) {
+			return nil, newError(KindInvalid, fmt.Sprintf("unknown email category: %s", category))
+		}
+	}
+
+	for category, subscribed := range req.Categories {
+		if subscribed {
+			query := `DELETE FROM email_suppressions WHERE user_id = $1 AND category = $2`
+			if _, err := s.db.Exec(ctx, query, userID, string(category)); err != nil {
+				log.Printf("Error removing email suppression %s for user %s: %v", category, userID, err)
+				return nil, fmt.Errorf("database error updating email preferences: %w", err)
+			}
+			continue
+		}
+		if err := s.suppress(ctx, userID, category, "preferences"); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("Email preferences updated for user %s", userID)
+	return s.GetPreferences(ctx, userID)
+}
+
+// isKnownEmailCategory reports whether the category is one users can opt out of.
+func isKnownEmailCategory(category models.EmailCategory) bool {
+	for _, known := range models.EmailCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers a rendered email over SMTP. Without SMTP configuration it only logs.
+// Non-critical emails also carry List-Unsubscribe headers for one-click unsubscribe in mail clients.
+func (s *EmailService) send(to string, email *RenderedEmail, unsubscribeURL string) error {
+	if s.cfg.SMTPHost == "" {
+		log.Printf("Email Info: SMTP not configured, skipping email '%s' to %s", email.Subject, to)
+		return nil
+	}
+
+	from, err := mail.ParseAddress(s.cfg.EmailFrom)
+	if err != nil {
+		return fmt.Errorf("invalid EMAIL_FROM address: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if unsubscribeURL != "" {
+		fmt.Fprintf(&msg, "List-Unsubscribe: <%s>\r\n", unsubscribeURL)
+		msg.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(email.HTML)
+
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+	addr := net.JoinHostPort(s.cfg.SMTPHost, s.cfg.SMTPPort)
+	return smtp.SendMail(addr, auth, from.Address, []string{to}, msg.Bytes())
+}
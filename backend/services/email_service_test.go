@@ -0,0 +1,43 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that unsubscribe tokens round-trip and that tampered tokens are rejected
+func TestEmailService_UnsubscribeToken(t *testing.T) {
+	service := &EmailService{cfg: &config.Config{UnsubscribeSecret: "test-secret"}}
+	userID := uuid.New()
+
+	token := service.unsubscribeToken(userID, models.EmailCategoryRideActivity)
+	gotUserID, gotCategory, err := service.parseUnsubscribeToken(token)
+	if err != nil {
+		t.Fatalf("parseUnsubscribeToken() unexpected error: %v", err)
+	}
+	if gotUserID != userID || gotCategory != models.EmailCategoryRideActivity {
+		t.Errorf("parseUnsubscribeToken() = (%s, %s), want (%s, %s)", gotUserID, gotCategory, userID, models.EmailCategoryRideActivity)
+	}
+
+	otherService := &EmailService{cfg: &config.Config{UnsubscribeSecret: "other-secret"}}
+	forged := otherService.unsubscribeToken(userID, models.EmailCategoryRideActivity)
+	otherUserToken := service.unsubscribeToken(uuid.New(), models.EmailCategoryRideActivity)
+	_, signature, _ := strings.Cut(token, ".")
+	otherPayload, _, _ := strings.Cut(otherUserToken, ".")
+
+	for name, badToken := range map[string]string{
+		"empty":           "",
+		"no signature":    "abc",
+		"wrong secret":    forged,
+		"swapped payload": otherPayload + "." + signature,
+	} {
+		if _, _, err := service.parseUnsubscribeToken(badToken); err == nil {
+			t.Errorf("parseUnsubscribeToken(%s) expected error, got nil", name)
+		}
+	}
+}
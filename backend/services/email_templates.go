@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"         // For rendering into buffers
+	"embed"         // For bundling templates into the binary
+	"fmt"           // For error formatting
+	"html"          // For unescaping rendered subjects
+	"html/template" // For HTML-safe template rendering
+	"io/fs"         // For listing embedded template files
+	"sort"          // For stable template listings
+	"strings"       // For file name parsing
+)
+
+//go:embed templates/email/*.html
+var emailTemplateFS embed.FS
+
+// emailLayoutFile is the shared layout every email variant is rendered inside.
+// Variants are named "<template>.<locale>.html" and define the "subject", "content" and "footer" blocks.
+const emailLayoutFile = "layout.html"
+
+// emailStyles holds the CSS inlined into templates through the "style" function.
+// Many email clients strip <style> blocks, so every rule is rendered as a style attribute.
+var emailStyles = map[string]string{
+	"body":      "margin:0;padding:0;background-color:#f4f5f7;font-family:Helvetica,Arial,sans-serif;",
+	"wrapper":   "background-color:#f4f5f7;padding:24px 0;",
+	"container": "background-color:#ffffff;border-radius:8px;overflow:hidden;",
+	"header":    "background-color:#1e88e5;color:#ffffff;font-size:22px;font-weight:bold;padding:20px 32px;",
+	"content":   "padding:32px;color:#222222;font-size:16px;line-height:24px;",
+	"footer":    "padding:16px 32px;color:#888888;font-size:12px;line-height:18px;border-top:1px solid #eeeeee;",
+	"h1":        "margin:0 0 16px 0;font-size:22px;color:#222222;",
+	"p":         "margin:0 0 16px 0;",
+	"link":      "color:#888888;text-decoration:underline;",
+	"button":    "display:inline-block;background-color:#1e88e5;color:#ffffff;padding:12px 24px;border-radius:6px;text-decoration:none;font-weight:bold;",
+}
+
+// EmailTemplateData is the data passed to every email template.
+type EmailTemplateData struct {
+	Locale         string            // Locale of the rendered variant
+	FirstName      string            // Recipient's first name
+	Data           map[string]string // Template-specific values (e.g. Route)
+	UnsubscribeURL string            // Signed opt-out link, set for non-critical emails only
+}
+
+// RenderedEmail is a fully rendered email ready to be sent.
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+}
+
+// EmailTemplateEngine renders transactional emails from the embedded templates.
+type EmailTemplateEngine struct {
+	defaultLocale string
+	templates     map[string]*template.Template // Keyed by "<template>.<locale>"
+}
+
+// NewEmailTemplateEngine parses the layout and all template variants once at startup.
+func NewEmailTemplateEngine(defaultLocale string) (*EmailTemplateEngine, error) {
+	funcs := template.FuncMap{
+		"style": func(name string) template.CSS {
+			return template.CSS(emailStyles[name]) // Rules are constants defined above, safe to mark as CSS
+		},
+	}
+	layout, err := template.New(emailLayoutFile).Funcs(funcs).ParseFS(emailTemplateFS, "templates/email/"+emailLayoutFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email layout: %w", err)
+	}
+
+	files, err := fs.Glob(emailTemplateFS, "templates/email/*.*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+
+	engine := &EmailTemplateEngine{defaultLocale: defaultLocale, templates: make(map[string]*template.Template)}
+	for _, file := range files {
+		key := strings.TrimSuffix(strings.TrimPrefix(file, "templates/email/"), ".html") // e.g. "ride_cancelled.fr"
+		variant, err := layout.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone email layout for %s: %w", key, err)
+		}
+		if _, err := variant.ParseFS(emailTemplateFS, file); err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", key, err)
+		}
+		engine.templates[key] = variant
+	}
+	return engine, nil
+}
+
+// Render renders a template in the requested locale, falling back to the default locale
+// when the template has no variant for it.
+func (e *EmailTemplateEngine) Render(name, locale string, data EmailTemplateData) (*RenderedEmail, error) {
+	tmpl, ok := e.templates[name+"."+locale]
+	if !ok {
+		locale = e.defaultLocale
+		tmpl, ok = e.templates[name+"."+locale]
+		if !ok {
+			return nil, newError(KindNotFound, fmt.Sprintf("unknown email template: %s", name))
+		}
+	}
+	data.Locale = locale
+
+	var subject bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render subject of %s.%s: %w", name, locale, err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&body, emailLayoutFile, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s.%s: %w", name, locale, err)
+	}
+
+	return &RenderedEmail{
+		Subject: strings.TrimSpace(html.UnescapeString(subject.String())), // Subjects are plain text headers
+		HTML:    body.String(),
+	}, nil
+}
+
+// Templates lists the available templates and their locales, e.g. {"ride_cancelled": ["en", "fr"]}.
+func (e *EmailTemplateEngine) Templates() map[string][]string {
+	result := make(map[string][]string)
+	for key := range e.templates {
+		name, locale, _ := strings.Cut(key, ".")
+		result[name] = append(result[name], locale)
+	}
+	for name := range result {
+		sort.Strings(result[name])
+	}
+	return result
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"       // For database and Stripe calls
+	"crypto/sha256" // For certificate digests
+	"encoding/hex"  // For certificate digests
+	"errors"        // For Stripe error checks
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"strings"       // For certificate digests
+	"time"          // For the grace period
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// erasureBatchSize bounds the users erased per run.
+const erasureBatchSize = 100
+
+// ErasureService irreversibly anonymizes the personal data of soft-deleted users once the grace
+// period is over. User rows are kept (anonymized) so rides, participations and payments, which
+// must be retained for accounting, stay consistent.
+type ErasureService struct {
+	cfg          *config.Config
+	db           database.DBPool
+	stripeClient StripeService
+}
+
+// NewErasureService creates a new ErasureService instance.
+func NewErasureService(cfg *config.Config, db database.DBPool, stripeClient StripeService) *ErasureService {
+	return &ErasureService{
+		cfg:          cfg,
+		db:           db,
+		stripeClient: stripeClient,
+	}
+}
+
+// Start processes due erasures every cfg.ErasureJobInterval in the background.
+func (s *ErasureService) Start() {
+	if s.cfg.ErasureJobInterval <= 0 {
+		log.Println("Erasure job disabled (ERASURE_JOB_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.ErasureJobInterval)
+		defer ticker.Stop()
+		for {
+			if erased, err := s.EraseDueUsers(context.Background()); err != nil {
+				log.Printf("Warning: Erasure job failed: %v", err)
+			} else if erased > 0 {
+				log.Printf("Erasure job anonymized %d users", erased)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// EraseDueUsers erases users deleted longer than the grace period ago. A user whose erasure
+// fails (e.g. Stripe unavailable) is retried on the next run.
+func (s *ErasureService) EraseDueUsers(ctx context.Context) (int, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deleted_at IS NOT NULL AND erased_at IS NULL AND deleted_at < NOW() - make_interval(secs => $1)
+		ORDER BY deleted_at ASC
+		LIMIT $2
+	`
+	rows, err := s.db.Query(ctx, query, s.cfg.ErasureGracePeriod.Seconds(), erasureBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("database error listing users due for erasure: %w", err)
+	}
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error processing users due for erasure: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("database iteration error for users due for erasure: %w", err)
+	}
+
+	erased := 0
+	for _, userID := range userIDs {
+		if _, err := s.EraseUser(ctx, userID); err != nil {
+			log.Printf("Warning: Could not erase user %s: %v", userID, err)
+			continue
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+// EraseUser anonymizes a soft-deleted user, deletes their Stripe customer and records an
+// erasure certificate.
+func (s *ErasureService) EraseUser(ctx context.Context, userID uuid.UUID) (*models.ErasureCertificate, error) {
+	var requestedAt *time.Time
+	var erasedAt *time.Time
+	var stripeCustomerID *string
+	err := s.db.QueryRow(ctx, `SELECT deleted_at, erased_at, stripe_customer_id FROM users WHERE id = $1`, userID).Scan(&requestedAt, &erasedAt, &stripeCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching user for erasure: %w", err)
+	}
+	if requestedAt == nil {
+		return nil, newError(KindConflict, "user has not deleted their account")
+	}
+	if erasedAt != nil {
+		return nil, newError(KindConflict, "user is already erased")
+	}
+
+	// Stripe first: if it fails nothing is erased yet and the whole user is retried later
+	steps := []string{models.ErasureStepUserProfile, models.ErasureStepNotifications, models.ErasureStepEmailPrefs, models.ErasureStepFraudSignals, models.ErasureStepProfileHistory}
+	if stripeCustomerID != nil && *stripeCustomerID != "" {
+		if err := s.stripeClient.DeleteCustomer(ctx, *stripeCustomerID); err != nil && !isStripeResourceMissing(err) {
+			return nil, fmt.Errorf("failed to delete Stripe customer: %w", err)
+		}
+		steps = append(steps, models.ErasureStepStripeCustomer)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The email gets a per-user placeholder (it's unique and required); everything else personal is cleared
+	anonymizeQuery := `
+		UPDATE users SET
+			email = 'erased-' || id || '@erased.invalid',
+			whatsapp = NULL, whatsapp_encrypted = NULL, whatsapp_hash = NULL,
+			password_hash = '',
+			first_name = NULL, last_name = NULL, birth_date = NULL, birth_date_encrypted = NULL, nationality = NULL,
+			stripe_customer_id = NULL, expo_push_token = NULL,
+			last_known_location = NULL, last_known_location_encrypted = NULL, last_known_geohash = NULL,
+			erased_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND erased_at IS NULL
+		RETURNING erased_at
+	`
+	certificate := &models.ErasureCertificate{UserID: userID, RequestedAt: *requestedAt, Steps: steps}
+	if err := tx.QueryRow(ctx, anonymizeQuery, userID).Scan(&certificate.ErasedAt); err != nil {
+		return nil, fmt.Errorf("database error anonymizing user: %w", err)
+	}
+	scrubQueries := []string{
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM email_suppressions WHERE user_id = $1`,
+		`DELETE FROM profile_changes WHERE user_id = $1`,
+		`DELETE FROM recent_searches WHERE user_id = $1`,
+		`DELETE FROM saved_commutes WHERE user_id = $1`,
+		`DELETE FROM vehicles WHERE user_id = $1`,
+		`DELETE FROM ride_templates WHERE user_id = $1`,
+		`DELETE FROM saved_searches WHERE user_id = $1`,
+		`DELETE FROM ride_views WHERE user_id = $1`,
+		`UPDATE analytics_events SET user_id = NULL WHERE user_id = $1`,
+		`UPDATE fraud_events SET ip_address = NULL, payment_method_id = NULL, latitude = NULL, longitude = NULL WHERE user_id = $1`,
+		`UPDATE fraud_flags SET ip_address = NULL WHERE user_id = $1`,
+		`UPDATE moderation_flags SET content = '' WHERE user_id = $1`,
+		`UPDATE ride_reviews SET comment = NULL WHERE reviewer_id = $1`,
+	}
+	for _, query := range scrubQueries {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return nil, fmt.Errorf("database error scrubbing personal data: %w", err)
+		}
+	}
+
+	certificate.Digest = certificateDigest(certificate)
+	certificateQuery := `
+		INSERT INTO erasure_certificates (user_id, requested_at, erased_at, steps, digest)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, certificateQuery, userID, certificate.RequestedAt, certificate.ErasedAt, certificate.Steps, certificate.Digest).Scan(&certificate.ID)
+	if err != nil {
+		return nil, fmt.Errorf("database error recording erasure certificate: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit erasure: %w", err)
+	}
+
+	log.Printf("User %s erased (certificate %s)", userID, certificate.ID)
+	return certificate, nil
+}
+
+// certificateDigest hashes the certificate fields so later tampering is detectable.
+func certificateDigest(certificate *models.ErasureCertificate) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		certificate.UserID.String(),
+		certificate.RequestedAt.UTC().Format(time.RFC3339Nano),
+		certificate.ErasedAt.UTC().Format(time.RFC3339Nano),
+		strings.Join(certificate.Steps, ","),
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// isStripeResourceMissing reports whether Stripe says the object no longer exists.
+func isStripeResourceMissing(err error) bool {
+	var stripeErr *stripe.Error
+	return errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// Test that the certificate digest is stable across time zones and changes with any field
+func TestCertificateDigest(t *testing.T) {
+	erasedAt := time.Date(2025, 7, 1, 3, 0, 0, 0, time.UTC)
+	certificate := &models.ErasureCertificate{
+		UserID:      uuid.MustParse("7b0a3c9e-2f4d-4b61-9a8e-1c2d3e4f5a6b"),
+		RequestedAt: erasedAt.Add(-30 * 24 * time.Hour),
+		ErasedAt:    erasedAt,
+		Steps:       []string{models.ErasureStepUserProfile, models.ErasureStepStripeCustomer},
+	}
+	digest := certificateDigest(certificate)
+	if len(digest) != 64 {
+		t.Fatalf("certificateDigest() = %q, want 64 hex characters", digest)
+	}
+
+	paris := *certificate
+	paris.ErasedAt = erasedAt.In(time.FixedZone("CEST", 2*3600))
+	if certificateDigest(&paris) != digest {
+		t.Error("digest depends on the time zone of ErasedAt")
+	}
+	withoutStripe := *certificate
+	withoutStripe.Steps = []string{models.ErasureStepUserProfile}
+	if certificateDigest(&withoutStripe) == digest {
+		t.Error("digest unchanged after removing a step")
+	}
+}
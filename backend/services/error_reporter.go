@@ -0,0 +1,262 @@
+package services
+
+import (
+	"bytes"         // For request bodies
+	"encoding/json" // For encoding events
+	"errors"        // For unwrapping error chains
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"net/http"      // For the store endpoint
+	"net/url"       // For parsing the DSN
+	"os"            // For the server name
+	"runtime"       // For stack traces
+	"runtime/debug" // For the build revision
+	"strings"       // For string handling
+	"time"          // For timestamps
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+)
+
+// inAppPrefix marks stack frames from this module (shown expanded in Sentry).
+const inAppPrefix = "rideshare/backend"
+
+// ErrorContext is the request context attached to an error report.
+type ErrorContext struct {
+	UserID  *uuid.UUID        // Authenticated user, if any
+	Method  string            // HTTP method
+	URL     string            // Request URL
+	IP      string            // Client IP
+	Headers map[string]string // Non-sensitive request headers
+	Tags    map[string]string // Extra searchable tags (e.g. stripe_event_type)
+}
+
+// ErrorReporter sends errors and panics to Sentry or a compatible service (GlitchTip)
+// through the store API. With no DSN configured every method is a no-op, and a nil
+// *ErrorReporter is safe to use.
+type ErrorReporter struct {
+	endpoint    string // https://host/api/<project>/store/
+	authHeader  string // X-Sentry-Auth value
+	environment string
+	release     string
+	serverName  string
+	httpClient  *http.Client
+}
+
+// NewErrorReporter creates an ErrorReporter from cfg.SentryDSN. An invalid DSN disables reporting.
+func NewErrorReporter(cfg *config.Config) *ErrorReporter {
+	reporter := &ErrorReporter{
+		environment: cfg.SentryEnvironment,
+		release:     cfg.Release,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+	if reporter.environment == "" {
+		reporter.environment = string(cfg.Profile)
+	}
+	if reporter.release == "" {
+		reporter.release = buildRevision()
+	}
+	reporter.serverName, _ = os.Hostname()
+
+	if cfg.SentryDSN == "" {
+		log.Println("Error reporting disabled (SENTRY_DSN not set)")
+		return reporter
+	}
+	endpoint, key, err := parseDSN(cfg.SentryDSN)
+	if err != nil {
+		log.Printf("Warning: Invalid SENTRY_DSN, error reporting disabled: %v", err)
+		return reporter
+	}
+	reporter.endpoint = endpoint
+	reporter.authHeader = fmt.Sprintf("Sentry sentry_version=7, sentry_client=rideshare-backend/1.0, sentry_key=%s", key)
+	log.Printf("Error reporting enabled (environment %s, release %s)", reporter.environment, reporter.release)
+	return reporter
+}
+
+// parseDSN turns https://<key>@host[/prefix]/<project> into the store endpoint and public key.
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", errors.New("missing public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID, prefix := path[slash+1:], ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	if projectID == "" {
+		return "", "", errors.New("missing project ID")
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID), u.User.Username(), nil
+}
+
+// buildRevision returns the VCS revision the binary was built from, or "" if unknown.
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+// Enabled reports whether errors are sent anywhere.
+func (r *ErrorReporter) Enabled() bool {
+	return r != nil && r.endpoint != ""
+}
+
+// CaptureError reports an error with the caller's stack trace. Sending happens in the background.
+func (r *ErrorReporter) CaptureError(err error, errCtx ErrorContext) {
+	if !r.Enabled() || err == nil {
+		return
+	}
+	root := err
+	for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+		root = next
+	}
+	r.capture("error", fmt.Sprintf("%T", root), err.Error(), captureStack(3), errCtx)
+}
+
+// CapturePanic reports a recovered panic. Call it from the deferred function that recovered,
+// so the stack trace still contains the panicking frames.
+func (r *ErrorReporter) CapturePanic(recovered any, errCtx ErrorContext) {
+	if !r.Enabled() {
+		return
+	}
+	r.capture("fatal", "panic", fmt.Sprint(recovered), captureStack(3), errCtx)
+}
+
+// sentryFrame is a stack frame in the Sentry event format.
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// captureStack returns the current goroutine's stack, oldest frame first (as Sentry expects),
+// skipping the given number of innermost frames.
+func captureStack(skip int) []sentryFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []sentryFrame
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, sentryFrame{
+			Function: frame.Function,
+			Filename: shortFilename(frame.File),
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    strings.HasPrefix(frame.Function, inAppPrefix),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return stack
+}
+
+// shortFilename keeps the package directory and file name (e.g. services/payment_service.go).
+func shortFilename(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 {
+		return strings.Join(parts[len(parts)-2:], "/")
+	}
+	return path
+}
+
+// sentryEvent is the subset of the Sentry event payload that we send.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	User        map[string]string `json:"user,omitempty"`
+	Request     map[string]any    `json:"request,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// sentryException is an exception entry with its stack trace.
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+// capture builds the event and sends it in the background.
+func (r *ErrorReporter) capture(level, errType, message string, stack []sentryFrame, errCtx ErrorContext) {
+	event := sentryEvent{
+		EventID:     strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       level,
+		Platform:    "go",
+		Environment: r.environment,
+		Release:     r.release,
+		ServerName:  r.serverName,
+		Tags:        errCtx.Tags,
+	}
+	if errCtx.UserID != nil {
+		event.User = map[string]string{"id": errCtx.UserID.String(), "ip_address": errCtx.IP}
+	} else if errCtx.IP != "" {
+		event.User = map[string]string{"ip_address": errCtx.IP}
+	}
+	if errCtx.Method != "" {
+		event.Request = map[string]any{"method": errCtx.Method, "url": errCtx.URL, "headers": errCtx.Headers}
+	}
+	exception := sentryException{Type: errType, Value: message}
+	exception.Stacktrace.Frames = stack
+	event.Exception.Values = []sentryException{exception}
+
+	// Deliver asynchronously; reporting must never slow down or fail the request.
+	go r.send(event)
+}
+
+// send posts an event to the store endpoint. Failures are logged and dropped.
+func (r *ErrorReporter) send(event sentryEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error Reporting Error: Could not encode event: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error Reporting Error: Could not build request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.authHeader)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Error Reporting Error: Could not send event %s: %v", event.EventID, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Error Reporting Error: Event %s rejected with status %d", event.EventID, resp.StatusCode)
+		return
+	}
+	log.Printf("Error reported (event %s)", event.EventID)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+)
+
+// Test that DSNs map to the store endpoint, including self-hosted path prefixes
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		endpoint string
+		key      string
+		wantErr  bool
+	}{
+		{"https://abc123@o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/api/42/store/", "abc123", false},
+		{"https://key@glitchtip.example.com/errors/7", "https://glitchtip.example.com/errors/api/7/store/", "key", false},
+		{"https://o1.ingest.sentry.io/42", "", "", true},
+		{"https://key@o1.ingest.sentry.io/", "", "", true},
+	}
+	for _, tt := range tests {
+		endpoint, key, err := parseDSN(tt.dsn)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDSN(%s) error = %v, wantErr %v", tt.dsn, err, tt.wantErr)
+			continue
+		}
+		if endpoint != tt.endpoint || key != tt.key {
+			t.Errorf("parseDSN(%s) = %s, %s, want %s, %s", tt.dsn, endpoint, key, tt.endpoint, tt.key)
+		}
+	}
+}
+
+// Test that a captured error is sent with the user, request, release and root cause type
+func TestErrorReporter_CaptureError(t *testing.T) {
+	events := make(chan sentryEvent, 1)
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("X-Sentry-Auth")
+		var event sentryEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid event body: %v", err)
+		}
+		events <- event
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://publickey@", 1) + "/1"
+	reporter := NewErrorReporter(&config.Config{SentryDSN: dsn, Release: "v1.2.3", Profile: config.ProfileStaging})
+	userID := uuid.New()
+	reporter.CaptureError(fmt.Errorf("refund failed: %w", errors.New("card declined")), ErrorContext{
+		UserID: &userID,
+		Method: "POST",
+		URL:    "https://api.example.com/api/v1/rides/1/join-automatic",
+	})
+
+	select {
+	case event := <-events:
+		if event.Release != "v1.2.3" || event.Environment != "staging" {
+			t.Errorf("release, environment = %s, %s, want v1.2.3, staging", event.Release, event.Environment)
+		}
+		if event.User["id"] != userID.String() {
+			t.Errorf("user id = %s, want %s", event.User["id"], userID)
+		}
+		if event.Request["method"] != "POST" {
+			t.Errorf("request method = %v, want POST", event.Request["method"])
+		}
+		exception := event.Exception.Values[0]
+		if exception.Type != "*errors.errorString" || exception.Value != "refund failed: card declined" {
+			t.Errorf("exception = %s %q, want root cause type and full message", exception.Type, exception.Value)
+		}
+		if len(exception.Stacktrace.Frames) == 0 {
+			t.Error("exception has no stack frames")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	if !strings.Contains(authHeader, "sentry_key=publickey") {
+		t.Errorf("X-Sentry-Auth = %s, want sentry_key=publickey", authHeader)
+	}
+}
+
+// Test that a reporter without a DSN (or a nil one) never sends anything
+func TestErrorReporter_Disabled(t *testing.T) {
+	var nilReporter *ErrorReporter
+	nilReporter.CaptureError(errors.New("boom"), ErrorContext{})
+	if NewErrorReporter(&config.Config{}).Enabled() {
+		t.Error("reporter without DSN is enabled")
+	}
+}
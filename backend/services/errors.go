@@ -0,0 +1,52 @@
+package services
+
+// ErrorKind classifies a service error so the HTTP layer can pick a status code
+// without matching on the message.
+type ErrorKind int
+
+const (
+	KindInvalid         ErrorKind = iota + 1 // The request is malformed or breaks a business rule on its own (400)
+	KindUnauthorized                         // Bad or missing credentials (401)
+	KindPaymentRequired                      // A saved payment method is needed (402)
+	KindForbidden                            // The caller may not perform the action (403)
+	KindNotFound                             // The resource does not exist (404)
+	KindConflict                             // The action conflicts with the resource's current state (409)
+)
+
+// Error is a service error whose Message is safe to return to clients.
+// Err, if set, is the underlying cause; it is logged but never sent to clients.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError creates a service error of the given kind.
+func newError(kind ErrorKind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Errors returned in more than one place. Compare with errors.Is.
+var (
+	ErrRideNotFound          = newError(KindNotFound, "ride not found")
+	ErrUserNotFound          = newError(KindNotFound, "user not found")
+	ErrBookingOnHold         = newError(KindConflict, "booking is on hold pending review")
+	ErrRideFull              = newError(KindConflict, "ride is already full")
+	ErrCannotJoinOwnRide     = newError(KindConflict, "you cannot join your own ride")
+	ErrRemovedFromRide       = newError(KindForbidden, "you were removed from this ride by its creator")
+	ErrInvalidCredentials    = newError(KindUnauthorized, "invalid email or password")
+	ErrUserNotFoundOrDeleted = newError(KindNotFound, "user not found or deleted")
+	ErrAlreadyRegistered     = newError(KindConflict, "email or WhatsApp number already registered")
+	ErrWhatsAppTaken         = newError(KindConflict, "whatsapp number already registered")
+)
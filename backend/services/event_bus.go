@@ -0,0 +1,81 @@
+package services
+
+import (
+	"log"  // For logging
+	"sync" // For the subscriber list
+	"time" // For event timestamps
+
+	"github.com/google/uuid"
+)
+
+// RideEventType identifies a committed change to a ride or its participants.
+type RideEventType string
+
+const (
+	RideEventCreated   RideEventType = "ride.created"   // A ride was published
+	RideEventUpdated   RideEventType = "ride.updated"   // Ride data changed (e.g. admin correction)
+	RideEventJoined    RideEventType = "ride.joined"    // A participant became active or holds a seat during checkout (seat taken)
+	RideEventLeft      RideEventType = "ride.left"      // A participant left (seat freed)
+	RideEventCancelled RideEventType = "ride.cancelled" // The ride was cancelled or deleted
+	RideEventArchived  RideEventType = "ride.archived"  // The ride departed and was archived
+	RideEventCompleted RideEventType = "ride.completed" // The creator confirmed the ride, or its estimated arrival passed
+)
+
+// RideEvent describes a ride change. The route and date are empty when the ride no longer exists.
+type RideEvent struct {
+	Type                  RideEventType
+	RideID                uuid.UUID
+	UserID                uuid.UUID // Who made the change (creator, participant or admin)
+	DepartureLocationName string
+	ArrivalLocationName   string
+	DepartureDate         time.Time
+	At                    time.Time
+}
+
+// EventBus delivers ride events to in-process subscribers. Publish runs subscribers synchronously,
+// so they see the change before the request that made it returns (write-through). Subscribers
+// must be fast and must not block. A nil *EventBus drops events.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []func(RideEvent)
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a handler for every ride event.
+func (b *EventBus) Subscribe(handler func(RideEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handler)
+}
+
+// HasSubscribers reports whether publishing an event would reach anyone.
+func (b *EventBus) HasSubscribers() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers) > 0
+}
+
+// Publish delivers an event to all subscribers. Publish only after the change is committed.
+func (b *EventBus) Publish(event RideEvent) {
+	if b == nil {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	log.Printf("Event: %s for ride %s", event.Type, event.RideID)
+	for _, handler := range subscribers {
+		handler(event)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"crypto/aes"      // For AES-256-GCM
+	"crypto/cipher"   // For AES-256-GCM
+	"crypto/hmac"     // For blind indexes
+	"crypto/rand"     // For data keys and nonces
+	"crypto/sha256"   // For blind indexes and development keys
+	"encoding/base64" // For the stored format
+	"errors"          // For error values
+	"fmt"             // For error formatting
+	"log"             // For logging
+	"regexp"          // For key ID validation
+	"strconv"         // For coordinates
+	"strings"         // For parsing the stored format
+	"time"            // For dates
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// encryptedFieldPrefix starts every encrypted value. Values without it are legacy plaintext,
+// not yet migrated by the backfill, and are passed through unchanged on decryption.
+const encryptedFieldPrefix = "enc:v1:"
+
+// keyIDPattern restricts key IDs so they can be embedded in stored values and LIKE patterns.
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// FieldEncryptor encrypts sensitive columns (WhatsApp numbers, birth dates, locations) with
+// envelope encryption: every value gets its own AES-256-GCM data key, which is wrapped by a
+// key-encryption key (KEK) from FIELD_ENCRYPTION_KEYS. Rotating KEKs only re-wraps data keys.
+// Equality lookups use a keyed blind index (FIELD_INDEX_KEY) instead of the plaintext.
+//
+// Stored format: enc:v1:<kek id>:<wrapped data key>:<ciphertext>, both base64 with the nonce first.
+type FieldEncryptor struct {
+	activeKeyID string
+	keys        map[string][]byte // KEK by ID
+	indexKey    []byte
+}
+
+// NewFieldEncryptor parses FIELD_ENCRYPTION_KEYS ("id:base64key,..." with the active key first)
+// and FIELD_INDEX_KEY. Outside production, missing keys are derived from JWT_SECRET.
+func NewFieldEncryptor(cfg *config.Config) (*FieldEncryptor, error) {
+	encryptor := &FieldEncryptor{keys: make(map[string][]byte)}
+
+	if cfg.FieldEncryptionKeys == "" {
+		if cfg.IsProduction() {
+			return nil, errors.New("FIELD_ENCRYPTION_KEYS is required in production")
+		}
+		log.Println("Warning: FIELD_ENCRYPTION_KEYS not set, deriving a development key from JWT_SECRET")
+		key := sha256.Sum256([]byte("field-encryption|" + cfg.JWTSecret))
+		encryptor.activeKeyID = "dev"
+		encryptor.keys["dev"] = key[:]
+	}
+	for i, entry := range strings.Split(cfg.FieldEncryptionKeys, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("FIELD_ENCRYPTION_KEYS entry %d must be id:base64key with an alphanumeric id", i+1)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("FIELD_ENCRYPTION_KEYS key %q must be 32 bytes, base64 encoded", id)
+		}
+		if encryptor.activeKeyID == "" {
+			encryptor.activeKeyID = id
+		}
+		encryptor.keys[id] = key
+	}
+	if encryptor.activeKeyID == "" {
+		return nil, errors.New("FIELD_ENCRYPTION_KEYS contains no keys")
+	}
+
+	if cfg.FieldIndexKey == "" {
+		if cfg.IsProduction() {
+			return nil, errors.New("FIELD_INDEX_KEY is required in production")
+		}
+		key := sha256.Sum256([]byte("field-index|" + cfg.JWTSecret))
+		encryptor.indexKey = key[:]
+	} else {
+		key, err := base64.StdEncoding.DecodeString(cfg.FieldIndexKey)
+		if err != nil || len(key) < 32 {
+			return nil, errors.New("FIELD_INDEX_KEY must be at least 32 bytes, base64 encoded")
+		}
+		encryptor.indexKey = key
+	}
+	return encryptor, nil
+}
+
+// ActiveKeyPrefix is the prefix of values wrapped with the active KEK; values without it need
+// re-wrapping after a rotation.
+func (e *FieldEncryptor) ActiveKeyPrefix() string {
+	return encryptedFieldPrefix + e.activeKeyID + ":"
+}
+
+// seal encrypts plaintext with key, returning nonce|ciphertext.
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts nonce|ciphertext with key.
+func open(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+// Encrypt encrypts a value under a fresh data key wrapped by the active KEK.
+func (e *FieldEncryptor) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt field: %w", err)
+	}
+	wrappedKey, err := seal(e.keys[e.activeKeyID], dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return e.ActiveKeyPrefix() + base64.StdEncoding.EncodeToString(wrappedKey) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// parse splits a stored value and unwraps its data key.
+func (e *FieldEncryptor) parse(value string) (keyID string, dataKey, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, encryptedFieldPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, errors.New("malformed encrypted value")
+	}
+	kek, ok := e.keys[parts[0]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unknown encryption key %q", parts[0])
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, errors.New("malformed wrapped data key")
+	}
+	if ciphertext, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, errors.New("malformed ciphertext")
+	}
+	if dataKey, err = open(kek, wrappedKey); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return parts[0], dataKey, ciphertext, nil
+}
+
+// Decrypt returns the plaintext of a stored value. Legacy plaintext is returned unchanged.
+func (e *FieldEncryptor) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return value, nil
+	}
+	_, dataKey, ciphertext, err := e.parse(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap re-wraps the data key of a value encrypted under an older KEK with the active KEK.
+// The ciphertext itself is unchanged. Values already under the active KEK are returned as-is.
+func (e *FieldEncryptor) Rewrap(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) || strings.HasPrefix(value, e.ActiveKeyPrefix()) {
+		return value, nil
+	}
+	_, dataKey, ciphertext, err := e.parse(value)
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(e.keys[e.activeKeyID], dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return e.ActiveKeyPrefix() + base64.StdEncoding.EncodeToString(wrappedKey) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// BlindIndex returns the keyed hash used to look up a value by equality (e.g. whatsapp_hash).
+func (e *FieldEncryptor) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(strings.TrimSpace(value)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// EncryptDate encrypts a date as YYYY-MM-DD.
+func (e *FieldEncryptor) EncryptDate(date time.Time) (string, error) {
+	return e.Encrypt(date.Format("2006-01-02"))
+}
+
+// DecryptDate decrypts a date stored by EncryptDate (or a legacy YYYY-MM-DD value).
+func (e *FieldEncryptor) DecryptDate(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plaintext, err := e.Decrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("2006-01-02", plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted date: %w", err)
+	}
+	return &date, nil
+}
+
+// EncryptLocation encrypts coordinates as "latitude,longitude".
+func (e *FieldEncryptor) EncryptLocation(latitude, longitude float64) (string, error) {
+	return e.Encrypt(strconv.FormatFloat(latitude, 'f', -1, 64) + "," + strconv.FormatFloat(longitude, 'f', -1, 64))
+}
+
+// DecryptLocation decrypts coordinates stored by EncryptLocation (or a legacy "lat,lon" value).
+func (e *FieldEncryptor) DecryptLocation(value string) (models.GeoPoint, error) {
+	plaintext, err := e.Decrypt(value)
+	if err != nil {
+		return models.GeoPoint{}, err
+	}
+	return ParseCoordinates(plaintext)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"rideshare/backend/config"
+)
+
+func testEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+// Test that values round-trip, survive a key rotation and that legacy plaintext passes through
+func TestFieldEncryptor_RoundTripAndRotation(t *testing.T) {
+	cfg := &config.Config{FieldEncryptionKeys: "k1:" + testEncryptionKey('a'), FieldIndexKey: testEncryptionKey('i')}
+	oldEncryptor, err := NewFieldEncryptor(cfg)
+	if err != nil {
+		t.Fatalf("NewFieldEncryptor failed: %v", err)
+	}
+	encrypted, err := oldEncryptor.Encrypt("+33612345678")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if strings.Contains(encrypted, "33612345678") || !strings.HasPrefix(encrypted, "enc:v1:k1:") {
+		t.Errorf("unexpected encrypted value %q", encrypted)
+	}
+
+	// Rotate: k2 becomes active, k1 is kept to decrypt and re-wrap existing values
+	cfg.FieldEncryptionKeys = "k2:" + testEncryptionKey('b') + ",k1:" + testEncryptionKey('a')
+	encryptor, err := NewFieldEncryptor(cfg)
+	if err != nil {
+		t.Fatalf("NewFieldEncryptor failed: %v", err)
+	}
+	rewrapped, err := encryptor.Rewrap(encrypted)
+	if err != nil {
+		t.Fatalf("Rewrap failed: %v", err)
+	}
+	if !strings.HasPrefix(rewrapped, encryptor.ActiveKeyPrefix()) {
+		t.Errorf("re-wrapped value %q is not under the active key", rewrapped)
+	}
+	for _, value := range []string{encrypted, rewrapped, "+33612345678"} {
+		if got, err := encryptor.Decrypt(value); err != nil || got != "+33612345678" {
+			t.Errorf("Decrypt(%q) = %q, %v", value, got, err)
+		}
+	}
+
+	// Once k1 is retired, only re-wrapped values can be read
+	cfg.FieldEncryptionKeys = "k2:" + testEncryptionKey('b')
+	retired, _ := NewFieldEncryptor(cfg)
+	if _, err := retired.Decrypt(encrypted); err == nil {
+		t.Error("decrypting a value under a retired key succeeded")
+	}
+	if _, err := retired.Decrypt(rewrapped); err != nil {
+		t.Errorf("decrypting a re-wrapped value failed: %v", err)
+	}
+
+	if encryptor.BlindIndex("+33612345678") != oldEncryptor.BlindIndex(" +33612345678") {
+		t.Error("blind index changed across key rotation")
+	}
+}
+
+// Test that production requires explicit keys
+func TestNewFieldEncryptor_RequiresKeysInProduction(t *testing.T) {
+	cfg := &config.Config{Profile: config.ProfileProd, JWTSecret: "secret"}
+	if _, err := NewFieldEncryptor(cfg); err == nil {
+		t.Error("expected an error without FIELD_ENCRYPTION_KEYS in production")
+	}
+	cfg.FieldEncryptionKeys = "bad id:" + testEncryptionKey('a')
+	cfg.FieldIndexKey = testEncryptionKey('i')
+	if _, err := NewFieldEncryptor(cfg); err == nil {
+		t.Error("expected an error for a non-alphanumeric key id")
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"bytes"         // For building export files
+	"context"       // For database calls
+	"encoding/csv"  // For CSV exports
+	"encoding/json" // For JSON exports
+	"errors"        // For pgx error checks
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"strings"       // For DATEV fields
+	"time"          // For the worker schedule and booking dates
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+const (
+	financeExportPollInterval = time.Minute      // How often the worker looks for exports it wasn't woken for
+	financeExportStaleAfter   = 15 * time.Minute // A running export not finished after this is generated again
+	financeExportListLimit    = 50
+)
+
+// ErrFinanceExportNotFound is returned when an export ID is unknown.
+var ErrFinanceExportNotFound = newError(KindNotFound, "export not found")
+
+// ErrFinanceExportNotReady is returned when downloading an export that hasn't been generated.
+var ErrFinanceExportNotReady = newError(KindConflict, "export is not ready")
+
+const financeExportColumns = `id, period_start::text, period_end::text, format, status, entry_count, last_error, created_by, created_at, finished_at`
+
+// FinanceExportService generates accounting journals of booking fees and refunds per period in the
+// background (CSV, JSON or DATEV), for admins to download. Every fee and refund gets a journal
+// number the first time it is exported, so exporting a period again yields the same numbers.
+// Payouts are not exported: the platform only collects its own booking fees.
+type FinanceExportService struct {
+	cfg   *config.Config
+	db    database.DBPool
+	audit *AuditService
+	wake  chan struct{}
+}
+
+// NewFinanceExportService creates a new FinanceExportService instance.
+func NewFinanceExportService(cfg *config.Config, db database.DBPool, audit *AuditService) *FinanceExportService {
+	return &FinanceExportService{
+		cfg:   cfg,
+		db:    db,
+		audit: audit,
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Create queues the export of a period.
+func (s *FinanceExportService) Create(ctx context.Context, adminID uuid.UUID, req models.CreateFinanceExportRequest, ip string) (*models.FinanceExport, error) {
+	if err := NewValidator().Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid export request: %w", err)
+	}
+	if req.From > req.To { // YYYY-MM-DD sorts chronologically
+		return nil, newError(KindInvalid, "from must not be after to")
+	}
+
+	query := `INSERT INTO finance_exports (period_start, period_end, format, created_by) VALUES ($1::date, $2::date, $3, $4) RETURNING ` + financeExportColumns
+	export, err := scanFinanceExport(s.db.QueryRow(ctx, query, req.From, req.To, req.Format, adminID))
+	if err != nil {
+		log.Printf("Error queueing finance export for admin %s: %v", adminID, err)
+		return nil, fmt.Errorf("database error queueing export: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionFinanceExportCreated,
+		TargetType: "finance_export",
+		TargetID:   export.ID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"from": req.From, "to": req.To, "format": req.Format},
+	})
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // The worker is already due to look for exports
+	}
+	return export, nil
+}
+
+// Get returns an export and its status.
+func (s *FinanceExportService) Get(ctx context.Context, exportID uuid.UUID) (*models.FinanceExport, error) {
+	export, err := scanFinanceExport(s.db.QueryRow(ctx, `SELECT `+financeExportColumns+` FROM finance_exports WHERE id = $1`, exportID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFinanceExportNotFound
+		}
+		return nil, fmt.Errorf("database error fetching export: %w", err)
+	}
+	return export, nil
+}
+
+// List returns the most recent exports, newest first.
+func (s *FinanceExportService) List(ctx context.Context) ([]models.FinanceExport, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+financeExportColumns+` FROM finance_exports ORDER BY created_at DESC LIMIT $1`, financeExportListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []models.FinanceExport{}
+	for rows.Next() {
+		export, err := scanFinanceExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for exports: %w", err)
+	}
+	return exports, nil
+}
+
+// Download returns the generated file of an export with its file name and content type.
+func (s *FinanceExportService) Download(ctx context.Context, adminID uuid.UUID, exportID uuid.UUID, ip string) (content []byte, filename string, contentType string, err error) {
+	export, err := s.Get(ctx, exportID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if export.Status != models.AdminJobSucceeded {
+		return nil, "", "", ErrFinanceExportNotReady
+	}
+	var text string
+	if err := s.db.QueryRow(ctx, `SELECT content FROM finance_exports WHERE id = $1`, exportID).Scan(&text); err != nil {
+		return nil, "", "", fmt.Errorf("database error fetching export content: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionFinanceExportFetched,
+		TargetType: "finance_export",
+		TargetID:   exportID.String(),
+		IPAddress:  ip,
+	})
+
+	from := strings.ReplaceAll(export.PeriodStart, "-", "")
+	to := strings.ReplaceAll(export.PeriodEnd, "-", "")
+	switch export.Format {
+	case models.FinanceExportJSON:
+		return []byte(text), fmt.Sprintf("journal_%s_%s.json", from, to), "application/json", nil
+	case models.FinanceExportDATEV:
+		return []byte(text), fmt.Sprintf("EXTF_Buchungsstapel_%s_%s.csv", from, to), "text/csv; charset=utf-8", nil
+	default:
+		return []byte(text), fmt.Sprintf("journal_%s_%s.csv", from, to), "text/csv; charset=utf-8", nil
+	}
+}
+
+func scanFinanceExport(row pgx.Row) (*models.FinanceExport, error) {
+	var export models.FinanceExport
+	err := row.Scan(&export.ID, &export.PeriodStart, &export.PeriodEnd, &export.Format, &export.Status, &export.EntryCount,
+		&export.LastError, &export.CreatedBy, &export.CreatedAt, &export.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Start generates queued exports one at a time in the background, when one is queued and every
+// financeExportPollInterval (to pick up exports queued by other instances or left stale).
+func (s *FinanceExportService) Start() {
+	go func() {
+		ticker := time.NewTicker(financeExportPollInterval)
+		defer ticker.Stop()
+		for {
+			for s.runNextExport(context.Background()) {
+			}
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// runNextExport claims and generates the oldest pending export. It reports whether there was one.
+func (s *FinanceExportService) runNextExport(ctx context.Context) bool {
+	claimQuery := `
+		UPDATE finance_exports
+		SET status = $1, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM finance_exports
+			WHERE status = $2 OR (status = $1 AND updated_at < NOW() - make_interval(secs => $3))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + financeExportColumns
+	export, err := scanFinanceExport(s.db.QueryRow(ctx, claimQuery, string(models.AdminJobRunning), string(models.AdminJobQueued), financeExportStaleAfter.Seconds()))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Warning: Failed claiming finance export: %v", err)
+		}
+		return false
+	}
+
+	entries, err := s.journal(ctx, export.PeriodStart, export.PeriodEnd)
+	var content []byte
+	if err == nil {
+		content, err = renderFinanceExport(export, entries, s.cfg)
+	}
+	if err != nil {
+		log.Printf("Finance export %s failed: %v", export.ID, err)
+		failQuery := `UPDATE finance_exports SET status = $2, last_error = $3, finished_at = NOW(), updated_at = NOW() WHERE id = $1`
+		if _, err := s.db.Exec(ctx, failQuery, export.ID, string(models.AdminJobFailed), err.Error()); err != nil {
+			log.Printf("Warning: Failed recording failure of finance export %s: %v", export.ID, err)
+		}
+		return true
+	}
+
+	doneQuery := `UPDATE finance_exports SET status = $2, content = $3, entry_count = $4, last_error = NULL, finished_at = NOW(), updated_at = NOW() WHERE id = $1`
+	if _, err := s.db.Exec(ctx, doneQuery, export.ID, string(models.AdminJobSucceeded), string(content), len(entries)); err != nil {
+		log.Printf("Warning: Failed storing finance export %s: %v", export.ID, err)
+		return true
+	}
+	log.Printf("Finance export %s (%s, %s to %s) generated: %d entries", export.ID, export.Format, export.PeriodStart, export.PeriodEnd, len(entries))
+	return true
+}
+
+// journal numbers the fees and refunds booked up to the end of the period that have no journal
+// number yet, in booking order, and returns the entries of the period.
+func (s *FinanceExportService) journal(ctx context.Context, from string, to string) ([]models.FinanceJournalEntry, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Concurrent exports would interleave their numbers
+	if _, err := tx.Exec(ctx, `LOCK TABLE finance_journal_entries IN EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("database error locking journal: %w", err)
+	}
+	numberQuery := `
+		INSERT INTO finance_journal_entries (source_type, source_id, booked_at)
+		SELECT source_type, source_id, booked_at FROM (
+			SELECT 'fee' AS source_type, id AS source_id, COALESCE(paid_at, created_at) AS booked_at
+			FROM payments WHERE status IN ('succeeded', 'refunded')
+			UNION ALL
+			SELECT 'refund', id, created_at FROM payment_refunds
+		) sources
+		WHERE booked_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC'
+		ORDER BY booked_at, source_id
+		ON CONFLICT (source_type, source_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, numberQuery, to); err != nil {
+		return nil, fmt.Errorf("database error numbering journal entries: %w", err)
+	}
+
+	entriesQuery := `
+		SELECT j.number, j.booked_at, j.source_type, pm.id,
+		       CASE WHEN j.source_type = 'fee' THEN pm.stripe_payment_intent_id ELSE COALESCE(rf.stripe_refund_id, pm.stripe_payment_intent_id) END,
+		       CASE WHEN j.source_type = 'fee' THEN pm.amount::bigint ELSE rf.amount END,
+		       pm.currency::text
+		FROM finance_journal_entries j
+		LEFT JOIN payment_refunds rf ON j.source_type = 'refund' AND rf.id = j.source_id
+		JOIN payments pm ON pm.id = CASE WHEN j.source_type = 'fee' THEN j.source_id ELSE rf.payment_id END
+		WHERE j.booked_at >= $1::timestamp AT TIME ZONE 'UTC' AND j.booked_at < ($2::date + 1)::timestamp AT TIME ZONE 'UTC'
+		ORDER BY j.number
+	`
+	rows, err := tx.Query(ctx, entriesQuery, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching journal entries: %w", err)
+	}
+	var entries []models.FinanceJournalEntry
+	for rows.Next() {
+		var entry models.FinanceJournalEntry
+		if err := rows.Scan(&entry.Number, &entry.BookedAt, &entry.Type, &entry.PaymentID, &entry.Reference, &entry.Gross, &entry.Currency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error processing journal entry: %w", err)
+		}
+		entry.Currency = strings.ToUpper(strings.TrimSpace(entry.Currency))
+		entry.Net, entry.VAT = splitVAT(entry.Gross, s.cfg.FinanceVATRateBasisPoints)
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for journal entries: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit journal numbers: %w", err)
+	}
+	return entries, nil
+}
+
+// splitVAT splits a gross amount that includes VAT at basisPoints (1900 = 19%) into net and VAT,
+// rounding the VAT half up.
+func splitVAT(gross int64, basisPoints int) (net int64, vat int64) {
+	if basisPoints <= 0 {
+		return gross, 0
+	}
+	divisor := int64(10000 + basisPoints)
+	vat = (gross*int64(basisPoints) + divisor/2) / divisor
+	return gross - vat, vat
+}
+
+// formatCents formats an amount in the smallest currency unit with two decimals and the given separator.
+func formatCents(cents int64, separator string) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d%s%02d", sign, cents/100, separator, cents%100)
+}
+
+// renderFinanceExport renders the journal entries of an export in its format.
+func renderFinanceExport(export *models.FinanceExport, entries []models.FinanceJournalEntry, cfg *config.Config) ([]byte, error) {
+	switch export.Format {
+	case models.FinanceExportJSON:
+		return renderFinanceJSON(export, entries, cfg)
+	case models.FinanceExportDATEV:
+		return renderFinanceDATEV(export, entries, cfg, time.Now())
+	case models.FinanceExportCSV:
+		return renderFinanceCSV(entries)
+	}
+	return nil, fmt.Errorf("unknown export format: %s", export.Format)
+}
+
+func renderFinanceCSV(entries []models.FinanceJournalEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"number", "booked_at", "type", "payment_id", "reference", "gross", "net", "vat", "currency"})
+	for _, e := range entries {
+		_ = w.Write([]string{
+			fmt.Sprint(e.Number), e.BookedAt.UTC().Format(time.RFC3339), string(e.Type), e.PaymentID.String(), e.Reference,
+			formatCents(e.Gross, "."), formatCents(e.Net, "."), formatCents(e.VAT, "."), e.Currency,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func renderFinanceJSON(export *models.FinanceExport, entries []models.FinanceJournalEntry, cfg *config.Config) ([]byte, error) {
+	type totals struct {
+		Fees    int64 `json:"fees"`
+		Refunds int64 `json:"refunds"`
+		VAT     int64 `json:"vat"` // VAT on fees minus VAT on refunds
+	}
+	document := struct {
+		PeriodStart        string                       `json:"period_start"`
+		PeriodEnd          string                       `json:"period_end"`
+		VATRateBasisPoints int                          `json:"vat_rate_basis_points"`
+		Entries            []models.FinanceJournalEntry `json:"entries"`
+		Totals             totals                       `json:"totals"`
+	}{
+		PeriodStart:        export.PeriodStart,
+		PeriodEnd:          export.PeriodEnd,
+		VATRateBasisPoints: cfg.FinanceVATRateBasisPoints,
+		Entries:            entries,
+	}
+	if document.Entries == nil {
+		document.Entries = []models.FinanceJournalEntry{}
+	}
+	for _, e := range entries {
+		if e.Type == models.FinanceEntryRefund {
+			document.Totals.Refunds += e.Gross
+			document.Totals.VAT -= e.VAT
+		} else {
+			document.Totals.Fees += e.Gross
+			document.Totals.VAT += e.VAT
+		}
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// renderFinanceDATEV renders a DATEV Buchungsstapel (EXTF format 700, category 21). Fees debit the
+// clearing account and credit the revenue account; refunds book the other way round. The revenue
+// account carries the VAT key, so no BU-Schlüssel is set.
+func renderFinanceDATEV(export *models.FinanceExport, entries []models.FinanceJournalEntry, cfg *config.Config, now time.Time) ([]byte, error) {
+	from, err := time.Parse("2006-01-02", export.PeriodStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period start: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", export.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period end: %w", err)
+	}
+	quote := func(value string) string {
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+
+	var buf bytes.Buffer
+	header := []string{
+		quote("EXTF"), "700", "21", quote("Buchungsstapel"), "13", now.UTC().Format("20060102150405") + "000", "", quote("RS"), quote(""), quote(""),
+		cfg.DATEVConsultantNumber, cfg.DATEVClientNumber, from.Format("2006") + "0101", "4", from.Format("20060102"), to.Format("20060102"),
+		quote(fmt.Sprintf("RideShare %s - %s", export.PeriodStart, export.PeriodEnd)), quote(""), "1", "0", "0", quote("EUR"),
+	}
+	buf.WriteString(strings.Join(header, ";") + "\r\n")
+	buf.WriteString("Umsatz (ohne Soll/Haben-Kz);Soll/Haben-Kennzeichen;WKZ Umsatz;Konto;Gegenkonto (ohne BU-Schlüssel);BU-Schlüssel;Belegdatum;Belegfeld 1;Buchungstext\r\n")
+	for _, e := range entries {
+		debitCredit, text := "S", "Buchungsgebühr "+e.Reference
+		if e.Type == models.FinanceEntryRefund {
+			debitCredit, text = "H", "Erstattung "+e.Reference
+		}
+		if len([]rune(text)) > 60 {
+			text = string([]rune(text)[:60])
+		}
+		row := []string{
+			formatCents(e.Gross, ","), quote(debitCredit), quote(e.Currency), cfg.FinanceClearingAccount, cfg.FinanceRevenueAccount, quote(""),
+			e.BookedAt.UTC().Format("0201"), quote(fmt.Sprint(e.Number)), quote(text),
+		}
+		buf.WriteString(strings.Join(row, ";") + "\r\n")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that VAT included in a fee is split off and rounded half up
+func TestSplitVAT(t *testing.T) {
+	tests := []struct {
+		gross, basisPoints int
+		wantNet, wantVAT   int64
+	}{
+		{200, 1900, 168, 32}, // 2.00 EUR at 19%: 31.93 cents of VAT
+		{119, 1900, 100, 19},
+		{200, 0, 200, 0},
+		{1, 700, 1, 0},
+	}
+	for _, tt := range tests {
+		net, vat := splitVAT(int64(tt.gross), tt.basisPoints)
+		if net != tt.wantNet || vat != tt.wantVAT {
+			t.Errorf("splitVAT(%d, %d) = %d, %d; want %d, %d", tt.gross, tt.basisPoints, net, vat, tt.wantNet, tt.wantVAT)
+		}
+	}
+}
+
+// Test that DATEV rows book fees and refunds on opposite sides with German amounts
+func TestRenderFinanceDATEV(t *testing.T) {
+	cfg := &config.Config{FinanceRevenueAccount: "8400", FinanceClearingAccount: "1360", DATEVConsultantNumber: "1001", DATEVClientNumber: "1"}
+	export := &models.FinanceExport{PeriodStart: "2025-03-01", PeriodEnd: "2025-03-31", Format: models.FinanceExportDATEV}
+	booked := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+	entries := []models.FinanceJournalEntry{
+		{Number: 41, BookedAt: booked, Type: models.FinanceEntryFee, PaymentID: uuid.New(), Reference: "pi_1", Gross: 200, Currency: "EUR"},
+		{Number: 42, BookedAt: booked, Type: models.FinanceEntryRefund, PaymentID: uuid.New(), Reference: "re_1", Gross: 100, Currency: "EUR"},
+	}
+
+	content, err := renderFinanceDATEV(export, entries, cfg, booked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\r\n"), "\r\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header, column names and 2 rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], `"EXTF";700;21;"Buchungsstapel"`) || !strings.Contains(lines[0], ";1001;1;20250101;4;20250301;20250331;") {
+		t.Errorf("Unexpected DATEV header: %s", lines[0])
+	}
+	if want := `2,00;"S";"EUR";1360;8400;"";0703;"41";"Buchungsgebühr pi_1"`; lines[2] != want {
+		t.Errorf("Fee row = %s, want %s", lines[2], want)
+	}
+	if want := `1,00;"H";"EUR";1360;8400;"";0703;"42";"Erstattung re_1"`; lines[3] != want {
+		t.Errorf("Refund row = %s, want %s", lines[3], want)
+	}
+}
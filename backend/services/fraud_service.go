@@ -0,0 +1,492 @@
+package services
+
+import (
+	"context" // For database operations context
+	"errors"  // For creating standard errors
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"math"    // For distance calculations
+	"slices"  // For flag rule lookups
+	"strings" // For building dynamic updates
+	"sync"    // For the rules cache
+	"time"    // For rule windows
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// FraudService evaluates the fraud rules on signup, join and payment.
+// Rules live in the 'fraud_rules' table and are cached for cfg.FraudRulesRefreshInterval,
+// so they can be tuned in the database (or through the admin API) without a redeploy.
+type FraudService struct {
+	cfg       *config.Config
+	db        database.DBPool
+	crypto    *FieldEncryptor // Decrypts users' last known locations
+	validator *validator.Validate
+
+	mu       sync.RWMutex
+	rules    []models.FraudRule
+	loadedAt time.Time
+}
+
+// NewFraudService creates a new FraudService instance.
+func NewFraudService(cfg *config.Config, db database.DBPool, crypto *FieldEncryptor) *FraudService {
+	return &FraudService{
+		cfg:       cfg,
+		db:        db,
+		crypto:    crypto,
+		validator: NewValidator(),
+	}
+}
+
+// fraudActionRank orders actions from least to most restrictive.
+var fraudActionRank = map[models.FraudAction]int{
+	models.FraudActionAllow: 0,
+	models.FraudActionFlag:  1,
+	models.FraudActionHold:  2,
+}
+
+// strongerFraudAction returns the more restrictive of two actions.
+func strongerFraudAction(a, b models.FraudAction) models.FraudAction {
+	if fraudActionRank[b] > fraudActionRank[a] {
+		return b
+	}
+	return a
+}
+
+// checkLocation resolves the country and last known device location used by the rules.
+func (s *FraudService) checkLocation(ctx context.Context, check *models.FraudCheck) (country string, latitude, longitude *float64) {
+	// IP geolocation (set by the geo middleware) fills in the IP and country. Its coordinates are too
+	// coarse to mix with device locations, so impossible-travel checks only use last_known_location.
+	geo := models.GeoFromContext(ctx)
+	if geo != nil {
+		country = geo.CountryCode
+		if check.IPAddress == "" {
+			check.IPAddress = geo.IP
+		}
+	}
+
+	if check.UserID != nil && check.Event != models.FraudEventSignup {
+		// Locations are stored encrypted; rows not yet backfilled still have the plaintext point
+		locationQuery := `
+			SELECT COALESCE(last_known_location_encrypted, ST_Y(last_known_location::geometry) || ',' || ST_X(last_known_location::geometry))
+			FROM users WHERE id = $1 AND (last_known_location_encrypted IS NOT NULL OR last_known_location IS NOT NULL)
+		`
+		var encrypted string
+		err := s.db.QueryRow(ctx, locationQuery, *check.UserID).Scan(&encrypted)
+		if err == nil {
+			if point, decryptErr := s.crypto.DecryptLocation(encrypted); decryptErr == nil {
+				latitude, longitude = &point.Latitude, &point.Longitude
+			} else {
+				log.Printf("Fraud Warning: Failed decrypting location for user %s: %v", *check.UserID, decryptErr)
+			}
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Fraud Warning: Failed loading location for user %s: %v", *check.UserID, err)
+		}
+	}
+	return country, latitude, longitude
+}
+
+// Evaluate runs the enabled rules for the check's event and, if any rule triggers, opens a fraud
+// flag for review. It does not record the event: callers call RecordEvent once the action went
+// through, so refused attempts don't count towards the rules. Evaluation fails open: database
+// errors are logged and the action is allowed, so an outage never blocks signups or bookings.
+func (s *FraudService) Evaluate(ctx context.Context, check models.FraudCheck) *models.FraudDecision {
+	decision := &models.FraudDecision{Action: models.FraudActionAllow}
+	country, latitude, longitude := s.checkLocation(ctx, &check)
+
+	for _, rule := range s.rulesFor(ctx, check.Event) {
+		triggered, err := s.evaluateRule(ctx, rule, check, country, latitude, longitude)
+		if err != nil {
+			log.Printf("Fraud Warning: Failed evaluating rule '%s' for %s: %v", rule.Name, check.Event, err)
+			continue
+		}
+		if triggered {
+			decision.TriggeredRules = append(decision.TriggeredRules, rule.Name)
+			decision.Action = strongerFraudAction(decision.Action, rule.Action)
+		}
+	}
+
+	if decision.Action == models.FraudActionAllow {
+		return decision
+	}
+
+	// A recent admin review cleared this user: keep flagging, but stop holding or blocking them
+	if decision.Action != models.FraudActionFlag && check.UserID != nil && s.recentlyCleared(ctx, *check.UserID) {
+		log.Printf("Fraud Info: User %s was recently cleared by review, downgrading '%s' to flag", *check.UserID, decision.Action)
+		decision.Action = models.FraudActionFlag
+	}
+
+	flagID := uuid.New()
+	insertFlag := `
+		INSERT INTO fraud_flags (id, event, user_id, ride_id, ip_address, action, rules)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+	`
+	if _, err := s.db.Exec(ctx, insertFlag, flagID, check.Event, check.UserID, check.RideID, check.IPAddress, decision.Action, decision.TriggeredRules); err != nil {
+		log.Printf("Fraud Error: Failed opening flag for %s by user %v (rules %v): %v", check.Event, check.UserID, decision.TriggeredRules, err)
+	} else {
+		decision.FlagID = &flagID
+	}
+
+	log.Printf("Fraud Info: %s by user %v (IP %s) triggered %v -> %s", check.Event, check.UserID, check.IPAddress, decision.TriggeredRules, decision.Action)
+	return decision
+}
+
+// RecordEvent records an event that went through (including bookings held for review), so
+// later checks count it. Failures are logged, never returned.
+func (s *FraudService) RecordEvent(ctx context.Context, check models.FraudCheck) {
+	country, latitude, longitude := s.checkLocation(ctx, &check)
+	insertEvent := `
+		INSERT INTO fraud_events (event, user_id, ip_address, payment_method_id, latitude, longitude, country)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, NULLIF($7, ''))
+	`
+	if _, err := s.db.Exec(ctx, insertEvent, check.Event, check.UserID, check.IPAddress, check.PaymentMethodID, latitude, longitude, country); err != nil {
+		log.Printf("Fraud Warning: Failed recording %s event for user %v: %v", check.Event, check.UserID, err)
+	}
+}
+
+// evaluateRule reports whether a single rule triggers for the check.
+func (s *FraudService) evaluateRule(ctx context.Context, rule models.FraudRule, check models.FraudCheck, country string, latitude, longitude *float64) (bool, error) {
+	since := time.Now().Add(-time.Duration(rule.WindowSeconds) * time.Second)
+
+	switch rule.RuleType {
+	case models.FraudRuleVelocity:
+		// Signups have no user yet, so they are counted per IP
+		var count int
+		var err error
+		if check.UserID != nil {
+			query := `SELECT COUNT(*) FROM fraud_events WHERE user_id = $1 AND event = $2 AND created_at > $3`
+			err = s.db.QueryRow(ctx, query, *check.UserID, check.Event, since).Scan(&count)
+		} else if check.IPAddress != "" {
+			query := `SELECT COUNT(*) FROM fraud_events WHERE ip_address = $1 AND event = $2 AND created_at > $3`
+			err = s.db.QueryRow(ctx, query, check.IPAddress, check.Event, since).Scan(&count)
+		} else {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return float64(count+1) > rule.Threshold, nil
+
+	case models.FraudRuleDistinctCards:
+		if check.UserID == nil || check.PaymentMethodID == "" {
+			return false, nil
+		}
+		var otherCards int
+		query := `
+			SELECT COUNT(DISTINCT payment_method_id) FROM fraud_events
+			WHERE user_id = $1 AND payment_method_id IS NOT NULL AND payment_method_id <> $2 AND created_at > $3
+		`
+		if err := s.db.QueryRow(ctx, query, *check.UserID, check.PaymentMethodID, since).Scan(&otherCards); err != nil {
+			return false, err
+		}
+		return float64(otherCards+1) > rule.Threshold, nil
+
+	case models.FraudRuleGeoImpossible:
+		if check.UserID == nil || latitude == nil || longitude == nil {
+			return false, nil
+		}
+		var prevLat, prevLon float64
+		var prevAt time.Time
+		query := `
+			SELECT latitude, longitude, created_at FROM fraud_events
+			WHERE user_id = $1 AND latitude IS NOT NULL AND longitude IS NOT NULL AND created_at > $2
+			ORDER BY created_at DESC LIMIT 1
+		`
+		err := s.db.QueryRow(ctx, query, *check.UserID, since).Scan(&prevLat, &prevLon, &prevAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+			return false, err
+		}
+		return impliedSpeedKmh(prevLat, prevLon, *latitude, *longitude, time.Since(prevAt)) > rule.Threshold, nil
+
+	case models.FraudRuleCountryChange:
+		if check.UserID == nil || country == "" {
+			return false, nil
+		}
+		var otherCountries int
+		query := `
+			SELECT COUNT(DISTINCT country) FROM fraud_events
+			WHERE user_id = $1 AND country IS NOT NULL AND country <> $2 AND created_at > $3
+		`
+		if err := s.db.QueryRow(ctx, query, *check.UserID, country, since).Scan(&otherCountries); err != nil {
+			return false, err
+		}
+		return float64(otherCountries+1) > rule.Threshold, nil
+	}
+
+	log.Printf("Fraud Warning: Rule '%s' has unknown type '%s', skipping", rule.Name, rule.RuleType)
+	return false, nil
+}
+
+// impliedSpeedKmh returns the speed needed to travel between two points in the elapsed time.
+// Elapsed time is floored at one minute so location jitter between quick requests doesn't explode.
+func impliedSpeedKmh(lat1, lon1, lat2, lon2 float64, elapsed time.Duration) float64 {
+	distance := haversineKm(lat1, lon1, lat2, lon2)
+	if distance < 1 {
+		return 0
+	}
+	if elapsed < time.Minute {
+		elapsed = time.Minute
+	}
+	return distance / elapsed.Hours()
+}
+
+// haversineKm returns the great-circle distance between two coordinates in kilometres.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// recentlyCleared reports whether an admin cleared a review for the user within cfg.FraudClearedGrace.
+func (s *FraudService) recentlyCleared(ctx context.Context, userID uuid.UUID) bool {
+	var cleared bool
+	query := `SELECT EXISTS(SELECT 1 FROM fraud_flags WHERE user_id = $1 AND status = $2 AND resolved_at > $3)`
+	err := s.db.QueryRow(ctx, query, userID, models.FraudFlagStatusCleared, time.Now().Add(-s.cfg.FraudClearedGrace)).Scan(&cleared)
+	if err != nil {
+		log.Printf("Fraud Warning: Failed checking cleared reviews for user %s: %v", userID, err)
+		return false
+	}
+	return cleared
+}
+
+// rulesFor returns the enabled rules for an event, reloading the cache when it is stale.
+// If reloading fails the previous rules are kept.
+func (s *FraudService) rulesFor(ctx context.Context, event models.FraudEvent) []models.FraudRule {
+	s.mu.RLock()
+	stale := s.loadedAt.IsZero() || time.Since(s.loadedAt) > s.cfg.FraudRulesRefreshInterval
+	s.mu.RUnlock()
+
+	if stale {
+		rules, err := s.ListRules(ctx)
+		if err != nil {
+			log.Printf("Fraud Warning: Failed reloading rules, keeping cached set: %v", err)
+		} else {
+			s.mu.Lock()
+			s.rules = rules
+			s.loadedAt = time.Now()
+			s.mu.Unlock()
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matching []models.FraudRule
+	for _, rule := range s.rules {
+		if rule.Enabled && rule.Event == event {
+			matching = append(matching, rule)
+		}
+	}
+	return matching
+}
+
+// invalidateRules forces the next evaluation to re-read the rules.
+func (s *FraudService) invalidateRules() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+const fraudRuleColumns = `id, name, event, rule_type, threshold::float8, window_seconds, action, enabled, updated_at`
+
+func scanFraudRule(row pgx.Row) (*models.FraudRule, error) {
+	var rule models.FraudRule
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Event, &rule.RuleType, &rule.Threshold, &rule.WindowSeconds, &rule.Action, &rule.Enabled, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules returns all fraud rules, enabled or not.
+func (s *FraudService) ListRules(ctx context.Context) ([]models.FraudRule, error) {
+	query := `SELECT ` + fraudRuleColumns + ` FROM fraud_rules ORDER BY event, name`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing fraud rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.FraudRule{}
+	for rows.Next() {
+		rule, err := scanFraudRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning fraud rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fraud rules: %w", err)
+	}
+	return rules, nil
+}
+
+// UpdateRule changes a rule's threshold, window, action or enabled flag and returns the
+// previous and updated versions. The change applies immediately on this instance and
+// within cfg.FraudRulesRefreshInterval on the others.
+func (s *FraudService) UpdateRule(ctx context.Context, ruleID uuid.UUID, req models.UpdateFraudRuleRequest) (*models.FraudRule, *models.FraudRule, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, nil, fmt.Errorf("invalid fraud rule update: %w", err)
+	}
+
+	setClauses := []string{}
+	args := []interface{}{ruleID}
+	if req.Threshold != nil {
+		args = append(args, *req.Threshold)
+		setClauses = append(setClauses, fmt.Sprintf("threshold = $%d", len(args)))
+	}
+	if req.WindowSeconds != nil {
+		args = append(args, *req.WindowSeconds)
+		setClauses = append(setClauses, fmt.Sprintf("window_seconds = $%d", len(args)))
+	}
+	if req.Action != nil {
+		args = append(args, *req.Action)
+		setClauses = append(setClauses, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if req.Enabled != nil {
+		args = append(args, *req.Enabled)
+		setClauses = append(setClauses, fmt.Sprintf("enabled = $%d", len(args)))
+	}
+	if len(setClauses) == 0 {
+		return nil, nil, newError(KindInvalid, "no update data provided")
+	}
+
+	previous, err := scanFraudRule(s.db.QueryRow(ctx, `SELECT `+fraudRuleColumns+` FROM fraud_rules WHERE id = $1`, ruleID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, newError(KindNotFound, "fraud rule not found")
+		}
+		return nil, nil, fmt.Errorf("database error fetching fraud rule: %w", err)
+	}
+
+	query := `UPDATE fraud_rules SET ` + strings.Join(setClauses, ", ") + ` WHERE id = $1 RETURNING ` + fraudRuleColumns
+	updated, err := scanFraudRule(s.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, newError(KindNotFound, "fraud rule not found")
+		}
+		log.Printf("Error updating fraud rule %s: %v", ruleID, err)
+		return nil, nil, fmt.Errorf("database error updating fraud rule: %w", err)
+	}
+
+	s.invalidateRules()
+	log.Printf("Fraud rule '%s' updated: threshold=%v window=%ds action=%s enabled=%t", updated.Name, updated.Threshold, updated.WindowSeconds, updated.Action, updated.Enabled)
+	return previous, updated, nil
+}
+
+const fraudFlagSelect = `
+	SELECT f.id, f.event, f.user_id, u.email, f.ride_id, f.ip_address, f.action, f.rules, f.status,
+	       f.resolved_by, f.resolved_at, f.resolution_note, f.created_at
+	FROM fraud_flags f
+	LEFT JOIN users u ON u.id = f.user_id
+`
+
+func scanFraudFlag(row pgx.Row) (*models.FraudFlag, error) {
+	var flag models.FraudFlag
+	err := row.Scan(&flag.ID, &flag.Event, &flag.UserID, &flag.UserEmail, &flag.RideID, &flag.IPAddress, &flag.Action, &flag.Rules, &flag.Status,
+		&flag.ResolvedBy, &flag.ResolvedAt, &flag.ResolutionNote, &flag.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// ListFlags returns the most recent fraud flags with the given status (all statuses if empty).
+func (s *FraudService) ListFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	query := fraudFlagSelect + ` WHERE ($1 = '' OR f.status = $1) ORDER BY f.created_at DESC LIMIT 100`
+	rows, err := s.db.Query(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing fraud flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.FraudFlag{}
+	for rows.Next() {
+		flag, err := scanFraudFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning fraud flag: %w", err)
+		}
+		flags = append(flags, *flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fraud flags: %w", err)
+	}
+	return flags, nil
+}
+
+// ResolveFlag closes an open review. For held bookings, clearing releases the booking to
+// 'pending_payment' so the user can pay, and confirming drops it (nothing was charged). Bookings
+// held because their payment didn't match are charged already: clearing confirms them, and
+// confirming drops them, their payment staying under review until refunded.
+func (s *FraudService) ResolveFlag(ctx context.Context, adminID uuid.UUID, flagID uuid.UUID, req models.ResolveFraudFlagRequest) (*models.FraudFlag, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid fraud flag resolution: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var currentStatus string
+	var action models.FraudAction
+	var userID, rideID *uuid.UUID
+	var rules []string
+	lockQuery := `SELECT status, action, user_id, ride_id, rules FROM fraud_flags WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, flagID).Scan(&currentStatus, &action, &userID, &rideID, &rules); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "fraud flag not found")
+		}
+		return nil, fmt.Errorf("database error fetching fraud flag: %w", err)
+	}
+	if currentStatus != models.FraudFlagStatusOpen {
+		return nil, newError(KindConflict, "fraud flag is already resolved")
+	}
+
+	updateQuery := `UPDATE fraud_flags SET status = $2, resolved_by = $3, resolved_at = NOW(), resolution_note = $4 WHERE id = $1`
+	if _, err := tx.Exec(ctx, updateQuery, flagID, req.Status, adminID, req.Note); err != nil {
+		return nil, fmt.Errorf("database error resolving fraud flag: %w", err)
+	}
+
+	if action == models.FraudActionHold && userID != nil && rideID != nil {
+		paid := false
+		if req.Status == models.FraudFlagStatusCleared && slices.Contains(rules, models.FraudRulePaymentMismatch) {
+			reviewQuery := `UPDATE payments SET status = $3, updated_at = NOW() WHERE user_id = $1 AND ride_id = $2 AND status = $4`
+			tag, err := tx.Exec(ctx, reviewQuery, *userID, *rideID, string(models.PaymentStatusSucceeded), string(models.PaymentStatusUnderReview))
+			if err != nil {
+				return nil, fmt.Errorf("database error confirming reviewed payment: %w", err)
+			}
+			paid = tag.RowsAffected() > 0
+		}
+		var holdQuery string
+		if paid {
+			holdQuery = `UPDATE participants SET status = 'active', updated_at = NOW() WHERE user_id = $1 AND ride_id = $2 AND status = 'on_hold'`
+		} else if req.Status == models.FraudFlagStatusCleared {
+			holdQuery = `UPDATE participants SET status = 'pending_payment', updated_at = NOW() WHERE user_id = $1 AND ride_id = $2 AND status = 'on_hold'`
+		} else {
+			holdQuery = `DELETE FROM participants WHERE user_id = $1 AND ride_id = $2 AND status = 'on_hold'`
+		}
+		if _, err := tx.Exec(ctx, holdQuery, *userID, *rideID); err != nil {
+			return nil, fmt.Errorf("database error releasing held booking: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to finalize fraud flag resolution: %w", err)
+	}
+
+	log.Printf("Fraud flag %s resolved as '%s' by admin %s", flagID, req.Status, adminID)
+	return scanFraudFlag(s.db.QueryRow(ctx, fraudFlagSelect+` WHERE f.id = $1`, flagID))
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rideshare/backend/models"
+)
+
+// Test that the most restrictive action wins when several rules trigger
+func TestStrongerFraudAction(t *testing.T) {
+	tests := []struct {
+		a, b models.FraudAction
+		want models.FraudAction
+	}{
+		{models.FraudActionAllow, models.FraudActionFlag, models.FraudActionFlag},
+		{models.FraudActionHold, models.FraudActionFlag, models.FraudActionHold},
+		{models.FraudActionFlag, models.FraudActionHold, models.FraudActionHold},
+		{models.FraudActionAllow, models.FraudActionAllow, models.FraudActionAllow},
+	}
+	for _, tt := range tests {
+		if got := strongerFraudAction(tt.a, tt.b); got != tt.want {
+			t.Errorf("strongerFraudAction(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// Test the travel speed used by the geo-impossible rule
+func TestImpliedSpeedKmh(t *testing.T) {
+	// Paris -> New York (~5840 km) in one hour is impossible
+	if speed := impliedSpeedKmh(48.8566, 2.3522, 40.7128, -74.0060, time.Hour); speed < 5000 || speed > 6500 {
+		t.Errorf("impliedSpeedKmh(Paris, New York, 1h) = %.0f, want ~5840", speed)
+	}
+	// Same place: no movement regardless of elapsed time
+	if speed := impliedSpeedKmh(48.8566, 2.3522, 48.8566, 2.3522, time.Second); speed != 0 {
+		t.Errorf("impliedSpeedKmh(same point) = %.0f, want 0", speed)
+	}
+	// Elapsed time is floored at one minute: Paris -> Versailles (~17 km) in a second is ~1000 km/h, not 60000
+	if speed := impliedSpeedKmh(48.8566, 2.3522, 48.8049, 2.1204, time.Second); speed > 1200 {
+		t.Errorf("impliedSpeedKmh(Paris, Versailles, 1s) = %.0f, want <= 1200", speed)
+	}
+}
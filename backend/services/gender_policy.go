@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrGenderRequired is returned when joining or creating a gender-restricted ride without a gender on file.
+	ErrGenderRequired = newError(KindForbidden, "add your gender to your profile to use gender-restricted rides")
+	// ErrGenderRestricted is returned when the ride is reserved to passengers of another gender.
+	ErrGenderRestricted = newError(KindForbidden, "this ride is reserved to passengers of another gender")
+	// ErrPassengerGenderNotOwn is returned when a creator reserves a ride to passengers of another gender.
+	ErrPassengerGenderNotOwn = newError(KindForbidden, "rides can only be reserved to passengers of your own gender")
+)
+
+// userGender returns the gender the user declared, or ErrGenderRequired if they didn't.
+func userGender(ctx context.Context, q rowQuerier, userID uuid.UUID) (string, error) {
+	var gender *string
+	if err := q.QueryRow(ctx, `SELECT gender FROM users WHERE id = $1 AND deleted_at IS NULL`, userID).Scan(&gender); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("database error fetching gender: %w", err)
+	}
+	if gender == nil {
+		return "", ErrGenderRequired
+	}
+	return *gender, nil
+}
+
+// checkJoinGender returns an error unless the ride is open to everyone or the user declared the
+// gender the ride is reserved to.
+func checkJoinGender(ctx context.Context, q rowQuerier, userID uuid.UUID, passengerGender *string) error {
+	if passengerGender == nil {
+		return nil
+	}
+	gender, err := userGender(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if gender != *passengerGender {
+		return ErrGenderRestricted
+	}
+	return nil
+}
+
+// checkCreatorGender returns an error unless the creator reserves the ride to their own gender, so
+// women-only rides are driven by women.
+func checkCreatorGender(ctx context.Context, q rowQuerier, creatorID uuid.UUID, passengerGender *string) error {
+	if passengerGender == nil {
+		return nil
+	}
+	gender, err := userGender(ctx, q, creatorID)
+	if err != nil {
+		return err
+	}
+	if gender != *passengerGender {
+		return ErrPassengerGenderNotOwn
+	}
+	return nil
+}
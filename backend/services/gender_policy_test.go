@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+// Test that gender-restricted rides only accept passengers who declared the ride's gender
+func TestCheckJoinGender(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer mock.Close()
+
+	userID := uuid.New()
+	female, male := "female", "male"
+	query := regexp.QuoteMeta(`SELECT gender FROM users WHERE id = $1 AND deleted_at IS NULL`)
+
+	// Rides open to everyone don't look the user up
+	if err := checkJoinGender(context.Background(), mock, userID, nil); err != nil {
+		t.Errorf("checkJoinGender(open ride) = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		gender *string
+		want   error
+	}{
+		{"same gender", &female, nil},
+		{"other gender", &male, ErrGenderRestricted},
+		{"no gender declared", nil, ErrGenderRequired},
+	}
+	for _, tt := range tests {
+		mock.ExpectQuery(query).WithArgs(userID).
+			WillReturnRows(pgxmock.NewRows([]string{"gender"}).AddRow(tt.gender))
+		if err := checkJoinGender(context.Background(), mock, userID, &female); !errors.Is(err, tt.want) {
+			t.Errorf("%s: checkJoinGender() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"math"    // For cell sizes
+	"strings" // For building hashes
+)
+
+// geohashPrecision is the length of the geohashes stored on rides (~5m cells).
+// Prefixes of a stored geohash are the larger cells containing it.
+const geohashPrecision = 9
+
+// userLocationGeohashPrecision is the length of users.last_known_geohash (~5km cells). The exact
+// location is only stored encrypted, so the plaintext geohash must stay coarse.
+const userLocationGeohashPrecision = 5
+
+// geohashAlphabet is the base32 alphabet of geohashes.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// metersPerDegree is the length of one degree of latitude (and of longitude at the equator).
+const metersPerDegree = 111320.0
+
+// EncodeGeohash returns the geohash of a point with the given number of characters.
+func EncodeGeohash(latitude, longitude float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var hash strings.Builder
+	bit, ch, evenBit := 0, 0, true
+	for hash.Len() < precision {
+		// Bits alternate between longitude and latitude, longitude first
+		rng, value := &latRange, latitude
+		if evenBit {
+			rng, value = &lonRange, longitude
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if value >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		evenBit = !evenBit
+		if bit++; bit == 5 {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// geohashCellDegrees returns the height and width in degrees of a geohash cell of the given length.
+func geohashCellDegrees(precision int) (float64, float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
+
+// GeohashProximityPrefixes returns the geohash prefixes whose cells cover every point within
+// radiusMeters of the given point: the cell containing it and its 8 neighbours, at the longest
+// length whose cells are still at least radiusMeters across. A row is nearby when its stored
+// geohash starts with one of them, which a text_pattern_ops index answers without PostGIS.
+func GeohashProximityPrefixes(latitude, longitude float64, radiusMeters float64) []string {
+	precision := 1
+	for p := geohashPrecision; p >= 1; p-- {
+		height, width := geohashCellDegrees(p)
+		widthMeters := width * metersPerDegree * math.Cos(latitude*math.Pi/180)
+		if math.Min(height*metersPerDegree, widthMeters) >= radiusMeters {
+			precision = p
+			break
+		}
+	}
+
+	height, width := geohashCellDegrees(precision)
+	seen := map[string]bool{}
+	prefixes := []string{}
+	for _, dLat := range []float64{-1, 0, 1} {
+		for _, dLon := range []float64{-1, 0, 1} {
+			lat := math.Max(-90, math.Min(90, latitude+dLat*height))
+			lon := longitude + dLon*width
+			if lon < -180 {
+				lon += 360 // Wrap around the antimeridian
+			} else if lon >= 180 {
+				lon -= 360
+			}
+			hash := EncodeGeohash(lat, lon, precision)
+			if !seen[hash] {
+				seen[hash] = true
+				prefixes = append(prefixes, hash)
+			}
+		}
+	}
+	return prefixes
+}
+
+// geohashLikePatterns turns geohash prefixes into LIKE patterns (geohashes never contain % or _).
+func geohashLikePatterns(prefixes []string) []string {
+	patterns := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		patterns[i] = prefix + "%"
+	}
+	return patterns
+}
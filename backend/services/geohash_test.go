@@ -0,0 +1,43 @@
+package services
+
+import "testing"
+
+// Test geohash encoding against known values
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"}, // Reference example from geohash.org
+		{42.6, -5.6, 5, "ezs42"},                // Example from the geohash Wikipedia article
+	}
+	for _, tt := range tests {
+		if got := EncodeGeohash(tt.lat, tt.lon, tt.precision); got != tt.want {
+			t.Errorf("EncodeGeohash(%v, %v, %d) = %s, want %s", tt.lat, tt.lon, tt.precision, got, tt.want)
+		}
+	}
+}
+
+// Test that proximity prefixes cover nearby points and exclude far ones
+func TestGeohashProximityPrefixes(t *testing.T) {
+	prefixes := GeohashProximityPrefixes(48.8566, 2.3522, 10000) // Paris, 10 km
+	matches := func(lat, lon float64) bool {
+		hash := EncodeGeohash(lat, lon, geohashPrecision)
+		for _, prefix := range prefixes {
+			if len(hash) >= len(prefix) && hash[:len(prefix)] == prefix {
+				return true
+			}
+		}
+		return false
+	}
+	if len(prefixes) == 0 || len(prefixes) > 9 {
+		t.Fatalf("got %d prefixes, want 1-9", len(prefixes))
+	}
+	if !matches(48.8584, 2.2945) { // Eiffel Tower, ~4 km
+		t.Error("point 4 km away not covered")
+	}
+	if matches(45.7640, 4.8357) { // Lyon, ~390 km
+		t.Error("point 390 km away covered")
+	}
+}
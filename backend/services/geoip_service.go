@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"       // For request context
+	"encoding/json" // For decoding provider responses
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"net"           // For IP parsing
+	"net/http"      // For provider HTTP calls
+	"strconv"       // For parsing coordinates
+	"strings"       // For string handling
+	"sync"          // For the lookup cache
+	"time"          // For cache expiry
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// GeoIPProvider resolves an IP address to a location. Implementations: IPinfo and MaxMind web services.
+type GeoIPProvider interface {
+	Lookup(ctx context.Context, ip string) (*models.GeoLocation, error)
+}
+
+// geoCacheEntry is a cached lookup; a nil location caches a failed lookup too.
+type geoCacheEntry struct {
+	location  *models.GeoLocation
+	expiresAt time.Time
+}
+
+// GeoIPService resolves request IPs with the configured provider and caches results in memory.
+type GeoIPService struct {
+	cfg      *config.Config
+	provider GeoIPProvider // nil when geolocation is disabled
+
+	mu    sync.Mutex
+	cache map[string]geoCacheEntry
+}
+
+// NewGeoIPService creates a GeoIPService for cfg.GeoIPProvider ("ipinfo" or "maxmind").
+// With no provider configured, Resolve always returns nil.
+func NewGeoIPService(cfg *config.Config) *GeoIPService {
+	httpClient := &http.Client{Timeout: 2 * time.Second} // Lookups are on the request path
+	var provider GeoIPProvider
+	switch strings.ToLower(cfg.GeoIPProvider) {
+	case "ipinfo":
+		provider = &ipinfoProvider{token: cfg.GeoIPToken, httpClient: httpClient}
+	case "maxmind":
+		provider = &maxMindProvider{accountID: cfg.MaxMindAccountID, licenseKey: cfg.MaxMindLicenseKey, httpClient: httpClient}
+	case "":
+		log.Println("IP geolocation disabled (GEOIP_PROVIDER not set)")
+	default:
+		log.Printf("Warning: Unknown GEOIP_PROVIDER '%s', IP geolocation disabled", cfg.GeoIPProvider)
+	}
+	return NewGeoIPServiceWithProvider(cfg, provider)
+}
+
+// NewGeoIPServiceWithProvider creates a GeoIPService with a custom provider.
+func NewGeoIPServiceWithProvider(cfg *config.Config, provider GeoIPProvider) *GeoIPService {
+	return &GeoIPService{
+		cfg:      cfg,
+		provider: provider,
+		cache:    make(map[string]geoCacheEntry),
+	}
+}
+
+// Resolve returns the location of an IP address, or nil if it can't be resolved
+// (geolocation disabled, private address or provider error).
+func (s *GeoIPService) Resolve(ctx context.Context, ip string) *models.GeoLocation {
+	if s.provider == nil {
+		return nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsUnspecified() || parsed.IsLinkLocalUnicast() {
+		return nil
+	}
+
+	s.mu.Lock()
+	entry, ok := s.cache[ip]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.location
+	}
+
+	location, err := s.provider.Lookup(ctx, ip)
+	if err != nil {
+		log.Printf("GeoIP Warning: Lookup failed for %s: %v", ip, err)
+		location = nil
+	}
+
+	s.mu.Lock()
+	s.cache[ip] = geoCacheEntry{location: location, expiresAt: time.Now().Add(s.cfg.GeoIPCacheTTL)}
+	if len(s.cache) > 50000 {
+		s.evictExpiredLocked()
+	}
+	s.mu.Unlock()
+	return location
+}
+
+// evictExpiredLocked drops expired cache entries, or everything if none expired. Caller holds s.mu.
+func (s *GeoIPService) evictExpiredLocked() {
+	now := time.Now()
+	evicted := 0
+	for ip, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, ip)
+			evicted++
+		}
+	}
+	if evicted == 0 {
+		s.cache = make(map[string]geoCacheEntry)
+	}
+}
+
+// countryCurrencies maps countries to their currency where it isn't the platform default (EUR).
+var countryCurrencies = map[string]string{
+	"GB": "gbp", "CH": "chf", "US": "usd", "CA": "cad", "MA": "mad", "TN": "tnd", "DZ": "dzd",
+	"SE": "sek", "DK": "dkk", "NO": "nok", "PL": "pln", "CZ": "czk", "HU": "huf", "RO": "ron",
+	"SN": "xof", "CI": "xof", "ML": "xof", "BF": "xof", "BJ": "xof", "TG": "xof", "NE": "xof",
+	"CM": "xaf", "GA": "xaf", "CG": "xaf", "TD": "xaf", "CF": "xaf", "GQ": "xaf",
+}
+
+// frenchSpeakingCountries are countries whose users default to the French locale.
+var frenchSpeakingCountries = map[string]bool{
+	"FR": true, "BE": true, "LU": true, "MC": true, "CH": true, "MA": true, "TN": true, "DZ": true,
+	"SN": true, "CI": true, "ML": true, "BF": true, "BJ": true, "TG": true, "NE": true,
+	"CM": true, "GA": true, "CG": true, "CD": true, "TD": true, "CF": true, "MG": true, "HT": true,
+}
+
+// CurrencyForCountry returns the default display currency for a country (lowercase ISO 4217).
+func CurrencyForCountry(countryCode string) string {
+	if currency, ok := countryCurrencies[strings.ToUpper(countryCode)]; ok {
+		return currency
+	}
+	return paymentCurrency
+}
+
+// LocaleForCountry returns the default locale for a country, or fallback if it has no specific one.
+func LocaleForCountry(countryCode string, fallback string) string {
+	if frenchSpeakingCountries[strings.ToUpper(countryCode)] {
+		return "fr"
+	}
+	return fallback
+}
+
+// ContextFor returns the defaults clients should use for a caller at the given location (nil if unknown).
+func (s *GeoIPService) ContextFor(geo *models.GeoLocation) *models.GeoContextResponse {
+	if geo == nil {
+		return &models.GeoContextResponse{Currency: paymentCurrency, Locale: s.cfg.DefaultLocale}
+	}
+	return &models.GeoContextResponse{
+		CountryCode: geo.CountryCode,
+		Region:      geo.Region,
+		City:        geo.City,
+		Currency:    CurrencyForCountry(geo.CountryCode),
+		Locale:      LocaleForCountry(geo.CountryCode, s.cfg.DefaultLocale),
+	}
+}
+
+// ipinfoProvider uses the IPinfo API (https://ipinfo.io).
+type ipinfoProvider struct {
+	token      string
+	httpClient *http.Client
+}
+
+func (p *ipinfoProvider) Lookup(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://ipinfo.io/"+ip+"/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ipinfo returned HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Country string `json:"country"`
+		Region  string `json:"region"`
+		City    string `json:"city"`
+		Loc     string `json:"loc"` // "lat,lon"
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode ipinfo response: %w", err)
+	}
+	if result.Country == "" {
+		return nil, nil // e.g. bogon or anycast address
+	}
+
+	location := &models.GeoLocation{IP: ip, CountryCode: result.Country, Region: result.Region, City: result.City}
+	if lat, lon, ok := strings.Cut(result.Loc, ","); ok {
+		latitude, latErr := strconv.ParseFloat(lat, 64)
+		longitude, lonErr := strconv.ParseFloat(lon, 64)
+		if latErr == nil && lonErr == nil {
+			location.Latitude, location.Longitude = &latitude, &longitude
+		}
+	}
+	return location, nil
+}
+
+// maxMindProvider uses the MaxMind GeoIP2 City web service.
+type maxMindProvider struct {
+	accountID  string
+	licenseKey string
+	httpClient *http.Client
+}
+
+func (p *maxMindProvider) Lookup(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://geoip.maxmind.com/geoip/v2.1/city/"+ip, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(p.accountID, p.licenseKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil // IP not in the database
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("maxmind returned HTTP %d", resp.StatusCode)
+	}
+
+	type names struct {
+		Names map[string]string `json:"names"`
+	}
+	var result struct {
+		Country struct {
+			IsoCode string `json:"iso_code"`
+		} `json:"country"`
+		Subdivisions []names `json:"subdivisions"`
+		City         names   `json:"city"`
+		Location     struct {
+			Latitude  *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+		} `json:"location"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode maxmind response: %w", err)
+	}
+	if result.Country.IsoCode == "" {
+		return nil, nil
+	}
+
+	location := &models.GeoLocation{
+		IP:          ip,
+		CountryCode: result.Country.IsoCode,
+		City:        result.City.Names["en"],
+		Latitude:    result.Location.Latitude,
+		Longitude:   result.Location.Longitude,
+	}
+	if len(result.Subdivisions) > 0 {
+		location.Region = result.Subdivisions[0].Names["en"]
+	}
+	return location, nil
+}
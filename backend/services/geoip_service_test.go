@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// countingGeoProvider returns a fixed location and counts lookups
+type countingGeoProvider struct {
+	lookups int
+}
+
+func (p *countingGeoProvider) Lookup(ctx context.Context, ip string) (*models.GeoLocation, error) {
+	p.lookups++
+	return &models.GeoLocation{IP: ip, CountryCode: "FR"}, nil
+}
+
+// Test that lookups are cached and private addresses are never sent to the provider
+func TestGeoIPService_Resolve(t *testing.T) {
+	provider := &countingGeoProvider{}
+	service := NewGeoIPServiceWithProvider(&config.Config{GeoIPCacheTTL: time.Hour}, provider)
+
+	for i := 0; i < 3; i++ {
+		if geo := service.Resolve(context.Background(), "81.2.69.160"); geo == nil || geo.CountryCode != "FR" {
+			t.Fatalf("Resolve() = %+v, want country FR", geo)
+		}
+	}
+	if provider.lookups != 1 {
+		t.Errorf("provider lookups = %d, want 1 (cached)", provider.lookups)
+	}
+
+	for _, ip := range []string{"127.0.0.1", "10.0.0.5", "192.168.1.1", "::1", "not-an-ip"} {
+		if geo := service.Resolve(context.Background(), ip); geo != nil {
+			t.Errorf("Resolve(%s) = %+v, want nil", ip, geo)
+		}
+	}
+	if provider.lookups != 1 {
+		t.Errorf("provider lookups = %d after private IPs, want 1", provider.lookups)
+	}
+}
+
+// Test the currency and locale defaults derived from the country
+func TestCountryDefaults(t *testing.T) {
+	tests := []struct {
+		country, currency, locale string
+	}{
+		{"FR", "eur", "fr"},
+		{"ch", "chf", "fr"},
+		{"GB", "gbp", "en"},
+		{"DE", "eur", "en"},
+		{"", "eur", "en"},
+	}
+	for _, tt := range tests {
+		if got := CurrencyForCountry(tt.country); got != tt.currency {
+			t.Errorf("CurrencyForCountry(%q) = %s, want %s", tt.country, got, tt.currency)
+		}
+		if got := LocaleForCountry(tt.country, "en"); got != tt.locale {
+			t.Errorf("LocaleForCountry(%q) = %s, want %s", tt.country, got, tt.locale)
+		}
+	}
+}
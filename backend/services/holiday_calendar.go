@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"       // For database and API calls
+	"encoding/json" // For the holiday API
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"net/http"      // For the holiday API
+	"strings"       // For API URLs
+	"sync"          // For the in-memory calendar
+	"time"          // For dates and the refresh interval
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// HolidayCalendar knows the nationwide public holidays of HOLIDAY_COUNTRIES. Holidays are fetched
+// from a Nager.Date compatible API by a scheduled job, persisted in the public_holidays table and
+// held in memory, so flagging peak travel days costs no query. A nil *HolidayCalendar has no peak days.
+type HolidayCalendar struct {
+	db         database.DBPool
+	apiURL     string
+	countries  []string
+	interval   time.Duration // 0 disables refreshing (persisted holidays are still used)
+	httpClient *http.Client
+
+	mu       sync.RWMutex
+	holidays map[string]string // Holiday name by date (YYYY-MM-DD); the first configured country names shared dates
+}
+
+// NewHolidayCalendar creates a HolidayCalendar from cfg. Without HOLIDAY_COUNTRIES, it returns nil.
+func NewHolidayCalendar(cfg *config.Config, db database.DBPool) *HolidayCalendar {
+	if len(cfg.HolidayCountries) == 0 {
+		log.Println("Holiday calendar disabled (HOLIDAY_COUNTRIES is empty)")
+		return nil
+	}
+	return &HolidayCalendar{
+		db:         db,
+		apiURL:     strings.TrimSuffix(cfg.HolidayAPIURL, "/"),
+		countries:  cfg.HolidayCountries,
+		interval:   cfg.HolidayRefreshInterval,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		holidays:   make(map[string]string),
+	}
+}
+
+// PeakDay reports whether date is a peak travel day: a public holiday, or the day before one when
+// people leave for the long weekend. It returns the holiday's name.
+func (h *HolidayCalendar) PeakDay(date time.Time) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if name, ok := h.holidays[date.Format("2006-01-02")]; ok {
+		return name, true
+	}
+	name, ok := h.holidays[date.AddDate(0, 0, 1).Format("2006-01-02")]
+	return name, ok
+}
+
+// Flag marks the ride if it departs on a peak travel day, and reports whether it does.
+func (h *HolidayCalendar) Flag(ride *models.Ride) bool {
+	name, peak := h.PeakDay(ride.DepartureDate)
+	if peak {
+		ride.PeakDay = true
+		ride.PeakHoliday = &name
+	}
+	return peak
+}
+
+// Start loads the persisted holidays, then refreshes the calendar in the background.
+func (h *HolidayCalendar) Start() {
+	if h == nil {
+		return
+	}
+	if err := h.load(context.Background()); err != nil {
+		log.Printf("Warning: Could not load holiday calendar: %v", err)
+	}
+	if h.interval <= 0 {
+		log.Println("Holiday calendar refresh disabled (HOLIDAY_REFRESH_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			h.refresh(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+// load replaces the in-memory calendar with the upcoming holidays of the public_holidays table.
+func (h *HolidayCalendar) load(ctx context.Context) error {
+	query := `
+		SELECT date, name FROM public_holidays
+		WHERE country_code = ANY($1) AND date >= current_date
+		ORDER BY array_position($1, country_code)
+	`
+	rows, err := h.db.Query(ctx, query, h.countries)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	holidays := make(map[string]string)
+	for rows.Next() {
+		var date time.Time
+		var name string
+		if err := rows.Scan(&date, &name); err != nil {
+			return err
+		}
+		if key := date.Format("2006-01-02"); holidays[key] == "" {
+			holidays[key] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.holidays = holidays
+	h.mu.Unlock()
+	log.Printf("Holiday calendar loaded with %d upcoming holidays", len(holidays))
+	return nil
+}
+
+// refresh fetches this year's and next year's holidays of every country, stores them and reloads
+// the calendar. A failing country keeps its stored holidays until next time.
+func (h *HolidayCalendar) refresh(ctx context.Context) {
+	year := time.Now().Year()
+	for _, country := range h.countries {
+		for _, y := range []int{year, year + 1} {
+			holidays, err := h.fetch(ctx, country, y)
+			if err != nil {
+				log.Printf("Warning: Could not fetch %d public holidays of %s: %v", y, country, err)
+				continue
+			}
+			if err := h.store(ctx, country, y, holidays); err != nil {
+				log.Printf("Warning: Could not store %d public holidays of %s: %v", y, country, err)
+			}
+		}
+	}
+	if err := h.load(ctx); err != nil {
+		log.Printf("Warning: Could not reload holiday calendar: %v", err)
+	}
+}
+
+// nagerHoliday is one entry of the Nager.Date PublicHolidays response.
+type nagerHoliday struct {
+	Date      string `json:"date"` // YYYY-MM-DD
+	LocalName string `json:"localName"`
+	Global    bool   `json:"global"` // False for regional holidays (e.g. Alsace-Moselle)
+}
+
+// fetch returns the nationwide holidays of a country in a year: their local name by date (YYYY-MM-DD).
+func (h *HolidayCalendar) fetch(ctx context.Context, country string, year int) (map[string]string, error) {
+	url := fmt.Sprintf("%s/PublicHolidays/%d/%s", h.apiURL, year, country)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("holiday request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("holiday API returned HTTP %d", resp.StatusCode)
+	}
+	var entries []nagerHoliday
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid holiday response: %w", err)
+	}
+	holidays := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if _, err := time.Parse("2006-01-02", entry.Date); err != nil || !entry.Global {
+			continue
+		}
+		holidays[entry.Date] = entry.LocalName
+	}
+	return holidays, nil
+}
+
+// store replaces the stored holidays of a country in a year.
+func (h *HolidayCalendar) store(ctx context.Context, country string, year int, holidays map[string]string) error {
+	dates := make([]string, 0, len(holidays))
+	names := make([]string, 0, len(holidays))
+	for date, name := range holidays {
+		dates = append(dates, date)
+		names = append(names, name)
+	}
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, `
+		DELETE FROM public_holidays
+		WHERE country_code = $1 AND date_part('year', date) = $2 AND NOT (date = ANY($3::date[]))
+	`, country, year, dates)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO public_holidays (country_code, date, name, refreshed_at)
+		SELECT $1, d, n, NOW() FROM unnest($2::date[], $3::text[]) AS h(d, n)
+		ON CONFLICT (country_code, date) DO UPDATE SET name = EXCLUDED.name, refreshed_at = NOW()
+	`, country, dates, names)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that public holidays and the day before them are peak travel days
+func TestHolidayCalendar_PeakDay(t *testing.T) {
+	calendar := NewHolidayCalendar(&config.Config{HolidayCountries: []string{"FR"}}, nil)
+	calendar.holidays = map[string]string{"2026-07-14": "Fête nationale"}
+
+	for date, want := range map[string]bool{"2026-07-13": true, "2026-07-14": true, "2026-07-15": false, "2026-07-12": false} {
+		day, _ := time.Parse("2006-01-02", date)
+		if name, peak := calendar.PeakDay(day); peak != want || (peak && name != "Fête nationale") {
+			t.Errorf("PeakDay(%s) = %q, %v, want %v", date, name, peak, want)
+		}
+	}
+
+	ride := models.Ride{DepartureDate: time.Date(2026, 7, 13, 0, 0, 0, 0, time.UTC)}
+	if !calendar.Flag(&ride) || !ride.PeakDay || ride.PeakHoliday == nil {
+		t.Errorf("ride departing the day before a holiday was not flagged: %+v", ride)
+	}
+	if _, peak := (*HolidayCalendar)(nil).PeakDay(ride.DepartureDate); peak {
+		t.Error("a disabled calendar has no peak days")
+	}
+}
+
+// Test that only nationwide holidays are kept from the holiday API
+func TestHolidayCalendar_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/PublicHolidays/2026/FR" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[
+			{"date": "2026-05-01", "localName": "Fête du Travail", "global": true},
+			{"date": "2026-12-26", "localName": "Saint-Étienne", "global": false}
+		]`))
+	}))
+	defer server.Close()
+	calendar := NewHolidayCalendar(&config.Config{HolidayCountries: []string{"FR"}, HolidayAPIURL: server.URL + "/"}, nil)
+
+	holidays, err := calendar.fetch(context.Background(), "FR", 2026)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(holidays) != 1 || holidays["2026-05-01"] != "Fête du Travail" {
+		t.Errorf("holidays = %v, want only the nationwide one", holidays)
+	}
+	if _, err := calendar.fetch(context.Background(), "XX", 2026); err == nil {
+		t.Error("fetch of an unknown country should fail")
+	}
+}
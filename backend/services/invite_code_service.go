@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"     // For database calls
+	"crypto/rand" // For generating codes
+	"errors"      // For pgx error checks
+	"fmt"         // For error formatting
+	"log"         // For logging
+	"strings"     // For normalizing codes
+	"time"        // For expiry checks
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+const (
+	inviteCodeLength   = 8
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O or 1/I, since codes are typed in; 32 letters keep byte%32 unbiased
+)
+
+var (
+	// ErrInviteCodeRequired is returned by SignUp without a code while signups are invite-only.
+	ErrInviteCodeRequired = newError(KindForbidden, "an invite code is required to sign up")
+	// ErrInvalidInviteCode is returned by SignUp when the code doesn't exist, was revoked, expired or is used up.
+	ErrInvalidInviteCode = newError(KindForbidden, "invite code is invalid, expired or already used")
+	// ErrInviteCodeNotFound is returned when revoking a code that doesn't exist or was already revoked.
+	ErrInviteCodeNotFound = newError(KindNotFound, "invite code not found or already revoked")
+)
+
+const inviteCodeColumns = `id, code, cohort, max_uses, uses, expires_at, created_by, created_at, revoked_at`
+
+// InviteCodeService mints and revokes the invite codes gating signups during soft launches
+// (SIGNUP_MODE=invite_only). Codes are redeemed by AuthService.SignUp.
+type InviteCodeService struct {
+	db        database.DBPool
+	validator *validator.Validate
+	audit     *AuditService
+}
+
+// NewInviteCodeService creates a new InviteCodeService instance.
+func NewInviteCodeService(db database.DBPool, audit *AuditService) *InviteCodeService {
+	return &InviteCodeService{
+		db:        db,
+		validator: NewValidator(),
+		audit:     audit,
+	}
+}
+
+// normalizeInviteCode uppercases a code as typed by a user.
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// generateInviteCode returns a random code of inviteCodeLength letters of inviteCodeAlphabet.
+func generateInviteCode() (string, error) {
+	random := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, inviteCodeLength)
+	for i, b := range random {
+		code[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// Mint creates req.Count codes for a cohort, each usable req.MaxUses times.
+func (s *InviteCodeService) Mint(ctx context.Context, adminID uuid.UUID, req models.MintInviteCodesRequest, ip string) ([]models.InviteCode, error) {
+	req.Cohort = strings.TrimSpace(req.Cohort)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid invite codes request: %w", err)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, newError(KindInvalid, "expires_at must be in the future")
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	codes := make([]string, req.Count)
+	for i := range codes {
+		code, err := generateInviteCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate invite code: %w", err)
+		}
+		codes[i] = code
+	}
+
+	// A code colliding with an existing one is skipped rather than failing the batch
+	query := `
+		INSERT INTO invite_codes (code, cohort, max_uses, expires_at, created_by)
+		SELECT code, $2, $3, $4, $5 FROM unnest($1::text[]) AS code
+		ON CONFLICT (code) DO NOTHING
+		RETURNING ` + inviteCodeColumns
+	rows, err := s.db.Query(ctx, query, codes, req.Cohort, req.MaxUses, req.ExpiresAt, adminID)
+	if err != nil {
+		log.Printf("Error minting invite codes for cohort %q: %v", req.Cohort, err)
+		return nil, fmt.Errorf("database error minting invite codes: %w", err)
+	}
+	minted, err := collectInviteCodes(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionInviteCodesMinted,
+		TargetType: "invite_code",
+		TargetID:   req.Cohort,
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"cohort": req.Cohort, "count": len(minted), "max_uses": req.MaxUses},
+	})
+	log.Printf("Admin %s minted %d invite codes for cohort %q", adminID, len(minted), req.Cohort)
+	return minted, nil
+}
+
+// List returns invite codes, newest first, optionally of one cohort.
+func (s *InviteCodeService) List(ctx context.Context, params models.ListInviteCodesRequest) ([]models.InviteCode, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid invite codes query: %w", err)
+	}
+	limit, offset := 100, 0
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if params.Page != nil && *params.Page > 1 {
+		offset = (*params.Page - 1) * limit
+	}
+	query := `
+		SELECT ` + inviteCodeColumns + ` FROM invite_codes
+		WHERE ($1::text IS NULL OR cohort = $1)
+		ORDER BY created_at DESC, code
+		LIMIT $2 OFFSET $3`
+	rows, err := s.db.Query(ctx, query, params.Cohort, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing invite codes: %w", err)
+	}
+	return collectInviteCodes(rows)
+}
+
+// Revoke stops a code from being redeemed. Accounts created with it are unaffected.
+func (s *InviteCodeService) Revoke(ctx context.Context, adminID, codeID uuid.UUID, ip string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE invite_codes SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, codeID)
+	if err != nil {
+		log.Printf("Error revoking invite code %s: %v", codeID, err)
+		return fmt.Errorf("database error revoking invite code: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInviteCodeNotFound
+	}
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionInviteCodeRevoked,
+		TargetType: "invite_code",
+		TargetID:   codeID.String(),
+		IPAddress:  ip,
+	})
+	log.Printf("Invite code %s revoked by admin %s", codeID, adminID)
+	return nil
+}
+
+// redeemInviteCode uses up one use of a valid code and returns its ID. The use is taken in one
+// statement, so concurrent signups can't exceed max_uses; callers redeem within the transaction
+// creating the account, so a failed signup doesn't consume the use.
+func redeemInviteCode(ctx context.Context, q rowQuerier, code string) (uuid.UUID, error) {
+	var codeID uuid.UUID
+	query := `
+		UPDATE invite_codes SET uses = uses + 1
+		WHERE code = $1 AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id`
+	if err := q.QueryRow(ctx, query, normalizeInviteCode(code)).Scan(&codeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrInvalidInviteCode
+		}
+		return uuid.Nil, fmt.Errorf("database error redeeming invite code: %w", err)
+	}
+	return codeID, nil
+}
+
+func collectInviteCodes(rows pgx.Rows) ([]models.InviteCode, error) {
+	defer rows.Close()
+	codes := []models.InviteCode{}
+	for rows.Next() {
+		var code models.InviteCode
+		if err := rows.Scan(&code.ID, &code.Code, &code.Cohort, &code.MaxUses, &code.Uses, &code.ExpiresAt, &code.CreatedBy, &code.CreatedAt, &code.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error processing invite code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for invite codes: %w", err)
+	}
+	return codes, nil
+}
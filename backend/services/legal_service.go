@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// legalDocumentsCacheTTL is how long the current document versions are cached. Every authenticated
+// request checks them, and a new version only needs to take effect within a minute.
+const legalDocumentsCacheTTL = time.Minute
+
+// LegalService publishes the terms of service and privacy policy and tracks which versions each
+// user accepted. The latest published version of each kind is current; when it is mandatory,
+// users who haven't accepted it are blocked (451) until they do.
+type LegalService struct {
+	db        database.DBPool
+	validator *validator.Validate
+
+	mu       sync.RWMutex
+	current  []models.LegalDocument
+	loadedAt time.Time
+}
+
+// NewLegalService creates a new LegalService instance.
+func NewLegalService(db database.DBPool) *LegalService {
+	return &LegalService{
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// CurrentDocuments returns the latest published version of each document kind.
+// If reloading fails the previously loaded versions are kept.
+func (s *LegalService) CurrentDocuments(ctx context.Context) ([]models.LegalDocument, error) {
+	s.mu.RLock()
+	current, loadedAt := s.current, s.loadedAt
+	s.mu.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < legalDocumentsCacheTTL {
+		return current, nil
+	}
+
+	documents, err := s.loadCurrentDocuments(ctx)
+	if err != nil {
+		if !loadedAt.IsZero() {
+			log.Printf("Legal Warning: Failed reloading current documents, keeping cached versions: %v", err)
+			return current, nil
+		}
+		return nil, err
+	}
+	s.mu.Lock()
+	s.current = documents
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return documents, nil
+}
+
+func (s *LegalService) loadCurrentDocuments(ctx context.Context) ([]models.LegalDocument, error) {
+	query := `
+		SELECT DISTINCT ON (kind) id, kind, version, title, content, mandatory, published_at
+		FROM legal_documents
+		WHERE published_at <= NOW()
+		ORDER BY kind, published_at DESC
+	`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching legal documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []models.LegalDocument{}
+	for rows.Next() {
+		var document models.LegalDocument
+		if err := rows.Scan(&document.ID, &document.Kind, &document.Version, &document.Title, &document.Content, &document.Mandatory, &document.PublishedAt); err != nil {
+			return nil, fmt.Errorf("error processing legal document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for legal documents: %w", err)
+	}
+	return documents, nil
+}
+
+// PendingDocuments returns the current mandatory documents the user has not accepted yet, without their content.
+func (s *LegalService) PendingDocuments(ctx context.Context, userID uuid.UUID) ([]models.LegalDocument, error) {
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var mandatoryIDs []uuid.UUID
+	for _, document := range current {
+		if document.Mandatory {
+			mandatoryIDs = append(mandatoryIDs, document.ID)
+		}
+	}
+	if len(mandatoryIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT document_id FROM user_document_acceptances WHERE user_id = $1 AND document_id = ANY($2)`, userID, mandatoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching document acceptances: %w", err)
+	}
+	defer rows.Close()
+	accepted := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var documentID uuid.UUID
+		if err := rows.Scan(&documentID); err != nil {
+			return nil, fmt.Errorf("error processing document acceptance: %w", err)
+		}
+		accepted[documentID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for document acceptances: %w", err)
+	}
+
+	var pending []models.LegalDocument
+	for _, document := range current {
+		if document.Mandatory && !accepted[document.ID] {
+			document.Content = ""
+			pending = append(pending, document)
+		}
+	}
+	return pending, nil
+}
+
+// Accept records that the user accepted the given document versions. Only current versions can be accepted.
+func (s *LegalService) Accept(ctx context.Context, userID uuid.UUID, req models.AcceptLegalDocumentsRequest, ip string) ([]models.LegalAcceptance, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid acceptance: %w", err)
+	}
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.LegalDocument, len(current))
+	for _, document := range current {
+		byID[document.ID] = document
+	}
+
+	var documents []models.LegalDocument
+	for _, documentID := range req.DocumentIDs {
+		document, ok := byID[documentID]
+		if !ok {
+			return nil, newError(KindInvalid, fmt.Sprintf("document %s is not a current version", documentID))
+		}
+		documents = append(documents, document)
+	}
+	return s.record(ctx, userID, documents, ip)
+}
+
+// AcceptCurrent records that the user accepted every current document, e.g. by signing up.
+func (s *LegalService) AcceptCurrent(ctx context.Context, userID uuid.UUID, ip string) ([]models.LegalAcceptance, error) {
+	current, err := s.CurrentDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(ctx, userID, current, ip)
+}
+
+// record stores acceptances; accepting a version again keeps the first acceptance.
+func (s *LegalService) record(ctx context.Context, userID uuid.UUID, documents []models.LegalDocument, ip string) ([]models.LegalAcceptance, error) {
+	acceptances := []models.LegalAcceptance{}
+	for _, document := range documents {
+		acceptance := models.LegalAcceptance{DocumentID: document.ID, Kind: document.Kind, Version: document.Version}
+		query := `
+			INSERT INTO user_document_acceptances (user_id, document_id, ip_address)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, document_id) DO UPDATE SET user_id = EXCLUDED.user_id
+			RETURNING accepted_at
+		`
+		if err := s.db.QueryRow(ctx, query, userID, document.ID, ip).Scan(&acceptance.AcceptedAt); err != nil {
+			log.Printf("Error recording acceptance of %s %s by user %s: %v", document.Kind, document.Version, userID, err)
+			return nil, fmt.Errorf("database error recording acceptance: %w", err)
+		}
+		acceptances = append(acceptances, acceptance)
+	}
+	if len(acceptances) > 0 {
+		log.Printf("User %s accepted %d legal document(s)", userID, len(acceptances))
+	}
+	return acceptances, nil
+}
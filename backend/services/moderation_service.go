@@ -0,0 +1,350 @@
+package services
+
+import (
+	"bytes"         // For the moderation API request body
+	"context"       // For request context
+	"encoding/json" // For the moderation API payloads
+	"errors"        // For error checks
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"net/http"      // For moderation API calls
+	"regexp"        // For contact details and links
+	"sort"          // For stable category order
+	"strings"       // For text normalization
+	"time"          // For the API timeout
+	"unicode"       // For word splitting
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// moderationAPITimeout bounds one moderation API call; moderation is on the request path.
+const moderationAPITimeout = 2 * time.Second
+
+// ModerationProvider is an external moderation API. It returns the categories the text was flagged for
+// (none if it is acceptable).
+type ModerationProvider interface {
+	Moderate(ctx context.Context, text string) ([]string, error)
+}
+
+// ModerationService screens user-written text (chat, comments, notes shown to other users).
+// A local filter catches abusive words and contact details (phone numbers, emails, messaging
+// links: harvesting them is the usual spam pattern) and links; an optional external API can flag
+// more. Callers decide what to do with the decision: refuse blocked text, or accept it and record a
+// flag (RecordFlag) in the same transaction, which queues it in 'moderation_flags' for admin review.
+type ModerationService struct {
+	db           database.DBPool
+	validator    *validator.Validate
+	provider     ModerationProvider // nil when no moderation API is configured
+	blockedWords map[string]bool    // Normalized single words
+	blockedTerms []string           // Normalized multi-word terms
+}
+
+// NewModerationService creates a ModerationService using the moderation API at cfg.ModerationAPIURL, if set.
+func NewModerationService(cfg *config.Config, db database.DBPool) *ModerationService {
+	var provider ModerationProvider
+	if cfg.ModerationAPIURL != "" {
+		provider = &httpModerationProvider{
+			url:        cfg.ModerationAPIURL,
+			apiKey:     cfg.ModerationAPIKey,
+			httpClient: &http.Client{Timeout: moderationAPITimeout},
+		}
+	} else {
+		log.Println("External moderation disabled (MODERATION_API_URL not set), using the local filter only")
+	}
+	return NewModerationServiceWithProvider(cfg, db, provider)
+}
+
+// NewModerationServiceWithProvider creates a ModerationService with a custom provider (nil for none).
+func NewModerationServiceWithProvider(cfg *config.Config, db database.DBPool, provider ModerationProvider) *ModerationService {
+	s := &ModerationService{
+		db:           db,
+		validator:    NewValidator(),
+		provider:     provider,
+		blockedWords: map[string]bool{},
+	}
+	for _, word := range cfg.ModerationBlockedWords {
+		normalized := strings.Join(moderationWords(word), " ")
+		switch {
+		case normalized == "":
+		case strings.Contains(normalized, " "):
+			s.blockedTerms = append(s.blockedTerms, normalized)
+		default:
+			s.blockedWords[normalized] = true
+		}
+	}
+	return s
+}
+
+var (
+	// Phone-like runs: digits with the usual separators. Runs of 9+ digits are treated as phone numbers,
+	// once dates and times (which look alike when written next to each other) are taken out.
+	moderationPhonePattern    = regexp.MustCompile(`\+?\d[\d\s().\-]{6,}\d`)
+	moderationDateTimePattern = regexp.MustCompile(`(?i)\b(\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{2,4}|\d{1,2}[:h]\d{2})\b`)
+	moderationEmailPattern    = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+\s*(@|\(at\)|\[at\])\s*[a-z0-9.\-]+\.[a-z]{2,}`)
+	// Messaging deep links that hand out a number or handle.
+	moderationMessengerPattern = regexp.MustCompile(`(?i)\b(wa\.me|api\.whatsapp\.com|chat\.whatsapp\.com|t\.me|telegram\.me|m\.me)/`)
+	moderationLinkPattern      = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`)
+)
+
+// moderationFold maps accented letters and common leetspeak to plain letters before matching words.
+var moderationFold = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+	"à", "a", "â", "a", "ä", "a", "é", "e", "è", "e", "ê", "e", "ë", "e", "î", "i", "ï", "i",
+	"ô", "o", "ö", "o", "ù", "u", "û", "u", "ü", "u", "ç", "c",
+)
+
+// moderationWords lowercases and folds text, then splits it into words.
+func moderationWords(text string) []string {
+	folded := moderationFold.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+// countDigits returns the number of ASCII digits in s.
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// localReasons runs the local filter and returns the reasons the text was caught for.
+func (s *ModerationService) localReasons(text string) []string {
+	var reasons []string
+
+	words := moderationWords(text)
+	profane := false
+	for _, word := range words {
+		if s.blockedWords[word] {
+			profane = true
+			break
+		}
+	}
+	if !profane && len(s.blockedTerms) > 0 {
+		joined := " " + strings.Join(words, " ") + " "
+		for _, term := range s.blockedTerms {
+			if strings.Contains(joined, " "+term+" ") {
+				profane = true
+				break
+			}
+		}
+	}
+	if profane {
+		reasons = append(reasons, models.ModerationReasonProfanity)
+	}
+
+	contact := moderationEmailPattern.MatchString(text) || moderationMessengerPattern.MatchString(text)
+	if !contact {
+		withoutDates := moderationDateTimePattern.ReplaceAllString(text, " ")
+		for _, match := range moderationPhonePattern.FindAllString(withoutDates, -1) {
+			if countDigits(match) >= 9 {
+				contact = true
+				break
+			}
+		}
+	}
+	if contact {
+		reasons = append(reasons, models.ModerationReasonContactDetails)
+	}
+
+	if moderationLinkPattern.MatchString(text) {
+		reasons = append(reasons, models.ModerationReasonLink)
+	}
+	return reasons
+}
+
+// moderationActionFor maps the reasons to an action: abuse and contact details are blocked,
+// anything else (links, external API hits) is flagged for review.
+func moderationActionFor(reasons []string) models.ModerationAction {
+	action := models.ModerationActionAllow
+	for _, reason := range reasons {
+		switch reason {
+		case models.ModerationReasonProfanity, models.ModerationReasonContactDetails:
+			return models.ModerationActionBlock
+		default:
+			action = models.ModerationActionFlag
+		}
+	}
+	return action
+}
+
+// Evaluate moderates the text. The external API fails open: errors are logged and the local
+// verdict stands, so an outage of the moderation API never blocks users.
+func (s *ModerationService) Evaluate(ctx context.Context, check models.ModerationCheck) *models.ModerationDecision {
+	reasons := s.localReasons(check.Text)
+	action := moderationActionFor(reasons)
+
+	// No need to ask the API about text that is already blocked.
+	if s.provider != nil && action != models.ModerationActionBlock && strings.TrimSpace(check.Text) != "" {
+		categories, err := s.provider.Moderate(ctx, check.Text)
+		if err != nil {
+			log.Printf("Warning: moderation API failed for %s by user %s, using local verdict: %v", check.ContentType, check.UserID, err)
+		}
+		for _, category := range categories {
+			reasons = append(reasons, models.ModerationReasonExternal+":"+category)
+		}
+		if len(categories) > 0 {
+			action = models.ModerationActionFlag
+		}
+	}
+
+	if action != models.ModerationActionAllow {
+		log.Printf("Moderation %s %s by user %s (reasons: %s)", action, check.ContentType, check.UserID, strings.Join(reasons, ","))
+	}
+	return &models.ModerationDecision{Action: action, Reasons: reasons}
+}
+
+// httpModerationProvider calls an OpenAI-compatible moderation endpoint:
+// POST {"input": text} -> {"results": [{"flagged": bool, "categories": {"harassment": true, ...}}]}.
+type httpModerationProvider struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func (p *httpModerationProvider) Moderate(ctx context.Context, text string) ([]string, error) {
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode moderation request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+
+	var categories []string
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		found := false
+		for category, hit := range r.Categories {
+			if hit {
+				categories = append(categories, category)
+				found = true
+			}
+		}
+		if !found {
+			categories = append(categories, "flagged")
+		}
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+// RecordFlag queues flagged or blocked text for admin review. It runs in the caller's transaction so
+// the flag only exists if the text was actually stored (or, for blocked text, the refusal is final).
+func (s *ModerationService) RecordFlag(ctx context.Context, tx pgx.Tx, check models.ModerationCheck, decision *models.ModerationDecision) error {
+	if decision.Action == models.ModerationActionAllow {
+		return nil
+	}
+	insertQuery := `
+		INSERT INTO moderation_flags (user_id, content_type, ride_id, content, action, reasons)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(ctx, insertQuery, check.UserID, check.ContentType, check.RideID, check.Text, decision.Action, decision.Reasons); err != nil {
+		return fmt.Errorf("database error recording moderation flag: %w", err)
+	}
+	return nil
+}
+
+const moderationFlagSelect = `
+	SELECT f.id, f.user_id, u.email, f.content_type, f.ride_id, f.content, f.action, f.reasons, f.status,
+	       f.resolved_by, f.resolved_at, f.resolution_note, f.created_at
+	FROM moderation_flags f
+	LEFT JOIN users u ON u.id = f.user_id
+`
+
+func scanModerationFlag(row pgx.Row) (*models.ModerationFlag, error) {
+	var flag models.ModerationFlag
+	err := row.Scan(&flag.ID, &flag.UserID, &flag.UserEmail, &flag.ContentType, &flag.RideID, &flag.Content, &flag.Action, &flag.Reasons, &flag.Status,
+		&flag.ResolvedBy, &flag.ResolvedAt, &flag.ResolutionNote, &flag.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// ListFlags returns the most recent moderation flags with the given status (all statuses if empty).
+func (s *ModerationService) ListFlags(ctx context.Context, status string) ([]models.ModerationFlag, error) {
+	query := moderationFlagSelect + ` WHERE ($1 = '' OR f.status = $1) ORDER BY f.created_at DESC LIMIT 100`
+	rows, err := s.db.Query(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing moderation flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.ModerationFlag{}
+	for rows.Next() {
+		flag, err := scanModerationFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning moderation flag: %w", err)
+		}
+		flags = append(flags, *flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating moderation flags: %w", err)
+	}
+	return flags, nil
+}
+
+// ResolveFlag closes an open moderation review.
+func (s *ModerationService) ResolveFlag(ctx context.Context, adminID uuid.UUID, flagID uuid.UUID, req models.ResolveModerationFlagRequest) (*models.ModerationFlag, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var status string
+	if err := s.db.QueryRow(ctx, `SELECT status FROM moderation_flags WHERE id = $1`, flagID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "moderation flag not found")
+		}
+		return nil, fmt.Errorf("database error fetching moderation flag: %w", err)
+	}
+	updateQuery := `
+		UPDATE moderation_flags
+		SET status = $2, resolved_by = $3, resolved_at = NOW(), resolution_note = $4
+		WHERE id = $1 AND status = 'open'
+	`
+	tag, err := s.db.Exec(ctx, updateQuery, flagID, req.Status, adminID, req.Note)
+	if err != nil {
+		return nil, fmt.Errorf("database error resolving moderation flag: %w", err)
+	}
+	if status != models.ModerationFlagStatusOpen || tag.RowsAffected() == 0 {
+		return nil, newError(KindConflict, "moderation flag is already resolved")
+	}
+
+	log.Printf("Moderation flag %s resolved as '%s' by admin %s", flagID, req.Status, adminID)
+	return scanModerationFlag(s.db.QueryRow(ctx, moderationFlagSelect+` WHERE f.id = $1`, flagID))
+}
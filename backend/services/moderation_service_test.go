@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test the local filter on abuse, contact details and links, and the action each one maps to
+func TestModerationService_LocalFilter(t *testing.T) {
+	service := NewModerationServiceWithProvider(&config.Config{ModerationBlockedWords: []string{"connard", "shit", "total loser"}}, nil, nil)
+
+	tests := []struct {
+		text        string
+		wantReasons []string
+		wantAction  models.ModerationAction
+	}{
+		{"No show at the meeting point, waited 20 minutes", nil, models.ModerationActionAllow},
+		{"Departure moved to 2026-10-16 08:30, see you at 8h45", nil, models.ModerationActionAllow},
+		{"Quel CONNARD", []string{models.ModerationReasonProfanity}, models.ModerationActionBlock},
+		{"what a sh1t trip", []string{models.ModerationReasonProfanity}, models.ModerationActionBlock},
+		{"you are a Total  Loser!", []string{models.ModerationReasonProfanity}, models.ModerationActionBlock},
+		{"Text me on 06 12 34 56 78 instead", []string{models.ModerationReasonContactDetails}, models.ModerationActionBlock},
+		{"call +33.6.12.34.56.78", []string{models.ModerationReasonContactDetails}, models.ModerationActionBlock},
+		{"write to driver (at) example.com", []string{models.ModerationReasonContactDetails}, models.ModerationActionBlock},
+		{"join me https://wa.me/33612345678", []string{models.ModerationReasonContactDetails, models.ModerationReasonLink}, models.ModerationActionBlock},
+		{"details on www.example.com", []string{models.ModerationReasonLink}, models.ModerationActionFlag},
+	}
+	for _, tt := range tests {
+		reasons := service.localReasons(tt.text)
+		if !reflect.DeepEqual(reasons, tt.wantReasons) {
+			t.Errorf("localReasons(%q) = %v, want %v", tt.text, reasons, tt.wantReasons)
+		}
+		if action := moderationActionFor(reasons); action != tt.wantAction {
+			t.Errorf("moderationActionFor(%q) = %s, want %s", tt.text, action, tt.wantAction)
+		}
+	}
+}
+
+// stubModerationProvider returns fixed categories or an error
+type stubModerationProvider struct {
+	categories []string
+	err        error
+	calls      int
+}
+
+func (p *stubModerationProvider) Moderate(ctx context.Context, text string) ([]string, error) {
+	p.calls++
+	return p.categories, p.err
+}
+
+// Test how the external API verdict combines with the local filter
+func TestModerationService_Evaluate(t *testing.T) {
+	cfg := &config.Config{ModerationBlockedWords: []string{"connard"}}
+	check := func(provider ModerationProvider, text string) *models.ModerationDecision {
+		return NewModerationServiceWithProvider(cfg, nil, provider).Evaluate(context.Background(), models.ModerationCheck{Text: text})
+	}
+
+	// External hits are flagged, not blocked
+	flagging := &stubModerationProvider{categories: []string{"harassment"}}
+	if d := check(flagging, "you will regret this"); d.Action != models.ModerationActionFlag || !reflect.DeepEqual(d.Reasons, []string{"external:harassment"}) {
+		t.Errorf("Evaluate(external hit) = %+v, want flag with external:harassment", d)
+	}
+	// Locally blocked text is not sent to the API
+	flagging.calls = 0
+	if d := check(flagging, "connard"); d.Action != models.ModerationActionBlock || flagging.calls != 0 {
+		t.Errorf("Evaluate(blocked word) = %+v after %d API calls, want block without calling the API", d, flagging.calls)
+	}
+	// API failures fail open
+	failing := &stubModerationProvider{err: errors.New("timeout")}
+	if d := check(failing, "see you tomorrow"); d.Action != models.ModerationActionAllow {
+		t.Errorf("Evaluate(API down) = %+v, want allow", d)
+	}
+}
+
+// Test the OpenAI-compatible moderation API client
+func TestHTTPModerationProvider_Moderate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want Bearer test-key", r.Header.Get("Authorization"))
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		flagged := body["input"] == "abusive"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]any{{
+				"flagged":    flagged,
+				"categories": map[string]bool{"harassment": flagged, "violence": flagged, "sexual": false},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	provider := &httpModerationProvider{url: server.URL, apiKey: "test-key", httpClient: server.Client()}
+	categories, err := provider.Moderate(context.Background(), "abusive")
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if want := []string{"harassment", "violence"}; !reflect.DeepEqual(categories, want) {
+		t.Errorf("Moderate(abusive) = %v, want %v", categories, want)
+	}
+	categories, err = provider.Moderate(context.Background(), "fine")
+	if err != nil || len(categories) != 0 {
+		t.Errorf("Moderate(fine) = %v, %v, want no categories", categories, err)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// pushEventSpec describes how a notification event is routed and delivered.
+type pushEventSpec struct {
+	screen      string              // App route opened on tap
+	priority    models.PushPriority // Expo delivery priority
+	collapseKey string              // fmt pattern taking the ride ID; empty means never collapse
+	critical    bool                // Critical events bypass per-user rate caps and collapsing
+}
+
+// pushEventSpecs is the per-event routing table used to build push payloads.
+var pushEventSpecs = map[models.NotificationEvent]pushEventSpec{
+	models.NotificationEventJoinConfirmed:        {screen: "RideDetails", priority: models.PushPriorityHigh, critical: true},
+	models.NotificationEventParticipantJoined:    {screen: "RideParticipants", priority: models.PushPriorityDefault, collapseKey: "ride:%s:participants"},
+	models.NotificationEventParticipantLeft:      {screen: "RideParticipants", priority: models.PushPriorityDefault, collapseKey: "ride:%s:participants"},
+	models.NotificationEventRideCancelled:        {screen: "MyRides", priority: models.PushPriorityHigh, critical: true},
+	models.NotificationEventParticipantRemoved:   {screen: "MyRides", priority: models.PushPriorityHigh, critical: true},
+	models.NotificationEventRideTransferProposed: {screen: "RideTransfers", priority: models.PushPriorityHigh},
+	models.NotificationEventRideTransferred:      {screen: "RideDetails", priority: models.PushPriorityHigh, critical: true},
+	models.NotificationEventRideTransferDeclined: {screen: "RideDetails", priority: models.PushPriorityDefault},
+	models.NotificationEventReviewReceived:       {screen: "Reviews", priority: models.PushPriorityDefault},
+	models.NotificationEventRideInvitation:       {screen: "RideInvitations", priority: models.PushPriorityHigh},
+	models.NotificationEventAutoJoinRefunded:     {screen: "RideDetails", priority: models.PushPriorityHigh, critical: true},
+	models.NotificationEventSavedSearchMatch:     {screen: "RideDetails", priority: models.PushPriorityDefault},
+	models.NotificationEventDepartureCheckin:     {screen: "RideDetails", priority: models.PushPriorityHigh, critical: true},
+}
+
+// NotificationService is the notification dispatcher: it records notifications,
+// delivers them as Expo push messages with a structured payload, and sends transactional emails.
+type NotificationService struct {
+	cfg        *config.Config
+	db         database.DBPool
+	emails     *EmailService
+	httpClient *http.Client
+}
+
+// NewNotificationService creates a new NotificationService instance.
+func NewNotificationService(cfg *config.Config, db database.DBPool, emails *EmailService) *NotificationService {
+	return &NotificationService{
+		cfg:        cfg,
+		db:         db,
+		emails:     emails,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// BuildPushPayload builds the typed push payload for an event.
+func BuildPushPayload(event models.NotificationEvent, rideID *uuid.UUID) models.PushPayload {
+	spec, ok := pushEventSpecs[event]
+	if !ok {
+		spec = pushEventSpec{screen: "Notifications", priority: models.PushPriorityDefault}
+	}
+	payload := models.PushPayload{
+		Event:    event,
+		RideID:   rideID,
+		Screen:   spec.screen,
+		Priority: spec.priority,
+	}
+	if spec.collapseKey != "" && rideID != nil {
+		payload.CollapseKey = fmt.Sprintf(spec.collapseKey, rideID.String())
+	}
+	return payload
+}
+
+// Notify records a notification for the user and delivers it as a push in the background.
+// Failures are logged, never returned: notifications must not break the calling flow.
+func (s *NotificationService) Notify(ctx context.Context, userID uuid.UUID, event models.NotificationEvent, rideID *uuid.UUID, title, body string) {
+	notification := models.Notification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		EventType: event,
+		RideID:    rideID,
+		Title:     title,
+		Body:      body,
+		Payload:   BuildPushPayload(event, rideID),
+	}
+
+	payloadJSON, err := json.Marshal(notification.Payload)
+	if err != nil {
+		log.Printf("Notification Error: Failed to encode payload for user %s (%s): %v", userID, event, err)
+		return
+	}
+
+	insertQuery := `
+		INSERT INTO notifications (id, user_id, event_type, ride_id, title, body, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+	err = s.db.QueryRow(ctx, insertQuery,
+		notification.ID, notification.UserID, string(notification.EventType), notification.RideID,
+		notification.Title, notification.Body, payloadJSON,
+	).Scan(&notification.CreatedAt)
+	if err != nil {
+		log.Printf("Notification Error: Failed to record %s notification for user %s: %v", event, userID, err)
+		return
+	}
+
+	// Deliver asynchronously; the request context ends with the HTTP response.
+	go s.deliver(notification)
+}
+
+// Email sends a transactional email to the user in the background.
+// Like Notify, failures are logged and never returned.
+func (s *NotificationService) Email(userID uuid.UUID, templateName string, data map[string]string) {
+	if s.emails == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.emails.SendToUser(ctx, userID, templateName, data); err != nil {
+			log.Printf("Email Error: %v", err)
+		}
+	}()
+}
+
+// expoPushMessage is a single message accepted by the Expo push API.
+type expoPushMessage struct {
+	To         string             `json:"to"`
+	Title      string             `json:"title"`
+	Body       string             `json:"body"`
+	Data       models.PushPayload `json:"data"`
+	Priority   string             `json:"priority,omitempty"`
+	Sound      string             `json:"sound,omitempty"`
+	CollapseID string             `json:"collapseId,omitempty"` // Device replaces an earlier push with the same ID
+}
+
+// newExpoPushMessage builds the Expo message delivering the notification to a push token.
+func newExpoPushMessage(pushToken string, notification models.Notification) expoPushMessage {
+	return expoPushMessage{
+		To:         pushToken,
+		Title:      notification.Title,
+		Body:       notification.Body,
+		Data:       notification.Payload,
+		Priority:   string(notification.Payload.Priority),
+		Sound:      "default",
+		CollapseID: notification.Payload.CollapseKey,
+	}
+}
+
+// expoPushResponse is the subset of the Expo push API response we inspect.
+type expoPushResponse struct {
+	Data []struct {
+		Status  string `json:"status"`
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		Details struct {
+			Error string `json:"error"` // e.g. DeviceNotRegistered
+		} `json:"details"`
+	} `json:"data"`
+}
+
+// expoDeviceNotRegistered is the Expo error for tokens of uninstalled apps; they never work again.
+const expoDeviceNotRegistered = "DeviceNotRegistered"
+
+// Push delivery outcomes recorded on the notifications table.
+const (
+	pushStatusSent      = "sent"
+	pushStatusThrottled = "throttled"
+	pushStatusCollapsed = "collapsed"
+	pushStatusNoToken   = "no_token"
+	pushStatusFailed    = "failed"
+)
+
+// checkPushAllowed applies per-user throttling to non-critical events: repeated events sharing a
+// collapse key within the collapse window are folded into the earlier push, and pushes beyond the
+// hourly cap are skipped. It returns an empty status when the push may be sent.
+func (s *NotificationService) checkPushAllowed(ctx context.Context, notification models.Notification) (string, error) {
+	if spec, ok := pushEventSpecs[notification.EventType]; ok && spec.critical {
+		return "", nil
+	}
+
+	if notification.Payload.CollapseKey != "" && s.cfg.PushCollapseWindow > 0 {
+		var recentlyPushed bool
+		collapseQuery := `
+			SELECT EXISTS(
+				SELECT 1 FROM notifications
+				WHERE user_id = $1 AND id != $2 AND payload->>'collapse_key' = $3
+				  AND pushed_at > NOW() - make_interval(secs => $4)
+			)
+		`
+		err := s.db.QueryRow(ctx, collapseQuery, notification.UserID, notification.ID, notification.Payload.CollapseKey, s.cfg.PushCollapseWindow.Seconds()).Scan(&recentlyPushed)
+		if err != nil {
+			return "", fmt.Errorf("database error checking collapsible pushes: %w", err)
+		}
+		if recentlyPushed {
+			return pushStatusCollapsed, nil
+		}
+	}
+
+	if s.cfg.MaxPushesPerHour > 0 {
+		var pushesLastHour int
+		countQuery := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND pushed_at > NOW() - INTERVAL '1 hour'`
+		if err := s.db.QueryRow(ctx, countQuery, notification.UserID).Scan(&pushesLastHour); err != nil {
+			return "", fmt.Errorf("database error counting recent pushes: %w", err)
+		}
+		if pushesLastHour >= s.cfg.MaxPushesPerHour {
+			return pushStatusThrottled, nil
+		}
+	}
+	return "", nil
+}
+
+// recordPushStatus stores the delivery outcome; pushed_at is only set for pushes handed to Expo.
+func (s *NotificationService) recordPushStatus(ctx context.Context, notificationID uuid.UUID, status string) {
+	query := `UPDATE notifications SET push_status = $1, pushed_at = CASE WHEN $1 = 'sent' THEN NOW() ELSE pushed_at END WHERE id = $2`
+	if _, err := s.db.Exec(ctx, query, status, notificationID); err != nil {
+		log.Printf("Push Error: Failed recording push status '%s' for notification %s: %v", status, notificationID, err)
+	}
+}
+
+// deliver sends the notification to the user's registered Expo push token, if any.
+func (s *NotificationService) deliver(notification models.Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	skipStatus, err := s.checkPushAllowed(ctx, notification)
+	if err != nil {
+		log.Printf("Push Error: Throttle check failed for notification %s, sending anyway: %v", notification.ID, err)
+	} else if skipStatus != "" {
+		log.Printf("Push Info: Notification %s (%s) for user %s not pushed: %s", notification.ID, notification.EventType, notification.UserID, skipStatus)
+		s.recordPushStatus(ctx, notification.ID, skipStatus)
+		return
+	}
+
+	var pushToken *string
+	// Dormant tokens (see PushHygieneJob) count as missing until the app registers again
+	tokenQuery := `SELECT CASE WHEN push_token_inactive_at IS NULL THEN expo_push_token END FROM users WHERE id = $1 AND deleted_at IS NULL`
+	err = s.db.QueryRow(ctx, tokenQuery, notification.UserID).Scan(&pushToken)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Push Error: Failed fetching push token for user %s: %v", notification.UserID, err)
+		}
+		s.recordPushStatus(ctx, notification.ID, pushStatusFailed)
+		return
+	}
+	if pushToken == nil || *pushToken == "" {
+		log.Printf("Push Info: User %s has no push token, notification %s stored only", notification.UserID, notification.ID)
+		s.recordPushStatus(ctx, notification.ID, pushStatusNoToken)
+		return
+	}
+
+	body, err := json.Marshal([]expoPushMessage{newExpoPushMessage(*pushToken, notification)})
+	if err != nil {
+		log.Printf("Push Error: Failed to encode message for notification %s: %v", notification.ID, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.ExpoPushURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Push Error: Failed to build request for notification %s: %v", notification.ID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.ExpoAccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.ExpoAccessToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Push Error: Failed to send notification %s to user %s: %v", notification.ID, notification.UserID, err)
+		s.recordPushStatus(ctx, notification.ID, pushStatusFailed)
+		return
+	}
+	defer resp.Body.Close()
+
+	var result expoPushResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || resp.StatusCode != http.StatusOK {
+		log.Printf("Push Error: Unexpected Expo response for notification %s (HTTP %d): %v", notification.ID, resp.StatusCode, err)
+		s.recordPushStatus(ctx, notification.ID, pushStatusFailed)
+		return
+	}
+	status := pushStatusSent
+	for _, ticket := range result.Data {
+		if ticket.Status != "ok" {
+			log.Printf("Push Error: Expo rejected notification %s for user %s: %s", notification.ID, notification.UserID, ticket.Message)
+			status = pushStatusFailed
+			if ticket.Details.Error == expoDeviceNotRegistered {
+				prunePushToken(ctx, s.db, notification.UserID, *pushToken)
+			}
+			continue
+		}
+		log.Printf("Push Info: Notification %s (%s) delivered to Expo for user %s (ticket %s)", notification.ID, notification.EventType, notification.UserID, ticket.ID)
+		// The delivery receipt is read later by PushHygieneJob
+		ticketQuery := `INSERT INTO push_tickets (id, notification_id, user_id, push_token) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`
+		if _, err := s.db.Exec(ctx, ticketQuery, ticket.ID, notification.ID, notification.UserID, *pushToken); err != nil {
+			log.Printf("Push Error: Failed recording ticket %s of notification %s: %v", ticket.ID, notification.ID, err)
+		}
+	}
+	s.recordPushStatus(ctx, notification.ID, status)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// stubPushDB answers the throttle queries of checkPushAllowed with fixed values.
+type stubPushDB struct {
+	database.DBPool
+	recentlyPushed bool
+	pushesLastHour int
+	queries        int
+}
+
+type stubPushRow struct{ value any }
+
+func (r stubPushRow) Scan(dest ...any) error {
+	switch d := dest[0].(type) {
+	case *bool:
+		*d = r.value.(bool)
+	case *int:
+		*d = r.value.(int)
+	}
+	return nil
+}
+
+func (db *stubPushDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.queries++
+	if strings.Contains(sql, "EXISTS") {
+		return stubPushRow{db.recentlyPushed}
+	}
+	return stubPushRow{db.pushesLastHour}
+}
+
+// Test throttling: collapse window, hourly cap and the critical-event bypass
+func TestNotificationService_CheckPushAllowed(t *testing.T) {
+	rideID := uuid.New()
+	tests := []struct {
+		name           string
+		event          models.NotificationEvent
+		collapseWindow time.Duration
+		recentlyPushed bool
+		pushesLastHour int
+		want           string
+	}{
+		{"first push in window", models.NotificationEventParticipantJoined, time.Minute, false, 0, ""},
+		{"collapsed within window", models.NotificationEventParticipantJoined, time.Minute, true, 0, pushStatusCollapsed},
+		{"collapsing disabled", models.NotificationEventParticipantJoined, 0, true, 0, ""},
+		{"hourly cap reached", models.NotificationEventParticipantLeft, time.Minute, false, 5, pushStatusThrottled},
+		{"below hourly cap", models.NotificationEventRideTransferDeclined, time.Minute, false, 4, ""},
+		{"critical bypasses collapse", models.NotificationEventRideCancelled, time.Minute, true, 0, ""},
+		{"critical bypasses cap", models.NotificationEventJoinConfirmed, time.Minute, false, 50, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &stubPushDB{recentlyPushed: tt.recentlyPushed, pushesLastHour: tt.pushesLastHour}
+			s := &NotificationService{cfg: &config.Config{PushCollapseWindow: tt.collapseWindow, MaxPushesPerHour: 5}, db: db}
+			notification := models.Notification{ID: uuid.New(), UserID: uuid.New(), EventType: tt.event, Payload: BuildPushPayload(tt.event, &rideID)}
+
+			got, err := s.checkPushAllowed(context.Background(), notification)
+			if err != nil {
+				t.Fatalf("checkPushAllowed() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("checkPushAllowed() = %q, want %q", got, tt.want)
+			}
+			if pushEventSpecs[tt.event].critical && db.queries != 0 {
+				t.Errorf("critical event ran %d throttle queries, want none", db.queries)
+			}
+		})
+	}
+}
+
+// Test that the collapse key is derived per ride and sent in Expo's collapse field
+func TestNewExpoPushMessage_CollapseKey(t *testing.T) {
+	rideID := uuid.MustParse("6f1c2a4e-8d3b-4f4a-9c1e-2b7d5e9a0c11")
+	tests := []struct {
+		name   string
+		event  models.NotificationEvent
+		rideID *uuid.UUID
+		want   string
+	}{
+		{"participant joined", models.NotificationEventParticipantJoined, &rideID, "ride:" + rideID.String() + ":participants"},
+		{"participant left shares key", models.NotificationEventParticipantLeft, &rideID, "ride:" + rideID.String() + ":participants"},
+		{"critical never collapses", models.NotificationEventRideCancelled, &rideID, ""},
+		{"no ride", models.NotificationEventParticipantJoined, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := models.Notification{Title: "t", Body: "b", Payload: BuildPushPayload(tt.event, tt.rideID)}
+			encoded, err := json.Marshal(newExpoPushMessage("ExponentPushToken[x]", notification))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var message map[string]any
+			if err := json.Unmarshal(encoded, &message); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			got, _ := message["collapseId"].(string)
+			if got != tt.want {
+				t.Errorf("collapseId = %q, want %q", got, tt.want)
+			}
+			data, _ := message["data"].(map[string]any)
+			if dataKey, _ := data["collapse_key"].(string); dataKey != tt.want {
+				t.Errorf("data.collapse_key = %q, want %q", dataKey, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For trimming notes
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// currentParticipantStatuses are the participations that still hold seats and can arrange a pickup.
+var currentParticipantStatuses = []string{
+	string(models.ParticipantStatusActive),
+	string(models.ParticipantStatusPendingPayment),
+	string(models.ParticipantStatusOnHold),
+}
+
+// trimmedOrNil trims text and returns nil if nothing is left, so blank values clear a field.
+func trimmedOrNil(text *string) *string {
+	if text == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*text)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// activeRideCreator returns the creator of a ride, which must be active for pickups to be arranged.
+func (s *RideService) activeRideCreator(ctx context.Context, q rowQuerier, rideID uuid.UUID) (uuid.UUID, error) {
+	var creatorID uuid.UUID
+	var status string
+	err := q.QueryRow(ctx, `SELECT user_id, status FROM rides WHERE id = $1`, rideID).Scan(&creatorID, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrRideNotFound
+		}
+		return uuid.Nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if status != string(models.RideStatusActive) {
+		return uuid.Nil, newError(KindConflict, "pickups can only be arranged on an active ride")
+	}
+	return creatorID, nil
+}
+
+// SetPickupNote sets (or clears) the participant's note to the driver, e.g. where they'll wait.
+// The note is moderated like other text shown to users: blocked notes are refused, flagged notes
+// are kept and queued for review.
+func (s *RideService) SetPickupNote(ctx context.Context, rideID, userID uuid.UUID, req models.UpdatePickupNoteRequest) (*models.ParticipantPickupResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid pickup note: %w", err)
+	}
+	note := trimmedOrNil(req.Note)
+
+	check := models.ModerationCheck{UserID: userID, ContentType: models.ModerationContentPickupNote, RideID: &rideID}
+	moderation := &models.ModerationDecision{Action: models.ModerationActionAllow}
+	if note != nil {
+		check.Text = *note
+		moderation = s.moderation.Evaluate(ctx, check)
+		if moderation.Action == models.ModerationActionBlock {
+			return nil, newError(KindInvalid, "pickup note can't contain contact details or offensive language")
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := s.activeRideCreator(ctx, tx, rideID); err != nil {
+		return nil, err
+	}
+	resp := &models.ParticipantPickupResponse{RideID: rideID, UserID: userID}
+	resp.PickupNote = note
+	updateQuery := `
+		UPDATE participants SET pickup_note = $3, updated_at = NOW()
+		WHERE ride_id = $1 AND user_id = $2 AND status = ANY($4)
+		RETURNING pickup_order, seat_label
+	`
+	err = tx.QueryRow(ctx, updateQuery, rideID, userID, note, currentParticipantStatuses).Scan(&resp.PickupOrder, &resp.Seat)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "you are not a current participant of this ride")
+		}
+		log.Printf("Error setting pickup note of user %s on ride %s: %v", userID, rideID, err)
+		return nil, fmt.Errorf("database error setting pickup note: %w", err)
+	}
+	if err := s.moderation.RecordFlag(ctx, tx, check, moderation); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save pickup note: %w", err)
+	}
+	return resp, nil
+}
+
+// AssignPickup sets the informal pickup order and seat of a participant. Only the ride creator
+// can assign them; both are replaced, so omitted fields are cleared.
+func (s *RideService) AssignPickup(ctx context.Context, rideID, creatorID, participantUserID uuid.UUID, req models.AssignPickupRequest) (*models.ParticipantPickupResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid pickup assignment: %w", err)
+	}
+	rideCreatorID, err := s.activeRideCreator(ctx, s.db, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if rideCreatorID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can assign pickups")
+	}
+
+	resp := &models.ParticipantPickupResponse{RideID: rideID, UserID: participantUserID}
+	resp.PickupOrder = req.PickupOrder
+	resp.Seat = trimmedOrNil(req.Seat)
+	updateQuery := `
+		UPDATE participants SET pickup_order = $3, seat_label = $4, updated_at = NOW()
+		WHERE ride_id = $1 AND user_id = $2 AND status = ANY($5)
+		RETURNING pickup_note
+	`
+	err = s.db.QueryRow(ctx, updateQuery, rideID, participantUserID, resp.PickupOrder, resp.Seat, currentParticipantStatuses).Scan(&resp.PickupNote)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "user is not a current participant of this ride")
+		}
+		log.Printf("Error assigning pickup of user %s on ride %s: %v", participantUserID, rideID, err)
+		return nil, fmt.Errorf("database error assigning pickup: %w", err)
+	}
+	log.Printf("Creator %s assigned pickup of user %s on ride %s", creatorID, participantUserID, rideID)
+	return resp, nil
+}
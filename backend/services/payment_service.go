@@ -9,6 +9,8 @@ import (
 	"io" // For reading webhook request body
 	"log"
 	"net/http" // For webhook request object
+	"strings"  // For currency comparison
+	"time"     // For webhook notification deadline
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"         // For pgx errors
@@ -23,39 +25,64 @@ import (
 )
 
 const (
-	// Fixed amount for joining a ride (2 EUR in cents)
-	fixedPaymentAmount int64  = 200
-	paymentCurrency    string = "eur"
+	paymentCurrency string = "eur" // Seat prices and the booking fee (cfg.Runtime().BookingFeeCents) are charged in this currency
 )
 
 // StripeService defines the interface for interacting with the Stripe API.
 // This allows for mocking in tests.
 type StripeService interface {
 	CreateCustomer(ctx context.Context, params *stripe.CustomerParams) (*stripe.Customer, error)
+	DeleteCustomer(ctx context.Context, customerID string) error
 	CreateSetupIntent(ctx context.Context, params *stripe.SetupIntentParams) (*stripe.SetupIntent, error)
 	CreatePaymentIntent(ctx context.Context, params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error)
 	CreateAndConfirmPaymentIntent(ctx context.Context, params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error)
+	CreateRefund(ctx context.Context, params *stripe.RefundParams) (*stripe.Refund, error)
 	ConstructWebhookEvent(payload []byte, signatureHeader string, secret string) (stripe.Event, error)
 }
 
 // PaymentService handles payment logic using Stripe.
 type PaymentService struct {
-	cfg          *config.Config
-	db           database.DBPool
-	rideService  *RideService  // Inject RideService
-	stripeClient StripeService // Inject Stripe client interface
+	cfg           *config.Config
+	db            database.DBPool
+	rideService   *RideService         // Inject RideService
+	stripeClient  StripeService        // Inject Stripe client interface
+	notifications *NotificationService // Notification dispatcher
+	fraud         *FraudService        // Fraud rules evaluated on join and payment
+	reporter      *ErrorReporter       // Reports webhook processing failures (nil-safe)
+	analytics     *AnalyticsService    // Product analytics events (nil-safe)
 }
 
 // NewPaymentService creates a new PaymentService instance.
-func NewPaymentService(cfg *config.Config, db database.DBPool, rideService *RideService, stripeClient StripeService) *PaymentService {
+func NewPaymentService(cfg *config.Config, db database.DBPool, rideService *RideService, stripeClient StripeService, notifications *NotificationService, fraud *FraudService, reporter *ErrorReporter, analytics *AnalyticsService) *PaymentService {
 	return &PaymentService{
-		cfg:          cfg,
-		db:           db,
-		rideService:  rideService,  // Store injected RideService
-		stripeClient: stripeClient, // Store injected Stripe client
+		cfg:           cfg,
+		db:            db,
+		rideService:   rideService,   // Store injected RideService
+		stripeClient:  stripeClient,  // Store injected Stripe client
+		notifications: notifications, // Store injected notification dispatcher
+		fraud:         fraud,
+		reporter:      reporter,
+		analytics:     analytics,
 	}
 }
 
+// seatAmount returns what joining a ride costs: its seat price, or the booking fee if it has none.
+// peak tells whether the ride departs on a peak travel day (HolidayCalendar.PeakDay).
+func seatAmount(runtime *config.RuntimeSettings, pricePerSeat *int64, peak bool) int64 {
+	if pricePerSeat != nil {
+		return *pricePerSeat
+	}
+	return bookingFee(runtime, peak)
+}
+
+// bookingFee returns the booking fee: the peak fee on peak travel days, when one is set.
+func bookingFee(runtime *config.RuntimeSettings, peak bool) int64 {
+	if peak && runtime.PeakFeeCents > 0 {
+		return runtime.PeakFeeCents
+	}
+	return runtime.BookingFeeCents
+}
+
 // CreatePaymentIntent creates a Stripe PaymentIntent and a corresponding transaction record.
 func (s *PaymentService) CreatePaymentIntent(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.CreatePaymentIntentResponse, error) {
 	log.Printf("Attempting to create PaymentIntent for user %s joining ride %s", userID, rideID)
@@ -64,12 +91,15 @@ func (s *PaymentService) CreatePaymentIntent(ctx context.Context, rideID uuid.UU
 	//    and get the participant ID.
 	var participantID uuid.UUID
 	var participantStatus string // Read status as string from DB
-	query := `SELECT id, status FROM participants WHERE user_id = $1 AND ride_id = $2`
-	err := s.db.QueryRow(ctx, query, userID, rideID).Scan(&participantID, &participantStatus)
+	var seatCount int
+	var pricePerSeat *int64
+	var departureDate time.Time
+	query := `SELECT p.id, p.status, p.seat_count, r.price_per_seat, r.departure_date FROM participants p JOIN rides r ON r.id = p.ride_id WHERE p.user_id = $1 AND p.ride_id = $2`
+	err := s.db.QueryRow(ctx, query, userID, rideID).Scan(&participantID, &participantStatus, &seatCount, &pricePerSeat, &departureDate)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("PaymentIntent creation failed: User %s has not joined ride %s", userID, rideID)
-			return nil, errors.New("user has not joined this ride or participation record not found")
+			return nil, newError(KindConflict, "user has not joined this ride or participation record not found")
 		}
 		log.Printf("Error fetching participant record for user %s, ride %s: %v", userID, rideID, err)
 		return nil, fmt.Errorf("database error fetching participation record: %w", err)
@@ -79,10 +109,28 @@ func (s *PaymentService) CreatePaymentIntent(ctx context.Context, rideID uuid.UU
 	if participantStatus != string(models.ParticipantStatusPendingPayment) {
 		log.Printf("PaymentIntent creation failed: Participation status for user %s, ride %s is '%s', expected '%s'",
 			userID, rideID, participantStatus, string(models.ParticipantStatusPendingPayment))
-		return nil, fmt.Errorf("cannot create payment for participation with status: %s", participantStatus)
+		return nil, newError(KindConflict, fmt.Sprintf("cannot create payment for participation with status: %s", participantStatus))
+	}
+
+	// Fraud rules: a hold parks the booking as 'on_hold' until an admin reviews it
+	fraudCheck := models.FraudCheck{Event: models.FraudEventPayment, UserID: &userID, RideID: &rideID}
+	if s.fraud != nil {
+		decision := s.fraud.Evaluate(ctx, fraudCheck)
+		if decision.Action == models.FraudActionHold {
+			holdQuery := `UPDATE participants SET status = $1, updated_at = NOW() WHERE id = $2`
+			if _, err := s.db.Exec(ctx, holdQuery, string(models.ParticipantStatusOnHold), participantID); err != nil {
+				log.Printf("Error holding participation %s for review: %v", participantID, err)
+				return nil, fmt.Errorf("database error holding booking: %w", err)
+			}
+			s.fraud.RecordEvent(ctx, fraudCheck)
+			log.Printf("PaymentIntent creation held by fraud rules for user %s, ride %s: %v", userID, rideID, decision.TriggeredRules)
+			return nil, ErrBookingOnHold
+		}
 	}
 
 	// 2. Create a transaction record in our database (status 'pending')
+	_, peak := s.rideService.holidays.PeakDay(departureDate)
+	amount := seatAmount(s.cfg.Runtime(), pricePerSeat, peak) * int64(seatCount) // Booked seats are paid together
 	payment := &models.Payment{
 		ID:                    uuid.New(),
 		UserID:                userID,
@@ -90,13 +138,13 @@ func (s *PaymentService) CreatePaymentIntent(ctx context.Context, rideID uuid.UU
 		ParticipantID:         &participantID,
 		StripePaymentIntentID: "", // Will be filled after creating Stripe PI
 		Status:                models.PaymentStatusPending,
-		Amount:                fixedPaymentAmount,
+		Amount:                amount,
 		Currency:              paymentCurrency,
 	}
 
 	// 3. Create PaymentIntent with Stripe
 	params := &stripe.PaymentIntentParams{
-		Amount:             stripe.Int64(fixedPaymentAmount),
+		Amount:             stripe.Int64(amount),
 		Currency:           stripe.String(paymentCurrency),
 		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
 	}
@@ -130,6 +178,9 @@ func (s *PaymentService) CreatePaymentIntent(ctx context.Context, rideID uuid.UU
 		return nil, fmt.Errorf("failed to save transaction record: %w", err)
 	}
 	log.Printf("Payment record created: %s for PI %s", payment.ID, pi.ID)
+	if s.fraud != nil {
+		s.fraud.RecordEvent(ctx, fraudCheck)
+	}
 
 	// 5. Return response to frontend
 	response := &models.CreatePaymentIntentResponse{
@@ -156,7 +207,7 @@ func (s *PaymentService) CreateSetupIntent(ctx context.Context, userID uuid.UUID
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("SetupIntent creation failed: User %s not found", userID)
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		log.Printf("Error fetching user %s for SetupIntent: %v", userID, err)
 		return nil, fmt.Errorf("database error fetching user: %w", err)
@@ -211,8 +262,16 @@ func (s *PaymentService) CreateSetupIntent(ctx context.Context, userID uuid.UUID
 }
 
 // HandleStripeWebhook processes incoming webhook events from Stripe.
+// DB work runs under the request's context, bounded by cfg.WebhookTimeout, so a slow database
+// fails the delivery (and Stripe retries it) instead of piling up requests.
 func (s *PaymentService) HandleStripeWebhook(request *http.Request) error {
 	log.Println("--- HandleStripeWebhook invoked ---") // Log entry
+	ctx := request.Context()
+	if s.cfg.WebhookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.WebhookTimeout)
+		defer cancel()
+	}
 
 	payload, err := io.ReadAll(request.Body)
 	if err != nil {
@@ -233,7 +292,20 @@ func (s *PaymentService) HandleStripeWebhook(request *http.Request) error {
 	}
 	log.Printf("--- Webhook STEP 4: Event constructed successfully (Type: %s, ID: %s) ---", event.Type, event.ID)
 
-	// Handle the event based on its type
+	// Processing failures (not bad signatures) are payment bugs or outages worth a report
+	if err := s.processWebhookEvent(ctx, event); err != nil {
+		s.reporter.CaptureError(err, ErrorContext{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Tags:   map[string]string{"stripe_event_type": string(event.Type), "stripe_event_id": event.ID},
+		})
+		return err
+	}
+	return nil
+}
+
+// processWebhookEvent handles a verified webhook event based on its type.
+func (s *PaymentService) processWebhookEvent(ctx context.Context, event stripe.Event) error {
 	switch event.Type {
 	case "payment_intent.succeeded":
 		log.Printf("--- Webhook STEP 5a: Handling event type %s ---", event.Type)
@@ -244,7 +316,7 @@ func (s *PaymentService) HandleStripeWebhook(request *http.Request) error {
 			return fmt.Errorf("error parsing webhook JSON for %s: %w", event.Type, err)
 		}
 		log.Printf("Webhook Handling: PaymentIntent Succeeded: %s", paymentIntent.ID)
-		return s.handlePaymentIntentSucceeded(context.Background(), &paymentIntent)
+		return s.handlePaymentIntentSucceeded(ctx, &paymentIntent)
 
 	case "payment_intent.payment_failed":
 		log.Printf("--- Webhook STEP 5a: Handling event type %s ---", event.Type)
@@ -255,7 +327,19 @@ func (s *PaymentService) HandleStripeWebhook(request *http.Request) error {
 			return fmt.Errorf("error parsing webhook JSON for %s: %w", event.Type, err)
 		}
 		log.Printf("Webhook Handling: PaymentIntent Failed: %s, Reason: %s", paymentIntent.ID, paymentIntent.LastPaymentError)
-		return s.handlePaymentIntentFailed(context.Background(), &paymentIntent)
+		return s.handlePaymentIntentFailed(ctx, &paymentIntent)
+
+	case "payment_intent.canceled":
+		// Stripe cancels intents that were abandoned or expired; the checkout is over, like a failure
+		log.Printf("--- Webhook STEP 5a: Handling event type %s ---", event.Type)
+		var paymentIntent stripe.PaymentIntent
+		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
+		if err != nil {
+			log.Printf("!!! Webhook Error STEP 5b (Unmarshal %s): %v", event.Type, err)
+			return fmt.Errorf("error parsing webhook JSON for %s: %w", event.Type, err)
+		}
+		log.Printf("Webhook Handling: PaymentIntent Canceled: %s", paymentIntent.ID)
+		return s.handlePaymentIntentFailed(ctx, &paymentIntent)
 
 	case "setup_intent.succeeded":
 		log.Printf("--- Webhook STEP 5a: Handling event type %s ---", event.Type)
@@ -266,7 +350,7 @@ func (s *PaymentService) HandleStripeWebhook(request *http.Request) error {
 			return fmt.Errorf("error parsing webhook JSON for %s: %w", event.Type, err)
 		}
 		log.Printf("Webhook Handling: SetupIntent Succeeded: %s", setupIntent.ID)
-		return s.handleSetupIntentSucceeded(context.Background(), &setupIntent)
+		return s.handleSetupIntentSucceeded(ctx, &setupIntent)
 
 	default:
 		log.Printf("Webhook Info: Unhandled event type: %s", event.Type)
@@ -320,7 +404,12 @@ func (s *PaymentService) handleSetupIntentSucceeded(ctx context.Context, si *str
 }
 
 // handlePaymentIntentSucceeded updates the database after a successful payment.
+// Charges of automatic joins finalize their intent instead.
 func (s *PaymentService) handlePaymentIntentSucceeded(ctx context.Context, pi *stripe.PaymentIntent) error {
+	if intentID, err := uuid.Parse(pi.Metadata["auto_join_intent_id"]); err == nil {
+		return s.finalizeAutoJoinWebhook(ctx, pi, intentID)
+	}
+
 	pool, ok := s.db.(*pgxpool.Pool)
 	if !ok {
 		log.Printf("Webhook Error: DB pool does not support transactions for PI succeeded %s", pi.ID)
@@ -334,8 +423,21 @@ func (s *PaymentService) handlePaymentIntentSucceeded(ctx context.Context, pi *s
 	}
 	defer tx.Rollback(ctx)
 
-	// 1. Update Payment status to 'succeeded'
-	updatePaymentQuery := `UPDATE payments SET status = $1, updated_at = NOW() WHERE stripe_payment_intent_id = $2 AND status = $3`
+	// 1. Check the payment against what Stripe charged, then update its status to 'succeeded'
+	var participantID, rideID, participantUserID uuid.UUID
+	var amount int64
+	var currency, status string
+	findPaymentQuery := `SELECT participant_id, ride_id, user_id, amount, currency, status FROM payments WHERE stripe_payment_intent_id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, findPaymentQuery, pi.ID).Scan(&participantID, &rideID, &participantUserID, &amount, &currency, &status)
+	if err != nil {
+		log.Printf("Webhook Error: Could not find participant ID linked to PI %s: %v", pi.ID, err)
+		return fmt.Errorf("could not find participant for PI %s: %w", pi.ID, err)
+	}
+	if status == string(models.PaymentStatusPending) && paymentIntentMismatch(pi, amount, currency) {
+		return s.holdMismatchedPayment(ctx, tx, pi, participantID, rideID, participantUserID, amount, currency)
+	}
+
+	updatePaymentQuery := `UPDATE payments SET status = $1, paid_at = NOW(), updated_at = NOW() WHERE stripe_payment_intent_id = $2 AND status = $3`
 	tag, err := tx.Exec(ctx, updatePaymentQuery, string(models.PaymentStatusSucceeded), pi.ID, string(models.PaymentStatusPending))
 	if err != nil {
 		log.Printf("Webhook Error: Failed updating payment status for PI %s: %v", pi.ID, err)
@@ -348,15 +450,7 @@ func (s *PaymentService) handlePaymentIntentSucceeded(ctx context.Context, pi *s
 	}
 
 	// 2. Update Participant status to 'active'
-	var participantID uuid.UUID
-	findParticipantQuery := `SELECT participant_id FROM payments WHERE stripe_payment_intent_id = $1`
-	err = tx.QueryRow(ctx, findParticipantQuery, pi.ID).Scan(&participantID)
-	if err != nil {
-		log.Printf("Webhook Error: Could not find participant ID linked to PI %s: %v", pi.ID, err)
-		return fmt.Errorf("could not find participant for PI %s: %w", pi.ID, err)
-	}
-
-	updateParticipantQuery := `UPDATE participants SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+	updateParticipantQuery := `UPDATE participants SET status = $1, seat_held_until = NULL, updated_at = NOW() WHERE id = $2 AND status = $3`
 	tag, err = tx.Exec(ctx, updateParticipantQuery, string(models.ParticipantStatusActive), participantID, string(models.ParticipantStatusPendingPayment))
 	if err != nil {
 		log.Printf("Webhook Error: Failed updating participant status for ID %s (PI %s): %v", participantID, pi.ID, err)
@@ -376,12 +470,82 @@ func (s *PaymentService) handlePaymentIntentSucceeded(ctx context.Context, pi *s
 	}
 
 	log.Printf("Webhook Handling Complete: Successfully processed payment_intent.succeeded for %s", pi.ID)
+	if tag.RowsAffected() > 0 {
+		// The payment is recorded; don't let the webhook response ending the request cut the notifications short
+		notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
+		defer cancel()
+		s.rideService.publishRideEvent(notifyCtx, RideEventJoined, rideID, participantUserID)
+		s.notifyJoinConfirmed(notifyCtx, participantID)
+		s.analytics.Track(AnalyticsEvent{
+			Name:       AnalyticsPaymentSucceeded,
+			UserID:     participantUserID,
+			Properties: map[string]interface{}{"ride_id": rideID.String(), "amount": pi.Amount, "currency": string(pi.Currency)},
+		})
+	}
+	return nil
+}
+
+// paymentIntentMismatch reports whether a succeeded PaymentIntent collected another amount or
+// currency than the payment row expects.
+func paymentIntentMismatch(pi *stripe.PaymentIntent, amount int64, currency string) bool {
+	return pi.AmountReceived != amount || !strings.EqualFold(string(pi.Currency), currency)
+}
+
+// holdMismatchedPayment puts a payment whose PaymentIntent doesn't match it under review instead of
+// confirming the booking: the participation is held and a fraud flag is opened. Clearing the flag
+// confirms the booking; otherwise the charge is refunded by an admin.
+func (s *PaymentService) holdMismatchedPayment(ctx context.Context, tx pgx.Tx, pi *stripe.PaymentIntent, participantID, rideID, userID uuid.UUID, amount int64, currency string) error {
+	log.Printf("Webhook Warning: PI %s collected %d %s but payment expects %d %s, holding it for review",
+		pi.ID, pi.AmountReceived, pi.Currency, amount, currency)
+
+	updatePaymentQuery := `UPDATE payments SET status = $1, paid_at = NOW(), updated_at = NOW() WHERE stripe_payment_intent_id = $2`
+	if _, err := tx.Exec(ctx, updatePaymentQuery, string(models.PaymentStatusUnderReview), pi.ID); err != nil {
+		return fmt.Errorf("db payment review update failed: %w", err)
+	}
+	holdQuery := `UPDATE participants SET status = $1, seat_held_until = NULL, updated_at = NOW() WHERE id = $2 AND status = $3`
+	if _, err := tx.Exec(ctx, holdQuery, string(models.ParticipantStatusOnHold), participantID, string(models.ParticipantStatusPendingPayment)); err != nil {
+		return fmt.Errorf("db participant hold failed: %w", err)
+	}
+	flagQuery := `
+		INSERT INTO fraud_flags (event, user_id, ride_id, action, rules)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(ctx, flagQuery, models.FraudEventPayment, userID, rideID, models.FraudActionHold, []string{models.FraudRulePaymentMismatch}); err != nil {
+		return fmt.Errorf("db fraud flag insert failed: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Webhook Error: Failed to commit review hold for PI %s: %v", pi.ID, err)
+		return fmt.Errorf("db transaction commit failed: %w", err)
+	}
 	return nil
 }
 
-// handlePaymentIntentFailed updates the database after a failed payment.
+// notifyJoinConfirmed tells a participant their seat is confirmed and the creator that a seat was taken.
+func (s *PaymentService) notifyJoinConfirmed(ctx context.Context, participantID uuid.UUID) {
+	var participantUserID, creatorID, rideID uuid.UUID
+	var routeName string
+	query := `
+		SELECT p.user_id, r.user_id, r.id, r.departure_location_name || ' → ' || r.arrival_location_name
+		FROM participants p
+		JOIN rides r ON r.id = p.ride_id
+		WHERE p.id = $1
+	`
+	if err := s.db.QueryRow(ctx, query, participantID).Scan(&participantUserID, &creatorID, &rideID, &routeName); err != nil {
+		log.Printf("Notification Error: Failed loading participation %s for join notifications: %v", participantID, err)
+		return
+	}
+	s.notifications.Notify(ctx, participantUserID, models.NotificationEventJoinConfirmed, &rideID,
+		"Seat confirmed", fmt.Sprintf("Your seat on %s is confirmed.", routeName))
+	s.notifications.Email(participantUserID, "join_confirmed", map[string]string{"Route": routeName})
+	s.notifications.Notify(ctx, creatorID, models.NotificationEventParticipantJoined, &rideID,
+		"New passenger", fmt.Sprintf("A passenger joined your ride %s.", routeName))
+	s.notifications.Email(creatorID, "participant_joined", map[string]string{"Route": routeName})
+}
+
+// handlePaymentIntentFailed updates the database after a failed or canceled payment and releases
+// the participant's checkout seat hold. The participation stays pending, so they can retry if a seat is left.
 func (s *PaymentService) handlePaymentIntentFailed(ctx context.Context, pi *stripe.PaymentIntent) error {
-	updatePaymentQuery := `UPDATE payments SET status = $1, updated_at = NOW() WHERE stripe_payment_intent_id = $2 AND status = $3`
+	updatePaymentQuery := `UPDATE payments SET status = $1, paid_at = NOW(), updated_at = NOW() WHERE stripe_payment_intent_id = $2 AND status = $3`
 	tag, err := s.db.Exec(ctx, updatePaymentQuery, string(models.PaymentStatusFailed), pi.ID, string(models.PaymentStatusPending))
 	if err != nil {
 		log.Printf("Webhook Error: Failed updating payment status to failed for PI %s: %v", pi.ID, err)
@@ -393,13 +557,48 @@ func (s *PaymentService) handlePaymentIntentFailed(ctx context.Context, pi *stri
 		log.Printf("Webhook DB Update: Payment status updated to failed for PI %s", pi.ID)
 	}
 
+	releaseQuery := `
+		UPDATE participants SET seat_held_until = NULL, updated_at = NOW()
+		WHERE id = (SELECT participant_id FROM payments WHERE stripe_payment_intent_id = $1) AND status = $2 AND seat_held_until IS NOT NULL
+	`
+	tag, err = s.db.Exec(ctx, releaseQuery, pi.ID, string(models.ParticipantStatusPendingPayment))
+	if err != nil {
+		log.Printf("Webhook Error: Failed releasing seat hold for PI %s: %v", pi.ID, err)
+		return fmt.Errorf("db seat hold release failed: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("Webhook DB Update: Seat hold released for PI %s", pi.ID)
+	}
+
 	log.Printf("Webhook Handling Complete: Successfully processed payment_intent.payment_failed for %s", pi.ID)
 	return nil
 }
 
-// JoinRideAutomatically attempts to join a user to a ride and charge their saved payment method.
-func (s *PaymentService) JoinRideAutomatically(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) error {
+// JoinRideAutomatically attempts to join a user to a ride for the given number of seats and charge
+// their saved payment method for all of them. Unless ignoreConflicts is set, it fails with a
+// *RideConflictError when the user has another ride around the same time.
+// The booking and an intent are committed before the card is charged; the charge is then finalized
+// here, by the payment_intent.succeeded webhook or by ReconcileAutoJoins, and refunded if the booking
+// can't be finalized. ErrAutoJoinPending means the outcome is settled later.
+func (s *PaymentService) JoinRideAutomatically(ctx context.Context, rideID uuid.UUID, userID uuid.UUID, seats int, ignoreConflicts bool) error {
 	log.Printf("Attempting automatic join for user %s on ride %s", userID, rideID)
+	runtime := s.cfg.Runtime() // Snapshot, so a config reload mid-join can't change the charge
+	if !runtime.FeatureEnabled(config.FeatureAutoJoin) {
+		return newError(KindForbidden, "automatic join is temporarily disabled, please join and pay manually")
+	}
+	if seats < 1 || seats > 5 {
+		return newError(KindInvalid, "seats must be between 1 and 5")
+	}
+
+	if err := s.rideService.checkJoinQuota(ctx, userID); err != nil {
+		return err
+	}
+
+	fraudAction := models.FraudActionAllow
+	joinCheck := models.FraudCheck{Event: models.FraudEventJoin, UserID: &userID, RideID: &rideID}
+	if s.fraud != nil {
+		fraudAction = s.fraud.Evaluate(ctx, joinCheck).Action
+	}
 
 	// --- Database Transaction ---
 	tx, err := s.db.Begin(ctx)
@@ -410,10 +609,12 @@ func (s *PaymentService) JoinRideAutomatically(ctx context.Context, rideID uuid.
 	defer tx.Rollback(ctx)
 
 	// --- 1. Validation (using RideService within the transaction) ---
-	_, err = s.rideService.ValidateRideForJoiningTx(ctx, tx, rideID, userID)
+	ride, err := s.rideService.ValidateRideForJoiningTx(ctx, tx, rideID, userID, seats, ignoreConflicts)
 	if err != nil {
 		return err // Validation failed (e.g., full, already joined, etc.)
 	}
+	_, peak := s.rideService.holidays.PeakDay(ride.DepartureDate)
+	amount := seatAmount(runtime, ride.PricePerSeat, peak) * int64(seats)
 
 	// --- 2. Get Stripe Customer ID and Default Payment Method ---
 	var stripeCustomerID sql.NullString
@@ -423,40 +624,59 @@ func (s *PaymentService) JoinRideAutomatically(ctx context.Context, rideID uuid.
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("Automatic Join Error: User %s not found", userID)
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		log.Printf("Automatic Join Error: Failed fetching Stripe details for user %s: %v", userID, err)
 		return fmt.Errorf("database error fetching user details: %w", err)
 	}
 	if !stripeCustomerID.Valid || stripeCustomerID.String == "" {
 		log.Printf("Automatic Join Error: User %s has no Stripe customer ID", userID)
-		return errors.New("user has no Stripe customer ID setup")
+		return newError(KindPaymentRequired, "user has no Stripe customer ID setup")
 	}
 	if !stripeDefaultPaymentMethodID.Valid || stripeDefaultPaymentMethodID.String == "" {
 		log.Printf("Automatic Join Error: User %s has no default payment method ID set", userID)
-		return errors.New("user has no saved default payment method")
+		return newError(KindPaymentRequired, "user has no saved default payment method")
 	}
 	customerID := stripeCustomerID.String
 	paymentMethodID := stripeDefaultPaymentMethodID.String
 	log.Printf("Automatic Join Info: Found Stripe Customer ID %s and PM ID %s for user %s", customerID, paymentMethodID, userID)
 
+	// --- 2b. Fraud rules (join velocity, payment velocity, cards per account, impossible travel) ---
+	paymentCheck := models.FraudCheck{Event: models.FraudEventPayment, UserID: &userID, RideID: &rideID, PaymentMethodID: paymentMethodID}
+	if s.fraud != nil {
+		paymentDecision := s.fraud.Evaluate(ctx, paymentCheck)
+		fraudAction = strongerFraudAction(fraudAction, paymentDecision.Action)
+	}
+	onHold := fraudAction == models.FraudActionHold
+	joinStatus := string(models.ParticipantStatusActive)
+	if onHold {
+		joinStatus = string(models.ParticipantStatusOnHold)
+	}
+
 	// --- 3. Check for existing participation record (especially 'left' status) ---
 	var existingParticipant models.Participant // Declare here
 	checkParticipantQuery := `SELECT id, status FROM participants WHERE user_id = $1 AND ride_id = $2`
 	err = tx.QueryRow(ctx, checkParticipantQuery, userID, rideID).Scan(&existingParticipant.ID, &existingParticipant.Status) // Scan into the declared variable
 
 	var participantIDToUse uuid.UUID
-	var needsPayment bool = true // Assume payment is needed unless rejoining
+	var needsPayment bool // Only new, non-held participants are charged
 
 	if err == nil { // Record found
 		switch existingParticipant.Status {
 		case string(models.ParticipantStatusActive), string(models.ParticipantStatusPendingPayment):
 			log.Printf("Automatic Join Error: User %s already has participation record with status '%s' for ride %s", userID, existingParticipant.Status, rideID)
-			return fmt.Errorf("user already participating with status: %s", existingParticipant.Status)
+			return newError(KindConflict, fmt.Sprintf("user already participating with status: %s", existingParticipant.Status))
+		case string(models.ParticipantStatusOnHold):
+			log.Printf("Automatic Join Error: User %s has a booking on hold for ride %s", userID, rideID)
+			return ErrBookingOnHold
+		case string(models.ParticipantStatusRemoved):
+			log.Printf("Automatic Join Error: User %s was removed from ride %s by its creator", userID, rideID)
+			return ErrRemovedFromRide
 		case string(models.ParticipantStatusLeft):
-			log.Printf("Automatic Join Info: User %s previously left ride %s. Updating status to active.", userID, rideID)
-			updateStatusQuery := `UPDATE participants SET status = $1, updated_at = NOW() WHERE id = $2`
-			_, updateErr := tx.Exec(ctx, updateStatusQuery, string(models.ParticipantStatusActive), existingParticipant.ID)
+			log.Printf("Automatic Join Info: User %s previously left ride %s. Updating status to %s.", userID, rideID, joinStatus)
+			// Automatic joins book the whole route (boarding at the departure, alighting at the arrival)
+			updateStatusQuery := `UPDATE participants SET status = $1, boarding_stop = 0, alighting_stop = NULL, updated_at = NOW() WHERE id = $2`
+			_, updateErr := tx.Exec(ctx, updateStatusQuery, joinStatus, existingParticipant.ID)
 			if updateErr != nil {
 				log.Printf("Automatic Join Error: Failed updating status for rejoining participant %s on ride %s: %v", userID, rideID, updateErr)
 				return fmt.Errorf("failed to update participation status for rejoin: %w", updateErr)
@@ -470,95 +690,446 @@ func (s *PaymentService) JoinRideAutomatically(ctx context.Context, rideID uuid.
 	} else if errors.Is(err, pgx.ErrNoRows) {
 		// No existing record, insert a new one
 		log.Printf("Automatic Join Info: No existing participation found for user %s on ride %s. Inserting new record.", userID, rideID)
+		needsPayment = !onHold // New participant, needs payment (held bookings are charged after review)
 		participant := &models.Participant{
-			ID:     uuid.New(),
-			RideID: rideID,
-			UserID: userID,
-			Status: string(models.ParticipantStatusActive), // Set to Active directly as payment will be attempted now
+			ID:        uuid.New(),
+			RideID:    rideID,
+			UserID:    userID,
+			Status:    joinStatus, // Held for review, or pending until the charge is finalized
+			SeatCount: seats,
 		}
-		insertParticipantQuery := `INSERT INTO participants (id, ride_id, user_id, status) VALUES ($1, $2, $3, $4)`
-		_, insertErr := tx.Exec(ctx, insertParticipantQuery, participant.ID, participant.RideID, participant.UserID, participant.Status)
+		// Charged bookings hold their seats until the intent settles; settling activates or removes them
+		var seatHeldUntil *time.Time
+		if needsPayment {
+			participant.Status = string(models.ParticipantStatusPendingPayment)
+			holdEnd := time.Now().Add(autoJoinSeatHold)
+			seatHeldUntil = &holdEnd
+		}
+		insertParticipantQuery := `INSERT INTO participants (id, ride_id, user_id, status, seat_count, seat_held_until) VALUES ($1, $2, $3, $4, $5, $6)`
+		_, insertErr := tx.Exec(ctx, insertParticipantQuery, participant.ID, participant.RideID, participant.UserID, participant.Status, participant.SeatCount, seatHeldUntil)
 		if insertErr != nil {
 			log.Printf("Automatic Join Error: Failed inserting participant record for user %s, ride %s: %v", userID, rideID, insertErr)
 			var pgErr *pgconn.PgError
 			if errors.As(insertErr, &pgErr) && pgErr.Code == "23505" { // unique_violation
 				log.Printf("Automatic Join Error: Unique constraint violation despite check for user %s, ride %s.", userID, rideID)
-				return errors.New("participation record conflict")
+				return newError(KindConflict, "participation record conflict")
 			}
 			return fmt.Errorf("database error inserting participant: %w", insertErr)
 		}
 		participantIDToUse = participant.ID
-		needsPayment = true // New participant, needs payment
 	} else {
 		// Actual database error during check
 		log.Printf("Automatic Join Error: Failed checking existing participation for user %s, ride %s: %v", userID, rideID, err)
 		return fmt.Errorf("database error checking participation: %w", err)
 	}
 
-	// --- 4. Create and Confirm PaymentIntent (Off-Session) ONLY IF NEEDED ---
-	var pi *stripe.PaymentIntent // Declare pi outside the block
-
+	// --- 4. Record the intent before charging, so a charge is always finalized or refunded ---
+	var intent *autoJoinIntent
 	if needsPayment {
-		piParams := &stripe.PaymentIntentParams{
-			Amount:                stripe.Int64(fixedPaymentAmount),
-			Currency:              stripe.String(paymentCurrency),
-			Customer:              stripe.String(customerID),
-			PaymentMethod:         stripe.String(paymentMethodID),
-			Confirm:               stripe.Bool(true),
-			OffSession:            stripe.Bool(true),
-			ErrorOnRequiresAction: stripe.Bool(true),
+		intent = &autoJoinIntent{
+			userID:          userID,
+			rideID:          rideID,
+			participantID:   participantIDToUse,
+			seats:           seats,
+			amount:          amount,
+			customerID:      customerID,
+			paymentMethodID: paymentMethodID,
+		}
+		if err := recordAutoJoinIntent(ctx, tx, intent); err != nil {
+			log.Printf("Automatic Join Error: Failed recording intent for user %s, ride %s: %v", userID, rideID, err)
+			return err
 		}
-		piParams.AddMetadata("app_user_id", userID.String())
-		piParams.AddMetadata("ride_id", rideID.String())
-		piParams.AddMetadata("charge_type", "automatic_join_new")
+	} else {
+		log.Printf("Automatic Join Info: Skipping Stripe payment for rejoining or held user %s, ride %s", userID, rideID)
+	}
+
+	// --- 5. Commit Transaction (nothing is charged yet) ---
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Printf("Automatic Join Error: Failed to commit transaction for user %s, ride %s: %v", userID, rideID, err)
+		return fmt.Errorf("db transaction commit failed: %w", err)
+	}
+
+	// Held and pending bookings were recorded too, so they count towards the quota and rules like completed ones
+	s.rideService.recordJoin(ctx, userID, joinCheck)
+
+	if onHold {
+		log.Printf("Automatic Join Info: Booking for user %s on ride %s held for fraud review", userID, rideID)
+		return ErrBookingOnHold
+	}
+	if !needsPayment {
+		log.Printf("Automatic Join Success: User %s successfully rejoined ride %s", userID, rideID)
+		s.rideService.publishRideEvent(ctx, RideEventJoined, rideID, userID)
+		s.notifyJoinConfirmed(ctx, participantIDToUse)
+		return nil
+	}
+
+	// --- 6. Charge the saved card (Off-Session), then finalize or undo the booking ---
+	pi, chargeErr := s.chargeAutoJoin(ctx, intent)
+	if err := s.settleAutoJoin(ctx, intent, pi, chargeErr); err != nil {
+		return err
+	}
+	if s.fraud != nil {
+		s.fraud.RecordEvent(ctx, paymentCheck)
+	}
+	log.Printf("Automatic Join Success: User %s successfully joined ride %s (PI %s)", userID, rideID, pi.ID)
+	return nil // Success
+}
+
+// LeaveRide removes the user from the ride and refunds their payment according to
+// the ride's cancellation policy.
+func (s *PaymentService) LeaveRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.LeaveRideResponse, error) {
+	result, err := s.rideService.LeaveRide(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyParticipantLeft(ctx, rideID)
+	if result.RefundPercent == 0 {
+		return result, nil
+	}
+
+	refunded, err := s.RefundRidePayment(ctx, rideID, userID, result.RefundPercent, "participant_left")
+	if err != nil {
+		// The participation is already released; the refund must be retried manually.
+		log.Printf("CRITICAL Error: User %s left ride %s but the %d%% refund failed: %v", userID, rideID, result.RefundPercent, err)
+		result.RefundStatus = "failed"
+		return result, nil
+	}
+	result.RefundAmount = refunded
+	if refunded > 0 {
+		result.RefundStatus = "refunded"
+	}
+	return result, nil
+}
 
-		pi, err = s.stripeClient.CreateAndConfirmPaymentIntent(ctx, piParams)
+// LeaveRideForAccountDeletion removes a user who is deleting their account from a ride they
+// joined. Their payment is refunded in full rather than per the cancellation policy, and the
+// creator is notified as for LeaveRide.
+func (s *PaymentService) LeaveRideForAccountDeletion(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.LeaveRideResponse, error) {
+	result, err := s.rideService.LeaveRide(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyParticipantLeft(ctx, rideID)
+	if result.PreviousStatus != string(models.ParticipantStatusActive) {
+		return result, nil
+	}
+
+	result.RefundPercent = 100
+	refunded, err := s.RefundRidePayment(ctx, rideID, userID, 100, "account_deleted")
+	if err != nil {
+		log.Printf("CRITICAL Error: User %s left ride %s on account deletion but the refund failed: %v", userID, rideID, err)
+		result.RefundStatus = "failed"
+		return result, nil
+	}
+	result.RefundAmount = refunded
+	if refunded > 0 {
+		result.RefundStatus = "refunded"
+	}
+	return result, nil
+}
+
+// RemoveParticipant removes a participant at the creator's request, fully refunds their payment
+// and notifies them with the creator's reason. A failed refund is reported, not returned as an error.
+func (s *PaymentService) RemoveParticipant(ctx context.Context, rideID uuid.UUID, creatorID uuid.UUID, participantUserID uuid.UUID, reason string) (*models.RemoveParticipantResponse, error) {
+	result, err := s.rideService.RemoveParticipant(ctx, rideID, creatorID, participantUserID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.PreviousStatus == string(models.ParticipantStatusActive) {
+		// Removals are not the participant's choice, so they are always fully refunded
+		refunded, err := s.RefundRidePayment(ctx, rideID, participantUserID, 100, "removed_by_creator")
 		if err != nil {
-			log.Printf("Automatic Join Error: Stripe PaymentIntent creation/confirmation failed for user %s, ride %s: %v", userID, rideID, err)
-			// Rollback should happen automatically due to defer tx.Rollback(ctx)
-			return fmt.Errorf("payment failed: %w", err)
+			log.Printf("CRITICAL Error: User %s was removed from ride %s but the refund failed: %v", participantUserID, rideID, err)
+			result.RefundStatus = "failed"
+		} else if refunded > 0 {
+			result.RefundAmount = refunded
+			result.RefundStatus = "refunded"
 		}
+	}
 
-		if pi.Status != stripe.PaymentIntentStatusSucceeded {
-			log.Printf("Automatic Join Error: PaymentIntent status is %s, expected succeeded for user %s, ride %s, PI %s", pi.Status, userID, rideID, pi.ID)
-			// Rollback should happen automatically
-			return fmt.Errorf("payment confirmation failed with status: %s", pi.Status)
+	s.notifications.Notify(ctx, participantUserID, models.NotificationEventParticipantRemoved, &rideID,
+		"Removed from a ride", fmt.Sprintf("The driver removed you from the ride %s. Reason: %s", result.Route, reason))
+	s.notifications.Email(participantUserID, "participant_removed", map[string]string{"Route": result.Route, "Reason": reason})
+	return result, nil
+}
+
+// CancelRide cancels the ride, refunds every active participant the share the refund policy
+// engine computes for the initiator, and notifies all affected participants. A failed refund is
+// reported per participant and does not stop the others. allowDeparted is passed through to
+// RideService.CancelRide for support overrides.
+func (s *PaymentService) CancelRide(ctx context.Context, rideID uuid.UUID, initiator RefundInitiator, allowDeparted bool, reason string) (*models.CancelRideResponse, error) {
+	return s.cancelRide(ctx, rideID, uuid.Nil, initiator, allowDeparted, reason)
+}
+
+// CancelOwnRide handles a creator cancelling their ride: like CancelRide, with the creator's
+// refund terms, after checking that userID created the ride.
+func (s *PaymentService) CancelOwnRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.CancelRideResponse, error) {
+	log.Printf("User %s cancelling ride %s", userID, rideID)
+	return s.cancelRide(ctx, rideID, userID, RefundInitiatorCreator, false, "ride_cancelled_by_creator")
+}
+
+// cancelRide cancels the ride (checking its creator unless creatorID is uuid.Nil), then refunds and notifies participants.
+func (s *PaymentService) cancelRide(ctx context.Context, rideID uuid.UUID, creatorID uuid.UUID, initiator RefundInitiator, allowDeparted bool, reason string) (*models.CancelRideResponse, error) {
+	result, err := s.rideService.CancelRide(ctx, rideID, creatorID, initiator, allowDeparted)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Participants {
+		participation := &result.Participants[i]
+		if participation.PreviousStatus == string(models.ParticipantStatusActive) && result.RefundPercent > 0 {
+			refunded, err := s.RefundRidePayment(ctx, rideID, participation.UserID, result.RefundPercent, reason)
+			if err != nil {
+				log.Printf("CRITICAL Error: Ride %s cancelled but refunding user %s failed: %v", rideID, participation.UserID, err)
+				participation.RefundStatus = "failed"
+			} else if refunded > 0 {
+				participation.RefundAmount = refunded
+				participation.RefundStatus = "refunded"
+			}
 		}
-		log.Printf("Automatic Join Info: Stripe PaymentIntent %s succeeded for user %s, ride %s", pi.ID, userID, rideID)
-
-		// --- 5. Insert payment record ONLY IF payment was made ---
-		payment := &models.Payment{
-			ID:                    uuid.New(),
-			UserID:                userID,
-			RideID:                rideID,
-			ParticipantID:         &participantIDToUse,
-			StripePaymentIntentID: pi.ID,
-			Status:                models.PaymentStatusSucceeded,
-			Amount:                fixedPaymentAmount,
-			Currency:              paymentCurrency,
+
+		s.notifications.Notify(ctx, participation.UserID, models.NotificationEventRideCancelled, &rideID,
+			"Ride cancelled", fmt.Sprintf("The ride %s has been cancelled.", result.Route))
+		s.notifications.Email(participation.UserID, "ride_cancelled", map[string]string{"Route": result.Route})
+	}
+	return result, nil
+}
+
+// notifyParticipantLeft tells the ride creator that a participant left.
+func (s *PaymentService) notifyParticipantLeft(ctx context.Context, rideID uuid.UUID) {
+	var creatorID uuid.UUID
+	var routeName string
+	query := `SELECT user_id, departure_location_name || ' → ' || arrival_location_name FROM rides WHERE id = $1`
+	if err := s.db.QueryRow(ctx, query, rideID).Scan(&creatorID, &routeName); err != nil {
+		log.Printf("Notification Error: Failed loading ride %s for leave notification: %v", rideID, err)
+		return
+	}
+	s.notifications.Notify(ctx, creatorID, models.NotificationEventParticipantLeft, &rideID,
+		"A passenger left", fmt.Sprintf("A passenger left your ride %s. The seat is available again.", routeName))
+}
+
+// RefundRidePayment refunds 'percent' of the user's succeeded payment for a ride through Stripe
+// and records the refunded amount. It returns the amount refunded by this call (0 if nothing was due).
+// The payment row stays locked until the refund is recorded, so concurrent or retried calls (leave,
+// removal, cancellation, transfer) can't refund the same share twice.
+func (s *PaymentService) RefundRidePayment(ctx context.Context, rideID uuid.UUID, userID uuid.UUID, percent int, reason string) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var paymentID uuid.UUID
+	var paymentIntentID string
+	var amount, refundedAmount int64
+	query := `
+		SELECT id, stripe_payment_intent_id, amount, refunded_amount
+		FROM payments
+		WHERE ride_id = $1 AND user_id = $2 AND status = $3
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, query, rideID, userID, string(models.PaymentStatusSucceeded)).Scan(&paymentID, &paymentIntentID, &amount, &refundedAmount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Refund Info: No succeeded payment found for user %s on ride %s, nothing to refund", userID, rideID)
+			return 0, nil
 		}
-		insertPaymentQuery := `INSERT INTO payments (id, user_id, ride_id, participant_id, stripe_payment_intent_id, status, amount, currency) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-		_, err = tx.Exec(ctx, insertPaymentQuery, payment.ID, payment.UserID, payment.RideID, payment.ParticipantID, payment.StripePaymentIntentID, payment.Status, payment.Amount, payment.Currency)
-		if err != nil {
-			log.Printf("Automatic Join Error: Failed inserting payment record for user %s, ride %s, PI %s: %v", userID, rideID, pi.ID, err)
-			// Rollback should happen automatically
-			return fmt.Errorf("database error inserting payment: %w", err)
+		log.Printf("Refund Error: Failed fetching payment for user %s on ride %s: %v", userID, rideID, err)
+		return 0, fmt.Errorf("database error fetching payment for refund: %w", err)
+	}
+
+	refundAmount := amount*int64(percent)/100 - refundedAmount
+	if refundAmount <= 0 {
+		log.Printf("Refund Info: Payment %s already refunded up to %d%%, nothing more to refund", paymentID, percent)
+		return 0, nil
+	}
+
+	return s.issueRefund(ctx, tx, paymentID, paymentIntentID, rideID, userID, refundedAmount, refundAmount, reason)
+}
+
+// markRefundPending records on the user's succeeded payment for the ride that a refund is owed,
+// within tx, so RetryPendingRefunds issues it if the immediate attempt fails.
+func (s *PaymentService) markRefundPending(ctx context.Context, tx pgx.Tx, rideID uuid.UUID, userID uuid.UUID, percent int, reason string) error {
+	query := `
+		UPDATE payments SET refund_pending_percent = $3, refund_pending_reason = $4, updated_at = NOW()
+		WHERE ride_id = $1 AND user_id = $2 AND status = $5
+	`
+	if _, err := tx.Exec(ctx, query, rideID, userID, percent, reason, string(models.PaymentStatusSucceeded)); err != nil {
+		return fmt.Errorf("database error recording pending refund: %w", err)
+	}
+	return nil
+}
+
+// refundPendingPayment issues the refund owed by markRefundPending and clears the mark.
+func (s *PaymentService) refundPendingPayment(ctx context.Context, rideID uuid.UUID, userID uuid.UUID, percent int, reason string) (int64, error) {
+	refunded, err := s.RefundRidePayment(ctx, rideID, userID, percent, reason)
+	if err != nil {
+		return 0, err
+	}
+	clearQuery := `UPDATE payments SET refund_pending_percent = NULL, refund_pending_reason = NULL WHERE ride_id = $1 AND user_id = $2 AND refund_pending_percent IS NOT NULL`
+	if _, err := s.db.Exec(ctx, clearQuery, rideID, userID); err != nil {
+		// The refund itself went through; a later retry finds nothing left to refund
+		log.Printf("Refund Warning: Failed clearing pending refund of user %s on ride %s: %v", userID, rideID, err)
+	}
+	return refunded, nil
+}
+
+// StartRefundRetries retries pending refunds and settles pending automatic joins (ReconcileAutoJoins)
+// every cfg.RefundRetryInterval in the background.
+func (s *PaymentService) StartRefundRetries() {
+	if s.cfg.RefundRetryInterval <= 0 {
+		log.Println("Refund retry job disabled (REFUND_RETRY_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.RefundRetryInterval)
+		defer ticker.Stop()
+		for {
+			if retried, err := s.RetryPendingRefunds(context.Background()); err != nil {
+				log.Printf("Warning: Refund retry job failed: %v", err)
+			} else if retried > 0 {
+				log.Printf("Refund retry job issued %d pending refunds", retried)
+			}
+			if settled, err := s.ReconcileAutoJoins(context.Background()); err != nil {
+				log.Printf("Warning: Automatic join reconciliation failed: %v", err)
+			} else if settled > 0 {
+				log.Printf("Automatic join reconciliation settled %d intents", settled)
+			}
+			<-ticker.C
 		}
-		log.Printf("Automatic Join Info: Payment record inserted for user %s, ride %s, PI %s", userID, rideID, pi.ID)
+	}()
+}
 
-	} else {
-		log.Printf("Automatic Join Info: Skipping Stripe payment and payment record insertion for rejoining user %s, ride %s", userID, rideID)
+// RetryPendingRefunds issues the refunds marked pending, oldest first. A refund that fails again
+// stays pending for the next run. It returns the number of refunds issued.
+func (s *PaymentService) RetryPendingRefunds(ctx context.Context) (int, error) {
+	type pendingRefund struct {
+		rideID, userID uuid.UUID
+		percent        int
+		reason         string
+	}
+	query := `
+		SELECT ride_id, user_id, refund_pending_percent, refund_pending_reason
+		FROM payments
+		WHERE refund_pending_percent IS NOT NULL
+		ORDER BY updated_at ASC
+		LIMIT 100
+	`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("database error listing pending refunds: %w", err)
+	}
+	var pending []pendingRefund
+	for rows.Next() {
+		var p pendingRefund
+		if err := rows.Scan(&p.rideID, &p.userID, &p.percent, &p.reason); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error processing pending refunds: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("database iteration error for pending refunds: %w", err)
 	}
 
-	// --- 6. Commit Transaction ---
-	err = tx.Commit(ctx)
+	retried := 0
+	for _, p := range pending {
+		if _, err := s.refundPendingPayment(ctx, p.rideID, p.userID, p.percent, p.reason); err != nil {
+			log.Printf("Refund Warning: Pending refund of user %s on ride %s failed again: %v", p.userID, p.rideID, err)
+			continue
+		}
+		retried++
+	}
+	return retried, nil
+}
+
+// refundIdempotencyKey identifies one refund step of a payment for Stripe. Retrying the same step
+// (same payment, reason and amount already refunded) reuses the key, so Stripe refunds it only once.
+func refundIdempotencyKey(paymentID uuid.UUID, reason string, refundedAmount int64) string {
+	return fmt.Sprintf("refund-%s-%s-%d", paymentID, reason, refundedAmount)
+}
+
+// issueRefund creates a Stripe refund for a payment locked by tx, records the refunded amount
+// and commits; the payment becomes 'refunded' once fully refunded.
+func (s *PaymentService) issueRefund(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID, paymentIntentID string, rideID uuid.UUID, userID uuid.UUID, refundedAmount int64, refundAmount int64, reason string) (int64, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Amount:        stripe.Int64(refundAmount),
+	}
+	params.SetIdempotencyKey(refundIdempotencyKey(paymentID, reason, refundedAmount))
+	params.AddMetadata("payment_id", paymentID.String())
+	params.AddMetadata("ride_id", rideID.String())
+	params.AddMetadata("app_user_id", userID.String())
+	params.AddMetadata("reason", reason)
+
+	refund, err := s.stripeClient.CreateRefund(ctx, params)
 	if err != nil {
-		log.Printf("Automatic Join Error: Failed to commit transaction for user %s, ride %s: %v", userID, rideID, err)
-		// Critical: Payment might have succeeded but DB update failed if needsPayment was true.
-		// If needsPayment was false, commit failure is less critical but still an issue.
-		return fmt.Errorf("critical error: failed to finalize participation records")
+		log.Printf("Refund Error: Stripe refund failed for payment %s (PI %s): %v", paymentID, paymentIntentID, err)
+		return 0, fmt.Errorf("failed to create refund with Stripe: %w", err)
 	}
+	log.Printf("Refund Info: Stripe refund %s created for payment %s (%d %s)", refund.ID, paymentID, refundAmount, paymentCurrency)
 
-	log.Printf("Automatic Join Success: User %s successfully joined/rejoined ride %s", userID, rideID)
-	return nil // Success
+	updateQuery := `
+		UPDATE payments
+		SET refunded_amount = refunded_amount + $1,
+		    status = CASE WHEN refunded_amount + $1 >= amount THEN $2 ELSE status END,
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err = tx.Exec(ctx, updateQuery, refundAmount, string(models.PaymentStatusRefunded), paymentID)
+	if err == nil {
+		// Refund ledger for accounting exports; a retried step gets the same refund back from Stripe
+		ledgerQuery := `INSERT INTO payment_refunds (payment_id, stripe_refund_id, amount, reason) VALUES ($1, $2, $3, $4) ON CONFLICT (stripe_refund_id) DO NOTHING`
+		_, err = tx.Exec(ctx, ledgerQuery, paymentID, refund.ID, refundAmount, reason)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		// Stripe already moved the money; surface loudly so the row can be reconciled.
+		// A retry of the same step reuses the idempotency key and won't refund twice.
+		log.Printf("CRITICAL Error: Refund %s succeeded but updating payment %s failed: %v", refund.ID, paymentID, err)
+		return refundAmount, fmt.Errorf("refund issued but failed to record it: %w", err)
+	}
+	return refundAmount, nil
+}
+
+// RefundPayment refunds part or all of a succeeded payment, or of one under review, identified by
+// its ID. A nil amount refunds everything not yet refunded.
+func (s *PaymentService) RefundPayment(ctx context.Context, paymentID uuid.UUID, amount *int64, reason string) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var paymentIntentID, status string
+	var rideID, userID uuid.UUID
+	var paidAmount, refundedAmount int64
+	query := `SELECT stripe_payment_intent_id, ride_id, user_id, status, amount, refunded_amount FROM payments WHERE id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, query, paymentID).Scan(&paymentIntentID, &rideID, &userID, &status, &paidAmount, &refundedAmount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, newError(KindNotFound, "payment not found")
+		}
+		return 0, fmt.Errorf("database error fetching payment for refund: %w", err)
+	}
+	if status != string(models.PaymentStatusSucceeded) && status != string(models.PaymentStatusUnderReview) {
+		return 0, newError(KindConflict, fmt.Sprintf("payment cannot be refunded in status: %s", status))
+	}
+
+	remaining := paidAmount - refundedAmount
+	refundAmount := remaining
+	if amount != nil {
+		refundAmount = *amount
+	}
+	if refundAmount <= 0 || refundAmount > remaining {
+		return 0, newError(KindInvalid, fmt.Sprintf("refund amount must be between 1 and %d", remaining))
+	}
+	return s.issueRefund(ctx, tx, paymentID, paymentIntentID, rideID, userID, refundedAmount, refundAmount, reason)
 }
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+
+	"rideshare/backend/config"
+)
+
+// Test that a retried refund step reuses its Stripe idempotency key and later steps get new ones
+func TestRefundIdempotencyKey(t *testing.T) {
+	paymentID := uuid.MustParse("6f1c2d4e-1a2b-4c3d-8e9f-0a1b2c3d4e5f")
+	first := refundIdempotencyKey(paymentID, "participant_left", 0)
+	if retry := refundIdempotencyKey(paymentID, "participant_left", 0); retry != first {
+		t.Errorf("retried refund key = %q, want %q", retry, first)
+	}
+	if next := refundIdempotencyKey(paymentID, "participant_left", 100); next == first {
+		t.Errorf("refund after a partial refund reused key %q", next)
+	}
+	if other := refundIdempotencyKey(paymentID, "removed_by_creator", 0); other == first {
+		t.Errorf("refund for another reason reused key %q", other)
+	}
+}
+
+// Test that joins charge the ride's seat price, and the booking fee (peak fee on peak days) for rides without one
+func TestSeatAmount(t *testing.T) {
+	runtime := &config.RuntimeSettings{BookingFeeCents: 200, PeakFeeCents: 300}
+	price := int64(1250)
+	if got := seatAmount(runtime, &price, true); got != 1250 {
+		t.Errorf("seatAmount(1250) = %d, want 1250", got)
+	}
+	if got := seatAmount(runtime, nil, false); got != 200 {
+		t.Errorf("seatAmount(nil) = %d, want the booking fee 200", got)
+	}
+	if got := seatAmount(runtime, nil, true); got != 300 {
+		t.Errorf("seatAmount(nil) on a peak day = %d, want the peak fee 300", got)
+	}
+	if got := seatAmount(&config.RuntimeSettings{BookingFeeCents: 200}, nil, true); got != 200 {
+		t.Errorf("seatAmount(nil) on a peak day without a peak fee = %d, want the booking fee 200", got)
+	}
+}
+
+// Test that succeeded PaymentIntents only match payments of the same amount and currency
+func TestPaymentIntentMismatch(t *testing.T) {
+	pi := &stripe.PaymentIntent{AmountReceived: 1250, Currency: "eur"}
+	if paymentIntentMismatch(pi, 1250, "EUR") {
+		t.Error("paymentIntentMismatch(same amount and currency) = true, want false")
+	}
+	if !paymentIntentMismatch(pi, 2500, "eur") {
+		t.Error("paymentIntentMismatch(other amount) = false, want true")
+	}
+	if !paymentIntentMismatch(pi, 1250, "usd") {
+		t.Error("paymentIntentMismatch(other currency) = false, want true")
+	}
+}
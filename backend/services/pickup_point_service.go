@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strconv" // For parsing coordinates
+	"strings" // For parsing coordinates
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Defaults for pickup point suggestions.
+const (
+	defaultPickupRadiusMeters = 3000
+	defaultPickupLimit        = 10
+	// maxPickupDistanceMeters is how far from a ride's departure its official pickup point may be.
+	maxPickupDistanceMeters = 5000
+)
+
+// PickupPointService suggests curated meeting spots (park-and-rides, stations) near a location.
+type PickupPointService struct {
+	validator *validator.Validate
+	db        database.DBPool
+}
+
+// NewPickupPointService creates a new PickupPointService instance.
+func NewPickupPointService(db database.DBPool) *PickupPointService {
+	return &PickupPointService{
+		validator: NewValidator(),
+		db:        db,
+	}
+}
+
+// ParseCoordinates parses a "latitude,longitude" pair.
+func ParseCoordinates(value string) (models.GeoPoint, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return models.GeoPoint{}, newError(KindInvalid, "coordinates must be formatted as latitude,longitude")
+	}
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return models.GeoPoint{}, newError(KindInvalid, "invalid latitude or longitude")
+	}
+
+	return models.GeoPoint{Latitude: lat, Longitude: lon}, nil
+}
+
+// NearbyPickupPoints returns the active pickup points closest to the requested location.
+func (s *PickupPointService) NearbyPickupPoints(ctx context.Context, params models.NearbyPickupPointsRequest) ([]models.PickupPoint, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid pickup point search: %w", err)
+	}
+	near, err := ParseCoordinates(params.Near)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validator.Struct(near); err != nil {
+		return nil, fmt.Errorf("invalid pickup point search: %w", err)
+	}
+	radius, limit := defaultPickupRadiusMeters, defaultPickupLimit
+	if params.RadiusMeters != nil {
+		radius = *params.RadiusMeters
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	query := `
+		SELECT id, name, kind, ST_X(location), ST_Y(location), city, created_at,
+		       ST_Distance(location::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography) AS distance
+		FROM pickup_points
+		WHERE is_active
+		  AND ST_DWithin(location::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, $3)
+		ORDER BY distance ASC
+		LIMIT $4
+	`
+	rows, err := s.db.Query(ctx, query, near.Longitude, near.Latitude, radius, limit)
+	if err != nil {
+		log.Printf("Error querying pickup points near %f,%f: %v", near.Latitude, near.Longitude, err)
+		return nil, fmt.Errorf("database error fetching pickup points: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.PickupPoint{}
+	for rows.Next() {
+		var point models.PickupPoint
+		var distance float64
+		if err := rows.Scan(&point.ID, &point.Name, &point.Kind, &point.Location.Longitude, &point.Location.Latitude, &point.City, &point.CreatedAt, &distance); err != nil {
+			log.Printf("Error scanning pickup point row: %v", err)
+			return nil, fmt.Errorf("error processing pickup point data: %w", err)
+		}
+		point.DistanceMeters = &distance
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for pickup points: %w", err)
+	}
+	return points, nil
+}
+
+// CreatePickupPoint adds a curated pickup point.
+func (s *PickupPointService) CreatePickupPoint(ctx context.Context, req models.CreatePickupPointRequest) (*models.PickupPoint, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid pickup point: %w", err)
+	}
+	point := models.PickupPoint{Name: strings.TrimSpace(req.Name), Kind: req.Kind, Location: *req.Location, City: req.City}
+	query := `
+		INSERT INTO pickup_points (name, kind, location, city)
+		VALUES ($1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326), $5)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRow(ctx, query, point.Name, point.Kind, point.Location.Longitude, point.Location.Latitude, point.City).Scan(&point.ID, &point.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating pickup point %q: %v", point.Name, err)
+		return nil, fmt.Errorf("database error creating pickup point: %w", err)
+	}
+	log.Printf("Pickup point %s (%s) created", point.ID, point.Name)
+	return &point, nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test that "latitude,longitude" pairs are parsed and out-of-range or malformed values rejected
+func TestParseCoordinates(t *testing.T) {
+	point, err := ParseCoordinates("48.8443, 2.3744")
+	if err != nil || point.Latitude != 48.8443 || point.Longitude != 2.3744 {
+		t.Errorf("ParseCoordinates() = %+v, %v, want lat 48.8443 lon 2.3744", point, err)
+	}
+
+	for _, value := range []string{"", "48.8", "91,2", "48.8,181", "north,east", "1,2,3"} {
+		_, err := ParseCoordinates(value)
+		var serviceErr *Error
+		if !errors.As(err, &serviceErr) || serviceErr.Kind != KindInvalid {
+			t.Errorf("ParseCoordinates(%q) error = %v, want KindInvalid", value, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/database"
+)
+
+// piiEncryptionBatchSize bounds the users loaded per batch.
+const piiEncryptionBatchSize = 500
+
+// PIIEncryptionJob encrypts the PII columns of users written before field encryption and, after
+// a key rotation, re-wraps values whose data key is wrapped by an older key-encryption key.
+// Once it has run, retired keys can be removed from FIELD_ENCRYPTION_KEYS.
+type PIIEncryptionJob struct {
+	db     database.DBPool
+	crypto *FieldEncryptor
+}
+
+// NewPIIEncryptionJob creates a new PIIEncryptionJob instance.
+func NewPIIEncryptionJob(db database.DBPool, crypto *FieldEncryptor) *PIIEncryptionJob {
+	return &PIIEncryptionJob{db: db, crypto: crypto}
+}
+
+// Start runs the job once in the background.
+func (j *PIIEncryptionJob) Start() {
+	go func() {
+		updated, err := j.Run(context.Background())
+		if err != nil {
+			log.Printf("Warning: PII encryption job failed after %d users: %v", updated, err)
+		} else if updated > 0 {
+			log.Printf("PII encryption job encrypted or re-wrapped the data of %d users", updated)
+		}
+	}()
+}
+
+// piiRow is a user whose PII still has plaintext or values under an older key.
+type piiRow struct {
+	id                                   uuid.UUID
+	whatsapp, whatsappEncrypted          *string
+	birthDate, birthDateEncrypted        *string
+	lastKnownLocation, locationEncrypted *string
+}
+
+// Run walks all users that need work, in id order, and returns how many were updated. A user
+// whose values can't be processed (e.g. their key is missing) is logged and skipped.
+func (j *PIIEncryptionJob) Run(ctx context.Context) (int, error) {
+	query := `
+		SELECT id, whatsapp, whatsapp_encrypted, birth_date::text, birth_date_encrypted,
+			ST_Y(last_known_location::geometry) || ',' || ST_X(last_known_location::geometry), last_known_location_encrypted
+		FROM users
+		WHERE id > $1 AND erased_at IS NULL
+			AND (whatsapp IS NOT NULL OR birth_date IS NOT NULL OR last_known_location IS NOT NULL
+				OR whatsapp_encrypted NOT LIKE $2 || '%'
+				OR birth_date_encrypted NOT LIKE $2 || '%'
+				OR last_known_location_encrypted NOT LIKE $2 || '%')
+		ORDER BY id
+		LIMIT $3
+	`
+	updateQuery := `
+		UPDATE users SET
+			whatsapp_encrypted = $2, whatsapp_hash = COALESCE($3, whatsapp_hash), whatsapp = NULL,
+			birth_date_encrypted = $4, birth_date = NULL,
+			last_known_location_encrypted = $5, last_known_location = NULL,
+			last_known_geohash = LEFT(last_known_geohash, $6)
+		WHERE id = $1
+	`
+
+	updated := 0
+	lastID := uuid.Nil
+	for {
+		rows, err := j.db.Query(ctx, query, lastID, j.crypto.ActiveKeyPrefix(), piiEncryptionBatchSize)
+		if err != nil {
+			return updated, fmt.Errorf("database error listing users to encrypt: %w", err)
+		}
+		var batch []piiRow
+		for rows.Next() {
+			var row piiRow
+			if err := rows.Scan(&row.id, &row.whatsapp, &row.whatsappEncrypted, &row.birthDate, &row.birthDateEncrypted,
+				&row.lastKnownLocation, &row.locationEncrypted); err != nil {
+				rows.Close()
+				return updated, fmt.Errorf("error processing users to encrypt: %w", err)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, fmt.Errorf("database error iterating users to encrypt: %w", err)
+		}
+
+		for _, row := range batch {
+			lastID = row.id
+			whatsapp, birthDate, location, err := j.encryptRow(row)
+			if err != nil {
+				log.Printf("Warning: Skipping PII encryption of user %s: %v", row.id, err)
+				continue
+			}
+			// New WhatsApp ciphertexts get their blind index; re-wrapped ones keep theirs
+			var whatsappHash *string
+			if row.whatsappEncrypted == nil && row.whatsapp != nil {
+				hash := j.crypto.BlindIndex(*row.whatsapp)
+				whatsappHash = &hash
+			}
+			if _, err := j.db.Exec(ctx, updateQuery, row.id, whatsapp, whatsappHash, birthDate, location, userLocationGeohashPrecision); err != nil {
+				return updated, fmt.Errorf("database error encrypting user %s: %w", row.id, err)
+			}
+			updated++
+		}
+		if len(batch) < piiEncryptionBatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// encryptRow returns the row's values under the active key. Encrypted values take precedence
+// over leftover plaintext.
+func (j *PIIEncryptionJob) encryptRow(row piiRow) (whatsapp, birthDate, location *string, err error) {
+	if whatsapp, err = j.encryptOrRewrap(row.whatsappEncrypted, row.whatsapp); err != nil {
+		return nil, nil, nil, fmt.Errorf("whatsapp: %w", err)
+	}
+	if birthDate, err = j.encryptOrRewrap(row.birthDateEncrypted, row.birthDate); err != nil {
+		return nil, nil, nil, fmt.Errorf("birth date: %w", err)
+	}
+	if location, err = j.encryptOrRewrap(row.locationEncrypted, row.lastKnownLocation); err != nil {
+		return nil, nil, nil, fmt.Errorf("location: %w", err)
+	}
+	return whatsapp, birthDate, location, nil
+}
+
+// encryptOrRewrap re-wraps an encrypted value or encrypts a plaintext one (nil if neither is set).
+func (j *PIIEncryptionJob) encryptOrRewrap(encrypted, plaintext *string) (*string, error) {
+	var value string
+	var err error
+	switch {
+	case encrypted != nil:
+		value, err = j.crypto.Rewrap(*encrypted)
+	case plaintext != nil:
+		value, err = j.crypto.Encrypt(*plaintext)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
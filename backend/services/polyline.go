@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"  // For decode errors
+	"math"    // For rounding
+	"strings" // For building the encoding
+
+	"rideshare/backend/models"
+)
+
+// EncodePolyline encodes points in the Google encoded polyline format (precision 5),
+// which map providers and client map SDKs accept directly.
+func EncodePolyline(points []models.GeoPoint) string {
+	var encoded strings.Builder
+	prevLat, prevLon := 0, 0
+	for _, point := range points {
+		lat := int(math.Round(point.Latitude * 1e5))
+		lon := int(math.Round(point.Longitude * 1e5))
+		encodePolylineValue(&encoded, lat-prevLat)
+		encodePolylineValue(&encoded, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return encoded.String()
+}
+
+// encodePolylineValue appends one signed delta as 5-bit chunks.
+func encodePolylineValue(encoded *strings.Builder, value int) {
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+	for shifted >= 0x20 {
+		encoded.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+	encoded.WriteByte(byte(shifted + 63))
+}
+
+// DecodePolyline decodes a Google encoded polyline (precision 5).
+func DecodePolyline(encoded string) ([]models.GeoPoint, error) {
+	points := []models.GeoPoint{}
+	lat, lon := 0, 0
+	for i := 0; i < len(encoded); {
+		var deltas [2]int
+		for d := range deltas {
+			result, shift := 0, 0
+			for {
+				if i >= len(encoded) {
+					return nil, errors.New("truncated polyline")
+				}
+				b := int(encoded[i]) - 63
+				i++
+				if b < 0 {
+					return nil, errors.New("invalid polyline character")
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[d] = ^(result >> 1)
+			} else {
+				deltas[d] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lon += deltas[1]
+		points = append(points, models.GeoPoint{Latitude: float64(lat) / 1e5, Longitude: float64(lon) / 1e5})
+	}
+	return points, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"rideshare/backend/models"
+)
+
+// Test the polyline encoding against the reference example of the format documentation
+func TestPolyline_RoundTrip(t *testing.T) {
+	points := []models.GeoPoint{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	const want = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+	if got := EncodePolyline(points); got != want {
+		t.Errorf("EncodePolyline() = %s, want %s", got, want)
+	}
+
+	decoded, err := DecodePolyline(want)
+	if err != nil {
+		t.Fatalf("DecodePolyline() error = %v", err)
+	}
+	if len(decoded) != len(points) {
+		t.Fatalf("DecodePolyline() returned %d points, want %d", len(decoded), len(points))
+	}
+	for i := range points {
+		if math.Abs(decoded[i].Latitude-points[i].Latitude) > 1e-9 || math.Abs(decoded[i].Longitude-points[i].Longitude) > 1e-9 {
+			t.Errorf("point %d = %+v, want %+v", i, decoded[i], points[i])
+		}
+	}
+
+	if _, err := DecodePolyline("_p~iF~ps|U_"); err == nil {
+		t.Error("DecodePolyline() of a truncated polyline returned no error")
+	}
+}
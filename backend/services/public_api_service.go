@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"       // For database calls
+	"crypto/rand"   // For generating keys
+	"crypto/sha256" // For hashing keys
+	"encoding/hex"  // For key encoding
+	"errors"        // For error checks
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"math"          // For coordinate rounding
+	"strings"       // For key parsing
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// apiKeyPrefix starts every public API key, so leaked keys are easy to spot.
+const apiKeyPrefix = "rsk_"
+
+// publicCoordinatePrecision is the number of decimals kept on public coordinates (~1.1 km).
+const publicCoordinatePrecision = 2
+
+// PublicAPIService issues scoped API keys and serves the anonymized public data API.
+type PublicAPIService struct {
+	validator *validator.Validate
+	db        database.DBPool
+	audit     *AuditService
+
+	limiter *RateLimiter // Per-key request counters
+}
+
+// NewPublicAPIService creates a new PublicAPIService instance.
+func NewPublicAPIService(db database.DBPool, audit *AuditService) *PublicAPIService {
+	return &PublicAPIService{
+		validator: NewValidator(),
+		db:        db,
+		audit:     audit,
+		limiter:   NewRateLimiter(),
+	}
+}
+
+// hashAPIKey returns the stored form of a key.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a new key. The plaintext key is returned once and only its hash is stored.
+func (s *PublicAPIService) CreateAPIKey(ctx context.Context, adminID uuid.UUID, req models.CreateAPIKeyRequest, ip string) (*models.CreateAPIKeyResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid API key request: %w", err)
+	}
+	if req.RateLimitPerMinute == 0 {
+		req.RateLimitPerMinute = 60
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+
+	resp := &models.CreateAPIKeyResponse{Key: key}
+	resp.Name = req.Name
+	resp.KeyPrefix = key[:len(apiKeyPrefix)+6]
+	resp.Scopes = req.Scopes
+	resp.RateLimitPerMinute = req.RateLimitPerMinute
+	resp.CreatedBy = &adminID
+	query := `
+		INSERT INTO api_keys (name, key_hash, key_prefix, scopes, rate_limit_per_minute, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRow(ctx, query, resp.Name, hashAPIKey(key), resp.KeyPrefix, resp.Scopes, resp.RateLimitPerMinute, adminID).Scan(&resp.ID, &resp.CreatedAt)
+	if err != nil {
+		log.Printf("Error creating API key %q: %v", req.Name, err)
+		return nil, fmt.Errorf("database error creating API key: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionAPIKeyCreated,
+		TargetType: "api_key",
+		TargetID:   resp.ID.String(),
+		IPAddress:  ip,
+		Metadata:   map[string]interface{}{"name": resp.Name, "scopes": resp.Scopes},
+	})
+	log.Printf("API key %s (%s) created by admin %s", resp.ID, resp.Name, adminID)
+	return resp, nil
+}
+
+// ListAPIKeys returns all keys, newest first, without their secrets.
+func (s *PublicAPIService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, rate_limit_per_minute, created_by, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at DESC
+	`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		log.Printf("Error listing API keys: %v", err)
+		return nil, fmt.Errorf("database error listing API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.ID, &key.Name, &key.KeyPrefix, &key.Scopes, &key.RateLimitPerMinute, &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error processing API key data: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey rejects a key from now on.
+func (s *PublicAPIService) RevokeAPIKey(ctx context.Context, adminID, keyID uuid.UUID, ip string) error {
+	tag, err := s.db.Exec(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, keyID)
+	if err != nil {
+		log.Printf("Error revoking API key %s: %v", keyID, err)
+		return fmt.Errorf("database error revoking API key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return newError(KindNotFound, "API key not found or already revoked")
+	}
+	_ = s.audit.Record(ctx, models.AuditLogEntry{
+		ActorID:    &adminID,
+		Action:     models.AuditActionAPIKeyRevoked,
+		TargetType: "api_key",
+		TargetID:   keyID.String(),
+		IPAddress:  ip,
+	})
+	log.Printf("API key %s revoked by admin %s", keyID, adminID)
+	return nil
+}
+
+// Authenticate returns the active key matching a presented key.
+func (s *PublicAPIService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, newError(KindUnauthorized, "invalid API key")
+	}
+	var apiKey models.APIKey
+	query := `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING id, name, key_prefix, scopes, rate_limit_per_minute
+	`
+	err := s.db.QueryRow(ctx, query, hashAPIKey(key)).Scan(&apiKey.ID, &apiKey.Name, &apiKey.KeyPrefix, &apiKey.Scopes, &apiKey.RateLimitPerMinute)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindUnauthorized, "invalid API key")
+		}
+		log.Printf("Error authenticating API key: %v", err)
+		return nil, fmt.Errorf("database error authenticating API key: %w", err)
+	}
+	return &apiKey, nil
+}
+
+// Allow counts a request against the key's per-minute limit and reports whether it may proceed,
+// with the budget left in the current minute.
+func (s *PublicAPIService) Allow(key *models.APIKey) models.RateLimitStatus {
+	return s.limiter.Hit(key.ID.String(), key.RateLimitPerMinute)
+}
+
+// roundCoordinate blurs a coordinate to publicCoordinatePrecision decimals.
+func roundCoordinate(value float64) float64 {
+	scale := math.Pow(10, publicCoordinatePrecision)
+	return math.Round(value*scale) / scale
+}
+
+// PublicRides returns anonymized upcoming active rides departing from or arriving in a city.
+// Only the fields of models.PublicRide leave the service; creators, participants, location
+// names and exact coordinates are never selected.
+func (s *PublicAPIService) PublicRides(ctx context.Context, params models.PublicRidesRequest) ([]models.PublicRide, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid public rides request: %w", err)
+	}
+	query := `
+		SELECT ST_X(r.departure_coords), ST_Y(r.departure_coords), ST_X(r.arrival_coords), ST_Y(r.arrival_coords),
+		       to_char(r.departure_date, 'YYYY-MM-DD'), EXTRACT(HOUR FROM r.departure_time)::int, r.total_seats,
+		       r.total_seats - (SELECT COALESCE(SUM(p.seat_count), 0) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active')
+		FROM rides r
+		WHERE r.status = $1
+		  AND (r.departure_date > current_date OR (r.departure_date = current_date AND r.departure_time > current_time))
+		  AND r.departure_coords IS NOT NULL AND r.arrival_coords IS NOT NULL
+		  AND (r.departure_location_name ILIKE $2 OR r.arrival_location_name ILIKE $2)
+		  AND ($3::date IS NULL OR r.departure_date = $3::date)
+		ORDER BY r.departure_date ASC, r.departure_time ASC
+		LIMIT 500
+	`
+	rows, err := s.db.Query(ctx, query, string(models.RideStatusActive), "%"+params.City+"%", params.DepartureDate)
+	if err != nil {
+		log.Printf("Error querying public rides for city %q: %v", params.City, err)
+		return nil, fmt.Errorf("database error fetching public rides: %w", err)
+	}
+	defer rows.Close()
+
+	rides := []models.PublicRide{}
+	for rows.Next() {
+		var ride models.PublicRide
+		err := rows.Scan(&ride.DepartureArea.Longitude, &ride.DepartureArea.Latitude, &ride.ArrivalArea.Longitude, &ride.ArrivalArea.Latitude,
+			&ride.DepartureDate, &ride.DepartureHour, &ride.TotalSeats, &ride.SeatsAvailable)
+		if err != nil {
+			return nil, fmt.Errorf("error processing public ride data: %w", err)
+		}
+		ride.DepartureArea = models.GeoPoint{Longitude: roundCoordinate(ride.DepartureArea.Longitude), Latitude: roundCoordinate(ride.DepartureArea.Latitude)}
+		ride.ArrivalArea = models.GeoPoint{Longitude: roundCoordinate(ride.ArrivalArea.Longitude), Latitude: roundCoordinate(ride.ArrivalArea.Latitude)}
+		rides = append(rides, ride)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for public rides: %w", err)
+	}
+	return rides, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// Test that each key gets its own per-minute budget, reset on the next minute
+func TestPublicAPIService_Allow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPublicAPIService(nil, nil)
+	service.limiter.now = func() time.Time { return now }
+	dashboard := &models.APIKey{ID: uuid.New(), RateLimitPerMinute: 2}
+	widget := &models.APIKey{ID: uuid.New(), RateLimitPerMinute: 2}
+
+	if !service.Allow(dashboard).Allowed || !service.Allow(dashboard).Allowed {
+		t.Fatal("requests within the limit were rejected")
+	}
+	if service.Allow(dashboard).Allowed {
+		t.Error("third request in the same minute was allowed, want 429")
+	}
+	if !service.Allow(widget).Allowed {
+		t.Error("another key was limited by the first key's traffic")
+	}
+	now = now.Add(time.Minute)
+	if !service.Allow(dashboard).Allowed {
+		t.Error("request in the next minute was rejected")
+	}
+}
+
+// Test that public coordinates are blurred to two decimals
+func TestRoundCoordinate(t *testing.T) {
+	if got := roundCoordinate(45.764043); got != 45.76 {
+		t.Errorf("roundCoordinate(45.764043) = %v, want 45.76", got)
+	}
+	if got := roundCoordinate(-0.005001); got != -0.01 {
+		t.Errorf("roundCoordinate(-0.005001) = %v, want -0.01", got)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"         // For request bodies
+	"context"       // For database and Expo calls
+	"encoding/json" // For the receipts API
+	"fmt"           // For error formatting and metrics
+	"io"            // For the metrics exposition
+	"log"           // For logging
+	"net/http"      // For the receipts API
+	"sort"          // For stable metrics output
+	"sync"          // For the counters
+	"time"          // For the job schedule
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+)
+
+const (
+	pushReceiptBatchSize = 1000             // Most ticket IDs Expo accepts per receipts request
+	pushReceiptDelay     = 15 * time.Minute // Expo advises waiting this long before reading receipts
+	pushReceiptTTL       = 24 * time.Hour   // Expo drops receipts after about a day
+	pushTicketRetention  = 7 * 24 * time.Hour
+)
+
+// prunePushToken removes a token Expo reported as DeviceNotRegistered, unless the user already
+// registered another one since the push was sent.
+func prunePushToken(ctx context.Context, db database.DBPool, userID uuid.UUID, pushToken string) bool {
+	query := `UPDATE users SET expo_push_token = NULL, push_token_inactive_at = NULL WHERE id = $1 AND expo_push_token = $2`
+	tag, err := db.Exec(ctx, query, userID, pushToken)
+	if err != nil {
+		log.Printf("Push Error: Failed pruning unregistered push token of user %s: %v", userID, err)
+		return false
+	}
+	if tag.RowsAffected() > 0 {
+		log.Printf("Push Info: Pruned unregistered push token of user %s", userID)
+	}
+	return tag.RowsAffected() > 0
+}
+
+// PushHygieneJob keeps push tokens deliverable: it reads the Expo receipts of sent pushes, prunes
+// tokens reported as DeviceNotRegistered, deactivates tokens the app hasn't registered again for
+// PUSH_TOKEN_DORMANCY, and counts receipt outcomes for the /metrics deliverability dashboards.
+type PushHygieneJob struct {
+	cfg        *config.Config
+	db         database.DBPool
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	receipts    map[string]int64 // By result: "ok", "expired" or the Expo error code
+	pruned      int64
+	deactivated int64
+}
+
+// NewPushHygieneJob creates a new PushHygieneJob instance.
+func NewPushHygieneJob(cfg *config.Config, db database.DBPool) *PushHygieneJob {
+	return &PushHygieneJob{
+		cfg:        cfg,
+		db:         db,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		receipts:   make(map[string]int64),
+	}
+}
+
+// Start runs the job every cfg.PushHygieneInterval in the background.
+func (j *PushHygieneJob) Start() {
+	if j.cfg.PushHygieneInterval <= 0 {
+		log.Println("Push token hygiene job disabled (PUSH_HYGIENE_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.cfg.PushHygieneInterval)
+		defer ticker.Stop()
+		for {
+			if err := j.Run(context.Background()); err != nil {
+				log.Printf("Warning: Push token hygiene job failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run checks due receipts, expires and purges old tickets, and deactivates dormant tokens.
+func (j *PushHygieneJob) Run(ctx context.Context) error {
+	if err := j.checkReceipts(ctx); err != nil {
+		return err
+	}
+
+	tag, err := j.db.Exec(ctx, `
+		UPDATE push_tickets SET receipt_status = 'expired', checked_at = NOW()
+		WHERE receipt_status IS NULL AND created_at < NOW() - make_interval(secs => $1)
+	`, pushReceiptTTL.Seconds())
+	if err != nil {
+		return fmt.Errorf("database error expiring push tickets: %w", err)
+	}
+	j.count("expired", tag.RowsAffected())
+	if _, err := j.db.Exec(ctx, `DELETE FROM push_tickets WHERE created_at < NOW() - make_interval(secs => $1)`, pushTicketRetention.Seconds()); err != nil {
+		return fmt.Errorf("database error purging push tickets: %w", err)
+	}
+
+	tag, err = j.db.Exec(ctx, `
+		UPDATE users SET push_token_inactive_at = NOW()
+		WHERE expo_push_token IS NOT NULL AND push_token_inactive_at IS NULL AND deleted_at IS NULL
+		  AND COALESCE(push_token_registered_at, updated_at) < NOW() - make_interval(secs => $1)
+	`, j.cfg.PushTokenDormancy.Seconds())
+	if err != nil {
+		return fmt.Errorf("database error deactivating dormant push tokens: %w", err)
+	}
+	if deactivated := tag.RowsAffected(); deactivated > 0 {
+		log.Printf("Push token hygiene job deactivated %d dormant tokens", deactivated)
+		j.mu.Lock()
+		j.deactivated += deactivated
+		j.mu.Unlock()
+	}
+	return nil
+}
+
+// pushTicket is a sent push awaiting its receipt.
+type pushTicket struct {
+	id        string
+	userID    uuid.UUID
+	pushToken string
+	createdAt time.Time
+}
+
+// checkReceipts reads the receipts of unchecked tickets old enough to have one, in batches.
+// Tickets whose receipt isn't ready yet stay unchecked until a later run.
+func (j *PushHygieneJob) checkReceipts(ctx context.Context) error {
+	query := `
+		SELECT id, user_id, push_token, created_at FROM push_tickets
+		WHERE receipt_status IS NULL AND created_at < NOW() - make_interval(secs => $1)
+		  AND (created_at, id) > ($2, $3)
+		ORDER BY created_at, id
+		LIMIT $4
+	`
+	after, afterID := time.Time{}, ""
+	for {
+		rows, err := j.db.Query(ctx, query, pushReceiptDelay.Seconds(), after, afterID, pushReceiptBatchSize)
+		if err != nil {
+			return fmt.Errorf("database error listing push tickets: %w", err)
+		}
+		tickets := make(map[string]pushTicket)
+		ids := []string{}
+		for rows.Next() {
+			var ticket pushTicket
+			if err := rows.Scan(&ticket.id, &ticket.userID, &ticket.pushToken, &ticket.createdAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("error processing push ticket: %w", err)
+			}
+			tickets[ticket.id] = ticket
+			ids = append(ids, ticket.id)
+			after, afterID = ticket.createdAt, ticket.id
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("database iteration error for push tickets: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		receipts, err := j.fetchReceipts(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for id, receipt := range receipts {
+			ticket, ok := tickets[id]
+			if !ok {
+				continue
+			}
+			status, errorCode := "ok", ""
+			if receipt.Status != "ok" {
+				status, errorCode = "error", receipt.Details.Error
+				if errorCode == "" {
+					errorCode = "unknown"
+				}
+				log.Printf("Push Error: Receipt %s for user %s: %s", id, ticket.userID, receipt.Message)
+			}
+			_, err := j.db.Exec(ctx, `UPDATE push_tickets SET receipt_status = $1, receipt_error = NULLIF($2, ''), checked_at = NOW() WHERE id = $3`, status, errorCode, id)
+			if err != nil {
+				return fmt.Errorf("database error recording push receipt: %w", err)
+			}
+			result := status
+			if errorCode != "" {
+				result = errorCode
+			}
+			j.count(result, 1)
+			if errorCode == expoDeviceNotRegistered && prunePushToken(ctx, j.db, ticket.userID, ticket.pushToken) {
+				j.mu.Lock()
+				j.pruned++
+				j.mu.Unlock()
+			}
+		}
+		if len(ids) < pushReceiptBatchSize {
+			return nil
+		}
+	}
+}
+
+// expoReceipt is one receipt of the Expo getReceipts response.
+type expoReceipt struct {
+	Status  string `json:"status"` // "ok" or "error"
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+// fetchReceipts returns the available receipts of the given tickets, by ticket ID.
+func (j *PushHygieneJob) fetchReceipts(ctx context.Context, ids []string) (map[string]expoReceipt, error) {
+	body, err := json.Marshal(map[string][]string{"ids": ids})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.cfg.ExpoReceiptsURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if j.cfg.ExpoAccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+j.cfg.ExpoAccessToken)
+	}
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("push receipts request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Data map[string]expoReceipt `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected push receipts response (HTTP %d): %v", resp.StatusCode, err)
+	}
+	return result.Data, nil
+}
+
+// count adds receipt outcomes to the deliverability counters.
+func (j *PushHygieneJob) count(result string, n int64) {
+	if n == 0 {
+		return
+	}
+	j.mu.Lock()
+	j.receipts[result] += n
+	j.mu.Unlock()
+}
+
+// WritePrometheus writes the push receipt outcomes and token hygiene counters.
+func (j *PushHygieneJob) WritePrometheus(w io.Writer) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	results := make([]string, 0, len(j.receipts))
+	for result := range j.receipts {
+		results = append(results, result)
+	}
+	sort.Strings(results)
+	fmt.Fprintln(w, "# HELP rideshare_push_receipts_total Expo push receipts by result (ok, expired or the Expo error code).")
+	fmt.Fprintln(w, "# TYPE rideshare_push_receipts_total counter")
+	for _, result := range results {
+		fmt.Fprintf(w, "rideshare_push_receipts_total%s %d\n", labels("result", result), j.receipts[result])
+	}
+	fmt.Fprintln(w, "# HELP rideshare_push_tokens_pruned_total Push tokens removed after a receipt reported DeviceNotRegistered.")
+	fmt.Fprintln(w, "# TYPE rideshare_push_tokens_pruned_total counter")
+	fmt.Fprintf(w, "rideshare_push_tokens_pruned_total %d\n", j.pruned)
+	fmt.Fprintln(w, "# HELP rideshare_push_tokens_deactivated_total Dormant push tokens deactivated.")
+	fmt.Fprintln(w, "# TYPE rideshare_push_tokens_deactivated_total counter")
+	fmt.Fprintf(w, "rideshare_push_tokens_deactivated_total %d\n", j.deactivated)
+}
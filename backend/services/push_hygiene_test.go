@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rideshare/backend/config"
+)
+
+// Test that receipts are requested by ticket ID and their error details decoded
+func TestPushHygieneJob_FetchReceipts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.IDs) != 2 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data": {
+			"ticket-1": {"status": "ok"},
+			"ticket-2": {"status": "error", "message": "not registered", "details": {"error": "DeviceNotRegistered"}}
+		}}`))
+	}))
+	defer server.Close()
+	job := NewPushHygieneJob(&config.Config{ExpoReceiptsURL: server.URL}, nil)
+
+	receipts, err := job.fetchReceipts(context.Background(), []string{"ticket-1", "ticket-2"})
+	if err != nil {
+		t.Fatalf("fetchReceipts: %v", err)
+	}
+	if receipts["ticket-1"].Status != "ok" || receipts["ticket-2"].Details.Error != expoDeviceNotRegistered {
+		t.Errorf("receipts = %+v", receipts)
+	}
+	if _, err := job.fetchReceipts(context.Background(), []string{"ticket-1"}); err == nil {
+		t.Error("fetchReceipts should fail on a non-200 response")
+	}
+}
+
+// Test the deliverability metrics exposition
+func TestPushHygieneJob_WritePrometheus(t *testing.T) {
+	job := NewPushHygieneJob(&config.Config{}, nil)
+	job.count("ok", 3)
+	job.count(expoDeviceNotRegistered, 1)
+	job.count("expired", 0)
+	job.pruned = 1
+
+	var out strings.Builder
+	job.WritePrometheus(&out)
+	for _, want := range []string{
+		`rideshare_push_receipts_total{result="DeviceNotRegistered"} 1`,
+		`rideshare_push_receipts_total{result="ok"} 3`,
+		"rideshare_push_tokens_pruned_total 1",
+		"rideshare_push_tokens_deactivated_total 0",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `result="expired"`) {
+		t.Error("results never counted should not be exposed")
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context" // For database operations context
+	"errors"  // For creating standard errors
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For quota windows
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// quotaDefinition describes how a quota is counted.
+type quotaDefinition struct {
+	window      time.Duration
+	description string // Used in the error message, e.g. "rides created per day"
+}
+
+// Counted actions are logged in the append-only 'quota_usage' table (see Record), so deleting a
+// ride or rejoining with an existing participation row doesn't give a slot back.
+var quotaDefinitions = map[models.QuotaName]quotaDefinition{
+	models.QuotaRidesCreated: {window: 24 * time.Hour, description: "rides created per day"},
+	models.QuotaRideJoins:    {window: time.Hour, description: "ride joins per hour"},
+}
+
+// QuotaExceededError is returned when a user hits a per-account quota.
+// Handlers map it to 429 Too Many Requests with a Retry-After header.
+type QuotaExceededError struct {
+	Quota       models.QuotaName
+	Limit       int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: at most %d %s", e.Limit, e.Description)
+}
+
+// QuotaService enforces per-account abuse quotas on write actions. Defaults come from config;
+// admins can override them per user.
+type QuotaService struct {
+	cfg       *config.Config
+	db        database.DBPool
+	validator *validator.Validate
+}
+
+// NewQuotaService creates a new QuotaService instance.
+func NewQuotaService(cfg *config.Config, db database.DBPool) *QuotaService {
+	return &QuotaService{
+		cfg:       cfg,
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// defaultLimit returns the configured limit for a quota, nil if unlimited.
+func (s *QuotaService) defaultLimit(quota models.QuotaName) *int {
+	var limit int
+	runtime := s.cfg.Runtime() // Limits can be changed at runtime
+	switch quota {
+	case models.QuotaRidesCreated:
+		limit = runtime.QuotaRidesPerDay
+	case models.QuotaRideJoins:
+		limit = runtime.QuotaJoinsPerHour
+	}
+	if limit <= 0 {
+		return nil
+	}
+	return &limit
+}
+
+// Status returns the user's current usage and limit for a quota.
+func (s *QuotaService) Status(ctx context.Context, userID uuid.UUID, quota models.QuotaName) (*models.QuotaStatus, *time.Time, error) {
+	definition, ok := quotaDefinitions[quota]
+	if !ok {
+		return nil, nil, newError(KindInvalid, fmt.Sprintf("unknown quota: %s", quota))
+	}
+
+	status := &models.QuotaStatus{Quota: quota, Limit: s.defaultLimit(quota), WindowSeconds: int(definition.window.Seconds())}
+
+	var overrideLimit *int
+	var overrideUntil *time.Time
+	overrideQuery := `SELECT limit_value, expires_at FROM user_quota_overrides WHERE user_id = $1 AND quota = $2 AND (expires_at IS NULL OR expires_at > NOW())`
+	err := s.db.QueryRow(ctx, overrideQuery, userID, quota).Scan(&overrideLimit, &overrideUntil)
+	if err == nil {
+		status.Limit = overrideLimit
+		status.Overridden = true
+		status.OverrideUntil = overrideUntil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, fmt.Errorf("database error fetching quota override: %w", err)
+	}
+
+	var oldest *time.Time
+	countQuery := `SELECT COUNT(*), MIN(created_at) FROM quota_usage WHERE user_id = $1 AND quota = $2 AND created_at > $3`
+	if err := s.db.QueryRow(ctx, countQuery, userID, quota, time.Now().Add(-definition.window)).Scan(&status.Used, &oldest); err != nil {
+		return nil, nil, fmt.Errorf("database error counting quota usage: %w", err)
+	}
+	return status, oldest, nil
+}
+
+// Check returns a *QuotaExceededError if the user may not perform one more action counted by the quota.
+// Database errors are logged and the action is allowed, so quota checks never take writes down.
+func (s *QuotaService) Check(ctx context.Context, userID uuid.UUID, quota models.QuotaName) error {
+	status, oldest, err := s.Status(ctx, userID, quota)
+	if err != nil {
+		log.Printf("Quota Warning: Failed checking %s for user %s, allowing: %v", quota, userID, err)
+		return nil
+	}
+	if status.Limit == nil || status.Used < *status.Limit {
+		return nil
+	}
+
+	definition := quotaDefinitions[quota]
+	retryAfter := definition.window
+	if oldest != nil {
+		// A slot frees up when the oldest counted action leaves the window
+		retryAfter = time.Until(oldest.Add(definition.window))
+	}
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	log.Printf("Quota exceeded: user %s hit %s (%d/%d)", userID, quota, status.Used, *status.Limit)
+	return &QuotaExceededError{Quota: quota, Limit: *status.Limit, Description: definition.description, RetryAfter: retryAfter}
+}
+
+// Record counts one action against a quota. Call it once the action succeeded.
+// Failures are logged, never returned: the action already happened.
+func (s *QuotaService) Record(ctx context.Context, userID uuid.UUID, quota models.QuotaName) {
+	if _, err := s.db.Exec(ctx, `INSERT INTO quota_usage (user_id, quota) VALUES ($1, $2)`, userID, quota); err != nil {
+		log.Printf("Quota Warning: Failed recording %s usage for user %s: %v", quota, userID, err)
+	}
+}
+
+// ListStatuses returns the user's usage of every quota.
+func (s *QuotaService) ListStatuses(ctx context.Context, userID uuid.UUID) ([]models.QuotaStatus, error) {
+	statuses := []models.QuotaStatus{}
+	for _, quota := range []models.QuotaName{models.QuotaRidesCreated, models.QuotaRideJoins} {
+		status, _, err := s.Status(ctx, userID, quota)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// SetOverride replaces the user's limit for a quota until the override expires or is removed.
+func (s *QuotaService) SetOverride(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, quota models.QuotaName, req models.SetQuotaOverrideRequest) (*models.QuotaOverride, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid quota override: %w", err)
+	}
+	if _, ok := quotaDefinitions[quota]; !ok {
+		return nil, newError(KindInvalid, fmt.Sprintf("unknown quota: %s", quota))
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return nil, newError(KindInvalid, "override expiry must be in the future")
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, userID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("database error checking user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	override := &models.QuotaOverride{UserID: userID, Quota: quota, Limit: req.Limit, Reason: req.Reason, CreatedBy: &adminID, ExpiresAt: req.ExpiresAt}
+	query := `
+		INSERT INTO user_quota_overrides (user_id, quota, limit_value, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, quota) DO UPDATE
+		SET limit_value = EXCLUDED.limit_value, reason = EXCLUDED.reason, created_by = EXCLUDED.created_by,
+		    expires_at = EXCLUDED.expires_at, created_at = NOW()
+		RETURNING created_at
+	`
+	err := s.db.QueryRow(ctx, query, userID, quota, req.Limit, req.Reason, adminID, req.ExpiresAt).Scan(&override.CreatedAt)
+	if err != nil {
+		log.Printf("Error setting %s override for user %s: %v", quota, userID, err)
+		return nil, fmt.Errorf("database error setting quota override: %w", err)
+	}
+	return override, nil
+}
+
+// RemoveOverride restores the default limit for a quota.
+func (s *QuotaService) RemoveOverride(ctx context.Context, userID uuid.UUID, quota models.QuotaName) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM user_quota_overrides WHERE user_id = $1 AND quota = $2`, userID, quota)
+	if err != nil {
+		return fmt.Errorf("database error removing quota override: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return newError(KindNotFound, "quota override not found")
+	}
+	return nil
+}
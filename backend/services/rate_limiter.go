@@ -0,0 +1,94 @@
+package services
+
+import (
+	"sync" // For the counters
+	"time" // For rate limit windows
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// rateLimiterMaxEntries bounds the counters; windows of past minutes are evicted when it is reached.
+const rateLimiterMaxEntries = 100000
+
+// rateWindow counts the requests of one caller in the current minute.
+type rateWindow struct {
+	minute int64
+	count  int
+}
+
+// RateLimiter counts requests per caller in fixed one-minute windows. Counters live in memory,
+// so each instance enforces its own budget.
+type RateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	now     func() time.Time // Replaced in tests
+}
+
+// NewRateLimiter creates an empty RateLimiter.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// Hit counts a request of key against limit per minute. A request over the limit is not counted
+// and the returned status has Allowed false.
+func (l *RateLimiter) Hit(key string, limit int) models.RateLimitStatus {
+	now := l.now()
+	minute := now.Unix() / 60
+	status := models.RateLimitStatus{Limit: limit, Reset: time.Unix((minute+1)*60, 0)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	window, ok := l.windows[key]
+	if !ok || window.minute != minute {
+		if !ok && len(l.windows) >= rateLimiterMaxEntries {
+			l.evict(minute)
+		}
+		window = &rateWindow{minute: minute}
+		l.windows[key] = window
+	}
+	if window.count < limit {
+		window.count++
+		status.Allowed = true
+	}
+	status.Remaining = limit - window.count
+	return status
+}
+
+// evict drops the windows of past minutes. Called with mu held.
+func (l *RateLimiter) evict(minute int64) {
+	for key, window := range l.windows {
+		if window.minute != minute {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// UserRateLimiter tracks the soft request budget of each user and anonymous browsing session.
+// It never rejects a request: it only reports the budget so clients can back off. A nil
+// *UserRateLimiter tracks nothing.
+type UserRateLimiter struct {
+	limit   int
+	limiter *RateLimiter
+}
+
+// NewUserRateLimiter creates a UserRateLimiter from cfg. A zero UserRateLimitPerMinute disables it.
+func NewUserRateLimiter(cfg *config.Config) *UserRateLimiter {
+	if cfg.UserRateLimitPerMinute <= 0 {
+		return nil
+	}
+	return &UserRateLimiter{limit: cfg.UserRateLimitPerMinute, limiter: NewRateLimiter()}
+}
+
+// Track counts a request of a user or anonymous session. It reports false when the limiter is disabled.
+func (u *UserRateLimiter) Track(callerID uuid.UUID) (models.RateLimitStatus, bool) {
+	if u == nil {
+		return models.RateLimitStatus{}, false
+	}
+	return u.limiter.Hit(callerID.String(), u.limit), true
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Test that the remaining budget counts down, stays at zero over the limit and resets next minute
+func TestRateLimiter_Hit(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 30, 0, time.UTC)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i, want := range []int{1, 0, 0} {
+		status := limiter.Hit("user", 2)
+		if status.Remaining != want {
+			t.Errorf("request %d: Remaining = %d, want %d", i+1, status.Remaining, want)
+		}
+		if status.Allowed != (i < 2) {
+			t.Errorf("request %d: Allowed = %v", i+1, status.Allowed)
+		}
+		if !status.Reset.Equal(time.Date(2025, 6, 1, 12, 1, 0, 0, time.UTC)) {
+			t.Errorf("request %d: Reset = %v, want the next minute", i+1, status.Reset)
+		}
+	}
+	now = now.Add(time.Minute)
+	if status := limiter.Hit("user", 2); status.Remaining != 1 {
+		t.Errorf("next minute: Remaining = %d, want 1", status.Remaining)
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// RefundInitiator identifies who triggered the cancellation a refund is computed for.
+type RefundInitiator string
+
+const (
+	RefundInitiatorParticipant RefundInitiator = "participant" // Participant left the ride
+	RefundInitiatorCreator     RefundInitiator = "creator"     // Creator cancelled the ride (always fully refunded)
+	RefundInitiatorAdmin       RefundInitiator = "admin"       // Support force-cancelled the ride (always fully refunded)
+)
+
+// refundTier grants a refund percentage when the cancellation happens at least minNotice before departure.
+type refundTier struct {
+	minNotice time.Duration
+	percent   int
+}
+
+// refundTiers lists, per policy, the tiers from the most to the least generous.
+var refundTiers = map[models.CancellationPolicy][]refundTier{
+	models.CancellationPolicyFlexible: {
+		{minNotice: 2 * time.Hour, percent: 100},
+		{minNotice: 0, percent: 50},
+	},
+	models.CancellationPolicyModerate: {
+		{minNotice: 24 * time.Hour, percent: 100},
+		{minNotice: 2 * time.Hour, percent: 50},
+	},
+	models.CancellationPolicyStrict: {
+		{minNotice: 7 * 24 * time.Hour, percent: 100},
+		{minNotice: 48 * time.Hour, percent: 50},
+	},
+}
+
+// RefundPolicyEngine resolves ride cancellation policies within platform bounds
+// and computes the refund percentage owed when a participation ends.
+type RefundPolicyEngine struct {
+	defaultPolicy models.CancellationPolicy
+	allowed       map[models.CancellationPolicy]bool
+}
+
+// NewRefundPolicyEngine creates a RefundPolicyEngine from the configured policy bounds.
+func NewRefundPolicyEngine(cfg *config.Config) *RefundPolicyEngine {
+	engine := &RefundPolicyEngine{
+		defaultPolicy: models.CancellationPolicyModerate,
+		allowed:       map[models.CancellationPolicy]bool{},
+	}
+	for _, name := range cfg.AllowedCancellationPolicies {
+		policy := models.CancellationPolicy(name)
+		if _, known := refundTiers[policy]; !known {
+			log.Printf("Warning: Ignoring unknown cancellation policy '%s' in configuration", name)
+			continue
+		}
+		engine.allowed[policy] = true
+	}
+	if len(engine.allowed) == 0 {
+		log.Println("Warning: No valid cancellation policies configured, allowing all policies")
+		for policy := range refundTiers {
+			engine.allowed[policy] = true
+		}
+	}
+	if policy := models.CancellationPolicy(cfg.DefaultCancellationPolicy); engine.allowed[policy] {
+		engine.defaultPolicy = policy
+	} else if !engine.allowed[engine.defaultPolicy] {
+		// Fall back to the most generous allowed policy
+		for _, candidate := range []models.CancellationPolicy{models.CancellationPolicyFlexible, models.CancellationPolicyModerate, models.CancellationPolicyStrict} {
+			if engine.allowed[candidate] {
+				engine.defaultPolicy = candidate
+				break
+			}
+		}
+	}
+	return engine
+}
+
+// ResolvePolicy returns the policy to store on a ride, applying the platform default
+// when none is requested and rejecting policies outside the configured bounds.
+func (e *RefundPolicyEngine) ResolvePolicy(requested string) (models.CancellationPolicy, error) {
+	if requested == "" {
+		return e.defaultPolicy, nil
+	}
+	policy := models.CancellationPolicy(requested)
+	if !e.allowed[policy] {
+		return "", newError(KindInvalid, fmt.Sprintf("cancellation policy not allowed: %s", requested))
+	}
+	return policy, nil
+}
+
+// RefundPercent computes the share (0-100) of a payment to refund when a participation
+// ends at 'now' for a ride departing at departureAt.
+func (e *RefundPolicyEngine) RefundPercent(policy models.CancellationPolicy, departureAt time.Time, now time.Time, initiator RefundInitiator) int {
+	if initiator == RefundInitiatorCreator || initiator == RefundInitiatorAdmin {
+		return 100 // Participants never pay for a ride the creator or support cancelled
+	}
+
+	notice := departureAt.Sub(now)
+	if notice < 0 {
+		return 0 // Ride already departed
+	}
+
+	tiers, ok := refundTiers[policy]
+	if !ok {
+		tiers = refundTiers[e.defaultPolicy]
+	}
+	for _, tier := range tiers {
+		if notice >= tier.minNotice {
+			return tier.percent
+		}
+	}
+	return 0
+}
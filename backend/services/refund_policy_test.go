@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test refund percentages across policies and notice periods
+func TestRefundPolicyEngine_RefundPercent(t *testing.T) {
+	engine := NewRefundPolicyEngine(&config.Config{
+		DefaultCancellationPolicy:   "moderate",
+		AllowedCancellationPolicies: []string{"flexible", "moderate", "strict"},
+	})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		policy    models.CancellationPolicy
+		notice    time.Duration
+		initiator RefundInitiator
+		want      int
+	}{
+		{"flexible well ahead", models.CancellationPolicyFlexible, 3 * time.Hour, RefundInitiatorParticipant, 100},
+		{"flexible last minute", models.CancellationPolicyFlexible, 30 * time.Minute, RefundInitiatorParticipant, 50},
+		{"moderate day before", models.CancellationPolicyModerate, 25 * time.Hour, RefundInitiatorParticipant, 100},
+		{"moderate same day", models.CancellationPolicyModerate, 5 * time.Hour, RefundInitiatorParticipant, 50},
+		{"moderate last minute", models.CancellationPolicyModerate, time.Hour, RefundInitiatorParticipant, 0},
+		{"strict week ahead", models.CancellationPolicyStrict, 8 * 24 * time.Hour, RefundInitiatorParticipant, 100},
+		{"strict three days", models.CancellationPolicyStrict, 72 * time.Hour, RefundInitiatorParticipant, 50},
+		{"strict day before", models.CancellationPolicyStrict, 24 * time.Hour, RefundInitiatorParticipant, 0},
+		{"already departed", models.CancellationPolicyFlexible, -time.Hour, RefundInitiatorParticipant, 0},
+		{"admin after departure", models.CancellationPolicyStrict, -time.Hour, RefundInitiatorAdmin, 100},
+		{"creator cancels late", models.CancellationPolicyStrict, time.Hour, RefundInitiatorCreator, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.RefundPercent(tt.policy, now.Add(tt.notice), now, tt.initiator)
+			if got != tt.want {
+				t.Errorf("Expected refund percent %d, but got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// Test that requested policies are bounded by configuration
+func TestRefundPolicyEngine_ResolvePolicy(t *testing.T) {
+	engine := NewRefundPolicyEngine(&config.Config{
+		DefaultCancellationPolicy:   "strict",
+		AllowedCancellationPolicies: []string{"moderate", "strict"},
+	})
+
+	policy, err := engine.ResolvePolicy("")
+	if err != nil || policy != models.CancellationPolicyStrict {
+		t.Errorf("Expected default policy 'strict', but got '%s' (err: %v)", policy, err)
+	}
+
+	policy, err = engine.ResolvePolicy("moderate")
+	if err != nil || policy != models.CancellationPolicyModerate {
+		t.Errorf("Expected policy 'moderate', but got '%s' (err: %v)", policy, err)
+	}
+
+	if _, err = engine.ResolvePolicy("flexible"); err == nil {
+		t.Error("Expected an error for a policy outside the configured bounds, but got nil")
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For building queries
+	"log"     // For logging
+	"sync"    // For the last report
+	"time"    // For retention periods
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// retentionRule purges the rows of one table older than a retention period. where is a SQL
+// condition whose $1 is the retention period in seconds; set, if not empty, clears columns instead of
+// deleting rows.
+type retentionRule struct {
+	name        string
+	description string
+	table       string
+	where       string
+	set         string
+	retain      time.Duration
+}
+
+// RetentionService enforces the data retention rules, either purging data or, in dry-run mode,
+// only reporting what would be purged.
+type RetentionService struct {
+	cfg *config.Config
+	db  database.DBPool
+
+	mu         sync.Mutex
+	lastReport *models.RetentionReport
+}
+
+// NewRetentionService creates a new RetentionService instance.
+func NewRetentionService(cfg *config.Config, db database.DBPool) *RetentionService {
+	return &RetentionService{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// rules returns the retention rules with the configured periods. A period of 0 disables a rule.
+func (s *RetentionService) rules() []retentionRule {
+	return []retentionRule{
+		{
+			name:        "location_data",
+			description: "Clear IP addresses, payment methods and coordinates on fraud events",
+			table:       "fraud_events",
+			where:       "created_at < NOW() - make_interval(secs => $1) AND (ip_address IS NOT NULL OR payment_method_id IS NOT NULL OR latitude IS NOT NULL OR longitude IS NOT NULL)",
+			set:         "ip_address = NULL, payment_method_id = NULL, latitude = NULL, longitude = NULL",
+			retain:      s.cfg.RetentionLocationData,
+		},
+		{
+			name:        "notifications",
+			description: "Delete in-app notifications",
+			table:       "notifications",
+			where:       "created_at < NOW() - make_interval(secs => $1)",
+			retain:      s.cfg.RetentionNotifications,
+		},
+		{
+			name:        "archived_rides",
+			description: "Delete archived, completed and cancelled rides without payments (payments are kept for accounting)",
+			table:       "rides",
+			where: "status IN ('archived', 'completed', 'cancelled') AND departure_date < (NOW() - make_interval(secs => $1))::date" +
+				" AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.ride_id = rides.id)",
+			retain: s.cfg.RetentionArchivedRides,
+		},
+		{
+			name:        "audit_logs",
+			description: "Delete audit log entries",
+			table:       "audit_logs",
+			where:       "created_at < NOW() - make_interval(secs => $1)",
+			retain:      s.cfg.RetentionAuditLogs,
+		},
+		{
+			name:        "anonymous_sessions",
+			description: "Delete anonymous browsing sessions and their recent searches once their token expired",
+			table:       "anonymous_sessions",
+			where:       "last_seen_at < NOW() - make_interval(secs => $1)",
+			retain:      s.cfg.AnonymousSessionTTL,
+		},
+		{
+			name:        "analytics_events",
+			description: "Delete product analytics events",
+			table:       "analytics_events",
+			where:       "occurred_at < NOW() - make_interval(secs => $1)",
+			retain:      s.cfg.RetentionAnalytics,
+		},
+	}
+}
+
+// query returns the statement a rule runs: a count in dry runs, else a delete or update.
+func (r retentionRule) query(dryRun bool) string {
+	switch {
+	case dryRun:
+		return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.table, r.where)
+	case r.set != "":
+		return fmt.Sprintf("UPDATE %s SET %s WHERE %s", r.table, r.set, r.where)
+	default:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", r.table, r.where)
+	}
+}
+
+// Start runs the retention rules every cfg.RetentionJobInterval in the configured mode.
+func (s *RetentionService) Start() {
+	if s.cfg.RetentionMode == config.RetentionOff || s.cfg.RetentionJobInterval <= 0 {
+		log.Println("Retention job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.RetentionJobInterval)
+		defer ticker.Stop()
+		for {
+			s.Run(context.Background(), s.cfg.RetentionMode != config.RetentionEnforce)
+			<-ticker.C
+		}
+	}()
+}
+
+// Run applies every enabled rule and returns the report, which is also kept as the last report.
+// A failing rule is reported and does not stop the others.
+func (s *RetentionService) Run(ctx context.Context, dryRun bool) *models.RetentionReport {
+	report := &models.RetentionReport{DryRun: dryRun, StartedAt: time.Now(), Rules: []models.RetentionRuleResult{}}
+	for _, rule := range s.rules() {
+		if rule.retain <= 0 {
+			continue
+		}
+		result := models.RetentionRuleResult{Rule: rule.name, Description: rule.description, RetainDays: int(rule.retain / (24 * time.Hour))}
+		if dryRun {
+			if err := s.db.QueryRow(ctx, rule.query(true), rule.retain.Seconds()).Scan(&result.Affected); err != nil {
+				result.Error = err.Error()
+			}
+		} else {
+			tag, err := s.db.Exec(ctx, rule.query(false), rule.retain.Seconds())
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Affected = tag.RowsAffected()
+			}
+		}
+		if result.Error != "" {
+			log.Printf("Warning: Retention rule %s failed: %s", rule.name, result.Error)
+		} else if result.Affected > 0 {
+			log.Printf("Retention rule %s (dry run: %t): %d rows", rule.name, dryRun, result.Affected)
+		}
+		report.Rules = append(report.Rules, result)
+	}
+	report.FinishedAt = time.Now()
+
+	s.mu.Lock()
+	s.lastReport = report
+	s.mu.Unlock()
+	return report
+}
+
+// LastReport returns the report of the most recent run, or nil before the first run.
+func (s *RetentionService) LastReport() *models.RetentionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastReport
+}
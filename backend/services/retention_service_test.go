@@ -0,0 +1,22 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test that dry runs only count, and rules clear columns or delete rows when enforced
+func TestRetentionRule_Query(t *testing.T) {
+	clear := retentionRule{table: "fraud_events", where: "created_at < NOW() - make_interval(secs => $1)", set: "ip_address = NULL"}
+	remove := retentionRule{table: "notifications", where: "created_at < NOW() - make_interval(secs => $1)"}
+
+	if got := clear.query(true); !strings.HasPrefix(got, "SELECT COUNT(*) FROM fraud_events WHERE ") {
+		t.Errorf("dry run query = %q, want a count", got)
+	}
+	if got := clear.query(false); got != "UPDATE fraud_events SET ip_address = NULL WHERE created_at < NOW() - make_interval(secs => $1)" {
+		t.Errorf("enforced column rule query = %q", got)
+	}
+	if got := remove.query(false); got != "DELETE FROM notifications WHERE created_at < NOW() - make_interval(secs => $1)" {
+		t.Errorf("enforced row rule query = %q", got)
+	}
+}
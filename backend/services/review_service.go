@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// reviewListLimit bounds the reviews listed on a profile.
+const reviewListLimit = 50
+
+// ReviewService handles the ratings and reviews left once a ride is completed: passengers review the
+// driver, the driver reviews each passenger.
+type ReviewService struct {
+	db            database.DBPool
+	validator     *validator.Validate
+	notifications *NotificationService // Tells reviewed users about their new review
+	moderation    *ModerationService   // Screens review comments
+}
+
+// NewReviewService creates a new ReviewService instance.
+func NewReviewService(db database.DBPool, notifications *NotificationService, moderation *ModerationService) *ReviewService {
+	return &ReviewService{
+		db:            db,
+		validator:     NewValidator(),
+		notifications: notifications,
+		moderation:    moderation,
+	}
+}
+
+// CreateReview records the reviewer's rating of another user of a completed ride. Participants
+// review the ride's creator; the creator reviews a participant, named by req.RevieweeID.
+// Each reviewer rates a user once per ride. Comments are moderated like other text shown to users.
+func (s *ReviewService) CreateReview(ctx context.Context, rideID, reviewerID uuid.UUID, req models.CreateReviewRequest) (*models.Review, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid review: %w", err)
+	}
+
+	var creatorID uuid.UUID
+	var status, route string
+	rideQuery := `
+		SELECT user_id, status, departure_location_name || ' → ' || arrival_location_name
+		FROM rides WHERE id = $1
+	`
+	if err := s.db.QueryRow(ctx, rideQuery, rideID).Scan(&creatorID, &status, &route); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if status == string(models.RideStatusCancelled) {
+		return nil, newError(KindConflict, "cancelled rides can't be reviewed")
+	}
+	if status != string(models.RideStatusCompleted) {
+		return nil, newError(KindConflict, "rides can only be reviewed once they're completed")
+	}
+
+	review := &models.Review{RideID: rideID, ReviewerID: reviewerID, Rating: req.Rating, Comment: trimmedOrNil(req.Comment)}
+	participantID := reviewerID
+	if reviewerID == creatorID {
+		if req.RevieweeID == nil {
+			return nil, newError(KindInvalid, "reviewee_id is required to review a passenger")
+		}
+		review.RevieweeID, review.RevieweeRole = *req.RevieweeID, models.ReviewRolePassenger
+		participantID = *req.RevieweeID
+	} else {
+		if req.RevieweeID != nil && *req.RevieweeID != creatorID {
+			return nil, newError(KindInvalid, "passengers can only review the driver")
+		}
+		review.RevieweeID, review.RevieweeRole = creatorID, models.ReviewRoleDriver
+	}
+	var participated bool
+	participantQuery := `SELECT EXISTS(SELECT 1 FROM participants WHERE ride_id = $1 AND user_id = $2 AND status = $3)`
+	if err := s.db.QueryRow(ctx, participantQuery, rideID, participantID, string(models.ParticipantStatusCompleted)).Scan(&participated); err != nil {
+		return nil, fmt.Errorf("database error checking participant: %w", err)
+	}
+	if !participated {
+		if review.RevieweeRole == models.ReviewRolePassenger {
+			return nil, newError(KindInvalid, "this user was not a passenger of the ride")
+		}
+		return nil, newError(KindForbidden, "only the driver and passengers of the ride can review it")
+	}
+
+	check := models.ModerationCheck{UserID: reviewerID, ContentType: models.ModerationContentReview, RideID: &rideID}
+	moderation := &models.ModerationDecision{Action: models.ModerationActionAllow}
+	if review.Comment != nil {
+		check.Text = *review.Comment
+		moderation = s.moderation.Evaluate(ctx, check)
+		if moderation.Action == models.ModerationActionBlock {
+			return nil, newError(KindInvalid, "review can't contain contact details or offensive language")
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+		INSERT INTO ride_reviews (ride_id, reviewer_id, reviewee_id, reviewee_role, rating, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (ride_id, reviewer_id, reviewee_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, insertQuery, rideID, reviewerID, review.RevieweeID, review.RevieweeRole, review.Rating, review.Comment).
+		Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindConflict, "you already reviewed this user for this ride")
+		}
+		log.Printf("Error saving review of user %s by user %s on ride %s: %v", review.RevieweeID, reviewerID, rideID, err)
+		return nil, fmt.Errorf("database error saving review: %w", err)
+	}
+	if err := s.moderation.RecordFlag(ctx, tx, check, moderation); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save review: %w", err)
+	}
+
+	log.Printf("User %s rated %s %s %d/5 on ride %s", reviewerID, review.RevieweeRole, review.RevieweeID, review.Rating, rideID)
+	s.notifications.Notify(ctx, review.RevieweeID, models.NotificationEventReviewReceived, &rideID,
+		"New review", fmt.Sprintf("You received a %d-star review for the ride %s.", review.Rating, route))
+	return review, nil
+}
+
+// ListUserReviews returns the user's ratings as driver and as passenger, with their most recent reviews.
+func (s *ReviewService) ListUserReviews(ctx context.Context, userID uuid.UUID) (*models.UserReviews, error) {
+	result := &models.UserReviews{Reviews: []models.Review{}}
+	summaryQuery := `
+		SELECT reviewee_role, ROUND(AVG(rating), 1)::float8, COUNT(*)
+		FROM ride_reviews WHERE reviewee_id = $1
+		GROUP BY reviewee_role
+	`
+	rows, err := s.db.Query(ctx, summaryQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error aggregating ratings: %w", err)
+	}
+	for rows.Next() {
+		var role models.ReviewRole
+		var summary models.RatingSummary
+		if err := rows.Scan(&role, &summary.Average, &summary.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error processing rating: %w", err)
+		}
+		if role == models.ReviewRoleDriver {
+			result.AsDriver = &summary
+		} else {
+			result.AsPassenger = &summary
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ratings: %w", err)
+	}
+
+	query := `
+		SELECT rr.id, rr.ride_id, rr.reviewer_id, u.first_name, rr.reviewee_id, rr.reviewee_role, rr.rating, rr.comment, rr.created_at
+		FROM ride_reviews rr
+		JOIN users u ON u.id = rr.reviewer_id
+		WHERE rr.reviewee_id = $1
+		ORDER BY rr.created_at DESC
+		LIMIT $2
+	`
+	rows, err = s.db.Query(ctx, query, userID, reviewListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing reviews: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var review models.Review
+		err := rows.Scan(&review.ID, &review.RideID, &review.ReviewerID, &review.ReviewerFirstName, &review.RevieweeID,
+			&review.RevieweeRole, &review.Rating, &review.Comment, &review.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error processing review: %w", err)
+		}
+		result.Reviews = append(result.Reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for reviews: %w", err)
+	}
+	return result, nil
+}
+
+// ratingSummaries aggregates the ratings the given users received in a role. Users without any
+// rating are left out.
+func ratingSummaries(ctx context.Context, q rowQuerier, userIDs []uuid.UUID, role models.ReviewRole) (map[uuid.UUID]models.RatingSummary, error) {
+	query := `
+		SELECT reviewee_id, ROUND(AVG(rating), 1)::float8, COUNT(*)
+		FROM ride_reviews
+		WHERE reviewee_id = ANY($1) AND reviewee_role = $2
+		GROUP BY reviewee_id
+	`
+	rows, err := q.Query(ctx, query, userIDs, string(role))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[uuid.UUID]models.RatingSummary, len(userIDs))
+	for rows.Next() {
+		var userID uuid.UUID
+		var summary models.RatingSummary
+		if err := rows.Scan(&userID, &summary.Average, &summary.Count); err != nil {
+			return nil, err
+		}
+		summaries[userID] = summary
+	}
+	return summaries, rows.Err()
+}
+
+// userRating aggregates every rating the user received, as driver or passenger. It returns nil
+// without any rating.
+func userRating(ctx context.Context, q rowQuerier, userID uuid.UUID) (*models.RatingSummary, error) {
+	var average *float64
+	var count int
+	query := `SELECT ROUND(AVG(rating), 1)::float8, COUNT(*) FROM ride_reviews WHERE reviewee_id = $1`
+	if err := q.QueryRow(ctx, query, userID).Scan(&average, &count); err != nil {
+		return nil, err
+	}
+	if average == nil {
+		return nil, nil
+	}
+	return &models.RatingSummary{Average: *average, Count: count}, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/models"
+)
+
+// Test who may review whom: only completed rides, passengers review the driver, the driver names a passenger
+func TestReviewService_CreateReview_Eligibility(t *testing.T) {
+	creatorID, passengerID := uuid.New(), uuid.New()
+	otherPassengerID := uuid.New()
+	tests := []struct {
+		name       string
+		reviewerID uuid.UUID
+		revieweeID *uuid.UUID
+		status     string
+		wantKind   ErrorKind
+	}{
+		{"ride not departed", passengerID, nil, "active", KindConflict},
+		{"ride not completed", passengerID, nil, "archived", KindConflict},
+		{"cancelled ride", passengerID, nil, "cancelled", KindConflict},
+		{"driver without reviewee", creatorID, nil, "completed", KindInvalid},
+		{"passenger reviewing a passenger", passengerID, &otherPassengerID, "completed", KindInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer mock.Close()
+			reviewService := NewReviewService(mock, nil, nil)
+			rideID := uuid.New()
+
+			mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).
+				WillReturnRows(pgxmock.NewRows([]string{"user_id", "status", "route"}).
+					AddRow(creatorID, tt.status, "Lyon → Paris"))
+
+			req := models.CreateReviewRequest{RevieweeID: tt.revieweeID, Rating: 4}
+			_, err = reviewService.CreateReview(context.Background(), rideID, tt.reviewerID, req)
+			var serviceErr *Error
+			if !errors.As(err, &serviceErr) || serviceErr.Kind != tt.wantKind {
+				t.Fatalf("CreateReview() = %v, want a %v error", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For the job schedule
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// rideArchivalBatchSize bounds the rides archived per statement, so a backlog (e.g. after downtime)
+// doesn't lock many rides at once.
+const rideArchivalBatchSize = 500
+
+// RideArchivalJob moves active rides whose departure has passed to 'archived', so history and
+// reliability queries can rely on the status. Upcoming ride listings keep their departure check,
+// as a ride departs between two runs. Rides their creator didn't confirm are completed once their
+// estimated arrival passed by cfg.RideAutoCompleteDelay.
+type RideArchivalJob struct {
+	cfg    *config.Config
+	db     database.DBPool
+	events *EventBus
+}
+
+// NewRideArchivalJob creates a new RideArchivalJob instance.
+func NewRideArchivalJob(cfg *config.Config, db database.DBPool, events *EventBus) *RideArchivalJob {
+	return &RideArchivalJob{
+		cfg:    cfg,
+		db:     db,
+		events: events,
+	}
+}
+
+// Start archives departed rides and completes finished ones every cfg.RideArchivalInterval in the background.
+func (j *RideArchivalJob) Start() {
+	if j.cfg.RideArchivalInterval <= 0 {
+		log.Println("Ride archival job disabled (RIDE_ARCHIVAL_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.cfg.RideArchivalInterval)
+		defer ticker.Stop()
+		for {
+			if archived, err := j.ArchiveDeparted(context.Background()); err != nil {
+				log.Printf("Warning: Ride archival job failed: %v", err)
+			} else if archived > 0 {
+				log.Printf("Ride archival job archived %d rides", archived)
+			}
+			if completed, err := j.CompleteFinished(context.Background()); err != nil {
+				log.Printf("Warning: Ride completion job failed: %v", err)
+			} else if completed > 0 {
+				log.Printf("Ride archival job completed %d rides", completed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ArchiveDeparted archives every active ride whose departure has passed and publishes a
+// RideEventArchived for each. Rides locked by a running request are skipped until the next run.
+func (j *RideArchivalJob) ArchiveDeparted(ctx context.Context) (int, error) {
+	query := `
+		UPDATE rides SET status = $1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM rides
+			WHERE status = $2 AND departure_date + departure_time <= LOCALTIMESTAMP
+			ORDER BY departure_date + departure_time
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, departure_location_name, arrival_location_name, departure_date
+	`
+	archived := 0
+	for {
+		rows, err := j.db.Query(ctx, query, string(models.RideStatusArchived), string(models.RideStatusActive), rideArchivalBatchSize)
+		if err != nil {
+			return archived, fmt.Errorf("database error archiving rides: %w", err)
+		}
+		events, err := collectRideEvents(rows, RideEventArchived)
+		if err != nil {
+			return archived, fmt.Errorf("error processing archived rides: %w", err)
+		}
+
+		archived += len(events)
+		for _, event := range events {
+			j.events.Publish(event)
+		}
+		if len(events) < rideArchivalBatchSize {
+			return archived, nil
+		}
+	}
+}
+
+// CompleteFinished completes every active or archived ride whose estimated arrival (departure plus
+// route duration, when known) passed more than cfg.RideAutoCompleteDelay ago, with its active
+// participants, and publishes a RideEventCompleted for each.
+func (j *RideArchivalJob) CompleteFinished(ctx context.Context) (int, error) {
+	query := `
+		UPDATE rides SET status = $1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM rides
+			WHERE status IN ($2, $3)
+			  AND departure_date + departure_time + make_interval(secs => COALESCE(route_duration_seconds, 0) + $4) <= LOCALTIMESTAMP
+			ORDER BY departure_date + departure_time
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, departure_location_name, arrival_location_name, departure_date
+	`
+	completed := 0
+	for {
+		tx, err := j.db.Begin(ctx)
+		if err != nil {
+			return completed, fmt.Errorf("failed to start database transaction: %w", err)
+		}
+		rows, err := tx.Query(ctx, query, string(models.RideStatusCompleted), string(models.RideStatusActive), string(models.RideStatusArchived),
+			j.cfg.RideAutoCompleteDelay.Seconds(), rideArchivalBatchSize)
+		if err != nil {
+			tx.Rollback(ctx)
+			return completed, fmt.Errorf("database error completing rides: %w", err)
+		}
+		events, err := collectRideEvents(rows, RideEventCompleted)
+		if err != nil {
+			tx.Rollback(ctx)
+			return completed, fmt.Errorf("error processing completed rides: %w", err)
+		}
+		rideIDs := make([]uuid.UUID, len(events))
+		for i, event := range events {
+			rideIDs[i] = event.RideID
+		}
+		if _, err := completeRideParticipants(ctx, tx, rideIDs); err != nil {
+			tx.Rollback(ctx)
+			return completed, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return completed, fmt.Errorf("failed to finalize ride completion: %w", err)
+		}
+
+		completed += len(events)
+		for _, event := range events {
+			j.events.Publish(event)
+		}
+		if len(events) < rideArchivalBatchSize {
+			return completed, nil
+		}
+	}
+}
+
+// collectRideEvents reads the rides returned by a status change as events of the given type.
+func collectRideEvents(rows pgx.Rows, eventType RideEventType) ([]RideEvent, error) {
+	defer rows.Close()
+	var events []RideEvent
+	for rows.Next() {
+		event := RideEvent{Type: eventType}
+		if err := rows.Scan(&event.RideID, &event.UserID, &event.DepartureLocationName, &event.ArrivalLocationName, &event.DepartureDate); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// CompleteRide lets the creator confirm a departed ride took place. The ride and its active
+// participants are marked completed, which opens reviews. Rides the creator doesn't confirm are
+// completed by RideArchivalJob once their estimated arrival passed.
+func (s *RideService) CompleteRide(ctx context.Context, rideID, creatorID uuid.UUID) (*models.CompleteRideResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var ownerID uuid.UUID
+	var status string
+	var departed bool
+	rideQuery := `
+		SELECT user_id, status, departure_date + departure_time <= LOCALTIMESTAMP
+		FROM rides WHERE id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, rideQuery, rideID).Scan(&ownerID, &status, &departed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error fetching ride %s for completion: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if ownerID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can complete the ride")
+	}
+	switch status {
+	case string(models.RideStatusCompleted):
+		return nil, newError(KindConflict, "ride is already completed")
+	case string(models.RideStatusCancelled):
+		return nil, newError(KindConflict, "cancelled rides can't be completed")
+	}
+	if !departed {
+		return nil, newError(KindConflict, "rides can only be completed once they departed")
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE rides SET status = $1, updated_at = NOW() WHERE id = $2`, string(models.RideStatusCompleted), rideID); err != nil {
+		log.Printf("Error completing ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("database error completing ride: %w", err)
+	}
+	participants, err := completeRideParticipants(ctx, tx, []uuid.UUID{rideID})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing completion of ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("failed to finalize ride completion: %w", err)
+	}
+
+	log.Printf("Ride %s completed by its creator, %d participations completed", rideID, participants)
+	s.publishRideEvent(ctx, RideEventCompleted, rideID, creatorID)
+	return &models.CompleteRideResponse{RideID: rideID, CompletedParticipants: int(participants)}, nil
+}
+
+// completeRideParticipants marks the active participants of completed rides completed. Pending and
+// held bookings are left to expire or be reviewed.
+func completeRideParticipants(ctx context.Context, tx pgx.Tx, rideIDs []uuid.UUID) (int64, error) {
+	if len(rideIDs) == 0 {
+		return 0, nil
+	}
+	query := `UPDATE participants SET status = $1, updated_at = NOW() WHERE ride_id = ANY($2) AND status = $3`
+	tag, err := tx.Exec(ctx, query, string(models.ParticipantStatusCompleted), rideIDs, string(models.ParticipantStatusActive))
+	if err != nil {
+		log.Printf("Error completing participants of %d rides: %v", len(rideIDs), err)
+		return 0, fmt.Errorf("database error completing ride participants: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
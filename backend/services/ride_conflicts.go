@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// RideConflictError is returned when creating or joining a ride would overlap another ride the user
+// created or joined. Handlers map it to 409 Conflict with the conflicting ride, so the app can show
+// it and let the user confirm (retrying with ignore_conflicts).
+type RideConflictError struct {
+	Ride *models.Ride
+}
+
+func (e *RideConflictError) Error() string {
+	return "this ride overlaps another ride you are part of"
+}
+
+// checkRideConflict returns a *RideConflictError if the user created or joined an active ride, other
+// than excludeRideID, departing within RideConflictWindow of departsAt ("YYYY-MM-DD HH:MM").
+// A zero RideConflictWindow disables the check.
+func (s *RideService) checkRideConflict(ctx context.Context, q rowQuerier, userID uuid.UUID, departsAt string, excludeRideID uuid.UUID) error {
+	if s.cfg.RideConflictWindow <= 0 {
+		return nil
+	}
+	query := `
+		SELECT` + rideSelectColumns + `
+		FROM rides r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.status = $1 AND r.id <> $3
+		  AND (r.user_id = $2 OR EXISTS (
+			SELECT 1 FROM participants p
+			WHERE p.ride_id = r.id AND p.user_id = $2 AND p.status IN ('active', 'pending_payment', 'on_hold')
+		  ))
+		  AND r.departure_date + r.departure_time BETWEEN $4::timestamp - make_interval(secs => $5) AND $4::timestamp + make_interval(secs => $5)
+		ORDER BY abs(extract(epoch FROM r.departure_date + r.departure_time - $4::timestamp))
+		LIMIT 1
+	`
+	ride, err := scanRideRow(q.QueryRow(ctx, query, string(models.RideStatusActive), userID, excludeRideID,
+		departsAt, s.cfg.RideConflictWindow.Seconds()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error checking ride conflicts for user %s at %s: %v", userID, departsAt, err)
+		return fmt.Errorf("database error checking ride conflicts: %w", err)
+	}
+	log.Printf("User %s has ride %s within %s of %s", userID, ride.ID, s.cfg.RideConflictWindow, departsAt)
+	return &RideConflictError{Ride: ride}
+}
+
+const (
+	// driveTimeHorizon bounds how far apart rides are checked for drive time: any transfer fits in a day.
+	driveTimeHorizon = 24 * time.Hour
+	// driveFallbackSpeedKmh is the speed assumed over the straight line when no route is available. It
+	// is a motorway speed, so the estimate stays a lower bound and only truly impossible schedules are rejected.
+	driveFallbackSpeedKmh = 130.0
+)
+
+// DriveTimeError is returned when a driver creates a ride they couldn't drive to or from in time,
+// given another ride they created. Impossible schedules are rejected; schedules leaving less than
+// RideDriveBuffer of slack can be confirmed with ignore_conflicts. Handlers map it to 409 Conflict
+// with the details.
+type DriveTimeError struct {
+	Conflict models.DriveTimeConflict
+}
+
+func (e *DriveTimeError) Error() string {
+	if e.Conflict.Impossible {
+		return "you can't drive between this ride and another of your rides in time"
+	}
+	return "this ride leaves little time to drive to or from another of your rides"
+}
+
+// checkDriveTime returns a *DriveTimeError if the driver can't make it from their previous ride to
+// the new one, or from the new one to their next ride, with RideDriveBuffer to spare. Tight
+// schedules are allowed with ignoreTight; impossible ones never are. A zero RideDriveBuffer disables
+// the check.
+func (s *RideService) checkDriveTime(ctx context.Context, ride *models.Ride, ignoreTight bool) error {
+	if s.cfg.RideDriveBuffer <= 0 || ride.DepartureCoords == nil || ride.ArrivalCoords == nil {
+		return nil
+	}
+	departure, err := rideDepartureAt(ride.DepartureDate, ride.DepartureTime)
+	if err != nil {
+		return nil
+	}
+
+	for _, position := range []string{"before", "after"} {
+		other, err := s.adjacentDrivenRide(ctx, ride.UserID, departure, position == "before")
+		if err != nil {
+			return err
+		}
+		if other == nil || other.DepartureCoords == nil || other.ArrivalCoords == nil {
+			continue
+		}
+		otherDeparture, err := rideDepartureAt(other.DepartureDate, other.DepartureTime)
+		if err != nil {
+			continue
+		}
+		earlier, later, earlierDeparture, laterDeparture := ride, other, departure, otherDeparture
+		if position == "before" {
+			earlier, later, earlierDeparture, laterDeparture = other, ride, otherDeparture, departure
+		}
+		transfer := s.estimateDrive(ctx, earlier.ArrivalLocationName, *earlier.ArrivalCoords, later.DepartureLocationName, *later.DepartureCoords)
+		conflict := driveTimeConflict(earlierDeparture, s.rideDriveDuration(ctx, earlier), transfer, laterDeparture, s.cfg.RideDriveBuffer)
+		if conflict == nil || (ignoreTight && !conflict.Impossible) {
+			continue
+		}
+		conflict.Ride, conflict.Position = other, position
+		s.applySeatPrice(other)
+		log.Printf("Ride of user %s at %s leaves %d min for the %d min drive with ride %s (%s)",
+			ride.UserID, departure.Format("2006-01-02 15:04"), conflict.AvailableMinutes, conflict.TransferMinutes, other.ID, position)
+		return &DriveTimeError{Conflict: *conflict}
+	}
+	return nil
+}
+
+// adjacentDrivenRide returns the driver's active ride departing last before departure (or first
+// after it), within driveTimeHorizon, or nil if there is none.
+func (s *RideService) adjacentDrivenRide(ctx context.Context, userID uuid.UUID, departure time.Time, before bool) (*models.Ride, error) {
+	window, order := `BETWEEN $3::timestamp AND $3::timestamp + make_interval(secs => $4)`, "ASC"
+	if before {
+		window, order = `BETWEEN $3::timestamp - make_interval(secs => $4) AND $3::timestamp`, "DESC"
+	}
+	query := `
+		SELECT` + rideSelectColumns + `
+		FROM rides r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.user_id = $1 AND r.status = $2
+		  AND r.departure_date + r.departure_time ` + window + `
+		ORDER BY r.departure_date + r.departure_time ` + order + `
+		LIMIT 1
+	`
+	ride, err := scanRideRow(s.db.QueryRow(ctx, query, userID, string(models.RideStatusActive),
+		departure.Format("2006-01-02 15:04"), driveTimeHorizon.Seconds()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Error fetching rides of user %s around %s: %v", userID, departure, err)
+		return nil, fmt.Errorf("database error checking drive time between rides: %w", err)
+	}
+	return ride, nil
+}
+
+// rideDriveDuration returns a ride's estimated driving time: its route estimate, or estimateDrive's.
+func (s *RideService) rideDriveDuration(ctx context.Context, ride *models.Ride) time.Duration {
+	if ride.EstimatedDurationMinutes != nil {
+		return time.Duration(*ride.EstimatedDurationMinutes) * time.Minute
+	}
+	estimate := s.estimateDrive(ctx, ride.DepartureLocationName, *ride.DepartureCoords, ride.ArrivalLocationName, *ride.ArrivalCoords)
+	return time.Duration(estimate.DurationSeconds * float64(time.Second))
+}
+
+// estimateDrive estimates the drive between two places: from the travel matrix, then the routing
+// service, and when neither has it from the straight line at driveFallbackSpeedKmh.
+func (s *RideService) estimateDrive(ctx context.Context, fromName string, from models.GeoPoint, toName string, to models.GeoPoint) models.TravelEstimate {
+	if estimate, ok := s.travelMatrix.Lookup(fromName, toName); ok {
+		return estimate
+	}
+	if s.routing != nil {
+		route, err := s.routing.Route(ctx, from, to)
+		if err != nil {
+			log.Printf("Warning: Could not route %s to %s for the drive time check: %v", fromName, toName, err)
+		} else if route != nil {
+			return models.TravelEstimate{DistanceMeters: route.DistanceMeters, DurationSeconds: route.DurationSeconds}
+		}
+	}
+	km := haversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	return models.TravelEstimate{DistanceMeters: km * 1000, DurationSeconds: km / driveFallbackSpeedKmh * 3600}
+}
+
+// driveTimeConflict returns the conflict between an earlier and a later ride of a driver, or nil
+// if after the earlier ride's drive and the transfer to the later ride's departure, at least
+// buffer is left. Ride and Position are left to the caller.
+func driveTimeConflict(earlierDeparture time.Time, earlierDuration time.Duration, transfer models.TravelEstimate, laterDeparture time.Time, buffer time.Duration) *models.DriveTimeConflict {
+	available := laterDeparture.Sub(earlierDeparture.Add(earlierDuration))
+	transferTime := time.Duration(transfer.DurationSeconds * float64(time.Second))
+	if available >= transferTime+buffer {
+		return nil
+	}
+	return &models.DriveTimeConflict{
+		AvailableMinutes: int(math.Floor(available.Minutes())),
+		TransferMinutes:  int(math.Ceil(transferTime.Minutes())),
+		TransferKm:       roundedKm(transfer.DistanceMeters),
+		BufferMinutes:    int(math.Ceil(buffer.Minutes())),
+		Impossible:       available < transferTime,
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rideshare/backend/models"
+)
+
+// Test that rides are flagged as impossible when the transfer doesn't fit, and tight when the buffer doesn't
+func TestDriveTimeConflict(t *testing.T) {
+	earlier := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	transfer := models.TravelEstimate{DistanceMeters: 300000, DurationSeconds: 3 * 3600} // Lyon -> Paris, 300 km in 3h
+	buffer := 30 * time.Minute
+
+	tests := []struct {
+		name       string
+		later      time.Time
+		wantNil    bool
+		impossible bool
+	}{
+		{"departs 30 minutes after arriving", earlier.Add(time.Hour + 30*time.Minute), false, true},
+		{"transfer fits without the buffer", earlier.Add(4*time.Hour + 10*time.Minute), false, false},
+		{"transfer and buffer fit", earlier.Add(4*time.Hour + 30*time.Minute), true, false},
+	}
+	for _, tt := range tests {
+		conflict := driveTimeConflict(earlier, time.Hour, transfer, tt.later, buffer)
+		if tt.wantNil {
+			if conflict != nil {
+				t.Errorf("%s: driveTimeConflict() = %+v, want nil", tt.name, conflict)
+			}
+			continue
+		}
+		if conflict == nil {
+			t.Fatalf("%s: driveTimeConflict() = nil, want a conflict", tt.name)
+		}
+		if conflict.Impossible != tt.impossible {
+			t.Errorf("%s: Impossible = %v, want %v", tt.name, conflict.Impossible, tt.impossible)
+		}
+		if conflict.TransferMinutes != 180 || conflict.TransferKm != 300 || conflict.BufferMinutes != 30 {
+			t.Errorf("%s: conflict = %+v, want a 180 min, 300 km transfer and a 30 min buffer", tt.name, conflict)
+		}
+	}
+
+	// Overlapping rides leave negative time
+	if conflict := driveTimeConflict(earlier, time.Hour, models.TravelEstimate{}, earlier.Add(30*time.Minute), buffer); conflict == nil || !conflict.Impossible || conflict.AvailableMinutes != -30 {
+		t.Errorf("driveTimeConflict(overlapping) = %+v, want impossible with -30 available minutes", conflict)
+	}
+}
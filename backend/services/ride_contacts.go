@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For departure dates
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/models"
+)
+
+// defaultUpcomingContactsDays is how far ahead UpcomingRideContacts looks without a days parameter.
+const defaultUpcomingContactsDays = 7
+
+// UpcomingRideContacts groups the contacts of one of the user's upcoming rides.
+type UpcomingRideContacts struct {
+	RideID                uuid.UUID         `json:"ride_id"`
+	DepartureLocationName string            `json:"departure_location_name"`
+	ArrivalLocationName   string            `json:"arrival_location_name"`
+	DepartureDate         time.Time         `json:"departure_date"`
+	DepartureTime         string            `json:"departure_time"`
+	Contacts              []RideContactInfo `json:"contacts"` // Same contacts as GetRideContacts, the requesting user included
+}
+
+// UpcomingRideContacts returns, in one call, the contacts of every active ride departing within
+// the next days that the user created or joined (active participation), soonest first. Access
+// follows GetRideContacts: only rides where the user may see contacts are listed.
+func (s *RideService) UpcomingRideContacts(ctx context.Context, userID uuid.UUID, params models.UpcomingContactsRequest) ([]UpcomingRideContacts, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid upcoming contacts request: %w", err)
+	}
+	days := defaultUpcomingContactsDays
+	if params.Days != nil {
+		days = *params.Days
+	}
+
+	ridesQuery := `
+		SELECT r.id, r.departure_location_name, r.arrival_location_name, r.departure_date, to_char(r.departure_time, 'HH24:MI')
+		FROM rides r
+		LEFT JOIN participants p ON p.ride_id = r.id AND p.user_id = $1
+		WHERE (r.user_id = $1 OR p.status = $2) AND ` + upcomingRideCondition + `
+		  AND r.departure_date <= current_date + $3::int
+		ORDER BY r.departure_date, r.departure_time
+	`
+	rows, err := s.db.Query(ctx, ridesQuery, userID, string(models.ParticipantStatusActive), days)
+	if err != nil {
+		log.Printf("Error listing upcoming rides for contacts of user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching upcoming rides: %w", err)
+	}
+	result := []UpcomingRideContacts{}
+	byRide := make(map[uuid.UUID]int)
+	var rideIDs []uuid.UUID
+	for rows.Next() {
+		ride := UpcomingRideContacts{Contacts: []RideContactInfo{}}
+		if err := rows.Scan(&ride.RideID, &ride.DepartureLocationName, &ride.ArrivalLocationName, &ride.DepartureDate, &ride.DepartureTime); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error processing upcoming ride: %w", err)
+		}
+		byRide[ride.RideID] = len(result)
+		rideIDs = append(rideIDs, ride.RideID)
+		result = append(result, ride)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for upcoming rides: %w", err)
+	}
+	if len(rideIDs) == 0 {
+		return result, nil
+	}
+
+	contactsQuery := `
+		SELECT r.id, u.id, u.first_name, u.last_name, COALESCE(u.whatsapp_encrypted, u.whatsapp, ''), (r.user_id = u.id) AS is_creator,
+		       p.pickup_note, p.pickup_order, p.seat_label
+		FROM rides r
+		JOIN LATERAL (
+			SELECT r.user_id
+			UNION
+			SELECT user_id FROM participants WHERE ride_id = r.id AND status = $2
+		) member ON TRUE
+		JOIN users u ON u.id = member.user_id AND u.deleted_at IS NULL
+		LEFT JOIN participants p ON p.ride_id = r.id AND p.user_id = u.id
+		WHERE r.id = ANY($1)
+		ORDER BY r.id, is_creator DESC, p.pickup_order ASC NULLS LAST
+	`
+	contactRows, err := s.db.Query(ctx, contactsQuery, rideIDs, string(models.ParticipantStatusActive))
+	if err != nil {
+		log.Printf("Error fetching upcoming ride contacts for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching contacts: %w", err)
+	}
+	defer contactRows.Close()
+	for contactRows.Next() {
+		var rideID uuid.UUID
+		var contact RideContactInfo
+		if err := contactRows.Scan(&rideID, &contact.UserID, &contact.FirstName, &contact.LastName, &contact.WhatsApp, &contact.IsCreator,
+			&contact.PickupNote, &contact.PickupOrder, &contact.Seat); err != nil {
+			return nil, fmt.Errorf("error processing contact data: %w", err)
+		}
+		if contact.WhatsApp, err = s.crypto.Decrypt(contact.WhatsApp); err != nil {
+			log.Printf("Error decrypting contact of user %s for ride %s: %v", contact.UserID, rideID, err)
+			return nil, fmt.Errorf("error processing contact data: %w", err)
+		}
+		ride := &result[byRide[rideID]]
+		ride.Contacts = append(ride.Contacts, contact)
+	}
+	if err := contactRows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for contacts: %w", err)
+	}
+
+	log.Printf("Fetched contacts of %d upcoming ride(s) for user %s", len(result), userID)
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// DuplicateRide creates a new ride departing at req's date and time with the route, stops, seats,
+// price, vehicle and preferences of one of the user's rides, whatever its status. The copy goes
+// through CreateRide, so it is validated and checked like any new ride.
+func (s *RideService) DuplicateRide(ctx context.Context, rideID, userID uuid.UUID, req models.DuplicateRideRequest) (*models.Ride, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid departure: %w", err)
+	}
+
+	var creatorID uuid.UUID
+	var musicAllowed bool
+	create := models.CreateRideRequest{
+		DepartureCoords: &models.GeoPoint{},
+		ArrivalCoords:   &models.GeoPoint{},
+		DepartureDate:   req.DepartureDate,
+		DepartureTime:   req.DepartureTime,
+		IgnoreConflicts: req.IgnoreConflicts,
+	}
+	query := `
+		SELECT user_id,
+			departure_location_name, ST_X(departure_coords), ST_Y(departure_coords),
+			arrival_location_name, ST_X(arrival_coords), ST_Y(arrival_coords),
+			total_seats, cancellation_policy, min_age, passenger_gender, price_per_seat, vehicle_id,
+			smoking_allowed, pets_allowed, music_allowed, chat_level, luggage_capacity
+		FROM rides WHERE id = $1
+	`
+	err := s.db.QueryRow(ctx, query, rideID).Scan(&creatorID,
+		&create.DepartureLocationName, &create.DepartureCoords.Longitude, &create.DepartureCoords.Latitude,
+		&create.ArrivalLocationName, &create.ArrivalCoords.Longitude, &create.ArrivalCoords.Latitude,
+		&create.TotalSeats, &create.CancellationPolicy, &create.MinAge, &create.PassengerGender, &create.PricePerSeat, &create.VehicleID,
+		&create.SmokingAllowed, &create.PetsAllowed, &musicAllowed, &create.ChatLevel, &create.LuggageCapacity,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error fetching ride %s to duplicate: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if creatorID != userID {
+		return nil, newError(KindForbidden, "only the ride creator can duplicate the ride")
+	}
+	create.MusicAllowed = &musicAllowed
+
+	stops, err := loadRideStops(ctx, s.db, rideID)
+	if err != nil {
+		return nil, err
+	}
+	for _, stop := range stops {
+		create.Stops = append(create.Stops, models.RideStopRequest{LocationName: stop.LocationName, Coords: stop.Coords})
+	}
+
+	ride, err := s.CreateRide(ctx, create, userID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("User %s duplicated ride %s as ride %s", userID, rideID, ride.ID)
+	return ride, nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context" // For database operations
+	"errors"  // For error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For trimming emails
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// ErrInvitationNotFound is returned when an invitation doesn't exist, isn't pending or concerns someone else.
+var ErrInvitationNotFound = newError(KindNotFound, "no pending invitation found")
+
+// RideInvitationService lets creators invite people to their rides. An invitation pre-approves the
+// invitee, who joins in one tap; the booking then goes through payment like any other join.
+type RideInvitationService struct {
+	db            database.DBPool
+	validator     *validator.Validate
+	rides         *RideService         // Runs the join when an invitation is accepted
+	notifications *NotificationService // Tells invitees about their invitation
+	crypto        *FieldEncryptor      // Matches WhatsApp numbers on their blind index
+}
+
+// NewRideInvitationService creates a new RideInvitationService instance.
+func NewRideInvitationService(db database.DBPool, rides *RideService, notifications *NotificationService, crypto *FieldEncryptor) *RideInvitationService {
+	return &RideInvitationService{
+		db:            db,
+		validator:     NewValidator(),
+		rides:         rides,
+		notifications: notifications,
+		crypto:        crypto,
+	}
+}
+
+// findInvitee resolves the invited user from their user ID, email or WhatsApp number.
+func (s *RideInvitationService) findInvitee(ctx context.Context, req models.CreateRideInvitationRequest) (uuid.UUID, error) {
+	var condition string
+	var arg any
+	switch {
+	case req.UserID != nil:
+		condition, arg = `id = $1`, *req.UserID
+	case req.Email != nil:
+		condition, arg = `email = $1`, strings.TrimSpace(*req.Email)
+	default:
+		// WhatsApp numbers are encrypted, so they're matched on their blind index
+		condition, arg = `whatsapp_hash = $1`, s.crypto.BlindIndex(*req.WhatsApp)
+	}
+	var inviteeID uuid.UUID
+	err := s.db.QueryRow(ctx, `SELECT id FROM users WHERE `+condition+` AND deleted_at IS NULL`, arg).Scan(&inviteeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, newError(KindNotFound, "no user found with these details")
+		}
+		return uuid.Nil, fmt.Errorf("database error finding invitee: %w", err)
+	}
+	return inviteeID, nil
+}
+
+// Invite invites a user to the creator's active ride and notifies them. A user has at most one
+// pending invitation per ride, and current participants can't be invited.
+func (s *RideInvitationService) Invite(ctx context.Context, rideID, creatorID uuid.UUID, req models.CreateRideInvitationRequest) (*models.RideInvitation, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid invitation: %w", err)
+	}
+	var ownerID uuid.UUID
+	var status, route string
+	query := `SELECT user_id, status, departure_location_name || ' → ' || arrival_location_name FROM rides WHERE id = $1`
+	if err := s.db.QueryRow(ctx, query, rideID).Scan(&ownerID, &status, &route); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if ownerID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can invite people to the ride")
+	}
+	if status != string(models.RideStatusActive) {
+		return nil, newError(KindConflict, "only active rides accept invitations")
+	}
+
+	inviteeID, err := s.findInvitee(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if inviteeID == creatorID {
+		return nil, newError(KindInvalid, "you can't invite yourself to your own ride")
+	}
+	var participating bool
+	participantQuery := `SELECT EXISTS(SELECT 1 FROM participants WHERE ride_id = $1 AND user_id = $2 AND status = ANY($3))`
+	if err := s.db.QueryRow(ctx, participantQuery, rideID, inviteeID, currentParticipantStatuses).Scan(&participating); err != nil {
+		return nil, fmt.Errorf("database error checking participation: %w", err)
+	}
+	if participating {
+		return nil, newError(KindConflict, "this user already joined the ride")
+	}
+
+	invitation := &models.RideInvitation{RideID: rideID, InvitedBy: creatorID, InviteeID: inviteeID, Route: route}
+	insertQuery := `
+		INSERT INTO ride_invitations (ride_id, invited_by, invitee_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (ride_id, invitee_id) WHERE status = 'pending' DO NOTHING
+		RETURNING id, status, created_at
+	`
+	err = s.db.QueryRow(ctx, insertQuery, rideID, creatorID, inviteeID).Scan(&invitation.ID, &invitation.Status, &invitation.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindConflict, "this user already has a pending invitation to the ride")
+		}
+		log.Printf("Error inviting user %s to ride %s: %v", inviteeID, rideID, err)
+		return nil, fmt.Errorf("database error creating invitation: %w", err)
+	}
+
+	log.Printf("User %s invited user %s to ride %s", creatorID, inviteeID, rideID)
+	s.notifications.Notify(ctx, inviteeID, models.NotificationEventRideInvitation, &rideID,
+		"You're invited to a ride", fmt.Sprintf("You were invited to join the ride %s. Tap to book your seat.", route))
+	return invitation, nil
+}
+
+// ListRideInvitations returns the invitations of the creator's ride, most recent first.
+func (s *RideInvitationService) ListRideInvitations(ctx context.Context, rideID, creatorID uuid.UUID) ([]models.RideInvitation, error) {
+	var ownerID uuid.UUID
+	if err := s.db.QueryRow(ctx, `SELECT user_id FROM rides WHERE id = $1`, rideID).Scan(&ownerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if ownerID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can view its invitations")
+	}
+	return s.listInvitations(ctx, `i.ride_id = $1`, rideID)
+}
+
+// ListPendingInvitations returns the invitations to active rides waiting for the user's answer.
+func (s *RideInvitationService) ListPendingInvitations(ctx context.Context, userID uuid.UUID) ([]models.RideInvitation, error) {
+	return s.listInvitations(ctx, `i.invitee_id = $1 AND i.status = 'pending' AND r.status = 'active'`, userID)
+}
+
+// listInvitations returns the invitations matching 'filter' (a condition on $1), most recent first.
+func (s *RideInvitationService) listInvitations(ctx context.Context, filter string, arg uuid.UUID) ([]models.RideInvitation, error) {
+	query := `
+		SELECT i.id, i.ride_id, i.invited_by, i.invitee_id, u.first_name, i.status, i.created_at, i.responded_at,
+			r.departure_location_name || ' → ' || r.arrival_location_name
+		FROM ride_invitations i
+		JOIN rides r ON r.id = i.ride_id
+		JOIN users u ON u.id = i.invitee_id
+		WHERE ` + filter + `
+		ORDER BY i.created_at DESC
+	`
+	rows, err := s.db.Query(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []models.RideInvitation{}
+	for rows.Next() {
+		var i models.RideInvitation
+		if err := rows.Scan(&i.ID, &i.RideID, &i.InvitedBy, &i.InviteeID, &i.InviteeFirstName, &i.Status, &i.CreatedAt, &i.RespondedAt, &i.Route); err != nil {
+			return nil, fmt.Errorf("error processing invitations: %w", err)
+		}
+		invitations = append(invitations, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation joins the invitee to the ride for the whole route. The join runs the usual
+// checks (seats, age, conflicts, fraud rules) and the booking waits for payment as any other.
+func (s *RideInvitationService) AcceptInvitation(ctx context.Context, invitationID, userID uuid.UUID, req models.AcceptRideInvitationRequest) (*models.Participant, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid invitation answer: %w", err)
+	}
+	var rideID uuid.UUID
+	query := `SELECT ride_id FROM ride_invitations WHERE id = $1 AND invitee_id = $2 AND status = 'pending'`
+	if err := s.db.QueryRow(ctx, query, invitationID, userID).Scan(&rideID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("database error fetching invitation: %w", err)
+	}
+
+	participant, err := s.rides.JoinRide(ctx, rideID, userID, models.JoinRideRequest{Seats: req.Seats, IgnoreConflicts: req.IgnoreConflicts})
+	if err != nil {
+		return nil, err
+	}
+	// The booking stands even if recording the answer fails; the invitation just stays listed
+	if _, err := s.closeInvitation(ctx, invitationID, `invitee_id = $3`, userID, models.RideInvitationStatusAccepted); err != nil {
+		log.Printf("Warning: User %s joined ride %s but accepting invitation %s failed: %v", userID, rideID, invitationID, err)
+	}
+	return participant, nil
+}
+
+// DeclineInvitation lets the invitee refuse an invitation.
+func (s *RideInvitationService) DeclineInvitation(ctx context.Context, invitationID, userID uuid.UUID) (*models.RideInvitation, error) {
+	return s.closeInvitation(ctx, invitationID, `invitee_id = $3`, userID, models.RideInvitationStatusDeclined)
+}
+
+// RevokeInvitation lets the creator withdraw a pending invitation.
+func (s *RideInvitationService) RevokeInvitation(ctx context.Context, invitationID, creatorID uuid.UUID) (*models.RideInvitation, error) {
+	return s.closeInvitation(ctx, invitationID, `invited_by = $3`, creatorID, models.RideInvitationStatusRevoked)
+}
+
+// closeInvitation ends a pending invitation if 'party' (a condition on $3) matches the user.
+func (s *RideInvitationService) closeInvitation(ctx context.Context, invitationID uuid.UUID, party string, userID uuid.UUID, status models.RideInvitationStatus) (*models.RideInvitation, error) {
+	query := `
+		UPDATE ride_invitations i
+		SET status = $2, responded_at = NOW()
+		FROM rides r
+		WHERE i.id = $1 AND i.status = 'pending' AND i.` + party + ` AND r.id = i.ride_id
+		RETURNING i.id, i.ride_id, i.invited_by, i.invitee_id, i.status, i.created_at, i.responded_at,
+			r.departure_location_name || ' → ' || r.arrival_location_name
+	`
+	var i models.RideInvitation
+	err := s.db.QueryRow(ctx, query, invitationID, string(status), userID).Scan(&i.ID, &i.RideID, &i.InvitedBy, &i.InviteeID, &i.Status, &i.CreatedAt, &i.RespondedAt, &i.Route)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("database error updating invitation: %w", err)
+	}
+	log.Printf("Invitation %s to ride %s %s by user %s", i.ID, i.RideID, status, userID)
+	return &i, nil
+}
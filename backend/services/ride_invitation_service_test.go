@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/models"
+)
+
+// Test that an invitation names its invitee exactly once
+func TestCreateRideInvitationRequest_Validation(t *testing.T) {
+	userID, email, whatsapp := uuid.New(), "ana@example.com", "+33612345678"
+	v := NewValidator()
+	tests := []struct {
+		name    string
+		req     models.CreateRideInvitationRequest
+		wantErr bool
+	}{
+		{"user ID", models.CreateRideInvitationRequest{UserID: &userID}, false},
+		{"email", models.CreateRideInvitationRequest{Email: &email}, false},
+		{"WhatsApp number", models.CreateRideInvitationRequest{WhatsApp: &whatsapp}, false},
+		{"no invitee", models.CreateRideInvitationRequest{}, true},
+		{"user ID and email", models.CreateRideInvitationRequest{UserID: &userID, Email: &email}, true},
+		{"email and WhatsApp number", models.CreateRideInvitationRequest{Email: &email, WhatsApp: &whatsapp}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.Struct(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("Struct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test that only the ride creator can invite people
+func TestRideInvitationService_Invite_NotCreator(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	invitationService := NewRideInvitationService(mock, nil, nil, nil)
+	rideID, inviteeID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).
+		WillReturnRows(pgxmock.NewRows([]string{"user_id", "status", "route"}).AddRow(uuid.New(), "active", "Lyon → Paris"))
+
+	_, err = invitationService.Invite(context.Background(), rideID, uuid.New(), models.CreateRideInvitationRequest{UserID: &inviteeID})
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind != KindForbidden {
+		t.Fatalf("Invite by another user = %v, want a KindForbidden error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
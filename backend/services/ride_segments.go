@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// seatLeg is the part of a route a participant travels, as stop positions: boards at From, alights at To,
+// and the number of seats booked on it. Position 0 is the departure and stopCount+1 the arrival.
+type seatLeg struct {
+	From  int
+	To    int
+	Seats int
+}
+
+// fullRouteLeg is the one-seat leg from departure to arrival of a ride with stopCount intermediate stops.
+func fullRouteLeg(stopCount int) seatLeg {
+	return seatLeg{From: 0, To: stopCount + 1, Seats: 1}
+}
+
+// resolveLeg turns the stops and seats of a join request into a leg, defaulting to one seat on the whole route.
+func resolveLeg(stopCount int, req models.JoinRideRequest) (seatLeg, error) {
+	leg := fullRouteLeg(stopCount)
+	if req.Seats != nil {
+		leg.Seats = *req.Seats
+	}
+	if req.BoardingStop != nil {
+		leg.From = *req.BoardingStop
+	}
+	if req.AlightingStop != nil {
+		leg.To = *req.AlightingStop
+	}
+	if leg.From < 0 || leg.To > stopCount+1 {
+		return leg, newError(KindInvalid, fmt.Sprintf("stops must be between 0 (departure) and %d (arrival)", stopCount+1))
+	}
+	if leg.From >= leg.To {
+		return leg, newError(KindInvalid, "boarding stop must come before the alighting stop")
+	}
+	return leg, nil
+}
+
+// segmentOccupancy sums the seats of the legs covering each segment of a route with stopCount intermediate
+// stops. Segment i runs from stop i to stop i+1, so there are stopCount+1 segments.
+func segmentOccupancy(stopCount int, legs []seatLeg) []int {
+	occupancy := make([]int, stopCount+1)
+	for _, leg := range legs {
+		for segment := max(leg.From, 0); segment < leg.To && segment < len(occupancy); segment++ {
+			occupancy[segment] += leg.Seats
+		}
+	}
+	return occupancy
+}
+
+// legHasSeat reports whether the leg's seats are free on every segment of it. A seat freed at an
+// intermediate stop can be taken for the remaining segments.
+func legHasSeat(occupancy []int, leg seatLeg, totalSeats int) bool {
+	for segment := leg.From; segment < leg.To; segment++ {
+		if occupancy[segment]+leg.Seats > totalSeats {
+			return false
+		}
+	}
+	return true
+}
+
+// loadSeatOccupancy returns the number of intermediate stops of a ride and the seats taken on each segment,
+// by active participants and by joiners within their checkout seat hold.
+func loadSeatOccupancy(ctx context.Context, q rowQuerier, rideID uuid.UUID) (int, []int, error) {
+	var stopCount int
+	if err := q.QueryRow(ctx, `SELECT COUNT(*) FROM ride_stops WHERE ride_id = $1`, rideID).Scan(&stopCount); err != nil {
+		return 0, nil, fmt.Errorf("database error counting ride stops: %w", err)
+	}
+
+	query := `
+		SELECT boarding_stop, COALESCE(alighting_stop, $3), seat_count
+		FROM participants
+		WHERE ride_id = $1 AND (status = $2 OR (status = $4 AND seat_held_until > NOW()))
+	`
+	rows, err := q.Query(ctx, query, rideID, string(models.ParticipantStatusActive), stopCount+1, string(models.ParticipantStatusPendingPayment))
+	if err != nil {
+		return 0, nil, fmt.Errorf("database error loading participant legs: %w", err)
+	}
+	defer rows.Close()
+
+	var legs []seatLeg
+	for rows.Next() {
+		var leg seatLeg
+		if err := rows.Scan(&leg.From, &leg.To, &leg.Seats); err != nil {
+			return 0, nil, fmt.Errorf("error processing participant leg: %w", err)
+		}
+		legs = append(legs, leg)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("database iteration error for participant legs: %w", err)
+	}
+	return stopCount, segmentOccupancy(stopCount, legs), nil
+}
+
+// loadRideStops returns the intermediate stops of a ride in driving order.
+func loadRideStops(ctx context.Context, q rowQuerier, rideID uuid.UUID) ([]models.RideStop, error) {
+	query := `
+		SELECT position, location_name, ST_X(coords), ST_Y(coords)
+		FROM ride_stops
+		WHERE ride_id = $1
+		ORDER BY position
+	`
+	rows, err := q.Query(ctx, query, rideID)
+	if err != nil {
+		return nil, fmt.Errorf("database error fetching ride stops: %w", err)
+	}
+	defer rows.Close()
+
+	var stops []models.RideStop
+	for rows.Next() {
+		stop := models.RideStop{Coords: &models.GeoPoint{}}
+		if err := rows.Scan(&stop.Position, &stop.LocationName, &stop.Coords.Longitude, &stop.Coords.Latitude); err != nil {
+			return nil, fmt.Errorf("error processing ride stop: %w", err)
+		}
+		stops = append(stops, stop)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ride stops: %w", err)
+	}
+	return stops, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"rideshare/backend/models"
+)
+
+// Test that a seat freed at an intermediate stop can be booked for the remaining legs only
+func TestLegHasSeat(t *testing.T) {
+	// Two stops, so three segments: departure->1, 1->2, 2->arrival
+	occupancy := segmentOccupancy(2, []seatLeg{{From: 0, To: 1, Seats: 2}, {From: 0, To: 3, Seats: 1}})
+	if got, want := occupancy, []int{3, 1, 1}; !slices.Equal(got, want) {
+		t.Fatalf("segmentOccupancy() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		leg  seatLeg
+		want bool
+	}{
+		{"after drop-off", seatLeg{From: 1, To: 3, Seats: 1}, true},
+		{"middle leg", seatLeg{From: 1, To: 2, Seats: 1}, true},
+		{"overlaps full first leg", seatLeg{From: 0, To: 2, Seats: 1}, false},
+		{"whole route", seatLeg{From: 0, To: 3, Seats: 1}, false},
+		{"group after drop-off", seatLeg{From: 1, To: 3, Seats: 2}, true},
+		{"group larger than free seats", seatLeg{From: 1, To: 3, Seats: 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legHasSeat(occupancy, tt.leg, 3); got != tt.want {
+				t.Errorf("legHasSeat(%v) = %v, want %v", tt.leg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLeg(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name    string
+		req     models.JoinRideRequest
+		want    seatLeg
+		wantErr bool
+	}{
+		{"defaults to whole route", models.JoinRideRequest{}, seatLeg{From: 0, To: 3, Seats: 1}, false},
+		{"boarding only", models.JoinRideRequest{BoardingStop: intPtr(1)}, seatLeg{From: 1, To: 3, Seats: 1}, false},
+		{"alighting only", models.JoinRideRequest{AlightingStop: intPtr(2)}, seatLeg{From: 0, To: 2, Seats: 1}, false},
+		{"several seats", models.JoinRideRequest{Seats: intPtr(3)}, seatLeg{From: 0, To: 3, Seats: 3}, false},
+		{"alighting before boarding", models.JoinRideRequest{BoardingStop: intPtr(2), AlightingStop: intPtr(1)}, seatLeg{}, true},
+		{"same stop", models.JoinRideRequest{BoardingStop: intPtr(1), AlightingStop: intPtr(1)}, seatLeg{}, true},
+		{"past the arrival", models.JoinRideRequest{AlightingStop: intPtr(4)}, seatLeg{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveLeg(2, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveLeg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("resolveLeg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
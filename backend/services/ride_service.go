@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -12,24 +15,106 @@ import (
 	"github.com/jackc/pgx/v5"         // For pgx errors
 	"github.com/jackc/pgx/v5/pgxpool" // Import pgxpool for transaction interface check
 
+	"rideshare/backend/config"
 	"rideshare/backend/database"
 	"rideshare/backend/models"
 )
 
 // RideService handles business logic related to rides.
 type RideService struct {
-	validator *validator.Validate
-	db        database.DBPool // Use the DBPool interface
+	cfg           *config.Config
+	validator     *validator.Validate
+	db            database.DBPool      // Use the DBPool interface
+	refundPolicy  *RefundPolicyEngine  // Computes refunds from the ride's cancellation policy
+	notifications *NotificationService // Notification dispatcher
+	fraud         *FraudService        // Fraud rules evaluated on join
+	quotas        *QuotaService        // Per-account abuse quotas on ride creation and joins
+	events        *EventBus            // Ride change events (search cache invalidation)
+	searchCache   *SearchCache         // First pages of common searches (nil = disabled)
+	routing       *RoutingService      // Driving routes and estimates computed at ride creation
+	travelMatrix  *TravelMatrix        // Cached driving estimates between frequent city pairs
+	holidays      *HolidayCalendar     // Peak travel days (nil = none)
+	driverStats   *DriverStatsCache    // Creator reliability on listings (nil = disabled)
+	crypto        *FieldEncryptor      // Decrypts WhatsApp numbers for ride contacts
+	moderation    *ModerationService   // Screens text shown to other users (removal reasons)
+	serviceAreas  *ServiceAreaService  // Regions rides may depart from and arrive in (nil = anywhere)
 }
 
 // NewRideService creates a new RideService instance.
-func NewRideService(db database.DBPool) *RideService {
+func NewRideService(cfg *config.Config, db database.DBPool, notifications *NotificationService, fraud *FraudService, quotas *QuotaService, events *EventBus, searchCache *SearchCache, travelMatrix *TravelMatrix, holidays *HolidayCalendar, driverStats *DriverStatsCache, crypto *FieldEncryptor, moderation *ModerationService) *RideService {
 	return &RideService{
-		validator: validator.New(),
-		db:        db,
+		cfg:           cfg,
+		validator:     NewValidator(),
+		db:            db,
+		refundPolicy:  NewRefundPolicyEngine(cfg),
+		notifications: notifications,
+		fraud:         fraud,
+		quotas:        quotas,
+		events:        events,
+		searchCache:   searchCache,
+		routing:       NewRoutingService(cfg),
+		travelMatrix:  travelMatrix,
+		holidays:      holidays,
+		driverStats:   driverStats,
+		crypto:        crypto,
+		moderation:    moderation,
+		serviceAreas:  NewServiceAreaService(cfg, db),
 	}
 }
 
+// publishRideEvent publishes a committed ride change. The ride's route and date are looked up
+// so subscribers can tell which searches it affects; they stay empty if the ride is gone.
+func (s *RideService) publishRideEvent(ctx context.Context, eventType RideEventType, rideID uuid.UUID, userID uuid.UUID) {
+	if !s.events.HasSubscribers() {
+		return
+	}
+	event := RideEvent{Type: eventType, RideID: rideID, UserID: userID}
+	query := `SELECT departure_location_name, arrival_location_name, departure_date FROM rides WHERE id = $1`
+	err := s.db.QueryRow(ctx, query, rideID).Scan(&event.DepartureLocationName, &event.ArrivalLocationName, &event.DepartureDate)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Warning: Could not load ride %s for %s event: %v", rideID, eventType, err)
+	}
+	s.events.Publish(event)
+}
+
+// checkJoinQuota enforces the per-account join quota. Shared by the manual and automatic join flows.
+func (s *RideService) checkJoinQuota(ctx context.Context, userID uuid.UUID) error {
+	if s.quotas == nil {
+		return nil
+	}
+	return s.quotas.Check(ctx, userID, models.QuotaRideJoins)
+}
+
+// recordJoin counts a successful join (including held bookings) towards the join quota and fraud rules.
+func (s *RideService) recordJoin(ctx context.Context, userID uuid.UUID, fraudCheck models.FraudCheck) {
+	if s.quotas != nil {
+		s.quotas.Record(ctx, userID, models.QuotaRideJoins)
+	}
+	if s.fraud != nil {
+		s.fraud.RecordEvent(ctx, fraudCheck)
+	}
+}
+
+// nearbyDepartureRadiusMeters is the radius within which rides count as departing near the caller in search defaults.
+const nearbyDepartureRadiusMeters = 100000
+
+// seatsTakenSubquery counts the seats taken on ride r: active participants, and joiners still within
+// their checkout seat hold, so the last seat can't be taken while its holder is paying.
+const seatsTakenSubquery = `(SELECT COALESCE(SUM(p_taken.seat_count), 0) FROM participants p_taken WHERE p_taken.ride_id = r.id
+			AND (p_taken.status = 'active' OR (p_taken.status = 'pending_payment' AND p_taken.seat_held_until > NOW())))`
+
+// rideSelectColumns is the SELECT list shared by ride listing queries, in the order expected by scanRideRow.
+// Queries using it must alias rides as 'r' and join the creator as 'u'.
+const rideSelectColumns = `
+			r.id, r.user_id,
+			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
+			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
+			r.departure_date, r.departure_time, r.total_seats, r.status, r.cancellation_policy, r.created_at, r.updated_at,
+			` + seatsTakenSubquery + ` AS places_taken,
+			u.first_name AS creator_first_name, r.price_per_seat,
+			r.smoking_allowed, r.pets_allowed, r.music_allowed, r.chat_level, r.luggage_capacity,
+			r.distance_meters, r.route_distance_meters, r.route_duration_seconds, r.passenger_gender`
+
 // CreateRide handles the creation of a new ride.
 func (s *RideService) CreateRide(ctx context.Context, req models.CreateRideRequest, userID uuid.UUID) (*models.Ride, error) {
 	// 1. Validate request data
@@ -40,14 +125,19 @@ func (s *RideService) CreateRide(ctx context.Context, req models.CreateRideReque
 	// Ensure coordinates are provided in the request
 	if req.DepartureCoords == nil || req.ArrivalCoords == nil {
 		log.Printf("Error creating ride for user %s: Departure or Arrival coordinates are missing in request", userID)
-		return nil, errors.New("departure or arrival coordinates are required")
+		return nil, newError(KindInvalid, "departure or arrival coordinates are required")
+	}
+	if s.quotas != nil {
+		if err := s.quotas.Check(ctx, userID, models.QuotaRidesCreated); err != nil {
+			return nil, err
+		}
 	}
 
 	// 2. Parse date and time strings
 	departureDate, err := time.Parse("2006-01-02", req.DepartureDate)
 	if err != nil {
 		log.Printf("Error parsing departure date '%s' for user %s: %v", req.DepartureDate, userID, err)
-		return nil, fmt.Errorf("invalid departure date format (use YYYY-MM-DD): %w", err)
+		return nil, &Error{Kind: KindInvalid, Message: "invalid departure date format (use YYYY-MM-DD)", Err: err}
 	}
 
 	// 3. Validate departure time is in the future
@@ -56,14 +146,53 @@ func (s *RideService) CreateRide(ctx context.Context, req models.CreateRideReque
 	departureDateTime, err := time.Parse(layout, departureDateTimeStr)
 	if err != nil {
 		log.Printf("Error combining departure date and time '%s %s' for user %s: %v", req.DepartureDate, req.DepartureTime, userID, err)
-		return nil, fmt.Errorf("invalid departure date or time format: %w", err)
+		return nil, &Error{Kind: KindInvalid, Message: "invalid departure date or time format", Err: err}
 	}
 	if departureDateTime.Before(time.Now()) {
 		log.Printf("Validation error: Departure date/time %s is in the past for user %s", departureDateTime, userID)
-		return nil, errors.New("departure date and time must be in the future")
+		return nil, newError(KindInvalid, "departure date and time must be in the future")
 	}
 
-	// 4. Create the ride in the database
+	if req.PricePerSeat != nil && (*req.PricePerSeat < s.cfg.SeatPriceMinCents || *req.PricePerSeat > s.cfg.SeatPriceMaxCents) {
+		log.Printf("Validation error creating ride for user %s: seat price %d outside %d-%d", userID, *req.PricePerSeat, s.cfg.SeatPriceMinCents, s.cfg.SeatPriceMaxCents)
+		return nil, newError(KindInvalid, fmt.Sprintf("price_per_seat must be between %d and %d cents", s.cfg.SeatPriceMinCents, s.cfg.SeatPriceMaxCents))
+	}
+
+	if err := s.serviceAreas.CheckRide(ctx, *req.DepartureCoords, *req.ArrivalCoords); err != nil {
+		log.Printf("Rejected ride of user %s: %v", userID, err)
+		return nil, err
+	}
+
+	if err := checkCreatorGender(ctx, s.db, userID, req.PassengerGender); err != nil {
+		log.Printf("Rejected ride of user %s restricted to %s passengers: %v", userID, *req.PassengerGender, err)
+		return nil, err
+	}
+
+	var vehicle *models.RideVehicle
+	if req.VehicleID != nil {
+		vehicle = &models.RideVehicle{}
+		vehicleQuery := `SELECT make, model, color FROM vehicles WHERE id = $1 AND user_id = $2`
+		if err := s.db.QueryRow(ctx, vehicleQuery, *req.VehicleID, userID).Scan(&vehicle.Make, &vehicle.Model, &vehicle.Color); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrVehicleNotFound
+			}
+			return nil, fmt.Errorf("database error checking vehicle: %w", err)
+		}
+	}
+
+	// 4. Resolve the cancellation policy within platform bounds
+	policy, err := s.refundPolicy.ResolvePolicy(req.CancellationPolicy)
+	if err != nil {
+		log.Printf("Validation error creating ride for user %s: %v", userID, err)
+		return nil, err
+	}
+	if !req.IgnoreConflicts {
+		if err := s.checkRideConflict(ctx, s.db, userID, departureDateTimeStr, uuid.Nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Create the ride in the database
 	newRide := &models.Ride{
 		ID:                    uuid.New(),
 		UserID:                userID,
@@ -75,6 +204,36 @@ func (s *RideService) CreateRide(ctx context.Context, req models.CreateRideReque
 		DepartureTime:         req.DepartureTime,
 		TotalSeats:            req.TotalSeats,
 		Status:                string(models.RideStatusActive),
+		CancellationPolicy:    string(policy),
+		MinAge:                req.MinAge,
+		PassengerGender:       req.PassengerGender,
+		PricePerSeat:          req.PricePerSeat,
+		Vehicle:               vehicle,
+		SmokingAllowed:        req.SmokingAllowed,
+		PetsAllowed:           req.PetsAllowed,
+		MusicAllowed:          req.MusicAllowed == nil || *req.MusicAllowed,
+		ChatLevel:             req.ChatLevel,
+		LuggageCapacity:       req.LuggageCapacity,
+	}
+	if newRide.ChatLevel == "" {
+		newRide.ChatLevel = string(models.ChatLevelModerate)
+	}
+	if newRide.LuggageCapacity == "" {
+		newRide.LuggageCapacity = string(models.LuggageSmall)
+	}
+
+	// The route is optional: if routing fails the ride is still created, and clients draw a straight line
+	var distance, routeDistance, routeDuration *float64 // Route estimates are cached for the ride's ETA
+	route, err := s.routing.Route(ctx, *req.DepartureCoords, *req.ArrivalCoords)
+	if err != nil {
+		log.Printf("Warning: Could not compute route for new ride of user %s: %v", userID, err)
+	} else if route != nil {
+		newRide.RoutePolyline = &route.Polyline
+		routeDistance, routeDuration = &route.DistanceMeters, &route.DurationSeconds
+		setTravelEstimate(newRide, route.DistanceMeters, route.DurationSeconds)
+	}
+	if err := s.checkDriveTime(ctx, newRide, req.IgnoreConflicts); err != nil {
+		return nil, err
 	}
 
 	// Use ST_SetSRID(ST_MakePoint(longitude, latitude), 4326) for inserting coordinates
@@ -83,31 +242,78 @@ func (s *RideService) CreateRide(ctx context.Context, req models.CreateRideReque
 			id, user_id,
 			departure_location_name, departure_coords,
 			arrival_location_name, arrival_coords,
-			departure_date, departure_time, total_seats, status
+			departure_date, departure_time, total_seats, status, cancellation_policy,
+			departure_geohash, arrival_geohash, route_polyline, route_geometry, min_age, price_per_seat, vehicle_id,
+			smoking_allowed, pets_allowed, music_allowed, chat_level, luggage_capacity,
+			route_distance_meters, route_duration_seconds, passenger_gender, distance_meters
 		)
-		VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, ST_SetSRID(ST_MakePoint($7, $8), 4326), $9, $10, $11, $12)
-		RETURNING created_at, updated_at
+		VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, ST_SetSRID(ST_MakePoint($7, $8), 4326), $9, $10, $11, $12, $13, $14, $15, $16, ST_LineFromEncodedPolyline($16), $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27,
+			ST_Distance(ST_SetSRID(ST_MakePoint($4, $5), 4326)::geography, ST_SetSRID(ST_MakePoint($7, $8), 4326)::geography))
+		RETURNING created_at, updated_at, distance_meters
 	`
-	err = s.db.QueryRow(ctx, insertQuery,
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction for new ride of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, insertQuery,
 		newRide.ID, newRide.UserID,
 		newRide.DepartureLocationName, newRide.DepartureCoords.Longitude, newRide.DepartureCoords.Latitude, // Lon, Lat for departure
 		newRide.ArrivalLocationName, newRide.ArrivalCoords.Longitude, newRide.ArrivalCoords.Latitude, // Lon, Lat for arrival
-		newRide.DepartureDate, newRide.DepartureTime, newRide.TotalSeats, newRide.Status,
-	).Scan(&newRide.CreatedAt, &newRide.UpdatedAt)
+		newRide.DepartureDate, newRide.DepartureTime, newRide.TotalSeats, newRide.Status, newRide.CancellationPolicy,
+		EncodeGeohash(newRide.DepartureCoords.Latitude, newRide.DepartureCoords.Longitude, geohashPrecision),
+		EncodeGeohash(newRide.ArrivalCoords.Latitude, newRide.ArrivalCoords.Longitude, geohashPrecision),
+		newRide.RoutePolyline, newRide.MinAge, newRide.PricePerSeat, req.VehicleID,
+		newRide.SmokingAllowed, newRide.PetsAllowed, newRide.MusicAllowed, newRide.ChatLevel, newRide.LuggageCapacity,
+		routeDistance, routeDuration, newRide.PassengerGender,
+	).Scan(&newRide.CreatedAt, &newRide.UpdatedAt, &distance)
 
 	if err != nil {
 		log.Printf("Error inserting new ride for user %s: %v", userID, err)
 		return nil, fmt.Errorf("failed to create ride in database: %w", err)
 	}
+	setRideDistance(newRide, distance)
+
+	// Intermediate stops, numbered from 1 in driving order
+	for i, stop := range req.Stops {
+		position := i + 1
+		_, err = tx.Exec(ctx, `
+			INSERT INTO ride_stops (ride_id, position, location_name, coords)
+			VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326))
+		`, newRide.ID, position, stop.LocationName, stop.Coords.Longitude, stop.Coords.Latitude)
+		if err != nil {
+			log.Printf("Error inserting stop %d of new ride for user %s: %v", position, userID, err)
+			return nil, fmt.Errorf("failed to create ride stops in database: %w", err)
+		}
+		newRide.Stops = append(newRide.Stops, models.RideStop{Position: position, LocationName: stop.LocationName, Coords: stop.Coords})
+	}
+	if err = tx.Commit(ctx); err != nil {
+		log.Printf("Error committing new ride for user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to finalize ride creation: %w", err)
+	}
 
 	log.Printf("Ride created successfully by user %s: Ride ID %s", userID, newRide.ID)
+	if s.quotas != nil {
+		s.quotas.Record(ctx, userID, models.QuotaRidesCreated)
+	}
+	s.events.Publish(RideEvent{
+		Type:                  RideEventCreated,
+		RideID:                newRide.ID,
+		UserID:                userID,
+		DepartureLocationName: newRide.DepartureLocationName,
+		ArrivalLocationName:   newRide.ArrivalLocationName,
+		DepartureDate:         newRide.DepartureDate,
+	})
 	return newRide, nil
 }
 
 // scanRideRow scans a row from a rides query into a models.Ride struct, handling coordinates.
 func scanRideRow(rows pgx.Row) (*models.Ride, error) {
 	var ride models.Ride
-	var depLon, depLat, arrLon, arrLat *float64 // Use pointers to handle potential NULLs from LEFT JOINs or if coords aren't selected
+	var depLon, depLat, arrLon, arrLat *float64         // Use pointers to handle potential NULLs from LEFT JOINs or if coords aren't selected
+	var distance, routeDistance, routeDuration *float64 // Route estimates are NULL until routing succeeded for the ride
 
 	// Adjust scan arguments based on the specific query's SELECT list
 	// This example assumes all standard fields + coordinates + creator name + places taken are selected
@@ -116,13 +322,20 @@ func scanRideRow(rows pgx.Row) (*models.Ride, error) {
 		&ride.DepartureLocationName, &depLon, &depLat,
 		&ride.ArrivalLocationName, &arrLon, &arrLat,
 		&ride.DepartureDate, &ride.DepartureTime, &ride.TotalSeats,
-		&ride.Status, &ride.CreatedAt, &ride.UpdatedAt,
+		&ride.Status, &ride.CancellationPolicy, &ride.CreatedAt, &ride.UpdatedAt,
 		&ride.PlacesTaken,      // Assumes this is calculated/selected in the query
 		&ride.CreatorFirstName, // Assumes this is joined/selected in the query
+		&ride.PricePerSeat,
+		&ride.SmokingAllowed, &ride.PetsAllowed, &ride.MusicAllowed, &ride.ChatLevel, &ride.LuggageCapacity,
+		&distance, &routeDistance, &routeDuration, &ride.PassengerGender,
 	)
 	if err != nil {
 		return nil, err // Return scan error directly
 	}
+	setRideDistance(&ride, distance)
+	if routeDistance != nil && routeDuration != nil {
+		setTravelEstimate(&ride, *routeDistance, *routeDuration)
+	}
 
 	// Populate GeoPoint structs if coordinates were scanned successfully
 	if depLon != nil && depLat != nil {
@@ -134,23 +347,47 @@ func scanRideRow(rows pgx.Row) (*models.Ride, error) {
 	return &ride, nil
 }
 
-// scanRideRowBasic scans a row with basic ride details + coordinates + creator name
+// scanRideRowBasic scans a row with basic ride details + coordinates + creator name + route polyline + pickup point + vehicle
 func scanRideRowBasic(row pgx.Row) (*models.Ride, error) {
 	var ride models.Ride
 	var depLon, depLat, arrLon, arrLat *float64
+	var pickupID *uuid.UUID
+	var pickupName, pickupKind, pickupCity *string
+	var pickupLon, pickupLat *float64
+	var vehicleMake, vehicleModel, vehicleColor *string
+	var distance, routeDistance, routeDuration *float64
 
 	err := row.Scan(
 		&ride.ID, &ride.UserID,
 		&ride.DepartureLocationName, &depLon, &depLat,
 		&ride.ArrivalLocationName, &arrLon, &arrLat,
 		&ride.DepartureDate, &ride.DepartureTime, &ride.TotalSeats,
-		&ride.Status,
+		&ride.Status, &ride.CancellationPolicy,
 		&ride.CreatedAt, &ride.UpdatedAt,
 		&ride.CreatorFirstName, // Assumes creator name is joined
+		&ride.RoutePolyline,
+		&pickupID, &pickupName, &pickupKind, &pickupLon, &pickupLat, &pickupCity,
+		&ride.MinAge, &ride.PricePerSeat,
+		&vehicleMake, &vehicleModel, &vehicleColor,
+		&ride.SmokingAllowed, &ride.PetsAllowed, &ride.MusicAllowed, &ride.ChatLevel, &ride.LuggageCapacity,
+		&distance, &routeDistance, &routeDuration, &ride.PassengerGender,
 	)
 	if err != nil {
 		return nil, err
 	}
+	setRideDistance(&ride, distance)
+	if routeDistance != nil && routeDuration != nil {
+		setTravelEstimate(&ride, *routeDistance, *routeDuration)
+	}
+	if vehicleMake != nil && vehicleModel != nil && vehicleColor != nil {
+		ride.Vehicle = &models.RideVehicle{Make: *vehicleMake, Model: *vehicleModel, Color: *vehicleColor}
+	}
+	if pickupID != nil && pickupName != nil && pickupKind != nil && pickupLon != nil && pickupLat != nil {
+		ride.PickupPoint = &models.PickupPoint{
+			ID: *pickupID, Name: *pickupName, Kind: *pickupKind, City: pickupCity,
+			Location: models.GeoPoint{Longitude: *pickupLon, Latitude: *pickupLat},
+		}
+	}
 
 	if depLon != nil && depLat != nil {
 		ride.DepartureCoords = &models.GeoPoint{Longitude: *depLon, Latitude: *depLat}
@@ -161,26 +398,63 @@ func scanRideRowBasic(row pgx.Row) (*models.Ride, error) {
 	return &ride, nil
 }
 
-// ListAvailableRides retrieves a list of rides that are currently 'active'.
-func (s *RideService) ListAvailableRides(ctx context.Context) ([]models.Ride, error) {
+// setRideDistance sets a ride's distance_km from its stored distance_meters, if any.
+func setRideDistance(ride *models.Ride, meters *float64) {
+	if meters == nil {
+		return
+	}
+	km := roundedKm(*meters)
+	ride.DistanceKm = &km
+}
+
+// applySeatPrice flags rides departing on peak travel days and shows the booking fee (the peak fee
+// on those days) as the seat price of a ride without its own price.
+func (s *RideService) applySeatPrice(ride *models.Ride) {
+	peak := s.holidays.Flag(ride)
+	if ride.PricePerSeat == nil {
+		fee := bookingFee(s.cfg.Runtime(), peak)
+		ride.PricePerSeat = &fee
+	}
+}
+
+// rideListDefaultLimit is the page size of the ride lists when none is requested.
+const rideListDefaultLimit = 20
+
+// rideListPage validates the pagination of a ride list and returns its LIMIT and OFFSET.
+func (s *RideService) rideListPage(params models.RideListRequest) (int, int, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return 0, 0, fmt.Errorf("invalid pagination: %w", err)
+	}
+	limit := rideListDefaultLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	offset := 0
+	if params.Page != nil && *params.Page > 1 {
+		offset = (*params.Page - 1) * limit
+	}
+	return limit, offset, nil
+}
+
+// ListAvailableRides retrieves a page of rides that are currently 'active', soonest first.
+func (s *RideService) ListAvailableRides(ctx context.Context, params models.RideListRequest) ([]models.Ride, error) {
+	limit, offset, err := s.rideListPage(params)
+	if err != nil {
+		return nil, err
+	}
 	rides := []models.Ride{}
 	query := `
-		SELECT
-			r.id, r.user_id,
-			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
-			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status, r.created_at, r.updated_at,
-			(SELECT COUNT(*) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active') AS places_taken,
-			u.first_name AS creator_first_name
+		SELECT` + rideSelectColumns + `
 		FROM rides r
 		JOIN users u ON r.user_id = u.id
 		WHERE r.status = $1
 		  AND (r.departure_date > current_date OR (r.departure_date = current_date AND r.departure_time > current_time))
-		  AND (SELECT COUNT(*) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active') < r.total_seats
-		ORDER BY r.departure_date ASC, r.departure_time ASC
+		  AND ` + seatsTakenSubquery + ` < r.total_seats
+		ORDER BY r.departure_date ASC, r.departure_time ASC, r.id
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := s.db.Query(ctx, query, string(models.RideStatusActive))
+	rows, err := s.db.Query(ctx, query, string(models.RideStatusActive), limit, offset)
 	if err != nil {
 		log.Printf("Error querying available rides: %v", err)
 		return nil, fmt.Errorf("database error fetching rides: %w", err)
@@ -193,6 +467,7 @@ func (s *RideService) ListAvailableRides(ctx context.Context) ([]models.Ride, er
 			log.Printf("Error scanning available ride row: %v", err)
 			return nil, fmt.Errorf("error processing ride data: %w", err)
 		}
+		s.applySeatPrice(ride)
 		rides = append(rides, *ride)
 	}
 
@@ -201,6 +476,7 @@ func (s *RideService) ListAvailableRides(ctx context.Context) ([]models.Ride, er
 		return nil, fmt.Errorf("database iteration error: %w", err)
 	}
 
+	s.driverStats.Attach(ctx, rides)
 	log.Printf("Fetched %d available rides", len(rides))
 	return rides, nil
 }
@@ -212,27 +488,40 @@ func (s *RideService) GetRideDetails(ctx context.Context, rideID uuid.UUID) (*mo
 			r.id, r.user_id,
 			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
 			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status,
+			r.departure_date, r.departure_time, r.total_seats, r.status, r.cancellation_policy,
 			r.created_at, r.updated_at,
-			u.first_name AS creator_first_name
+			u.first_name AS creator_first_name,
+			r.route_polyline,
+			pp.id, pp.name, pp.kind, ST_X(pp.location), ST_Y(pp.location), pp.city,
+			r.min_age, r.price_per_seat,
+			v.make, v.model, v.color,
+			r.smoking_allowed, r.pets_allowed, r.music_allowed, r.chat_level, r.luggage_capacity,
+			r.distance_meters, r.route_distance_meters, r.route_duration_seconds, r.passenger_gender
 		FROM rides r
 		JOIN users u ON r.user_id = u.id
+		LEFT JOIN pickup_points pp ON pp.id = r.pickup_point_id
+		LEFT JOIN vehicles v ON v.id = r.vehicle_id
 		WHERE r.id = $1
 	`
 	ride, err := scanRideRowBasic(s.db.QueryRow(ctx, query, rideID)) // Use basic scanner
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("Ride not found: ID %s", rideID)
-			return nil, errors.New("ride not found")
+			return nil, ErrRideNotFound
 		}
 		log.Printf("Error fetching ride details for ID %s: %v", rideID, err)
 		return nil, fmt.Errorf("database error fetching ride details: %w", err)
 	}
 
-	// Calculate places taken separately
+	s.applySeatPrice(ride)
+	if ride.EstimatedDurationMinutes == nil {
+		s.cacheRideTravelEstimate(ctx, ride)
+	}
+
+	// Calculate places taken separately (active participants and unexpired checkout holds)
 	var activeParticipantsCount int
-	countQuery := `SELECT COUNT(*) FROM participants WHERE ride_id = $1 AND status = $2`
-	err = s.db.QueryRow(ctx, countQuery, rideID, string(models.ParticipantStatusActive)).Scan(&activeParticipantsCount)
+	countQuery := `SELECT COALESCE(SUM(seat_count), 0) FROM participants WHERE ride_id = $1 AND (status = $2 OR (status = $3 AND seat_held_until > NOW()))`
+	err = s.db.QueryRow(ctx, countQuery, rideID, string(models.ParticipantStatusActive), string(models.ParticipantStatusPendingPayment)).Scan(&activeParticipantsCount)
 	if err != nil {
 		log.Printf("Error counting active participants for ride %s during GetRideDetails: %v", rideID, err)
 		ride.PlacesTaken = 0 // Fallback
@@ -240,18 +529,128 @@ func (s *RideService) GetRideDetails(ctx context.Context, rideID uuid.UUID) (*mo
 		ride.PlacesTaken = activeParticipantsCount
 	}
 
+	// Waypoint rides: seats taken per leg, since a seat freed at a stop can be booked for the rest of the route
+	stops, err := loadRideStops(ctx, s.db, rideID)
+	if err != nil {
+		log.Printf("Error fetching stops for ride %s during GetRideDetails: %v", rideID, err)
+		return nil, err
+	}
+	if len(stops) > 0 {
+		ride.Stops = stops
+		if _, occupancy, err := loadSeatOccupancy(ctx, s.db, rideID); err != nil {
+			log.Printf("Error computing seat occupancy for ride %s during GetRideDetails: %v", rideID, err)
+		} else {
+			ride.SegmentSeatsTaken = occupancy
+		}
+	}
+
+	details := []models.Ride{*ride}
+	s.driverStats.Attach(ctx, details)
+	ride.DriverStats = details[0].DriverStats
+
 	log.Printf("Fetched details for ride ID %s (Places Taken: %d)", rideID, ride.PlacesTaken)
 	return ride, nil
 }
 
+// cacheRideTravelEstimate computes the driving estimate of a ride created without one (routing was
+// unavailable, or the ride predates estimates) and stores it, so the route is computed once per
+// ride. When routing still fails, the travel matrix estimate of the ride's city pair is shown.
+func (s *RideService) cacheRideTravelEstimate(ctx context.Context, ride *models.Ride) {
+	if ride.DepartureCoords != nil && ride.ArrivalCoords != nil {
+		route, err := s.routing.Route(ctx, *ride.DepartureCoords, *ride.ArrivalCoords)
+		if err != nil {
+			log.Printf("Warning: Could not compute route for ride %s: %v", ride.ID, err)
+		} else if route != nil {
+			setTravelEstimate(ride, route.DistanceMeters, route.DurationSeconds)
+			query := `UPDATE rides SET route_distance_meters = $2, route_duration_seconds = $3 WHERE id = $1 AND route_duration_seconds IS NULL`
+			if _, err := s.db.Exec(ctx, query, ride.ID, route.DistanceMeters, route.DurationSeconds); err != nil {
+				log.Printf("Warning: Could not store travel estimate of ride %s: %v", ride.ID, err)
+			}
+			return
+		}
+	}
+	rides := []models.Ride{*ride}
+	s.travelMatrix.Enrich(rides)
+	*ride = rides[0]
+}
+
+// SetPickupPoint attaches a curated pickup point to an active ride as its official pickup location,
+// or clears it when pickupPointID is nil. Only the creator may change it, and the point must be
+// active and near the ride's departure.
+func (s *RideService) SetPickupPoint(ctx context.Context, rideID uuid.UUID, userID uuid.UUID, pickupPointID *uuid.UUID) (*models.Ride, error) {
+	var creatorID uuid.UUID
+	var status string
+	err := s.db.QueryRow(ctx, `SELECT user_id, status FROM rides WHERE id = $1`, rideID).Scan(&creatorID, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error fetching ride %s for pickup point update: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if creatorID != userID {
+		return nil, newError(KindForbidden, "only the ride creator can set its pickup point")
+	}
+	if status != string(models.RideStatusActive) {
+		return nil, newError(KindConflict, "pickup point can only be set on an active ride")
+	}
+
+	if pickupPointID != nil {
+		var nearDeparture bool
+		query := `
+			SELECT ST_DWithin(pp.location::geography, r.departure_coords::geography, $3)
+			FROM pickup_points pp, rides r
+			WHERE pp.id = $1 AND pp.is_active AND r.id = $2
+		`
+		err = s.db.QueryRow(ctx, query, *pickupPointID, rideID, maxPickupDistanceMeters).Scan(&nearDeparture)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, newError(KindNotFound, "pickup point not found")
+			}
+			log.Printf("Error checking pickup point %s for ride %s: %v", *pickupPointID, rideID, err)
+			return nil, fmt.Errorf("database error checking pickup point: %w", err)
+		}
+		if !nearDeparture {
+			return nil, newError(KindInvalid, fmt.Sprintf("pickup point must be within %d m of the departure", maxPickupDistanceMeters))
+		}
+	}
+
+	_, err = s.db.Exec(ctx, `UPDATE rides SET pickup_point_id = $1, updated_at = NOW() WHERE id = $2`, pickupPointID, rideID)
+	if err != nil {
+		log.Printf("Error setting pickup point on ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("database error updating ride: %w", err)
+	}
+	log.Printf("User %s set pickup point %v on ride %s", userID, pickupPointID, rideID)
+	s.publishRideEvent(ctx, RideEventUpdated, rideID, userID)
+	return s.GetRideDetails(ctx, rideID)
+}
+
 // JoinRide allows a user to join an existing ride.
-func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.Participant, error) {
+// req picks the leg of the route on waypoint rides; the zero value books the whole route.
+func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID, req models.JoinRideRequest) (*models.Participant, error) {
 	pool, ok := s.db.(*pgxpool.Pool)
 	if !ok {
 		log.Println("Warning: Database pool does not support transactions, proceeding without.")
 		return nil, errors.New("database does not support transactions required for JoinRide")
 	}
 
+	if err := s.checkJoinQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// Fraud rules: a hold keeps the booking as 'on_hold' (no payment possible) until an admin reviews it
+	joinStatus := string(models.ParticipantStatusPendingPayment)
+	fraudCheck := models.FraudCheck{Event: models.FraudEventJoin, UserID: &userID, RideID: &rideID}
+	if s.fraud != nil && s.fraud.Evaluate(ctx, fraudCheck).Action == models.FraudActionHold {
+		joinStatus = string(models.ParticipantStatusOnHold)
+	}
+	// The seat is held during checkout so nobody else takes it while the joiner pays
+	var seatHeldUntil *time.Time
+	if joinStatus == string(models.ParticipantStatusPendingPayment) && s.cfg.SeatHoldDuration > 0 {
+		holdEnd := time.Now().Add(s.cfg.SeatHoldDuration)
+		seatHeldUntil = &holdEnd
+	}
+
 	tx, err := pool.Begin(ctx)
 	if err != nil {
 		log.Printf("Error starting transaction for joining ride %s by user %s: %v", rideID, userID, err)
@@ -261,19 +660,20 @@ func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uui
 
 	// 1. Get ride details and lock the row (only need fields for validation)
 	var ride models.Ride
+	var departsAt string
 	lockQuery := `
-		SELECT id, user_id, total_seats, status
+		SELECT id, user_id, total_seats, status, min_age, passenger_gender, to_char(departure_date + departure_time, 'YYYY-MM-DD HH24:MI')
 		FROM rides
 		WHERE id = $1
 		FOR UPDATE
 	`
 	err = tx.QueryRow(ctx, lockQuery, rideID).Scan(
-		&ride.ID, &ride.UserID, &ride.TotalSeats, &ride.Status,
+		&ride.ID, &ride.UserID, &ride.TotalSeats, &ride.Status, &ride.MinAge, &ride.PassengerGender, &departsAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("JoinRide failed: Ride not found: ID %s", rideID)
-			return nil, errors.New("ride not found")
+			return nil, ErrRideNotFound
 		}
 		log.Printf("Error fetching/locking ride %s for join by user %s: %v", rideID, userID, err)
 		return nil, fmt.Errorf("database error fetching ride: %w", err)
@@ -282,24 +682,39 @@ func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uui
 	// 2. Check ride status and availability
 	if ride.Status != string(models.RideStatusActive) {
 		log.Printf("JoinRide failed: Ride %s is not active (status: %s)", rideID, ride.Status)
-		return nil, errors.New("ride is not active for joining")
+		return nil, newError(KindConflict, "ride is not active for joining")
 	}
 
-	var activeParticipantsCount int
-	countQuery := `SELECT COUNT(*) FROM participants WHERE ride_id = $1 AND status = $2`
-	err = tx.QueryRow(ctx, countQuery, rideID, string(models.ParticipantStatusActive)).Scan(&activeParticipantsCount)
+	// Capacity is checked on the segments of the requested leg only
+	stopCount, occupancy, err := loadSeatOccupancy(ctx, tx, rideID)
 	if err != nil {
-		log.Printf("Error counting active participants for ride %s: %v", rideID, err)
-		return nil, fmt.Errorf("database error checking ride capacity: %w", err)
+		log.Printf("Error loading seat occupancy for ride %s: %v", rideID, err)
+		return nil, err
 	}
-
-	if activeParticipantsCount >= ride.TotalSeats {
-		log.Printf("JoinRide failed: Ride %s is full (%d/%d seats taken)", rideID, activeParticipantsCount, ride.TotalSeats)
-		return nil, errors.New("ride is already full")
+	leg, err := resolveLeg(stopCount, req)
+	if err != nil {
+		return nil, err
+	}
+	if !legHasSeat(occupancy, leg, ride.TotalSeats) {
+		log.Printf("JoinRide failed: Ride %s has no %d free seat(s) between stops %d and %d (%v of %d seats taken)", rideID, leg.Seats, leg.From, leg.To, occupancy, ride.TotalSeats)
+		return nil, ErrRideFull
 	}
 	if ride.UserID == userID {
 		log.Printf("JoinRide failed: User %s cannot join their own ride %s", userID, rideID)
-		return nil, errors.New("you cannot join your own ride")
+		return nil, ErrCannotJoinOwnRide
+	}
+	if err := s.checkJoinAge(ctx, tx, userID, ride.MinAge); err != nil {
+		log.Printf("JoinRide failed: Age check for user %s on ride %s: %v", userID, rideID, err)
+		return nil, err
+	}
+	if err := checkJoinGender(ctx, tx, userID, ride.PassengerGender); err != nil {
+		log.Printf("JoinRide failed: Gender check for user %s on ride %s: %v", userID, rideID, err)
+		return nil, err
+	}
+	if !req.IgnoreConflicts {
+		if err := s.checkRideConflict(ctx, tx, userID, departsAt, rideID); err != nil {
+			return nil, err
+		}
 	}
 
 	// 3. Check existing participation
@@ -311,24 +726,37 @@ func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uui
 		switch existingParticipant.Status {
 		case string(models.ParticipantStatusActive), string(models.ParticipantStatusPendingPayment):
 			log.Printf("JoinRide failed: User %s has already joined ride %s with status '%s'", userID, rideID, existingParticipant.Status)
-			return nil, errors.New("you have already joined this ride or payment is pending")
+			return nil, newError(KindConflict, "you have already joined this ride or payment is pending")
+		case string(models.ParticipantStatusOnHold):
+			log.Printf("JoinRide failed: User %s has a booking on hold for ride %s", userID, rideID)
+			return nil, ErrBookingOnHold
+		case string(models.ParticipantStatusRemoved):
+			log.Printf("JoinRide failed: User %s was removed from ride %s by its creator", userID, rideID)
+			return nil, ErrRemovedFromRide
 		case string(models.ParticipantStatusLeft):
-			log.Printf("User %s previously left ride %s. Updating status to pending_payment.", userID, rideID)
-			updateStatusQuery := `UPDATE participants SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING created_at, updated_at` // Also return timestamps
-			updateErr := tx.QueryRow(ctx, updateStatusQuery, string(models.ParticipantStatusPendingPayment), existingParticipant.ID).Scan(&existingParticipant.CreatedAt, &existingParticipant.UpdatedAt)
+			log.Printf("User %s previously left ride %s. Updating status to %s.", userID, rideID, joinStatus)
+			updateStatusQuery := `UPDATE participants SET status = $1, boarding_stop = $3, alighting_stop = $4, seat_held_until = $5, seat_count = $6, updated_at = NOW() WHERE id = $2 RETURNING created_at, updated_at` // Also return timestamps
+			updateErr := tx.QueryRow(ctx, updateStatusQuery, joinStatus, existingParticipant.ID, leg.From, leg.To, seatHeldUntil, leg.Seats).Scan(&existingParticipant.CreatedAt, &existingParticipant.UpdatedAt)
 			if updateErr != nil {
 				log.Printf("Error updating status for rejoining participant %s on ride %s: %v", userID, rideID, updateErr)
 				return nil, fmt.Errorf("failed to update participation status for rejoin: %w", updateErr)
 			}
-			existingParticipant.Status = string(models.ParticipantStatusPendingPayment)
+			existingParticipant.Status = joinStatus
 			existingParticipant.UserID = userID // Ensure UserID and RideID are set
 			existingParticipant.RideID = rideID
+			existingParticipant.BoardingStop, existingParticipant.AlightingStop = leg.From, leg.To
+			existingParticipant.SeatHeldUntil = seatHeldUntil
+			existingParticipant.SeatCount = leg.Seats
 			// Commit transaction after successful update
 			commitErr := tx.Commit(ctx)
 			if commitErr != nil {
 				log.Printf("Error committing transaction after rejoining ride %s by user %s: %v", rideID, userID, commitErr)
 				return nil, fmt.Errorf("failed to finalize rejoining ride: %w", commitErr)
 			}
+			s.recordJoin(ctx, userID, fraudCheck)
+			if seatHeldUntil != nil {
+				s.publishRideEvent(ctx, RideEventJoined, rideID, userID) // The held seat is taken until the hold ends
+			}
 			return &existingParticipant, nil
 		default:
 			log.Printf("JoinRide failed: User %s has an unexpected participation status '%s' for ride %s", userID, rideID, existingParticipant.Status)
@@ -341,18 +769,23 @@ func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uui
 
 	// 4. Create NEW participant record
 	newParticipant := &models.Participant{
-		ID:     uuid.New(),
-		UserID: userID,
-		RideID: rideID,
-		Status: string(models.ParticipantStatusPendingPayment),
+		ID:            uuid.New(),
+		UserID:        userID,
+		RideID:        rideID,
+		Status:        joinStatus,
+		BoardingStop:  leg.From,
+		AlightingStop: leg.To,
+		SeatHeldUntil: seatHeldUntil,
+		SeatCount:     leg.Seats,
 	}
 	insertParticipantQuery := `
-		INSERT INTO participants (id, user_id, ride_id, status)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO participants (id, user_id, ride_id, status, boarding_stop, alighting_stop, seat_held_until, seat_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING created_at, updated_at
 	`
 	err = tx.QueryRow(ctx, insertParticipantQuery,
 		newParticipant.ID, newParticipant.UserID, newParticipant.RideID, newParticipant.Status,
+		newParticipant.BoardingStop, newParticipant.AlightingStop, newParticipant.SeatHeldUntil, newParticipant.SeatCount,
 	).Scan(&newParticipant.CreatedAt, &newParticipant.UpdatedAt)
 	if err != nil {
 		log.Printf("Error inserting participant for user %s on ride %s: %v", userID, rideID, err)
@@ -367,26 +800,31 @@ func (s *RideService) JoinRide(ctx context.Context, rideID uuid.UUID, userID uui
 	}
 
 	log.Printf("User %s successfully joined ride %s (Participant ID: %s). Status: %s", userID, rideID, newParticipant.ID, newParticipant.Status)
+	s.recordJoin(ctx, userID, fraudCheck)
+	if seatHeldUntil != nil {
+		s.publishRideEvent(ctx, RideEventJoined, rideID, userID) // The held seat is taken until the hold ends
+	}
 	return newParticipant, nil
 }
 
 // ValidateRideForJoiningTx performs validation checks within an existing transaction.
-func (s *RideService) ValidateRideForJoiningTx(ctx context.Context, tx pgx.Tx, rideID uuid.UUID, userID uuid.UUID) (*models.Ride, error) {
+func (s *RideService) ValidateRideForJoiningTx(ctx context.Context, tx pgx.Tx, rideID uuid.UUID, userID uuid.UUID, seats int, ignoreConflicts bool) (*models.Ride, error) {
 	var ride models.Ride
-	// Only select fields needed for validation
+	var departsAt string
+	// Only select fields needed for validation, and the seat price and date to charge
 	lockQuery := `
-		SELECT id, user_id, total_seats, status
+		SELECT id, user_id, total_seats, status, min_age, passenger_gender, to_char(departure_date + departure_time, 'YYYY-MM-DD HH24:MI'), price_per_seat, departure_date
 		FROM rides
 		WHERE id = $1
 		FOR UPDATE
 	`
 	err := tx.QueryRow(ctx, lockQuery, rideID).Scan(
-		&ride.ID, &ride.UserID, &ride.TotalSeats, &ride.Status,
+		&ride.ID, &ride.UserID, &ride.TotalSeats, &ride.Status, &ride.MinAge, &ride.PassengerGender, &departsAt, &ride.PricePerSeat, &ride.DepartureDate,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("ValidationTx failed: Ride not found: ID %s", rideID)
-			return nil, errors.New("ride not found")
+			return nil, ErrRideNotFound
 		}
 		log.Printf("Error fetching/locking ride %s for validation by user %s: %v", rideID, userID, err)
 		return nil, fmt.Errorf("database error fetching ride for validation: %w", err)
@@ -394,29 +832,42 @@ func (s *RideService) ValidateRideForJoiningTx(ctx context.Context, tx pgx.Tx, r
 
 	if ride.Status != string(models.RideStatusActive) {
 		log.Printf("ValidationTx failed: Ride %s is not active (status: %s)", rideID, ride.Status)
-		return nil, errors.New("ride is not open for joining")
+		return nil, newError(KindConflict, "ride is not open for joining")
 	}
 
-	var activeParticipantsCount int
-	countQuery := `SELECT COUNT(*) FROM participants WHERE ride_id = $1 AND status = $2`
-	err = tx.QueryRow(ctx, countQuery, rideID, string(models.ParticipantStatusActive)).Scan(&activeParticipantsCount)
+	// Automatic joins book the whole route, so every segment needs the seats free
+	stopCount, occupancy, err := loadSeatOccupancy(ctx, tx, rideID)
 	if err != nil {
-		log.Printf("Error counting active participants for ride %s during validation: %v", rideID, err)
-		return nil, fmt.Errorf("database error checking ride capacity: %w", err)
+		log.Printf("Error loading seat occupancy for ride %s during validation: %v", rideID, err)
+		return nil, err
 	}
-
-	if activeParticipantsCount >= ride.TotalSeats {
-		log.Printf("ValidationTx failed: Ride %s is full (%d/%d seats taken)", rideID, activeParticipantsCount, ride.TotalSeats)
-		return nil, errors.New("ride is already full")
+	leg := fullRouteLeg(stopCount)
+	leg.Seats = seats
+	if !legHasSeat(occupancy, leg, ride.TotalSeats) {
+		log.Printf("ValidationTx failed: Ride %s has no %d free seat(s) (%v of %d seats taken)", rideID, seats, occupancy, ride.TotalSeats)
+		return nil, ErrRideFull
 	}
 
 	if ride.UserID == userID {
 		log.Printf("ValidationTx failed: User %s cannot join their own ride %s", userID, rideID)
-		return nil, errors.New("you cannot join your own ride")
+		return nil, ErrCannotJoinOwnRide
+	}
+	if err := s.checkJoinAge(ctx, tx, userID, ride.MinAge); err != nil {
+		log.Printf("ValidationTx failed: Age check for user %s on ride %s: %v", userID, rideID, err)
+		return nil, err
+	}
+	if err := checkJoinGender(ctx, tx, userID, ride.PassengerGender); err != nil {
+		log.Printf("ValidationTx failed: Gender check for user %s on ride %s: %v", userID, rideID, err)
+		return nil, err
+	}
+	if !ignoreConflicts {
+		if err := s.checkRideConflict(ctx, tx, userID, departsAt, rideID); err != nil {
+			return nil, err
+		}
 	}
 
 	var participationCount int
-	countQuery = `SELECT COUNT(*) FROM participants WHERE ride_id = $1 AND user_id = $2 AND status = $3`
+	countQuery := `SELECT COUNT(*) FROM participants WHERE ride_id = $1 AND user_id = $2 AND status = $3`
 	err = tx.QueryRow(ctx, countQuery, rideID, userID, string(models.ParticipantStatusActive)).Scan(&participationCount)
 	if err != nil {
 		log.Printf("Error checking active participation for user %s on ride %s: %v", userID, rideID, err)
@@ -424,13 +875,64 @@ func (s *RideService) ValidateRideForJoiningTx(ctx context.Context, tx pgx.Tx, r
 	}
 	if participationCount > 0 {
 		log.Printf("ValidationTx failed: User %s has already actively joined ride %s", userID, rideID)
-		return nil, errors.New("you have already joined this ride")
+		return nil, newError(KindConflict, "you have already joined this ride")
 	}
 
 	log.Printf("ValidationTx successful for user %s joining ride %s", userID, rideID)
 	return &ride, nil
 }
 
+// GetRideRoster lists every participation on a ride, whatever its status, with its payment state
+// and pickup arrangements, in pickup order.
+// Only the ride creator can see the roster.
+func (s *RideService) GetRideRoster(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) ([]models.RosterEntry, error) {
+	var creatorID uuid.UUID
+	err := s.db.QueryRow(ctx, `SELECT user_id FROM rides WHERE id = $1`, rideID).Scan(&creatorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error fetching ride %s for roster: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if creatorID != userID {
+		return nil, newError(KindForbidden, "only the ride creator can view its participants")
+	}
+
+	query := `
+		SELECT p.id, p.user_id, u.first_name, u.last_name, p.seat_count, p.status, pay.status, p.created_at,
+		       p.pickup_note, p.pickup_order, p.seat_label
+		FROM participants p
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN LATERAL (
+			SELECT status FROM payments WHERE participant_id = p.id ORDER BY created_at DESC LIMIT 1
+		) pay ON TRUE
+		WHERE p.ride_id = $1
+		ORDER BY p.pickup_order ASC NULLS LAST, p.created_at ASC
+	`
+	rows, err := s.db.Query(ctx, query, rideID)
+	if err != nil {
+		log.Printf("Error fetching roster for ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching participants: %w", err)
+	}
+	defer rows.Close()
+
+	roster := []models.RosterEntry{}
+	for rows.Next() {
+		var entry models.RosterEntry
+		if err := rows.Scan(&entry.ParticipantID, &entry.UserID, &entry.FirstName, &entry.LastName, &entry.Seats, &entry.Status, &entry.PaymentStatus, &entry.JoinedAt,
+			&entry.PickupNote, &entry.PickupOrder, &entry.Seat); err != nil {
+			log.Printf("Error scanning roster row for ride %s: %v", rideID, err)
+			return nil, fmt.Errorf("error processing participant data: %w", err)
+		}
+		roster = append(roster, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for participants: %w", err)
+	}
+	return roster, nil
+}
+
 // RideContactInfo defines the structure for returning participant contact details.
 type RideContactInfo struct {
 	UserID    uuid.UUID `json:"user_id"`
@@ -438,9 +940,14 @@ type RideContactInfo struct {
 	LastName  *string   `json:"last_name"`
 	WhatsApp  string    `json:"whatsapp"`
 	IsCreator bool      `json:"is_creator"`
+
+	VehiclePlate *string `json:"vehicle_plate,omitempty"` // Creator only: plate of the ride's vehicle
+
+	models.PickupDetails // Participants only
 }
 
-// GetRideContacts retrieves contact info for confirmed participants and the creator.
+// GetRideContacts retrieves contact info for confirmed participants and the creator. Participants of
+// a completed ride keep access, e.g. to return a forgotten item.
 func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, requestingUserID uuid.UUID) ([]RideContactInfo, error) {
 	log.Printf("User %s requesting contacts for ride %s", requestingUserID, rideID)
 
@@ -458,7 +965,7 @@ func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, req
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("GetRideContacts failed: Ride %s not found.", rideID)
-			return nil, errors.New("ride not found")
+			return nil, ErrRideNotFound
 		}
 		log.Printf("Error checking requester status for user %s on ride %s: %v", requestingUserID, rideID, err)
 		return nil, fmt.Errorf("database error verifying access: %w", err)
@@ -469,10 +976,10 @@ func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, req
 		requesterStatus = models.ParticipantStatus(*requesterStatusStr)
 	}
 
-	if !isCreator && requesterStatus != models.ParticipantStatusActive {
+	if !isCreator && requesterStatus != models.ParticipantStatusActive && requesterStatus != models.ParticipantStatusCompleted {
 		log.Printf("GetRideContacts failed: User %s is not authorized (Status: %s, IsCreator: %t) for ride %s",
 			requestingUserID, requesterStatus, isCreator, rideID)
-		return nil, errors.New("unauthorized to view contacts for this ride")
+		return nil, newError(KindForbidden, "unauthorized to view contacts for this ride")
 	}
 
 	log.Printf("User %s authorized to view contacts for ride %s (Status: %s, IsCreator: %t)",
@@ -481,20 +988,21 @@ func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, req
 	contacts := []RideContactInfo{}
 	getContactsQuery := `
 		SELECT
-			u.id, u.first_name, u.last_name, u.whatsapp,
-			(r.user_id = u.id) AS is_creator
+			u.id, u.first_name, u.last_name, COALESCE(u.whatsapp_encrypted, u.whatsapp, ''),
+			(r.user_id = u.id) AS is_creator, p.pickup_note, p.pickup_order, p.seat_label, v.license_plate
 		FROM users u
 		JOIN rides r ON r.id = $1
 		LEFT JOIN participants p ON p.user_id = u.id AND p.ride_id = r.id
+		LEFT JOIN vehicles v ON v.id = r.vehicle_id AND r.user_id = u.id
 		WHERE
 			r.id = $1
 			AND (
 				r.user_id = u.id -- Include the creator OR
-				OR p.status = $2 -- Include active participants
+				OR p.status IN ($2, $3) -- Include active participants (completed once the ride is)
 			)
 			AND u.deleted_at IS NULL -- Exclude deleted users
 	`
-	rows, err := s.db.Query(ctx, getContactsQuery, rideID, string(models.ParticipantStatusActive))
+	rows, err := s.db.Query(ctx, getContactsQuery, rideID, string(models.ParticipantStatusActive), string(models.ParticipantStatusCompleted))
 	if err != nil {
 		log.Printf("Error fetching contacts for ride %s: %v", rideID, err)
 		return nil, fmt.Errorf("database error fetching contacts: %w", err)
@@ -505,11 +1013,17 @@ func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, req
 		var contact RideContactInfo
 		err := rows.Scan(
 			&contact.UserID, &contact.FirstName, &contact.LastName, &contact.WhatsApp, &contact.IsCreator,
+			&contact.PickupNote, &contact.PickupOrder, &contact.Seat, &contact.VehiclePlate,
 		)
 		if err != nil {
 			log.Printf("Error scanning contact row for ride %s: %v", rideID, err)
 			return nil, fmt.Errorf("error processing contact data: %w", err)
 		}
+		// Contacts are the flow WhatsApp numbers are encrypted for: decrypt only after the access check
+		if contact.WhatsApp, err = s.crypto.Decrypt(contact.WhatsApp); err != nil {
+			log.Printf("Error decrypting contact of user %s for ride %s: %v", contact.UserID, rideID, err)
+			return nil, fmt.Errorf("error processing contact data: %w", err)
+		}
 		contacts = append(contacts, contact)
 	}
 
@@ -522,6 +1036,42 @@ func (s *RideService) GetRideContacts(ctx context.Context, rideID uuid.UUID, req
 	return contacts, nil
 }
 
+// searchDefaultRadiusKm is the radius around search points when none is given.
+const searchDefaultRadiusKm = 10
+
+// searchSortOrders maps each search sort order to the ORDER BY terms placed before the usual order.
+// Only these fixed terms reach the query; the price order takes the booking fee, which rides without
+// their own price cost, as an argument.
+var searchSortOrders = map[string]string{
+	models.SearchSortDepartureTime:   "", // The usual order
+	models.SearchSortPrice:           "COALESCE(r.price_per_seat, $%d) ASC",
+	models.SearchSortDistance:        "r.distance_meters ASC NULLS LAST",
+	models.SearchSortRecentlyCreated: "r.created_at DESC",
+}
+
+// optionalSearchPoint returns the search point given by lat and lon, nil if neither is set.
+func optionalSearchPoint(lat, lon *float64, name string) (*models.GeoPoint, error) {
+	if lat == nil && lon == nil {
+		return nil, nil
+	}
+	if lat == nil || lon == nil {
+		return nil, newError(KindInvalid, fmt.Sprintf("%s_lat and %s_lon must be given together", name, name))
+	}
+	return &models.GeoPoint{Latitude: *lat, Longitude: *lon}, nil
+}
+
+// luggageCapacitiesFitting returns the luggage capacities that have room for luggage of the given size.
+func luggageCapacitiesFitting(size models.LuggageCapacity) []string {
+	switch size {
+	case models.LuggageLarge:
+		return []string{string(models.LuggageLarge)}
+	case models.LuggageSmall:
+		return []string{string(models.LuggageSmall), string(models.LuggageLarge)}
+	default:
+		return []string{string(models.LuggageNone), string(models.LuggageSmall), string(models.LuggageLarge)}
+	}
+}
+
 // SearchRides searches for available rides based on criteria.
 func (s *RideService) SearchRides(ctx context.Context, params models.SearchRidesRequest) ([]models.Ride, error) {
 	// 1. Validate parameters (basic validation done via tags, add more if needed)
@@ -529,24 +1079,56 @@ func (s *RideService) SearchRides(ctx context.Context, params models.SearchRides
 		log.Printf("Validation error during ride search: %v", err)
 		return nil, fmt.Errorf("invalid search parameters: %w", err)
 	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return nil, newError(KindInvalid, "max_price must not be lower than min_price")
+	}
+	if params.DepartureAfter != nil && params.DepartureBefore != nil && *params.DepartureAfter > *params.DepartureBefore { // HH:MM sorts chronologically
+		return nil, newError(KindInvalid, "departure_before must not be earlier than departure_after")
+	}
+	departurePoint, err := optionalSearchPoint(params.DepartureLat, params.DepartureLon, "departure")
+	if err != nil {
+		return nil, err
+	}
+	arrivalPoint, err := optionalSearchPoint(params.ArrivalLat, params.ArrivalLon, "arrival")
+	if err != nil {
+		return nil, err
+	}
+	radiusMeters := searchDefaultRadiusKm * 1000
+	if params.RadiusKm != nil {
+		radiusMeters = *params.RadiusKm * 1000
+	}
+	corridor := params.Match != nil && *params.Match == models.SearchMatchCorridor
+	if corridor && (departurePoint == nil || arrivalPoint == nil) {
+		return nil, newError(KindInvalid, "corridor matching needs departure and arrival points")
+	}
+
+	// Results ordered by the caller's location differ per caller, so only location-independent searches are cached
+	geo := models.GeoFromContext(ctx)
+	geoOrdered := (params.StartLocation == nil || *params.StartLocation == "") && departurePoint == nil && geo != nil && geo.Latitude != nil && geo.Longitude != nil
+	// The cache key does not include the arrival time nor search points; the search_cache flag lets ops bypass the cache at runtime
+	cacheable := !geoOrdered && params.ArriveBy == nil && departurePoint == nil && arrivalPoint == nil && s.cfg.Runtime().FeatureEnabled(config.FeatureSearchCache)
+	if cacheable {
+		if rides, ok := s.searchCache.Get(params); ok {
+			log.Printf("Returning %d cached rides for search", len(rides))
+			return rides, nil
+		}
+	}
 
 	// 2. Build the base query
 	baseQuery := `
-		SELECT
-			r.id, r.user_id,
-			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
-			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status, r.created_at, r.updated_at,
-			(SELECT COUNT(*) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active') AS places_taken,
-			u.first_name AS creator_first_name
+		SELECT` + rideSelectColumns + `
 		FROM rides r
 		JOIN users u ON r.user_id = u.id
 		WHERE r.status = $1 -- Always filter for active rides
 		  AND (r.departure_date > current_date OR (r.departure_date = current_date AND r.departure_time > current_time)) -- Filter out past rides
-		  AND (SELECT COUNT(*) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active') < r.total_seats -- Filter out full rides
+		  AND r.total_seats - ` + seatsTakenSubquery + ` >= $2 -- Enough free seats for the whole group
 	`
-	args := []interface{}{string(models.RideStatusActive)}
-	argID := 2 // Start next argument index at 2
+	seats := 1 // Without a group size, only full rides are filtered out
+	if params.Seats != nil {
+		seats = *params.Seats
+	}
+	args := []interface{}{string(models.RideStatusActive), seats}
+	argID := 3 // Start next argument index at 3
 
 	// 3. Add filters dynamically
 	if params.StartLocation != nil && *params.StartLocation != "" {
@@ -564,9 +1146,134 @@ func (s *RideService) SearchRides(ctx context.Context, params models.SearchRides
 		args = append(args, *params.DepartureDate)
 		argID++
 	}
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		minPrice, maxPrice := int64(0), int64(math.MaxInt32) // price_per_seat is an INT
+		if params.MinPrice != nil {
+			minPrice = *params.MinPrice
+		}
+		if params.MaxPrice != nil {
+			maxPrice = *params.MaxPrice
+		}
+		priceFilter := fmt.Sprintf("r.price_per_seat BETWEEN $%d AND $%d", argID, argID+1)
+		if fee := s.cfg.Runtime().BookingFeeCents; fee >= minPrice && fee <= maxPrice {
+			priceFilter = "(" + priceFilter + " OR r.price_per_seat IS NULL)" // Rides without their own price cost the booking fee
+		}
+		baseQuery += " AND " + priceFilter
+		args = append(args, minPrice, maxPrice)
+		argID += 2
+	}
+	if corridor {
+		condition, conditionArgs := corridorCondition(*departurePoint, *arrivalPoint, radiusMeters, argID)
+		baseQuery += " AND " + condition
+		args = append(args, conditionArgs...)
+		argID += len(conditionArgs)
+	} else if departurePoint != nil {
+		condition, conditionArgs := proximityCondition(s.cfg.ProximityStrategy, "r.departure_coords", "r.departure_geohash", *departurePoint, radiusMeters, argID)
+		baseQuery += " AND " + condition
+		args = append(args, conditionArgs...)
+		argID += len(conditionArgs)
+	}
+	if arrivalPoint != nil && !corridor {
+		condition, conditionArgs := proximityCondition(s.cfg.ProximityStrategy, "r.arrival_coords", "r.arrival_geohash", *arrivalPoint, radiusMeters, argID)
+		baseQuery += " AND " + condition
+		args = append(args, conditionArgs...)
+		argID += len(conditionArgs)
+	}
+	if condition, conditionArgs := s.serviceAreas.SearchCondition(argID); condition != "" {
+		baseQuery += " AND " + condition
+		args = append(args, conditionArgs...)
+		argID += len(conditionArgs)
+	}
+	for _, filter := range []struct {
+		column string
+		value  *bool
+	}{
+		{"r.smoking_allowed", params.SmokingAllowed},
+		{"r.pets_allowed", params.PetsAllowed},
+		{"r.music_allowed", params.MusicAllowed},
+	} {
+		if filter.value != nil {
+			baseQuery += fmt.Sprintf(" AND %s = $%d", filter.column, argID)
+			args = append(args, *filter.value)
+			argID++
+		}
+	}
+	if params.ChatLevel != nil && *params.ChatLevel != "" {
+		baseQuery += fmt.Sprintf(" AND r.chat_level = $%d", argID)
+		args = append(args, *params.ChatLevel)
+		argID++
+	}
+	if params.Luggage != nil && *params.Luggage != "" {
+		baseQuery += fmt.Sprintf(" AND r.luggage_capacity = ANY($%d)", argID)
+		args = append(args, luggageCapacitiesFitting(models.LuggageCapacity(*params.Luggage)))
+		argID++
+	}
+	if params.PassengerGender != nil && *params.PassengerGender != "" {
+		baseQuery += fmt.Sprintf(" AND r.passenger_gender = $%d", argID)
+		args = append(args, *params.PassengerGender)
+		argID++
+	}
+	if params.DepartureAfter != nil && *params.DepartureAfter != "" {
+		baseQuery += fmt.Sprintf(" AND r.departure_time >= $%d::time", argID)
+		args = append(args, *params.DepartureAfter)
+		argID++
+	}
+	if params.DepartureBefore != nil && *params.DepartureBefore != "" {
+		baseQuery += fmt.Sprintf(" AND r.departure_time <= $%d::time", argID)
+		args = append(args, *params.DepartureBefore)
+		argID++
+	}
+	if params.ArriveBy != nil && *params.ArriveBy != "" {
+		// Rides between pairs missing from the travel matrix are kept, since their arrival time is unknown
+		baseQuery += fmt.Sprintf(` AND NOT EXISTS (
+			SELECT 1 FROM travel_matrix tm
+			WHERE tm.departure_key = lower(trim(r.departure_location_name)) AND tm.arrival_key = lower(trim(r.arrival_location_name))
+			  AND (r.departure_time + make_interval(secs => tm.duration_seconds) > $%d::time
+			       OR r.departure_time + make_interval(secs => tm.duration_seconds) < r.departure_time) -- Wrapped past midnight: arrives the next day
+		)`, argID)
+		args = append(args, *params.ArriveBy)
+		argID++
+	}
 
-	// 4. Add ordering
-	baseQuery += " ORDER BY r.departure_date ASC, r.departure_time ASC"
+	// 4. Add ordering: by weighted relevance (search_ranking flag), else chronologically. Without a start
+	// location, rides departing near the caller (IP geolocation) rank higher or come first.
+	// Any other sort order comes first, the usual order breaking ties.
+	runtime := s.cfg.Runtime()
+	baseQuery += " ORDER BY "
+	if params.Sort != nil {
+		if order := searchSortOrders[*params.Sort]; order != "" {
+			if *params.Sort == models.SearchSortPrice {
+				order = fmt.Sprintf(order, argID)
+				args = append(args, runtime.BookingFeeCents)
+				argID++
+			}
+			baseQuery += order + ", "
+		}
+	}
+	near := departurePoint // Pickup distance is measured from the searched departure point, else from the caller
+	if geoOrdered {
+		near = &models.GeoPoint{Latitude: *geo.Latitude, Longitude: *geo.Longitude}
+	}
+	rankExpr, rankArgs := "", []interface{}(nil)
+	if runtime.FeatureEnabled(config.FeatureSearchRank) {
+		rankExpr, rankArgs = searchRankExpression(runtime.SearchWeights, s.cfg.ProximityStrategy, runtime.BookingFeeCents, near, argID)
+	}
+	if rankExpr != "" {
+		baseQuery += "(" + rankExpr + ") DESC, "
+		args = append(args, rankArgs...)
+		argID += len(rankArgs)
+	} else if geoOrdered {
+		if s.cfg.ProximityStrategy == config.ProximityGeohash {
+			baseQuery += fmt.Sprintf("(r.departure_geohash LIKE ANY($%d)) DESC, ", argID)
+			args = append(args, geohashLikePatterns(GeohashProximityPrefixes(*geo.Latitude, *geo.Longitude, nearbyDepartureRadiusMeters)))
+			argID++
+		} else {
+			baseQuery += fmt.Sprintf("ST_DWithin(r.departure_coords::geography, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography, %d) DESC, ", argID, argID+1, nearbyDepartureRadiusMeters)
+			args = append(args, *geo.Longitude, *geo.Latitude)
+			argID += 2
+		}
+	}
+	baseQuery += "r.departure_date ASC, r.departure_time ASC"
 
 	// 5. Add pagination
 	limit := 20 // Default limit
@@ -603,6 +1310,7 @@ func (s *RideService) SearchRides(ctx context.Context, params models.SearchRides
 			log.Printf("Error scanning search result row: %v", err)
 			return nil, fmt.Errorf("error processing search result data: %w", err)
 		}
+		s.applySeatPrice(ride)
 		rides = append(rides, *ride)
 	}
 
@@ -611,27 +1319,230 @@ func (s *RideService) SearchRides(ctx context.Context, params models.SearchRides
 		return nil, fmt.Errorf("database iteration error during search: %w", err)
 	}
 
+	s.travelMatrix.Enrich(rides)
+	s.driverStats.Attach(ctx, rides)
 	log.Printf("Found %d rides matching search criteria", len(rides))
+	if cacheable {
+		s.searchCache.Set(params, rides)
+	}
 	return rides, nil
 }
 
-// ListUserCreatedRides retrieves rides created by a specific user.
-func (s *RideService) ListUserCreatedRides(ctx context.Context, userID uuid.UUID) ([]models.Ride, error) {
+// proximityCondition matches rides whose point (coordinates column, or its geohash column with the
+// geohash strategy) lies within radiusMeters of point. Its placeholders start at $argID.
+func proximityCondition(strategy, coordsColumn, geohashColumn string, point models.GeoPoint, radiusMeters int, argID int) (string, []interface{}) {
+	if strategy == config.ProximityGeohash {
+		return fmt.Sprintf("%s LIKE ANY($%d)", geohashColumn, argID),
+			[]interface{}{geohashLikePatterns(GeohashProximityPrefixes(point.Latitude, point.Longitude, float64(radiusMeters)))}
+	}
+	return fmt.Sprintf("ST_DWithin(%s::geography, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography, $%d)", coordsColumn, argID, argID+1, argID+2),
+		[]interface{}{point.Longitude, point.Latitude, radiusMeters}
+}
+
+// corridorCondition matches rides whose driving route passes within radiusMeters of the departure
+// point and then of the arrival point, so passengers can share part of a longer ride. Rides without
+// a stored route match on their endpoints. It needs PostGIS whatever the proximity strategy. Its
+// placeholders start at $argID.
+func corridorCondition(departure, arrival models.GeoPoint, radiusMeters int, argID int) (string, []interface{}) {
+	from := fmt.Sprintf("ST_SetSRID(ST_MakePoint($%d, $%d), 4326)", argID, argID+1)
+	to := fmt.Sprintf("ST_SetSRID(ST_MakePoint($%d, $%d), 4326)", argID+2, argID+3)
+	radius := fmt.Sprintf("$%d", argID+4)
+	condition := fmt.Sprintf(`(
+		(r.route_geometry IS NOT NULL
+		 AND ST_Intersects(r.route_geometry, ST_Buffer(%[1]s::geography, %[3]s)::geometry)
+		 AND ST_Intersects(r.route_geometry, ST_Buffer(%[2]s::geography, %[3]s)::geometry)
+		 AND ST_LineLocatePoint(r.route_geometry, %[1]s) < ST_LineLocatePoint(r.route_geometry, %[2]s)) -- Same direction as the passenger
+		OR (r.route_geometry IS NULL
+		 AND ST_DWithin(r.departure_coords::geography, %[1]s::geography, %[3]s)
+		 AND ST_DWithin(r.arrival_coords::geography, %[2]s::geography, %[3]s))
+	)`, from, to, radius)
+	return condition, []interface{}{departure.Longitude, departure.Latitude, arrival.Longitude, arrival.Latitude, radiusMeters}
+}
+
+// ErrLocationRequired is returned by NearbyRides when no point is given and the user has no stored location.
+var ErrLocationRequired = newError(KindInvalid, "share your location or pass lat and lon to find nearby rides")
+
+// nearbyRidesDefaultRadiusKm and nearbyRidesLimit bound GET /rides/nearby.
+const (
+	nearbyRidesDefaultRadiusKm = 30
+	nearbyRidesLimit           = 50
+)
+
+// NearbyRides returns today's and tomorrow's upcoming rides departing within a radius of the given
+// point, or of the user's last known location, earliest departure first. It powers the app home screen.
+func (s *RideService) NearbyRides(ctx context.Context, userID uuid.UUID, params models.NearbyRidesRequest) ([]models.Ride, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid nearby parameters: %w", err)
+	}
+	if (params.Latitude == nil) != (params.Longitude == nil) {
+		return nil, newError(KindInvalid, "lat and lon must be given together")
+	}
+	var point models.GeoPoint
+	if params.Latitude != nil && params.Longitude != nil {
+		point = models.GeoPoint{Latitude: *params.Latitude, Longitude: *params.Longitude}
+	} else {
+		stored, err := s.lastKnownLocation(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		point = stored
+	}
+	radiusMeters := nearbyRidesDefaultRadiusKm * 1000
+	if params.RadiusKm != nil {
+		radiusMeters = *params.RadiusKm * 1000
+	}
+
+	query := `
+		SELECT` + rideSelectColumns + `
+		FROM rides r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.status = $1
+		  AND r.departure_date IN (current_date, current_date + 1)
+		  AND (r.departure_date > current_date OR r.departure_time > current_time)
+		  AND ` + seatsTakenSubquery + ` < r.total_seats
+	`
+	condition, args := proximityCondition(s.cfg.ProximityStrategy, "r.departure_coords", "r.departure_geohash", point, radiusMeters, 2)
+	query += " AND " + condition
+	args = append([]interface{}{string(models.RideStatusActive)}, args...)
+	query += fmt.Sprintf(" ORDER BY r.departure_date ASC, r.departure_time ASC LIMIT %d", nearbyRidesLimit)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error querying nearby rides for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error fetching nearby rides: %w", err)
+	}
+	defer rows.Close()
+
 	rides := []models.Ride{}
+	for rows.Next() {
+		ride, err := scanRideRow(rows)
+		if err != nil {
+			log.Printf("Error scanning nearby ride row: %v", err)
+			return nil, fmt.Errorf("error processing nearby ride data: %w", err)
+		}
+		s.applySeatPrice(ride)
+		rides = append(rides, *ride)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for nearby rides: %w", err)
+	}
+
+	s.travelMatrix.Enrich(rides)
+	s.driverStats.Attach(ctx, rides)
+	log.Printf("Found %d rides within %d m for user %s", len(rides), radiusMeters, userID)
+	return rides, nil
+}
+
+// lastKnownLocation returns the user's stored device location, or ErrLocationRequired without one.
+func (s *RideService) lastKnownLocation(ctx context.Context, userID uuid.UUID) (models.GeoPoint, error) {
+	// Locations are stored encrypted; rows not yet backfilled still have the plaintext point
 	query := `
-		SELECT
-			r.id, r.user_id,
-			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
-			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status, r.created_at, r.updated_at,
-			(SELECT COUNT(*) FROM participants p WHERE p.ride_id = r.id AND p.status = 'active') AS places_taken,
-			u.first_name AS creator_first_name -- Creator name is the user themselves here
+		SELECT COALESCE(last_known_location_encrypted, ST_Y(last_known_location::geometry) || ',' || ST_X(last_known_location::geometry))
+		FROM users WHERE id = $1 AND (last_known_location_encrypted IS NOT NULL OR last_known_location IS NOT NULL)
+	`
+	var encrypted string
+	if err := s.db.QueryRow(ctx, query, userID).Scan(&encrypted); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.GeoPoint{}, ErrLocationRequired
+		}
+		return models.GeoPoint{}, fmt.Errorf("database error fetching location: %w", err)
+	}
+	point, err := s.crypto.DecryptLocation(encrypted)
+	if err != nil {
+		return models.GeoPoint{}, fmt.Errorf("failed to decrypt location: %w", err)
+	}
+	return point, nil
+}
+
+// ClusterRides groups the active, upcoming rides departing inside the bounding box into map
+// clusters. Departures are snapped to a grid whose cells shrink as the zoom level grows, so the
+// map receives at most a few clusters per tile however many rides are active.
+func (s *RideService) ClusterRides(ctx context.Context, params models.RideMapRequest) ([]models.RideCluster, error) {
+	if err := s.validator.Struct(params); err != nil {
+		return nil, fmt.Errorf("invalid map parameters: %w", err)
+	}
+	minLon, minLat, maxLon, maxLat, err := parseBBox(params.BBox)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT COUNT(*),
+		       ST_X(ST_Centroid(ST_Collect(r.departure_coords))), ST_Y(ST_Centroid(ST_Collect(r.departure_coords))),
+		       CASE WHEN COUNT(*) = 1 THEN MIN(r.id::text)::uuid END
+		FROM rides r
+		WHERE r.status = $1
+		  AND (r.departure_date > current_date OR (r.departure_date = current_date AND r.departure_time > current_time))
+		  AND r.departure_coords && ST_MakeEnvelope($2, $3, $4, $5, 4326)
+		GROUP BY ST_SnapToGrid(r.departure_coords, $6)
+		LIMIT $7
+	`
+	rows, err := s.db.Query(ctx, query, string(models.RideStatusActive), minLon, minLat, maxLon, maxLat, clusterCellDegrees(params.Zoom), maxRideClusters)
+	if err != nil {
+		log.Printf("Error clustering rides in bbox %s: %v", params.BBox, err)
+		return nil, fmt.Errorf("database error clustering rides: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []models.RideCluster{}
+	for rows.Next() {
+		var cluster models.RideCluster
+		if err := rows.Scan(&cluster.Count, &cluster.Coords.Longitude, &cluster.Coords.Latitude, &cluster.RideID); err != nil {
+			log.Printf("Error scanning ride cluster row: %v", err)
+			return nil, fmt.Errorf("error processing ride cluster data: %w", err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ride clusters: %w", err)
+	}
+	return clusters, nil
+}
+
+// maxRideClusters caps the clusters returned for one map view.
+const maxRideClusters = 2000
+
+// clusterCellDegrees returns the grid cell size for a zoom level: four cells across each
+// 256 px web map tile, i.e. roughly one cluster per 64 px.
+func clusterCellDegrees(zoom int) float64 {
+	return 360 / float64(int(1)<<zoom) / 4
+}
+
+// parseBBox parses a "minLon,minLat,maxLon,maxLat" bounding box.
+func parseBBox(bbox string) (minLon, minLat, maxLon, maxLat float64, err error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return 0, 0, 0, 0, newError(KindInvalid, "bbox must be formatted as minLon,minLat,maxLon,maxLat")
+	}
+	values := make([]float64, 4)
+	for i, part := range parts {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+			return 0, 0, 0, 0, newError(KindInvalid, "bbox contains an invalid number")
+		}
+	}
+	minLon, minLat, maxLon, maxLat = values[0], values[1], values[2], values[3]
+	if minLon < -180 || maxLon > 180 || minLat < -90 || maxLat > 90 || minLon >= maxLon || minLat >= maxLat {
+		return 0, 0, 0, 0, newError(KindInvalid, "bbox is out of range or inverted")
+	}
+	return minLon, minLat, maxLon, maxLat, nil
+}
+
+// ListUserCreatedRides retrieves a page of the rides created by a specific user.
+func (s *RideService) ListUserCreatedRides(ctx context.Context, userID uuid.UUID, params models.RideListRequest) ([]models.Ride, error) {
+	limit, offset, err := s.rideListPage(params)
+	if err != nil {
+		return nil, err
+	}
+	rides := []models.Ride{}
+	query := `
+		SELECT` + rideSelectColumns + `
 		FROM rides r
 		JOIN users u ON r.user_id = u.id
 		WHERE r.user_id = $1
-		ORDER BY r.departure_date DESC, r.departure_time DESC -- Show most recent first
+		ORDER BY r.departure_date DESC, r.departure_time DESC, r.id -- Show most recent first
+		LIMIT $2 OFFSET $3
 	`
-	rows, err := s.db.Query(ctx, query, userID)
+	rows, err := s.db.Query(ctx, query, userID, limit, offset)
 	if err != nil {
 		log.Printf("Error querying created rides for user %s: %v", userID, err)
 		return nil, fmt.Errorf("database error fetching created rides: %w", err)
@@ -644,6 +1555,7 @@ func (s *RideService) ListUserCreatedRides(ctx context.Context, userID uuid.UUID
 			log.Printf("Error scanning created ride row for user %s: %v", userID, err)
 			return nil, fmt.Errorf("error processing created ride data: %w", err)
 		}
+		s.applySeatPrice(ride)
 		rides = append(rides, *ride)
 	}
 
@@ -656,24 +1568,23 @@ func (s *RideService) ListUserCreatedRides(ctx context.Context, userID uuid.UUID
 	return rides, nil
 }
 
-// ListUserJoinedRides retrieves rides a specific user has joined (and is active).
-func (s *RideService) ListUserJoinedRides(ctx context.Context, userID uuid.UUID) ([]models.Ride, error) {
+// ListUserJoinedRides retrieves a page of the rides a specific user has joined (and is active).
+func (s *RideService) ListUserJoinedRides(ctx context.Context, userID uuid.UUID, params models.RideListRequest) ([]models.Ride, error) {
+	limit, offset, err := s.rideListPage(params)
+	if err != nil {
+		return nil, err
+	}
 	rides := []models.Ride{}
 	query := `
-		SELECT
-			r.id, r.user_id,
-			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
-			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status, r.created_at, r.updated_at,
-			(SELECT COUNT(*) FROM participants p_count WHERE p_count.ride_id = r.id AND p_count.status = 'active') AS places_taken,
-			u.first_name AS creator_first_name
+		SELECT` + rideSelectColumns + `
 		FROM rides r
 		JOIN participants p ON r.id = p.ride_id
 		JOIN users u ON r.user_id = u.id -- Join users table for creator info
 		WHERE p.user_id = $1 AND p.status = $2 -- Filter by user ID and active participation status
-		ORDER BY r.departure_date ASC, r.departure_time ASC -- Show upcoming first
+		ORDER BY r.departure_date ASC, r.departure_time ASC, r.id -- Show upcoming first
+		LIMIT $3 OFFSET $4
 	`
-	rows, err := s.db.Query(ctx, query, userID, string(models.ParticipantStatusActive))
+	rows, err := s.db.Query(ctx, query, userID, string(models.ParticipantStatusActive), limit, offset)
 	if err != nil {
 		log.Printf("Error querying joined rides for user %s: %v", userID, err)
 		return nil, fmt.Errorf("database error fetching joined rides: %w", err)
@@ -686,6 +1597,7 @@ func (s *RideService) ListUserJoinedRides(ctx context.Context, userID uuid.UUID)
 			log.Printf("Error scanning joined ride row for user %s: %v", userID, err)
 			return nil, fmt.Errorf("error processing joined ride data: %w", err)
 		}
+		s.applySeatPrice(ride)
 		rides = append(rides, *ride)
 	}
 
@@ -698,123 +1610,260 @@ func (s *RideService) ListUserJoinedRides(ctx context.Context, userID uuid.UUID)
 	return rides, nil
 }
 
-// DeleteRide handles deleting a ride, checking permissions first.
-func (s *RideService) DeleteRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (bool, error) {
-	log.Printf("User %s attempting to delete ride %s", userID, rideID)
-
-	pool, ok := s.db.(*pgxpool.Pool)
-	if !ok {
-		return false, errors.New("database does not support transactions required for DeleteRide")
-	}
-
-	tx, err := pool.Begin(ctx)
+// CancelRide marks an active, not yet departed ride as cancelled and releases its active, pending
+// and on-hold participations. Rides are never deleted, so refunds, driver stats and history stay
+// traceable; retention purges old cancelled rides without payments. The refund share owed to active
+// participants is computed by the refund policy engine for the initiator; issuing refunds and
+// notifying is handled by PaymentService. creatorID, unless uuid.Nil, must be the ride's creator.
+// allowDeparted lifts the departure and archive guards; it is reserved for support overrides.
+func (s *RideService) CancelRide(ctx context.Context, rideID uuid.UUID, creatorID uuid.UUID, initiator RefundInitiator, allowDeparted bool) (*models.CancelRideResponse, error) {
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		log.Printf("Error starting transaction for deleting ride %s by user %s: %v", rideID, userID, err)
-		return false, fmt.Errorf("failed to start database transaction: %w", err)
+		log.Printf("Error starting transaction for cancelling ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	// 1. Verify ownership and get participant count
-	var rideUserID uuid.UUID
-	var participantCount int
-	checkQuery := `
-		SELECT r.user_id, COUNT(p.id)
-		FROM rides r
-		LEFT JOIN participants p ON r.id = p.ride_id AND p.status = 'active'
-		WHERE r.id = $1
-		GROUP BY r.user_id
+	result := &models.CancelRideResponse{RideID: rideID, Participants: []models.CancelledParticipation{}}
+	var ownerID uuid.UUID
+	var status, departureTime string
+	var departureDate time.Time
+	rideQuery := `
+		SELECT user_id, status, cancellation_policy, departure_date, departure_time::text,
+		       departure_location_name || ' → ' || arrival_location_name
+		FROM rides WHERE id = $1
+		FOR UPDATE
 	`
-	err = tx.QueryRow(ctx, checkQuery, rideID).Scan(&rideUserID, &participantCount)
+	err = tx.QueryRow(ctx, rideQuery, rideID).Scan(&ownerID, &status, &result.CancellationPolicy, &departureDate, &departureTime, &result.Route)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			log.Printf("DeleteRide failed: Ride %s not found.", rideID)
-			return false, errors.New("ride not found")
+			return nil, ErrRideNotFound
 		}
-		log.Printf("Error checking ride ownership/participants for ride %s: %v", rideID, err)
-		return false, fmt.Errorf("database error checking ride details: %w", err)
+		log.Printf("Error fetching ride %s for cancellation: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
 	}
-
-	// 2. Check ownership
-	if rideUserID != userID {
-		log.Printf("DeleteRide failed: User %s does not own ride %s", userID, rideID)
-		return false, errors.New("unauthorized to delete this ride")
+	if creatorID != uuid.Nil && ownerID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can cancel the ride")
 	}
-
-	// 3. Perform deletion (soft or hard based on participants)
-	// For now, let's implement a hard delete as per original logic (no participants check mentioned in v2 for delete)
-	// If soft delete is preferred, update status to 'cancelled' or similar.
-	// Let's stick to hard delete for now. We need to delete participants first due to FK constraints.
-	deleteParticipantsQuery := `DELETE FROM participants WHERE ride_id = $1`
-	_, err = tx.Exec(ctx, deleteParticipantsQuery, rideID)
-	if err != nil {
-		log.Printf("Error deleting participants for ride %s during ride deletion: %v", rideID, err)
-		return false, fmt.Errorf("failed to delete ride participants: %w", err)
+	if status == string(models.RideStatusCancelled) {
+		return nil, newError(KindConflict, "ride is already cancelled")
 	}
-
-	deleteRideQuery := `DELETE FROM rides WHERE id = $1 AND user_id = $2`
-	tag, err := tx.Exec(ctx, deleteRideQuery, rideID, userID)
+	if status != string(models.RideStatusActive) && !(allowDeparted && status == string(models.RideStatusArchived)) {
+		return nil, newError(KindConflict, fmt.Sprintf("a ride with status %s cannot be cancelled", status))
+	}
+	departureAt, err := rideDepartureAt(departureDate, departureTime)
 	if err != nil {
-		log.Printf("Error deleting ride %s owned by user %s: %v", rideID, userID, err)
-		return false, fmt.Errorf("failed to delete ride: %w", err)
+		return nil, fmt.Errorf("invalid departure of ride %s: %w", rideID, err)
 	}
+	now := time.Now()
+	if !departureAt.After(now) && !allowDeparted {
+		return nil, newError(KindConflict, "ride has already departed and can no longer be cancelled")
+	}
+	result.RefundPercent = s.refundPolicy.RefundPercent(models.CancellationPolicy(result.CancellationPolicy), departureAt, now, initiator)
 
-	if tag.RowsAffected() == 0 {
-		// Should not happen if ownership check passed, but handle defensively
-		log.Printf("DeleteRide failed: Ride %s not found or ownership mismatch after check.", rideID)
-		return false, errors.New("ride not found or could not be deleted")
+	if _, err := tx.Exec(ctx, `UPDATE rides SET status = $1, updated_at = NOW() WHERE id = $2`, string(models.RideStatusCancelled), rideID); err != nil {
+		log.Printf("Error cancelling ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("database error cancelling ride: %w", err)
 	}
 
-	// 4. Commit transaction
-	err = tx.Commit(ctx)
+	participantsQuery := `
+		WITH previous AS (
+			SELECT id, status FROM participants
+			WHERE ride_id = $1 AND status IN ($2, $3, $4)
+			FOR UPDATE
+		)
+		UPDATE participants p
+		SET status = $5, updated_at = NOW()
+		FROM previous
+		WHERE p.id = previous.id
+		RETURNING p.user_id, previous.status
+	`
+	rows, err := tx.Query(ctx, participantsQuery, rideID,
+		string(models.ParticipantStatusActive), string(models.ParticipantStatusPendingPayment), string(models.ParticipantStatusOnHold),
+		string(models.ParticipantStatusCancelledRide))
 	if err != nil {
-		log.Printf("Error committing transaction for deleting ride %s: %v", rideID, err)
-		return false, fmt.Errorf("failed to finalize ride deletion: %w", err)
+		log.Printf("Error releasing participants of cancelled ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("database error updating ride participants: %w", err)
+	}
+	for rows.Next() {
+		participation := models.CancelledParticipation{RefundStatus: "none"}
+		if err := rows.Scan(&participation.UserID, &participation.PreviousStatus); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error processing ride participants: %w", err)
+		}
+		result.Participants = append(result.Participants, participation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ride participants: %w", err)
 	}
 
-	log.Printf("Ride %s deleted successfully by user %s", rideID, userID)
-	// Return participantCount > 0 to indicate if participants were present (as per v2 spec popup)
-	return participantCount > 0, nil
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing cancellation of ride %s: %v", rideID, err)
+		return nil, fmt.Errorf("failed to finalize ride cancellation: %w", err)
+	}
+	log.Printf("Ride %s cancelled, %d participations released", rideID, len(result.Participants))
+	s.publishRideEvent(ctx, RideEventCancelled, rideID, uuid.Nil)
+	return result, nil
 }
 
 // LeaveRide allows a user to leave a ride they have joined.
-func (s *RideService) LeaveRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) error {
+// It returns the refund share owed under the ride's cancellation policy; issuing the
+// refund itself is handled by PaymentService.
+func (s *RideService) LeaveRide(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.LeaveRideResponse, error) {
 	log.Printf("User %s attempting to leave ride %s", userID, rideID)
 
-	// Update participant status to 'left'
+	// Update participant status to 'left', returning the previous status and the ride's policy details
 	// We only allow leaving if the current status is 'active' or 'pending_payment'
 	query := `
-		UPDATE participants
+		WITH previous AS (
+			SELECT id, status FROM participants
+			WHERE ride_id = $2 AND user_id = $3 AND (status = $4 OR status = $5)
+			FOR UPDATE
+		)
+		UPDATE participants p
 		SET status = $1, updated_at = NOW()
-		WHERE ride_id = $2 AND user_id = $3 AND (status = $4 OR status = $5)
+		FROM previous, rides r
+		WHERE p.id = previous.id AND r.id = p.ride_id
+		RETURNING previous.status, r.cancellation_policy, r.departure_date, r.departure_time
 	`
-	tag, err := s.db.Exec(ctx, query,
+	var previousStatus, policy, departureTime string
+	var departureDate time.Time
+	err := s.db.QueryRow(ctx, query,
 		string(models.ParticipantStatusLeft),
 		rideID,
 		userID,
 		string(models.ParticipantStatusActive),
 		string(models.ParticipantStatusPendingPayment),
-	)
+	).Scan(&previousStatus, &policy, &departureDate, &departureTime)
 
 	if err != nil {
-		log.Printf("Error updating participant status to 'left' for user %s on ride %s: %v", userID, rideID, err)
-		return fmt.Errorf("database error leaving ride: %w", err)
-	}
-
-	if tag.RowsAffected() == 0 {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Error updating participant status to 'left' for user %s on ride %s: %v", userID, rideID, err)
+			return nil, fmt.Errorf("database error leaving ride: %w", err)
+		}
 		log.Printf("LeaveRide failed: User %s not found as an active/pending participant on ride %s, or ride not found.", userID, rideID)
 		// Check if the ride exists at all to give a better error message
 		var exists bool
 		checkRideQuery := `SELECT EXISTS(SELECT 1 FROM rides WHERE id = $1)`
 		_ = s.db.QueryRow(ctx, checkRideQuery, rideID).Scan(&exists)
 		if !exists {
-			return errors.New("ride not found")
+			return nil, ErrRideNotFound
+		}
+		return nil, newError(KindConflict, "you are not currently an active participant in this ride")
+	}
+
+	result := &models.LeaveRideResponse{
+		RideID:             rideID,
+		PreviousStatus:     previousStatus,
+		CancellationPolicy: policy,
+		RefundStatus:       "none",
+	}
+
+	// Only active participants have paid, so only they are owed a refund
+	if previousStatus == string(models.ParticipantStatusActive) {
+		departureAt, err := rideDepartureAt(departureDate, departureTime)
+		if err != nil {
+			log.Printf("Error computing departure time for ride %s, no refund computed: %v", rideID, err)
+		} else {
+			result.RefundPercent = s.refundPolicy.RefundPercent(models.CancellationPolicy(policy), departureAt, time.Now(), RefundInitiatorParticipant)
+		}
+	}
+
+	log.Printf("User %s successfully left ride %s (previous status: %s, refund: %d%%)", userID, rideID, previousStatus, result.RefundPercent)
+	s.publishRideEvent(ctx, RideEventLeft, rideID, userID)
+	return result, nil
+}
+
+// RemoveParticipant lets the ride creator remove a participant (active, pending payment or on hold)
+// from an active ride. The seat is released right away; refunding and notifying the participant
+// is up to the caller (PaymentService.RemoveParticipant). The reason is shown to the participant, so it
+// goes through moderation; a removal is never refused over its wording, problematic reasons are flagged
+// for review instead.
+func (s *RideService) RemoveParticipant(ctx context.Context, rideID uuid.UUID, creatorID uuid.UUID, participantUserID uuid.UUID, reason string) (*models.RemoveParticipantResponse, error) {
+	var rideCreatorID uuid.UUID
+	var status, route string
+	query := `SELECT user_id, status, departure_location_name || ' → ' || arrival_location_name FROM rides WHERE id = $1`
+	err := s.db.QueryRow(ctx, query, rideID).Scan(&rideCreatorID, &status, &route)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		log.Printf("Error fetching ride %s for participant removal: %v", rideID, err)
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if rideCreatorID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can remove participants")
+	}
+	if status != string(models.RideStatusActive) {
+		return nil, newError(KindConflict, "participants can only be removed from an active ride")
+	}
+
+	check := models.ModerationCheck{UserID: creatorID, ContentType: models.ModerationContentRemovalReason, RideID: &rideID, Text: reason}
+	moderation := s.moderation.Evaluate(ctx, check)
+	if moderation.Action == models.ModerationActionBlock {
+		moderation.Action = models.ModerationActionFlag
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	removeQuery := `
+		WITH previous AS (
+			SELECT id, status FROM participants
+			WHERE ride_id = $2 AND user_id = $3 AND status IN ($4, $5, $6)
+			FOR UPDATE
+		)
+		UPDATE participants p
+		SET status = $1, removal_reason = $7, removed_at = NOW(), updated_at = NOW()
+		FROM previous
+		WHERE p.id = previous.id
+		RETURNING previous.status
+	`
+	var previousStatus string
+	err = tx.QueryRow(ctx, removeQuery,
+		string(models.ParticipantStatusRemoved),
+		rideID,
+		participantUserID,
+		string(models.ParticipantStatusActive),
+		string(models.ParticipantStatusPendingPayment),
+		string(models.ParticipantStatusOnHold),
+		reason,
+	).Scan(&previousStatus)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindNotFound, "user is not a current participant of this ride")
 		}
-		return errors.New("you are not currently an active participant in this ride")
+		log.Printf("Error removing user %s from ride %s: %v", participantUserID, rideID, err)
+		return nil, fmt.Errorf("database error removing participant: %w", err)
+	}
+	if err := s.moderation.RecordFlag(ctx, tx, check, moderation); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to finalize participant removal: %w", err)
 	}
 
-	log.Printf("User %s successfully left ride %s", userID, rideID)
-	// TODO: Consider if any notification should be sent to the creator?
-	return nil
+	log.Printf("Creator %s removed user %s from ride %s (previous status: %s)", creatorID, participantUserID, rideID, previousStatus)
+	s.publishRideEvent(ctx, RideEventLeft, rideID, participantUserID)
+	return &models.RemoveParticipantResponse{
+		RideID:         rideID,
+		UserID:         participantUserID,
+		PreviousStatus: previousStatus,
+		Route:          route,
+		RefundStatus:   "none",
+	}, nil
+}
+
+// rideDepartureAt combines a ride's departure date and time (HH:MM or HH:MM:SS) into a single timestamp.
+func rideDepartureAt(departureDate time.Time, departureTime string) (time.Time, error) {
+	if len(departureTime) < 5 {
+		return time.Time{}, fmt.Errorf("invalid departure time: %s", departureTime)
+	}
+	return time.Parse("2006-01-02 15:04", departureDate.Format("2006-01-02")+" "+departureTime[:5])
 }
 
 // GetUserParticipationStatus checks if a user is participating in a ride and returns their status.
@@ -832,31 +1881,26 @@ func (s *RideService) GetUserParticipationStatus(ctx context.Context, rideID uui
 	return status, nil
 }
 
-// ListUserHistoryRides retrieves past or cancelled rides for a user (both created and joined).
-func (s *RideService) ListUserHistoryRides(ctx context.Context, userID uuid.UUID) ([]models.Ride, error) {
+// ListUserHistoryRides retrieves a page of past or cancelled rides for a user (both created and joined).
+func (s *RideService) ListUserHistoryRides(ctx context.Context, userID uuid.UUID, params models.RideListRequest) ([]models.Ride, error) {
+	limit, offset, err := s.rideListPage(params)
+	if err != nil {
+		return nil, err
+	}
 	rides := []models.Ride{}
-	// Select rides created by the user OR joined by the user WHERE the ride status is archived/cancelled OR departure is in the past
+	// Select rides created by the user OR joined by the user WHERE the ride is archived (departed, see RideArchivalJob), completed or cancelled
 	query := `
-		SELECT DISTINCT -- Use DISTINCT to avoid duplicates if user created AND joined (though joining own ride is disallowed)
-			r.id, r.user_id,
-			r.departure_location_name, ST_X(r.departure_coords) AS departure_lon, ST_Y(r.departure_coords) AS departure_lat,
-			r.arrival_location_name, ST_X(r.arrival_coords) AS arrival_lon, ST_Y(r.arrival_coords) AS arrival_lat,
-			r.departure_date, r.departure_time, r.total_seats, r.status, r.created_at, r.updated_at,
-			(SELECT COUNT(*) FROM participants p_count WHERE p_count.ride_id = r.id AND p_count.status = 'active') AS places_taken,
-			u.first_name AS creator_first_name
+		SELECT DISTINCT` + rideSelectColumns + ` -- DISTINCT avoids duplicates if user created AND joined (though joining own ride is disallowed)
 		FROM rides r
 		JOIN users u ON r.user_id = u.id
 		LEFT JOIN participants p ON r.id = p.ride_id AND p.user_id = $1 -- Join participants for the requesting user
 		WHERE
 			(r.user_id = $1 OR p.user_id = $1) -- Ride created by user OR joined by user
-			AND
-			(
-				r.status = $2 OR r.status = $3 -- Ride is archived or cancelled
-				OR (r.departure_date < current_date OR (r.departure_date = current_date AND r.departure_time <= current_time)) -- Ride is in the past
-			)
-		ORDER BY r.departure_date DESC, r.departure_time DESC
+			AND r.status IN ($2, $3, $4) -- Ride is archived, completed or cancelled
+		ORDER BY r.departure_date DESC, r.departure_time DESC, r.id
+		LIMIT $5 OFFSET $6
 	`
-	rows, err := s.db.Query(ctx, query, userID, string(models.RideStatusArchived), string(models.RideStatusCancelled))
+	rows, err := s.db.Query(ctx, query, userID, string(models.RideStatusArchived), string(models.RideStatusCompleted), string(models.RideStatusCancelled), limit, offset)
 	if err != nil {
 		log.Printf("Error querying history rides for user %s: %v", userID, err)
 		return nil, fmt.Errorf("database error fetching history rides: %w", err)
@@ -869,6 +1913,7 @@ func (s *RideService) ListUserHistoryRides(ctx context.Context, userID uuid.UUID
 			log.Printf("Error scanning history ride row for user %s: %v", userID, err)
 			return nil, fmt.Errorf("error processing history ride data: %w", err)
 		}
+		s.applySeatPrice(ride)
 		rides = append(rides, *ride)
 	}
 
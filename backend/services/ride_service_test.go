@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that bounding boxes are parsed in lon/lat order and invalid ones rejected
+func TestParseBBox(t *testing.T) {
+	minLon, minLat, maxLon, maxLat, err := parseBBox("2.22,48.81, 2.47,48.90")
+	if err != nil || minLon != 2.22 || minLat != 48.81 || maxLon != 2.47 || maxLat != 48.90 {
+		t.Errorf("parseBBox() = %v %v %v %v, %v", minLon, minLat, maxLon, maxLat, err)
+	}
+	for _, bbox := range []string{"", "1,2,3", "2.47,48.81,2.22,48.90", "-181,0,0,1", "a,b,c,d"} {
+		if _, _, _, _, err := parseBBox(bbox); err == nil {
+			t.Errorf("parseBBox(%q) succeeded, want an error", bbox)
+		}
+	}
+}
+
+// Test that clusters shrink by half per zoom level
+func TestClusterCellDegrees(t *testing.T) {
+	if got := clusterCellDegrees(0); got != 90 {
+		t.Errorf("clusterCellDegrees(0) = %v, want 90", got)
+	}
+	if got := clusterCellDegrees(10); got != 90.0/1024 {
+		t.Errorf("clusterCellDegrees(10) = %v, want %v", got, 90.0/1024)
+	}
+}
+
+// Test that the search ranking only includes weighted signals and numbers its arguments from argID
+func TestSearchRankExpression(t *testing.T) {
+	if expr, args := searchRankExpression(config.SearchWeights{}, config.ProximityPostGIS, 200, nil, 3); expr != "" || args != nil {
+		t.Errorf("searchRankExpression(no weights) = %q, %v, want no ranking", expr, args)
+	}
+
+	weights := config.SearchWeights{DepartureTime: 50, Price: 10, PickupDistance: 5}
+	near := &models.GeoPoint{Latitude: 48.85, Longitude: 2.35}
+	expr, args := searchRankExpression(weights, config.ProximityPostGIS, 200, near, 3)
+	if !strings.Contains(expr, "COALESCE(r.price_per_seat, $3)") || !strings.Contains(expr, "ST_MakePoint($4, $5)") {
+		t.Errorf("searchRankExpression() = %q, want price at $3 and point at $4, $5", expr)
+	}
+	if strings.Contains(expr, "d.status = 'cancelled'") {
+		t.Errorf("searchRankExpression() = %q, want no driver reliability term without its weight", expr)
+	}
+	if len(args) != 3 || args[0] != int64(200) || args[1] != 2.35 || args[2] != 48.85 {
+		t.Errorf("searchRankExpression() args = %v, want [200 2.35 48.85]", args)
+	}
+
+	if expr, _ := searchRankExpression(weights, config.ProximityPostGIS, 200, nil, 3); strings.Contains(expr, "ST_Distance") {
+		t.Errorf("searchRankExpression(no location) = %q, want no pickup distance term", expr)
+	}
+}
+
+// Test that the sort order puts its whitelisted terms before the ranking
+func TestRideService_SearchRides_Sort(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	rideService := NewRideService(&config.Config{}, mock, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		sort  string
+		order string
+		args  int
+	}{
+		{models.SearchSortDepartureTime, `ORDER BY \(50 \*`, 5}, // The default ranking
+		{models.SearchSortPrice, `ORDER BY COALESCE\(r\.price_per_seat, \$3\) ASC, \(50 \*`, 6},
+		{models.SearchSortDistance, `ORDER BY r\.distance_meters ASC NULLS LAST, \(50 \*`, 5},
+		{models.SearchSortRecentlyCreated, `ORDER BY r\.created_at DESC, \(50 \*`, 5},
+	}
+	for _, tt := range tests {
+		args := make([]interface{}, tt.args)
+		for i := range args {
+			args[i] = pgxmock.AnyArg()
+		}
+		mock.ExpectQuery(tt.order).WithArgs(args...).WillReturnError(errors.New("stop"))
+		if _, err := rideService.SearchRides(context.Background(), models.SearchRidesRequest{Sort: strPtr(tt.sort)}); err == nil {
+			t.Errorf("sort=%s: SearchRides() = nil error, want the query error", tt.sort)
+		}
+	}
+	if _, err := rideService.SearchRides(context.Background(), models.SearchRidesRequest{Sort: strPtr("r.id; DROP TABLE rides")}); err == nil {
+		t.Error("SearchRides() accepted a sort outside the whitelist")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that proximity conditions follow the configured strategy and number their placeholders from argID
+func TestProximityCondition(t *testing.T) {
+	lyon := models.GeoPoint{Latitude: 45.76, Longitude: 4.84}
+
+	condition, args := proximityCondition(config.ProximityPostGIS, "r.arrival_coords", "r.arrival_geohash", lyon, 10000, 4)
+	if want := "ST_DWithin(r.arrival_coords::geography, ST_SetSRID(ST_MakePoint($4, $5), 4326)::geography, $6)"; condition != want {
+		t.Errorf("postgis condition = %q, want %q", condition, want)
+	}
+	if len(args) != 3 || args[0] != 4.84 || args[1] != 45.76 || args[2] != 10000 {
+		t.Errorf("postgis args = %v, want lon, lat, radius", args)
+	}
+
+	condition, args = proximityCondition(config.ProximityGeohash, "r.arrival_coords", "r.arrival_geohash", lyon, 10000, 4)
+	if condition != "r.arrival_geohash LIKE ANY($4)" || len(args) != 1 {
+		t.Errorf("geohash condition = %q with %d args, want one pattern list at $4", condition, len(args))
+	}
+}
+
+// Test that corridor conditions check the route near both points in driving order, with an endpoint fallback
+func TestCorridorCondition(t *testing.T) {
+	lyon := models.GeoPoint{Latitude: 45.76, Longitude: 4.84}
+	paris := models.GeoPoint{Latitude: 48.86, Longitude: 2.35}
+
+	condition, args := corridorCondition(lyon, paris, 5000, 3)
+	for _, want := range []string{
+		"ST_Intersects(r.route_geometry, ST_Buffer(ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography, $7)::geometry)",
+		"ST_LineLocatePoint(r.route_geometry, ST_SetSRID(ST_MakePoint($3, $4), 4326)) < ST_LineLocatePoint(r.route_geometry, ST_SetSRID(ST_MakePoint($5, $6), 4326))",
+		"r.route_geometry IS NULL",
+	} {
+		if !strings.Contains(condition, want) {
+			t.Errorf("corridor condition misses %q:\n%s", want, condition)
+		}
+	}
+	if len(args) != 5 || args[0] != 4.84 || args[3] != 48.86 || args[4] != 5000 {
+		t.Errorf("args = %v, want departure lon/lat, arrival lon/lat, radius", args)
+	}
+}
+
+// Test that a luggage filter matches rides with room for luggage of that size or larger
+func TestLuggageCapacitiesFitting(t *testing.T) {
+	for size, want := range map[models.LuggageCapacity]string{
+		models.LuggageNone:  "none,small,large",
+		models.LuggageSmall: "small,large",
+		models.LuggageLarge: "large",
+	} {
+		if got := strings.Join(luggageCapacitiesFitting(size), ","); got != want {
+			t.Errorf("luggageCapacitiesFitting(%s) = %s, want %s", size, got, want)
+		}
+	}
+}
+
+// Test that only the creator can cancel a ride through the creator flow, and nothing is changed otherwise
+func TestRideService_CancelRide_NotCreator(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	rideService := NewRideService(&config.Config{}, mock, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	rideID, ownerID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).
+		WillReturnRows(pgxmock.NewRows([]string{"user_id", "status", "cancellation_policy", "departure_date", "departure_time", "route"}).
+			AddRow(ownerID, "active", "moderate", time.Now().AddDate(0, 0, 1), "08:00:00", "Lyon → Paris"))
+	mock.ExpectRollback()
+
+	_, err = rideService.CancelRide(context.Background(), rideID, uuid.New(), RefundInitiatorCreator, false)
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind != KindForbidden {
+		t.Fatalf("CancelRide by another user = %v, want a KindForbidden error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that only the ride creator can duplicate a ride
+func TestRideService_DuplicateRide_NotCreator(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	rideService := NewRideService(&config.Config{}, mock, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	rideID := uuid.New()
+	musicAllowed, minAge, pricePerSeat, vehicleID := true, (*int)(nil), (*int64)(nil), (*uuid.UUID)(nil)
+
+	mock.ExpectQuery("SELECT user_id").WithArgs(rideID).
+		WillReturnRows(pgxmock.NewRows([]string{"user_id", "departure_location_name", "departure_lon", "departure_lat", "arrival_location_name", "arrival_lon", "arrival_lat",
+			"total_seats", "cancellation_policy", "min_age", "passenger_gender", "price_per_seat", "vehicle_id", "smoking_allowed", "pets_allowed", "music_allowed", "chat_level", "luggage_capacity"}).
+			AddRow(uuid.New(), "Lyon", 4.83, 45.76, "Paris", 2.35, 48.85, 3, "moderate", minAge, (*string)(nil), pricePerSeat, vehicleID, false, false, musicAllowed, "moderate", "small"))
+
+	req := models.DuplicateRideRequest{DepartureDate: time.Now().AddDate(0, 0, 7).Format("2006-01-02"), DepartureTime: "08:00"}
+	_, err = rideService.DuplicateRide(context.Background(), rideID, uuid.New(), req)
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind != KindForbidden {
+		t.Fatalf("DuplicateRide by another user = %v, want a KindForbidden error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that a departed ride completes with its active participants, and only its creator can complete it
+func TestRideService_CompleteRide(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	rideService := NewRideService(&config.Config{}, mock, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	rideID, ownerID := uuid.New(), uuid.New()
+	rideRows := func(status string, departed bool) *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"user_id", "status", "departed"}).AddRow(ownerID, status, departed)
+	}
+	var serviceErr *Error
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).WillReturnRows(rideRows("active", true))
+	mock.ExpectRollback()
+	if _, err := rideService.CompleteRide(context.Background(), rideID, uuid.New()); !errors.As(err, &serviceErr) || serviceErr.Kind != KindForbidden {
+		t.Errorf("CompleteRide by another user = %v, want a KindForbidden error", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).WillReturnRows(rideRows("active", false))
+	mock.ExpectRollback()
+	if _, err := rideService.CompleteRide(context.Background(), rideID, ownerID); !errors.As(err, &serviceErr) || serviceErr.Kind != KindConflict {
+		t.Errorf("CompleteRide before departure = %v, want a KindConflict error", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, status").WithArgs(rideID).WillReturnRows(rideRows("archived", true))
+	mock.ExpectExec("UPDATE rides SET status").WithArgs("completed", rideID).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE participants SET status").WithArgs("completed", []uuid.UUID{rideID}, "active").WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectCommit()
+	completion, err := rideService.CompleteRide(context.Background(), rideID, ownerID)
+	if err != nil {
+		t.Fatalf("CompleteRide() error = %v", err)
+	}
+	if completion.CompletedParticipants != 2 {
+		t.Errorf("completed %d participants, want 2", completion.CompletedParticipants)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that share tokens round-trip and tampered or foreign tokens are rejected
+func TestRideService_ShareToken(t *testing.T) {
+	rideService := NewRideService(&config.Config{ShareLinkSecret: "share-secret"}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	otherService := NewRideService(&config.Config{ShareLinkSecret: "other-secret"}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	rideID := uuid.New()
+	token := rideService.shareToken(rideID)
+
+	if got, err := rideService.parseShareToken(token); err != nil || got != rideID {
+		t.Fatalf("parseShareToken(shareToken()) = %s, %v, want %s", got, err, rideID)
+	}
+	otherRide := rideService.shareToken(uuid.New())
+	forged := token[:strings.Index(token, ".")] + otherRide[strings.Index(otherRide, "."):]
+	for _, invalid := range []string{forged, otherService.shareToken(rideID), "not-a-token", token + "x", ""} {
+		if _, err := rideService.parseShareToken(invalid); !errors.Is(err, ErrInvalidShareToken) {
+			t.Errorf("parseShareToken(%q) = %v, want ErrInvalidShareToken", invalid, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"         // For database calls
+	"crypto/hmac"     // For signing share tokens
+	"crypto/sha256"   // For signing share tokens
+	"encoding/base64" // For URL-safe tokens
+	"errors"          // For pgx error checks
+	"fmt"             // For error formatting
+	"log"             // For logging
+	"strings"         // For token parsing
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/models"
+)
+
+// shareSignatureSize is the number of HMAC bytes kept in a share token, so links stay short
+// enough to paste in messages.
+const shareSignatureSize = 16
+
+// ErrInvalidShareToken is returned for malformed or tampered share tokens. Unknown rides return
+// it too, so tokens can't be used to probe ride IDs.
+var ErrInvalidShareToken = newError(KindNotFound, "shared ride not found")
+
+// signShareToken computes the truncated HMAC signature of a ride's share token. The purpose prefix
+// keeps signatures apart from other tokens signed with the same secret.
+func (s *RideService) signShareToken(rideID uuid.UUID) []byte {
+	mac := hmac.New(sha256.New, []byte(s.cfg.ShareLinkSecret))
+	mac.Write([]byte("ride-share:" + rideID.String()))
+	return mac.Sum(nil)[:shareSignatureSize]
+}
+
+// shareToken builds a ride's share token of the form "<ride ID>.<signature>", both base64url
+// encoded. Tokens don't expire: the ride's status tells viewers when it's over.
+func (s *RideService) shareToken(rideID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(rideID[:]) + "." +
+		base64.RawURLEncoding.EncodeToString(s.signShareToken(rideID))
+}
+
+// parseShareToken verifies a share token's signature and returns the ride it points to.
+func (s *RideService) parseShareToken(token string) (uuid.UUID, error) {
+	encodedID, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	rawID, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	rideID, err := uuid.FromBytes(rawID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.signShareToken(rideID)) {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	return rideID, nil
+}
+
+// ShareLink returns the public link of one of the creator's rides.
+func (s *RideService) ShareLink(ctx context.Context, rideID, userID uuid.UUID) (*models.RideShareLink, error) {
+	var creatorID uuid.UUID
+	if err := s.db.QueryRow(ctx, `SELECT user_id FROM rides WHERE id = $1`, rideID).Scan(&creatorID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if creatorID != userID {
+		return nil, newError(KindForbidden, "only the ride creator can share the ride")
+	}
+	token := s.shareToken(rideID)
+	return &models.RideShareLink{Token: token, URL: s.shareURL(token)}, nil
+}
+
+// shareURL returns the public link of a share token.
+func (s *RideService) shareURL(token string) string {
+	return strings.TrimRight(s.cfg.PublicBaseURL, "/") + "/api/v1/public/rides/" + token
+}
+
+// SharedRide returns the limited public view of the ride a share token points to.
+func (s *RideService) SharedRide(ctx context.Context, token string) (*models.SharedRide, error) {
+	rideID, err := s.parseShareToken(token)
+	if err != nil {
+		log.Printf("Shared ride requested with an invalid token")
+		return nil, err
+	}
+	ride, err := s.GetRideDetails(ctx, rideID)
+	if err != nil {
+		if errors.Is(err, ErrRideNotFound) {
+			return nil, ErrInvalidShareToken
+		}
+		return nil, err
+	}
+
+	shared := &models.SharedRide{
+		DepartureLocationName: ride.DepartureLocationName,
+		ArrivalLocationName:   ride.ArrivalLocationName,
+		DepartureDate:         ride.DepartureDate.Format("2006-01-02"),
+		DepartureTime:         clockTime(ride.DepartureTime),
+		EstimatedArrivalTime:  ride.EstimatedArrivalTime,
+		Status:                ride.Status,
+		SeatsAvailable:        max(ride.TotalSeats-ride.PlacesTaken, 0),
+		PricePerSeat:          ride.PricePerSeat,
+		DriverFirstName:       ride.CreatorFirstName,
+		DriverStats:           ride.DriverStats,
+	}
+	return shared, nil
+}
+
+// clockTime trims a TIME value ("08:30:00") to HH:MM.
+func clockTime(value string) string {
+	if len(value) > 5 {
+		return value[:5]
+	}
+	return value
+}
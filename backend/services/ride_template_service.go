@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For normalizing input
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// maxRideTemplatesPerUser bounds the templates a user may save.
+const maxRideTemplatesPerUser = 20
+
+var (
+	// ErrRideTemplateNotFound is returned when a template doesn't exist or belongs to someone else.
+	ErrRideTemplateNotFound = newError(KindNotFound, "ride template not found")
+	// ErrRideTemplateExists is returned when the user already has a template with the same name.
+	ErrRideTemplateExists = newError(KindConflict, "you already have a ride template with this name")
+	// ErrTooManyRideTemplates is returned when the user already saved maxRideTemplatesPerUser templates.
+	ErrTooManyRideTemplates = newError(KindConflict, fmt.Sprintf("you can save up to %d ride templates", maxRideTemplatesPerUser))
+)
+
+const rideTemplateColumns = `id, name, departure_location_name, ST_X(departure_coords), ST_Y(departure_coords),
+	arrival_location_name, ST_X(arrival_coords), ST_Y(arrival_coords), total_seats, price_per_seat, created_at, updated_at`
+
+// RideTemplateService manages the ride templates drivers create rides from, so frequent routes
+// aren't entered again every time.
+type RideTemplateService struct {
+	db        database.DBPool
+	validator *validator.Validate
+	rides     *RideService // Creates rides from templates
+}
+
+// NewRideTemplateService creates a new RideTemplateService instance.
+func NewRideTemplateService(db database.DBPool, rides *RideService) *RideTemplateService {
+	return &RideTemplateService{
+		db:        db,
+		validator: NewValidator(),
+		rides:     rides,
+	}
+}
+
+// normalizeRideTemplate trims the names of a template request.
+func normalizeRideTemplate(req models.RideTemplateRequest) models.RideTemplateRequest {
+	req.Name = strings.TrimSpace(req.Name)
+	req.DepartureLocationName = strings.TrimSpace(req.DepartureLocationName)
+	req.ArrivalLocationName = strings.TrimSpace(req.ArrivalLocationName)
+	return req
+}
+
+// List returns the user's ride templates, by name.
+func (s *RideTemplateService) List(ctx context.Context, userID uuid.UUID) ([]models.RideTemplate, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+rideTemplateColumns+` FROM ride_templates WHERE user_id = $1 ORDER BY lower(name)`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing ride templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.RideTemplate{}
+	for rows.Next() {
+		template, err := scanRideTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing ride template: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for ride templates: %w", err)
+	}
+	return templates, nil
+}
+
+// Create saves a ride template for the user.
+func (s *RideTemplateService) Create(ctx context.Context, userID uuid.UUID, req models.RideTemplateRequest) (*models.RideTemplate, error) {
+	req = normalizeRideTemplate(req)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid ride template: %w", err)
+	}
+	// The count check and insert are one statement, so concurrent saves can't exceed the limit by much
+	query := `
+		INSERT INTO ride_templates (user_id, name, departure_location_name, departure_coords, arrival_location_name, arrival_coords, total_seats, price_per_seat)
+		SELECT $1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, ST_SetSRID(ST_MakePoint($7, $8), 4326), $9, $10
+		WHERE (SELECT COUNT(*) FROM ride_templates WHERE user_id = $1) < $11
+		RETURNING ` + rideTemplateColumns
+	template, err := scanRideTemplate(s.db.QueryRow(ctx, query, userID, req.Name,
+		req.DepartureLocationName, req.DepartureCoords.Longitude, req.DepartureCoords.Latitude,
+		req.ArrivalLocationName, req.ArrivalCoords.Longitude, req.ArrivalCoords.Latitude,
+		req.TotalSeats, req.PricePerSeat, maxRideTemplatesPerUser))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+			return nil, ErrRideTemplateExists
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrTooManyRideTemplates
+		}
+		log.Printf("Error saving ride template for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error saving ride template: %w", err)
+	}
+	log.Printf("User %s saved ride template %s", userID, template.ID)
+	return template, nil
+}
+
+// Update replaces one of the user's ride templates. Rides already created from it are unchanged.
+func (s *RideTemplateService) Update(ctx context.Context, userID, templateID uuid.UUID, req models.RideTemplateRequest) (*models.RideTemplate, error) {
+	req = normalizeRideTemplate(req)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid ride template: %w", err)
+	}
+	query := `
+		UPDATE ride_templates SET name = $3,
+			departure_location_name = $4, departure_coords = ST_SetSRID(ST_MakePoint($5, $6), 4326),
+			arrival_location_name = $7, arrival_coords = ST_SetSRID(ST_MakePoint($8, $9), 4326),
+			total_seats = $10, price_per_seat = $11, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + rideTemplateColumns
+	template, err := scanRideTemplate(s.db.QueryRow(ctx, query, templateID, userID, req.Name,
+		req.DepartureLocationName, req.DepartureCoords.Longitude, req.DepartureCoords.Latitude,
+		req.ArrivalLocationName, req.ArrivalCoords.Longitude, req.ArrivalCoords.Latitude,
+		req.TotalSeats, req.PricePerSeat))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+			return nil, ErrRideTemplateExists
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrRideTemplateNotFound
+		}
+		return nil, fmt.Errorf("database error updating ride template: %w", err)
+	}
+	return template, nil
+}
+
+// Delete removes one of the user's ride templates.
+func (s *RideTemplateService) Delete(ctx context.Context, userID, templateID uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM ride_templates WHERE id = $1 AND user_id = $2`, templateID, userID)
+	if err != nil {
+		return fmt.Errorf("database error deleting ride template: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRideTemplateNotFound
+	}
+	return nil
+}
+
+// CreateRide creates a ride from one of the user's templates, departing at req's date and time.
+// The ride goes through CreateRide, so it is validated and checked like any new ride.
+func (s *RideTemplateService) CreateRide(ctx context.Context, userID, templateID uuid.UUID, req models.RideFromTemplateRequest) (*models.Ride, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid departure: %w", err)
+	}
+	template, err := scanRideTemplate(s.db.QueryRow(ctx, `SELECT `+rideTemplateColumns+` FROM ride_templates WHERE id = $1 AND user_id = $2`, templateID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideTemplateNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride template: %w", err)
+	}
+
+	ride, err := s.rides.CreateRide(ctx, models.CreateRideRequest{
+		DepartureLocationName: template.DepartureLocationName,
+		DepartureCoords:       template.DepartureCoords,
+		ArrivalLocationName:   template.ArrivalLocationName,
+		ArrivalCoords:         template.ArrivalCoords,
+		DepartureDate:         req.DepartureDate,
+		DepartureTime:         req.DepartureTime,
+		TotalSeats:            template.TotalSeats,
+		PricePerSeat:          template.PricePerSeat,
+		IgnoreConflicts:       req.IgnoreConflicts,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("User %s created ride %s from template %s", userID, ride.ID, templateID)
+	return ride, nil
+}
+
+func scanRideTemplate(row pgx.Row) (*models.RideTemplate, error) {
+	template := models.RideTemplate{DepartureCoords: &models.GeoPoint{}, ArrivalCoords: &models.GeoPoint{}}
+	err := row.Scan(&template.ID, &template.Name,
+		&template.DepartureLocationName, &template.DepartureCoords.Longitude, &template.DepartureCoords.Latitude,
+		&template.ArrivalLocationName, &template.ArrivalCoords.Longitude, &template.ArrivalCoords.Latitude,
+		&template.TotalSeats, &template.PricePerSeat, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/models"
+)
+
+// Test that the template limit surfaces as a conflict
+func TestRideTemplateService_Create_TooMany(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	templateService := NewRideTemplateService(mock, nil)
+	userID := uuid.New()
+	req := models.RideTemplateRequest{
+		Name:                  "  Friday to Lyon ",
+		DepartureLocationName: "Paris",
+		DepartureCoords:       &models.GeoPoint{Longitude: 2.35, Latitude: 48.85},
+		ArrivalLocationName:   "Lyon",
+		ArrivalCoords:         &models.GeoPoint{Longitude: 4.83, Latitude: 45.76},
+		TotalSeats:            3,
+	}
+
+	mock.ExpectQuery("INSERT INTO ride_templates").
+		WithArgs(userID, "Friday to Lyon", "Paris", 2.35, 48.85, "Lyon", 4.83, 45.76, 3, (*int64)(nil), maxRideTemplatesPerUser).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	if _, err := templateService.Create(context.Background(), userID, req); !errors.Is(err, ErrTooManyRideTemplates) {
+		t.Fatalf("Create over the limit = %v, want ErrTooManyRideTemplates", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that rides can't be created from another user's template
+func TestRideTemplateService_CreateRide_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	templateService := NewRideTemplateService(mock, nil)
+	userID, templateID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery("FROM ride_templates WHERE id = \\$1 AND user_id = \\$2").WithArgs(templateID, userID).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	req := models.RideFromTemplateRequest{DepartureDate: "2030-05-17", DepartureTime: "18:30"}
+	if _, err := templateService.CreateRide(context.Background(), userID, templateID, req); !errors.Is(err, ErrRideTemplateNotFound) {
+		t.Fatalf("CreateRide from another user's template = %v, want ErrRideTemplateNotFound", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context" // For database operations
+	"errors"  // For error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For the overlap window
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// ErrNoPendingTransfer is returned when a ride has no open transfer proposal.
+var ErrNoPendingTransfer = newError(KindNotFound, "no pending transfer for this ride")
+
+// transferOverlapWindow is how close another ride driven by the new driver may depart before it
+// counts as overlapping: one driver can't drive two rides leaving within this window of each other.
+const transferOverlapWindow = 3 * time.Hour
+
+// overlappingRideQuery reports whether user $2 drives another active ride departing within $3
+// seconds of ride $1.
+const overlappingRideQuery = `
+	SELECT EXISTS(
+		SELECT 1 FROM rides r, rides other
+		WHERE r.id = $1 AND other.user_id = $2 AND other.id <> r.id AND other.status = 'active'
+		  AND ABS(EXTRACT(EPOCH FROM (other.departure_date + other.departure_time) - (r.departure_date + r.departure_time))) < $3
+	)
+`
+
+// errOverlappingRide is returned when the new driver already drives a ride at about the same time.
+var errOverlappingRide = newError(KindConflict, "this user already drives another ride departing around the same time")
+
+// RideTransferService hands rides over to another driver. The creator proposes a transfer, the
+// proposed driver accepts or declines it; participants and their payments stay on the ride.
+type RideTransferService struct {
+	db            database.DBPool
+	rides         *RideService
+	payments      *PaymentService      // Refunds the new driver's own seat, if they had booked one
+	notifications *NotificationService // Tells the people involved about proposals and transfers
+}
+
+// NewRideTransferService creates a new RideTransferService instance.
+func NewRideTransferService(db database.DBPool, rides *RideService, payments *PaymentService, notifications *NotificationService) *RideTransferService {
+	return &RideTransferService{
+		db:            db,
+		rides:         rides,
+		payments:      payments,
+		notifications: notifications,
+	}
+}
+
+// ProposeTransfer offers the creator's active ride to another user. The user must have an active
+// account with no open or confirmed fraud flag and must not drive another ride departing within
+// transferOverlapWindow. A ride has at most one pending proposal.
+func (s *RideTransferService) ProposeTransfer(ctx context.Context, rideID uuid.UUID, creatorID uuid.UUID, req models.ProposeRideTransferRequest) (*models.RideTransfer, error) {
+	var ownerID uuid.UUID
+	var status, route string
+	query := `SELECT user_id, status, departure_location_name || ' → ' || arrival_location_name FROM rides WHERE id = $1`
+	if err := s.db.QueryRow(ctx, query, rideID).Scan(&ownerID, &status, &route); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRideNotFound
+		}
+		return nil, fmt.Errorf("database error fetching ride: %w", err)
+	}
+	if ownerID != creatorID {
+		return nil, newError(KindForbidden, "only the ride creator can transfer the ride")
+	}
+	if status != string(models.RideStatusActive) {
+		return nil, newError(KindConflict, "only active rides can be transferred")
+	}
+	if req.ToUserID == creatorID {
+		return nil, newError(KindInvalid, "you already own this ride")
+	}
+
+	var eligible bool
+	eligibleQuery := `
+		SELECT NOT EXISTS(SELECT 1 FROM fraud_flags WHERE user_id = u.id AND status IN ('open', 'confirmed'))
+		FROM users u WHERE u.id = $1 AND u.deleted_at IS NULL
+	`
+	if err := s.db.QueryRow(ctx, eligibleQuery, req.ToUserID).Scan(&eligible); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("database error checking new driver: %w", err)
+	}
+	if !eligible {
+		return nil, newError(KindForbidden, "this user cannot take over rides at the moment")
+	}
+
+	var overlapping bool
+	if err := s.db.QueryRow(ctx, overlappingRideQuery, rideID, req.ToUserID, transferOverlapWindow.Seconds()).Scan(&overlapping); err != nil {
+		return nil, fmt.Errorf("database error checking new driver's rides: %w", err)
+	}
+	if overlapping {
+		return nil, errOverlappingRide
+	}
+
+	transfer := &models.RideTransfer{RideID: rideID, FromUserID: creatorID, ToUserID: req.ToUserID, Route: route}
+	insertQuery := `
+		INSERT INTO ride_transfers (ride_id, from_user_id, to_user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (ride_id) WHERE status = 'pending' DO NOTHING
+		RETURNING id, status, created_at
+	`
+	err := s.db.QueryRow(ctx, insertQuery, rideID, creatorID, req.ToUserID).Scan(&transfer.ID, &transfer.Status, &transfer.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, newError(KindConflict, "a transfer is already pending for this ride")
+		}
+		log.Printf("Error proposing transfer of ride %s to user %s: %v", rideID, req.ToUserID, err)
+		return nil, fmt.Errorf("database error proposing transfer: %w", err)
+	}
+
+	log.Printf("User %s proposed transferring ride %s to user %s", creatorID, rideID, req.ToUserID)
+	s.notifications.Notify(ctx, req.ToUserID, models.NotificationEventRideTransferProposed, &rideID,
+		"Can you drive this ride?", fmt.Sprintf("You were asked to take over the ride %s as its driver.", route))
+	return transfer, nil
+}
+
+// ListPendingTransfers returns the transfer proposals waiting for the user's answer.
+func (s *RideTransferService) ListPendingTransfers(ctx context.Context, userID uuid.UUID) ([]models.RideTransfer, error) {
+	query := `
+		SELECT t.id, t.ride_id, t.from_user_id, t.to_user_id, t.status, t.created_at, t.responded_at,
+			r.departure_location_name || ' → ' || r.arrival_location_name
+		FROM ride_transfers t
+		JOIN rides r ON r.id = t.ride_id
+		WHERE t.to_user_id = $1 AND t.status = 'pending'
+		ORDER BY t.created_at DESC
+	`
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []models.RideTransfer{}
+	for rows.Next() {
+		var t models.RideTransfer
+		if err := rows.Scan(&t.ID, &t.RideID, &t.FromUserID, &t.ToUserID, &t.Status, &t.CreatedAt, &t.RespondedAt, &t.Route); err != nil {
+			return nil, fmt.Errorf("error processing transfers: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error iterating transfers: %w", err)
+	}
+	return transfers, nil
+}
+
+// AcceptTransfer makes the proposed driver the ride's creator. If they had booked a seat on the
+// ride, the booking is released and fully refunded (retried in the background if Stripe fails).
+// Participants are notified of the new driver.
+func (s *RideTransferService) AcceptTransfer(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.RideTransfer, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	transfer := &models.RideTransfer{RideID: rideID}
+	var ownerID uuid.UUID
+	var rideStatus string
+	query := `
+		SELECT t.id, t.from_user_id, t.to_user_id, t.created_at, r.user_id, r.status,
+			r.departure_location_name || ' → ' || r.arrival_location_name
+		FROM ride_transfers t
+		JOIN rides r ON r.id = t.ride_id
+		WHERE t.ride_id = $1 AND t.status = 'pending'
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, query, rideID).Scan(&transfer.ID, &transfer.FromUserID, &transfer.ToUserID, &transfer.CreatedAt, &ownerID, &rideStatus, &transfer.Route)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoPendingTransfer
+		}
+		return nil, fmt.Errorf("database error fetching transfer: %w", err)
+	}
+	if transfer.ToUserID != userID {
+		return nil, newError(KindForbidden, "this transfer was proposed to another user")
+	}
+	if ownerID != transfer.FromUserID || rideStatus != string(models.RideStatusActive) {
+		return nil, newError(KindConflict, "the ride changed since the transfer was proposed")
+	}
+	// The new driver may have created a ride since the proposal
+	var overlapping bool
+	if err := tx.QueryRow(ctx, overlappingRideQuery, rideID, userID, transferOverlapWindow.Seconds()).Scan(&overlapping); err != nil {
+		return nil, fmt.Errorf("database error checking new driver's rides: %w", err)
+	}
+	if overlapping {
+		return nil, errOverlappingRide
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE rides SET user_id = $2, vehicle_id = NULL, updated_at = NOW() WHERE id = $1`, rideID, userID); err != nil {
+		return nil, fmt.Errorf("database error transferring ride: %w", err)
+	}
+	err = tx.QueryRow(ctx, `UPDATE ride_transfers SET status = $2, responded_at = NOW() WHERE id = $1 RETURNING status, responded_at`,
+		transfer.ID, string(models.RideTransferStatusAccepted)).Scan(&transfer.Status, &transfer.RespondedAt)
+	if err != nil {
+		return nil, fmt.Errorf("database error accepting transfer: %w", err)
+	}
+
+	// The new driver can't also be a passenger on their own ride
+	var previousStatus string
+	releaseQuery := `
+		WITH previous AS (
+			SELECT id, status FROM participants
+			WHERE ride_id = $2 AND user_id = $3 AND status IN ($4, $5, $6)
+			FOR UPDATE
+		)
+		UPDATE participants p
+		SET status = $1, updated_at = NOW()
+		FROM previous
+		WHERE p.id = previous.id
+		RETURNING previous.status
+	`
+	err = tx.QueryRow(ctx, releaseQuery,
+		string(models.ParticipantStatusLeft), rideID, userID,
+		string(models.ParticipantStatusActive), string(models.ParticipantStatusPendingPayment), string(models.ParticipantStatusOnHold),
+	).Scan(&previousStatus)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("database error releasing new driver's booking: %w", err)
+	}
+	// Record the refund owed with the transfer, so it is retried if issuing it below fails
+	if previousStatus == string(models.ParticipantStatusActive) {
+		if err := s.payments.markRefundPending(ctx, tx, rideID, userID, 100, "became_driver"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit ride transfer: %w", err)
+	}
+	log.Printf("Ride %s transferred from user %s to user %s", rideID, transfer.FromUserID, userID)
+
+	if previousStatus == string(models.ParticipantStatusActive) {
+		if _, err := s.payments.refundPendingPayment(ctx, rideID, userID, 100, "became_driver"); err != nil {
+			log.Printf("Refund Warning: Ride %s was transferred to user %s, refunding their booking failed and will be retried: %v", rideID, userID, err)
+		}
+	}
+	s.rides.publishRideEvent(ctx, RideEventUpdated, rideID, userID)
+	s.notifyTransferred(ctx, transfer)
+	return transfer, nil
+}
+
+// notifyTransferred tells the previous creator and the active participants about the new driver.
+func (s *RideTransferService) notifyTransferred(ctx context.Context, transfer *models.RideTransfer) {
+	rideID := transfer.RideID
+	s.notifications.Notify(ctx, transfer.FromUserID, models.NotificationEventRideTransferred, &rideID,
+		"Ride transferred", fmt.Sprintf("Your ride %s has been taken over by its new driver.", transfer.Route))
+
+	rows, err := s.db.Query(ctx, `SELECT user_id FROM participants WHERE ride_id = $1 AND status = $2`, rideID, string(models.ParticipantStatusActive))
+	if err != nil {
+		log.Printf("Notification Error: Failed loading participants of ride %s for transfer notice: %v", rideID, err)
+		return
+	}
+	var participantIDs []uuid.UUID
+	for rows.Next() {
+		var participantID uuid.UUID
+		if err := rows.Scan(&participantID); err == nil {
+			participantIDs = append(participantIDs, participantID)
+		}
+	}
+	rows.Close()
+	for _, participantID := range participantIDs {
+		s.notifications.Notify(ctx, participantID, models.NotificationEventRideTransferred, &rideID,
+			"New driver", fmt.Sprintf("Your ride %s has a new driver. Your booking is unchanged.", transfer.Route))
+	}
+}
+
+// DeclineTransfer lets the proposed driver refuse a transfer.
+func (s *RideTransferService) DeclineTransfer(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.RideTransfer, error) {
+	transfer, err := s.closeTransfer(ctx, rideID, `to_user_id = $3`, userID, models.RideTransferStatusDeclined)
+	if err != nil {
+		return nil, err
+	}
+	s.notifications.Notify(ctx, transfer.FromUserID, models.NotificationEventRideTransferDeclined, &rideID,
+		"Transfer declined", fmt.Sprintf("Your transfer request for the ride %s was declined.", transfer.Route))
+	return transfer, nil
+}
+
+// CancelTransfer lets the creator withdraw a pending proposal.
+func (s *RideTransferService) CancelTransfer(ctx context.Context, rideID uuid.UUID, userID uuid.UUID) (*models.RideTransfer, error) {
+	return s.closeTransfer(ctx, rideID, `from_user_id = $3`, userID, models.RideTransferStatusCancelled)
+}
+
+// closeTransfer ends the ride's pending proposal if 'party' (a condition on $3) matches the user.
+func (s *RideTransferService) closeTransfer(ctx context.Context, rideID uuid.UUID, party string, userID uuid.UUID, status models.RideTransferStatus) (*models.RideTransfer, error) {
+	query := `
+		UPDATE ride_transfers t
+		SET status = $2, responded_at = NOW()
+		FROM rides r
+		WHERE t.ride_id = $1 AND t.status = 'pending' AND t.` + party + ` AND r.id = t.ride_id
+		RETURNING t.id, t.ride_id, t.from_user_id, t.to_user_id, t.status, t.created_at, t.responded_at,
+			r.departure_location_name || ' → ' || r.arrival_location_name
+	`
+	var t models.RideTransfer
+	err := s.db.QueryRow(ctx, query, rideID, string(status), userID).Scan(&t.ID, &t.RideID, &t.FromUserID, &t.ToUserID, &t.Status, &t.CreatedAt, &t.RespondedAt, &t.Route)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoPendingTransfer
+		}
+		return nil, fmt.Errorf("database error updating transfer: %w", err)
+	}
+	log.Printf("Transfer %s of ride %s %s by user %s", t.ID, rideID, status, userID)
+	return &t, nil
+}
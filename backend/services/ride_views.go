@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+)
+
+const rideViewQueueSize = 1024 // Views waiting to be recorded
+
+// rideView is one user viewing a ride's details.
+type rideView struct {
+	rideID uuid.UUID
+	userID uuid.UUID
+}
+
+// RideViewCounter counts the distinct users viewing each ride's details, for its creator. Views
+// are recorded in the background, so showing a ride never waits on the counter.
+type RideViewCounter struct {
+	db    database.DBPool
+	queue chan rideView
+}
+
+// NewRideViewCounter creates a new RideViewCounter instance.
+func NewRideViewCounter(db database.DBPool) *RideViewCounter {
+	return &RideViewCounter{
+		db:    db,
+		queue: make(chan rideView, rideViewQueueSize),
+	}
+}
+
+// Record queues a view of a ride by a user other than its creator. It never blocks: views
+// arriving while the queue is full aren't counted.
+func (c *RideViewCounter) Record(rideID, userID uuid.UUID) {
+	if c == nil {
+		return
+	}
+	select {
+	case c.queue <- rideView{rideID: rideID, userID: userID}:
+	default:
+		log.Printf("Warning: Ride view queue full, not counting view of ride %s", rideID)
+	}
+}
+
+// Start records queued views in the background.
+func (c *RideViewCounter) Start() {
+	go func() {
+		for view := range c.queue {
+			if err := c.record(context.Background(), view); err != nil {
+				log.Printf("Error recording view of ride %s: %v", view.rideID, err)
+			}
+		}
+	}()
+}
+
+// record counts a view if it is the user's first of the ride.
+func (c *RideViewCounter) record(ctx context.Context, view rideView) error {
+	query := `
+		WITH first_view AS (
+			INSERT INTO ride_views (ride_id, user_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING ride_id
+		)
+		INSERT INTO ride_view_counts (ride_id, views)
+		SELECT ride_id, 1 FROM first_view
+		ON CONFLICT (ride_id) DO UPDATE SET views = ride_view_counts.views + 1, updated_at = NOW()
+	`
+	if _, err := c.db.Exec(ctx, query, view.rideID, view.userID); err != nil {
+		return fmt.Errorf("database error recording ride view: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of distinct users who viewed a ride's details. Views still queued
+// aren't counted yet.
+func (c *RideViewCounter) Count(ctx context.Context, rideID uuid.UUID) (int, error) {
+	var views int
+	if err := c.db.QueryRow(ctx, `SELECT views FROM ride_view_counts WHERE ride_id = $1`, rideID).Scan(&views); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("database error fetching ride views: %w", err)
+	}
+	return views, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+// Test that views are recorded per viewer and that rides never viewed count zero views
+func TestRideViewCounter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	counter := NewRideViewCounter(mock)
+	rideID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec("INSERT INTO ride_views").WithArgs(rideID, userID).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	if err := counter.record(context.Background(), rideView{rideID: rideID, userID: userID}); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+
+	mock.ExpectQuery("SELECT views FROM ride_view_counts").WithArgs(rideID).WillReturnError(pgx.ErrNoRows)
+	if views, err := counter.Count(context.Background(), rideID); err != nil || views != 0 {
+		t.Errorf("Count() of a ride never viewed = %d, %v, want 0", views, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
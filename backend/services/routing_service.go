@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"         // For request bodies
+	"context"       // For request context
+	"encoding/json" // For the directions API
+	"fmt"           // For error formatting
+	"log"           // For logging
+	"net/http"      // For provider HTTP calls
+	"time"          // For timeouts
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// openRouteServiceDirectionsURL is the OpenRouteService driving directions endpoint.
+const openRouteServiceDirectionsURL = "https://api.openrouteservice.org/v2/directions/driving-car"
+
+// RoutingService computes driving routes with an OSRM server (OSRM_URL) or OpenRouteService.
+type RoutingService struct {
+	apiKey     string
+	baseURL    string
+	osrmURL    string // Takes precedence over OpenRouteService when set
+	httpClient *http.Client
+}
+
+// NewRoutingService creates a RoutingService. Without OSRM_URL or OPENROUTESERVICE_API_KEY, Route returns nil.
+func NewRoutingService(cfg *config.Config) *RoutingService {
+	return &RoutingService{
+		apiKey:     cfg.OpenRouteServiceAPIKey,
+		baseURL:    openRouteServiceDirectionsURL,
+		osrmURL:    cfg.OSRMURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second}, // Routes are computed on the ride creation path
+	}
+}
+
+// Route returns the driving route between two points, or nil if routing is not configured.
+func (s *RoutingService) Route(ctx context.Context, from, to models.GeoPoint) (*models.Route, error) {
+	if s.osrmURL != "" {
+		return s.routeOSRM(ctx, from, to)
+	}
+	if s.apiKey == "" {
+		return nil, nil
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"coordinates": [][2]float64{{from.Longitude, from.Latitude}, {to.Longitude, to.Latitude}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("routing request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openrouteservice returned HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Routes []struct {
+			Summary struct {
+				Distance float64 `json:"distance"` // Meters
+				Duration float64 `json:"duration"` // Seconds
+			} `json:"summary"`
+			Geometry string `json:"geometry"` // Encoded polyline (precision 5)
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode openrouteservice response: %w", err)
+	}
+	if len(result.Routes) == 0 || result.Routes[0].Geometry == "" {
+		return nil, fmt.Errorf("openrouteservice returned no route")
+	}
+	route := result.Routes[0]
+	log.Printf("Route computed: %.0f m, %.0f s", route.Summary.Distance, route.Summary.Duration)
+	return &models.Route{
+		Polyline:        route.Geometry,
+		DistanceMeters:  route.Summary.Distance,
+		DurationSeconds: route.Summary.Duration,
+	}, nil
+}
+
+// routeOSRM computes the route with the OSRM route service.
+func (s *RoutingService) routeOSRM(ctx context.Context, from, to models.GeoPoint) (*models.Route, error) {
+	url := fmt.Sprintf("%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=polyline",
+		s.osrmURL, from.Longitude, from.Latitude, to.Longitude, to.Latitude)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("routing request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("osrm returned HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Code   string `json:"code"`
+		Routes []struct {
+			Distance float64 `json:"distance"` // Meters
+			Duration float64 `json:"duration"` // Seconds
+			Geometry string  `json:"geometry"` // Encoded polyline (precision 5)
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode osrm response: %w", err)
+	}
+	if result.Code != "Ok" || len(result.Routes) == 0 || result.Routes[0].Geometry == "" {
+		return nil, fmt.Errorf("osrm returned no route (code %s)", result.Code)
+	}
+	route := result.Routes[0]
+	log.Printf("Route computed (OSRM): %.0f m, %.0f s", route.Distance, route.Duration)
+	return &models.Route{
+		Polyline:        route.Geometry,
+		DistanceMeters:  route.Distance,
+		DurationSeconds: route.Duration,
+	}, nil
+}
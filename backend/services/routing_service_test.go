@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that the directions request carries the key and lon/lat pairs, and the polyline is returned
+func TestRoutingService_Route(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "ors-key" {
+			t.Errorf("Authorization = %s, want ors-key", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Coordinates [][2]float64 `json:"coordinates"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Coordinates) != 2 || body.Coordinates[0] != [2]float64{2.35, 48.85} {
+			t.Errorf("coordinates = %v, want [lon, lat] pairs starting with [2.35 48.85]", body.Coordinates)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"routes":[{"summary":{"distance":465000.5,"duration":16200},"geometry":"_p~iF~ps|U_ulLnnqC"}]}`))
+	}))
+	defer server.Close()
+
+	service := NewRoutingService(&config.Config{OpenRouteServiceAPIKey: "ors-key"})
+	service.baseURL = server.URL
+	route, err := service.Route(context.Background(), models.GeoPoint{Latitude: 48.85, Longitude: 2.35}, models.GeoPoint{Latitude: 45.76, Longitude: 4.84})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if route.Polyline != "_p~iF~ps|U_ulLnnqC" || route.DistanceMeters != 465000.5 || route.DurationSeconds != 16200 {
+		t.Errorf("Route() = %+v", route)
+	}
+
+	if route, err := NewRoutingService(&config.Config{}).Route(context.Background(), models.GeoPoint{}, models.GeoPoint{}); route != nil || err != nil {
+		t.Errorf("Route() without API key = %+v, %v, want nil, nil", route, err)
+	}
+}
+
+// Test that an OSRM server is used instead of OpenRouteService when configured
+func TestRoutingService_RouteOSRM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/route/v1/driving/2.350000,48.850000;4.840000,45.760000") {
+			t.Errorf("path = %s, want lon,lat pairs starting with the departure", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":"Ok","routes":[{"distance":465000.5,"duration":16200,"geometry":"_p~iF~ps|U_ulLnnqC"}]}`))
+	}))
+	defer server.Close()
+
+	service := NewRoutingService(&config.Config{OpenRouteServiceAPIKey: "ors-key", OSRMURL: server.URL})
+	route, err := service.Route(context.Background(), models.GeoPoint{Latitude: 48.85, Longitude: 2.35}, models.GeoPoint{Latitude: 45.76, Longitude: 4.84})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if route.Polyline != "_p~iF~ps|U_ulLnnqC" || route.DistanceMeters != 465000.5 || route.DurationSeconds != 16200 {
+		t.Errorf("Route() = %+v", route)
+	}
+}
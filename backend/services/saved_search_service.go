@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For normalizing input
+	"time"    // For date ranges
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+const (
+	maxSavedSearches          = 10  // Searches a user may save
+	defaultSavedSearchRadius  = 10  // Km, when the request doesn't set one
+	maxSavedSearchSpanDays    = 90  // Longest date range of a saved search
+	savedSearchMatchQueueSize = 256 // Created rides waiting to be matched
+)
+
+var (
+	// ErrSavedSearchNotFound is returned when a saved search doesn't exist or belongs to someone else.
+	ErrSavedSearchNotFound = newError(KindNotFound, "saved search not found")
+	// ErrTooManySavedSearches is returned when the user already saved maxSavedSearches searches.
+	ErrTooManySavedSearches = newError(KindConflict, fmt.Sprintf("you can save up to %d searches", maxSavedSearches))
+)
+
+const savedSearchColumns = `id, departure_location_name, ST_X(departure_coords), ST_Y(departure_coords),
+	arrival_location_name, ST_X(arrival_coords), ST_Y(arrival_coords), radius_km, date_from, date_to, created_at`
+
+// SavedSearchService manages saved searches (a route and a date range) and alerts their users by
+// push when a matching ride is published. Created rides are matched in the background, so
+// publishing a ride never waits on the matcher.
+type SavedSearchService struct {
+	db            database.DBPool
+	validator     *validator.Validate
+	notifications *NotificationService
+	queue         chan uuid.UUID // IDs of created rides to match
+}
+
+// NewSavedSearchService creates a new SavedSearchService instance.
+func NewSavedSearchService(db database.DBPool, notifications *NotificationService) *SavedSearchService {
+	return &SavedSearchService{
+		db:            db,
+		validator:     NewValidator(),
+		notifications: notifications,
+		queue:         make(chan uuid.UUID, savedSearchMatchQueueSize),
+	}
+}
+
+// List returns the user's saved searches, oldest first.
+func (s *SavedSearchService) List(ctx context.Context, userID uuid.UUID) ([]models.SavedSearch, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+savedSearchColumns+` FROM saved_searches WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing saved search: %w", err)
+		}
+		searches = append(searches, *search)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// Create saves a search for the user. Rides published afterwards that match it are announced by push.
+func (s *SavedSearchService) Create(ctx context.Context, userID uuid.UUID, req models.CreateSavedSearchRequest) (*models.SavedSearch, error) {
+	req.DepartureLocationName = strings.TrimSpace(req.DepartureLocationName)
+	req.ArrivalLocationName = strings.TrimSpace(req.ArrivalLocationName)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid saved search: %w", err)
+	}
+	from, to, err := savedSearchDates(req.DateFrom, req.DateTo, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved search: %w", err)
+	}
+	radius := defaultSavedSearchRadius
+	if req.RadiusKm != nil {
+		radius = *req.RadiusKm
+	}
+
+	// The count check and insert are one statement, so concurrent saves can't exceed the limit by much
+	query := `
+		INSERT INTO saved_searches (user_id, departure_location_name, departure_coords, arrival_location_name, arrival_coords, radius_km, date_from, date_to)
+		SELECT $1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326), $5, ST_SetSRID(ST_MakePoint($6, $7), 4326), $8, $9, $10
+		WHERE (SELECT COUNT(*) FROM saved_searches WHERE user_id = $1) < $11
+		RETURNING ` + savedSearchColumns
+	search, err := scanSavedSearch(s.db.QueryRow(ctx, query, userID,
+		req.DepartureLocationName, req.DepartureCoords.Longitude, req.DepartureCoords.Latitude,
+		req.ArrivalLocationName, req.ArrivalCoords.Longitude, req.ArrivalCoords.Latitude,
+		radius, from, to, maxSavedSearches))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTooManySavedSearches
+		}
+		log.Printf("Error saving search for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error saving search: %w", err)
+	}
+	log.Printf("User %s saved search %s", userID, search.ID)
+	return search, nil
+}
+
+// savedSearchDates parses a saved search's date range. The range must not have ended by today and
+// spans at most maxSavedSearchSpanDays.
+func savedSearchDates(dateFrom, dateTo string, now time.Time) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01-02", dateFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date_from: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", dateTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date_to: %w", err)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case to.Before(from):
+		return time.Time{}, time.Time{}, errors.New("date_to must not be before date_from")
+	case to.Before(today):
+		return time.Time{}, time.Time{}, errors.New("date_to must not be in the past")
+	case to.Sub(from) > maxSavedSearchSpanDays*24*time.Hour:
+		return time.Time{}, time.Time{}, fmt.Errorf("date range must not exceed %d days", maxSavedSearchSpanDays)
+	}
+	return from, to, nil
+}
+
+// Delete removes one of the user's saved searches.
+func (s *SavedSearchService) Delete(ctx context.Context, userID, searchID uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, searchID, userID)
+	if err != nil {
+		return fmt.Errorf("database error deleting saved search: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// HandleRideEvent queues created rides for matching against saved searches. It never blocks:
+// rides arriving while the queue is full aren't announced.
+func (s *SavedSearchService) HandleRideEvent(event RideEvent) {
+	if event.Type != RideEventCreated {
+		return
+	}
+	select {
+	case s.queue <- event.RideID:
+	default:
+		log.Printf("Warning: Saved search queue full, not matching ride %s", event.RideID)
+	}
+}
+
+// Start matches queued rides against saved searches in the background.
+func (s *SavedSearchService) Start() {
+	go func() {
+		for rideID := range s.queue {
+			s.matchRide(context.Background(), rideID)
+		}
+	}()
+}
+
+// matchRide alerts the users of the saved searches a ride matches: it departs within the search's
+// dates, and both its departure and arrival are within the search's radius. Each search is alerted
+// at most once per ride (saved_search_alerts), and each user at most once per ride even when
+// several of their searches match. Creators aren't alerted of their own rides.
+func (s *SavedSearchService) matchRide(ctx context.Context, rideID uuid.UUID) {
+	query := `
+		WITH matched AS (
+			INSERT INTO saved_search_alerts (saved_search_id, ride_id)
+			SELECT ss.id, r.id
+			FROM saved_searches ss
+			JOIN rides r ON r.id = $1
+			WHERE r.status = 'active'
+			  AND ss.user_id <> r.user_id
+			  AND r.departure_date BETWEEN ss.date_from AND ss.date_to
+			  AND ST_DWithin(r.departure_coords::geography, ss.departure_coords::geography, ss.radius_km * 1000)
+			  AND ST_DWithin(r.arrival_coords::geography, ss.arrival_coords::geography, ss.radius_km * 1000)
+			ON CONFLICT DO NOTHING
+			RETURNING saved_search_id
+		)
+		SELECT DISTINCT ss.user_id, r.departure_location_name || ' → ' || r.arrival_location_name, r.departure_date
+		FROM matched m
+		JOIN saved_searches ss ON ss.id = m.saved_search_id
+		JOIN rides r ON r.id = $1
+	`
+	rows, err := s.db.Query(ctx, query, rideID)
+	if err != nil {
+		log.Printf("Error matching ride %s against saved searches: %v", rideID, err)
+		return
+	}
+	type alert struct {
+		userID        uuid.UUID
+		routeName     string
+		departureDate time.Time
+	}
+	var alerts []alert
+	for rows.Next() {
+		var a alert
+		if err := rows.Scan(&a.userID, &a.routeName, &a.departureDate); err != nil {
+			log.Printf("Error reading saved search match for ride %s: %v", rideID, err)
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("Error matching ride %s against saved searches: %v", rideID, err)
+	}
+
+	for _, a := range alerts {
+		s.notifications.Notify(ctx, a.userID, models.NotificationEventSavedSearchMatch, &rideID,
+			"New ride for your search", fmt.Sprintf("A ride %s on %s matches one of your saved searches.", a.routeName, a.departureDate.Format("2006-01-02")))
+	}
+	if len(alerts) > 0 {
+		log.Printf("Ride %s matched saved searches of %d users", rideID, len(alerts))
+	}
+}
+
+func scanSavedSearch(row pgx.Row) (*models.SavedSearch, error) {
+	search := models.SavedSearch{DepartureCoords: &models.GeoPoint{}, ArrivalCoords: &models.GeoPoint{}}
+	err := row.Scan(&search.ID,
+		&search.DepartureLocationName, &search.DepartureCoords.Longitude, &search.DepartureCoords.Latitude,
+		&search.ArrivalLocationName, &search.ArrivalCoords.Longitude, &search.ArrivalCoords.Latitude,
+		&search.RadiusKm, &search.DateFrom, &search.DateTo, &search.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
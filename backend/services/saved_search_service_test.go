@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test the date range rules of saved searches
+func TestSavedSearchDates(t *testing.T) {
+	now := time.Date(2030, 5, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		from, to string
+		valid    bool
+	}{
+		{"2030-05-10", "2030-05-10", true},  // Today only
+		{"2030-05-01", "2030-05-12", true},  // Started, not over
+		{"2030-05-12", "2030-08-10", true},  // 90 days
+		{"2030-05-12", "2030-08-11", false}, // 91 days
+		{"2030-05-12", "2030-05-11", false}, // Ends before it starts
+		{"2030-05-01", "2030-05-09", false}, // Over
+		{"2030-5-12", "2030-05-13", false},  // Not YYYY-MM-DD
+	}
+	for _, tt := range tests {
+		_, _, err := savedSearchDates(tt.from, tt.to, now)
+		if (err == nil) != tt.valid {
+			t.Errorf("savedSearchDates(%s, %s) error = %v, want valid %v", tt.from, tt.to, err, tt.valid)
+		}
+	}
+}
+
+// Test that only created rides are queued for matching
+func TestSavedSearchService_HandleRideEvent(t *testing.T) {
+	searchService := NewSavedSearchService(nil, nil)
+	created := uuid.New()
+	searchService.HandleRideEvent(RideEvent{Type: RideEventUpdated, RideID: uuid.New()})
+	searchService.HandleRideEvent(RideEvent{Type: RideEventCreated, RideID: created})
+
+	if len(searchService.queue) != 1 {
+		t.Fatalf("queued %d rides, want 1", len(searchService.queue))
+	}
+	if rideID := <-searchService.queue; rideID != created {
+		t.Errorf("queued ride %s, want %s", rideID, created)
+	}
+}
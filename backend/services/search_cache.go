@@ -0,0 +1,240 @@
+package services
+
+import (
+	"fmt"     // For cache keys and metrics
+	"io"      // For the metrics exposition
+	"log"     // For logging
+	"strconv" // For price filters in cache keys
+	"strings" // For normalizing filters
+	"sync"    // For the cache map
+	"time"    // For expiry
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// searchFilters are the normalized filters of a cached search.
+type searchFilters struct {
+	start string // Lower-cased departure location substring
+	end   string // Lower-cased arrival location substring
+	date  string // YYYY-MM-DD or empty
+	seats int    // Free seats required
+	price string // Seat price range in cents, "min-max" with empty bounds omitted
+	times string // Departure time range, "after-before" with empty bounds omitted
+	prefs string // Preference filters, "smoking,pets,music,chat,luggage" with unset filters empty
+}
+
+// searchCacheEntry is a cached page of search results.
+type searchCacheEntry struct {
+	filters   searchFilters
+	rides     []models.Ride
+	rideIDs   map[uuid.UUID]bool
+	expiresAt time.Time
+}
+
+// SearchCache caches the first pages of ride searches (e.g. a city pair for today or tomorrow)
+// and drops the affected pages when a ride event says their results changed. A nil
+// *SearchCache caches nothing.
+type SearchCache struct {
+	ttl        time.Duration
+	maxPages   int
+	maxEntries int
+
+	mu            sync.Mutex
+	entries       map[string]*searchCacheEntry
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// NewSearchCache creates a SearchCache from cfg. A zero SearchCacheTTL disables caching.
+func NewSearchCache(cfg *config.Config) *SearchCache {
+	if cfg.SearchCacheTTL <= 0 {
+		log.Println("Search cache disabled (SEARCH_CACHE_TTL is 0)")
+		return nil
+	}
+	return &SearchCache{
+		ttl:        cfg.SearchCacheTTL,
+		maxPages:   cfg.SearchCacheMaxPages,
+		maxEntries: cfg.SearchCacheMaxEntries,
+		entries:    make(map[string]*searchCacheEntry),
+	}
+}
+
+// normalizeSearch returns the filters and cache key of a search, and whether it may be cached.
+// Only the first pages are cached, since later pages are rarely requested.
+func (c *SearchCache) normalizeSearch(params models.SearchRidesRequest) (searchFilters, string, bool) {
+	var filters searchFilters
+	if params.StartLocation != nil {
+		filters.start = strings.ToLower(strings.TrimSpace(*params.StartLocation))
+	}
+	if params.EndLocation != nil {
+		filters.end = strings.ToLower(strings.TrimSpace(*params.EndLocation))
+	}
+	if params.DepartureDate != nil {
+		filters.date = *params.DepartureDate
+	}
+	filters.seats = 1
+	if params.Seats != nil {
+		filters.seats = *params.Seats
+	}
+	if params.MinPrice != nil {
+		filters.price = strconv.FormatInt(*params.MinPrice, 10)
+	}
+	filters.price += "-"
+	if params.MaxPrice != nil {
+		filters.price += strconv.FormatInt(*params.MaxPrice, 10)
+	}
+	if params.DepartureAfter != nil {
+		filters.times = *params.DepartureAfter
+	}
+	filters.times += "-"
+	if params.DepartureBefore != nil {
+		filters.times += *params.DepartureBefore
+	}
+	prefs := make([]string, 0, 4)
+	for _, pref := range []*bool{params.SmokingAllowed, params.PetsAllowed, params.MusicAllowed} {
+		value := ""
+		if pref != nil {
+			value = strconv.FormatBool(*pref)
+		}
+		prefs = append(prefs, value)
+	}
+	chatLevel, luggage, passengerGender := "", "", ""
+	if params.ChatLevel != nil {
+		chatLevel = *params.ChatLevel
+	}
+	if params.Luggage != nil {
+		luggage = *params.Luggage
+	}
+	if params.PassengerGender != nil {
+		passengerGender = *params.PassengerGender
+	}
+	filters.prefs = strings.Join(append(prefs, chatLevel, luggage, passengerGender), ",")
+	page, limit := 1, 0
+	if params.Page != nil {
+		page = *params.Page
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	sort := models.SearchSortDepartureTime
+	if params.Sort != nil && *params.Sort != "" {
+		sort = *params.Sort
+	}
+	key := fmt.Sprintf("%s|%s|%s|%d|%s|%s|%s|%s|%d|%d", filters.start, filters.end, filters.date, filters.seats, filters.price, filters.times, filters.prefs, sort, page, limit)
+	return filters, key, page <= c.maxPages
+}
+
+// Get returns the cached results of a search, if present and fresh.
+func (c *SearchCache) Get(params models.SearchRidesRequest) ([]models.Ride, bool) {
+	if c == nil {
+		return nil, false
+	}
+	_, key, cacheable := c.normalizeSearch(params)
+	if !cacheable {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return append([]models.Ride(nil), entry.rides...), true
+}
+
+// Set caches the results of a search if it is one of the cached pages.
+func (c *SearchCache) Set(params models.SearchRidesRequest, rides []models.Ride) {
+	if c == nil {
+		return
+	}
+	filters, key, cacheable := c.normalizeSearch(params)
+	if !cacheable {
+		return
+	}
+	entry := &searchCacheEntry{
+		filters:   filters,
+		rides:     append([]models.Ride(nil), rides...),
+		rideIDs:   make(map[uuid.UUID]bool, len(rides)),
+		expiresAt: time.Now().Add(c.ttl),
+	}
+	for _, ride := range rides {
+		entry.rideIDs[ride.ID] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.evictExpired()
+		if len(c.entries) >= c.maxEntries {
+			return // Full of fresh entries; the hottest searches are already cached
+		}
+	}
+	c.entries[key] = entry
+}
+
+// evictExpired removes stale entries. Callers hold mu.
+func (c *SearchCache) evictExpired() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// HandleRideEvent drops the cached pages a ride change affects: pages listing the ride (its seats
+// or status changed) and pages whose filters match the ride (it may now appear in them).
+func (c *SearchCache) HandleRideEvent(event RideEvent) {
+	if c == nil {
+		return
+	}
+	departure := strings.ToLower(event.DepartureLocationName)
+	arrival := strings.ToLower(event.ArrivalLocationName)
+	date := ""
+	if !event.DepartureDate.IsZero() {
+		date = event.DepartureDate.Format("2006-01-02")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.rideIDs[event.RideID] || (departure != "" && entry.filters.matches(departure, arrival, date)) {
+			delete(c.entries, key)
+			c.invalidations++
+		}
+	}
+}
+
+// matches reports whether a ride could appear in a search with these filters. It mirrors the
+// ILIKE substring filters of SearchRides; filters with SQL wildcards always match.
+func (f searchFilters) matches(departure, arrival, date string) bool {
+	matchesName := func(filter, name string) bool {
+		return filter == "" || strings.ContainsAny(filter, "%_") || strings.Contains(name, filter)
+	}
+	return matchesName(f.start, departure) && matchesName(f.end, arrival) && (f.date == "" || f.date == date)
+}
+
+// WritePrometheus writes the cache hit, miss and invalidation counters.
+func (c *SearchCache) WritePrometheus(w io.Writer) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(w, "# HELP rideshare_search_cache_requests_total Ride search cache lookups by result.")
+	fmt.Fprintln(w, "# TYPE rideshare_search_cache_requests_total counter")
+	fmt.Fprintf(w, "rideshare_search_cache_requests_total%s %d\n", labels("result", "hit"), c.hits)
+	fmt.Fprintf(w, "rideshare_search_cache_requests_total%s %d\n", labels("result", "miss"), c.misses)
+	fmt.Fprintln(w, "# HELP rideshare_search_cache_invalidations_total Cached search pages dropped by ride events.")
+	fmt.Fprintln(w, "# TYPE rideshare_search_cache_invalidations_total counter")
+	fmt.Fprintf(w, "rideshare_search_cache_invalidations_total %d\n", c.invalidations)
+	fmt.Fprintln(w, "# HELP rideshare_search_cache_entries Cached search pages.")
+	fmt.Fprintln(w, "# TYPE rideshare_search_cache_entries gauge")
+	fmt.Fprintf(w, "rideshare_search_cache_entries %d\n", len(c.entries))
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+// Test that only the first pages are cached and that ride events drop exactly the affected pages
+func TestSearchCache_Invalidation(t *testing.T) {
+	cache := NewSearchCache(&config.Config{SearchCacheTTL: time.Minute, SearchCacheMaxPages: 2, SearchCacheMaxEntries: 10})
+	listedRide := models.Ride{ID: uuid.New(), DepartureLocationName: "Paris", ArrivalLocationName: "Lyon"}
+
+	parisLyon := models.SearchRidesRequest{StartLocation: strPtr(" Paris"), EndLocation: strPtr("lyon")}
+	parisLyonToday := models.SearchRidesRequest{StartLocation: strPtr("paris"), EndLocation: strPtr("lyon"), DepartureDate: strPtr("2025-06-01")}
+	lilleNantes := models.SearchRidesRequest{StartLocation: strPtr("lille"), EndLocation: strPtr("nantes")}
+	page := 3
+	thirdPage := models.SearchRidesRequest{StartLocation: strPtr("paris"), Page: &page}
+
+	cache.Set(parisLyon, []models.Ride{listedRide})
+	cache.Set(parisLyonToday, []models.Ride{})
+	cache.Set(lilleNantes, []models.Ride{})
+	cache.Set(thirdPage, []models.Ride{listedRide})
+
+	if rides, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("PARIS"), EndLocation: strPtr("Lyon ")}); !ok || len(rides) != 1 {
+		t.Fatalf("Get() with differently cased filters = %v, %v, want the cached page", rides, ok)
+	}
+	if _, ok := cache.Get(thirdPage); ok {
+		t.Error("page 3 was cached, want only the first 2 pages")
+	}
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), EndLocation: strPtr("lyon"), DepartureAfter: strPtr("18:00")}); ok {
+		t.Error("search with a departure time range was served the unfiltered page")
+	}
+
+	// A new Paris → Lyon ride for 2025-06-02 affects searches without a date, not those for 2025-06-01
+	cache.HandleRideEvent(RideEvent{
+		Type:                  RideEventCreated,
+		RideID:                uuid.New(),
+		DepartureLocationName: "Paris Gare de Lyon",
+		ArrivalLocationName:   "Lyon Part-Dieu",
+		DepartureDate:         time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+	})
+	if _, ok := cache.Get(parisLyon); ok {
+		t.Error("Paris → Lyon page still cached after a matching ride was created")
+	}
+	if _, ok := cache.Get(parisLyonToday); !ok {
+		t.Error("Paris → Lyon page for another date was dropped")
+	}
+	if _, ok := cache.Get(lilleNantes); !ok {
+		t.Error("unrelated Lille → Nantes page was dropped")
+	}
+
+	// A seat taken on a listed ride drops the pages listing it, even without route details
+	cache.Set(parisLyon, []models.Ride{listedRide})
+	cache.HandleRideEvent(RideEvent{Type: RideEventCancelled, RideID: listedRide.ID})
+	if _, ok := cache.Get(parisLyon); ok {
+		t.Error("page listing a cancelled ride is still cached")
+	}
+	if _, ok := cache.Get(lilleNantes); !ok {
+		t.Error("unrelated page was dropped by a cancellation")
+	}
+}
+
+// Test that a disabled (nil) cache never returns results
+func TestSearchCache_Disabled(t *testing.T) {
+	cache := NewSearchCache(&config.Config{})
+	cache.Set(models.SearchRidesRequest{}, []models.Ride{{ID: uuid.New()}})
+	if _, ok := cache.Get(models.SearchRidesRequest{}); ok {
+		t.Error("disabled cache returned a result")
+	}
+}
+
+// Test that searches for different group sizes are cached separately
+func TestSearchCache_SeatsInKey(t *testing.T) {
+	cache := NewSearchCache(&config.Config{SearchCacheTTL: time.Minute, SearchCacheMaxPages: 2, SearchCacheMaxEntries: 10})
+	one, three := 1, 3
+	cache.Set(models.SearchRidesRequest{StartLocation: strPtr("paris"), Seats: &three}, []models.Ride{})
+
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris")}); ok {
+		t.Error("search without seats served the page cached for 3 seats")
+	}
+	cache.Set(models.SearchRidesRequest{StartLocation: strPtr("paris")}, []models.Ride{})
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), Seats: &one}); !ok {
+		t.Error("seats=1 should share the page of a search without seats")
+	}
+}
+
+// Test that preference filters are part of the cache key
+func TestSearchCache_PreferencesInKey(t *testing.T) {
+	cache := NewSearchCache(&config.Config{SearchCacheTTL: time.Minute, SearchCacheMaxPages: 2, SearchCacheMaxEntries: 10})
+	yes, no := true, false
+	cache.Set(models.SearchRidesRequest{StartLocation: strPtr("paris"), PetsAllowed: &yes}, []models.Ride{})
+
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris")}); ok {
+		t.Error("search without preferences served the page cached for pets_allowed=true")
+	}
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), PetsAllowed: &no}); ok {
+		t.Error("pets_allowed=false served the page cached for pets_allowed=true")
+	}
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), SmokingAllowed: &yes}); ok {
+		t.Error("smoking_allowed=true served the page cached for pets_allowed=true")
+	}
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), PetsAllowed: &yes}); !ok {
+		t.Error("same preferences should hit the cache")
+	}
+}
+
+// Test that the sort order is part of the cache key, the default order sharing the page of sort=departure_time
+func TestSearchCache_SortInKey(t *testing.T) {
+	cache := NewSearchCache(&config.Config{SearchCacheTTL: time.Minute, SearchCacheMaxPages: 2, SearchCacheMaxEntries: 10})
+	cache.Set(models.SearchRidesRequest{StartLocation: strPtr("paris")}, []models.Ride{})
+
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), Sort: strPtr(models.SearchSortDistance)}); ok {
+		t.Error("sort=distance served the page cached in departure order")
+	}
+	if _, ok := cache.Get(models.SearchRidesRequest{StartLocation: strPtr("paris"), Sort: strPtr(models.SearchSortDepartureTime)}); !ok {
+		t.Error("sort=departure_time should share the page of the default order")
+	}
+}
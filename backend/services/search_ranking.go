@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Distances and amounts at which a ranking signal scores 0.5.
+const (
+	rankHalfScoreSeconds = 24 * 60 * 60 // Departure a day away
+	rankHalfScoreCents   = 1000         // 10 EUR seat
+	rankHalfScoreMeters  = 10000        // Departure 10 km from the caller
+)
+
+// searchRankExpression returns the SQL relevance score of a ride (aliased r) in search results, the
+// weighted sum of the signals in weights, and the arguments it adds starting at $argID. near is the
+// caller's location, nil if unknown. It returns an empty expression when every weight is 0.
+func searchRankExpression(weights config.SearchWeights, proximityStrategy string, bookingFee int64, near *models.GeoPoint, argID int) (string, []interface{}) {
+	var terms []string
+	var args []interface{}
+	if weights.DepartureTime > 0 {
+		terms = append(terms, fmt.Sprintf("%d * (1.0 / (1 + GREATEST(EXTRACT(EPOCH FROM r.departure_date + r.departure_time - LOCALTIMESTAMP), 0) / %d))",
+			weights.DepartureTime, rankHalfScoreSeconds))
+	}
+	if weights.DriverReliability > 0 {
+		// Cancellation rate of the driver's rides; drivers without rides aren't penalized
+		terms = append(terms, fmt.Sprintf(`%d * (1.0 - COALESCE((
+			SELECT COUNT(*) FILTER (WHERE d.status = 'cancelled')::float8 / NULLIF(COUNT(*), 0) FROM rides d WHERE d.user_id = r.user_id
+		), 0))`, weights.DriverReliability))
+	}
+	if weights.Price > 0 {
+		terms = append(terms, fmt.Sprintf("%d * (1.0 / (1 + COALESCE(r.price_per_seat, $%d) / %d.0))", weights.Price, argID, rankHalfScoreCents))
+		args = append(args, bookingFee)
+		argID++
+	}
+	if weights.PickupDistance > 0 && near != nil {
+		if proximityStrategy == config.ProximityGeohash {
+			terms = append(terms, fmt.Sprintf("%d * (r.departure_geohash LIKE ANY($%d))::int", weights.PickupDistance, argID))
+			args = append(args, geohashLikePatterns(GeohashProximityPrefixes(near.Latitude, near.Longitude, rankHalfScoreMeters)))
+		} else {
+			terms = append(terms, fmt.Sprintf("%d * (1.0 / (1 + ST_Distance(r.departure_coords::geography, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography) / %d))",
+				weights.PickupDistance, argID, argID+1, rankHalfScoreMeters))
+			args = append(args, near.Longitude, near.Latitude)
+		}
+	}
+	return strings.Join(terms, " + "), args
+}
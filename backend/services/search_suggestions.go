@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"net/url" // For search query strings
+	"strings" // For matching typed text
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+const (
+	searchSuggestionsPerKind = 5  // Suggestions of each kind returned at most
+	maxSavedCommutes         = 10 // Commutes a user may save
+)
+
+var (
+	// ErrCommuteNotFound is returned when a saved commute doesn't exist or belongs to someone else.
+	ErrCommuteNotFound = newError(KindNotFound, "commute not found")
+	// ErrCommuteExists is returned when the user already saved a commute between the same locations.
+	ErrCommuteExists = newError(KindConflict, "this commute is already saved")
+	// ErrTooManyCommutes is returned when the user already saved maxSavedCommutes commutes.
+	ErrTooManyCommutes = newError(KindConflict, fmt.Sprintf("you can save up to %d commutes", maxSavedCommutes))
+)
+
+// SearchSuggestionService fills the ride search box: the caller's recent searches, their saved
+// commutes and the routes with the most upcoming rides.
+type SearchSuggestionService struct {
+	db        database.DBPool
+	validator *validator.Validate
+}
+
+// NewSearchSuggestionService creates a new SearchSuggestionService instance.
+func NewSearchSuggestionService(db database.DBPool) *SearchSuggestionService {
+	return &SearchSuggestionService{
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// Suggestions returns search suggestions for a user (uuid.Nil for visitors) or an anonymous
+// session: saved commutes first, then recent searches, then popular routes not already suggested.
+// With typed text, only suggestions mentioning it are returned.
+func (s *SearchSuggestionService) Suggestions(ctx context.Context, userID, sessionID uuid.UUID, req models.SearchSuggestionsRequest) ([]models.SearchSuggestion, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid suggestion parameters: %w", err)
+	}
+	typed := ""
+	if req.Query != nil {
+		typed = strings.TrimSpace(*req.Query)
+	}
+
+	suggestions := []models.SearchSuggestion{}
+	suggestedRoutes := make(map[string]bool)
+	add := func(candidates []models.SearchSuggestion) {
+		added := 0
+		for _, suggestion := range candidates {
+			if added == searchSuggestionsPerKind || !suggestionMatches(suggestion, typed) {
+				continue
+			}
+			route := travelMatrixKey(derefString(suggestion.DepartureLocationName), derefString(suggestion.ArrivalLocationName))
+			if suggestion.Kind == models.SearchSuggestionPopular && suggestedRoutes[route] {
+				continue
+			}
+			suggestedRoutes[route] = true
+			suggestions = append(suggestions, suggestion)
+			added++
+		}
+	}
+
+	if userID != uuid.Nil {
+		commutes, err := s.ListCommutes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]models.SearchSuggestion, 0, len(commutes))
+		for _, commute := range commutes {
+			candidates = append(candidates, commuteSuggestion(commute))
+		}
+		add(candidates)
+	}
+
+	recents, err := s.recentSuggestions(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	add(recents)
+
+	popular, err := s.popularSuggestions(ctx, typed)
+	if err != nil {
+		return nil, err
+	}
+	add(popular)
+	return suggestions, nil
+}
+
+// recentSuggestions turns the recent searches of the user, or else of the anonymous session, into
+// suggestions. Searches without a location (e.g. by coordinates only) are skipped.
+func (s *SearchSuggestionService) recentSuggestions(ctx context.Context, userID, sessionID uuid.UUID) ([]models.SearchSuggestion, error) {
+	column, ownerID := "user_id", userID
+	if userID == uuid.Nil {
+		if sessionID == uuid.Nil {
+			return nil, nil
+		}
+		column, ownerID = "anonymous_session_id", sessionID
+	}
+	query := `SELECT query FROM recent_searches WHERE ` + column + ` = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := s.db.Query(ctx, query, ownerID, recentSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing recent searches: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []models.SearchSuggestion
+	for rows.Next() {
+		var rawQuery string
+		if err := rows.Scan(&rawQuery); err != nil {
+			return nil, fmt.Errorf("error processing recent search: %w", err)
+		}
+		if suggestion, ok := recentSearchSuggestion(rawQuery); ok {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for recent searches: %w", err)
+	}
+	return suggestions, nil
+}
+
+// popularSuggestions returns the routes with the most upcoming rides, optionally mentioning the typed text.
+func (s *SearchSuggestionService) popularSuggestions(ctx context.Context, typed string) ([]models.SearchSuggestion, error) {
+	query := `
+		SELECT mode() WITHIN GROUP (ORDER BY r.departure_location_name),
+		       mode() WITHIN GROUP (ORDER BY r.arrival_location_name),
+		       COUNT(*)
+		FROM rides r
+		WHERE ` + upcomingRideCondition + `
+		  AND (r.departure_location_name ILIKE $1 OR r.arrival_location_name ILIKE $1)
+		GROUP BY lower(trim(r.departure_location_name)), lower(trim(r.arrival_location_name))
+		ORDER BY COUNT(*) DESC
+		LIMIT $2
+	`
+	// Extra rows make up for popular routes dropped as duplicates of commutes or recent searches
+	rows, err := s.db.Query(ctx, query, "%"+typed+"%", 2*searchSuggestionsPerKind)
+	if err != nil {
+		log.Printf("Error listing popular routes: %v", err)
+		return nil, fmt.Errorf("database error listing popular routes: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []models.SearchSuggestion
+	for rows.Next() {
+		var departure, arrival string
+		var rideCount int
+		if err := rows.Scan(&departure, &arrival, &rideCount); err != nil {
+			return nil, fmt.Errorf("error processing popular route: %w", err)
+		}
+		suggestions = append(suggestions, models.SearchSuggestion{
+			Kind:                  models.SearchSuggestionPopular,
+			Label:                 suggestionLabel(departure, arrival),
+			Query:                 url.Values{"start_location": {departure}, "end_location": {arrival}}.Encode(),
+			DepartureLocationName: &departure,
+			ArrivalLocationName:   &arrival,
+			RideCount:             &rideCount,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for popular routes: %w", err)
+	}
+	return suggestions, nil
+}
+
+// recentSearchSuggestion builds the suggestion of a recent search query string. It reports false
+// when the search had neither a start nor an end location.
+func recentSearchSuggestion(rawQuery string) (models.SearchSuggestion, bool) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return models.SearchSuggestion{}, false
+	}
+	departure, arrival := strings.TrimSpace(values.Get("start_location")), strings.TrimSpace(values.Get("end_location"))
+	if departure == "" && arrival == "" {
+		return models.SearchSuggestion{}, false
+	}
+	suggestion := models.SearchSuggestion{
+		Kind:  models.SearchSuggestionRecent,
+		Label: suggestionLabel(departure, arrival),
+		Query: rawQuery,
+	}
+	if departure != "" {
+		suggestion.DepartureLocationName = &departure
+	}
+	if arrival != "" {
+		suggestion.ArrivalLocationName = &arrival
+	}
+	return suggestion, true
+}
+
+// commuteSuggestion builds the suggestion of a saved commute; its usual time becomes departure_after.
+func commuteSuggestion(commute models.SavedCommute) models.SearchSuggestion {
+	values := url.Values{"start_location": {commute.DepartureLocationName}, "end_location": {commute.ArrivalLocationName}}
+	if commute.DepartureTime != nil {
+		values.Set("departure_after", *commute.DepartureTime)
+	}
+	return models.SearchSuggestion{
+		Kind:                  models.SearchSuggestionCommute,
+		Label:                 commute.Label,
+		Query:                 values.Encode(),
+		DepartureLocationName: &commute.DepartureLocationName,
+		ArrivalLocationName:   &commute.ArrivalLocationName,
+	}
+}
+
+// suggestionLabel describes a route, e.g. "Lyon → Paris", "From Lyon" or "To Paris".
+func suggestionLabel(departure, arrival string) string {
+	switch {
+	case arrival == "":
+		return "From " + departure
+	case departure == "":
+		return "To " + arrival
+	default:
+		return departure + " → " + arrival
+	}
+}
+
+// suggestionMatches reports whether a suggestion mentions the typed text (case-insensitively).
+func suggestionMatches(suggestion models.SearchSuggestion, typed string) bool {
+	if typed == "" {
+		return true
+	}
+	typed = strings.ToLower(typed)
+	for _, text := range []string{suggestion.Label, derefString(suggestion.DepartureLocationName), derefString(suggestion.ArrivalLocationName)} {
+		if strings.Contains(strings.ToLower(text), typed) {
+			return true
+		}
+	}
+	return false
+}
+
+// derefString returns the string, or "" for nil.
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// ListCommutes returns the user's saved commutes, in the order they were saved.
+func (s *SearchSuggestionService) ListCommutes(ctx context.Context, userID uuid.UUID) ([]models.SavedCommute, error) {
+	query := `
+		SELECT id, label, departure_location_name, arrival_location_name, to_char(departure_time, 'HH24:MI'), created_at
+		FROM saved_commutes WHERE user_id = $1 ORDER BY created_at
+	`
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing commutes: %w", err)
+	}
+	defer rows.Close()
+
+	commutes := []models.SavedCommute{}
+	for rows.Next() {
+		var commute models.SavedCommute
+		if err := rows.Scan(&commute.ID, &commute.Label, &commute.DepartureLocationName, &commute.ArrivalLocationName, &commute.DepartureTime, &commute.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error processing commute: %w", err)
+		}
+		commutes = append(commutes, commute)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for commutes: %w", err)
+	}
+	return commutes, nil
+}
+
+// SaveCommute saves a route the user travels regularly.
+func (s *SearchSuggestionService) SaveCommute(ctx context.Context, userID uuid.UUID, req models.SaveCommuteRequest) (*models.SavedCommute, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid commute: %w", err)
+	}
+	commute := models.SavedCommute{
+		Label:                 strings.TrimSpace(req.Label),
+		DepartureLocationName: strings.TrimSpace(req.DepartureLocationName),
+		ArrivalLocationName:   strings.TrimSpace(req.ArrivalLocationName),
+		DepartureTime:         req.DepartureTime,
+	}
+	// The count check and insert are one statement, so concurrent saves can't exceed the limit by much
+	query := `
+		INSERT INTO saved_commutes (user_id, label, departure_location_name, arrival_location_name, departure_time)
+		SELECT $1, $2, $3, $4, $5::time
+		WHERE (SELECT COUNT(*) FROM saved_commutes WHERE user_id = $1) < $6
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRow(ctx, query, userID, commute.Label, commute.DepartureLocationName, commute.ArrivalLocationName, commute.DepartureTime, maxSavedCommutes).
+		Scan(&commute.ID, &commute.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+			return nil, ErrCommuteExists
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrTooManyCommutes
+		}
+		log.Printf("Error saving commute for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error saving commute: %w", err)
+	}
+	log.Printf("User %s saved commute %s", userID, commute.ID)
+	return &commute, nil
+}
+
+// DeleteCommute deletes one of the user's saved commutes.
+func (s *SearchSuggestionService) DeleteCommute(ctx context.Context, userID, commuteID uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM saved_commutes WHERE id = $1 AND user_id = $2`, commuteID, userID)
+	if err != nil {
+		return fmt.Errorf("database error deleting commute: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCommuteNotFound
+	}
+	return nil
+}
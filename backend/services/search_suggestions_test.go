@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"rideshare/backend/models"
+)
+
+// Test that recent searches become labelled suggestions, and searches without a location are skipped
+func TestRecentSearchSuggestion(t *testing.T) {
+	tests := []struct {
+		query string
+		label string
+		ok    bool
+	}{
+		{"end_location=Paris&start_location=Lyon", "Lyon → Paris", true},
+		{"departure_date=2025-06-01&start_location=Lyon", "From Lyon", true},
+		{"end_location=Paris", "To Paris", true},
+		{"departure_lat=45.76&departure_lon=4.84", "", false},
+	}
+	for _, tt := range tests {
+		suggestion, ok := recentSearchSuggestion(tt.query)
+		if ok != tt.ok || suggestion.Label != tt.label {
+			t.Errorf("recentSearchSuggestion(%q) = %q, %t, want %q, %t", tt.query, suggestion.Label, ok, tt.label, tt.ok)
+		}
+		if ok && suggestion.Query != tt.query {
+			t.Errorf("query = %q, want the recent search replayed as is", suggestion.Query)
+		}
+	}
+}
+
+// Test that a commute's usual time is suggested as the earliest departure, and typed text matches labels and locations
+func TestCommuteSuggestion(t *testing.T) {
+	eight := "08:00"
+	suggestion := commuteSuggestion(models.SavedCommute{Label: "Work", DepartureLocationName: "Lyon", ArrivalLocationName: "Villeurbanne", DepartureTime: &eight})
+
+	if suggestion.Kind != models.SearchSuggestionCommute || suggestion.Label != "Work" {
+		t.Errorf("suggestion = %+v, want a commute labelled Work", suggestion)
+	}
+	if want := "departure_after=08%3A00&end_location=Villeurbanne&start_location=Lyon"; suggestion.Query != want {
+		t.Errorf("query = %q, want %q", suggestion.Query, want)
+	}
+	for typed, want := range map[string]bool{"": true, "wo": true, "VILLEUR": true, "paris": false} {
+		if got := suggestionMatches(suggestion, typed); got != want {
+			t.Errorf("suggestionMatches(%q) = %t, want %t", typed, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For SQL conditions and error messages
+	"log"     // For logging
+	"strings" // For listing the supported regions
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// ServiceAreaService restricts rides to the regions the platform operates in: the service_areas
+// selected by SERVICE_AREAS (migration 051). A nil *ServiceAreaService allows every location.
+type ServiceAreaService struct {
+	db    database.DBPool
+	codes []string
+}
+
+// NewServiceAreaService creates a ServiceAreaService from cfg. Without SERVICE_AREAS, rides are not restricted.
+func NewServiceAreaService(cfg *config.Config, db database.DBPool) *ServiceAreaService {
+	if len(cfg.ServiceAreas) == 0 {
+		return nil
+	}
+	log.Printf("Rides restricted to service areas %s", strings.Join(cfg.ServiceAreas, ", "))
+	return &ServiceAreaService{db: db, codes: cfg.ServiceAreas}
+}
+
+// CheckRide returns a KindInvalid error naming the supported regions when the departure or the
+// arrival lies outside every service area.
+func (s *ServiceAreaService) CheckRide(ctx context.Context, departure, arrival models.GeoPoint) error {
+	if s == nil {
+		return nil
+	}
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM service_areas WHERE code = ANY($1) AND ST_Covers(boundary, ST_SetSRID(ST_MakePoint($2, $3), 4326))),
+			EXISTS (SELECT 1 FROM service_areas WHERE code = ANY($1) AND ST_Covers(boundary, ST_SetSRID(ST_MakePoint($4, $5), 4326)))
+	`
+	var departureCovered, arrivalCovered bool
+	err := s.db.QueryRow(ctx, query, s.codes, departure.Longitude, departure.Latitude, arrival.Longitude, arrival.Latitude).
+		Scan(&departureCovered, &arrivalCovered)
+	if err != nil {
+		return fmt.Errorf("database error checking service areas: %w", err)
+	}
+
+	var outside string
+	switch {
+	case !departureCovered && !arrivalCovered:
+		outside = "departure and arrival are"
+	case !departureCovered:
+		outside = "departure is"
+	case !arrivalCovered:
+		outside = "arrival is"
+	default:
+		return nil
+	}
+	return newError(KindInvalid, fmt.Sprintf("%s outside the regions we operate in (%s)", outside, strings.Join(s.codes, ", ")))
+}
+
+// SearchCondition returns the SQL condition keeping rides that depart from and arrive in a
+// service area, with its arguments starting at $argID. Queries must alias rides as 'r'.
+// It returns an empty condition when rides are not restricted.
+func (s *ServiceAreaService) SearchCondition(argID int) (string, []interface{}) {
+	if s == nil {
+		return "", nil
+	}
+	condition := fmt.Sprintf(`EXISTS (SELECT 1 FROM service_areas sa WHERE sa.code = ANY($%d) AND ST_Covers(sa.boundary, r.departure_coords))
+		  AND EXISTS (SELECT 1 FROM service_areas sa WHERE sa.code = ANY($%d) AND ST_Covers(sa.boundary, r.arrival_coords))`, argID, argID)
+	return condition, []interface{}{s.codes}
+}
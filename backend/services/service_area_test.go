@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that rides outside the configured service areas are rejected with the regions we operate in
+func TestServiceAreaService_CheckRide(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	areas := NewServiceAreaService(&config.Config{ServiceAreas: []string{"FR", "BE"}}, mock)
+	paris, london := models.GeoPoint{Longitude: 2.35, Latitude: 48.86}, models.GeoPoint{Longitude: -0.13, Latitude: 51.51}
+
+	mock.ExpectQuery("service_areas").
+		WithArgs([]string{"FR", "BE"}, paris.Longitude, paris.Latitude, london.Longitude, london.Latitude).
+		WillReturnRows(pgxmock.NewRows([]string{"departure", "arrival"}).AddRow(true, false))
+	err = areas.CheckRide(context.Background(), paris, london)
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind != KindInvalid {
+		t.Fatalf("CheckRide = %v, want a KindInvalid error", err)
+	}
+	if !strings.HasPrefix(serviceErr.Message, "arrival is outside") || !strings.Contains(serviceErr.Message, "FR, BE") {
+		t.Errorf("message = %q, want the arrival and the supported regions named", serviceErr.Message)
+	}
+
+	mock.ExpectQuery("service_areas").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"departure", "arrival"}).AddRow(true, true))
+	if err := areas.CheckRide(context.Background(), paris, paris); err != nil {
+		t.Errorf("CheckRide inside the service areas = %v, want nil", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test that rides are not restricted without SERVICE_AREAS
+func TestServiceAreaService_Unrestricted(t *testing.T) {
+	areas := NewServiceAreaService(&config.Config{}, nil)
+	if err := areas.CheckRide(context.Background(), models.GeoPoint{}, models.GeoPoint{}); err != nil {
+		t.Errorf("CheckRide = %v, want nil", err)
+	}
+	if condition, args := areas.SearchCondition(3); condition != "" || args != nil {
+		t.Errorf("SearchCondition = %q %v, want none", condition, args)
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"fmt"     // For metric formatting
+	"io"      // For writing the exposition
+	"sort"    // For stable output order
+	"strings" // For label formatting
+	"sync"    // For the counters
+	"time"    // For latencies and windows
+)
+
+// MetricsSource writes metrics in the Prometheus text exposition format.
+type MetricsSource interface {
+	WritePrometheus(w io.Writer)
+}
+
+// SLOTarget is the service level objective of a route group.
+type SLOTarget struct {
+	Group                 string        // Route group: auth, rides, payments, webhook
+	LatencyThreshold      time.Duration // A request slower than this counts against the latency SLO
+	LatencyObjective      float64       // Fraction of requests that must be faster than LatencyThreshold
+	AvailabilityObjective float64       // Fraction of requests that must not fail with a 5xx
+}
+
+// DefaultSLOTargets are the objectives per route group. Payments and the webhook get the
+// strictest availability target since a failure there loses money or bookings.
+var DefaultSLOTargets = []SLOTarget{
+	{Group: "auth", LatencyThreshold: 500 * time.Millisecond, LatencyObjective: 0.99, AvailabilityObjective: 0.999},
+	{Group: "rides", LatencyThreshold: 800 * time.Millisecond, LatencyObjective: 0.95, AvailabilityObjective: 0.995},
+	{Group: "payments", LatencyThreshold: 2 * time.Second, LatencyObjective: 0.99, AvailabilityObjective: 0.999},
+	{Group: "webhook", LatencyThreshold: 5 * time.Second, LatencyObjective: 0.99, AvailabilityObjective: 0.999},
+}
+
+// burnRateWindows are the windows burn rates are reported for; alert rules pair a short and a
+// long window (e.g. 5m and 1h) to catch fast burns without paging on blips.
+var burnRateWindows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour}
+
+// latencyBuckets are the upper bounds (seconds) of the request duration histogram.
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// sloMinute aggregates one minute of requests for burn-rate windows.
+type sloMinute struct {
+	minute int64 // Unix minute this slot holds; stale slots are ignored
+	total  int64
+	errors int64 // 5xx responses
+	slow   int64 // Slower than the latency threshold
+}
+
+// routeGroupStats holds the counters of one route group.
+type routeGroupStats struct {
+	target      *SLOTarget       // nil for groups without an SLO
+	byClass     map[string]int64 // Request count by status class ("2xx", "5xx", ...)
+	buckets     []int64          // Histogram counts, one per latencyBuckets entry
+	durationSum float64          // Seconds
+	count       int64
+	errors      int64
+	slow        int64
+	minutes     []sloMinute // Ring buffer covering the longest burn-rate window
+}
+
+// SLOTracker records request outcomes per route group and exposes them, with SLO burn rates,
+// in the Prometheus text format.
+type SLOTracker struct {
+	mu      sync.Mutex
+	targets map[string]*SLOTarget
+	groups  map[string]*routeGroupStats
+	now     func() time.Time // Replaced in tests
+}
+
+// NewSLOTracker creates a tracker for the given objectives.
+func NewSLOTracker(targets []SLOTarget) *SLOTracker {
+	tracker := &SLOTracker{
+		targets: make(map[string]*SLOTarget),
+		groups:  make(map[string]*routeGroupStats),
+		now:     time.Now,
+	}
+	for i := range targets {
+		tracker.targets[targets[i].Group] = &targets[i]
+		tracker.groupStats(targets[i].Group) // Report every SLO group, even before traffic
+	}
+	return tracker
+}
+
+// groupStats returns the stats of a group, creating them on first use. Callers hold mu.
+func (t *SLOTracker) groupStats(group string) *routeGroupStats {
+	stats, ok := t.groups[group]
+	if !ok {
+		longest := burnRateWindows[len(burnRateWindows)-1]
+		stats = &routeGroupStats{
+			target:  t.targets[group],
+			byClass: make(map[string]int64),
+			buckets: make([]int64, len(latencyBuckets)),
+			minutes: make([]sloMinute, int(longest/time.Minute)),
+		}
+		t.groups[group] = stats
+	}
+	return stats
+}
+
+// Record adds one finished request to its route group.
+func (t *SLOTracker) Record(group string, statusCode int, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.groupStats(group)
+	seconds := duration.Seconds()
+	stats.byClass[fmt.Sprintf("%dxx", statusCode/100)]++
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+	stats.durationSum += seconds
+	stats.count++
+
+	failed := statusCode >= 500
+	slow := stats.target != nil && duration > stats.target.LatencyThreshold
+	minute := t.now().Unix() / 60
+	slot := &stats.minutes[minute%int64(len(stats.minutes))]
+	if slot.minute != minute {
+		*slot = sloMinute{minute: minute}
+	}
+	slot.total++
+	if failed {
+		stats.errors++
+		slot.errors++
+	}
+	if slow {
+		stats.slow++
+		slot.slow++
+	}
+}
+
+// BurnRate returns how fast a group consumes its error budget over the window: 1 means the
+// budget would be exactly used up over the SLO period, above 1 means it runs out early.
+// slo is "availability" or "latency". Returns 0 for groups without a target or traffic.
+func (t *SLOTracker) BurnRate(group, slo string, window time.Duration) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats, ok := t.groups[group]
+	if !ok {
+		return 0
+	}
+	return t.burnRate(stats, slo, window)
+}
+
+// burnRate implements BurnRate. Callers hold mu.
+func (t *SLOTracker) burnRate(stats *routeGroupStats, slo string, window time.Duration) float64 {
+	if stats.target == nil {
+		return 0
+	}
+	current := t.now().Unix() / 60
+	oldest := current - int64(window/time.Minute) + 1
+	var total, bad int64
+	for _, slot := range stats.minutes {
+		if slot.minute < oldest || slot.minute > current {
+			continue
+		}
+		total += slot.total
+		if slo == "latency" {
+			bad += slot.slow
+		} else {
+			bad += slot.errors
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return (float64(bad) / float64(total)) / (1 - objective(stats.target, slo))
+}
+
+// objective returns the target fraction of good requests for an SLO.
+func objective(target *SLOTarget, slo string) float64 {
+	if slo == "latency" {
+		return target.LatencyObjective
+	}
+	return target.AvailabilityObjective
+}
+
+// WritePrometheus writes request counters, latency histograms, SLO targets, burn rates and
+// remaining error budget (since process start) in the Prometheus text exposition format.
+func (t *SLOTracker) WritePrometheus(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	groups := make([]string, 0, len(t.groups))
+	for group := range t.groups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	fmt.Fprintln(w, "# HELP rideshare_http_requests_total HTTP requests by route group and status class.")
+	fmt.Fprintln(w, "# TYPE rideshare_http_requests_total counter")
+	for _, group := range groups {
+		stats := t.groups[group]
+		classes := make([]string, 0, len(stats.byClass))
+		for class := range stats.byClass {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(w, "rideshare_http_requests_total%s %d\n", labels("group", group, "code", class), stats.byClass[class])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP rideshare_http_request_duration_seconds HTTP request latency by route group.")
+	fmt.Fprintln(w, "# TYPE rideshare_http_request_duration_seconds histogram")
+	for _, group := range groups {
+		stats := t.groups[group]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "rideshare_http_request_duration_seconds_bucket%s %d\n", labels("group", group, "le", fmt.Sprint(bound)), stats.buckets[i])
+		}
+		fmt.Fprintf(w, "rideshare_http_request_duration_seconds_bucket%s %d\n", labels("group", group, "le", "+Inf"), stats.count)
+		fmt.Fprintf(w, "rideshare_http_request_duration_seconds_sum%s %g\n", labels("group", group), stats.durationSum)
+		fmt.Fprintf(w, "rideshare_http_request_duration_seconds_count%s %d\n", labels("group", group), stats.count)
+	}
+
+	sloGroups := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if t.groups[group].target != nil {
+			sloGroups = append(sloGroups, group)
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP rideshare_slo_objective Target fraction of good requests.")
+	fmt.Fprintln(w, "# TYPE rideshare_slo_objective gauge")
+	for _, group := range sloGroups {
+		for _, slo := range []string{"availability", "latency"} {
+			fmt.Fprintf(w, "rideshare_slo_objective%s %g\n", labels("group", group, "slo", slo), objective(t.groups[group].target, slo))
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP rideshare_slo_latency_threshold_seconds Requests slower than this count against the latency SLO.")
+	fmt.Fprintln(w, "# TYPE rideshare_slo_latency_threshold_seconds gauge")
+	for _, group := range sloGroups {
+		fmt.Fprintf(w, "rideshare_slo_latency_threshold_seconds%s %g\n", labels("group", group), t.groups[group].target.LatencyThreshold.Seconds())
+	}
+
+	fmt.Fprintln(w, "# HELP rideshare_slo_burn_rate Error budget burn rate over the window (1 = budget used exactly over the SLO period).")
+	fmt.Fprintln(w, "# TYPE rideshare_slo_burn_rate gauge")
+	for _, group := range sloGroups {
+		for _, slo := range []string{"availability", "latency"} {
+			for _, window := range burnRateWindows {
+				fmt.Fprintf(w, "rideshare_slo_burn_rate%s %g\n", labels("group", group, "slo", slo, "window", formatWindow(window)), t.burnRate(t.groups[group], slo, window))
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP rideshare_slo_error_budget_remaining Fraction of the error budget left since process start (negative when overspent).")
+	fmt.Fprintln(w, "# TYPE rideshare_slo_error_budget_remaining gauge")
+	for _, group := range sloGroups {
+		stats := t.groups[group]
+		for _, slo := range []string{"availability", "latency"} {
+			remaining := 1.0
+			if stats.count > 0 {
+				bad := stats.errors
+				if slo == "latency" {
+					bad = stats.slow
+				}
+				remaining = 1 - (float64(bad)/float64(stats.count))/(1-objective(stats.target, slo))
+			}
+			fmt.Fprintf(w, "rideshare_slo_error_budget_remaining%s %g\n", labels("group", group, "slo", slo), remaining)
+		}
+	}
+}
+
+// labels formats name/value pairs as a Prometheus label set.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", pairs[i], pairs[i+1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatWindow renders a window the way Prometheus durations are written (5m, 1h, 6h).
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+	return fmt.Sprintf("%dm", window/time.Minute)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test that burn rates are computed per group and window from errors and slow requests
+func TestSLOTracker_BurnRate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewSLOTracker([]SLOTarget{
+		{Group: "payments", LatencyThreshold: time.Second, LatencyObjective: 0.9, AvailabilityObjective: 0.99},
+	})
+	tracker.now = func() time.Time { return now }
+
+	// Two hours ago: 10 failures, outside the 5m and 1h windows
+	now = now.Add(-2 * time.Hour)
+	for i := 0; i < 10; i++ {
+		tracker.Record("payments", 500, 10*time.Millisecond)
+	}
+	now = now.Add(2 * time.Hour)
+
+	// Now: 100 requests, 2 failures and 20 slow
+	for i := 0; i < 100; i++ {
+		status, duration := 200, 50*time.Millisecond
+		if i < 2 {
+			status = 502
+		}
+		if i >= 80 {
+			duration = 2 * time.Second
+		}
+		tracker.Record("payments", status, duration)
+	}
+
+	tests := []struct {
+		slo    string
+		window time.Duration
+		want   float64
+	}{
+		{"availability", 5 * time.Minute, 2},                 // 2% errors / 1% budget
+		{"latency", 5 * time.Minute, 2},                      // 20% slow / 10% budget
+		{"availability", 6 * time.Hour, (12.0 / 110) / 0.01}, // Includes the old failures
+	}
+	for _, tt := range tests {
+		if got := tracker.BurnRate("payments", tt.slo, tt.window); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("BurnRate(%s, %s) = %v, want %v", tt.slo, tt.window, got, tt.want)
+		}
+	}
+	if got := tracker.BurnRate("rides", "availability", time.Hour); got != 0 {
+		t.Errorf("BurnRate for a group without traffic = %v, want 0", got)
+	}
+}
+
+// Test that the exposition contains the counters, histogram and SLO gauges
+func TestSLOTracker_WritePrometheus(t *testing.T) {
+	tracker := NewSLOTracker(DefaultSLOTargets)
+	tracker.Record("rides", 200, 100*time.Millisecond)
+	tracker.Record("other", 404, time.Millisecond)
+
+	var out bytes.Buffer
+	tracker.WritePrometheus(&out)
+	for _, want := range []string{
+		`rideshare_http_requests_total{group="rides",code="2xx"} 1`,
+		`rideshare_http_requests_total{group="other",code="4xx"} 1`,
+		`rideshare_http_request_duration_seconds_bucket{group="rides",le="0.1"} 1`,
+		`rideshare_slo_objective{group="payments",slo="availability"} 0.999`,
+		`rideshare_slo_burn_rate{group="rides",slo="latency",window="5m"} 0`,
+		`rideshare_slo_error_budget_remaining{group="webhook",slo="availability"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+	if strings.Contains(out.String(), `rideshare_slo_objective{group="other"`) {
+		t.Error("metrics output has SLO gauges for a group without a target")
+	}
+}
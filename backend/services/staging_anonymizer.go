@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Names given to staging users, picked at random per user.
+var (
+	stagingFirstNames = []string{"Alice", "Bruno", "Camille", "David", "Emma", "Farid", "Gabrielle", "Hugo", "Inès", "Jules", "Karima", "Louis", "Manon", "Nathan", "Océane", "Paul", "Rose", "Samuel", "Théo", "Yasmine"}
+	stagingLastNames  = []string{"Bernard", "Dubois", "Durand", "Fournier", "Garcia", "Girard", "Lambert", "Laurent", "Lefebvre", "Leroy", "Martin", "Mercier", "Michel", "Moreau", "Petit", "Richard", "Robert", "Roux", "Simon", "Thomas"}
+)
+
+// stagingUsersQuery replaces the personal data of every user. Emails are hashed, so they stay unique
+// and a production account can still be found by hashing its email; phone numbers are numbered in
+// the fictional +1 555 range so they stay unique. Numbers and birth dates are left in plaintext, to
+// be encrypted with the staging keys by PIIEncryptionJob; values encrypted with the production keys
+// couldn't be read in staging anyway.
+const stagingUsersQuery = `
+	UPDATE users u SET
+		email = 'user-' || left(encode(sha256(convert_to(u.email, 'UTF8')), 'hex'), 16) || '@staging.invalid',
+		password_hash = $1,
+		first_name = CASE WHEN u.first_name IS NULL THEN NULL ELSE ($2::text[])[1 + floor(random() * cardinality($2::text[]))::int] END,
+		last_name = CASE WHEN u.last_name IS NULL THEN NULL ELSE ($3::text[])[1 + floor(random() * cardinality($3::text[]))::int] END,
+		whatsapp = CASE WHEN u.whatsapp IS NULL AND u.whatsapp_encrypted IS NULL THEN NULL ELSE '+1555' || lpad(seq.n::text, 7, '0') END,
+		whatsapp_encrypted = NULL, whatsapp_hash = NULL,
+		birth_date = CASE WHEN u.birth_date IS NULL AND u.birth_date_encrypted IS NULL THEN NULL
+			ELSE (CURRENT_DATE - make_interval(years => 18 + floor(random() * 50)::int, days => floor(random() * 365)::int))::date END,
+		birth_date_encrypted = NULL,
+		last_known_location = NULL, last_known_location_encrypted = NULL, last_known_geohash = NULL,
+		stripe_customer_id = NULL, stripe_default_payment_method_id = NULL,
+		expo_push_token = NULL, push_token_registered_at = NULL
+	FROM (SELECT id, row_number() OVER (ORDER BY id) AS n FROM users) seq
+	WHERE u.id = seq.id
+`
+
+// stagingScrubQueries replace or remove the personal data and secrets outside users: Stripe IDs,
+// push tokens, IP addresses, free text written by users and API key hashes (so production keys
+// don't work against staging). Rows are kept wherever they can be, so volumes stay realistic.
+var stagingScrubQueries = []string{
+	`UPDATE payments SET stripe_payment_intent_id = 'pi_staging_' || replace(id::text, '-', '')`,
+	`UPDATE payment_refunds SET stripe_refund_id = 're_staging_' || replace(id::text, '-', '') WHERE stripe_refund_id IS NOT NULL`,
+	`UPDATE auto_join_intents SET
+		stripe_customer_id = 'cus_staging_' || replace(user_id::text, '-', ''),
+		stripe_payment_method_id = 'pm_staging_' || replace(id::text, '-', ''),
+		stripe_payment_intent_id = CASE WHEN stripe_payment_intent_id IS NULL THEN NULL ELSE 'pi_staging_' || replace(id::text, '-', '') END,
+		last_error = NULL`,
+	`UPDATE finance_exports SET content = NULL, last_error = NULL`,
+	`DELETE FROM push_tickets`,
+	`DELETE FROM profile_changes`,
+	`UPDATE notifications SET title = initcap(replace(event_type, '_', ' ')), body = 'Staging notification'`,
+	`UPDATE vehicles SET license_plate = 'STG-' || upper(left(md5(id::text), 6))`,
+	`UPDATE ride_reviews SET comment = NULL WHERE comment IS NOT NULL`,
+	`UPDATE moderation_flags SET content = '', resolution_note = NULL`,
+	`UPDATE fraud_events SET ip_address = NULL, payment_method_id = NULL, latitude = NULL, longitude = NULL`,
+	`UPDATE fraud_flags SET ip_address = NULL, resolution_note = NULL`,
+	`UPDATE audit_logs SET ip_address = NULL, metadata = '{}'::jsonb`,
+	`UPDATE user_document_acceptances SET ip_address = NULL`,
+	`UPDATE user_consents SET ip_address = NULL`,
+	`UPDATE api_keys SET key_hash = md5(random()::text || id::text)`,
+}
+
+// AnonymizeStagingData replaces the personal data of a database cloned from production, within
+// the caller's transaction, so the clone is never committed with real data. Every user gets the
+// password hashed as passwordHash, so testers can sign in as anyone.
+func AnonymizeStagingData(ctx context.Context, tx pgx.Tx, passwordHash string) error {
+	tag, err := tx.Exec(ctx, stagingUsersQuery, passwordHash, stagingFirstNames, stagingLastNames)
+	if err != nil {
+		return fmt.Errorf("database error anonymizing users: %w", err)
+	}
+	log.Printf("Anonymized %d users", tag.RowsAffected())
+	var scrubbed int64
+	for _, query := range stagingScrubQueries {
+		tag, err := tx.Exec(ctx, query)
+		if err != nil {
+			return fmt.Errorf("database error scrubbing staging data: %w", err)
+		}
+		scrubbed += tag.RowsAffected()
+	}
+	log.Printf("Scrubbed %d rows of personal data outside users", scrubbed)
+	return nil
+}
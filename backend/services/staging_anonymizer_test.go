@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+// Test that users are anonymized with the staging password before every other table is scrubbed
+func TestAnonymizeStagingData(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users u SET").WithArgs("staging-hash", stagingFirstNames, stagingLastNames).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	for range stagingScrubQueries {
+		mock.ExpectExec(".").WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	}
+	tx, err := mock.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := AnonymizeStagingData(context.Background(), tx, "staging-hash"); err != nil {
+		t.Fatalf("AnonymizeStagingData() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %v", err)
+	}
+}
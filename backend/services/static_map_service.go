@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"  // For request context
+	"fmt"      // For error formatting and URLs
+	"io"       // For reading images
+	"log"      // For logging
+	"net/http" // For provider HTTP calls
+	"net/url"  // For query encoding
+	"strings"  // For string handling
+	"sync"     // For the image cache
+	"time"     // For cache expiry
+
+	"github.com/google/uuid"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Static map image size (rendered at 2x for high-density screens where the provider supports it).
+const (
+	staticMapWidth    = 600
+	staticMapHeight   = 300
+	maxMapImageSize   = 2 << 20 // Upper bound on a provider image, in bytes
+	maxMapRoutePoints = 100     // Routes are thinned to keep provider URLs short
+)
+
+// StaticMapProvider builds the image URL of a map showing a ride's route. The URL contains the
+// provider API key, so it must never be sent to clients.
+type StaticMapProvider interface {
+	ImageURL(departure, arrival models.GeoPoint, route []models.GeoPoint) string
+}
+
+// mapImageEntry is a cached map image.
+type mapImageEntry struct {
+	image     []byte
+	expiresAt time.Time
+}
+
+// StaticMapService renders ride thumbnails through a static map provider and caches them in memory.
+type StaticMapService struct {
+	cfg         *config.Config
+	rideService *RideService
+	provider    StaticMapProvider // nil when no provider is configured
+	httpClient  *http.Client
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]mapImageEntry
+}
+
+// NewStaticMapService creates a StaticMapService for cfg.StaticMapProvider ("mapbox" or "geoapify").
+func NewStaticMapService(cfg *config.Config, rideService *RideService) *StaticMapService {
+	var provider StaticMapProvider
+	switch strings.ToLower(cfg.StaticMapProvider) {
+	case "mapbox":
+		provider = &mapboxStaticProvider{token: cfg.StaticMapAPIKey}
+	case "geoapify":
+		provider = &geoapifyStaticProvider{apiKey: cfg.StaticMapAPIKey}
+	case "":
+		log.Println("Static ride maps disabled (STATIC_MAP_PROVIDER not set)")
+	default:
+		log.Printf("Warning: Unknown STATIC_MAP_PROVIDER '%s', static ride maps disabled", cfg.StaticMapProvider)
+	}
+	return &StaticMapService{
+		cfg:         cfg,
+		rideService: rideService,
+		provider:    provider,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		cache:       make(map[uuid.UUID]mapImageEntry),
+	}
+}
+
+// Enabled reports whether a map provider is configured.
+func (s *StaticMapService) Enabled() bool {
+	return s.provider != nil
+}
+
+// RideMapImage returns the PNG thumbnail of a ride's route, from the cache if possible.
+func (s *StaticMapService) RideMapImage(ctx context.Context, rideID uuid.UUID) ([]byte, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[rideID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.image, nil
+	}
+
+	ride, err := s.rideService.GetRideDetails(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.DepartureCoords == nil || ride.ArrivalCoords == nil {
+		return nil, newError(KindNotFound, "ride has no coordinates to draw")
+	}
+	route := []models.GeoPoint{*ride.DepartureCoords, *ride.ArrivalCoords} // Straight line if no route is stored
+	if ride.RoutePolyline != nil {
+		if points, err := DecodePolyline(*ride.RoutePolyline); err == nil && len(points) >= 2 {
+			route = simplifyRoute(points, maxMapRoutePoints)
+		}
+	}
+
+	image, err := s.fetchImage(ctx, s.provider.ImageURL(*ride.DepartureCoords, *ride.ArrivalCoords, route))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render map for ride %s: %w", rideID, err)
+	}
+
+	s.mu.Lock()
+	s.cache[rideID] = mapImageEntry{image: image, expiresAt: time.Now().Add(s.cfg.StaticMapCacheTTL)}
+	if len(s.cache) > 5000 {
+		s.evictExpiredLocked()
+	}
+	s.mu.Unlock()
+	return image, nil
+}
+
+// fetchImage downloads a PNG from the provider.
+func (s *StaticMapService) fetchImage(ctx context.Context, imageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/png")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		// The URL carries the API key; only report the error kind
+		return nil, fmt.Errorf("map provider request failed: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("map provider returned HTTP %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("map provider returned %s instead of an image", contentType)
+	}
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxMapImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map image: %w", err)
+	}
+	if len(image) > maxMapImageSize {
+		return nil, fmt.Errorf("map image larger than %d bytes", maxMapImageSize)
+	}
+	return image, nil
+}
+
+// simplifyRoute keeps at most maxPoints evenly spaced points, always including both ends.
+func simplifyRoute(points []models.GeoPoint, maxPoints int) []models.GeoPoint {
+	if len(points) <= maxPoints {
+		return points
+	}
+	simplified := make([]models.GeoPoint, 0, maxPoints)
+	step := float64(len(points)-1) / float64(maxPoints-1)
+	for i := 0; i < maxPoints; i++ {
+		simplified = append(simplified, points[int(float64(i)*step+0.5)])
+	}
+	return simplified
+}
+
+// unwrapURLError drops the request URL (and the API key in it) from HTTP client errors.
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// evictExpiredLocked drops expired images, or everything if none expired. Caller holds s.mu.
+func (s *StaticMapService) evictExpiredLocked() {
+	now := time.Now()
+	evicted := 0
+	for rideID, entry := range s.cache {
+		if now.After(entry.expiresAt) {
+			delete(s.cache, rideID)
+			evicted++
+		}
+	}
+	if evicted == 0 {
+		s.cache = make(map[uuid.UUID]mapImageEntry)
+	}
+}
+
+// HandleRideEvent drops the cached map of a ride whose route data changed.
+func (s *StaticMapService) HandleRideEvent(event RideEvent) {
+	if event.Type != RideEventUpdated {
+		return
+	}
+	s.mu.Lock()
+	delete(s.cache, event.RideID)
+	s.mu.Unlock()
+}
+
+// mapboxStaticProvider uses the Mapbox Static Images API.
+type mapboxStaticProvider struct {
+	token string
+}
+
+func (p *mapboxStaticProvider) ImageURL(departure, arrival models.GeoPoint, route []models.GeoPoint) string {
+	overlays := []string{
+		fmt.Sprintf("path-4+3b82f6-0.8(%s)", url.PathEscape(EncodePolyline(route))),
+		fmt.Sprintf("pin-s-a+22c55e(%f,%f)", departure.Longitude, departure.Latitude),
+		fmt.Sprintf("pin-s-b+ef4444(%f,%f)", arrival.Longitude, arrival.Latitude),
+	}
+	return fmt.Sprintf("https://api.mapbox.com/styles/v1/mapbox/streets-v12/static/%s/auto/%dx%d@2x?access_token=%s",
+		strings.Join(overlays, ","), staticMapWidth, staticMapHeight, url.QueryEscape(p.token))
+}
+
+// geoapifyStaticProvider uses the Geoapify Static Maps API.
+type geoapifyStaticProvider struct {
+	apiKey string
+}
+
+func (p *geoapifyStaticProvider) ImageURL(departure, arrival models.GeoPoint, route []models.GeoPoint) string {
+	coords := make([]string, 0, len(route))
+	for _, point := range route {
+		coords = append(coords, fmt.Sprintf("%f,%f", point.Longitude, point.Latitude))
+	}
+	query := url.Values{}
+	query.Set("style", "osm-bright")
+	query.Set("width", fmt.Sprint(staticMapWidth))
+	query.Set("height", fmt.Sprint(staticMapHeight))
+	query.Set("scaleFactor", "2")
+	query.Set("format", "png")
+	query.Set("geometry", "polyline:"+strings.Join(coords, ",")+";linecolor:#3b82f6;linewidth:4")
+	query.Set("marker", fmt.Sprintf("lonlat:%f,%f;color:#22c55e;text:A|lonlat:%f,%f;color:#ef4444;text:B",
+		departure.Longitude, departure.Latitude, arrival.Longitude, arrival.Latitude))
+	query.Set("apiKey", p.apiKey)
+	return "https://maps.geoapify.com/v1/staticmap?" + query.Encode()
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/balance"
+	"github.com/stripe/stripe-go/v72/customer"
+	"github.com/stripe/stripe-go/v72/paymentintent"
+	"github.com/stripe/stripe-go/v72/refund"
+	"github.com/stripe/stripe-go/v72/setupintent"
+	"github.com/stripe/stripe-go/v72/webhook"
+)
+
+// StripeServiceImpl implements StripeService using the global stripe-go client.
+// stripe.Key must be set before any call (done in main.go).
+type StripeServiceImpl struct{}
+
+// NewStripeServiceImpl creates a new StripeServiceImpl instance.
+func NewStripeServiceImpl() *StripeServiceImpl {
+	return &StripeServiceImpl{}
+}
+
+// CreateCustomer creates a Stripe Customer.
+func (s *StripeServiceImpl) CreateCustomer(ctx context.Context, params *stripe.CustomerParams) (*stripe.Customer, error) {
+	params.Context = ctx
+	return customer.New(params)
+}
+
+// DeleteCustomer deletes a Stripe Customer, detaching its saved payment methods.
+func (s *StripeServiceImpl) DeleteCustomer(ctx context.Context, customerID string) error {
+	params := &stripe.CustomerParams{}
+	params.Context = ctx
+	_, err := customer.Del(customerID, params)
+	return err
+}
+
+// CreateSetupIntent creates a Stripe SetupIntent for saving a payment method.
+func (s *StripeServiceImpl) CreateSetupIntent(ctx context.Context, params *stripe.SetupIntentParams) (*stripe.SetupIntent, error) {
+	params.Context = ctx
+	return setupintent.New(params)
+}
+
+// CreatePaymentIntent creates a Stripe PaymentIntent to be confirmed by the client.
+func (s *StripeServiceImpl) CreatePaymentIntent(ctx context.Context, params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
+	params.Context = ctx
+	return paymentintent.New(params)
+}
+
+// CreateAndConfirmPaymentIntent creates a PaymentIntent; params are expected to set Confirm (and OffSession for saved cards).
+func (s *StripeServiceImpl) CreateAndConfirmPaymentIntent(ctx context.Context, params *stripe.PaymentIntentParams) (*stripe.PaymentIntent, error) {
+	params.Context = ctx
+	return paymentintent.New(params)
+}
+
+// CreateRefund refunds all or part of a PaymentIntent.
+func (s *StripeServiceImpl) CreateRefund(ctx context.Context, params *stripe.RefundParams) (*stripe.Refund, error) {
+	params.Context = ctx
+	return refund.New(params)
+}
+
+// ConstructWebhookEvent verifies the webhook signature and parses the event.
+func (s *StripeServiceImpl) ConstructWebhookEvent(payload []byte, signatureHeader string, secret string) (stripe.Event, error) {
+	return webhook.ConstructEvent(payload, signatureHeader, secret)
+}
+
+// VerifyCredentials checks that stripe.Key is accepted by Stripe with a cheap authenticated call.
+func (s *StripeServiceImpl) VerifyCredentials(ctx context.Context) error {
+	params := &stripe.BalanceParams{}
+	params.Context = ctx
+	_, err := balance.Get(params)
+	return err
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context" // For database calls
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"time"    // For sync cursors
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+const (
+	// syncBatchLimit bounds each entity list of a sync; the app syncs again while has_more is set.
+	syncBatchLimit = 200
+	// syncOverlap is subtracted from the cursor, so a change committed by a transaction that
+	// started before the previous sync ended is still picked up. Entities are upserted by ID, so
+	// sending one twice is harmless.
+	syncOverlap = 30 * time.Second
+)
+
+// SyncService returns everything that changed for a user since a cursor: their rides,
+// participations, payments and notifications.
+type SyncService struct {
+	db    database.DBPool
+	rides *RideService // Ride scanning and seat prices, as in ride listings
+}
+
+// NewSyncService creates a new SyncService instance.
+func NewSyncService(db database.DBPool, rides *RideService) *SyncService {
+	return &SyncService{
+		db:    db,
+		rides: rides,
+	}
+}
+
+// Changes returns the user's entities changed since params.Since, oldest change first, or all of
+// them (up to syncBatchLimit each) on a first sync. The lists are read from one snapshot, so they
+// agree with each other and with SyncedAt.
+func (s *SyncService) Changes(ctx context.Context, userID uuid.UUID, params models.SyncRequest) (*models.SyncResponse, error) {
+	since := time.Time{}
+	if params.Since != nil {
+		cursor, err := time.Parse(time.RFC3339, *params.Since)
+		if err != nil {
+			return nil, &Error{Kind: KindInvalid, Message: "since must be an RFC 3339 timestamp", Err: err}
+		}
+		since = cursor.Add(-syncOverlap)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		return nil, fmt.Errorf("database error starting sync: %w", err)
+	}
+
+	result := &models.SyncResponse{}
+	if err := tx.QueryRow(ctx, `SELECT NOW()`).Scan(&result.SyncedAt); err != nil {
+		return nil, fmt.Errorf("database error starting sync: %w", err)
+	}
+	// A cut-short list moves the cursor back to its last change, so the next sync resumes there
+	var truncatedAt []time.Time
+	var lastRideChange time.Time
+	if result.Rides, lastRideChange, err = s.changedRides(ctx, tx, userID, since); err != nil {
+		return nil, err
+	}
+	if len(result.Rides) == syncBatchLimit {
+		truncatedAt = append(truncatedAt, lastRideChange)
+	}
+	if result.Participations, err = s.changedParticipations(ctx, tx, userID, since); err != nil {
+		return nil, err
+	}
+	if len(result.Participations) == syncBatchLimit {
+		truncatedAt = append(truncatedAt, result.Participations[len(result.Participations)-1].UpdatedAt)
+	}
+	if result.Payments, err = s.changedPayments(ctx, tx, userID, since); err != nil {
+		return nil, err
+	}
+	if len(result.Payments) == syncBatchLimit {
+		truncatedAt = append(truncatedAt, result.Payments[len(result.Payments)-1].UpdatedAt)
+	}
+	var lastNotificationChange time.Time
+	if result.Notifications, lastNotificationChange, err = s.changedNotifications(ctx, tx, userID, since); err != nil {
+		return nil, err
+	}
+	if len(result.Notifications) == syncBatchLimit {
+		truncatedAt = append(truncatedAt, lastNotificationChange)
+	}
+	for _, at := range truncatedAt {
+		result.HasMore = true
+		if at.Before(result.SyncedAt) {
+			result.SyncedAt = at
+		}
+	}
+
+	log.Printf("Sync for user %s since %s: %d rides, %d participations, %d payments, %d notifications (more: %t)",
+		userID, since.Format(time.RFC3339), len(result.Rides), len(result.Participations), len(result.Payments), len(result.Notifications), result.HasMore)
+	return result, nil
+}
+
+// changedRides returns the rides the user created or booked that changed since, including the
+// ones whose seats changed through another participant, with the time of the last change listed.
+func (s *SyncService) changedRides(ctx context.Context, tx pgx.Tx, userID uuid.UUID, since time.Time) ([]models.Ride, time.Time, error) {
+	query := `
+		SELECT` + rideSelectColumns + `, changes.changed_at
+		FROM rides r
+		JOIN users u ON r.user_id = u.id
+		CROSS JOIN LATERAL (
+			SELECT GREATEST(r.updated_at, MAX(p2.updated_at)) AS changed_at FROM participants p2 WHERE p2.ride_id = r.id
+		) changes
+		WHERE (r.user_id = $1 OR EXISTS (SELECT 1 FROM participants mine WHERE mine.ride_id = r.id AND mine.user_id = $1))
+		  AND changes.changed_at > $2
+		ORDER BY changes.changed_at, r.id
+		LIMIT $3
+	`
+	rows, err := tx.Query(ctx, query, userID, since, syncBatchLimit)
+	if err != nil {
+		log.Printf("Error syncing rides of user %s: %v", userID, err)
+		return nil, time.Time{}, fmt.Errorf("database error syncing rides: %w", err)
+	}
+	defer rows.Close()
+	rides := []models.Ride{}
+	var lastChange time.Time
+	for rows.Next() {
+		ride, err := scanRideRow(trailingColumnsRow{Row: rows, dest: []any{&lastChange}})
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("error processing synced ride: %w", err)
+		}
+		s.rides.applySeatPrice(ride)
+		rides = append(rides, *ride)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("database iteration error for synced rides: %w", err)
+	}
+	return rides, lastChange, nil
+}
+
+// trailingColumnsRow scans the columns a query selects after the ones a shared scanner (e.g.
+// scanRideRow) expects into dest.
+type trailingColumnsRow struct {
+	pgx.Row
+	dest []any
+}
+
+func (r trailingColumnsRow) Scan(dest ...any) error {
+	return r.Row.Scan(append(dest, r.dest...)...)
+}
+
+// changedParticipations returns the user's participations changed since.
+func (s *SyncService) changedParticipations(ctx context.Context, tx pgx.Tx, userID uuid.UUID, since time.Time) ([]models.Participant, error) {
+	query := `
+		SELECT id, user_id, ride_id, status, created_at, updated_at, boarding_stop, alighting_stop, seat_held_until, seat_count
+		FROM participants
+		WHERE user_id = $1 AND updated_at > $2
+		ORDER BY updated_at, id
+		LIMIT $3
+	`
+	rows, err := tx.Query(ctx, query, userID, since, syncBatchLimit)
+	if err != nil {
+		log.Printf("Error syncing participations of user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error syncing participations: %w", err)
+	}
+	defer rows.Close()
+	participations := []models.Participant{}
+	for rows.Next() {
+		var p models.Participant
+		if err := rows.Scan(&p.ID, &p.UserID, &p.RideID, &p.Status, &p.CreatedAt, &p.UpdatedAt, &p.BoardingStop, &p.AlightingStop, &p.SeatHeldUntil, &p.SeatCount); err != nil {
+			return nil, fmt.Errorf("error processing synced participation: %w", err)
+		}
+		participations = append(participations, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for synced participations: %w", err)
+	}
+	return participations, nil
+}
+
+// changedPayments returns the user's payments changed since (e.g. succeeded or refunded).
+func (s *SyncService) changedPayments(ctx context.Context, tx pgx.Tx, userID uuid.UUID, since time.Time) ([]models.Payment, error) {
+	query := `
+		SELECT id, user_id, ride_id, participant_id, stripe_payment_intent_id, status::text, amount, currency::text, refunded_amount, created_at, updated_at
+		FROM payments
+		WHERE user_id = $1 AND updated_at > $2
+		ORDER BY updated_at, id
+		LIMIT $3
+	`
+	rows, err := tx.Query(ctx, query, userID, since, syncBatchLimit)
+	if err != nil {
+		log.Printf("Error syncing payments of user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error syncing payments: %w", err)
+	}
+	defer rows.Close()
+	payments := []models.Payment{}
+	for rows.Next() {
+		var pm models.Payment
+		if err := rows.Scan(&pm.ID, &pm.UserID, &pm.RideID, &pm.ParticipantID, &pm.StripePaymentIntentID, &pm.Status, &pm.Amount, &pm.Currency, &pm.RefundedAmount, &pm.CreatedAt, &pm.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error processing synced payment: %w", err)
+		}
+		payments = append(payments, pm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for synced payments: %w", err)
+	}
+	return payments, nil
+}
+
+// changedNotifications returns the user's notifications received or read since, with the time of
+// the last change listed.
+func (s *SyncService) changedNotifications(ctx context.Context, tx pgx.Tx, userID uuid.UUID, since time.Time) ([]models.Notification, time.Time, error) {
+	query := `
+		SELECT id, user_id, event_type, ride_id, title, body, payload, created_at, read_at,
+		       GREATEST(created_at, COALESCE(read_at, created_at)) AS changed_at
+		FROM notifications
+		WHERE user_id = $1 AND (created_at > $2 OR read_at > $2)
+		ORDER BY changed_at, id
+		LIMIT $3
+	`
+	rows, err := tx.Query(ctx, query, userID, since, syncBatchLimit)
+	if err != nil {
+		log.Printf("Error syncing notifications of user %s: %v", userID, err)
+		return nil, time.Time{}, fmt.Errorf("database error syncing notifications: %w", err)
+	}
+	defer rows.Close()
+	notifications := []models.Notification{}
+	var lastChange time.Time
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.EventType, &n.RideID, &n.Title, &n.Body, &n.Payload, &n.CreatedAt, &n.ReadAt, &lastChange); err != nil {
+			return nil, time.Time{}, fmt.Errorf("error processing synced notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("database iteration error for synced notifications: %w", err)
+	}
+	return notifications, lastChange, nil
+}
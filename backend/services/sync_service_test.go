@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+
+	"rideshare/backend/config"
+	"rideshare/backend/models"
+)
+
+// Test that a list cut short at the batch limit flags more changes and moves the cursor back to its last change
+func TestSyncService_Changes_Truncated(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+	rideService := NewRideService(&config.Config{}, mock, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	syncService := NewSyncService(mock, rideService)
+	userID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+	since := now.Add(-24 * time.Hour)
+
+	participations := pgxmock.NewRows([]string{"id", "user_id", "ride_id", "status", "created_at", "updated_at", "boarding_stop", "alighting_stop", "seat_held_until", "seat_count"})
+	lastChange := since
+	for i := 0; i < syncBatchLimit; i++ {
+		lastChange = since.Add(time.Duration(i) * time.Minute)
+		participations.AddRow(uuid.New(), userID, uuid.New(), "active", since, lastChange, 0, 1, (*time.Time)(nil), 1)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY").WillReturnResult(pgxmock.NewResult("SET", 0))
+	mock.ExpectQuery("SELECT NOW").WillReturnRows(pgxmock.NewRows([]string{"now"}).AddRow(now))
+	mock.ExpectQuery("FROM rides r").WithArgs(userID, since.Add(-syncOverlap), syncBatchLimit).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM participants").WithArgs(userID, since.Add(-syncOverlap), syncBatchLimit).
+		WillReturnRows(participations)
+	mock.ExpectQuery("FROM payments").WithArgs(userID, since.Add(-syncOverlap), syncBatchLimit).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM notifications").WithArgs(userID, since.Add(-syncOverlap), syncBatchLimit).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	cursor := since.Format(time.RFC3339)
+	changes, err := syncService.Changes(context.Background(), userID, models.SyncRequest{Since: &cursor})
+	if err != nil {
+		t.Fatalf("Changes() error = %v", err)
+	}
+	if !changes.HasMore || !changes.SyncedAt.Equal(lastChange) {
+		t.Errorf("Changes() has_more = %t, synced_at = %v, want true and %v", changes.HasMore, changes.SyncedAt, lastChange)
+	}
+	if len(changes.Participations) != syncBatchLimit || len(changes.Rides) != 0 {
+		t.Errorf("Changes() returned %d participations and %d rides, want %d and 0", len(changes.Participations), len(changes.Rides), syncBatchLimit)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
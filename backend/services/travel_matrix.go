@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context" // For database and provider calls
+	"log"     // For logging
+	"strings" // For normalizing location names
+	"sync"    // For the in-memory matrix
+	"time"    // For the refresh interval
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// travelMatrixLookback is how far back rides are counted to find the popular pairs.
+const travelMatrixLookback = 90 * 24 * time.Hour
+
+// TravelMatrix caches driving time and distance between the most frequent origin/destination
+// pairs. Estimates are persisted in the travel_matrix table and held in memory, so searches are
+// enriched without calling the routing provider per request.
+type TravelMatrix struct {
+	db       database.DBPool
+	routing  *RoutingService
+	interval time.Duration // 0 disables refreshing (persisted estimates are still used)
+	maxPairs int
+
+	mu        sync.RWMutex
+	estimates map[string]models.TravelEstimate // Keyed by travelMatrixKey
+}
+
+// NewTravelMatrix creates a TravelMatrix refreshed every cfg.TravelMatrixRefreshInterval.
+func NewTravelMatrix(cfg *config.Config, db database.DBPool) *TravelMatrix {
+	return &TravelMatrix{
+		db:        db,
+		routing:   NewRoutingService(cfg),
+		interval:  cfg.TravelMatrixRefreshInterval,
+		maxPairs:  cfg.TravelMatrixMaxPairs,
+		estimates: make(map[string]models.TravelEstimate),
+	}
+}
+
+// travelMatrixKey normalizes a pair of location names the way the travel_matrix table does.
+func travelMatrixKey(departure, arrival string) string {
+	return strings.ToLower(strings.TrimSpace(departure)) + "|" + strings.ToLower(strings.TrimSpace(arrival))
+}
+
+// Lookup returns the cached estimate between two locations, if the pair is in the matrix.
+func (m *TravelMatrix) Lookup(departure, arrival string) (models.TravelEstimate, bool) {
+	if m == nil {
+		return models.TravelEstimate{}, false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	estimate, ok := m.estimates[travelMatrixKey(departure, arrival)]
+	return estimate, ok
+}
+
+// Enrich sets the estimated driving time, distance and arrival on rides without an estimate of
+// their own whose pair is in the matrix.
+func (m *TravelMatrix) Enrich(rides []models.Ride) {
+	for i := range rides {
+		if rides[i].EstimatedDurationMinutes != nil {
+			continue
+		}
+		estimate, ok := m.Lookup(rides[i].DepartureLocationName, rides[i].ArrivalLocationName)
+		if !ok {
+			continue
+		}
+		setTravelEstimate(&rides[i], estimate.DistanceMeters, estimate.DurationSeconds)
+	}
+}
+
+// setTravelEstimate sets a ride's estimated driving time and distance, rounded for display, and
+// its estimated arrival: the departure plus the driving time.
+func setTravelEstimate(ride *models.Ride, distanceMeters, durationSeconds float64) {
+	minutes := int(durationSeconds/60 + 0.5)
+	km := roundedKm(distanceMeters)
+	ride.EstimatedDurationMinutes = &minutes
+	ride.EstimatedDistanceKm = &km
+	departure, err := rideDepartureAt(ride.DepartureDate, ride.DepartureTime)
+	if err != nil {
+		return
+	}
+	arrival := departure.Add(time.Duration(minutes) * time.Minute)
+	date, clock := arrival.Format("2006-01-02"), arrival.Format("15:04")
+	ride.EstimatedArrivalDate = &date
+	ride.EstimatedArrivalTime = &clock
+}
+
+// Start loads the persisted estimates, then refreshes stale pairs in the background.
+func (m *TravelMatrix) Start() {
+	if err := m.load(context.Background()); err != nil {
+		log.Printf("Warning: Could not load travel matrix: %v", err)
+	}
+	if m.interval <= 0 {
+		log.Println("Travel matrix refresh disabled (TRAVEL_MATRIX_REFRESH_INTERVAL is 0)")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.refresh(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+// load replaces the in-memory matrix with the travel_matrix table.
+func (m *TravelMatrix) load(ctx context.Context) error {
+	rows, err := m.db.Query(ctx, `SELECT departure_key, arrival_key, distance_meters, duration_seconds, refreshed_at FROM travel_matrix`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]models.TravelEstimate)
+	for rows.Next() {
+		var departure, arrival string
+		var estimate models.TravelEstimate
+		if err := rows.Scan(&departure, &arrival, &estimate.DistanceMeters, &estimate.DurationSeconds, &estimate.RefreshedAt); err != nil {
+			return err
+		}
+		estimates[travelMatrixKey(departure, arrival)] = estimate
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.estimates = estimates
+	m.mu.Unlock()
+	log.Printf("Travel matrix loaded with %d city pairs", len(estimates))
+	return nil
+}
+
+// refresh recomputes the most frequent pairs whose estimate is missing or older than the
+// refresh interval, then reloads the matrix. Provider failures skip the pair until next time.
+func (m *TravelMatrix) refresh(ctx context.Context) {
+	query := `
+		SELECT lower(trim(r.departure_location_name)) AS departure_key, lower(trim(r.arrival_location_name)) AS arrival_key,
+		       AVG(ST_Y(r.departure_coords::geometry)), AVG(ST_X(r.departure_coords::geometry)),
+		       AVG(ST_Y(r.arrival_coords::geometry)), AVG(ST_X(r.arrival_coords::geometry))
+		FROM rides r
+		LEFT JOIN travel_matrix tm ON tm.departure_key = lower(trim(r.departure_location_name)) AND tm.arrival_key = lower(trim(r.arrival_location_name))
+		WHERE r.created_at > NOW() - make_interval(secs => $1)
+		  AND r.departure_coords IS NOT NULL AND r.arrival_coords IS NOT NULL
+		  AND (tm.refreshed_at IS NULL OR tm.refreshed_at < NOW() - make_interval(secs => $2))
+		GROUP BY 1, 2
+		ORDER BY COUNT(*) DESC
+		LIMIT $3
+	`
+	rows, err := m.db.Query(ctx, query, travelMatrixLookback.Seconds(), m.interval.Seconds(), m.maxPairs)
+	if err != nil {
+		log.Printf("Warning: Could not list city pairs for the travel matrix: %v", err)
+		return
+	}
+	type cityPair struct {
+		departure, arrival string
+		from, to           models.GeoPoint
+	}
+	var pairs []cityPair
+	for rows.Next() {
+		var pair cityPair
+		if err := rows.Scan(&pair.departure, &pair.arrival, &pair.from.Latitude, &pair.from.Longitude, &pair.to.Latitude, &pair.to.Longitude); err != nil {
+			log.Printf("Warning: Could not scan travel matrix city pair: %v", err)
+			rows.Close()
+			return
+		}
+		pairs = append(pairs, pair)
+	}
+	rows.Close()
+
+	refreshed := 0
+	for _, pair := range pairs {
+		route, err := m.routing.Route(ctx, pair.from, pair.to)
+		if err != nil {
+			log.Printf("Warning: Could not compute travel estimate %s → %s: %v", pair.departure, pair.arrival, err)
+			continue
+		}
+		if route == nil {
+			return // Routing not configured
+		}
+		_, err = m.db.Exec(ctx, `
+			INSERT INTO travel_matrix (departure_key, arrival_key, distance_meters, duration_seconds, refreshed_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (departure_key, arrival_key) DO UPDATE
+			SET distance_meters = EXCLUDED.distance_meters, duration_seconds = EXCLUDED.duration_seconds, refreshed_at = NOW()
+		`, pair.departure, pair.arrival, route.DistanceMeters, route.DurationSeconds)
+		if err != nil {
+			log.Printf("Warning: Could not store travel estimate %s → %s: %v", pair.departure, pair.arrival, err)
+			continue
+		}
+		refreshed++
+	}
+	if refreshed > 0 {
+		log.Printf("Travel matrix refreshed %d city pairs", refreshed)
+	}
+	if err := m.load(ctx); err != nil {
+		log.Printf("Warning: Could not reload travel matrix: %v", err)
+	}
+}
+
+// roundedKm converts meters to kilometers rounded to one decimal, as distances are displayed.
+func roundedKm(meters float64) float64 {
+	return float64(int(meters/100+0.5)) / 10
+}
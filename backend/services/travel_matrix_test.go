@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rideshare/backend/models"
+)
+
+// Test that rides are enriched from the matrix regardless of name case and spacing
+func TestTravelMatrix_Enrich(t *testing.T) {
+	matrix := &TravelMatrix{estimates: map[string]models.TravelEstimate{
+		travelMatrixKey("paris", "lyon"): {DistanceMeters: 465349, DurationSeconds: 16170},
+	}}
+	rides := []models.Ride{
+		{DepartureLocationName: " Paris", ArrivalLocationName: "LYON"},
+		{DepartureLocationName: "Lille", ArrivalLocationName: "Nantes"},
+	}
+	matrix.Enrich(rides)
+
+	if rides[0].EstimatedDurationMinutes == nil || *rides[0].EstimatedDurationMinutes != 270 {
+		t.Errorf("EstimatedDurationMinutes = %v, want 270", rides[0].EstimatedDurationMinutes)
+	}
+	if rides[0].EstimatedDistanceKm == nil || *rides[0].EstimatedDistanceKm != 465.3 {
+		t.Errorf("EstimatedDistanceKm = %v, want 465.3", rides[0].EstimatedDistanceKm)
+	}
+	if rides[1].EstimatedDurationMinutes != nil {
+		t.Error("ride between uncached cities was enriched")
+	}
+
+	var disabled *TravelMatrix
+	if _, ok := disabled.Lookup("paris", "lyon"); ok {
+		t.Error("nil matrix returned an estimate")
+	}
+}
+
+// Test that the estimated arrival rolls over to the next day after midnight
+func TestSetTravelEstimate_Arrival(t *testing.T) {
+	ride := models.Ride{DepartureDate: time.Date(2030, 5, 17, 0, 0, 0, 0, time.UTC), DepartureTime: "21:40:00"}
+	setTravelEstimate(&ride, 465349, 16170)
+
+	if ride.EstimatedArrivalDate == nil || *ride.EstimatedArrivalDate != "2030-05-18" {
+		t.Errorf("EstimatedArrivalDate = %v, want 2030-05-18", ride.EstimatedArrivalDate)
+	}
+	if ride.EstimatedArrivalTime == nil || *ride.EstimatedArrivalTime != "02:10" {
+		t.Errorf("EstimatedArrivalTime = %v, want 02:10", ride.EstimatedArrivalTime)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"reflect" // For struct tag lookup
+	"strings" // For tag parsing
+
+	"github.com/go-playground/validator/v10"
+
+	"rideshare/backend/models"
+)
+
+// NewValidator returns the validator used by all services and handlers.
+// Field errors are reported under the JSON (or query) name clients send, e.g. "departure_time"
+// rather than "DepartureTime", so they can be returned to clients as-is.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name // Server-set fields (json:"-") keep their Go name
+	})
+	v.RegisterStructValidation(validateCoordinates, models.GeoPoint{}, models.UpdateLocationRequest{}, models.NearbyRidesRequest{}, models.SearchRidesRequest{})
+	return v
+}
+
+// validateCoordinates rejects (0, 0), reported on the latitude as rule "null_island". Ranges are
+// checked by the latitude/longitude tags of each struct.
+func validateCoordinates(sl validator.StructLevel) {
+	rejectNullIsland := func(lat, lon *float64, field string) {
+		if lat != nil && lon != nil && *lat == 0 && *lon == 0 {
+			sl.ReportError(*lat, field, "Latitude", "null_island", "")
+		}
+	}
+	switch point := sl.Current().Interface().(type) {
+	case models.GeoPoint:
+		rejectNullIsland(&point.Latitude, &point.Longitude, "latitude")
+	case models.UpdateLocationRequest:
+		rejectNullIsland(&point.Latitude, &point.Longitude, "latitude")
+	case models.NearbyRidesRequest:
+		rejectNullIsland(point.Latitude, point.Longitude, "lat")
+	case models.SearchRidesRequest:
+		rejectNullIsland(point.DepartureLat, point.DepartureLon, "departure_lat")
+		rejectNullIsland(point.ArrivalLat, point.ArrivalLon, "arrival_lat")
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"rideshare/backend/models"
+)
+
+// Test that validation errors report the client-facing field names
+func TestNewValidator_FieldNames(t *testing.T) {
+	type request struct {
+		DepartureTime string `json:"departure_time" validate:"required"`
+		MinSeats      int    `query:"min_seats" validate:"min=1"`
+		Internal      string `json:"-" validate:"required"`
+	}
+
+	err := NewValidator().Struct(request{})
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Struct() error = %v, want validation errors", err)
+	}
+
+	got := map[string]string{}
+	for _, fe := range validationErrors {
+		got[fe.Field()] = fe.Tag()
+	}
+	if got["departure_time"] != "required" {
+		t.Errorf("departure_time rule = %q, want required", got["departure_time"])
+	}
+	if got["min_seats"] != "min" {
+		t.Errorf("min_seats rule = %q, want min", got["min_seats"])
+	}
+	if got["Internal"] != "required" {
+		t.Errorf("Internal rule = %q, want required (json:\"-\" fields keep their Go name)", got["Internal"])
+	}
+}
+
+// Test that ride coordinates are range-checked, (0, 0) is rejected and the SRID must be WGS 84
+func TestNewValidator_Coordinates(t *testing.T) {
+	paris := &models.GeoPoint{Latitude: 48.85, Longitude: 2.35}
+	req := models.CreateRideRequest{
+		DepartureLocationName: "Paris",
+		DepartureCoords:       &models.GeoPoint{Latitude: 91, Longitude: 2.35},
+		ArrivalLocationName:   "Lyon",
+		ArrivalCoords:         &models.GeoPoint{Latitude: 45.76, Longitude: 4.84, SRID: 3857},
+		DepartureDate:         "2030-01-01",
+		DepartureTime:         "08:00",
+		TotalSeats:            3,
+		Stops:                 []models.RideStopRequest{{LocationName: "Nowhere", Coords: &models.GeoPoint{}}},
+	}
+
+	err := NewValidator().Struct(req)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Struct() error = %v, want validation errors", err)
+	}
+	got := map[string]string{}
+	for _, fe := range validationErrors {
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		got[path] = fe.Tag()
+	}
+	want := map[string]string{
+		"departure_coords.latitude": "latitude",
+		"arrival_coords.srid":       "eq",
+		"stops[0].coords.latitude":  "null_island",
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("%s rule = %q, want %q (errors: %v)", field, got[field], rule, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d field errors, want %d: %v", len(got), len(want), got)
+	}
+
+	if err := NewValidator().Struct(paris); err != nil {
+		t.Errorf("Struct(%+v) error = %v, want nil", paris, err)
+	}
+}
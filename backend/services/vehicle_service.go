@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context" // For database calls
+	"errors"  // For pgx error checks
+	"fmt"     // For error formatting
+	"log"     // For logging
+	"strings" // For normalizing input
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"rideshare/backend/database"
+	"rideshare/backend/models"
+)
+
+// maxVehiclesPerUser bounds the vehicles a user may register.
+const maxVehiclesPerUser = 5
+
+var (
+	// ErrVehicleNotFound is returned when a vehicle doesn't exist or belongs to someone else.
+	ErrVehicleNotFound = newError(KindNotFound, "vehicle not found")
+	// ErrTooManyVehicles is returned when the user already registered maxVehiclesPerUser vehicles.
+	ErrTooManyVehicles = newError(KindConflict, fmt.Sprintf("you can register up to %d vehicles", maxVehiclesPerUser))
+)
+
+const vehicleColumns = `id, make, model, color, license_plate, created_at, updated_at`
+
+// VehicleService manages the vehicles users drive their rides with.
+type VehicleService struct {
+	db        database.DBPool
+	validator *validator.Validate
+}
+
+// NewVehicleService creates a new VehicleService instance.
+func NewVehicleService(db database.DBPool) *VehicleService {
+	return &VehicleService{
+		db:        db,
+		validator: NewValidator(),
+	}
+}
+
+// normalizeVehicle trims the fields of a vehicle request and upper-cases the plate, so "ab-123-cd"
+// and "AB-123-CD " are the same plate.
+func normalizeVehicle(req models.VehicleRequest) models.VehicleRequest {
+	return models.VehicleRequest{
+		Make:         strings.TrimSpace(req.Make),
+		Model:        strings.TrimSpace(req.Model),
+		Color:        strings.TrimSpace(req.Color),
+		LicensePlate: strings.ToUpper(strings.Join(strings.Fields(req.LicensePlate), " ")),
+	}
+}
+
+// List returns the user's vehicles, in the order they were registered.
+func (s *VehicleService) List(ctx context.Context, userID uuid.UUID) ([]models.Vehicle, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+vehicleColumns+` FROM vehicles WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error listing vehicles: %w", err)
+	}
+	defer rows.Close()
+
+	vehicles := []models.Vehicle{}
+	for rows.Next() {
+		vehicle, err := scanVehicle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error processing vehicle: %w", err)
+		}
+		vehicles = append(vehicles, *vehicle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database iteration error for vehicles: %w", err)
+	}
+	return vehicles, nil
+}
+
+// Create registers a vehicle for the user.
+func (s *VehicleService) Create(ctx context.Context, userID uuid.UUID, req models.VehicleRequest) (*models.Vehicle, error) {
+	req = normalizeVehicle(req)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid vehicle: %w", err)
+	}
+	query := `
+		INSERT INTO vehicles (user_id, make, model, color, license_plate)
+		SELECT $1, $2, $3, $4, $5
+		WHERE (SELECT COUNT(*) FROM vehicles WHERE user_id = $1) < $6
+		RETURNING ` + vehicleColumns
+	vehicle, err := scanVehicle(s.db.QueryRow(ctx, query, userID, req.Make, req.Model, req.Color, req.LicensePlate, maxVehiclesPerUser))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTooManyVehicles
+		}
+		log.Printf("Error registering vehicle for user %s: %v", userID, err)
+		return nil, fmt.Errorf("database error registering vehicle: %w", err)
+	}
+	log.Printf("User %s registered vehicle %s", userID, vehicle.ID)
+	return vehicle, nil
+}
+
+// Update replaces the details of one of the user's vehicles. Rides driven with it show the new details.
+func (s *VehicleService) Update(ctx context.Context, userID, vehicleID uuid.UUID, req models.VehicleRequest) (*models.Vehicle, error) {
+	req = normalizeVehicle(req)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("invalid vehicle: %w", err)
+	}
+	query := `
+		UPDATE vehicles SET make = $3, model = $4, color = $5, license_plate = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + vehicleColumns
+	vehicle, err := scanVehicle(s.db.QueryRow(ctx, query, vehicleID, userID, req.Make, req.Model, req.Color, req.LicensePlate))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrVehicleNotFound
+		}
+		return nil, fmt.Errorf("database error updating vehicle: %w", err)
+	}
+	return vehicle, nil
+}
+
+// Delete removes one of the user's vehicles. Rides driven with it no longer show a vehicle.
+func (s *VehicleService) Delete(ctx context.Context, userID, vehicleID uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM vehicles WHERE id = $1 AND user_id = $2`, vehicleID, userID)
+	if err != nil {
+		return fmt.Errorf("database error deleting vehicle: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrVehicleNotFound
+	}
+	return nil
+}
+
+func scanVehicle(row pgx.Row) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := row.Scan(&vehicle.ID, &vehicle.Make, &vehicle.Model, &vehicle.Color, &vehicle.LicensePlate, &vehicle.CreatedAt, &vehicle.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &vehicle, nil
+}
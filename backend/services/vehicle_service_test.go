@@ -0,0 +1,19 @@
+package services
+
+import (
+	"testing"
+
+	"rideshare/backend/models"
+)
+
+// Test that vehicle details are trimmed and plates compared case- and spacing-insensitively
+func TestNormalizeVehicle(t *testing.T) {
+	got := normalizeVehicle(models.VehicleRequest{Make: " Renault ", Model: "Clio", Color: "blue ", LicensePlate: " ab-123-cd "})
+	want := models.VehicleRequest{Make: "Renault", Model: "Clio", Color: "blue", LicensePlate: "AB-123-CD"}
+	if got != want {
+		t.Errorf("normalizeVehicle = %+v, want %+v", got, want)
+	}
+	if got := normalizeVehicle(models.VehicleRequest{LicensePlate: "gb  ab12   cde"}).LicensePlate; got != "GB AB12 CDE" {
+		t.Errorf("plate = %q, want inner spaces collapsed", got)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"  // For check deadlines
+	"errors"   // For error type checks
+	"fmt"      // For error formatting
+	"log"      // For logging
+	"net/http" // For Stripe status codes
+	"time"     // For backoff
+
+	"github.com/stripe/stripe-go/v72"
+
+	"rideshare/backend/config"
+	"rideshare/backend/database"
+	"rideshare/backend/services"
+)
+
+// StartupCheckError reports a dependency that was still unavailable after all attempts.
+type StartupCheckError struct {
+	Check    string // e.g. "postgres", "stripe"
+	Attempts int
+	Err      error // Last error seen
+}
+
+func (e *StartupCheckError) Error() string {
+	return fmt.Sprintf("startup check %q failed after %d attempt(s): %v", e.Check, e.Attempts, e.Err)
+}
+
+func (e *StartupCheckError) Unwrap() error {
+	return e.Err
+}
+
+// permanentError marks a check failure that retrying can't fix (e.g. rejected credentials).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// retryCheck runs check until it succeeds, returns a permanent error, or attempts run out.
+// Waits double after every failure (1s, 2s, 4s, ...) up to maxBackoff.
+func retryCheck(name string, attempts int, maxBackoff time.Duration, check func(ctx context.Context) error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Second
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = check(ctx)
+		cancel()
+		if err == nil {
+			log.Printf("Startup check '%s' passed (attempt %d/%d)", name, attempt, attempts)
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return &StartupCheckError{Check: name, Attempts: attempt, Err: permanent.err}
+		}
+		if attempt == attempts {
+			break
+		}
+		log.Printf("Startup check '%s' failed (attempt %d/%d), retrying in %s: %v", name, attempt, attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return &StartupCheckError{Check: name, Attempts: attempts, Err: err}
+}
+
+// runStartupChecks connects to Postgres and verifies the Stripe credentials before the server
+// starts taking traffic. Transient failures (DB still starting, network) are retried with backoff,
+// so orchestrated restarts don't crash-loop while dependencies come up.
+func runStartupChecks(cfg *config.Config, stripeService *services.StripeServiceImpl) error {
+	err := retryCheck("postgres", cfg.StartupRetryAttempts, cfg.StartupRetryMaxBackoff, func(ctx context.Context) error {
+		err := database.ConnectDB(ctx, cfg)
+		if errors.Is(err, database.ErrInvalidConfig) {
+			return &permanentError{err: err}
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.StripeSecretKey == "" {
+		log.Println("Warning: STRIPE_SECRET_KEY not set, skipping Stripe credentials check")
+		return nil
+	}
+	return retryCheck("stripe", cfg.StartupRetryAttempts, cfg.StartupRetryMaxBackoff, func(ctx context.Context) error {
+		err := stripeService.VerifyCredentials(ctx)
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && (stripeErr.HTTPStatusCode == http.StatusUnauthorized || stripeErr.HTTPStatusCode == http.StatusForbidden) {
+			return &permanentError{err: fmt.Errorf("stripe rejected the configured secret key: %w", err)}
+		}
+		return err
+	})
+}
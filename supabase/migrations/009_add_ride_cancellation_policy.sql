@@ -0,0 +1,24 @@
+-- Migration: 009_add_ride_cancellation_policy
+-- Description: Add a creator-selected cancellation policy to rides and track refunds on payments.
+-- Created at: NOW()
+
+-- Cancellation policy chosen by the driver when creating the ride
+ALTER TABLE rides
+ADD COLUMN cancellation_policy TEXT NOT NULL DEFAULT 'moderate'
+CONSTRAINT ride_cancellation_policy_check CHECK (cancellation_policy IN ('flexible', 'moderate', 'strict'));
+
+COMMENT ON COLUMN rides.cancellation_policy IS 'Refund policy applied when participants leave (flexible, moderate, strict)';
+
+-- Track how much of a payment has been refunded (in the smallest currency unit)
+ALTER TABLE payments
+ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0
+CONSTRAINT payment_refunded_amount_check CHECK (refunded_amount >= 0);
+
+COMMENT ON COLUMN payments.refunded_amount IS 'Amount refunded to the user so far (smallest currency unit)';
+
+-- Allow the 'refunded' status once a payment has been fully refunded
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payment_status_check;
+ALTER TABLE payments
+ADD CONSTRAINT payment_status_check CHECK (status IN ('pending', 'succeeded', 'failed', 'refunded'));
+
+COMMENT ON COLUMN payments.status IS 'Status of the payment (pending, succeeded, failed, refunded)';
@@ -0,0 +1,21 @@
+-- Migration: 010_create_notifications_table
+-- Description: Store notifications emitted by the backend dispatcher (also delivered as Expo pushes).
+-- Created at: NOW()
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Recipient
+    event_type TEXT NOT NULL,                                     -- e.g. join_confirmed, ride_cancelled
+    ride_id UUID REFERENCES rides(id) ON DELETE SET NULL,         -- Related ride (optional)
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,                   -- Typed push payload (event, ride_id, screen route, ...)
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    read_at TIMESTAMPTZ                                           -- Set when the user opens the notification
+);
+
+COMMENT ON TABLE notifications IS 'Notifications sent to users (in-app list and Expo push deliveries).';
+COMMENT ON COLUMN notifications.payload IS 'Structured push payload used by the mobile app to deep link on tap';
+
+CREATE INDEX idx_notifications_user_id_created_at ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_ride_id ON notifications(ride_id);
@@ -0,0 +1,16 @@
+-- Migration: 011_add_notification_push_status
+-- Description: Track push delivery per notification to support per-user rate caps and collapsing.
+-- Created at: NOW()
+
+ALTER TABLE notifications
+ADD COLUMN push_status TEXT NOT NULL DEFAULT 'pending'
+CONSTRAINT notification_push_status_check CHECK (push_status IN ('pending', 'sent', 'throttled', 'collapsed', 'no_token', 'failed'));
+
+ALTER TABLE notifications
+ADD COLUMN pushed_at TIMESTAMPTZ; -- When the push was handed to Expo (NULL if not pushed)
+
+COMMENT ON COLUMN notifications.push_status IS 'Push delivery outcome (pending, sent, throttled, collapsed, no_token, failed)';
+COMMENT ON COLUMN notifications.pushed_at IS 'Timestamp the push was sent, used for per-user rate caps';
+
+-- Supports the "pushes sent in the last hour" and collapse lookups
+CREATE INDEX idx_notifications_user_id_pushed_at ON notifications(user_id, pushed_at) WHERE pushed_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Migration: 012_add_user_preferred_locale
+-- Description: Store the user's preferred locale so transactional emails use the right template variant.
+-- Created at: NOW()
+
+ALTER TABLE users
+ADD COLUMN preferred_locale TEXT NOT NULL DEFAULT 'en'; -- BCP 47 language code (e.g. 'en', 'fr')
+
+COMMENT ON COLUMN users.preferred_locale IS 'Preferred language for emails and notifications';
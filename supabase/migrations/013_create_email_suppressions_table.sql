@@ -0,0 +1,13 @@
+-- Migration: 013_create_email_suppressions_table
+-- Description: Suppression list for non-critical emails (unsubscribe links and email preference center).
+-- Created at: NOW()
+
+CREATE TABLE email_suppressions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,                        -- Email category opted out of (e.g. ride_activity)
+    source TEXT NOT NULL DEFAULT 'preferences',    -- How the opt-out was recorded: 'unsubscribe_link' or 'preferences'
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, category)
+);
+
+COMMENT ON TABLE email_suppressions IS 'Email categories each user opted out of; critical transactional emails ignore this list.';
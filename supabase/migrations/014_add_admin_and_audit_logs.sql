@@ -0,0 +1,29 @@
+-- Migration: 014_add_admin_and_audit_logs
+-- Description: Admin flag on users and an audit log for admin and impersonated actions.
+-- Created at: NOW()
+
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE; -- Grants access to /admin routes
+
+COMMENT ON COLUMN users.is_admin IS 'Whether the user is a RideShare staff member with admin access';
+
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,                -- Admin who performed the action
+    impersonated_user_id UUID REFERENCES users(id) ON DELETE SET NULL,    -- Set when acting on behalf of a user
+    action TEXT NOT NULL,                                                 -- e.g. impersonation.start, impersonated.request
+    target_type TEXT,                                                     -- e.g. user, ride, payment
+    target_id TEXT,
+    method TEXT,                                                          -- HTTP method of the request
+    path TEXT,                                                            -- HTTP path of the request
+    status_code INT,                                                      -- HTTP status returned
+    ip_address TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,                          -- Action-specific details (reason, changes, ...)
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE audit_logs IS 'Append-only trail of admin actions and requests made with impersonation tokens';
+
+CREATE INDEX idx_audit_logs_actor_id_created_at ON audit_logs(actor_id, created_at DESC);
+CREATE INDEX idx_audit_logs_impersonated_user_id ON audit_logs(impersonated_user_id) WHERE impersonated_user_id IS NOT NULL;
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id);
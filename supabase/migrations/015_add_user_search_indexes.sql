@@ -0,0 +1,9 @@
+-- Migration: 015_add_user_search_indexes
+-- Description: Trigram indexes backing fuzzy admin user search on email, phone and name.
+-- Created at: NOW()
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_users_email_trgm ON users USING GIN (email gin_trgm_ops);
+CREATE INDEX idx_users_whatsapp_trgm ON users USING GIN (whatsapp gin_trgm_ops);
+CREATE INDEX idx_users_full_name_trgm ON users USING GIN ((COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) gin_trgm_ops);
@@ -0,0 +1,75 @@
+-- Migration: 016_create_fraud_tables
+-- Description: Fraud rules engine - configurable rules, evaluated events and flags for review.
+-- Created at: NOW()
+
+-- Rules are read at runtime (cached briefly), so they can be tuned without a redeploy.
+CREATE TABLE fraud_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    event TEXT NOT NULL CHECK (event IN ('signup', 'join', 'payment')),
+    rule_type TEXT NOT NULL CHECK (rule_type IN ('velocity', 'distinct_cards', 'geo_impossible')),
+    threshold NUMERIC NOT NULL,                  -- Count for velocity/distinct_cards, km/h for geo_impossible
+    window_seconds INT NOT NULL CHECK (window_seconds > 0),
+    action TEXT NOT NULL CHECK (action IN ('flag', 'hold', 'require_verification')),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE fraud_rules IS 'Fraud rules evaluated on signup, join and payment';
+
+CREATE TRIGGER update_fraud_rules_updated_at
+BEFORE UPDATE ON fraud_rules
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Every evaluated action is recorded so velocity, card and location rules can look back over a window.
+CREATE TABLE fraud_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,   -- NULL for signups (user not created yet)
+    ip_address TEXT,
+    payment_method_id TEXT,                                -- Stripe payment method used, for payment events
+    latitude DOUBLE PRECISION,                             -- User's last known location at the time of the event
+    longitude DOUBLE PRECISION,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_fraud_events_user_event_created_at ON fraud_events(user_id, event, created_at DESC);
+CREATE INDEX idx_fraud_events_ip_event_created_at ON fraud_events(ip_address, event, created_at DESC);
+
+CREATE TABLE fraud_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    ride_id UUID REFERENCES rides(id) ON DELETE SET NULL,
+    ip_address TEXT,
+    action TEXT NOT NULL,                                  -- Strongest action taken (flag, hold, require_verification)
+    rules TEXT[] NOT NULL,                                 -- Names of the rules that triggered
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'cleared', 'confirmed')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    resolution_note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE fraud_flags IS 'Actions flagged by fraud rules, queued for admin review';
+
+CREATE INDEX idx_fraud_flags_status_created_at ON fraud_flags(status, created_at DESC);
+CREATE INDEX idx_fraud_flags_user_id ON fraud_flags(user_id);
+
+-- Bookings held by a fraud rule are neither charged nor counted as seats until reviewed.
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participant_status_check;
+ALTER TABLE participants
+ADD CONSTRAINT participant_status_check CHECK (status IN ('pending_payment', 'active', 'left', 'cancelled_ride', 'on_hold'));
+
+COMMENT ON COLUMN participants.status IS 'Current status of the participation (pending_payment, active, left, cancelled_ride, on_hold)';
+
+-- Default rules
+INSERT INTO fraud_rules (name, event, rule_type, threshold, window_seconds, action) VALUES
+    ('signup_velocity_per_ip', 'signup', 'velocity', 5, 3600, 'require_verification'),
+    ('join_velocity', 'join', 'velocity', 10, 3600, 'hold'),
+    ('payment_velocity', 'payment', 'velocity', 8, 3600, 'flag'),
+    ('many_cards_per_account', 'payment', 'distinct_cards', 3, 86400, 'hold'),
+    ('geo_impossible_join', 'join', 'geo_impossible', 900, 86400, 'flag'),
+    ('geo_impossible_payment', 'payment', 'geo_impossible', 900, 86400, 'require_verification');
@@ -0,0 +1,20 @@
+-- Migration: 017_create_user_quota_overrides
+-- Description: Admin overrides of per-account abuse quotas (rides created per day, joins per hour).
+-- Created at: NOW()
+
+CREATE TABLE user_quota_overrides (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quota TEXT NOT NULL,                                        -- e.g. rides_created, ride_joins
+    limit_value INT CHECK (limit_value IS NULL OR limit_value >= 0), -- NULL = unlimited
+    reason TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,    -- Admin who granted the override
+    expires_at TIMESTAMPTZ,                                     -- NULL = until removed
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, quota)
+);
+
+COMMENT ON TABLE user_quota_overrides IS 'Per-user replacements for the default abuse quotas, granted by admins';
+
+-- Quota counts look back over the user's recent rides and participations
+CREATE INDEX IF NOT EXISTS idx_rides_user_id_created_at ON rides(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_participants_user_id_updated_at ON participants(user_id, updated_at DESC);
@@ -0,0 +1,15 @@
+-- Migration: 018_add_fraud_event_country
+-- Description: Record the IP country of fraud events and add the country_change rule type.
+-- Created at: NOW()
+
+ALTER TABLE fraud_events
+ADD COLUMN country TEXT; -- ISO country code resolved from the request IP
+
+COMMENT ON COLUMN fraud_events.country IS 'Country of the request IP (IP geolocation), when resolvable';
+
+ALTER TABLE fraud_rules DROP CONSTRAINT IF EXISTS fraud_rules_rule_type_check;
+ALTER TABLE fraud_rules
+ADD CONSTRAINT fraud_rules_rule_type_check CHECK (rule_type IN ('velocity', 'distinct_cards', 'geo_impossible', 'country_change'));
+
+INSERT INTO fraud_rules (name, event, rule_type, threshold, window_seconds, action) VALUES
+    ('payment_many_countries', 'payment', 'country_change', 2, 86400, 'flag');
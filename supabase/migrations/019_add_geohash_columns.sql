@@ -0,0 +1,24 @@
+-- Migration: 019_add_geohash_columns
+-- Description: Geohash columns for the prefix-match proximity strategy (PROXIMITY_STRATEGY=geohash), backfilled from existing coordinates.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN departure_geohash TEXT, -- Geohash (9 chars) of departure_coords
+ADD COLUMN arrival_geohash TEXT;   -- Geohash (9 chars) of arrival_coords
+
+ALTER TABLE users
+ADD COLUMN last_known_geohash TEXT; -- Geohash (9 chars) of last_known_location
+
+COMMENT ON COLUMN rides.departure_geohash IS 'Geohash of the departure point; prefixes are the enclosing cells, used for proximity queries without PostGIS';
+COMMENT ON COLUMN rides.arrival_geohash IS 'Geohash of the arrival point';
+COMMENT ON COLUMN users.last_known_geohash IS 'Geohash of the user''s last known location';
+
+-- Backfill existing rows (new rows are written by the backend)
+UPDATE rides SET departure_geohash = ST_GeoHash(departure_coords, 9) WHERE departure_coords IS NOT NULL;
+UPDATE rides SET arrival_geohash = ST_GeoHash(arrival_coords, 9) WHERE arrival_coords IS NOT NULL;
+UPDATE users SET last_known_geohash = ST_GeoHash(last_known_location, 9) WHERE last_known_location IS NOT NULL;
+
+-- text_pattern_ops lets LIKE 'prefix%' use the index
+CREATE INDEX idx_rides_departure_geohash ON rides (departure_geohash text_pattern_ops);
+CREATE INDEX idx_rides_arrival_geohash ON rides (arrival_geohash text_pattern_ops);
+CREATE INDEX idx_users_last_known_geohash ON users (last_known_geohash text_pattern_ops);
@@ -0,0 +1,8 @@
+-- Migration: 020_add_ride_route_polyline
+-- Description: Store the driving route computed at ride creation as an encoded polyline.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN route_polyline TEXT; -- Google encoded polyline (precision 5); NULL when routing was unavailable
+
+COMMENT ON COLUMN rides.route_polyline IS 'Driving route from OpenRouteService at creation, as an encoded polyline; clients draw it and corridor search reuses it';
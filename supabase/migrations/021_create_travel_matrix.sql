@@ -0,0 +1,14 @@
+-- Migration: 021_create_travel_matrix
+-- Description: Cached driving time/distance between frequent city pairs, refreshed periodically by the backend.
+-- Created at: NOW()
+
+CREATE TABLE travel_matrix (
+    departure_key TEXT NOT NULL,          -- lower(trim(rides.departure_location_name))
+    arrival_key TEXT NOT NULL,            -- lower(trim(rides.arrival_location_name))
+    distance_meters DOUBLE PRECISION NOT NULL,
+    duration_seconds DOUBLE PRECISION NOT NULL,
+    refreshed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (departure_key, arrival_key)
+);
+
+COMMENT ON TABLE travel_matrix IS 'Driving estimates between popular origin/destination pairs, used to enrich searches and filter by arrival time without calling the routing provider per request';
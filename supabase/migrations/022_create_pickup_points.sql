@@ -0,0 +1,21 @@
+-- Migration: 022_create_pickup_points
+-- Description: Curated pickup points (park-and-rides, stations, landmarks) suggested as meeting spots; drivers can attach one to a ride.
+-- Created at: NOW()
+
+CREATE TABLE pickup_points (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('park_and_ride', 'station', 'landmark')),
+    location GEOMETRY(Point, 4326) NOT NULL,
+    city TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE, -- Inactive points are no longer suggested nor attachable
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pickup_points_location ON pickup_points USING GIST (location);
+
+ALTER TABLE rides
+ADD COLUMN pickup_point_id UUID REFERENCES pickup_points(id) ON DELETE SET NULL; -- Official pickup location chosen by the driver
+
+COMMENT ON TABLE pickup_points IS 'Curated meeting spots suggested near ride departures (GET /places/pickup-points)';
+COMMENT ON COLUMN rides.pickup_point_id IS 'Official pickup location of the ride; NULL means the departure coordinates';
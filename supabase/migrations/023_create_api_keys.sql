@@ -0,0 +1,18 @@
+-- Migration: 023_create_api_keys
+-- Description: Scoped API keys for the read-only public data API (municipal dashboards, widgets).
+-- Created at: NOW()
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,                         -- Who the key was issued to, e.g. "Lyon mobility dashboard"
+    key_hash TEXT NOT NULL UNIQUE,              -- SHA-256 (hex) of the key; the key itself is shown once at creation
+    key_prefix TEXT NOT NULL,                   -- First characters of the key, to recognize it in listings
+    scopes TEXT[] NOT NULL DEFAULT '{}',        -- e.g. {rides:read}
+    rate_limit_per_minute INTEGER NOT NULL DEFAULT 60 CHECK (rate_limit_per_minute > 0),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ                      -- Revoked keys are rejected
+);
+
+COMMENT ON TABLE api_keys IS 'Keys for the public data API; only hashes are stored';
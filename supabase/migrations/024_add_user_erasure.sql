@@ -0,0 +1,22 @@
+-- Migration: 024_add_user_erasure
+-- Description: Right-to-erasure pipeline: soft-deleted users are anonymized after a grace period and a certificate is recorded.
+-- Created at: NOW()
+
+ALTER TABLE users
+ADD COLUMN erased_at TIMESTAMPTZ; -- Set when personal data was irreversibly anonymized
+
+COMMENT ON COLUMN users.erased_at IS 'When the erasure job anonymized this soft-deleted user; the row is kept so rides and payments stay consistent';
+
+CREATE TABLE erasure_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,                         -- No foreign key: the certificate must outlive any later purge
+    requested_at TIMESTAMPTZ NOT NULL,             -- users.deleted_at
+    erased_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    steps TEXT[] NOT NULL,                         -- What was erased, e.g. {user_profile,notifications,stripe_customer}
+    digest TEXT NOT NULL                           -- SHA-256 (hex) over the certificate fields, for tamper evidence
+);
+
+COMMENT ON TABLE erasure_certificates IS 'Proof that a user''s personal data was erased, kept for accountability';
+
+CREATE INDEX idx_erasure_certificates_user_id ON erasure_certificates(user_id);
+CREATE INDEX idx_users_deleted_at_pending_erasure ON users(deleted_at) WHERE deleted_at IS NOT NULL AND erased_at IS NULL;
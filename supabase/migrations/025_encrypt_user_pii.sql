@@ -0,0 +1,24 @@
+-- Migration: 025_encrypt_user_pii
+-- Description: Application-level envelope encryption for WhatsApp numbers, birth dates and last known locations.
+-- Created at: NOW()
+
+-- Encrypted values have the form enc:v1:<key id>:<wrapped data key>:<ciphertext>.
+-- The plaintext columns are cleared as rows are encrypted (on write and by the startup backfill).
+ALTER TABLE users
+ADD COLUMN whatsapp_encrypted TEXT,            -- Encrypted E.164 WhatsApp number
+ADD COLUMN whatsapp_hash TEXT,                 -- HMAC-SHA256 blind index of the number, for uniqueness and exact lookups
+ADD COLUMN birth_date_encrypted TEXT,          -- Encrypted birth date (YYYY-MM-DD)
+ADD COLUMN last_known_location_encrypted TEXT; -- Encrypted "latitude,longitude"
+
+ALTER TABLE users ALTER COLUMN whatsapp DROP NOT NULL;
+
+COMMENT ON COLUMN users.whatsapp_encrypted IS 'Envelope-encrypted WhatsApp number; decrypted only for authorized flows such as ride contacts';
+COMMENT ON COLUMN users.whatsapp_hash IS 'Keyed blind index of the WhatsApp number (FIELD_INDEX_KEY)';
+COMMENT ON COLUMN users.birth_date_encrypted IS 'Envelope-encrypted birth date';
+COMMENT ON COLUMN users.last_known_location_encrypted IS 'Envelope-encrypted last known device location; last_known_geohash keeps only a coarse cell';
+
+-- Uniqueness moves from the plaintext column to the blind index; soft-deleted users free their number.
+CREATE UNIQUE INDEX idx_users_whatsapp_hash ON users(whatsapp_hash) WHERE deleted_at IS NULL;
+
+-- Plaintext search indexes are useless once the column is cleared
+DROP INDEX IF EXISTS idx_users_whatsapp_trgm;
@@ -0,0 +1,17 @@
+-- Migration: 026_create_profile_changes
+-- Description: History of profile updates, for support disputes and spotting account takeovers.
+-- Created at: NOW()
+
+CREATE TABLE profile_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,                  -- Profile field, e.g. 'whatsapp', 'first_name'
+    old_value TEXT,                       -- Value before the change (encrypted for whatsapp and birth_date)
+    new_value TEXT,                       -- Value after the change (encrypted for whatsapp and birth_date)
+    ip_address TEXT,                      -- Client IP of the request that made the change
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE profile_changes IS 'One row per profile field changed through UpdateProfile';
+
+CREATE INDEX idx_profile_changes_user_id_changed_at ON profile_changes(user_id, changed_at DESC);
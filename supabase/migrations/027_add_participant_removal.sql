@@ -0,0 +1,14 @@
+-- Migration: 027_add_participant_removal
+-- Description: Ride creators can remove a participant, with a mandatory reason.
+-- Created at: NOW()
+
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participant_status_check;
+ALTER TABLE participants
+ADD CONSTRAINT participant_status_check CHECK (status IN ('pending_payment', 'active', 'left', 'cancelled_ride', 'on_hold', 'removed'));
+
+ALTER TABLE participants
+ADD COLUMN removal_reason TEXT,      -- Reason given by the creator when removing the participant
+ADD COLUMN removed_at TIMESTAMPTZ;   -- When the creator removed the participant
+
+COMMENT ON COLUMN participants.status IS 'Current status of the participation (pending_payment, active, left, cancelled_ride, on_hold, removed)';
+COMMENT ON COLUMN participants.removal_reason IS 'Why the ride creator removed this participant (status removed)';
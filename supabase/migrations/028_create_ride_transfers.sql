@@ -0,0 +1,19 @@
+-- Migration: 028_create_ride_transfers
+-- Description: Ride ownership transfers, proposed by the creator and accepted by the new driver.
+-- Created at: NOW()
+
+CREATE TABLE ride_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Creator proposing the transfer
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,   -- User asked to take over the ride
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMPTZ                                            -- When the proposal was accepted, declined or cancelled
+);
+
+COMMENT ON TABLE ride_transfers IS 'Proposals to hand a ride over to another driver; rides.user_id changes when one is accepted';
+
+-- At most one open proposal per ride
+CREATE UNIQUE INDEX idx_ride_transfers_pending_ride ON ride_transfers(ride_id) WHERE status = 'pending';
+CREATE INDEX idx_ride_transfers_to_user_pending ON ride_transfers(to_user_id) WHERE status = 'pending';
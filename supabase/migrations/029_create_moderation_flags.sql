@@ -0,0 +1,23 @@
+-- Migration: 029_create_moderation_flags
+-- Description: User-written text caught by the moderation filter, queued for admin review.
+-- Created at: NOW()
+
+CREATE TABLE moderation_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,  -- Author of the content
+    content_type TEXT NOT NULL,                           -- Where the text was used (e.g. removal_reason)
+    ride_id UUID REFERENCES rides(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,                                -- Copy of the text as submitted
+    action TEXT NOT NULL CHECK (action IN ('flag', 'block')),
+    reasons TEXT[] NOT NULL,                              -- profanity, contact_details, link, external:<category>
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'confirmed')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    resolution_note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE moderation_flags IS 'User-written text flagged by the moderation filter, queued for admin review';
+
+CREATE INDEX idx_moderation_flags_status_created_at ON moderation_flags(status, created_at DESC);
+CREATE INDEX idx_moderation_flags_user_id ON moderation_flags(user_id);
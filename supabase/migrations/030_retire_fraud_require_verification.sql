@@ -0,0 +1,9 @@
+-- Migration: 030_retire_fraud_require_verification
+-- Description: Retire the 'require_verification' fraud action. Users had no verification flow to
+-- complete, so rules using it become holds (bookings wait for admin review; signups are refused).
+-- Created at: NOW()
+
+UPDATE fraud_rules SET action = 'hold' WHERE action = 'require_verification';
+
+ALTER TABLE fraud_rules DROP CONSTRAINT IF EXISTS fraud_rules_action_check;
+ALTER TABLE fraud_rules ADD CONSTRAINT fraud_rules_action_check CHECK (action IN ('flag', 'hold'));
@@ -0,0 +1,19 @@
+-- Migration: 031_create_quota_usage
+-- Description: Append-only log of actions counted by the per-account quotas. Counting rides and
+-- participations directly let users dodge the limits (delete a ride and create another; a rejoin
+-- or payment update moves a participation's updated_at).
+-- Created at: NOW()
+
+CREATE TABLE quota_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quota TEXT NOT NULL,                                        -- e.g. rides_created, ride_joins
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE quota_usage IS 'One row per action counted by a per-account quota; rows are never updated';
+
+CREATE INDEX IF NOT EXISTS idx_quota_usage_user_quota_created_at ON quota_usage(user_id, quota, created_at DESC);
+
+-- Counts no longer read participations by update time
+DROP INDEX IF EXISTS idx_participants_user_id_updated_at;
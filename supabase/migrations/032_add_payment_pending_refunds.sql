@@ -0,0 +1,10 @@
+-- Migration: 032_add_payment_pending_refunds
+-- Description: Refunds owed on a payment but not yet issued (e.g. Stripe was unavailable), so a
+-- background job can retry them instead of the obligation only living in a log line.
+-- Created at: NOW()
+
+ALTER TABLE payments
+    ADD COLUMN refund_pending_percent INT CHECK (refund_pending_percent BETWEEN 1 AND 100), -- Share of the payment owed back
+    ADD COLUMN refund_pending_reason TEXT;                                                 -- Stripe metadata reason of the owed refund
+
+CREATE INDEX IF NOT EXISTS idx_payments_refund_pending ON payments(updated_at) WHERE refund_pending_percent IS NOT NULL;
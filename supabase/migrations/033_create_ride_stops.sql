@@ -0,0 +1,23 @@
+-- Migration: 033_create_ride_stops
+-- Description: Intermediate stops on a ride, and the leg of the route each participant travels.
+-- Created at: NOW()
+
+CREATE TABLE ride_stops (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position > 0), -- 1..n in driving order; 0 is the departure and n+1 the arrival
+    location_name TEXT NOT NULL,
+    coords GEOMETRY(Point, 4326) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (ride_id, position)
+);
+
+COMMENT ON TABLE ride_stops IS 'Waypoints between a ride''s departure and arrival where participants can board or alight';
+
+ALTER TABLE participants
+ADD COLUMN boarding_stop INT NOT NULL DEFAULT 0 CHECK (boarding_stop >= 0), -- Stop position where the participant gets in (0 = departure)
+ADD COLUMN alighting_stop INT,                                              -- Stop position where the participant gets out (NULL = arrival)
+ADD CONSTRAINT participant_leg_check CHECK (alighting_stop IS NULL OR alighting_stop > boarding_stop);
+
+COMMENT ON COLUMN participants.boarding_stop IS 'Position of the stop where the participant boards (0 = ride departure)';
+COMMENT ON COLUMN participants.alighting_stop IS 'Position of the stop where the participant alights (NULL = ride arrival)';
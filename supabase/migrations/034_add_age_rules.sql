@@ -0,0 +1,14 @@
+-- Migration: 034_add_age_rules
+-- Description: Per-ride minimum age, and admin guardianship overrides of the platform minimum join age.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN min_age INT CHECK (min_age BETWEEN 1 AND 99); -- Minimum traveller age set by the creator (NULL = platform minimum)
+
+ALTER TABLE users
+ADD COLUMN age_override_reason TEXT,                                     -- Why an admin let this user join below MIN_JOIN_AGE
+ADD COLUMN age_override_by UUID REFERENCES users(id) ON DELETE SET NULL, -- Admin who granted the override
+ADD COLUMN age_override_at TIMESTAMPTZ;                                  -- When the override was granted (NULL = no override)
+
+COMMENT ON COLUMN rides.min_age IS 'Minimum age of travellers on this ride; admin age overrides do not lift it';
+COMMENT ON COLUMN users.age_override_at IS 'Set when an admin lets a minor join rides, e.g. with a guardian''s consent';
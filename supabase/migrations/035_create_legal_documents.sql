@@ -0,0 +1,28 @@
+-- Migration: 035_create_legal_documents
+-- Description: Versioned terms of service and privacy policy, and which versions each user accepted.
+-- Created at: NOW()
+
+CREATE TABLE legal_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL CHECK (kind IN ('terms', 'privacy')),
+    version TEXT NOT NULL,                       -- e.g. '2026-10'
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,                       -- Markdown shown in the app
+    mandatory BOOLEAN NOT NULL DEFAULT TRUE,     -- Users must accept this version before using the API again
+    published_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- A version takes effect once published
+    UNIQUE (kind, version)
+);
+
+COMMENT ON TABLE legal_documents IS 'Terms of service and privacy policy versions; the latest published version of each kind is current';
+
+CREATE INDEX idx_legal_documents_kind_published ON legal_documents(kind, published_at DESC);
+
+CREATE TABLE user_document_acceptances (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document_id UUID NOT NULL REFERENCES legal_documents(id) ON DELETE CASCADE,
+    accepted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ip_address TEXT,                             -- Client IP of the acceptance
+    PRIMARY KEY (user_id, document_id)
+);
+
+COMMENT ON TABLE user_document_acceptances IS 'Legal document versions each user accepted, with when and from where';
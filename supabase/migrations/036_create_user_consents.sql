@@ -0,0 +1,16 @@
+-- Migration: 036_create_user_consents
+-- Description: Granular, opt-in consents to data processing (marketing emails, location storage, analytics).
+-- Created at: NOW()
+
+CREATE TABLE user_consents (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    consent_type TEXT NOT NULL CHECK (consent_type IN ('marketing_emails', 'location_storage', 'analytics')),
+    granted BOOLEAN NOT NULL,
+    granted_at TIMESTAMPTZ,           -- Last time the consent was given
+    withdrawn_at TIMESTAMPTZ,         -- Last time the consent was withdrawn
+    ip_address TEXT,                  -- Client IP of the last change
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, consent_type)
+);
+
+COMMENT ON TABLE user_consents IS 'Per-user consents; a missing row means the consent was never given';
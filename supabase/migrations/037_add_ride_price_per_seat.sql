@@ -0,0 +1,11 @@
+-- Migration: 037_add_ride_price_per_seat
+-- Description: Per-seat price on rides, filterable in search. Rides without one cost the platform booking fee.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN price_per_seat INT CHECK (price_per_seat > 0); -- Cents of the payment currency (NULL = booking fee)
+
+COMMENT ON COLUMN rides.price_per_seat IS 'Price of one seat in cents; NULL means the platform booking fee applies';
+
+-- Price range filter of the ride search only looks at active rides
+CREATE INDEX idx_rides_active_price_per_seat ON rides (price_per_seat) WHERE status = 'active';
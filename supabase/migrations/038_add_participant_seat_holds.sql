@@ -0,0 +1,11 @@
+-- Migration: 038_add_participant_seat_holds
+-- Description: Seats held for participants during checkout, counted toward capacity until they expire.
+-- Created at: NOW()
+
+ALTER TABLE participants
+ADD COLUMN seat_held_until TIMESTAMPTZ; -- Set while 'pending_payment'; the seat counts as taken until then
+
+COMMENT ON COLUMN participants.seat_held_until IS 'End of the checkout seat hold of a pending_payment participant (NULL = no hold)';
+
+-- Capacity checks count unexpired holds of pending participants
+CREATE INDEX idx_participants_pending_seat_holds ON participants (ride_id, seat_held_until) WHERE status = 'pending_payment';
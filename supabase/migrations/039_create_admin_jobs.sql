@@ -0,0 +1,24 @@
+-- Migration: 039_create_admin_jobs
+-- Description: Queue of bulk admin operations on rides, processed in the background with progress.
+-- Created at: NOW()
+
+CREATE TABLE admin_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL CHECK (kind IN ('cancel_user_rides', 'archive_rides')),
+    params JSONB NOT NULL DEFAULT '{}',                                       -- Kind-specific parameters (user, date range, reason)
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    total INT NOT NULL DEFAULT 0,                                             -- Rides the job applies to, known once it starts
+    processed INT NOT NULL DEFAULT 0,                                         -- Rides done so far, failures included
+    failed INT NOT NULL DEFAULT 0,                                            -- Rides that could not be processed
+    last_error TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),                            -- Touched on each ride; a running job left stale is resumed
+    finished_at TIMESTAMPTZ
+);
+
+COMMENT ON TABLE admin_jobs IS 'Bulk admin ride operations; the worker claims queued jobs and reports progress per ride';
+
+CREATE INDEX idx_admin_jobs_pending ON admin_jobs(created_at) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_admin_jobs_created_at ON admin_jobs(created_at DESC);
@@ -0,0 +1,61 @@
+-- Migration: 040_create_finance_exports
+-- Description: Accounting exports of booking fees and refunds. Refunds get their own ledger (the
+-- payment row only keeps a running total), and every fee and refund gets a journal number once,
+-- so re-exporting a period yields the same document numbers.
+-- Created at: NOW()
+
+ALTER TABLE payments
+ADD COLUMN paid_at TIMESTAMPTZ; -- When Stripe confirmed the payment (fee booking date)
+
+UPDATE payments SET paid_at = created_at WHERE status IN ('succeeded', 'refunded') AND paid_at IS NULL;
+
+CREATE TABLE payment_refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    stripe_refund_id TEXT UNIQUE,                               -- NULL for refunds issued before this ledger existed
+    amount BIGINT NOT NULL CHECK (amount > 0),                  -- Smallest currency unit
+    reason TEXT NOT NULL,                                       -- e.g. participant_left, ride_cancelled_by_creator
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE payment_refunds IS 'One row per Stripe refund; payments.refunded_amount is their sum';
+
+-- Refunds issued so far are known only as a total per payment
+INSERT INTO payment_refunds (payment_id, amount, reason, created_at)
+SELECT id, refunded_amount, 'legacy', updated_at FROM payments WHERE refunded_amount > 0;
+
+CREATE INDEX idx_payment_refunds_created_at ON payment_refunds(created_at);
+CREATE INDEX idx_payment_refunds_payment_id ON payment_refunds(payment_id);
+
+CREATE TABLE finance_journal_entries (
+    number BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,   -- Document number used by every export
+    source_type TEXT NOT NULL CHECK (source_type IN ('fee', 'refund')),
+    source_id UUID NOT NULL,                                    -- payments.id or payment_refunds.id
+    booked_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (source_type, source_id)
+);
+
+COMMENT ON TABLE finance_journal_entries IS 'Journal numbers assigned to fees and refunds the first time they are exported; never renumbered';
+
+CREATE INDEX idx_finance_journal_entries_booked_at ON finance_journal_entries(booked_at);
+
+CREATE TABLE finance_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,                                   -- Inclusive
+    format TEXT NOT NULL CHECK (format IN ('csv', 'json', 'datev')),
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    entry_count INT NOT NULL DEFAULT 0,
+    content TEXT,                                               -- Generated file, set once succeeded
+    last_error TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    CHECK (period_start <= period_end)
+);
+
+COMMENT ON TABLE finance_exports IS 'Accounting journal exports generated in the background and downloaded by admins';
+
+CREATE INDEX idx_finance_exports_pending ON finance_exports(created_at) WHERE status IN ('queued', 'running');
+CREATE INDEX idx_finance_exports_created_at ON finance_exports(created_at DESC);
@@ -0,0 +1,7 @@
+-- Migration: 041_add_coordinate_srid_checks
+-- Description: Ride coordinates must use WGS 84 (SRID 4326), like ride stops and pickup points whose columns are typed.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD CONSTRAINT rides_departure_coords_srid_check CHECK (departure_coords IS NULL OR ST_SRID(departure_coords) = 4326),
+ADD CONSTRAINT rides_arrival_coords_srid_check CHECK (arrival_coords IS NULL OR ST_SRID(arrival_coords) = 4326);
@@ -0,0 +1,8 @@
+-- Migration: 042_add_participant_seat_count
+-- Description: Seats booked by a participation, so one user can book for their group and pay for all the seats at once.
+-- Created at: NOW()
+
+ALTER TABLE participants
+ADD COLUMN seat_count INT NOT NULL DEFAULT 1 CHECK (seat_count BETWEEN 1 AND 5); -- Existing participations booked one seat
+
+COMMENT ON COLUMN participants.seat_count IS 'Seats taken by this participation; capacity checks sum it and the payment covers all of them';
@@ -0,0 +1,28 @@
+-- Migration: 043_create_anonymous_sessions
+-- Description: Anonymous browsing sessions for visitors without an account, and the recent searches kept for sessions and users (carried over at signup).
+-- Created at: NOW()
+
+CREATE TABLE anonymous_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    search_count INT NOT NULL DEFAULT 0, -- Searches made with the session (analytics)
+    upgraded_user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- Account created from the session
+    upgraded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_anonymous_sessions_last_seen_at ON anonymous_sessions(last_seen_at); -- Retention purge
+
+COMMENT ON TABLE anonymous_sessions IS 'Browsing sessions of visitors without an account; tokens carry the session ID';
+
+CREATE TABLE recent_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    anonymous_session_id UUID REFERENCES anonymous_sessions(id) ON DELETE CASCADE,
+    query TEXT NOT NULL, -- Search query string, without pagination, replayable on /rides/search
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (anonymous_session_id IS NULL)) -- Owned by a user or a session
+);
+
+CREATE INDEX idx_recent_searches_user_id ON recent_searches(user_id, created_at DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_recent_searches_anonymous_session_id ON recent_searches(anonymous_session_id) WHERE anonymous_session_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Migration: 044_add_rides_active_departure_index
+-- Description: Lets the archival job find active rides whose departure has passed without scanning all active rides.
+-- Created at: NOW()
+
+CREATE INDEX idx_rides_active_departure ON rides ((departure_date + departure_time)) WHERE status = 'active';
@@ -0,0 +1,12 @@
+-- Migration: 045_add_participant_pickup_details
+-- Description: Pickup note written by the participant, and the informal pickup order and seat assigned by the driver.
+-- Created at: NOW()
+
+ALTER TABLE participants
+ADD COLUMN pickup_note TEXT CHECK (char_length(pickup_note) <= 200), -- e.g. "North entrance, blue jacket"
+ADD COLUMN pickup_order INT CHECK (pickup_order BETWEEN 1 AND 20),    -- Set by the driver, 1 = picked up first
+ADD COLUMN seat_label TEXT CHECK (char_length(seat_label) <= 30);     -- Set by the driver, e.g. "front" or "back left"
+
+COMMENT ON COLUMN participants.pickup_note IS 'Note from the participant to help the driver find them at pickup';
+COMMENT ON COLUMN participants.pickup_order IS 'Informal pickup order assigned by the ride creator';
+COMMENT ON COLUMN participants.seat_label IS 'Informal seat assigned by the ride creator';
@@ -0,0 +1,22 @@
+-- Migration: 046_create_analytics_events
+-- Description: Product analytics events (ride views, searches, joins, payments), partitioned by month. Used when ANALYTICS_SINK is "db".
+-- Created at: NOW()
+
+CREATE TABLE analytics_events (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL, -- e.g. ride_viewed, search_performed, join_started, payment_succeeded
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- Only set when the user gave the analytics consent
+    properties JSONB NOT NULL DEFAULT '{}'::jsonb, -- Scrubbed of personal data before insertion
+    sample_rate INT NOT NULL DEFAULT 100, -- Percentage of events of this kind that were kept, to weight counts
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, occurred_at)
+) PARTITION BY RANGE (occurred_at);
+
+-- Monthly partitions (analytics_events_YYYY_MM) are created ahead by the backend; the default
+-- partition catches events if that ever lags behind.
+CREATE TABLE analytics_events_default PARTITION OF analytics_events DEFAULT;
+
+CREATE INDEX idx_analytics_events_name_occurred_at ON analytics_events(name, occurred_at);
+CREATE INDEX idx_analytics_events_user_id ON analytics_events(user_id) WHERE user_id IS NOT NULL; -- Consent withdrawal
+
+COMMENT ON TABLE analytics_events IS 'Product analytics events; user_id only with the analytics consent, properties scrubbed of personal data';
@@ -0,0 +1,13 @@
+-- Migration: 047_add_ride_route_geometry
+-- Description: Store the driving route as a PostGIS line, so searches can match rides whose route passes near the passenger's origin and destination.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN route_geometry geometry(LineString, 4326); -- Decoded from route_polyline; NULL when routing was unavailable
+
+UPDATE rides SET route_geometry = ST_LineFromEncodedPolyline(route_polyline)
+WHERE route_polyline IS NOT NULL AND route_geometry IS NULL;
+
+CREATE INDEX idx_rides_route_geometry ON rides USING GIST (route_geometry) WHERE status = 'active'; -- Corridor search
+
+COMMENT ON COLUMN rides.route_geometry IS 'Driving route as a line (WGS 84), kept in sync with route_polyline; used by corridor search';
@@ -0,0 +1,17 @@
+-- Migration: 048_create_saved_commutes
+-- Description: Routes users travel regularly, offered as search suggestions.
+-- Created at: NOW()
+
+CREATE TABLE saved_commutes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label TEXT NOT NULL, -- e.g. "Work"
+    departure_location_name TEXT NOT NULL,
+    arrival_location_name TEXT NOT NULL,
+    departure_time TIME, -- Usual departure time, suggested as departure_after
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_saved_commutes_user_route ON saved_commutes(user_id, lower(trim(departure_location_name)), lower(trim(arrival_location_name)));
+
+COMMENT ON TABLE saved_commutes IS 'Routes users travel regularly; suggested in the ride search box';
@@ -0,0 +1,21 @@
+-- Migration: 049_create_vehicles
+-- Description: Users' vehicles (make, model, color, plate), referenced by rides so passengers know which car to look for.
+-- Created at: NOW()
+
+CREATE TABLE vehicles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    make TEXT NOT NULL,
+    model TEXT NOT NULL,
+    color TEXT NOT NULL,
+    license_plate TEXT NOT NULL, -- Normalized to upper case; only shown to the ride's confirmed participants
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_vehicles_user_id ON vehicles(user_id);
+
+ALTER TABLE rides
+ADD COLUMN vehicle_id UUID REFERENCES vehicles(id) ON DELETE SET NULL; -- Optional; cleared when the driver deletes the vehicle or hands the ride over
+
+COMMENT ON TABLE vehicles IS 'Vehicles owned by users; rides reference the one they are driven with';
@@ -0,0 +1,10 @@
+-- Migration: 050_add_ride_preferences
+-- Description: Driver's preferences for a ride (smoking, pets, music, chat level), shown to passengers and filterable in search.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN smoking_allowed BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN pets_allowed BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN music_allowed BOOLEAN NOT NULL DEFAULT true,
+ADD COLUMN chat_level TEXT NOT NULL DEFAULT 'moderate',
+ADD CONSTRAINT ride_chat_level_check CHECK (chat_level IN ('quiet', 'moderate', 'chatty'));
@@ -0,0 +1,14 @@
+-- Migration: 051_create_service_areas
+-- Description: Regions the platform operates in; SERVICE_AREAS selects which ones rides may depart from and arrive in.
+-- Created at: NOW()
+
+CREATE TABLE service_areas (
+    code TEXT PRIMARY KEY, -- ISO 3166-1 alpha-2 country code (e.g. 'FR'), or a custom region code (e.g. 'FR-IDF')
+    name TEXT NOT NULL,
+    boundary geometry(MultiPolygon, 4326) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_service_areas_boundary ON service_areas USING GIST (boundary);
+
+COMMENT ON TABLE service_areas IS 'Boundaries are loaded per deployment, e.g. country polygons from Natural Earth or hand-drawn regions with ST_Multi(ST_GeomFromGeoJSON(...))';
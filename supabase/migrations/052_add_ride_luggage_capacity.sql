@@ -0,0 +1,7 @@
+-- Migration: 052_add_ride_luggage_capacity
+-- Description: Luggage the driver has room for on a ride (none, small, large), filterable in search.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN luggage_capacity TEXT NOT NULL DEFAULT 'small',
+ADD CONSTRAINT ride_luggage_capacity_check CHECK (luggage_capacity IN ('none', 'small', 'large'));
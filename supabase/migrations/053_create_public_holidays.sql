@@ -0,0 +1,13 @@
+-- Migration: 053_create_public_holidays
+-- Description: Nationwide public holidays per country, fetched by the holiday calendar job; rides on peak travel days are flagged and may carry a peak booking fee.
+-- Created at: NOW()
+
+CREATE TABLE public_holidays (
+    country_code TEXT NOT NULL, -- ISO 3166-1 alpha-2
+    date DATE NOT NULL,
+    name TEXT NOT NULL, -- Local name, e.g. 'Jour de l''an'
+    refreshed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (country_code, date)
+);
+
+COMMENT ON TABLE public_holidays IS 'Holiday calendar (HOLIDAY_COUNTRIES), refreshed from a Nager.Date compatible API';
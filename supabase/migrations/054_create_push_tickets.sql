@@ -0,0 +1,26 @@
+-- Migration: 054_create_push_tickets
+-- Description: Expo push tickets awaiting their delivery receipt, and push token freshness, for the push token hygiene job.
+-- Created at: NOW()
+
+ALTER TABLE users
+ADD COLUMN push_token_registered_at TIMESTAMPTZ, -- Last registration of the token; the app registers it on every launch
+ADD COLUMN push_token_inactive_at TIMESTAMPTZ; -- Set after long dormancy; nothing is pushed until the app registers again
+
+UPDATE users SET push_token_registered_at = updated_at WHERE expo_push_token IS NOT NULL;
+
+CREATE TABLE push_tickets (
+    id TEXT PRIMARY KEY, -- Expo push ticket ID
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    push_token TEXT NOT NULL, -- Token the push was sent to; pruned only if the user still has it
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    receipt_status TEXT, -- NULL until the receipt is read
+    receipt_error TEXT, -- Expo error code of failed deliveries, e.g. 'DeviceNotRegistered'
+    checked_at TIMESTAMPTZ,
+    CONSTRAINT push_ticket_receipt_status_check CHECK (receipt_status IN ('ok', 'error', 'expired'))
+);
+
+CREATE INDEX idx_push_tickets_unchecked ON push_tickets(created_at, id) WHERE receipt_status IS NULL;
+CREATE INDEX idx_push_tickets_created_at ON push_tickets(created_at); -- Purge of old tickets
+
+COMMENT ON TABLE push_tickets IS 'Expo only keeps receipts for a day; tickets without one by then are marked expired';
@@ -0,0 +1,21 @@
+-- Migration: 055_create_ride_reviews
+-- Description: Ratings and reviews left after a ride departed, by passengers about the driver and by the driver about passengers.
+-- Created at: NOW()
+
+CREATE TABLE ride_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    reviewer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_role TEXT NOT NULL CHECK (reviewee_role IN ('driver', 'passenger')), -- Role of the reviewed user on the ride
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT,                                                                 -- Optional, moderated like other text shown to users
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    CONSTRAINT ride_reviews_once UNIQUE (ride_id, reviewer_id, reviewee_id),
+    CONSTRAINT ride_reviews_not_self CHECK (reviewer_id <> reviewee_id)
+);
+
+COMMENT ON TABLE ride_reviews IS 'One rating per reviewer, reviewed user and ride, left once the ride departed';
+
+CREATE INDEX idx_ride_reviews_reviewee ON ride_reviews(reviewee_id, reviewee_role);
+CREATE INDEX idx_ride_reviews_reviewer_id ON ride_reviews(reviewer_id);
@@ -0,0 +1,19 @@
+-- Migration: 056_create_ride_invitations
+-- Description: Per-ride invitations sent by the creator; the invitee joins in one tap and still pays for their seat.
+-- Created at: NOW()
+
+CREATE TABLE ride_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    invited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Ride creator
+    invitee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Found by user ID, email or WhatsApp number
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMPTZ                                          -- When the invitation was accepted, declined or revoked
+);
+
+COMMENT ON TABLE ride_invitations IS 'Invitations to join a ride, pre-approving the invitee';
+
+-- At most one open invitation per ride and invitee
+CREATE UNIQUE INDEX idx_ride_invitations_pending ON ride_invitations(ride_id, invitee_id) WHERE status = 'pending';
+CREATE INDEX idx_ride_invitations_invitee_pending ON ride_invitations(invitee_id) WHERE status = 'pending';
@@ -0,0 +1,21 @@
+-- Migration: 057_create_ride_templates
+-- Description: Named ride templates (route, seats, price) that drivers create rides from.
+-- Created at: NOW()
+
+CREATE TABLE ride_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL, -- e.g. "Friday to Lyon"
+    departure_location_name TEXT NOT NULL,
+    departure_coords geometry(Point, 4326) NOT NULL,
+    arrival_location_name TEXT NOT NULL,
+    arrival_coords geometry(Point, 4326) NOT NULL,
+    total_seats INTEGER NOT NULL CHECK (total_seats BETWEEN 1 AND 5),
+    price_per_seat INT CHECK (price_per_seat > 0), -- Cents of the payment currency (NULL = booking fee)
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_ride_templates_user_name ON ride_templates(user_id, lower(trim(name)));
+
+COMMENT ON TABLE ride_templates IS 'Saved ride settings drivers create rides from (POST /rides/from-template/:templateId)';
@@ -0,0 +1,26 @@
+-- Migration: 058_create_auto_join_intents
+-- Description: Intents recorded before automatic joins charge the saved card, so a charge is always finalized or refunded.
+-- Created at: NOW()
+
+CREATE TABLE auto_join_intents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(), -- Also the Stripe idempotency key of the charge
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    participant_id UUID REFERENCES participants(id) ON DELETE SET NULL, -- 'pending_payment' participant holding the seats
+    seat_count INTEGER NOT NULL CHECK (seat_count BETWEEN 1 AND 5),
+    amount INTEGER NOT NULL CHECK (amount > 0), -- Cents
+    currency CHAR(3) NOT NULL,
+    stripe_customer_id TEXT NOT NULL,
+    stripe_payment_method_id TEXT NOT NULL, -- Charges are replayed with the same card while the intent is pending
+    stripe_payment_intent_id TEXT UNIQUE, -- Set once the charge succeeded
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0, -- Failed finalizations; the charge is refunded past a limit
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT auto_join_intent_status_check CHECK (status IN ('pending', 'finalized', 'failed', 'refunded'))
+);
+
+CREATE INDEX idx_auto_join_intents_pending ON auto_join_intents(created_at) WHERE status = 'pending';
+
+COMMENT ON TABLE auto_join_intents IS 'Pending intents are settled by the automatic join itself, the payment_intent.succeeded webhook or the reconciliation job';
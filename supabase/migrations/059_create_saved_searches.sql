@@ -0,0 +1,28 @@
+-- Migration: 059_create_saved_searches
+-- Description: Saved ride searches (route and date range) whose users are alerted when a matching ride is published.
+-- Created at: NOW()
+
+CREATE TABLE saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    departure_location_name TEXT NOT NULL,
+    departure_coords geometry(Point, 4326) NOT NULL,
+    arrival_location_name TEXT NOT NULL,
+    arrival_coords geometry(Point, 4326) NOT NULL,
+    radius_km INTEGER NOT NULL DEFAULT 10 CHECK (radius_km BETWEEN 1 AND 200), -- Rides departing and arriving this close match
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT saved_search_dates_check CHECK (date_to >= date_from)
+);
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+CREATE INDEX idx_saved_searches_dates ON saved_searches(date_from, date_to);
+
+-- One alert per saved search and ride, so a ride is never announced twice for the same search
+CREATE TABLE saved_search_alerts (
+    saved_search_id UUID NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (saved_search_id, ride_id)
+);
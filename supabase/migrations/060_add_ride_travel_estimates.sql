@@ -0,0 +1,15 @@
+-- Migration: 060_add_ride_travel_estimates
+-- Description: Cache each ride's driving distance and time, from which responses derive the estimated arrival.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN route_distance_meters DOUBLE PRECISION, -- NULL until routing succeeded for the ride
+ADD COLUMN route_duration_seconds DOUBLE PRECISION;
+
+-- Existing rides start from the travel matrix estimate of their city pair, if any
+UPDATE rides r SET route_distance_meters = tm.distance_meters, route_duration_seconds = tm.duration_seconds
+FROM travel_matrix tm
+WHERE tm.departure_key = lower(trim(r.departure_location_name)) AND tm.arrival_key = lower(trim(r.arrival_location_name))
+  AND r.route_duration_seconds IS NULL;
+
+COMMENT ON COLUMN rides.route_duration_seconds IS 'Driving time from the routing provider (OSRM or OpenRouteService), computed at creation or on first view; the ETA is the departure plus this';
@@ -0,0 +1,11 @@
+-- Migration: 061_add_ride_distance
+-- Description: Store the great-circle distance between each ride's departure and arrival, returned with rides and used to sort searches.
+-- Created at: NOW()
+
+ALTER TABLE rides
+ADD COLUMN distance_meters DOUBLE PRECISION; -- ST_Distance of the endpoints, set at creation
+
+UPDATE rides SET distance_meters = ST_Distance(departure_coords::geography, arrival_coords::geography)
+WHERE departure_coords IS NOT NULL AND arrival_coords IS NOT NULL AND distance_meters IS NULL;
+
+COMMENT ON COLUMN rides.distance_meters IS 'Great-circle distance between departure_coords and arrival_coords, in meters; the driving distance is route_distance_meters';
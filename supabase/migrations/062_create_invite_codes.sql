@@ -0,0 +1,23 @@
+-- Migration: 062_create_invite_codes
+-- Description: Invite codes gating signups during soft launches (SIGNUP_MODE=invite_only), with usage limits and cohort tags.
+-- Created at: NOW()
+
+CREATE TABLE invite_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,                 -- Uppercase, entered by users at signup
+    cohort TEXT NOT NULL,                      -- Rollout cohort, e.g. "lyon-beta"; new users are attributed to it
+    max_uses INTEGER NOT NULL CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,                    -- NULL: never expires
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ,                    -- Revoked codes are rejected
+    CONSTRAINT invite_code_uses_check CHECK (uses BETWEEN 0 AND max_uses)
+);
+
+CREATE INDEX idx_invite_codes_cohort ON invite_codes(cohort);
+
+ALTER TABLE users
+ADD COLUMN invite_code_id UUID REFERENCES invite_codes(id) ON DELETE SET NULL; -- Code redeemed at signup, if any
+
+COMMENT ON TABLE invite_codes IS 'Codes minted by admins; a signup redeems one use atomically with the account creation';
@@ -0,0 +1,22 @@
+-- Migration: 063_add_ride_completion
+-- Description: Rides are completed by their creator, or automatically once their estimated arrival passed.
+--              Completion unlocks reviews and marks the active participants completed.
+-- Created at: NOW()
+
+ALTER TABLE rides DROP CONSTRAINT IF EXISTS ride_status_check;
+ALTER TABLE rides
+ADD CONSTRAINT ride_status_check CHECK (status IN ('active', 'archived', 'cancelled', 'completed'));
+
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participant_status_check;
+ALTER TABLE participants
+ADD CONSTRAINT participant_status_check CHECK (status IN ('pending_payment', 'active', 'left', 'cancelled_ride', 'on_hold', 'removed', 'completed'));
+
+COMMENT ON COLUMN participants.status IS 'Current status of the participation (pending_payment, active, left, cancelled_ride, on_hold, removed, completed)';
+
+-- Departed rides were reviewable once archived: complete them, so their reviews stay open
+UPDATE participants p SET status = 'completed', updated_at = NOW()
+FROM rides r
+WHERE r.id = p.ride_id AND r.status = 'archived' AND p.status = 'active';
+
+UPDATE rides SET status = 'completed', updated_at = NOW()
+WHERE status = 'archived';
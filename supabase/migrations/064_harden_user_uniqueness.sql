@@ -0,0 +1,17 @@
+-- Migration: 064_harden_user_uniqueness
+-- Description: Email and WhatsApp uniqueness is enforced among active users by partial unique indexes, so
+--              concurrent signups and profile updates racing past the application's EXISTS checks fail,
+--              and soft-deleted accounts free their email like they already free their number.
+-- Created at: NOW()
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL;
+
+-- Plaintext numbers are cleared once encrypted; rows not yet backfilled stay unique among active users
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_whatsapp_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_whatsapp_active ON users(whatsapp) WHERE deleted_at IS NULL AND whatsapp IS NOT NULL;
+
+-- Created by 025_encrypt_user_pii; kept here so every uniqueness rule of users is in one place
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_whatsapp_hash ON users(whatsapp_hash) WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN users.email IS 'User login email address (unique among active users)';
@@ -0,0 +1,23 @@
+-- Migration: 065_create_ride_views
+-- Description: Count the distinct users viewing each ride's details, shown to the ride's creator. Views are
+--              recorded in the background, one row per ride and viewer, and the per-ride counter is bumped
+--              only for first views, so reading the count never scans the viewers.
+-- Created at: NOW()
+
+CREATE TABLE ride_views (
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- First view; later views by the same user aren't counted
+    PRIMARY KEY (ride_id, user_id)
+);
+
+CREATE INDEX idx_ride_views_user_id ON ride_views(user_id);
+
+CREATE TABLE ride_view_counts (
+    ride_id UUID PRIMARY KEY REFERENCES rides(id) ON DELETE CASCADE,
+    views INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE ride_views IS 'Users who viewed a ride''s details, deduplicating ride_view_counts; scrubbed on erasure';
+COMMENT ON TABLE ride_view_counts IS 'Distinct viewers of each ride''s details, excluding its creator; rides never viewed have no row';
@@ -0,0 +1,16 @@
+-- Migration: 066_add_gender_restricted_rides
+-- Description: Users may declare their gender, and creators may reserve a ride to passengers of their own
+--              gender (e.g. women-only rides). Joins are checked against the ride's restriction, and searches
+--              can filter on it.
+-- Created at: NOW()
+
+ALTER TABLE users
+ADD COLUMN gender TEXT, -- Self-declared; NULL until set, which keeps the user out of restricted rides
+ADD CONSTRAINT user_gender_check CHECK (gender IN ('female', 'male', 'other'));
+
+ALTER TABLE rides
+ADD COLUMN passenger_gender TEXT, -- NULL: open to every passenger
+ADD CONSTRAINT ride_passenger_gender_check CHECK (passenger_gender IN ('female', 'male'));
+
+COMMENT ON COLUMN users.gender IS 'Self-declared gender (female, male, other), checked when joining gender-restricted rides';
+COMMENT ON COLUMN rides.passenger_gender IS 'Only passengers of this gender may join; set by creators of the same gender';
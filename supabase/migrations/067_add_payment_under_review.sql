@@ -0,0 +1,10 @@
+-- Migration: 067_add_payment_under_review
+-- Description: Payments whose succeeded PaymentIntent doesn't match the stored amount or currency are held
+--              for admin review instead of confirming the booking.
+-- Created at: NOW()
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payment_status_check;
+ALTER TABLE payments
+ADD CONSTRAINT payment_status_check CHECK (status IN ('pending', 'succeeded', 'failed', 'refunded', 'under_review'));
+
+COMMENT ON COLUMN payments.status IS 'Status of the payment (pending, succeeded, failed, refunded, under_review)';
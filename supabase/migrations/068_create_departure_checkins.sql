@@ -0,0 +1,13 @@
+-- Migration: 068_create_departure_checkins
+-- Description: On the morning of departure, drivers and passengers get one check-in message with the meeting point,
+--              contacts, confirmation codes and the live ride link. Sent messages are recorded so each is sent once.
+-- Created at: NOW()
+
+CREATE TABLE departure_checkins (
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Driver or active participant
+    sent_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (ride_id, user_id)
+);
+
+COMMENT ON TABLE departure_checkins IS 'Departure-day check-in messages sent, one per ride and recipient';